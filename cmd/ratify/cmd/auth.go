@@ -0,0 +1,192 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ratify-project/ratify/config"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common/oras/authprovider"
+	rc "github.com/ratify-project/ratify/pkg/referrerstore/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	authUse = "auth"
+)
+
+type authTestCmdOptions struct {
+	configFilePath string
+	registry       string
+	storeName      string
+}
+
+func NewCmdAuth(argv ...string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   authUse,
+		Short: "Troubleshoot the auth providers of the stores",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(NewCmdAuthTest(argv...))
+	return cmd
+}
+
+func NewCmdAuthTest(argv ...string) *cobra.Command {
+	var opts authTestCmdOptions
+
+	if len(argv) == 0 {
+		argv = []string{os.Args[0]}
+	}
+
+	eg := fmt.Sprintf(`  # Obtain credentials for a registry with the auth provider of the configured store
+  %s auth test -c ./config.yaml --registry myregistry.azurecr.io`, strings.Join(argv, " "))
+
+	cmd := &cobra.Command{
+		Use:     "test [OPTIONS]",
+		Short:   "Obtain credentials for a registry with the configured auth provider",
+		Example: eg,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return authTest(opts, cmd.OutOrStdout())
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVarP(&opts.configFilePath, "config", "c", "", "Config File Path")
+	flags.StringVar(&opts.registry, "registry", "", "Registry host, e.g. myregistry.azurecr.io")
+	flags.StringVar(&opts.storeName, "store", "", "Name of the store whose auth provider is tested. Defaults to the first store configuring one")
+	return cmd
+}
+
+func authTest(opts authTestCmdOptions, out io.Writer) error {
+	if opts.registry == "" {
+		return errors.New("registry parameter is required")
+	}
+
+	cf, err := config.Load(opts.configFilePath)
+	if err != nil {
+		return err
+	}
+
+	if err := logger.InitLogConfig(cf.LoggerConfig); err != nil {
+		return err
+	}
+
+	authProviderConfig, err := storeAuthProviderConfig(cf.StoresConfig, opts.storeName)
+	if err != nil {
+		return err
+	}
+	providerName := authprovider.DefaultAuthProviderName
+	if name, ok := authProviderConfig["name"]; ok {
+		providerName = fmt.Sprintf("%s", name)
+	}
+
+	provider, err := authprovider.CreateAuthProviderFromConfig(authProviderConfig)
+	if err != nil {
+		fmt.Fprintf(out, "FAILED: could not create auth provider %s\n", providerName)
+		return err
+	}
+	ctx := context.Background()
+	if !provider.Enabled(ctx) {
+		fmt.Fprintf(out, "FAILED: auth provider %s is not enabled\n", providerName)
+		return fmt.Errorf("auth provider %s is not enabled", providerName)
+	}
+	if err := provider.Validate(ctx); err != nil {
+		fmt.Fprintf(out, "FAILED: auth provider %s could not validate its credentials\n", providerName)
+		return err
+	}
+	authConfig, err := provider.Provide(ctx, opts.registry)
+	if err != nil {
+		fmt.Fprintf(out, "FAILED: auth provider %s could not obtain credentials for registry %s\n", providerName, opts.registry)
+		return err
+	}
+
+	fmt.Fprintf(out, "SUCCESS: auth provider %s obtained credentials for registry %s\n", providerName, opts.registry)
+	for _, item := range authConfigMetadata(authConfig, time.Now()) {
+		fmt.Fprintf(out, "  %s: %s\n", item[0], item[1])
+	}
+	return nil
+}
+
+// storeAuthProviderConfig returns the auth provider config of the store with
+// the given name, or of the first store configuring one if the name is empty.
+// A nil config selects the default auth provider.
+func storeAuthProviderConfig(storesConfig rc.StoresConfig, storeName string) (authprovider.AuthProviderConfig, error) {
+	for _, store := range storesConfig.Stores {
+		if storeName != "" && fmt.Sprintf("%v", store["name"]) != storeName {
+			continue
+		}
+		authProviderConfig, ok := store["authProvider"].(map[string]interface{})
+		if !ok {
+			if storeName != "" {
+				return nil, nil
+			}
+			continue
+		}
+		return authprovider.AuthProviderConfig(authProviderConfig), nil
+	}
+	if storeName != "" {
+		return nil, fmt.Errorf("store %s is not configured", storeName)
+	}
+	return nil, nil
+}
+
+// authConfigMetadata describes the obtained credentials without revealing
+// the secrets.
+func authConfigMetadata(authConfig authprovider.AuthConfig, now time.Time) [][]string {
+	credentialType := "anonymous"
+	switch {
+	case authConfig.RegistryToken != "":
+		credentialType = "registry token"
+	case authConfig.IdentityToken != "":
+		credentialType = "identity token"
+	case authConfig.Username != "" || authConfig.Password != "":
+		credentialType = "username and password"
+	}
+
+	items := [][]string{{"Credential type", credentialType}}
+	if authConfig.Username != "" {
+		items = append(items, []string{"Username", authConfig.Username})
+	}
+	for _, secret := range []struct {
+		name  string
+		value string
+	}{
+		{"Password", authConfig.Password},
+		{"Identity token", authConfig.IdentityToken},
+		{"Registry token", authConfig.RegistryToken},
+	} {
+		if secret.value != "" {
+			items = append(items, []string{secret.name, fmt.Sprintf("<redacted, %d characters>", len(secret.value))})
+		}
+	}
+	if !authConfig.ExpiresOn.IsZero() {
+		items = append(items, []string{"Expires on", fmt.Sprintf("%s (in %s)", authConfig.ExpiresOn.UTC().Format(time.RFC3339), authConfig.ExpiresOn.Sub(now).Round(time.Second))})
+	}
+	return items
+}
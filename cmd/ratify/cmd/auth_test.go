@@ -0,0 +1,129 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/common/oras/authprovider"
+)
+
+const testAuthProviderName = "testAuthTestProvider"
+
+type testAuthProviderFactory struct{}
+
+type testAuthProvider struct {
+	fail bool
+}
+
+func (f *testAuthProviderFactory) Create(authProviderConfig authprovider.AuthProviderConfig) (authprovider.AuthProvider, error) {
+	fail, _ := authProviderConfig["fail"].(bool)
+	return &testAuthProvider{fail: fail}, nil
+}
+
+func (p *testAuthProvider) Enabled(_ context.Context) bool {
+	return true
+}
+
+func (p *testAuthProvider) Validate(_ context.Context) error {
+	return nil
+}
+
+func (p *testAuthProvider) Provide(_ context.Context, _ string) (authprovider.AuthConfig, error) {
+	if p.fail {
+		return authprovider.AuthConfig{}, errors.New("token exchange denied")
+	}
+	return authprovider.AuthConfig{
+		Username:  "00000000-0000-0000-0000-000000000000",
+		Password:  "secret-refresh-token",
+		ExpiresOn: time.Now().Add(time.Hour),
+	}, nil
+}
+
+func init() {
+	authprovider.Register(testAuthProviderName, &testAuthProviderFactory{})
+}
+
+func writeAuthTestConfig(t *testing.T, fail bool) string {
+	t.Helper()
+	failValue := "false"
+	if fail {
+		failValue = "true"
+	}
+	config := `{
+    "store": {
+        "version": "1.0.0",
+        "plugins": [
+            {"name": "oras", "authProvider": {"name": "` + testAuthProviderName + `", "fail": ` + failValue + `}}
+        ]
+    }
+}`
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestAuthTest(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := authTest(authTestCmdOptions{
+		configFilePath: writeAuthTestConfig(t, false),
+		registry:       "myregistry.azurecr.io",
+	}, out)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	output := out.String()
+	for _, expected := range []string{"SUCCESS", testAuthProviderName, "username and password", "<redacted, 20 characters>", "Expires on"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, output)
+		}
+	}
+	if strings.Contains(output, "secret-refresh-token") {
+		t.Errorf("expected the password to be redacted, got %q", output)
+	}
+}
+
+func TestAuthTest_Failure(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := authTest(authTestCmdOptions{
+		configFilePath: writeAuthTestConfig(t, true),
+		registry:       "myregistry.azurecr.io",
+	}, out)
+	if err == nil || !strings.Contains(err.Error(), "token exchange denied") {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "FAILED") {
+		t.Errorf("expected output to report the failure, got %q", out.String())
+	}
+}
+
+func TestAuthTest_Options(t *testing.T) {
+	if err := authTest(authTestCmdOptions{configFilePath: writeAuthTestConfig(t, false)}, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error without a registry")
+	}
+	if err := authTest(authTestCmdOptions{configFilePath: writeAuthTestConfig(t, false), registry: "myregistry.azurecr.io", storeName: "missing"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an unknown store")
+	}
+}
@@ -41,6 +41,18 @@ func TestVerify(t *testing.T) {
 	}
 }
 
+func TestResolve_DigestOnly(t *testing.T) {
+	// the digest of a subject referenced by digest is not resolved from the
+	// stores
+	err := resolve(resolveCmdOptions{
+		subject:        "localhost:5000/net-monitor@" + digest,
+		configFilePath: configFilePath,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
 func TestDiscover(t *testing.T) {
 	err := discover((discoverCmdOptions{
 		subject:        subject,
@@ -0,0 +1,126 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ratify-project/ratify/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	configUse = "config"
+)
+
+type configMigrateCmdOptions struct {
+	configFilePath string
+	write          bool
+}
+
+func NewCmdConfig(argv ...string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   configUse,
+		Short: "Manage the Ratify configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(NewCmdConfigMigrate(argv...))
+	return cmd
+}
+
+func NewCmdConfigMigrate(argv ...string) *cobra.Command {
+	var opts configMigrateCmdOptions
+
+	if len(argv) == 0 {
+		argv = []string{os.Args[0]}
+	}
+
+	eg := fmt.Sprintf(`  # Print the configuration with its deprecated fields upgraded
+  %s migrate -c ./config.json
+
+  # Upgrade the deprecated fields of the configuration file in place
+  %s migrate -c ./config.json --write`, strings.Join(argv, " "), strings.Join(argv, " "))
+
+	cmd := &cobra.Command{
+		Use:     "migrate [OPTIONS]",
+		Short:   "Upgrade the deprecated fields of a configuration",
+		Example: eg,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return migrateConfig(opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVarP(&opts.configFilePath, "config", "c", "", "Config File Path")
+	flags.BoolVar(&opts.write, "write", false, "Write the upgraded configuration back to the config file instead of printing it")
+	return cmd
+}
+
+// migrateConfig prints the upgraded configuration to out, or writes it to the
+// config file, and reports the applied migrations to reportOut.
+func migrateConfig(opts configMigrateCmdOptions, out io.Writer, reportOut io.Writer) error {
+	if opts.configFilePath == "" {
+		return errors.New("config parameter is required")
+	}
+	body, err := os.ReadFile(opts.configFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to read config file at path %s: %w", opts.configFilePath, err)
+	}
+	migrated, report, err := config.Migrate(body)
+	if err != nil {
+		return err
+	}
+
+	if opts.write {
+		if len(report.Applied) > 0 {
+			info, err := os.Stat(opts.configFilePath)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(opts.configFilePath, migrated, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("unable to write config file at path %s: %w", opts.configFilePath, err)
+			}
+		}
+	} else if _, err := out.Write(migrated); err != nil {
+		return err
+	}
+
+	if len(report.Applied) == 0 {
+		fmt.Fprintln(reportOut, "No migrations applied.")
+	} else {
+		fmt.Fprintln(reportOut, "Applied migrations:")
+		for _, applied := range report.Applied {
+			fmt.Fprintf(reportOut, "  - %s\n", applied)
+		}
+	}
+	if len(report.Manual) > 0 {
+		fmt.Fprintln(reportOut, "Manual migrations required:")
+		for _, manual := range report.Manual {
+			fmt.Fprintf(reportOut, "  - %s\n", manual)
+		}
+	}
+	return nil
+}
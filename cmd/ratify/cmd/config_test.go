@@ -0,0 +1,64 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const deprecatedCosignConfig = `{"verifier":{"version":"1.0.0","plugins":[{"name":"cosign","artifactTypes":"application/vnd.dev.cosign.artifact.sig.v1+json","key":"/keys/cosign.pub"}]}}`
+
+func TestMigrateConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(deprecatedCosignConfig), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	out, reportOut := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := migrateConfig(configMigrateCmdOptions{configFilePath: path}, out, reportOut); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), `"trustPolicies"`) || strings.Contains(out.String(), `"key"`) {
+		t.Fatalf("expected the upgraded config to be printed, got %s", out.String())
+	}
+	if !strings.Contains(reportOut.String(), "Applied migrations:") {
+		t.Fatalf("expected the applied migrations to be reported, got %s", reportOut.String())
+	}
+	if body, _ := os.ReadFile(path); string(body) != deprecatedCosignConfig {
+		t.Fatalf("expected the config file to be unchanged without --write, got %s", body)
+	}
+
+	out.Reset()
+	if err := migrateConfig(configMigrateCmdOptions{configFilePath: path, write: true}, out, &bytes.Buffer{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no config to be printed with --write, got %s", out.String())
+	}
+	if body, _ := os.ReadFile(path); !strings.Contains(string(body), `"trustPolicies"`) {
+		t.Fatalf("expected the config file to be upgraded with --write, got %s", body)
+	}
+}
+
+func TestMigrateConfig_MissingConfig(t *testing.T) {
+	if err := migrateConfig(configMigrateCmdOptions{}, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an error without a config file")
+	}
+}
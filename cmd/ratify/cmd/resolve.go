@@ -91,12 +91,12 @@ func resolve(opts resolveCmdOptions) error {
 		return err
 	}
 
-	result, err := su.ResolveSubjectDescriptor(context.Background(), &stores, subRef)
+	result, err := su.SubjectDigest(context.Background(), &stores, subRef)
 
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(result.Digest)
+	fmt.Println(result)
 	return nil
 }
@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"github.com/ratify-project/ratify/config"
 	"github.com/ratify-project/ratify/pkg/common"
 	"github.com/ratify-project/ratify/pkg/featureflag"
 	"github.com/sirupsen/logrus"
@@ -32,6 +33,7 @@ var Root = New(use, shortDesc)
 func New(use, short string) *cobra.Command {
 	featureflag.InitFeatureFlagsFromEnv()
 	var enableDebug bool
+	var configOverlay string
 	root := &cobra.Command{
 		Use:   use,
 		Short: short,
@@ -41,6 +43,9 @@ func New(use, short string) *cobra.Command {
 			} else {
 				common.SetLoggingLevelFromEnv(logrus.StandardLogger())
 			}
+			if configOverlay != "" {
+				config.SetOverlayFile(configOverlay)
+			}
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return cmd.Usage()
@@ -55,7 +60,10 @@ func New(use, short string) *cobra.Command {
 	root.AddCommand(NewCmdDiscover(use, discoverUse))
 	root.AddCommand(NewCmdVersion(use, versionUse))
 	root.AddCommand(NewCmdResolve(use, resolveUse))
+	root.AddCommand(NewCmdAuth(use, authUse))
+	root.AddCommand(NewCmdConfig(use, configUse))
 
 	root.PersistentFlags().BoolVarP(&enableDebug, "debug", "d", false, "Enable debug mode. If enabled, set logger level to debug")
+	root.PersistentFlags().StringVar(&configOverlay, "config-overlay", "", "Config overlay file path deep merged over the config file. Overrides the "+config.ConfigOverlayEnv+" environment variable")
 	return root
 }
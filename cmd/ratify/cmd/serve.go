@@ -25,6 +25,7 @@ import (
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/cache"
 	"github.com/ratify-project/ratify/pkg/manager"
+	"github.com/ratify-project/ratify/pkg/metrics"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -39,6 +40,7 @@ type serveCmdOptions struct {
 	certDirectory     string
 	caCertFile        string
 	enableCrdManager  bool
+	standalone        bool
 	cacheEnabled      bool
 	cacheType         string
 	cacheName         string
@@ -48,6 +50,9 @@ type serveCmdOptions struct {
 	metricsType       string
 	metricsPort       int
 	healthPort        string
+	adminAddress      string
+	maxBatchSize      int
+	batchConcurrency  int
 }
 
 func NewCmdServe(_ ...string) *cobra.Command {
@@ -70,6 +75,7 @@ func NewCmdServe(_ ...string) *cobra.Command {
 	flags.StringVar(&opts.certDirectory, "cert-dir", "", "Path to ratify certs")
 	flags.StringVar(&opts.caCertFile, "ca-cert-file", "", "Path to CA cert file")
 	flags.BoolVar(&opts.enableCrdManager, "enable-crd-manager", false, "Start crd manager if enabled (default: false)")
+	flags.BoolVar(&opts.standalone, "standalone", false, "Serve verification requests from the config file without Kubernetes dependencies, e.g. in CI (default: false)")
 	flags.BoolVar(&opts.cacheEnabled, "cache-enabled", false, "Enable cache if enabled (default: false)")
 	flags.StringVar(&opts.cacheType, "cache-type", cache.DefaultCacheType, fmt.Sprintf("Cache type to use (default: %s)", cache.DefaultCacheType))
 	flags.StringVar(&opts.cacheName, "cache-name", cache.DefaultCacheName, fmt.Sprintf("Cache implementation name to use (default: %s)", cache.DefaultCacheName))
@@ -79,10 +85,22 @@ func NewCmdServe(_ ...string) *cobra.Command {
 	flags.StringVar(&opts.metricsType, "metrics-type", httpserver.DefaultMetricsType, fmt.Sprintf("Metrics exporter type to use (default: %s)", httpserver.DefaultMetricsType))
 	flags.IntVar(&opts.metricsPort, "metrics-port", httpserver.DefaultMetricsPort, fmt.Sprintf("Metrics exporter port to use (default: %d)", httpserver.DefaultMetricsPort))
 	flags.StringVar(&opts.healthPort, "health-port", httpserver.DefaultHealthPort, fmt.Sprintf("Health port to use (default: %s)", httpserver.DefaultHealthPort))
+	flags.StringVar(&opts.adminAddress, "admin-address", "", "Loopback address of the admin API, e.g. 127.0.0.1:9098. The admin API is disabled if empty (default: \"\")")
+	flags.IntVar(&opts.maxBatchSize, "max-batch-size", httpserver.DefaultMaxBatchSize, fmt.Sprintf("Maximum number of references of a batch verification request (default: %d)", httpserver.DefaultMaxBatchSize))
+	flags.IntVar(&opts.batchConcurrency, "batch-verify-concurrency", httpserver.DefaultBatchVerifyConcurrency, fmt.Sprintf("Maximum number of references of a batch verified concurrently (default: %d)", httpserver.DefaultBatchVerifyConcurrency))
 	return cmd
 }
 
 func serve(opts serveCmdOptions) error {
+	if opts.standalone && opts.enableCrdManager {
+		return fmt.Errorf("standalone mode cannot be used with the crd manager")
+	}
+	if opts.maxBatchSize <= 0 {
+		return fmt.Errorf("max-batch-size must be positive, got %d", opts.maxBatchSize)
+	}
+	if opts.batchConcurrency <= 0 {
+		return fmt.Errorf("batch-verify-concurrency must be positive, got %d", opts.batchConcurrency)
+	}
 	if opts.cacheEnabled {
 		// initialize global cache of specified type
 		if _, err := cache.NewCacheProvider(context.TODO(), opts.cacheType, opts.cacheName, opts.cacheSize); err != nil {
@@ -97,17 +115,49 @@ func serve(opts serveCmdOptions) error {
 	if err := logger.InitLogConfig(logConfig); err != nil {
 		return fmt.Errorf("failed to initialize logger configuration: %w", err)
 	}
+	if opts.metricsEnabled {
+		metricsConfig, err := config.GetMetricsConfig(opts.configFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve metrics configuration: %w", err)
+		}
+		if err := metrics.SetLabelConfig(metricsConfig); err != nil {
+			return fmt.Errorf("failed to initialize metrics configuration: %w", err)
+		}
+	}
 
 	// in crd mode, the manager gets latest store/verifier from crd and pass on to the http server
 	if opts.enableCrdManager {
 		certRotatorReady := make(chan struct{})
 		logrus.Infof("starting crd manager")
 		go manager.StartManager(certRotatorReady, opts.healthPort)
-		manager.StartServer(opts.httpServerAddress, opts.configFilePath, opts.certDirectory, opts.caCertFile, opts.cacheTTL, opts.metricsEnabled, opts.metricsType, opts.metricsPort, certRotatorReady)
+		manager.StartServer(opts.httpServerAddress, opts.adminAddress, opts.maxBatchSize, opts.batchConcurrency, opts.configFilePath, opts.certDirectory, opts.caCertFile, opts.cacheTTL, opts.metricsEnabled, opts.metricsType, opts.metricsPort, certRotatorReady)
 
 		return nil
 	}
 
+	// in standalone mode, only the executor and the http endpoints are started
+	if opts.standalone {
+		server, err := httpserver.NewStandaloneServer(context.Background(), httpserver.StandaloneOptions{
+			Address:        opts.httpServerAddress,
+			ConfigFilePath: opts.configFilePath,
+			CertDirectory:  opts.certDirectory,
+			CaCertFile:     opts.caCertFile,
+			CacheTTL:       opts.cacheTTL,
+			MetricsEnabled: opts.metricsEnabled,
+			MetricsType:    opts.metricsType,
+			MetricsPort:    opts.metricsPort,
+			AdminAddress:   opts.adminAddress,
+
+			MaxBatchSize:           opts.maxBatchSize,
+			BatchVerifyConcurrency: opts.batchConcurrency,
+		})
+		if err != nil {
+			return err
+		}
+		logrus.Infof("starting standalone server at %s", opts.httpServerAddress)
+		return server.RunStandalone()
+	}
+
 	getExecutor, err := config.GetExecutorAndWatchForUpdate(opts.configFilePath)
 	if err != nil {
 		return err
@@ -118,6 +168,9 @@ func serve(opts serveCmdOptions) error {
 		if err != nil {
 			return err
 		}
+		server.AdminAddress = opts.adminAddress
+		server.MaxBatchSize = opts.maxBatchSize
+		server.BatchVerifyConcurrency = opts.batchConcurrency
 		logrus.Infof("starting server at" + opts.httpServerAddress)
 		if err := server.Run(nil); err != nil {
 			return err
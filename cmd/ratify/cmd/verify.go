@@ -18,6 +18,7 @@ package cmd
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/ratify-project/ratify/config"
 	"github.com/ratify-project/ratify/internal/constants"
@@ -44,6 +45,8 @@ type verifyCmdOptions struct {
 	subject        string
 	artifactTypes  []string
 	silentMode     bool
+	explain        bool
+	since          string
 }
 
 func NewCmdVerify(_ ...string) *cobra.Command {
@@ -65,6 +68,8 @@ func NewCmdVerify(_ ...string) *cobra.Command {
 	flags.StringVarP(&opts.configFilePath, "config", "c", "", "Config File Path")
 	flags.StringArrayVarP(&opts.artifactTypes, "artifactType", "t", nil, "artifact type to filter")
 	flags.BoolVar(&opts.silentMode, "silent", false, "Silent output")
+	flags.BoolVar(&opts.explain, "explain", false, "Include the derivation of the verification decision in the output")
+	flags.StringVar(&opts.since, "since", "", "Force re-verification, bypassing cached verification results produced before the given RFC 3339 timestamp or duration ago, e.g. 0s")
 	return cmd
 }
 
@@ -78,6 +83,13 @@ func verify(opts verifyCmdOptions) error {
 		return err
 	}
 
+	var since time.Time
+	if opts.since != "" {
+		if since, err = utils.ParseSince(opts.since, time.Now()); err != nil {
+			return err
+		}
+	}
+
 	cf, err := config.Load(opts.configFilePath)
 	if err != nil {
 		return err
@@ -103,7 +115,7 @@ func verify(opts verifyCmdOptions) error {
 		return err
 	}
 
-	policyEnforcer, err := pf.CreatePolicyProviderFromConfig(cf.PoliciesConfig)
+	policyEnforcer, err := pf.CreatePolicyProviderOrDefault(cf.PoliciesConfig, cf.ExecutorConfig.DefaultPolicy)
 
 	if err != nil {
 		return err
@@ -119,6 +131,8 @@ func verify(opts verifyCmdOptions) error {
 	verifyParameters := e.VerifyParameters{
 		Subject:        opts.subject,
 		ReferenceTypes: opts.artifactTypes,
+		Explain:        opts.explain,
+		Since:          since,
 	}
 
 	result, err := executor.VerifySubject(context.Background(), verifyParameters)
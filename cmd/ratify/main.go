@@ -21,10 +21,14 @@ import (
 	"github.com/ratify-project/ratify/cmd/ratify/cmd"
 	_ "github.com/ratify-project/ratify/pkg/cache/dapr"                  // register dapr cache
 	_ "github.com/ratify-project/ratify/pkg/cache/ristretto"             // register ristretto cache
+	_ "github.com/ratify-project/ratify/pkg/policyprovider/celpolicy"    // register celpolicy policy provider
 	_ "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy" // register configpolicy policy provider
 	_ "github.com/ratify-project/ratify/pkg/policyprovider/regopolicy"   // register regopolicy policy provider
+	_ "github.com/ratify-project/ratify/pkg/policyprovider/scorepolicy"  // register scorepolicy policy provider
 	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"          // register oras referrer store
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/router"        // register router referrer store
 	_ "github.com/ratify-project/ratify/pkg/verifier/cosign"             // register cosign verifier
+	_ "github.com/ratify-project/ratify/pkg/verifier/helmprovenance"     // register helm provenance verifier
 	_ "github.com/ratify-project/ratify/pkg/verifier/notation"           // register notation verifier
 )
 
@@ -16,6 +16,7 @@ limitations under the License.
 package config
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -29,6 +30,7 @@ import (
 	"github.com/ratify-project/ratify/internal/logger"
 	exConfig "github.com/ratify-project/ratify/pkg/executor/config"
 	"github.com/ratify-project/ratify/pkg/homedir"
+	"github.com/ratify-project/ratify/pkg/metrics"
 	"github.com/ratify-project/ratify/pkg/policyprovider"
 	pcConfig "github.com/ratify-project/ratify/pkg/policyprovider/config"
 	pf "github.com/ratify-project/ratify/pkg/policyprovider/factory"
@@ -53,6 +55,7 @@ type Config struct {
 	VerifiersConfig vfConfig.VerifiersConfig `json:"verifier,omitempty"`
 	ExecutorConfig  exConfig.ExecutorConfig  `json:"executor,omitempty"`
 	LoggerConfig    logger.Config            `json:"logger,omitempty"`
+	MetricsConfig   metrics.LabelConfig      `json:"metrics,omitempty"`
 	fileHash        string                   `json:"-"`
 }
 
@@ -101,18 +104,32 @@ func CreateFromConfig(cf Config) ([]referrerstore.ReferrerStore, []verifier.Refe
 
 	logrus.Infof("verifiers successfully created. number of verifiers %d", len(verifiers))
 
-	policyEnforcer, err := pf.CreatePolicyProviderFromConfig(cf.PoliciesConfig)
+	policyEnforcer, err := pf.CreatePolicyProviderOrDefault(cf.PoliciesConfig, cf.ExecutorConfig.DefaultPolicy)
 
 	if err != nil {
+		verifier.DeleteConfigHashes(verifiers)
 		return nil, nil, nil, errors.Wrap(err, "failed to load policy provider from config")
 	}
 
+	// the policy may reference the verifiers of the config file by name
+	if validator, ok := policyEnforcer.(policyprovider.VerifierNamesPolicyProvider); ok {
+		verifierNames := make([]string, 0, len(verifiers))
+		for _, referenceVerifier := range verifiers {
+			verifierNames = append(verifierNames, referenceVerifier.Name())
+		}
+		if err := validator.ValidateVerifierNames(context.Background(), verifierNames); err != nil {
+			verifier.DeleteConfigHashes(verifiers)
+			return nil, nil, nil, errors.Wrap(err, "failed to load policy provider from config")
+		}
+	}
+
 	logrus.Infof("policies successfully created.")
 
 	return stores, verifiers, policyEnforcer, nil
 }
 
-// Load the config from file path provided, read from default path if configFilePath is empty
+// Load the config from file path provided, read from default path if configFilePath is empty.
+// The overlay file selected by SetOverlayFile or RATIFY_CONFIG_OVERLAY is merged over it.
 func Load(configFilePath string) (Config, error) {
 	config := Config{}
 	var err error
@@ -122,10 +139,21 @@ func Load(configFilePath string) (Config, error) {
 		return config, fmt.Errorf("unable to read config file at path %s: %w", configFilePath, err)
 	}
 
+	if body, err = applyOverlay(body); err != nil {
+		return config, err
+	}
+
 	if err = json.Unmarshal(body, &config); err != nil {
 		return config, fmt.Errorf("unable to unmarshal config body: %w", err)
 	}
 
+	if err = config.ExecutorConfig.Validate(); err != nil {
+		return config, fmt.Errorf("invalid executor config: %w", err)
+	}
+	if config.ExecutorConfig.Normalize() {
+		logrus.Warn("failOnUnknownArtifactType is deprecated, set unknownArtifactType to fail instead")
+	}
+
 	if config.fileHash, err = getFileHash(body); err != nil {
 		return config, fmt.Errorf("error getting configuration file hash error: %w", err)
 	}
@@ -155,6 +183,16 @@ func GetLoggerConfig(configFilePath string) (logger.Config, error) {
 	return config.LoggerConfig, nil
 }
 
+// GetMetricsConfig returns metrics label configuration from config file at specified path.
+func GetMetricsConfig(configFilePath string) (metrics.LabelConfig, error) {
+	config, err := Load(configFilePath)
+	if err != nil {
+		return metrics.LabelConfig{}, fmt.Errorf("unable to load config: %w", err)
+	}
+
+	return config.MetricsConfig, nil
+}
+
 // if configFilePath is empty, return configuration path from environment variable
 func getConfigurationFile(configFilePath string) string {
 	if configFilePath == "" {
@@ -18,11 +18,14 @@ package config
 import (
 	"context"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/pkg/errors"
 	ef "github.com/ratify-project/ratify/pkg/executor/core"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/sirupsen/logrus"
 )
 
@@ -91,27 +94,38 @@ func reloadExecutor(configFilePath string) {
 			return
 		}
 
+		previous := executor
 		executor = newExecutor
 		configHash = cf.fileHash
+		for _, store := range previous.ReferrerStores {
+			referrerstore.Close(store)
+		}
+		// the verifiers of the previous config are no longer in use
+		verifier.DeleteConfigHashes(previous.Verifiers)
 		logrus.Infof("configuration file has been updated, reloading executor succeeded")
 	} else {
 		logrus.Infof("no change found in config file, no executor update needed")
 	}
 }
 
-// Setup a watcher on file at configFilePath, reload executor on file change
+// Setup a watcher on file at configFilePath and on the overlay file if any, reload executor on file change
 func watchForConfigurationChange(configFilePath string) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return errors.Wrap(err, "new file watcher on configuration file failed ")
 	}
 
-	if err = watcher.Add(configFilePath); err != nil {
-		logrus.Errorf("adding configuration file watcher failed, err: %v", err)
-		return err
+	watchedFiles := []string{configFilePath}
+	if overlayPath := getOverlayFile(); overlayPath != "" {
+		watchedFiles = append(watchedFiles, overlayPath)
+	}
+	for _, watchedFile := range watchedFiles {
+		if err = watcher.Add(watchedFile); err != nil {
+			logrus.Errorf("adding configuration file watcher failed, err: %v", err)
+			return err
+		}
+		logrus.Infof("watcher added on configuration file %v", watchedFile)
 	}
-
-	logrus.Infof("watcher added on configuration file %v", configFilePath)
 
 	// setup for loop to listen for events
 	go func() {
@@ -125,16 +139,20 @@ func watchForConfigurationChange(configFilePath string) error {
 
 				logrus.Infof("file watcher event detected %v", event)
 
+				if !slices.Contains(watchedFiles, event.Name) {
+					continue
+				}
+
 				// In a cluster scenario, a configMap will recreate the config file
 				// after the remove event, the watcher will also be removed
 				// since a watcher on a non existent file is not supported, we sleep until the file exist add the watcher back
-				if event.Name == configFilePath && event.Op&fsnotify.Remove == fsnotify.Remove {
+				if event.Op&fsnotify.Remove == fsnotify.Remove {
 					logrus.Infof("config file remove event detected")
 					sleepTime := 1 * time.Second
 					waitTime := 60 //1min
 
 					time.Sleep(sleepTime)
-					_, err := os.Stat(configFilePath)
+					_, err := os.Stat(event.Name)
 
 					for err != nil {
 						if waitTime < 0 {
@@ -142,23 +160,23 @@ func watchForConfigurationChange(configFilePath string) error {
 							return
 						}
 						logrus.Infof("config file does not exist yet, sleeping again")
-						_, err = os.Stat(configFilePath)
+						_, err = os.Stat(event.Name)
 						time.Sleep(sleepTime)
 						waitTime--
 					}
 					reloadExecutor(configFilePath)
-					err = watcher.Add(configFilePath)
+					err = watcher.Add(event.Name)
 
 					if err != nil {
 						logrus.Errorf("adding configuration file watcher failed, err: %v", err)
 						continue
 					}
 
-					logrus.Infof("watcher added on configuration directory %v", configFilePath)
+					logrus.Infof("watcher added on configuration directory %v", event.Name)
 				}
 
 				// In a local scenario, the configuration will be updated through a write event
-				if event.Name == configFilePath && event.Op&fsnotify.Write == fsnotify.Write {
+				if event.Op&fsnotify.Write == fsnotify.Write {
 					reloadExecutor(configFilePath)
 				}
 
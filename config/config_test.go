@@ -85,6 +85,32 @@ func TestLoad_FromGivenPath(t *testing.T) {
 	}
 }
 
+func TestGetMetricsConfig(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test-config")
+	if err != nil {
+		t.Fatalf("temp dir creation failed %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fileName := filepath.Join(tmpDir, ConfigFileName)
+	content := []byte(`{"metrics": {"dropLabels": ["verifier"], "allowedValues": {"verifier_type": ["notation"]}}}`)
+	err = os.WriteFile(fileName, content, 0600)
+	if err != nil {
+		t.Fatalf("config file creation failed %v", err)
+	}
+
+	metricsConfig, err := GetMetricsConfig(fileName)
+	if err != nil {
+		t.Fatalf("loading metrics config failed %v", err)
+	}
+	if len(metricsConfig.DropLabels) != 1 || metricsConfig.DropLabels[0] != "verifier" {
+		t.Fatalf("unexpected dropLabels %v", metricsConfig.DropLabels)
+	}
+	if values := metricsConfig.AllowedValues["verifier_type"]; len(values) != 1 || values[0] != "notation" {
+		t.Fatalf("unexpected allowedValues %v", metricsConfig.AllowedValues)
+	}
+}
+
 func TestLoad_NonExistentConfigFile(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "test-config")
 	if err != nil {
@@ -0,0 +1,162 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	notationVerifierType = "notation"
+	cosignVerifierType   = "cosign"
+	// migratedTrustPolicyName is the name of the cosign trust policy created
+	// from a legacy cosign verifier configuration.
+	migratedTrustPolicyName = "default"
+)
+
+// notationTrustStoreTypes are the trust store types keying the
+// verificationCertStores of the notation verifier.
+var notationTrustStoreTypes = map[string]bool{"ca": true, "signingAuthority": true, "tsa": true}
+
+// MigrationReport lists the migrations applied to a configuration and the
+// deprecated fields that must be migrated manually.
+type MigrationReport struct {
+	Applied []string `json:"applied"`
+	Manual  []string `json:"manual,omitempty"`
+}
+
+// migration upgrades a deprecated field of a verifier configuration in place.
+type migration func(path string, verifier map[string]interface{}, report *MigrationReport)
+
+// migrations are applied in order to every verifier configuration.
+var migrations = []migration{
+	migrateVerifierType,
+	migrateNotationCertStores,
+	migrateCosignLegacyKey,
+}
+
+// Migrate upgrades the deprecated fields of the configuration body and returns
+// the upgraded configuration along with the applied migrations. Fields unknown
+// to the migrations are preserved.
+func Migrate(body []byte) ([]byte, MigrationReport, error) {
+	report := MigrationReport{Applied: []string{}}
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, report, fmt.Errorf("unable to unmarshal config body: %w", err)
+	}
+
+	if verifiers, ok := config["verifier"].(map[string]interface{}); ok {
+		plugins, _ := verifiers["plugins"].([]interface{})
+		for idx, plugin := range plugins {
+			verifier, ok := plugin.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path := fmt.Sprintf("verifier.plugins[%d]", idx)
+			for _, migrate := range migrations {
+				migrate(path, verifier, &report)
+			}
+		}
+	}
+
+	migrated, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return nil, report, fmt.Errorf("unable to marshal migrated config: %w", err)
+	}
+	return append(migrated, '\n'), report, nil
+}
+
+// verifierType returns the type of the verifier, which defaults to its name.
+func verifierType(verifier map[string]interface{}) string {
+	if verifierType, ok := verifier["type"].(string); ok && verifierType != "" {
+		return verifierType
+	}
+	name, _ := verifier["name"].(string)
+	return name
+}
+
+// migrateVerifierType sets the type of verifiers relying on the deprecated use
+// of their name as the type.
+func migrateVerifierType(path string, verifier map[string]interface{}, report *MigrationReport) {
+	if _, ok := verifier["type"]; ok {
+		return
+	}
+	name, ok := verifier["name"].(string)
+	if !ok || name == "" {
+		return
+	}
+	verifier["type"] = name
+	report.Applied = append(report.Applied, fmt.Sprintf("%s: set type %q instead of deriving it from the name", path, name))
+}
+
+// migrateNotationCertStores nests the legacy verificationCertStores of the
+// notation verifier, which lists named stores without a trust store type,
+// under the ca trust store type.
+func migrateNotationCertStores(path string, verifier map[string]interface{}, report *MigrationReport) {
+	if verifierType(verifier) != notationVerifierType {
+		return
+	}
+	certStores, ok := verifier["verificationCertStores"].(map[string]interface{})
+	if !ok || len(certStores) == 0 {
+		return
+	}
+	for storeType := range certStores {
+		if notationTrustStoreTypes[storeType] {
+			return
+		}
+	}
+	verifier["verificationCertStores"] = map[string]interface{}{"ca": certStores}
+	report.Applied = append(report.Applied, fmt.Sprintf("%s: moved the legacy verificationCertStores under the ca trust store type", path))
+}
+
+// migrateCosignLegacyKey replaces the legacy key and rekorURL fields of the
+// cosign verifier with a trust policy verifying the signatures of every scope
+// with the key. Legacy keyless configurations are reported for manual
+// migration since trust policies require the certificate identity to verify.
+func migrateCosignLegacyKey(path string, verifier map[string]interface{}, report *MigrationReport) {
+	if verifierType(verifier) != cosignVerifierType {
+		return
+	}
+	key, hasKey := verifier["key"].(string)
+	rekorURL, hasRekorURL := verifier["rekorURL"].(string)
+	if !hasKey && !hasRekorURL {
+		return
+	}
+	if _, ok := verifier["trustPolicies"]; ok {
+		report.Manual = append(report.Manual, fmt.Sprintf("%s: remove the legacy key and rekorURL fields, which cannot be used with trustPolicies", path))
+		return
+	}
+	if key == "" {
+		report.Manual = append(report.Manual, fmt.Sprintf("%s: replace the legacy keyless rekorURL configuration with a trust policy declaring the keyless certificate identity and issuer", path))
+		return
+	}
+
+	trustPolicy := map[string]interface{}{
+		"name":       migratedTrustPolicyName,
+		"version":    "1.0.0",
+		"scopes":     []interface{}{"*"},
+		"keys":       []interface{}{map[string]interface{}{"file": key}},
+		"tLogVerify": rekorURL != "",
+	}
+	if rekorURL != "" {
+		trustPolicy["rekorURL"] = rekorURL
+	}
+	delete(verifier, "key")
+	delete(verifier, "rekorURL")
+	verifier["trustPolicies"] = []interface{}{trustPolicy}
+	report.Applied = append(report.Applied, fmt.Sprintf("%s: replaced the legacy key and rekorURL fields with trust policy %q", path, migratedTrustPolicyName))
+}
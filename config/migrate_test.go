@@ -0,0 +1,137 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const deprecatedConfig = `{
+    "store": {"version": "1.0.0", "plugins": [{"name": "oras"}]},
+    "verifier": {
+        "version": "1.0.0",
+        "plugins": [
+            {
+                "name": "notation",
+                "artifactTypes": "application/vnd.cncf.notary.signature",
+                "verificationCertStores": {"certs": ["kv1", "kv2"]}
+            },
+            {
+                "name": "cosign",
+                "artifactTypes": "application/vnd.dev.cosign.artifact.sig.v1+json",
+                "key": "/keys/cosign.pub",
+                "rekorURL": "https://rekor.sigstore.dev"
+            },
+            {
+                "name": "cosign-keyless",
+                "type": "cosign",
+                "artifactTypes": "application/vnd.dev.cosign.artifact.sig.v1+json",
+                "rekorURL": "https://rekor.sigstore.dev"
+            }
+        ]
+    }
+}`
+
+const migratedConfig = `{
+    "store": {"version": "1.0.0", "plugins": [{"name": "oras"}]},
+    "verifier": {
+        "version": "1.0.0",
+        "plugins": [
+            {
+                "name": "notation",
+                "type": "notation",
+                "artifactTypes": "application/vnd.cncf.notary.signature",
+                "verificationCertStores": {"ca": {"certs": ["kv1", "kv2"]}}
+            },
+            {
+                "name": "cosign",
+                "type": "cosign",
+                "artifactTypes": "application/vnd.dev.cosign.artifact.sig.v1+json",
+                "trustPolicies": [
+                    {
+                        "name": "default",
+                        "version": "1.0.0",
+                        "scopes": ["*"],
+                        "keys": [{"file": "/keys/cosign.pub"}],
+                        "tLogVerify": true,
+                        "rekorURL": "https://rekor.sigstore.dev"
+                    }
+                ]
+            },
+            {
+                "name": "cosign-keyless",
+                "type": "cosign",
+                "artifactTypes": "application/vnd.dev.cosign.artifact.sig.v1+json",
+                "rekorURL": "https://rekor.sigstore.dev"
+            }
+        ]
+    }
+}`
+
+func TestMigrate(t *testing.T) {
+	migrated, report, err := Migrate([]byte(deprecatedConfig))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var actual, expected map[string]interface{}
+	if err := json.Unmarshal(migrated, &actual); err != nil {
+		t.Fatalf("failed to unmarshal the migrated config: %v", err)
+	}
+	if err := json.Unmarshal([]byte(migratedConfig), &expected); err != nil {
+		t.Fatalf("failed to unmarshal the expected config: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected migrated config %s, got %s", migratedConfig, migrated)
+	}
+
+	expectedApplied := []string{
+		`verifier.plugins[0]: set type "notation" instead of deriving it from the name`,
+		"verifier.plugins[0]: moved the legacy verificationCertStores under the ca trust store type",
+		`verifier.plugins[1]: set type "cosign" instead of deriving it from the name`,
+		`verifier.plugins[1]: replaced the legacy key and rekorURL fields with trust policy "default"`,
+	}
+	if !reflect.DeepEqual(report.Applied, expectedApplied) {
+		t.Fatalf("expected applied migrations %v, got %v", expectedApplied, report.Applied)
+	}
+	if len(report.Manual) != 1 {
+		t.Fatalf("expected the keyless cosign verifier to require a manual migration, got %v", report.Manual)
+	}
+}
+
+func TestMigrate_UpToDate(t *testing.T) {
+	migrated, report, err := Migrate([]byte(migratedConfig))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(report.Applied) != 0 {
+		t.Fatalf("expected no migrations, got %v", report.Applied)
+	}
+	var actual, expected map[string]interface{}
+	_ = json.Unmarshal(migrated, &actual)
+	_ = json.Unmarshal([]byte(migratedConfig), &expected)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected the config to be unchanged, got %s", migrated)
+	}
+}
+
+func TestMigrate_InvalidConfig(t *testing.T) {
+	if _, _, err := Migrate([]byte("{")); err == nil {
+		t.Fatal("expected an error for an invalid config")
+	}
+}
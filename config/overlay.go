@@ -0,0 +1,169 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ConfigOverlayEnv is the environment variable holding the path of the overlay
+// file merged over the base configuration.
+const ConfigOverlayEnv = "RATIFY_CONFIG_OVERLAY"
+
+// overlayNameKey is the key identifying list entries merged by name.
+const overlayNameKey = "name"
+
+var overlayFilePath string
+
+// SetOverlayFile sets the path of the overlay file merged over the base
+// configuration. It takes precedence over RATIFY_CONFIG_OVERLAY.
+func SetOverlayFile(path string) {
+	overlayFilePath = path
+}
+
+// getOverlayFile returns the path of the overlay file, or an empty string if
+// no overlay is selected.
+func getOverlayFile() string {
+	if overlayFilePath != "" {
+		return overlayFilePath
+	}
+	return os.Getenv(ConfigOverlayEnv)
+}
+
+// applyOverlay merges the selected overlay file, if any, over the base
+// configuration body.
+func applyOverlay(body []byte) ([]byte, error) {
+	overlayPath := getOverlayFile()
+	if overlayPath == "" {
+		return body, nil
+	}
+	overlay, err := os.ReadFile(overlayPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config overlay file at path %s: %w", overlayPath, err)
+	}
+	merged, err := mergeOverlay(body, overlay)
+	if err != nil {
+		return nil, fmt.Errorf("unable to merge config overlay file at path %s: %w", overlayPath, err)
+	}
+	return merged, nil
+}
+
+// mergeOverlay deep merges the overlay JSON document over the base document.
+// The overlay wins on conflicts with the following semantics:
+//   - objects are merged key by key, recursively.
+//   - a null value in the overlay removes the key from the base. Null values
+//     in objects the base does not have are dropped.
+//   - non-empty lists whose entries are all objects with a "name", such as
+//     verifier and store plugins, are merged by name: entries with the same
+//     name are deep merged and new entries are appended in overlay order.
+//   - any other list, including an empty one, and any other value, is
+//     replaced by the overlay.
+func mergeOverlay(base, overlay []byte) ([]byte, error) {
+	var baseDoc, overlayDoc interface{}
+	if err := json.Unmarshal(base, &baseDoc); err != nil {
+		return nil, fmt.Errorf("invalid base config: %w", err)
+	}
+	if err := json.Unmarshal(overlay, &overlayDoc); err != nil {
+		return nil, fmt.Errorf("invalid overlay config: %w", err)
+	}
+	return json.Marshal(mergeValues(baseDoc, overlayDoc))
+}
+
+func mergeValues(base, overlay interface{}) interface{} {
+	switch overlayValue := overlay.(type) {
+	case map[string]interface{}:
+		baseValue, ok := base.(map[string]interface{})
+		if !ok {
+			return withoutNulls(overlayValue)
+		}
+		for key, value := range overlayValue {
+			if value == nil {
+				delete(baseValue, key)
+				continue
+			}
+			baseValue[key] = mergeValues(baseValue[key], value)
+		}
+		return baseValue
+	case []interface{}:
+		baseValue, ok := base.([]interface{})
+		if !ok || !namedEntries(baseValue) || !namedEntries(overlayValue) {
+			return withoutNulls(overlayValue)
+		}
+		return mergeNamedEntries(baseValue, overlayValue)
+	default:
+		return overlay
+	}
+}
+
+// namedEntries reports whether the list is not empty and every entry of the
+// list is an object with a string name.
+func namedEntries(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, entry := range list {
+		object, ok := entry.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := object[overlayNameKey].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeNamedEntries(base, overlay []interface{}) []interface{} {
+	indexes := make(map[string]int, len(base))
+	for i, entry := range base {
+		indexes[entry.(map[string]interface{})[overlayNameKey].(string)] = i
+	}
+	for _, entry := range overlay {
+		name := entry.(map[string]interface{})[overlayNameKey].(string)
+		if i, ok := indexes[name]; ok {
+			base[i] = mergeValues(base[i], entry)
+			continue
+		}
+		indexes[name] = len(base)
+		base = append(base, withoutNulls(entry))
+	}
+	return base
+}
+
+// withoutNulls removes the null values of the objects in an overlay value
+// that is not merged over the base, recursively.
+func withoutNulls(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, entry := range value {
+			if entry == nil {
+				delete(value, key)
+				continue
+			}
+			value[key] = withoutNulls(entry)
+		}
+		return value
+	case []interface{}:
+		for i, entry := range value {
+			value[i] = withoutNulls(entry)
+		}
+		return value
+	default:
+		return value
+	}
+}
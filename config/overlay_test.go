@@ -0,0 +1,172 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeOverlay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		overlay  string
+		expected string
+		isErr    bool
+	}{
+		{
+			name:     "nested objects are deep merged",
+			base:     `{"store":{"version":"1.0.0","plugins":[]},"executor":{"verificationRequestTimeout":2900,"order":["a"]}}`,
+			overlay:  `{"executor":{"verificationRequestTimeout":5000}}`,
+			expected: `{"store":{"version":"1.0.0","plugins":[]},"executor":{"verificationRequestTimeout":5000,"order":["a"]}}`,
+		},
+		{
+			name:     "overlay adds keys at any depth",
+			base:     `{"executor":{}}`,
+			overlay:  `{"executor":{"notification":{"url":"https://example.com"}},"logger":{"formatter":"json"}}`,
+			expected: `{"executor":{"notification":{"url":"https://example.com"}},"logger":{"formatter":"json"}}`,
+		},
+		{
+			name:     "null removes a key",
+			base:     `{"executor":{"order":["a"],"shortCircuitOnFail":true}}`,
+			overlay:  `{"executor":{"order":null}}`,
+			expected: `{"executor":{"shortCircuitOnFail":true}}`,
+		},
+		{
+			name:     "value of another kind is replaced",
+			base:     `{"executor":{"order":["a"]}}`,
+			overlay:  `{"executor":"none"}`,
+			expected: `{"executor":"none"}`,
+		},
+		{
+			name:     "unnamed lists are replaced",
+			base:     `{"executor":{"order":["a","b"]}}`,
+			overlay:  `{"executor":{"order":["c"]}}`,
+			expected: `{"executor":{"order":["c"]}}`,
+		},
+		{
+			name: "named lists are merged by name and appended",
+			base: `{"verifier":{"plugins":[
+				{"name":"notation","artifactTypes":"application/vnd.cncf.notary.signature","trustPolicyDoc":{"version":"1.0","trustPolicies":[{"name":"default","registryScopes":["*"]}]}},
+				{"name":"cosign","artifactTypes":"application/vnd.dev.cosign.artifact.sig.v1+json"}
+			]}}`,
+			overlay: `{"verifier":{"plugins":[
+				{"name":"notation","trustPolicyDoc":{"trustPolicies":[{"name":"default","registryScopes":["prod.azurecr.io/*"]}]}},
+				{"name":"sbom","artifactTypes":"application/spdx+json"}
+			]}}`,
+			expected: `{"verifier":{"plugins":[
+				{"name":"notation","artifactTypes":"application/vnd.cncf.notary.signature","trustPolicyDoc":{"version":"1.0","trustPolicies":[{"name":"default","registryScopes":["prod.azurecr.io/*"]}]}},
+				{"name":"cosign","artifactTypes":"application/vnd.dev.cosign.artifact.sig.v1+json"},
+				{"name":"sbom","artifactTypes":"application/spdx+json"}
+			]}}`,
+		},
+		{
+			name:     "lists with unnamed entries are replaced",
+			base:     `{"store":{"plugins":[{"name":"oras","cosignEnabled":false}]}}`,
+			overlay:  `{"store":{"plugins":[{"cosignEnabled":true}]}}`,
+			expected: `{"store":{"plugins":[{"cosignEnabled":true}]}}`,
+		},
+		{
+			name:     "empty list replaces a named list",
+			base:     `{"verifier":{"plugins":[{"name":"notation"},{"name":"cosign"}]}}`,
+			overlay:  `{"verifier":{"plugins":[]}}`,
+			expected: `{"verifier":{"plugins":[]}}`,
+		},
+		{
+			name:     "nulls are dropped from new subtrees",
+			base:     `{"executor":{}}`,
+			overlay:  `{"executor":{"notification":{"url":"https://example.com","headers":null,"retry":{"maxAttempts":null}}},"logger":{"formatter":null}}`,
+			expected: `{"executor":{"notification":{"url":"https://example.com","retry":{}}},"logger":{}}`,
+		},
+		{
+			name:     "nulls are dropped from new named entries",
+			base:     `{"verifier":{"plugins":[{"name":"notation"}]}}`,
+			overlay:  `{"verifier":{"plugins":[{"name":"sbom","disallowedLicenses":null,"nested":{"key":null}}]}}`,
+			expected: `{"verifier":{"plugins":[{"name":"notation"},{"name":"sbom","nested":{}}]}}`,
+		},
+		{
+			name:    "invalid base",
+			base:    `{`,
+			overlay: `{}`,
+			isErr:   true,
+		},
+		{
+			name:    "invalid overlay",
+			base:    `{}`,
+			overlay: `{`,
+			isErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := mergeOverlay([]byte(tt.base), []byte(tt.overlay))
+			if tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+			if tt.isErr {
+				return
+			}
+			var actual, expected interface{}
+			if err := json.Unmarshal(merged, &actual); err != nil {
+				t.Fatalf("failed to unmarshal merged config: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("failed to unmarshal expected config: %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("expected merged config %s, got %s", tt.expected, merged)
+			}
+		})
+	}
+}
+
+func TestLoad_WithOverlay(t *testing.T) {
+	tmpDir := t.TempDir()
+	fileName := filepath.Join(tmpDir, ConfigFileName)
+	if err := os.WriteFile(fileName, []byte(`{"store":{"version":"1.0.0"},"executor":{"verificationRequestTimeout":2900,"order":["notation"]}}`), 0600); err != nil {
+		t.Fatalf("config file creation failed %v", err)
+	}
+	overlayName := filepath.Join(tmpDir, "prod.json")
+	if err := os.WriteFile(overlayName, []byte(`{"executor":{"verificationRequestTimeout":5000}}`), 0600); err != nil {
+		t.Fatalf("overlay file creation failed %v", err)
+	}
+
+	t.Setenv(ConfigOverlayEnv, overlayName)
+	config, err := Load(fileName)
+	if err != nil {
+		t.Fatalf("loading config failed %v", err)
+	}
+	if config.StoresConfig.Version != testVersion {
+		t.Fatalf("expected store version %s, got %s", testVersion, config.StoresConfig.Version)
+	}
+	if *config.ExecutorConfig.VerificationRequestTimeout != 5000 {
+		t.Fatalf("expected overlay timeout 5000, got %d", *config.ExecutorConfig.VerificationRequestTimeout)
+	}
+	if !reflect.DeepEqual(config.ExecutorConfig.Order, []string{"notation"}) {
+		t.Fatalf("expected base order to be kept, got %v", config.ExecutorConfig.Order)
+	}
+
+	// the overlay file set explicitly takes precedence over the environment
+	SetOverlayFile(filepath.Join(tmpDir, "missing.json"))
+	defer SetOverlayFile("")
+	if _, err := Load(fileName); err == nil {
+		t.Fatalf("expected error for missing overlay file")
+	}
+}
@@ -132,4 +132,44 @@ var (
 		Message:     "operation forbidden",
 		Description: "The requested operation is forbidden. Please verify the permission to the requested resource.",
 	})
+
+	// ErrorCodeThrottled is returned when a remote service throttled the
+	// request.
+	ErrorCodeThrottled = Register("errcode", ErrorDescriptor{
+		Value:       "THROTTLED",
+		Message:     "request throttled",
+		Description: "The request was throttled by the remote service. Please retry later or reduce the request rate.",
+	})
+
+	// ErrorCodeNetworkFailure is returned when a request to a remote service
+	// failed transiently, e.g. on a network error, a timeout or a server error.
+	ErrorCodeNetworkFailure = Register("errcode", ErrorDescriptor{
+		Value:       "NETWORK_FAILURE",
+		Message:     "network failure",
+		Description: "The request to the remote service failed transiently. Please verify the connectivity to the service and retry.",
+	})
+
+	// ErrorCodeResourceBusy is returned when Ratify ran out of a local
+	// resource, such as its memory budget, for too long.
+	ErrorCodeResourceBusy = Register("errcode", ErrorDescriptor{
+		Value:       "RESOURCE_BUSY",
+		Message:     "resource busy",
+		Description: "Ratify is busy serving other requests and could not reserve the resources for this one. Please retry later.",
+	})
+
+	// ErrorCodeRegistryNotAllowed is returned when the subject is served from a
+	// registry host that is not allowed by the executor configuration.
+	ErrorCodeRegistryNotAllowed = Register("errcode", ErrorDescriptor{
+		Value:       "REGISTRY_NOT_ALLOWED",
+		Message:     "registry not allowed",
+		Description: "The subject is served from a registry host that is not listed in the allowed registries of the executor. Please pull the subject from an allowed registry or update the executor configuration.",
+	})
+
+	// ErrorCodePlatformNotFound is returned when no manifest of an index
+	// subject matches the platform configured for the executor.
+	ErrorCodePlatformNotFound = Register("errcode", ErrorDescriptor{
+		Value:       "PLATFORM_NOT_FOUND",
+		Message:     "platform not found",
+		Description: "The image index of the subject has no single manifest matching the configured platform. Please verify the platform, including the os.version of Windows images, of the executor configuration.",
+	})
 )
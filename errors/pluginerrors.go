@@ -112,6 +112,14 @@ var (
 		Description: "No verifier report was generated. This might be due to various factors, such as lack of artifacts attached to the image, a misconfiguration in the Referrer Store preventing access to the registry, or the absence of appropriate verifiers corresponding to the referenced image artifacts.",
 	})
 
+	// ErrorCodeVerificationLimitExceeded is returned when the verification of
+	// a subject exceeds a configured depth or breadth guard.
+	ErrorCodeVerificationLimitExceeded = Register("errcode", ErrorDescriptor{
+		Value:       "VERIFICATION_LIMIT_EXCEEDED",
+		Message:     "verification limit exceeded",
+		Description: "The subject nests referrers deeper, or attaches more referrers, than the configured maxDepth or maxReferrersPerNode guards of the executor allow. Please check the error details for the guard that was exceeded.",
+	})
+
 	// Generic errors happen in plugins
 
 	// ErrorCodePluginInitFailure is returned when executor or controller fails
@@ -33,9 +33,13 @@ import (
 	"github.com/ratify-project/ratify/pkg/metrics"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	pkgUtils "github.com/ratify-project/ratify/pkg/utils"
+	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/utils"
 
+	"github.com/gorilla/mux"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
 )
 
 const apiVersion = "externaldata.gatekeeper.sh/v1alpha1"
@@ -64,6 +68,10 @@ func (server *Server) verify(ctx context.Context, w http.ResponseWriter, r *http
 	if err = json.Unmarshal(body, &providerRequest); err != nil {
 		return fmt.Errorf("unable to unmarshal request body: %w", err)
 	}
+	since, err := parseSince(r)
+	if err != nil {
+		return errors.ErrorCodeBadRequest.WithError(err)
+	}
 
 	results := make([]externaldata.Item, 0)
 	wg := sync.WaitGroup{}
@@ -74,87 +82,314 @@ func (server *Server) verify(ctx context.Context, w http.ResponseWriter, r *http
 		wg.Add(1)
 		go func(key string, ctx context.Context) {
 			defer wg.Done()
-			routineStartTime := time.Now()
-			returnItem := externaldata.Item{
-				Key: key,
-			}
-			defer func() {
-				mu.Lock()
-				results = append(results, returnItem)
-				mu.Unlock()
-			}()
-			requestKey, err := pkgUtils.ParseRequestKey(key)
-			if err != nil {
-				returnItem.Error = err.Error()
-				return
-			}
-			subjectReference, err := pkgUtils.ParseSubjectReference(requestKey.Subject)
-			if err != nil {
-				returnItem.Error = err.Error()
-				return
-			}
-			ctx = ctxUtils.SetContextWithNamespace(ctx, requestKey.Namespace)
+			returnItem := server.verifyKey(ctx, key, since, nil, nil)
+			mu.Lock()
+			results = append(results, returnItem)
+			mu.Unlock()
+		}(utils.SanitizeString(key), ctx)
+	}
+	wg.Wait()
+	elapsedTime := time.Since(startTime).Milliseconds()
+	logger.GetLogger(ctx, server.LogOption).Debugf("verification: execution time for request: %dms", elapsedTime)
+	metrics.ReportVerificationRequest(ctx, elapsedTime)
+	return sendResponse(&results, "", w, http.StatusOK, false)
+}
 
-			if err := server.validateComponents(ctx, verifyComponents); err != nil {
-				logger.GetLogger(ctx, server.LogOption).Error(err)
-				returnItem.Error = err.Error()
-				return
-			}
+// batchVerify validates a batch of images against the configured policy. The
+// images are verified with bounded concurrency and the response holds the
+// result of every image, in request order, along with the combined decision.
+// Images not verified before the batch times out are reported with a timeout
+// error.
+func (server *Server) batchVerify(_ context.Context, w http.ResponseWriter, r *http.Request) error {
+	startTime := time.Now()
+	ctx := logger.InitContext(r.Context(), r)
+	sanitizedMethod := utils.SanitizeString(r.Method)
+	sanitizedURL := utils.SanitizeURL(*r.URL)
+	logger.GetLogger(ctx, server.LogOption).Debugf("start request %s %s", sanitizedMethod, sanitizedURL)
 
-			if subjectReference.Digest.String() == "" {
-				logger.GetLogger(ctx, server.LogOption).Warn("Digest should be used instead of tagged reference. The resolved digest may not point to the same signed artifact, since tags are mutable.")
-			}
-			resolvedSubjectReference := subjectReference.Original
-			unlock := server.keyMutex.Lock(resolvedSubjectReference)
-			defer unlock()
-
-			logger.GetLogger(ctx, server.LogOption).Infof("verifying subject %v", resolvedSubjectReference)
-			var result types.VerifyResult
-			found := false
-			cacheHit := false
-			var cacheResponse string
-			cacheProvider := cache.GetCacheProvider()
-			if cacheProvider != nil {
-				cacheResponse, found = cacheProvider.Get(ctx, fmt.Sprintf(cache.CacheKeyVerifyHandler, resolvedSubjectReference))
-			}
-			if found && cacheResponse != "" {
-				if err := json.Unmarshal([]byte(cacheResponse), &result); err != nil {
-					err = errors.ErrorCodeDataDecodingFailure.WithError(err).WithDetail(fmt.Sprintf("unable to unmarshal cache entry for subject %v", resolvedSubjectReference))
-					logger.GetLogger(ctx, server.LogOption).Warn(err)
-				} else {
-					cacheHit = true
-					logger.GetLogger(ctx, server.LogOption).Debugf("cache hit for subject %v", resolvedSubjectReference)
-				}
-			}
-			if !cacheHit {
-				verifyParameters := executor.VerifyParameters{
-					Subject: resolvedSubjectReference,
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return sendBatchResponse(&BatchVerifyResponse{Error: fmt.Sprintf("unable to read request body: %v", err)}, w, http.StatusInternalServerError)
+	}
+	defer r.Body.Close()
+
+	var batchRequest BatchVerifyRequest
+	if err = json.Unmarshal(body, &batchRequest); err != nil {
+		return sendBatchResponse(&BatchVerifyResponse{Error: fmt.Sprintf("unable to unmarshal request body: %v", err)}, w, http.StatusBadRequest)
+	}
+	since, err := parseSince(r)
+	if err != nil {
+		return sendBatchResponse(&BatchVerifyResponse{Error: err.Error()}, w, http.StatusBadRequest)
+	}
+	if !batchRequest.RequestContext.IsEmpty() && !clientAuthenticated(r) {
+		return sendBatchResponse(&BatchVerifyResponse{Error: "requestContext is only accepted from clients authenticated with a client certificate"}, w, http.StatusForbidden)
+	}
+	if platform := batchRequest.Platform; platform != nil && (platform.OS == "" || platform.Architecture == "") {
+		return sendBatchResponse(&BatchVerifyResponse{Error: "platform must set os and architecture"}, w, http.StatusBadRequest)
+	}
+	if len(batchRequest.References) == 0 {
+		return sendBatchResponse(&BatchVerifyResponse{Error: "references must not be empty"}, w, http.StatusBadRequest)
+	}
+	maxBatchSize := server.maxBatchSize()
+	if len(batchRequest.References) > maxBatchSize {
+		return sendBatchResponse(&BatchVerifyResponse{Error: fmt.Sprintf("batch size %d exceeds the maximum of %d references", len(batchRequest.References), maxBatchSize)}, w, http.StatusRequestEntityTooLarge)
+	}
+
+	timeout := server.batchVerifyTimeout(len(batchRequest.References))
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response := BatchVerifyResponse{
+		IsSuccess: true,
+		Results:   make([]BatchVerifyItem, len(batchRequest.References)),
+	}
+	// done marks the results set, by their verification or by the timeout,
+	// so that verifications finishing after the timeout are discarded
+	var mu sync.Mutex
+	done := make([]bool, len(batchRequest.References))
+	setResult := func(idx int, item BatchVerifyItem) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done[idx] {
+			response.Results[idx] = item
+			done[idx] = true
+		}
+	}
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		eg := errgroup.Group{}
+		eg.SetLimit(server.batchVerifyConcurrency())
+		for idx, reference := range batchRequest.References {
+			idx, reference := idx, utils.SanitizeString(reference)
+			eg.Go(func() error {
+				if ctx.Err() != nil {
+					return nil
 				}
-				if result, err = server.GetExecutor(ctx).VerifySubject(ctx, verifyParameters); err != nil {
-					returnItem.Error = errors.ErrorCodeExecutorFailure.WithError(err).WithComponentType(errors.Executor).Error()
-					return
+				item := server.verifyKey(ctx, reference, since, batchRequest.RequestContext, batchRequest.Platform)
+				batchItem := BatchVerifyItem{Reference: reference, Error: item.Error}
+				if verificationResponse, ok := item.Value.(VerificationResponse); ok {
+					batchItem.Result = &verificationResponse
 				}
+				setResult(idx, batchItem)
+				return nil
+			})
+		}
+		_ = eg.Wait()
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		logger.GetLogger(ctx, server.LogOption).Warnf("batch verification of %d references timed out after %v", len(batchRequest.References), timeout)
+	}
+	// the references not verified in time fail with a timeout error
+	for idx, reference := range batchRequest.References {
+		setResult(idx, BatchVerifyItem{Reference: utils.SanitizeString(reference), Error: fmt.Sprintf("verification timed out after %v", timeout)})
+	}
+	for _, item := range response.Results {
+		if item.Error != "" || item.Result == nil || !item.Result.IsSuccess {
+			response.IsSuccess = false
+			break
+		}
+	}
 
-				if cacheProvider != nil {
-					logger.GetLogger(ctx, server.LogOption).Debugf("cache miss for subject %v", resolvedSubjectReference)
-					if !cacheProvider.SetWithTTL(ctx, fmt.Sprintf(cache.CacheKeyVerifyHandler, resolvedSubjectReference), result, server.CacheTTL) {
-						logger.GetLogger(ctx, server.LogOption).Warnf("unable to insert cache entry for subject %v", resolvedSubjectReference)
-					}
-				}
+	elapsedTime := time.Since(startTime).Milliseconds()
+	logger.GetLogger(ctx, server.LogOption).Debugf("batch verification: execution time for %d references: %dms", len(batchRequest.References), elapsedTime)
+	metrics.ReportVerificationRequest(ctx, elapsedTime)
+	return sendBatchResponse(&response, w, http.StatusOK)
+}
+
+// batchVerifyTimeout returns the timeout of a batch of references: the
+// timeout of a single verification for each round of references verified
+// concurrently.
+func (server *Server) batchVerifyTimeout(references int) time.Duration {
+	concurrency := server.batchVerifyConcurrency()
+	rounds := (references + concurrency - 1) / concurrency
+	return time.Duration(rounds) * server.GetExecutor(server.Context).GetVerifyRequestTimeout()
+}
+
+// parseSince returns the time before which cached verification results are
+// bypassed, set by the since query parameter of the request.
+func parseSince(r *http.Request) (time.Time, error) {
+	since := r.URL.Query().Get(sinceQueryParameter)
+	if since == "" {
+		return time.Time{}, nil
+	}
+	return pkgUtils.ParseSince(since, time.Now())
+}
+
+// resultCacheTTL returns the TTL of the cached result, the configured TTL
+// clamped to the earliest expiry of the trust material and signatures the
+// result relies on. A zero TTL never expires. It returns false if the result
+// must not be cached since its trust material already expired.
+func resultCacheTTL(result types.VerifyResult, ttl time.Duration, now time.Time) (time.Duration, bool) {
+	var validUntil *time.Time
+	for _, report := range result.VerifierReports {
+		validUntil = earliestValidUntil(validUntil, report)
+	}
+	if validUntil == nil {
+		return ttl, true
+	}
+	remaining := validUntil.Sub(now)
+	if remaining <= 0 {
+		return 0, false
+	}
+	if ttl == 0 || remaining < ttl {
+		return remaining, true
+	}
+	return ttl, true
+}
+
+func earliestValidUntil(validUntil *time.Time, report interface{}) *time.Time {
+	earlier := func(candidate *time.Time) {
+		if candidate != nil && (validUntil == nil || candidate.Before(*validUntil)) {
+			validUntil = candidate
+		}
+	}
+	switch r := report.(type) {
+	case verifier.VerifierResult:
+		earlier(r.ValidUntil)
+		for _, nested := range r.NestedResults {
+			validUntil = earliestValidUntil(validUntil, nested)
+		}
+	case types.NestedVerifierReport:
+		for _, verifierReport := range r.VerifierReports {
+			earlier(verifierReport.ValidUntil)
+		}
+		for _, nested := range r.NestedReports {
+			validUntil = earliestValidUntil(validUntil, nested)
+		}
+	}
+	return validUntil
+}
+
+// verifyKey verifies the subject of a request key for the admission request
+// with the request context and on the platform, if any, and returns the
+// verification response of the subject, or the error preventing it. Cached
+// results produced before since are bypassed.
+func (server *Server) verifyKey(ctx context.Context, key string, since time.Time, requestContext *types.RequestContext, platform *oci.Platform) externaldata.Item {
+	routineStartTime := time.Now()
+	returnItem := externaldata.Item{
+		Key: key,
+	}
+	requestKey, err := pkgUtils.ParseRequestKey(key)
+	if err != nil {
+		returnItem.Error = err.Error()
+		return returnItem
+	}
+	subjectReference, err := pkgUtils.ParseSubjectReference(requestKey.Subject)
+	if err != nil {
+		returnItem.Error = err.Error()
+		return returnItem
+	}
+	ctx = ctxUtils.SetContextWithNamespace(ctx, requestKey.Namespace)
+	requestContext = withRequestNamespace(requestContext, requestKey.Namespace)
+
+	if err := server.validateComponents(ctx, verifyComponents); err != nil {
+		logger.GetLogger(ctx, server.LogOption).Error(err)
+		returnItem.Error = err.Error()
+		return returnItem
+	}
+
+	if subjectReference.Digest.String() == "" {
+		logger.GetLogger(ctx, server.LogOption).Warn("Digest should be used instead of tagged reference. The resolved digest may not point to the same signed artifact, since tags are mutable.")
+	}
+	resolvedSubjectReference := subjectReference.Original
+	unlock := server.keyMutex.Lock(resolvedSubjectReference)
+	defer unlock()
+
+	logger.GetLogger(ctx, server.LogOption).Infof("verifying subject %v", resolvedSubjectReference)
+	var cached cachedVerifyResult
+	found := false
+	cacheHit := false
+	var cacheResponse string
+	var cacheKey string
+	cacheProvider := cache.GetCacheProvider()
+	if cacheProvider != nil {
+		// results are keyed by the config hash of the executor so that a
+		// reloaded config misses the results cached under the previous one
+		configHash, err := server.GetExecutor(ctx).ConfigHash()
+		if err != nil {
+			logger.GetLogger(ctx, server.LogOption).Warnf("unable to hash the executor config, not using the cache: %v", err)
+			cacheProvider = nil
+		} else {
+			cacheKey = fmt.Sprintf(cache.CacheKeyVerifyHandler, configHash, resolvedSubjectReference)
+			if requestHash := requestContext.Hash(); requestHash != "" && server.GetExecutor(ctx).UsesRequestContext(ctx) {
+				// the policy may depend on the request context
+				cacheKey = fmt.Sprintf("%s_%s", cacheKey, requestHash)
 			}
-			verificationResponse := fromVerifyResult(ctx, result, server.GetExecutor(ctx).PolicyEnforcer.GetPolicyType(ctx))
-			returnItem.Value = verificationResponse
-			if res, err := json.MarshalIndent(verificationResponse, "", "  "); err == nil {
-				logger.GetLogger(ctx, server.LogOption).Infof("verification response for subject %s: \n%s", resolvedSubjectReference, string(res))
+			if platform != nil {
+				// the platform selects the manifest verified for indexes
+				cacheKey = fmt.Sprintf("%s_%s/%s/%s:%s", cacheKey, platform.OS, platform.Architecture, platform.Variant, platform.OSVersion)
 			}
-			logger.GetLogger(ctx, server.LogOption).Debugf("verification: execution time for image %s: %dms", resolvedSubjectReference, time.Since(routineStartTime).Milliseconds())
-		}(utils.SanitizeString(key), ctx)
+			cacheResponse, found = cacheProvider.Get(ctx, cacheKey)
+		}
 	}
-	wg.Wait()
-	elapsedTime := time.Since(startTime).Milliseconds()
-	logger.GetLogger(ctx, server.LogOption).Debugf("verification: execution time for request: %dms", elapsedTime)
-	metrics.ReportVerificationRequest(ctx, elapsedTime)
-	return sendResponse(&results, "", w, http.StatusOK, false)
+	if found && cacheResponse != "" {
+		if err := json.Unmarshal([]byte(cacheResponse), &cached); err != nil {
+			err = errors.ErrorCodeDataDecodingFailure.WithError(err).WithDetail(fmt.Sprintf("unable to unmarshal cache entry for subject %v", resolvedSubjectReference))
+			logger.GetLogger(ctx, server.LogOption).Warn(err)
+		} else if !since.IsZero() && cached.VerifiedAt.Before(since) {
+			logger.GetLogger(ctx, server.LogOption).Infof("bypassing cache entry for subject %v verified at %v before %v", resolvedSubjectReference, cached.VerifiedAt, since)
+		} else {
+			cacheHit = true
+			logger.GetLogger(ctx, server.LogOption).Debugf("cache hit for subject %v", resolvedSubjectReference)
+		}
+	}
+	result := cached.VerifyResult
+	if !cacheHit {
+		verifyParameters := executor.VerifyParameters{
+			Subject:        resolvedSubjectReference,
+			Since:          since,
+			RequestContext: requestContext,
+			Platform:       platform,
+		}
+		verifiedAt := time.Now()
+		if result, err = server.GetExecutor(ctx).VerifySubject(ctx, verifyParameters); err != nil {
+			returnItem.Error = errors.ErrorCodeExecutorFailure.WithError(err).WithComponentType(errors.Executor).Error()
+			return returnItem
+		}
+
+		if cacheProvider != nil {
+			logger.GetLogger(ctx, server.LogOption).Debugf("cache miss for subject %v", resolvedSubjectReference)
+			if ttl, ok := resultCacheTTL(result, server.CacheTTL, time.Now()); !ok {
+				logger.GetLogger(ctx, server.LogOption).Infof("not caching the result of subject %v relying on expired trust material", resolvedSubjectReference)
+			} else if !cacheProvider.SetWithTTL(ctx, cacheKey, cachedVerifyResult{VerifyResult: result, VerifiedAt: verifiedAt}, ttl) {
+				logger.GetLogger(ctx, server.LogOption).Warnf("unable to insert cache entry for subject %v", resolvedSubjectReference)
+			}
+		}
+	}
+	verificationResponse := fromVerifyResult(ctx, result, server.GetExecutor(ctx).PolicyEnforcer.GetPolicyType(ctx))
+	returnItem.Value = verificationResponse
+	if res, err := json.MarshalIndent(verificationResponse, "", "  "); err == nil {
+		logger.GetLogger(ctx, server.LogOption).Infof("verification response for subject %s: \n%s", resolvedSubjectReference, string(res))
+	}
+	logger.GetLogger(ctx, server.LogOption).Debugf("verification: execution time for image %s: %dms", resolvedSubjectReference, time.Since(routineStartTime).Milliseconds())
+	return returnItem
+}
+
+// clientAuthenticated returns true if the client of the request presented a
+// certificate verified against the client CA of the server. Only such
+// clients, e.g. Gatekeeper, are trusted to describe the admission request.
+func clientAuthenticated(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
+
+// withRequestNamespace returns the request context with the namespace of the
+// request key, which takes precedence over the namespace of the request
+// context. The given request context is not modified since it is shared by the
+// keys of a request.
+func withRequestNamespace(requestContext *types.RequestContext, namespace string) *types.RequestContext {
+	if namespace == "" {
+		return requestContext
+	}
+	merged := types.RequestContext{Namespace: namespace}
+	if requestContext != nil {
+		merged.Labels = requestContext.Labels
+		merged.User = requestContext.User
+		merged.Resource = requestContext.Resource
+	}
+	return &merged
 }
 
 func (server *Server) mutate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -286,6 +521,61 @@ func sendResponse(results *[]externaldata.Item, systemErr string, w http.Respons
 	return json.NewEncoder(w).Encode(response)
 }
 
+func sendBatchResponse(response *BatchVerifyResponse, w http.ResponseWriter, respCode int) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(respCode)
+	return json.NewEncoder(w).Encode(response)
+}
+
+// listVerifiers returns the configured verifiers and whether they are enabled.
+func (server *Server) listVerifiers(ctx context.Context, w http.ResponseWriter, _ *http.Request) error {
+	response := VerifiersResponse{Verifiers: []VerifierStatus{}}
+	for _, referenceVerifier := range server.GetExecutor(ctx).Verifiers {
+		response.Verifiers = append(response.Verifiers, VerifierStatus{
+			Name:    referenceVerifier.Name(),
+			Type:    referenceVerifier.Type(),
+			Enabled: verifier.IsEnabled(referenceVerifier.Name()),
+		})
+	}
+	return sendVerifiersResponse(&response, w, http.StatusOK)
+}
+
+// toggleVerifier enables or disables a configured verifier until the next
+// config reload. Disabled verifiers are skipped with a report note. The toggle
+// is global to the process and keyed by the verifier name only, so it applies
+// to the verifiers of that name in every namespace.
+func (server *Server) toggleVerifier(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	name := mux.Vars(r)["name"]
+	var request VerifierToggleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return sendVerifiersResponse(&VerifiersResponse{Error: fmt.Sprintf("unable to unmarshal request body: %v", err)}, w, http.StatusBadRequest)
+	}
+	defer r.Body.Close()
+	if request.Enabled == nil {
+		return sendVerifiersResponse(&VerifiersResponse{Error: "enabled must be set"}, w, http.StatusBadRequest)
+	}
+
+	for _, referenceVerifier := range server.GetExecutor(ctx).Verifiers {
+		if referenceVerifier.Name() != name {
+			continue
+		}
+		verifier.SetEnabled(name, *request.Enabled)
+		logger.GetLogger(ctx, server.LogOption).Infof("verifier %s enabled set to %v", utils.SanitizeString(name), *request.Enabled)
+		return sendVerifiersResponse(&VerifiersResponse{Verifiers: []VerifierStatus{{
+			Name:    name,
+			Type:    referenceVerifier.Type(),
+			Enabled: *request.Enabled,
+		}}}, w, http.StatusOK)
+	}
+	return sendVerifiersResponse(&VerifiersResponse{Error: fmt.Sprintf("verifier %s is not configured", utils.SanitizeString(name))}, w, http.StatusNotFound)
+}
+
+func sendVerifiersResponse(response *VerifiersResponse, w http.ResponseWriter, respCode int) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(respCode)
+	return json.NewEncoder(w).Encode(response)
+}
+
 func processTimeout(h ContextHandler, duration time.Duration, isMutation bool) ContextHandler {
 	return func(_ context.Context, w http.ResponseWriter, r *http.Request) error {
 		ctx, cancel := context.WithTimeout(r.Context(), duration)
@@ -0,0 +1,86 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/ratify-project/ratify/pkg/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+// newRateLimiter returns the limiter of the verify requests configured for the
+// executor, or nil if the requests are not limited.
+func (server *Server) newRateLimiter() (*ratelimit.Limiter, error) {
+	executor := server.GetExecutor(server.Context)
+	if executor == nil || executor.Config == nil || executor.Config.RateLimit == nil {
+		return nil, nil
+	}
+	limiter, err := ratelimit.New(*executor.Config.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("limiting verify requests to %v per second, per client: %t", executor.Config.RateLimit.RequestsPerSecond, executor.Config.RateLimit.PerClient)
+	return limiter, nil
+}
+
+// rateLimit rejects the requests beyond the rate limit of the server with 429
+// Too Many Requests and a Retry-After header. Requests from the loopback
+// interface, such as those Ratify sends to itself, are never limited.
+func (server *Server) rateLimit(h ContextHandler, isBatch bool) ContextHandler {
+	if server.rateLimiter == nil {
+		return h
+	}
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		client := clientAddress(r)
+		if isLoopback(client) {
+			return h(ctx, w, r)
+		}
+		allowed, retryAfter := server.rateLimiter.Allow(client)
+		if allowed {
+			return h(ctx, w, r)
+		}
+		retryAfterSeconds := int(math.Ceil(retryAfter.Seconds()))
+		if retryAfterSeconds < 1 {
+			retryAfterSeconds = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+		message := fmt.Sprintf("rate limit exceeded, retry after %d seconds", retryAfterSeconds)
+		if isBatch {
+			return sendBatchResponse(&BatchVerifyResponse{Error: message}, w, http.StatusTooManyRequests)
+		}
+		return sendResponse(nil, message, w, http.StatusTooManyRequests, false)
+	}
+}
+
+// clientAddress returns the IP address of the client sending the request.
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func isLoopback(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsLoopback()
+}
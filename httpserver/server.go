@@ -32,6 +32,7 @@ import (
 	"github.com/ratify-project/ratify/config"
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/metrics"
+	"github.com/ratify-project/ratify/pkg/ratelimit"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -45,6 +46,9 @@ const (
 	idleTimeout                      = 90 * time.Second
 	defaultMutationReferrerStoreName = "oras"
 
+	DefaultMaxBatchSize           = 100
+	DefaultBatchVerifyConcurrency = 10
+
 	DefaultMetricsType = "prometheus"
 	DefaultMetricsPort = 8888
 	DefaultHealthPort  = ":9099"
@@ -63,8 +67,21 @@ type Server struct {
 	MetricsPort       int
 	CacheTTL          time.Duration
 	LogOption         logger.Option
+	// MaxBatchSize is the maximum number of references of a batch verification
+	// request. Defaults to DefaultMaxBatchSize.
+	MaxBatchSize int
+	// BatchVerifyConcurrency is the maximum number of references of a batch
+	// verified concurrently. Defaults to DefaultBatchVerifyConcurrency.
+	BatchVerifyConcurrency int
+	// AdminAddress is the loopback address, e.g. 127.0.0.1:9098, of the
+	// listener serving the admin API apart from the verification endpoints
+	// reachable by Gatekeeper. The admin API is disabled if empty.
+	AdminAddress string
+	// AdminRouter routes the requests of the admin API.
+	AdminRouter *mux.Router
 
-	keyMutex keyMutex
+	keyMutex    keyMutex
+	rateLimiter *ratelimit.Limiter
 }
 
 // keyMutex is a thread-safe map of mutexes, indexed by key.
@@ -98,6 +115,7 @@ func NewServer(context context.Context,
 		Address:           address,
 		GetExecutor:       getExecutor,
 		Router:            mux.NewRouter(),
+		AdminRouter:       mux.NewRouter(),
 		Context:           context,
 		CertDirectory:     certDir,
 		CaCertFile:        caCertFile,
@@ -108,6 +126,9 @@ func NewServer(context context.Context,
 		CacheTTL:          cacheTTL,
 		keyMutex:          keyMutex{},
 		LogOption:         logger.Option{ComponentType: logger.Server},
+
+		MaxBatchSize:           DefaultMaxBatchSize,
+		BatchVerifyConcurrency: DefaultBatchVerifyConcurrency,
 	}
 
 	return server, server.registerHandlers()
@@ -132,6 +153,14 @@ func (server *Server) Run(certRotatorReady chan struct{}) error {
 		return err
 	}
 
+	if server.AdminAddress != "" {
+		adminSvr, err := server.startAdminServer()
+		if err != nil {
+			return err
+		}
+		defer adminSvr.Close()
+	}
+
 	svr := &http.Server{
 		Addr:              server.Address,
 		Handler:           server.Router,
@@ -175,12 +204,75 @@ func (server *Server) register(method, path string, handler ContextHandler) {
 	})
 }
 
+func (server *Server) registerAdmin(method, path string, handler ContextHandler) {
+	server.AdminRouter.Methods(method).Path(path).Handler(&contextHandler{
+		context: server.Context,
+		handler: handler,
+	})
+}
+
+// startAdminServer serves the admin API on the admin address. The admin API
+// is unauthenticated, so it only listens on a loopback address reachable from
+// within the pod.
+func (server *Server) startAdminServer() (*http.Server, error) {
+	if err := validateAdminAddress(server.AdminAddress); err != nil {
+		return nil, err
+	}
+	lsnr, err := net.Listen("tcp", server.AdminAddress)
+	if err != nil {
+		return nil, err
+	}
+	svr := &http.Server{
+		Addr:              server.AdminAddress,
+		Handler:           server.AdminRouter,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	go func() {
+		logrus.Infof("starting admin server at %s", server.AdminAddress)
+		if err := svr.Serve(lsnr); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("failed to start admin server: %v", err)
+		}
+	}()
+	return svr, nil
+}
+
+// validateAdminAddress returns an error if the admin address is not a
+// loopback address.
+func validateAdminAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid admin address %s: %w", address, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("admin address %s must be a loopback address", address)
+}
+
 func (server *Server) registerHandlers() error {
+	rateLimiter, err := server.newRateLimiter()
+	if err != nil {
+		return err
+	}
+	server.rateLimiter = rateLimiter
+
 	verifyPath, err := url.JoinPath(ServerRootURL, "verify")
 	if err != nil {
 		return err
 	}
-	server.register(http.MethodPost, verifyPath, processTimeout(server.verify, server.GetExecutor(server.Context).GetVerifyRequestTimeout(), false))
+	server.register(http.MethodPost, verifyPath, server.rateLimit(processTimeout(server.verify, server.GetExecutor(server.Context).GetVerifyRequestTimeout(), false), false))
+
+	batchVerifyPath, err := url.JoinPath(ServerRootURL, "verify", "batch")
+	if err != nil {
+		return err
+	}
+	// the batch handler times out its verifications itself, scaled by the size
+	// of the batch, to report the references it verified in time
+	server.register(http.MethodPost, batchVerifyPath, server.rateLimit(server.batchVerify, true))
 
 	mutatePath, err := url.JoinPath(ServerRootURL, "mutate")
 	if err != nil {
@@ -188,9 +280,36 @@ func (server *Server) registerHandlers() error {
 	}
 	server.register(http.MethodPost, mutatePath, processTimeout(server.mutate, server.GetExecutor(server.Context).GetMutationRequestTimeout(), true))
 
+	return server.registerAdminHandlers()
+}
+
+func (server *Server) registerAdminHandlers() error {
+	if server.AdminRouter == nil {
+		server.AdminRouter = mux.NewRouter()
+	}
+	verifiersPath, err := url.JoinPath(ServerRootURL, "admin", "verifiers")
+	if err != nil {
+		return err
+	}
+	server.registerAdmin(http.MethodGet, verifiersPath, server.listVerifiers)
+	server.registerAdmin(http.MethodPut, verifiersPath+"/{name}", server.toggleVerifier)
 	return nil
 }
 
+func (server *Server) maxBatchSize() int {
+	if server.MaxBatchSize <= 0 {
+		return DefaultMaxBatchSize
+	}
+	return server.MaxBatchSize
+}
+
+func (server *Server) batchVerifyConcurrency() int {
+	if server.BatchVerifyConcurrency <= 0 {
+		return DefaultBatchVerifyConcurrency
+	}
+	return server.BatchVerifyConcurrency
+}
+
 type ServerAddrNotFoundError struct{}
 
 func (err ServerAddrNotFoundError) Error() string {
@@ -18,29 +18,42 @@ package httpserver
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
 
 	ratifyerrors "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/cache"
 	exconfig "github.com/ratify-project/ratify/pkg/executor/config"
 	"github.com/ratify-project/ratify/pkg/executor/core"
+	executorTypes "github.com/ratify-project/ratify/pkg/executor/types"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
+	_ "github.com/ratify-project/ratify/pkg/policyprovider/celpolicy"
+	pc "github.com/ratify-project/ratify/pkg/policyprovider/config"
 	config "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"
+	pf "github.com/ratify-project/ratify/pkg/policyprovider/factory"
 	"github.com/sirupsen/logrus"
 
+	"github.com/gorilla/mux"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
 	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/ratelimit"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
 	"github.com/ratify-project/ratify/pkg/verifier"
+	verifierTypes "github.com/ratify-project/ratify/pkg/verifier/types"
 )
 
 const testArtifactType string = "test-type1"
@@ -716,3 +729,827 @@ func TestServer_serverGracefulShutdown(t *testing.T) {
 	// wait some time to see shutdown logs
 	time.Sleep(5 * time.Second)
 }
+
+// TestServer_BatchVerify tests the per reference results and combined decision
+// of a batch verification request, and that the batch size is capped
+func TestServer_BatchVerify(t *testing.T) {
+	testCases := []struct {
+		name              string
+		references        []string
+		platform          *oci.Platform
+		maxBatchSize      int
+		expectedCode      int
+		expectedIsSuccess bool
+		expectedErrors    []bool
+	}{
+		{
+			name:              "all references verified",
+			references:        []string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2"},
+			expectedCode:      http.StatusOK,
+			expectedIsSuccess: true,
+			expectedErrors:    []bool{false, false},
+		},
+		{
+			name:           "invalid reference fails the batch",
+			references:     []string{"localhost:5000/net-monitor:v1", "&&"},
+			expectedCode:   http.StatusOK,
+			expectedErrors: []bool{false, true},
+		},
+		{
+			name:         "batch size exceeds the maximum",
+			references:   []string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2"},
+			maxBatchSize: 1,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "empty batch",
+			references:   []string{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:              "platform of the node",
+			references:        []string{"localhost:5000/net-monitor:v1"},
+			platform:          &oci.Platform{OS: "linux", Architecture: "amd64"},
+			expectedCode:      http.StatusOK,
+			expectedIsSuccess: true,
+			expectedErrors:    []bool{false},
+		},
+		{
+			name:         "platform without architecture",
+			references:   []string{"localhost:5000/net-monitor:v1"},
+			platform:     &oci.Platform{OS: "linux"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := json.Marshal(BatchVerifyRequest{References: tc.references, Platform: tc.platform})
+			if err != nil {
+				t.Fatalf("failed to encode request body: %v", err)
+			}
+			request := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify/batch", bytes.NewReader(body))
+			responseRecorder := httptest.NewRecorder()
+
+			configPolicy := config.PolicyEnforcer{
+				ArtifactTypePolicies: map[string]types.ArtifactTypeVerifyPolicy{
+					testArtifactType: types.AnyVerifySuccess,
+				}}
+			store := &mocks.TestStore{References: []ocispecs.ReferenceDescriptor{
+				{
+					ArtifactType: testArtifactType,
+				}},
+				ResolveMap: map[string]digest.Digest{
+					"v1": digest.FromString("v1"),
+					"v2": digest.FromString("v2"),
+				},
+			}
+			ver := &core.TestVerifier{
+				CanVerifyFunc: func(at string) bool {
+					return at == testArtifactType
+				},
+				VerifyResult: func(_ string) bool {
+					return true
+				},
+			}
+			ex := &core.Executor{
+				PolicyEnforcer: configPolicy,
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers:      []verifier.ReferenceVerifier{ver},
+				Config:         &exconfig.ExecutorConfig{},
+			}
+			server := &Server{
+				GetExecutor: func(context.Context) *core.Executor {
+					return ex
+				},
+				Context:                request.Context(),
+				MaxBatchSize:           tc.maxBatchSize,
+				BatchVerifyConcurrency: 1,
+
+				keyMutex: keyMutex{},
+			}
+			handler := contextHandler{
+				context: server.Context,
+				handler: server.batchVerify,
+			}
+
+			handler.ServeHTTP(responseRecorder, request)
+			if responseRecorder.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, responseRecorder.Code)
+			}
+			var respBody BatchVerifyResponse
+			if err := json.NewDecoder(responseRecorder.Result().Body).Decode(&respBody); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+			if tc.expectedCode != http.StatusOK {
+				if respBody.Error == "" {
+					t.Fatalf("expected error in response")
+				}
+				return
+			}
+			if respBody.IsSuccess != tc.expectedIsSuccess {
+				t.Fatalf("expected combined decision %v, got %v", tc.expectedIsSuccess, respBody.IsSuccess)
+			}
+			if len(respBody.Results) != len(tc.references) {
+				t.Fatalf("expected %d results, got %d", len(tc.references), len(respBody.Results))
+			}
+			for idx, result := range respBody.Results {
+				if result.Reference != tc.references[idx] {
+					t.Fatalf("expected result %d for reference %s, got %s", idx, tc.references[idx], result.Reference)
+				}
+				if (result.Error != "") != tc.expectedErrors[idx] {
+					t.Fatalf("expected error %v for reference %s, got %q", tc.expectedErrors[idx], result.Reference, result.Error)
+				}
+				if !tc.expectedErrors[idx] && (result.Result == nil || !result.Result.IsSuccess) {
+					t.Fatalf("expected successful result for reference %s, got %+v", result.Reference, result.Result)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_BatchVerify_RequestContext(t *testing.T) {
+	references := []string{"[prod]localhost:5000/net-monitor:v1", "[dev]localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v1"}
+	body, err := json.Marshal(BatchVerifyRequest{
+		References:     references,
+		RequestContext: &executorTypes.RequestContext{Namespace: "staging", Labels: map[string]string{"tier": "critical"}, User: "alice"},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode request body: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify/batch", bytes.NewReader(body))
+	// the client presented a certificate verified against the client CA
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	responseRecorder := httptest.NewRecorder()
+
+	// unsigned images are only admitted to critical workloads outside of prod
+	policy, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{PolicyPlugin: pc.PolicyPluginConfig{
+		"name":       types.CELPolicy,
+		"expression": `request.user == "alice" && !(request.namespace == "prod" && request.labels.tier == "critical")`,
+	}})
+	if err != nil {
+		t.Fatalf("failed to create the policy provider: %v", err)
+	}
+	store := &mocks.TestStore{References: []ocispecs.ReferenceDescriptor{
+		{
+			ArtifactType: testArtifactType,
+		}},
+		ResolveMap: map[string]digest.Digest{
+			"v1": digest.FromString("v1"),
+		},
+	}
+	ver := &core.TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return at == testArtifactType
+		},
+		VerifyResult: func(_ string) bool {
+			return false
+		},
+	}
+	ex := &core.Executor{
+		PolicyEnforcer: policy,
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers:      []verifier.ReferenceVerifier{ver},
+		Config:         &exconfig.ExecutorConfig{},
+	}
+	server := &Server{
+		GetExecutor: func(context.Context) *core.Executor {
+			return ex
+		},
+		Context:                request.Context(),
+		BatchVerifyConcurrency: 1,
+
+		keyMutex: keyMutex{},
+	}
+	handler := contextHandler{
+		context: server.Context,
+		handler: server.batchVerify,
+	}
+
+	handler.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, responseRecorder.Code)
+	}
+	var respBody BatchVerifyResponse
+	if err := json.NewDecoder(responseRecorder.Result().Body).Decode(&respBody); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	// the namespace prefix of a reference takes precedence over the namespace
+	// of the request context
+	expectedSuccess := []bool{false, true, true}
+	for idx, result := range respBody.Results {
+		if result.Error != "" || result.Result == nil {
+			t.Fatalf("expected result for reference %s, got error %q", result.Reference, result.Error)
+		}
+		if result.Result.IsSuccess != expectedSuccess[idx] {
+			t.Fatalf("expected success %v for reference %s, got %v", expectedSuccess[idx], result.Reference, result.Result.IsSuccess)
+		}
+	}
+
+	// the request context is refused from unauthenticated clients
+	request = httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify/batch", bytes.NewReader(body))
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d for an unauthenticated client, got %d", http.StatusForbidden, responseRecorder.Code)
+	}
+}
+
+// TestServer_BatchVerify_Timeout tests that the references of a batch not
+// verified within the batch timeout fail with a timeout error
+func TestServer_BatchVerify_Timeout(t *testing.T) {
+	references := []string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2"}
+	body, err := json.Marshal(BatchVerifyRequest{References: references})
+	if err != nil {
+		t.Fatalf("failed to encode request body: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify/batch", bytes.NewReader(body))
+	responseRecorder := httptest.NewRecorder()
+
+	store := &mocks.TestStore{References: []ocispecs.ReferenceDescriptor{
+		{
+			ArtifactType: testArtifactType,
+		}},
+		ResolveMap: map[string]digest.Digest{
+			"v1": digest.FromString("v1"),
+			"v2": digest.FromString("v2"),
+		},
+	}
+	ver := &core.TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return at == testArtifactType
+		},
+		VerifyResult: func(_ string) bool {
+			time.Sleep(500 * time.Millisecond)
+			return true
+		},
+	}
+	timeoutMilliseconds := 50
+	ex := &core.Executor{
+		PolicyEnforcer: config.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]types.ArtifactTypeVerifyPolicy{
+				testArtifactType: types.AnyVerifySuccess,
+			}},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers:      []verifier.ReferenceVerifier{ver},
+		Config:         &exconfig.ExecutorConfig{VerificationRequestTimeout: &timeoutMilliseconds},
+	}
+	server := &Server{
+		GetExecutor: func(context.Context) *core.Executor {
+			return ex
+		},
+		Context:                request.Context(),
+		BatchVerifyConcurrency: 1,
+
+		keyMutex: keyMutex{},
+	}
+	// the batch timeout scales with the rounds of concurrent verifications
+	if timeout := server.batchVerifyTimeout(len(references)); timeout != 100*time.Millisecond {
+		t.Fatalf("expected a batch timeout of %v, got %v", 100*time.Millisecond, timeout)
+	}
+	handler := contextHandler{
+		context: server.Context,
+		handler: server.batchVerify,
+	}
+
+	handler.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, responseRecorder.Code)
+	}
+	var respBody BatchVerifyResponse
+	if err := json.NewDecoder(responseRecorder.Result().Body).Decode(&respBody); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if respBody.IsSuccess {
+		t.Fatalf("expected the timed out batch to fail")
+	}
+	if len(respBody.Results) != len(references) {
+		t.Fatalf("expected %d results, got %d", len(references), len(respBody.Results))
+	}
+	for idx, result := range respBody.Results {
+		if result.Reference != references[idx] {
+			t.Fatalf("expected result %d for reference %s, got %s", idx, references[idx], result.Reference)
+		}
+		if !strings.Contains(result.Error, "timed out") {
+			t.Fatalf("expected a timeout error for reference %s, got %q", result.Reference, result.Error)
+		}
+	}
+}
+
+func TestWithRequestNamespace(t *testing.T) {
+	requestContext := &executorTypes.RequestContext{Namespace: "staging", Labels: map[string]string{"tier": "critical"}, User: "alice"}
+	if merged := withRequestNamespace(requestContext, ""); merged != requestContext {
+		t.Fatalf("expected the request context to be kept without a key namespace, got %+v", merged)
+	}
+	merged := withRequestNamespace(requestContext, "prod")
+	if merged.Namespace != "prod" || merged.Labels["tier"] != "critical" || merged.User != "alice" {
+		t.Fatalf("expected the key namespace to override the request context namespace, got %+v", merged)
+	}
+	if requestContext.Namespace != "staging" {
+		t.Fatalf("expected the shared request context not to be modified, got %+v", requestContext)
+	}
+	if merged := withRequestNamespace(nil, "prod"); merged.Namespace != "prod" {
+		t.Fatalf("expected a request context with the key namespace, got %+v", merged)
+	}
+}
+
+func TestServer_Verify_RateLimited(t *testing.T) {
+	store := &mocks.TestStore{References: []ocispecs.ReferenceDescriptor{
+		{
+			ArtifactType: testArtifactType,
+		}},
+		ResolveMap: map[string]digest.Digest{
+			"v1": digest.FromString("v1"),
+		},
+	}
+	ver := &core.TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return at == testArtifactType
+		},
+		VerifyResult: func(_ string) bool {
+			return true
+		},
+	}
+	ex := &core.Executor{
+		PolicyEnforcer: config.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]types.ArtifactTypeVerifyPolicy{
+				testArtifactType: types.AnyVerifySuccess,
+			}},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers:      []verifier.ReferenceVerifier{ver},
+		Config: &exconfig.ExecutorConfig{
+			RateLimit: &ratelimit.Config{RequestsPerSecond: 0.01, Burst: 1, PerClient: true},
+		},
+	}
+	server := &Server{
+		GetExecutor: func(context.Context) *core.Executor {
+			return ex
+		},
+		Context:                context.Background(),
+		BatchVerifyConcurrency: 1,
+
+		keyMutex: keyMutex{},
+	}
+	rateLimiter, err := server.newRateLimiter()
+	if err != nil {
+		t.Fatalf("failed to create rate limiter: %v", err)
+	}
+	server.rateLimiter = rateLimiter
+	verifyHandler := contextHandler{
+		context: server.Context,
+		handler: server.rateLimit(processTimeout(server.verify, ex.GetVerifyRequestTimeout(), false), false),
+	}
+	batchHandler := contextHandler{
+		context: server.Context,
+		handler: server.rateLimit(server.batchVerify, true),
+	}
+
+	sendVerify := func(remoteAddr string) *httptest.ResponseRecorder {
+		body := new(bytes.Buffer)
+		if err := json.NewEncoder(body).Encode(externaldata.NewProviderRequest([]string{testImageNameTagged})); err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+		request := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify", body)
+		request.RemoteAddr = remoteAddr
+		responseRecorder := httptest.NewRecorder()
+		verifyHandler.ServeHTTP(responseRecorder, request)
+		return responseRecorder
+	}
+
+	if resp := sendVerify("10.0.0.1:1234"); resp.Code != http.StatusOK {
+		t.Fatalf("expected the first request to succeed, got status %d", resp.Code)
+	}
+	resp := sendVerify("10.0.0.1:1235")
+	if resp.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the request beyond the rate to get status %d, got %d", http.StatusTooManyRequests, resp.Code)
+	}
+	if resp.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected a Retry-After header on the rejected request")
+	}
+	if resp := sendVerify("10.0.0.2:1234"); resp.Code != http.StatusOK {
+		t.Fatalf("expected the request of another client to succeed, got status %d", resp.Code)
+	}
+	for i := 0; i < 3; i++ {
+		if resp := sendVerify("127.0.0.1:1234"); resp.Code != http.StatusOK {
+			t.Fatalf("expected the loopback request to not be limited, got status %d", resp.Code)
+		}
+	}
+
+	body, err := json.Marshal(BatchVerifyRequest{References: []string{testImageNameTagged}})
+	if err != nil {
+		t.Fatalf("failed to encode request body: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify/batch", bytes.NewReader(body))
+	request.RemoteAddr = "10.0.0.1:1236"
+	batchResp := httptest.NewRecorder()
+	batchHandler.ServeHTTP(batchResp, request)
+	if batchResp.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the batch request beyond the rate to get status %d, got %d", http.StatusTooManyRequests, batchResp.Code)
+	}
+	var respBody BatchVerifyResponse
+	if err := json.NewDecoder(batchResp.Result().Body).Decode(&respBody); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if respBody.Error == "" {
+		t.Fatalf("expected error in the batch response")
+	}
+}
+
+// testCacheProvider is an in-memory cache provider storing entries
+// synchronously.
+type testCacheProvider struct {
+	mu       sync.Mutex
+	entries  map[string]string
+	disabled bool
+}
+
+func (c *testCacheProvider) Get(_ context.Context, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		return "", false
+	}
+	value, ok := c.entries[key]
+	return value, ok
+}
+
+func (c *testCacheProvider) Set(ctx context.Context, key string, value interface{}) bool {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+func (c *testCacheProvider) SetWithTTL(_ context.Context, key string, value interface{}, _ time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		return false
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	c.entries[key] = string(bytes)
+	return true
+}
+
+func (c *testCacheProvider) Delete(_ context.Context, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return true
+}
+
+type testCacheFactory struct {
+	provider *testCacheProvider
+}
+
+func (f *testCacheFactory) Create(_ context.Context, _ string, _ int) (cache.CacheProvider, error) {
+	return f.provider, nil
+}
+
+func TestServer_Verify_Since(t *testing.T) {
+	cacheProvider := &testCacheProvider{entries: map[string]string{}}
+	cache.Register("httpserver-test", &testCacheFactory{provider: cacheProvider})
+	if _, err := cache.NewCacheProvider(context.Background(), "httpserver-test", "", 0); err != nil {
+		t.Fatalf("failed to create cache provider: %v", err)
+	}
+	// the cache provider is global, disable it for the other tests
+	defer func() {
+		cacheProvider.mu.Lock()
+		cacheProvider.disabled = true
+		cacheProvider.mu.Unlock()
+	}()
+
+	verifierCalls := 0
+	var mu sync.Mutex
+	ex := &core.Executor{
+		PolicyEnforcer: config.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]types.ArtifactTypeVerifyPolicy{
+				testArtifactType: types.AnyVerifySuccess,
+			}},
+		ReferrerStores: []referrerstore.ReferrerStore{&mocks.TestStore{
+			References: []ocispecs.ReferenceDescriptor{{ArtifactType: testArtifactType}},
+			ResolveMap: map[string]digest.Digest{"v1": digest.FromString("v1")},
+		}},
+		Verifiers: []verifier.ReferenceVerifier{&core.TestVerifier{
+			CanVerifyFunc: func(at string) bool {
+				return at == testArtifactType
+			},
+			VerifyResult: func(_ string) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				verifierCalls++
+				return true
+			},
+		}},
+		Config: &exconfig.ExecutorConfig{},
+	}
+	configHash, err := ex.ConfigHash()
+	if err != nil {
+		t.Fatalf("failed to hash the executor config: %v", err)
+	}
+	cacheKey := fmt.Sprintf(cache.CacheKeyVerifyHandler, configHash, testImageNameTagged)
+	staleVerifiedAt := time.Now().Add(-time.Hour)
+	if !cacheProvider.Set(context.Background(), cacheKey, cachedVerifyResult{VerifyResult: executorTypes.VerifyResult{IsSuccess: false}, VerifiedAt: staleVerifiedAt}) {
+		t.Fatalf("failed to seed the cache")
+	}
+
+	verify := func(query string) externaldata.ProviderResponse {
+		body := new(bytes.Buffer)
+		if err := json.NewEncoder(body).Encode(externaldata.NewProviderRequest([]string{testImageNameTagged})); err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+		request := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify"+query, bytes.NewReader(body.Bytes()))
+		responseRecorder := httptest.NewRecorder()
+		server := &Server{
+			GetExecutor: func(context.Context) *core.Executor {
+				return ex
+			},
+			Context:  request.Context(),
+			CacheTTL: time.Minute,
+			keyMutex: keyMutex{},
+		}
+		handler := contextHandler{
+			context: server.Context,
+			handler: processTimeout(server.verify, server.GetExecutor(nil).GetVerifyRequestTimeout(), false),
+		}
+		handler.ServeHTTP(responseRecorder, request)
+		var respBody externaldata.ProviderResponse
+		if err := json.NewDecoder(responseRecorder.Result().Body).Decode(&respBody); err != nil {
+			t.Fatalf("failed to decode response body: %v", err)
+		}
+		return respBody
+	}
+	isSuccess := func(respBody externaldata.ProviderResponse) bool {
+		if len(respBody.Response.Items) != 1 {
+			t.Fatalf("expected 1 item, got %+v", respBody.Response)
+		}
+		return respBody.Response.Items[0].Value.(map[string]interface{})["isSuccess"].(bool)
+	}
+
+	// the cached result is reused without since
+	if isSuccess(verify("")) || verifierCalls != 0 {
+		t.Fatalf("expected the cached result to be reused, got %d verifier calls", verifierCalls)
+	}
+
+	// the cached result verified before since is bypassed and refreshed
+	if !isSuccess(verify("?since=30m")) || verifierCalls != 1 {
+		t.Fatalf("expected the subject to be re-verified, got %d verifier calls", verifierCalls)
+	}
+	cacheResponse, _ := cacheProvider.Get(context.Background(), cacheKey)
+	var cached cachedVerifyResult
+	if err := json.Unmarshal([]byte(cacheResponse), &cached); err != nil {
+		t.Fatalf("failed to decode cache entry: %v", err)
+	}
+	if !cached.IsSuccess || !cached.VerifiedAt.After(staleVerifiedAt) {
+		t.Fatalf("expected the cache entry to be refreshed, got %+v", cached)
+	}
+
+	// the refreshed result is verified after since
+	if !isSuccess(verify("?since="+staleVerifiedAt.Format(time.RFC3339))) || verifierCalls != 1 {
+		t.Fatalf("expected the refreshed result to be reused, got %d verifier calls", verifierCalls)
+	}
+
+	if respBody := verify("?since=yesterday"); respBody.Response.SystemError == "" {
+		t.Fatalf("expected an error for an invalid since")
+	}
+}
+
+func TestServer_Verify_ConfigReload(t *testing.T) {
+	cacheProvider := &testCacheProvider{entries: map[string]string{}}
+	cache.Register("httpserver-reload-test", &testCacheFactory{provider: cacheProvider})
+	if _, err := cache.NewCacheProvider(context.Background(), "httpserver-reload-test", "", 0); err != nil {
+		t.Fatalf("failed to create cache provider: %v", err)
+	}
+	// the cache provider is global, disable it for the other tests
+	defer func() {
+		cacheProvider.mu.Lock()
+		cacheProvider.disabled = true
+		cacheProvider.mu.Unlock()
+	}()
+
+	verifierCalls := 0
+	var mu sync.Mutex
+	newVerifier := func(configHash string) verifier.ReferenceVerifier {
+		testVerifier := &core.TestVerifier{
+			CanVerifyFunc: func(at string) bool {
+				return at == testArtifactType
+			},
+			VerifyResult: func(_ string) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				verifierCalls++
+				return true
+			},
+		}
+		verifier.SetConfigHash(testVerifier, configHash)
+		t.Cleanup(func() { verifier.DeleteConfigHash(testVerifier) })
+		return testVerifier
+	}
+	ex := &core.Executor{
+		PolicyEnforcer: config.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]types.ArtifactTypeVerifyPolicy{
+				testArtifactType: types.AnyVerifySuccess,
+			}},
+		ReferrerStores: []referrerstore.ReferrerStore{&mocks.TestStore{
+			References: []ocispecs.ReferenceDescriptor{{ArtifactType: testArtifactType}},
+			ResolveMap: map[string]digest.Digest{"v1": digest.FromString("v1")},
+		}},
+		Verifiers: []verifier.ReferenceVerifier{newVerifier("config-v1")},
+		Config:    &exconfig.ExecutorConfig{},
+	}
+	server := &Server{
+		GetExecutor: func(context.Context) *core.Executor {
+			return ex
+		},
+		Context:  context.Background(),
+		CacheTTL: time.Minute,
+		keyMutex: keyMutex{},
+	}
+	verify := func(expectedCalls int) {
+		t.Helper()
+		item := server.verifyKey(context.Background(), testImageNameTagged, time.Time{}, nil, nil)
+		if item.Error != "" {
+			t.Fatalf("expected no error, got %s", item.Error)
+		}
+		if verifierCalls != expectedCalls {
+			t.Fatalf("expected %d verifier calls, got %d", expectedCalls, verifierCalls)
+		}
+	}
+
+	verify(1)
+	verify(1)
+	// a hot-reloaded verifier config misses the cached result
+	ex.Verifiers = []verifier.ReferenceVerifier{newVerifier("config-v2")}
+	verify(2)
+	verify(2)
+	// reloading the previous config hits its cached result again
+	ex.Verifiers = []verifier.ReferenceVerifier{newVerifier("config-v1")}
+	verify(2)
+}
+
+func TestServer_ToggleVerifier(t *testing.T) {
+	testVerifier := &core.TestVerifier{}
+	defer verifier.SetEnabled(testVerifier.Name(), true)
+	ex := &core.Executor{
+		Verifiers: []verifier.ReferenceVerifier{testVerifier},
+		Config:    &exconfig.ExecutorConfig{},
+	}
+	server := &Server{
+		GetExecutor: func(context.Context) *core.Executor {
+			return ex
+		},
+		Router:      mux.NewRouter(),
+		AdminRouter: mux.NewRouter(),
+		Context:     context.Background(),
+	}
+	if err := server.registerHandlers(); err != nil {
+		t.Fatalf("failed to register handlers: %v", err)
+	}
+
+	// the admin API is not served with the verification endpoints
+	request := httptest.NewRequest(http.MethodPut, "/ratify/gatekeeper/v1/admin/verifiers/"+testVerifier.Name(), bytes.NewReader([]byte(`{"enabled": false}`)))
+	responseRecorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Code != http.StatusNotFound || !verifier.IsEnabled(testVerifier.Name()) {
+		t.Fatalf("expected the admin API not to be routed by the verification router, got status %d", responseRecorder.Code)
+	}
+
+	serve := func(method, path, body string) (int, VerifiersResponse) {
+		request := httptest.NewRequest(method, "/ratify/gatekeeper/v1/admin/verifiers"+path, bytes.NewReader([]byte(body)))
+		responseRecorder := httptest.NewRecorder()
+		server.AdminRouter.ServeHTTP(responseRecorder, request)
+		var response VerifiersResponse
+		if err := json.NewDecoder(responseRecorder.Result().Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response body: %v", err)
+		}
+		return responseRecorder.Code, response
+	}
+	isEnabled := func() bool {
+		code, response := serve(http.MethodGet, "", "")
+		if code != http.StatusOK || len(response.Verifiers) != 1 || response.Verifiers[0].Name != testVerifier.Name() {
+			t.Fatalf("unexpected verifiers response %d %+v", code, response)
+		}
+		return response.Verifiers[0].Enabled
+	}
+
+	if !isEnabled() {
+		t.Fatalf("expected the verifier to be enabled by default")
+	}
+	if code, _ := serve(http.MethodPut, "/"+testVerifier.Name(), `{"enabled": false}`); code != http.StatusOK {
+		t.Fatalf("expected status %d disabling the verifier, got %d", http.StatusOK, code)
+	}
+	if isEnabled() || verifier.IsEnabled(testVerifier.Name()) {
+		t.Fatalf("expected the verifier to be disabled")
+	}
+	if code, _ := serve(http.MethodPut, "/"+testVerifier.Name(), `{"enabled": true}`); code != http.StatusOK {
+		t.Fatalf("expected status %d enabling the verifier, got %d", http.StatusOK, code)
+	}
+	if !isEnabled() {
+		t.Fatalf("expected the verifier to be enabled again")
+	}
+
+	if code, _ := serve(http.MethodPut, "/unknown", `{"enabled": false}`); code != http.StatusNotFound {
+		t.Fatalf("expected status %d for an unknown verifier, got %d", http.StatusNotFound, code)
+	}
+	if code, _ := serve(http.MethodPut, "/"+testVerifier.Name(), `{}`); code != http.StatusBadRequest {
+		t.Fatalf("expected status %d without enabled, got %d", http.StatusBadRequest, code)
+	}
+}
+
+func TestResultCacheTTL(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		validUntil := now.Add(d)
+		return &validUntil
+	}
+	tests := []struct {
+		name          string
+		result        executorTypes.VerifyResult
+		ttl           time.Duration
+		expectTTL     time.Duration
+		expectCaching bool
+	}{
+		{
+			name:          "no expiry keeps the configured TTL",
+			result:        executorTypes.VerifyResult{VerifierReports: []interface{}{verifier.VerifierResult{IsSuccess: true}}},
+			ttl:           time.Minute,
+			expectTTL:     time.Minute,
+			expectCaching: true,
+		},
+		{
+			name:          "later expiry keeps the configured TTL",
+			result:        executorTypes.VerifyResult{VerifierReports: []interface{}{verifier.VerifierResult{ValidUntil: at(time.Hour)}}},
+			ttl:           time.Minute,
+			expectTTL:     time.Minute,
+			expectCaching: true,
+		},
+		{
+			name: "TTL is clamped to the earliest expiry",
+			result: executorTypes.VerifyResult{VerifierReports: []interface{}{
+				verifier.VerifierResult{ValidUntil: at(time.Hour)},
+				verifier.VerifierResult{ValidUntil: at(time.Hour), NestedResults: []verifier.VerifierResult{{ValidUntil: at(30 * time.Second)}}},
+			}},
+			ttl:           time.Minute,
+			expectTTL:     30 * time.Second,
+			expectCaching: true,
+		},
+		{
+			name: "TTL is clamped to the earliest expiry of nested reports",
+			result: executorTypes.VerifyResult{VerifierReports: []interface{}{
+				executorTypes.NestedVerifierReport{
+					VerifierReports: []verifierTypes.VerifierResult{{ValidUntil: at(time.Hour)}},
+					NestedReports: []executorTypes.NestedVerifierReport{
+						{VerifierReports: []verifierTypes.VerifierResult{{ValidUntil: at(10 * time.Second)}}},
+					},
+				},
+			}},
+			ttl:           time.Minute,
+			expectTTL:     10 * time.Second,
+			expectCaching: true,
+		},
+		{
+			name:          "expiry clamps a TTL that never expires",
+			result:        executorTypes.VerifyResult{VerifierReports: []interface{}{verifier.VerifierResult{ValidUntil: at(time.Hour)}}},
+			expectTTL:     time.Hour,
+			expectCaching: true,
+		},
+		{
+			name:   "expired trust material is not cached",
+			result: executorTypes.VerifyResult{VerifierReports: []interface{}{verifier.VerifierResult{ValidUntil: at(-time.Second)}}},
+			ttl:    time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, ok := resultCacheTTL(tt.result, tt.ttl, now)
+			if ok != tt.expectCaching {
+				t.Fatalf("expected caching %v, got %v", tt.expectCaching, ok)
+			}
+			if ok && ttl != tt.expectTTL {
+				t.Fatalf("expected TTL %v, got %v", tt.expectTTL, ttl)
+			}
+		})
+	}
+}
+
+func TestValidateAdminAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{address: "127.0.0.1:9098"},
+		{address: "[::1]:9098"},
+		{address: "localhost:9098"},
+		{address: ":9098", wantErr: true},
+		{address: "0.0.0.0:9098", wantErr: true},
+		{address: "10.0.0.1:9098", wantErr: true},
+		{address: "127.0.0.1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if err := validateAdminAddress(tt.address); (err != nil) != tt.wantErr {
+				t.Fatalf("validateAdminAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ratify-project/ratify/config"
+)
+
+// kubernetesAuthProviders are the auth providers of the referrer stores that
+// read credentials from the Kubernetes API server.
+var kubernetesAuthProviders = map[string]bool{
+	"k8Secrets": true,
+}
+
+// StandaloneOptions configures a server running outside of Kubernetes, e.g. in
+// CI.
+type StandaloneOptions struct {
+	Address        string
+	ConfigFilePath string
+	CertDirectory  string
+	CaCertFile     string
+	CacheTTL       time.Duration
+	MetricsEnabled bool
+	MetricsType    string
+	MetricsPort    int
+	// AdminAddress is the loopback address of the admin API, which is
+	// disabled if empty.
+	AdminAddress string
+	// MaxBatchSize is the maximum number of references of a batch
+	// verification request. Defaults to DefaultMaxBatchSize.
+	MaxBatchSize int
+	// BatchVerifyConcurrency is the maximum number of references of a batch
+	// verified concurrently. Defaults to DefaultBatchVerifyConcurrency.
+	BatchVerifyConcurrency int
+}
+
+// NewStandaloneServer creates a server verifying subjects with the executor
+// loaded from the config file. Unlike the server started along with the CRD
+// manager, it does not depend on Kubernetes: no kube client is initialized and
+// the config is reloaded from the file on change.
+func NewStandaloneServer(ctx context.Context, opts StandaloneOptions) (*Server, error) {
+	cf, err := config.Load(opts.ConfigFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := validateStandaloneConfig(cf); err != nil {
+		return nil, err
+	}
+	getExecutor, err := config.GetExecutorAndWatchForUpdate(opts.ConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+	server, err := NewServer(ctx, opts.Address, getExecutor, opts.CertDirectory, opts.CaCertFile, opts.CacheTTL, opts.MetricsEnabled, opts.MetricsType, opts.MetricsPort)
+	if err != nil {
+		return nil, err
+	}
+	server.AdminAddress = opts.AdminAddress
+	if opts.MaxBatchSize > 0 {
+		server.MaxBatchSize = opts.MaxBatchSize
+	}
+	if opts.BatchVerifyConcurrency > 0 {
+		server.BatchVerifyConcurrency = opts.BatchVerifyConcurrency
+	}
+	return server, nil
+}
+
+// RunStandalone runs the server. The TLS certificates, if any, are read from
+// the cert directory right away instead of waiting for the cert rotator of the
+// CRD manager.
+func (server *Server) RunStandalone() error {
+	certsReady := make(chan struct{})
+	close(certsReady)
+	return server.Run(certsReady)
+}
+
+// validateStandaloneConfig returns an error if the config requires a
+// Kubernetes API server, which is not available in standalone mode.
+func validateStandaloneConfig(cf config.Config) error {
+	for _, store := range cf.StoresConfig.Stores {
+		authProvider, ok := store["authProvider"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := authProvider["name"].(string); kubernetesAuthProviders[name] {
+			return fmt.Errorf("store %v uses the %s auth provider which is not supported in standalone mode", store["name"], name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"      // register ORAS referrer store
+	_ "github.com/ratify-project/ratify/pkg/verifier/helmprovenance" // register helm provenance verifier
+)
+
+// newStandaloneRegistry returns a registry serving the net-monitor:v1 image
+// without referrers.
+func newStandaloneRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+	configBlob := []byte(`{"architecture":"amd64","os":"linux"}`)
+	manifest, err := json.Marshal(oci.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: oci.MediaTypeImageManifest,
+		Config: oci.Descriptor{
+			MediaType: oci.MediaTypeImageConfig,
+			Digest:    digest.FromBytes(configBlob),
+			Size:      int64(len(configBlob)),
+		},
+		Layers: []oci.Descriptor{},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode manifest: %v", err)
+	}
+	manifestDigest := digest.FromBytes(manifest)
+	referrers, err := json.Marshal(oci.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: oci.MediaTypeImageIndex,
+		Manifests: []oci.Descriptor{},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode referrers: %v", err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/v2/net-monitor/manifests/v1" || r.URL.Path == "/v2/net-monitor/manifests/"+manifestDigest.String():
+			w.Header().Set("Content-Type", oci.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+			w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(manifest)
+			}
+		case r.URL.Path == "/v2/net-monitor/referrers/"+manifestDigest.String():
+			w.Header().Set("Content-Type", oci.MediaTypeImageIndex)
+			_, _ = w.Write(referrers)
+		case r.URL.Path == "/v2/net-monitor/blobs/"+digest.FromBytes(configBlob).String():
+			w.Header().Set("Content-Type", oci.MediaTypeImageConfig)
+			_, _ = w.Write(configBlob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func writeStandaloneConfig(t *testing.T, content string) string {
+	t.Helper()
+	configFilePath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFilePath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return configFilePath
+}
+
+// TestNewStandaloneServer_Verify tests that the standalone server verifies a
+// subject via HTTP with the executor loaded from the config file
+func TestNewStandaloneServer_Verify(t *testing.T) {
+	registry := newStandaloneRegistry(t)
+	defer registry.Close()
+	configFilePath := writeStandaloneConfig(t, `{
+		"store": {"version": "1.0.0", "plugins": [{"name": "oras", "useHttp": true}]},
+		"policy": {"version": "1.0.0", "plugin": {"name": "celpolicy", "expression": "size(reports) == 0 && subject.digest != \"\""}},
+		"verifier": {"version": "1.0.0", "plugins": [{"name": "helmprovenance", "keyManagementProviders": ["helm-keys"]}]}
+	}`)
+
+	server, err := NewStandaloneServer(context.Background(), StandaloneOptions{
+		Address:        "localhost:0",
+		ConfigFilePath: configFilePath,
+	})
+	if err != nil {
+		t.Fatalf("failed to create standalone server: %v", err)
+	}
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	subject := strings.TrimPrefix(registry.URL, "http://") + "/net-monitor:v1"
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(externaldata.NewProviderRequest([]string{subject})); err != nil {
+		t.Fatalf("failed to encode request body: %v", err)
+	}
+	resp, err := http.Post(ts.URL+"/ratify/gatekeeper/v1/verify", "application/json", body)
+	if err != nil {
+		t.Fatalf("failed to send verify request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var providerResponse externaldata.ProviderResponse
+	if err := json.NewDecoder(resp.Body).Decode(&providerResponse); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if len(providerResponse.Response.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(providerResponse.Response.Items))
+	}
+	item := providerResponse.Response.Items[0]
+	if item.Error != "" {
+		t.Fatalf("expected no error, got %s", item.Error)
+	}
+	result, ok := item.Value.(map[string]interface{})
+	if !ok || result["isSuccess"] != true {
+		t.Fatalf("expected successful verification of subject %s, got %+v", subject, item.Value)
+	}
+}
+
+func TestNewStandaloneServer_KubernetesAuthProvider(t *testing.T) {
+	configFilePath := writeStandaloneConfig(t, `{
+		"store": {"version": "1.0.0", "plugins": [{"name": "oras", "authProvider": {"name": "k8Secrets"}}]},
+		"policy": {"version": "1.0.0", "plugin": {"name": "celpolicy", "expression": "true"}},
+		"verifier": {"version": "1.0.0", "plugins": [{"name": "helmprovenance", "keyManagementProviders": ["helm-keys"]}]}
+	}`)
+
+	_, err := NewStandaloneServer(context.Background(), StandaloneOptions{
+		Address:        "localhost:0",
+		ConfigFilePath: configFilePath,
+	})
+	if err == nil || !strings.Contains(err.Error(), "not supported in standalone mode") {
+		t.Fatalf("expected the k8Secrets auth provider to be rejected, got %v", err)
+	}
+}
+
+// TestNewStandaloneServer_BatchLimits tests that the standalone server caps
+// batch verification requests at the configured batch size
+func TestNewStandaloneServer_BatchLimits(t *testing.T) {
+	configFilePath := writeStandaloneConfig(t, `{
+		"store": {"version": "1.0.0", "plugins": [{"name": "oras", "useHttp": true}]},
+		"policy": {"version": "1.0.0", "plugin": {"name": "celpolicy", "expression": "true"}},
+		"verifier": {"version": "1.0.0", "plugins": [{"name": "helmprovenance", "keyManagementProviders": ["helm-keys"]}]}
+	}`)
+
+	server, err := NewStandaloneServer(context.Background(), StandaloneOptions{
+		Address:                "localhost:0",
+		ConfigFilePath:         configFilePath,
+		MaxBatchSize:           1,
+		BatchVerifyConcurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create standalone server: %v", err)
+	}
+	if server.MaxBatchSize != 1 || server.BatchVerifyConcurrency != 2 {
+		t.Fatalf("expected the configured batch limits, got size %d and concurrency %d", server.MaxBatchSize, server.BatchVerifyConcurrency)
+	}
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	body, err := json.Marshal(BatchVerifyRequest{References: []string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2"}})
+	if err != nil {
+		t.Fatalf("failed to encode request body: %v", err)
+	}
+	resp, err := http.Post(ts.URL+"/ratify/gatekeeper/v1/verify/batch", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to send batch verify request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d for a batch over the configured size, got %d", http.StatusRequestEntityTooLarge, resp.StatusCode)
+	}
+
+	server, err = NewStandaloneServer(context.Background(), StandaloneOptions{
+		Address:        "localhost:0",
+		ConfigFilePath: configFilePath,
+	})
+	if err != nil {
+		t.Fatalf("failed to create standalone server: %v", err)
+	}
+	if server.maxBatchSize() != DefaultMaxBatchSize || server.batchVerifyConcurrency() != DefaultBatchVerifyConcurrency {
+		t.Fatalf("expected the default batch limits, got size %d and concurrency %d", server.maxBatchSize(), server.batchVerifyConcurrency())
+	}
+}
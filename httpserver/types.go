@@ -22,6 +22,9 @@ import (
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/verifier/attestation"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 const (
@@ -31,6 +34,10 @@ const (
 	// evaluated by Ratify embedded OPA engine.
 	ResultVersionSupportingRego = "1.0.0"
 	ResultVersion1_1_0          = "1.1.0"
+
+	// sinceQueryParameter is the query parameter of the verification
+	// endpoints bypassing the cached results produced before its value.
+	sinceQueryParameter = "since"
 )
 
 type VerificationResponse struct {
@@ -39,6 +46,77 @@ type VerificationResponse struct {
 	TraceID         string        `json:"traceID,omitempty"`
 	Timestamp       string        `json:"timestamp,omitempty"`
 	VerifierReports []interface{} `json:"verifierReports,omitempty"`
+	Warnings        []string      `json:"warnings,omitempty"`
+	// Reason is the reason code of the decision, e.g. signature-invalid.
+	Reason string `json:"reason,omitempty"`
+	// Degraded is set when the subject was allowed without verification
+	// because the failure policy is open.
+	Degraded bool `json:"degraded,omitempty"`
+	// Annotations are set by decision post-processors.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Score is the score of the subject computed by a scoring policy.
+	Score *types.ScoreReport `json:"score,omitempty"`
+	// ExceededGuard is the depth or breadth guard of the executor that stopped
+	// the verification.
+	ExceededGuard string `json:"exceededGuard,omitempty"`
+	// Attestation is the signed in-toto statement of the verification result.
+	Attestation *attestation.Envelope `json:"attestation,omitempty"`
+}
+
+// cachedVerifyResult is the verification result of a subject stored in the
+// cache along with the time of its verification.
+type cachedVerifyResult struct {
+	types.VerifyResult
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// BatchVerifyRequest is the request body of the batch verification endpoint.
+type BatchVerifyRequest struct {
+	// References are the images to verify, optionally prefixed with a namespace.
+	References []string `json:"references"`
+	// RequestContext is the context of the admission request the images are
+	// verified for, exposed to the policy. The namespace prefix of a
+	// reference takes precedence over the namespace of the request context.
+	// It is only accepted from clients authenticated with a client
+	// certificate since the policy may relax verification based on it.
+	RequestContext *types.RequestContext `json:"requestContext,omitempty"`
+	// Platform is the platform of the node the images are pulled on, selecting
+	// the manifest verified for image index references in place of the
+	// platform of the executor config.
+	Platform *oci.Platform `json:"platform,omitempty"`
+}
+
+// BatchVerifyItem is the verification outcome of a single image of a batch.
+type BatchVerifyItem struct {
+	Reference string                `json:"reference"`
+	Result    *VerificationResponse `json:"result,omitempty"`
+	Error     string                `json:"error,omitempty"`
+}
+
+// BatchVerifyResponse is the response body of the batch verification endpoint.
+type BatchVerifyResponse struct {
+	// IsSuccess is true only if every image of the batch passed verification.
+	IsSuccess bool              `json:"isSuccess"`
+	Results   []BatchVerifyItem `json:"results,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// VerifierStatus is the runtime state of a verifier exposed by the admin API.
+type VerifierStatus struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+// VerifierToggleRequest is the request body enabling or disabling a verifier.
+type VerifierToggleRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// VerifiersResponse is the response body of the verifier admin endpoints.
+type VerifiersResponse struct {
+	Verifiers []VerifierStatus `json:"verifiers,omitempty"`
+	Error     string           `json:"error,omitempty"`
 }
 
 func fromVerifyResult(ctx context.Context, res types.VerifyResult, policyType string) VerificationResponse {
@@ -52,5 +130,12 @@ func fromVerifyResult(ctx context.Context, res types.VerifyResult, policyType st
 		Timestamp:       time.Now().Format(time.RFC3339Nano),
 		TraceID:         logger.GetTraceID(ctx),
 		VerifierReports: res.VerifierReports,
+		Warnings:        res.Warnings,
+		Reason:          string(res.Reason),
+		Degraded:        res.Degraded,
+		Annotations:     res.Annotations,
+		Score:           res.Score,
+		ExceededGuard:   res.ExceededGuard,
+		Attestation:     res.Attestation,
 	}
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/google/uuid"
 	re "github.com/ratify-project/ratify/errors"
 	icontext "github.com/ratify-project/ratify/internal/context"
+	"github.com/ratify-project/ratify/internal/redact"
 	"github.com/sirupsen/logrus"
 )
 
@@ -83,6 +84,32 @@ const (
 	traceIDHeaderName = "traceIDHeaderName"
 )
 
+func init() {
+	logrus.AddHook(redactHook{})
+}
+
+// redactHook masks credentials in the message and fields of log entries.
+type redactHook struct{}
+
+// Levels returns all levels since credentials must not be logged at any level.
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire masks the credentials of the entry before it is formatted.
+func (redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = redact.String(entry.Message)
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = redact.String(v)
+		case error:
+			entry.Data[key] = redact.Error(v)
+		}
+	}
+	return nil
+}
+
 // InitLogConfig initializes log configuration for the server.
 func InitLogConfig(config Config) error {
 	initTraceIDHeaders(config.RequestHeaders)
@@ -16,13 +16,18 @@ limitations under the License.
 package logger
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	logstash "github.com/bshuster-repo/logrus-logstash-hook"
+	"github.com/ratify-project/ratify/internal/redact"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -201,3 +206,24 @@ func TestInitLogConfig(t *testing.T) {
 		t.Fatalf("expected no error, but got %v", err)
 	}
 }
+
+func TestRedactHook(t *testing.T) {
+	const refreshToken = "test-acr-refresh-token-value"
+	redact.Register(refreshToken)
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	defer logrus.SetOutput(os.Stderr)
+
+	ctx := InitContext(context.Background(), &http.Request{Header: testHeader})
+	GetLogger(ctx, Option{ComponentType: AuthProvider}).Infof("exchanged refresh token %s", refreshToken)
+	logrus.WithError(fmt.Errorf("request with refresh_token=%s denied", refreshToken)).Warn("token exchange failed")
+
+	output := buf.String()
+	if strings.Contains(output, refreshToken) {
+		t.Fatalf("expected refresh token to be redacted from logs, got %s", output)
+	}
+	if strings.Count(output, redact.Mask) != 2 {
+		t.Fatalf("expected both log entries to be redacted, got %s", output)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package redact masks credentials in log messages, errors and serialized
+// reports.
+package redact
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// Mask replaces the redacted values.
+	Mask = "<redacted>"
+
+	// maxSecrets bounds the number of registered values. The oldest are
+	// forgotten first since short-lived tokens are replaced on refresh.
+	maxSecrets = 256
+	// minSecretLength is the length below which registered values are ignored
+	// so that short common strings are not masked.
+	minSecretLength = 8
+
+	sensitiveKeys = `(?:password|passwd|secret|client_secret|token|access_token|accesstoken|refresh_token|refreshtoken|identitytoken|registrytoken|assertion)`
+)
+
+var (
+	// authorizationPattern matches the credentials of authorization headers.
+	authorizationPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]{16,}=*`)
+	// keyValuePattern matches the values of sensitive keys in query strings,
+	// form bodies and key=value pairs.
+	keyValuePattern = regexp.MustCompile(`(?i)\b(` + sensitiveKeys + `=)[^&\s,;"]+`)
+	// jsonPattern matches the values of sensitive keys in JSON documents.
+	jsonPattern = regexp.MustCompile(`(?i)("` + sensitiveKeys + `"\s*:\s*")[^"]*`)
+	// jwtPattern matches JSON web tokens.
+	jwtPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+	mu      sync.RWMutex
+	secrets []string
+	next    int
+)
+
+// Register records sensitive values, such as the tokens obtained by auth
+// providers, to be masked wherever they appear.
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, value := range values {
+		if len(value) < minSecretLength || slices.Contains(secrets, value) {
+			continue
+		}
+		if len(secrets) < maxSecrets {
+			secrets = append(secrets, value)
+			continue
+		}
+		secrets[next] = value
+		next = (next + 1) % maxSecrets
+	}
+}
+
+// String masks the registered values and the known sensitive substrings, e.g.
+// bearer tokens and passwords, in s.
+func String(s string) string {
+	if s == "" {
+		return s
+	}
+	mu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	mu.RUnlock()
+	s = authorizationPattern.ReplaceAllString(s, "$1 "+Mask)
+	s = keyValuePattern.ReplaceAllString(s, "${1}"+Mask)
+	s = jsonPattern.ReplaceAllString(s, "${1}"+Mask)
+	return jwtPattern.ReplaceAllString(s, Mask)
+}
+
+// Error returns the message of err with sensitive substrings masked.
+func Error(err error) string {
+	if err == nil {
+		return ""
+	}
+	return String(err.Error())
+}
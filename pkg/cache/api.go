@@ -23,7 +23,7 @@ import (
 const (
 	CacheKeySubjectDescriptor string = "cache_ratify_subject_descriptor_%s"
 	CacheKeyListReferrers     string = "cache_ratify_list_referrers_%s"
-	CacheKeyVerifyHandler     string = "cache_ratify_verify_handler_%s"
+	CacheKeyVerifyHandler     string = "cache_ratify_verify_handler_%s_%s" // executor config hash, subject
 	CacheKeyOrasAuth          string = "cache_ratify_oras_auth_%s"
 
 	DefaultCacheType string = "ristretto"
@@ -17,7 +17,6 @@ package authprovider
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/url"
 	"os"
@@ -29,6 +28,8 @@ import (
 	"github.com/docker/cli/cli/config/types"
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/internal/redact"
+	commonutils "github.com/ratify-project/ratify/pkg/common/utils"
 )
 
 // This config represents the credentials that should be used
@@ -37,6 +38,9 @@ type AuthConfig struct {
 	Username      string
 	Password      string
 	IdentityToken string
+	// RegistryToken is a bearer token presented to the registry as is, e.g. an
+	// anonymous token scoped to a single repository.
+	RegistryToken string
 	Email         string
 	Provider      AuthProvider `json:"-"` // Provider is not serialized
 	ExpiresOn     time.Time
@@ -49,6 +53,18 @@ type AuthProvider interface {
 	Enabled(ctx context.Context) bool
 	// Provide returns AuthConfig for registry.
 	Provide(ctx context.Context, artifact string) (AuthConfig, error)
+	// Validate checks the credentials of the provider, e.g. by obtaining a
+	// token, so that misconfigurations fail fast. Providers without
+	// credentials to check return nil.
+	Validate(ctx context.Context) error
+}
+
+// Closer is implemented by auth providers running background work, e.g. a
+// token prefetch loop, that must be stopped once the store using the provider
+// is replaced or deleted.
+type Closer interface {
+	// Close stops the background work of the provider
+	Close()
 }
 
 type defaultProviderFactory struct{}
@@ -79,12 +95,7 @@ func (s *defaultProviderFactory) Create(authProviderConfig AuthProviderConfig) (
 	}
 
 	conf := defaultAuthProviderConf{}
-	authProviderConfigBytes, err := json.Marshal(authProviderConfig)
-	if err != nil {
-		return nil, re.ErrorCodeConfigInvalid.WithError(err).WithComponentType(re.AuthProvider)
-	}
-
-	if err := json.Unmarshal(authProviderConfigBytes, &conf); err != nil {
+	if err := commonutils.DecodeConfig(authProviderConfig, &conf); err != nil {
 		return nil, re.ErrorCodeConfigInvalid.NewError(re.AuthProvider, "", re.AuthProviderLink, err, "failed to parse auth provider configuration", re.HideStackTrace)
 	}
 
@@ -98,6 +109,12 @@ func (d *defaultAuthProvider) Enabled(_ context.Context) bool {
 	return true
 }
 
+// Validate is a no-op for defaultAuthProvider since the docker config file is
+// read on every Provide call.
+func (d *defaultAuthProvider) Validate(_ context.Context) error {
+	return nil
+}
+
 // Provide reads docker config file and returns corresponding credentials from file if exists
 func (d *defaultAuthProvider) Provide(ctx context.Context, artifact string) (AuthConfig, error) {
 	// load docker config file at default path if config file path not specified
@@ -140,6 +157,7 @@ func (d *defaultAuthProvider) Provide(ctx context.Context, artifact string) (Aut
 	if dockerAuthConfig == (types.AuthConfig{}) {
 		return AuthConfig{}, nil
 	}
+	redact.Register(dockerAuthConfig.Password, dockerAuthConfig.IdentityToken)
 	authConfig := AuthConfig{
 		Username:      dockerAuthConfig.Username,
 		Password:      dockerAuthConfig.Password,
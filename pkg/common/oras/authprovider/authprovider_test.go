@@ -55,6 +55,10 @@ func (ap *TestAuthProvider) Enabled(_ context.Context) bool {
 	return true
 }
 
+func (ap *TestAuthProvider) Validate(_ context.Context) error {
+	return nil
+}
+
 func (ap *TestAuthProvider) Provide(_ context.Context, _ string) (AuthConfig, error) {
 	return AuthConfig{
 		Username: "test",
@@ -28,6 +28,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/ecr/types"
 	"github.com/pkg/errors"
+	"github.com/ratify-project/ratify/internal/redact"
 	provider "github.com/ratify-project/ratify/pkg/common/oras/authprovider"
 	"github.com/ratify-project/ratify/pkg/utils/awsauth"
 	"github.com/sirupsen/logrus"
@@ -155,6 +156,12 @@ func (d *awsEcrBasicAuthProvider) Enabled(_ context.Context) bool {
 	return true
 }
 
+// Validate is a no-op for awsEcrBasicAuthProvider since the ECR token depends
+// on the region of the registry of the artifact.
+func (d *awsEcrBasicAuthProvider) Validate(_ context.Context) error {
+	return nil
+}
+
 // Provide returns the credentials for a specified artifact.
 // Uses AWS IRSA to retrieve creds from IRSA credential chain
 func (d *awsEcrBasicAuthProvider) Provide(ctx context.Context, artifact string) (provider.AuthConfig, error) {
@@ -169,6 +176,14 @@ func (d *awsEcrBasicAuthProvider) Provide(ctx context.Context, artifact string)
 		return provider.AuthConfig{}, errors.Wrapf(err, "could not get ECR registry from %s", artifact)
 	}
 
+	// ECR Public and other registries serving content anonymously do not
+	// take ECR credentials, their anonymous tokens are acquired through the
+	// auth challenge of the registry
+	if !awsauth.IsPrivateECRRegistry(registry) {
+		logrus.Debugf("registry %s is not a private ECR registry, using anonymous credentials", registry)
+		return provider.AuthConfig{}, nil
+	}
+
 	if !d.ecrAuthToken.exists(registry) {
 		logrus.Debugf("ecrAuthToken for %s does not exist", registry)
 		_, err = d.getEcrAuthToken(artifact)
@@ -195,6 +210,7 @@ func (d *awsEcrBasicAuthProvider) Provide(ctx context.Context, artifact string)
 		return provider.AuthConfig{}, errors.Wrapf(err, "could not retrieve ECR credentials for %s", artifact)
 	}
 
+	redact.Register(creds[1])
 	authConfig := provider.AuthConfig{
 		Username:  creds[0],
 		Password:  creds[1],
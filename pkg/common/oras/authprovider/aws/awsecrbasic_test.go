@@ -123,3 +123,20 @@ func TestAwsEcrBasicAuthProvider_GetAuthTokenWithoutRegion(t *testing.T) {
 		t.Fatalf("expected message: %s, instead got error: %s", expectedMessage, err.Error())
 	}
 }
+
+func TestAwsEcrBasicAuthProvider_ProvidesAnonymousForPublicRegistry(t *testing.T) {
+	authProvider := mockAuthProvider()
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_ROLE_ARN", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+
+	for _, artifact := range []string{testArtifactWithoutRegion, "ghcr.io/foo/foo:latest"} {
+		authConfig, err := authProvider.Provide(context.TODO(), artifact)
+		if err != nil {
+			t.Fatalf("expected anonymous credentials for %s without AWS credentials, got error: %+v", artifact, err)
+		}
+		if authConfig.Username != "" || authConfig.Password != "" {
+			t.Fatalf("expected anonymous credentials for %s, got user %s", artifact, authConfig.Username)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/metrics"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/containerregistry/runtime/2019-08-15-preview/containerregistry"
+)
+
+// refreshTokenExchanger exchanges an AAD access token for an ACR refresh token.
+type refreshTokenExchanger interface {
+	ExchangeRefreshToken(ctx context.Context, registryHost, tenantID, aadAccessToken string) (string, error)
+}
+
+// acrRefreshTokenExchanger calls the token exchange endpoint of the registry.
+// The authentication client of each registry is cached so that connections
+// are reused across exchanges.
+type acrRefreshTokenExchanger struct {
+	// httpClient sends the exchange request. The default client is used if nil.
+	httpClient *http.Client
+	// authClientFactory creates the authentication client of the registry with
+	// the server URL. newAuthClient is used if nil.
+	authClientFactory func(serverURL string) containerregistry.RefreshTokensClient
+
+	mu          sync.Mutex
+	authClients map[string]containerregistry.RefreshTokensClient
+}
+
+// ExchangeRefreshToken returns the ACR refresh token issued by the registry
+// for the AAD access token.
+func (e *acrRefreshTokenExchanger) ExchangeRefreshToken(ctx context.Context, registryHost, tenantID, aadAccessToken string) (string, error) {
+	// add protocol to generate complete URI
+	serverURL := "https://" + registryHost
+
+	// exchange AAD token for registry refresh token
+	refreshTokenClient := e.authClient(serverURL)
+	startTime := time.Now()
+	rt, err := refreshTokenClient.GetFromExchange(ctx, "access_token", registryHost, tenantID, "", aadAccessToken)
+	if err != nil {
+		// drop the client so that the next exchange starts over with a new
+		// connection
+		e.invalidate(serverURL)
+		if rt.Response.Response != nil {
+			return "", &httpStatusError{StatusCode: rt.StatusCode, Err: err}
+		}
+		return "", err
+	}
+	metrics.ReportACRExchangeDuration(ctx, time.Since(startTime).Milliseconds(), registryHost)
+	if rt.RefreshToken == nil || *rt.RefreshToken == "" {
+		return "", fmt.Errorf("registry %s returned an empty refresh token", registryHost)
+	}
+	return *rt.RefreshToken, nil
+}
+
+// authClient returns the cached authentication client of the registry,
+// creating it on first use.
+func (e *acrRefreshTokenExchanger) authClient(serverURL string) containerregistry.RefreshTokensClient {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if client, ok := e.authClients[serverURL]; ok {
+		return client
+	}
+	factory := e.authClientFactory
+	if factory == nil {
+		factory = e.newAuthClient
+	}
+	client := factory(serverURL)
+	if e.authClients == nil {
+		e.authClients = make(map[string]containerregistry.RefreshTokensClient)
+	}
+	e.authClients[serverURL] = client
+	return client
+}
+
+// invalidate removes the cached authentication client of the registry.
+func (e *acrRefreshTokenExchanger) invalidate(serverURL string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.authClients, serverURL)
+}
+
+func (e *acrRefreshTokenExchanger) newAuthClient(serverURL string) containerregistry.RefreshTokensClient {
+	client := containerregistry.NewRefreshTokensClient(serverURL)
+	if e.httpClient != nil {
+		client.Sender = e.httpClient
+	}
+	return client
+}
@@ -0,0 +1,143 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/containerregistry/runtime/2019-08-15-preview/containerregistry"
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
+)
+
+func TestACRRefreshTokenExchanger(t *testing.T) {
+	testCases := []struct {
+		name          string
+		response      string
+		status        int
+		expectedToken string
+		expectErr     bool
+	}{
+		{
+			name:          "refresh token is returned",
+			response:      `{"refresh_token":"acr_refresh_token"}`,
+			status:        http.StatusOK,
+			expectedToken: "acr_refresh_token",
+		},
+		{
+			name:      "exchange is denied",
+			response:  `{"errors":[{"code":"UNAUTHORIZED"}]}`,
+			status:    http.StatusUnauthorized,
+			expectErr: true,
+		},
+		{
+			name:      "empty refresh token",
+			response:  `{}`,
+			status:    http.StatusOK,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/oauth2/exchange" {
+					t.Errorf("unexpected exchange path %s", r.URL.Path)
+				}
+				if err := r.ParseForm(); err != nil {
+					t.Errorf("failed to parse exchange form: %v", err)
+				}
+				if r.PostForm.Get("access_token") != "aad_token" || r.PostForm.Get("tenant") != "test_tenant" {
+					t.Errorf("unexpected exchange form %v", r.PostForm)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			exchanger := &acrRefreshTokenExchanger{httpClient: server.Client()}
+			registryHost := strings.TrimPrefix(server.URL, "https://")
+			refreshToken, err := exchanger.ExchangeRefreshToken(context.Background(), registryHost, "test_tenant", "aad_token")
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if refreshToken != tc.expectedToken {
+				t.Fatalf("expected refresh token %s, got %s", tc.expectedToken, refreshToken)
+			}
+		})
+	}
+}
+
+// Verifies that the authentication client of a registry is created once and
+// reused across Provide calls, and recreated after a failed exchange
+func TestACRRefreshTokenExchanger_ReusesAuthClient(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if fail.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"refresh_token":"acr_refresh_token"}`))
+	}))
+	defer server.Close()
+
+	created := map[string]int{}
+	exchanger := &acrRefreshTokenExchanger{
+		authClientFactory: func(serverURL string) containerregistry.RefreshTokensClient {
+			created[serverURL]++
+			client := containerregistry.NewRefreshTokensClient(server.URL)
+			client.Sender = server.Client()
+			return client
+		},
+	}
+	authProvider := azureWIAuthProvider{
+		aadToken: confidential.AuthResult{
+			AccessToken: "aad_token",
+			ExpiresOn:   time.Now().Add(time.Hour),
+		},
+		tenantID:  "test_tenant",
+		clientID:  "test_client",
+		exchanger: exchanger,
+	}
+
+	for _, artifact := range []string{"first.azurecr.io/app:v1", "first.azurecr.io/app:v2", "second.azurecr.io/app:v1", "first.azurecr.io/other:v1"} {
+		if _, err := authProvider.Provide(context.Background(), artifact); err != nil {
+			t.Fatalf("failed to provide credentials for %s: %v", artifact, err)
+		}
+	}
+	if created["https://first.azurecr.io"] != 1 || created["https://second.azurecr.io"] != 1 {
+		t.Fatalf("expected one auth client per registry, got %v", created)
+	}
+
+	fail.Store(true)
+	if _, err := authProvider.Provide(context.Background(), "first.azurecr.io/app:v1"); err == nil {
+		t.Fatalf("expected exchange failure")
+	}
+	fail.Store(false)
+	if _, err := authProvider.Provide(context.Background(), "first.azurecr.io/app:v1"); err != nil {
+		t.Fatalf("failed to provide credentials: %v", err)
+	}
+	if created["https://first.azurecr.io"] != 2 {
+		t.Fatalf("expected the auth client to be recreated after a failed exchange, got %v", created)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ratify-project/ratify/internal/redact"
+	provider "github.com/ratify-project/ratify/pkg/common/oras/authprovider"
+	"oras.land/oras-go/v2/registry"
+)
+
+const (
+	// defaultAnonymousTokenExpiryDuration is shorter than the lifetime of ACR
+	// access tokens so that tokens are renewed before they expire.
+	defaultAnonymousTokenExpiryDuration = 5 * time.Minute
+	maxAnonymousTokenResponseBytes      = 1 << 20
+)
+
+// anonymousTokenClient acquires pull-scoped ACR access tokens without AAD
+// credentials from registries that allow anonymous pull.
+type anonymousTokenClient struct {
+	// httpClient sends the token request. http.DefaultClient is used if nil.
+	httpClient *http.Client
+}
+
+type anonymousTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// GetAnonymousToken returns an access token scoped to pull the repository.
+func (c anonymousTokenClient) GetAnonymousToken(ctx context.Context, registryHost, repository string) (string, error) {
+	query := url.Values{}
+	query.Set("service", registryHost)
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", repository))
+	tokenURL := url.URL{Scheme: "https", Host: registryHost, Path: "/oauth2/token", RawQuery: query.Encode()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAnonymousTokenResponseBytes))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &httpStatusError{StatusCode: resp.StatusCode, Err: fmt.Errorf("registry %s denied anonymous token request with status %d: %s", registryHost, resp.StatusCode, string(body))}
+	}
+	var tokenResponse anonymousTokenResponse
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to parse anonymous token response of registry %s: %w", registryHost, err)
+	}
+	if tokenResponse.AccessToken == "" {
+		return "", fmt.Errorf("registry %s returned an empty anonymous access token", registryHost)
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+// anonymousRegistrySet returns the set of registries configured for anonymous
+// pull.
+func anonymousRegistrySet(registries []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(registries))
+	for _, registryHost := range registries {
+		set[registryHost] = struct{}{}
+	}
+	return set
+}
+
+// provideAnonymous returns credentials holding an anonymous access token
+// scoped to pull the repository of the artifact.
+func provideAnonymous(ctx context.Context, client anonymousTokenClient, artifact string, authProvider provider.AuthProvider) (provider.AuthConfig, error) {
+	ref, err := registry.ParseReference(artifact)
+	if err != nil {
+		return provider.AuthConfig{}, err
+	}
+	token, err := client.GetAnonymousToken(ctx, ref.Registry, ref.Repository)
+	if err != nil {
+		return provider.AuthConfig{}, err
+	}
+	redact.Register(token)
+	return provider.AuthConfig{
+		RegistryToken: token,
+		Provider:      authProvider,
+		ExpiresOn:     time.Now().Add(defaultAnonymousTokenExpiryDuration),
+	}, nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
+)
+
+func newAnonymousTokenServer(t *testing.T, status int, response string) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth2/token" {
+			t.Errorf("unexpected token path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("anonymous token request must not carry credentials")
+		}
+		if scope := r.URL.Query().Get("scope"); scope != "repository:library/app:pull" {
+			t.Errorf("unexpected token scope %s", scope)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, strings.TrimPrefix(server.URL, "https://")
+}
+
+func TestAnonymousTokenClient_GetAnonymousToken(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		response      string
+		expectedToken string
+		expectErr     bool
+	}{
+		{
+			name:          "anonymous pull is allowed",
+			status:        http.StatusOK,
+			response:      `{"access_token":"anonymous_token"}`,
+			expectedToken: "anonymous_token",
+		},
+		{
+			name:      "anonymous pull is disabled",
+			status:    http.StatusUnauthorized,
+			response:  `{"errors":[{"code":"UNAUTHORIZED"}]}`,
+			expectErr: true,
+		},
+		{
+			name:      "empty access token",
+			status:    http.StatusOK,
+			response:  `{}`,
+			expectErr: true,
+		},
+		{
+			name:      "malformed response",
+			status:    http.StatusOK,
+			response:  `not json`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, registryHost := newAnonymousTokenServer(t, tc.status, tc.response)
+			client := anonymousTokenClient{httpClient: server.Client()}
+
+			token, err := client.GetAnonymousToken(context.Background(), registryHost, "library/app")
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if token != tc.expectedToken {
+				t.Fatalf("expected token %s, got %s", tc.expectedToken, token)
+			}
+		})
+	}
+}
+
+// Verifies that anonymous registries are served anonymous tokens without an
+// AAD token exchange
+func TestAzureWIProvide_AnonymousRegistry(t *testing.T) {
+	server, registryHost := newAnonymousTokenServer(t, http.StatusOK, `{"access_token":"anonymous_token"}`)
+	exchanger := &mockExchanger{refreshToken: "refresh_token"}
+	authProvider := azureWIAuthProvider{
+		aadToken: confidential.AuthResult{
+			AccessToken: "aad_token",
+			ExpiresOn:   time.Now().Add(time.Hour),
+		},
+		tenantID:            "test_tenant",
+		clientID:            "test_client",
+		exchanger:           exchanger,
+		anonymousRegistries: anonymousRegistrySet([]string{registryHost}),
+		anonymousClient:     anonymousTokenClient{httpClient: server.Client()},
+	}
+
+	authConfig, err := authProvider.Provide(context.Background(), registryHost+"/library/app:v1")
+	if err != nil {
+		t.Fatalf("failed to provide credentials: %v", err)
+	}
+	if authConfig.RegistryToken != "anonymous_token" || authConfig.Username != "" || authConfig.Password != "" {
+		t.Fatalf("expected anonymous registry token only, got %+v", authConfig)
+	}
+	if exchanger.aadToken != "" {
+		t.Fatalf("expected no AAD token exchange for anonymous registry")
+	}
+
+	if _, err := authProvider.Provide(context.Background(), "myregistry.azurecr.io/library/app:v1"); err != nil {
+		t.Fatalf("failed to provide credentials: %v", err)
+	}
+	if exchanger.aadToken != "aad_token" {
+		t.Fatalf("expected AAD token exchange for other registries")
+	}
+}
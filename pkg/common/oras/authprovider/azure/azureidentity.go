@@ -20,28 +20,65 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/internal/redact"
 	provider "github.com/ratify-project/ratify/pkg/common/oras/authprovider"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
-	"github.com/Azure/azure-sdk-for-go/services/preview/containerregistry/runtime/2019-08-15-preview/containerregistry"
 )
 
-type azureManagedIdentityProviderFactory struct{}
+type azureManagedIdentityProviderFactory struct {
+	// tokenClient acquires managed identity tokens. IMDS is used if nil.
+	tokenClient managedIdentityTokenClient
+	// exchanger exchanges AAD tokens for ACR refresh tokens. The registry
+	// exchange endpoint is used if nil.
+	exchanger refreshTokenExchanger
+	// anonymousClient acquires anonymous tokens for anonymous registries.
+	anonymousClient anonymousTokenClient
+}
 type azureManagedIdentityAuthProvider struct {
-	identityToken azcore.AccessToken
-	clientID      string
-	tenantID      string
+	identityToken       azcore.AccessToken
+	clientID            string
+	tenantID            string
+	tokenClient         managedIdentityTokenClient
+	exchanger           refreshTokenExchanger
+	anonymousRegistries map[string]struct{}
+	anonymousClient     anonymousTokenClient
+	registryIdentities  map[string]registryIdentity
+
+	mu sync.Mutex
+	// registryTokens holds the tokens of the registry identities by client ID.
+	registryTokens map[string]azcore.AccessToken
+}
+
+// managedIdentityTokenClient acquires AAD access tokens for the managed
+// identity with the given client ID.
+type managedIdentityTokenClient interface {
+	GetToken(ctx context.Context, clientID string) (azcore.AccessToken, error)
 }
 
+// imdsTokenClient acquires managed identity tokens from the Azure Instance
+// Metadata Service.
+type imdsTokenClient struct{}
+
 type azureManagedIdentityAuthProviderConf struct {
 	Name     string `json:"name"`
 	ClientID string `json:"clientID"`
+	// AnonymousRegistries are pulled from with anonymous tokens instead of
+	// AAD credentials.
+	AnonymousRegistries []string `json:"anonymousRegistries,omitempty"`
+	// RegistryIdentities maps a registry host to the managed identity used to
+	// authenticate to it. Other registries use the identity of ClientID.
+	RegistryIdentities map[string]registryIdentity `json:"registryIdentities,omitempty"`
+	// transportConfig configures the proxy and CA bundle of the requests sent
+	// to the registry to authenticate.
+	transportConfig
 }
 
 const (
@@ -76,19 +113,43 @@ func (s *azureManagedIdentityProviderFactory) Create(authProviderConfig provider
 			return nil, re.ErrorCodeEnvNotSet.WithDetail("AZURE_CLIENT_ID environment variable is empty").WithComponentType(re.AuthProvider)
 		}
 	}
+
+	if err := validateRegistryIdentities(conf.RegistryIdentities); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "invalid registry identities", re.HideStackTrace)
+	}
+
+	tokenClient := s.tokenClient
+	if tokenClient == nil {
+		tokenClient = imdsTokenClient{}
+	}
+	httpClient, err := conf.newHTTPClient()
 	if err != nil {
-		return nil, err
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "failed to configure the auth client transport", re.HideStackTrace)
+	}
+	exchanger := s.exchanger
+	if exchanger == nil {
+		exchanger = &acrRefreshTokenExchanger{httpClient: httpClient}
 	}
+	anonymousClient := s.anonymousClient
+	if anonymousClient.httpClient == nil {
+		anonymousClient.httpClient = httpClient
+	}
+
 	// retrieve an AAD Access token
-	token, err := getManagedIdentityToken(context.Background(), client)
+	token, err := tokenClient.GetToken(context.Background(), client)
 	if err != nil {
-		return nil, re.ErrorCodeAuthDenied.NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "", re.HideStackTrace)
+		return nil, classifyError(err).NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "", re.HideStackTrace)
 	}
 
 	return &azureManagedIdentityAuthProvider{
-		identityToken: token,
-		clientID:      client,
-		tenantID:      tenant,
+		identityToken:       token,
+		clientID:            client,
+		tenantID:            tenant,
+		tokenClient:         tokenClient,
+		exchanger:           exchanger,
+		anonymousRegistries: anonymousRegistrySet(conf.AnonymousRegistries),
+		anonymousClient:     anonymousClient,
+		registryIdentities:  conf.RegistryIdentities,
 	}, nil
 }
 
@@ -109,6 +170,12 @@ func (d *azureManagedIdentityAuthProvider) Enabled(_ context.Context) bool {
 	return true
 }
 
+// Validate is a no-op for azureManagedIdentityAuthProvider since the AAD token
+// is acquired when the provider is created.
+func (d *azureManagedIdentityAuthProvider) Validate(_ context.Context) error {
+	return nil
+}
+
 // Provide returns the credentials for a specified artifact.
 // Uses Managed Identity to retrieve an AAD access token which can be
 // exchanged for a valid ACR refresh token for login.
@@ -122,30 +189,30 @@ func (d *azureManagedIdentityAuthProvider) Provide(ctx context.Context, artifact
 		return provider.AuthConfig{}, err
 	}
 
-	// need to refresh AAD token if it's expired
-	if time.Now().Add(time.Minute * 5).After(d.identityToken.ExpiresOn) {
-		newToken, err := getManagedIdentityToken(ctx, d.clientID)
+	if _, ok := d.anonymousRegistries[artifactHostName]; ok {
+		authConfig, err := provideAnonymous(ctx, d.anonymousClient, artifact, d)
 		if err != nil {
-			return provider.AuthConfig{}, re.ErrorCodeAuthDenied.NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "could not refresh azure managed identity token", re.HideStackTrace)
+			return provider.AuthConfig{}, classifyError(err).NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "failed to get anonymous access token for container registry", re.HideStackTrace)
 		}
-		d.identityToken = newToken
-		logger.GetLogger(ctx, logOpt).Info("successfully refreshed azure managed identity token")
+		return authConfig, nil
 	}
-	// add protocol to generate complete URI
-	serverURL := "https://" + artifactHostName
 
-	// create registry client and exchange AAD token for registry refresh token
-	refreshTokenClient := containerregistry.NewRefreshTokensClient(serverURL)
-	rt, err := refreshTokenClient.GetFromExchange(ctx, "access_token", artifactHostName, d.tenantID, "", d.identityToken.Token)
+	clientID, tenantID := resolveIdentity(d.registryIdentities, artifactHostName, d.clientID, d.tenantID)
+	identityToken, err := d.getToken(ctx, clientID)
+	if err != nil {
+		return provider.AuthConfig{}, classifyError(err).NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "could not refresh azure managed identity token", re.HideStackTrace)
+	}
+	refreshToken, err := d.exchanger.ExchangeRefreshToken(ctx, artifactHostName, tenantID, identityToken.Token)
 	if err != nil {
-		return provider.AuthConfig{}, re.ErrorCodeAuthDenied.NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "failed to get refresh token for container registry by azure managed identity token", re.HideStackTrace)
+		return provider.AuthConfig{}, classifyError(err).NewError(re.AuthProvider, "", re.AzureManagedIdentityLink, err, "failed to get refresh token for container registry by azure managed identity token", re.HideStackTrace)
 	}
 
-	expiresOn := getACRExpiryIfEarlier(d.identityToken.ExpiresOn)
+	redact.Register(identityToken.Token, refreshToken)
+	expiresOn := getACRExpiryIfEarlier(identityToken.ExpiresOn)
 
 	authConfig := provider.AuthConfig{
 		Username:  dockerTokenLoginUsernameGUID,
-		Password:  *rt.RefreshToken,
+		Password:  refreshToken,
 		Provider:  d,
 		ExpiresOn: expiresOn,
 	}
@@ -153,7 +220,37 @@ func (d *azureManagedIdentityAuthProvider) Provide(ctx context.Context, artifact
 	return authConfig, nil
 }
 
-func getManagedIdentityToken(ctx context.Context, clientID string) (azcore.AccessToken, error) {
+// getToken returns the token of the managed identity with the client ID,
+// refreshing it if it expires soon.
+func (d *azureManagedIdentityAuthProvider) getToken(ctx context.Context, clientID string) (azcore.AccessToken, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	token := d.identityToken
+	if clientID != d.clientID {
+		token = d.registryTokens[clientID]
+	}
+	// need to refresh AAD token if it's expired
+	if time.Now().Add(time.Minute * 5).Before(token.ExpiresOn) {
+		return token, nil
+	}
+	newToken, err := d.tokenClient.GetToken(ctx, clientID)
+	if err != nil {
+		return azcore.AccessToken{}, err
+	}
+	if clientID == d.clientID {
+		d.identityToken = newToken
+	} else {
+		if d.registryTokens == nil {
+			d.registryTokens = make(map[string]azcore.AccessToken)
+		}
+		d.registryTokens[clientID] = newToken
+	}
+	logger.GetLogger(ctx, logOpt).Infof("successfully refreshed azure managed identity token of client %s", clientID)
+	return newToken, nil
+}
+
+// GetToken returns an AAD access token for the container registry resource.
+func (imdsTokenClient) GetToken(ctx context.Context, clientID string) (azcore.AccessToken, error) {
 	id := azidentity.ClientID(clientID)
 	opts := azidentity.ManagedIdentityCredentialOptions{ID: id}
 	cred, err := azidentity.NewManagedIdentityCredential(&opts)
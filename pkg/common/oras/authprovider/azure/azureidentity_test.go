@@ -20,6 +20,7 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	ratifyerrors "github.com/ratify-project/ratify/errors"
@@ -89,3 +90,109 @@ func TestAzureMSIValidation_EnvironmentVariables_ExpectedResults(t *testing.T) {
 		t.Fatalf("create auth provider should have failed: expected err %s, but got err %s", expectedErr, err)
 	}
 }
+
+type mockIMDSClient struct {
+	token     azcore.AccessToken
+	err       error
+	clientIDs []string
+}
+
+func (c *mockIMDSClient) GetToken(_ context.Context, clientID string) (azcore.AccessToken, error) {
+	c.clientIDs = append(c.clientIDs, clientID)
+	return c.token, c.err
+}
+
+type mockExchanger struct {
+	refreshToken string
+	err          error
+	registry     string
+	tenantID     string
+	aadToken     string
+}
+
+func (e *mockExchanger) ExchangeRefreshToken(_ context.Context, registryHost, tenantID, aadAccessToken string) (string, error) {
+	e.registry = registryHost
+	e.tenantID = tenantID
+	e.aadToken = aadAccessToken
+	return e.refreshToken, e.err
+}
+
+// Verifies that the IMDS token of the configured client is exchanged for an
+// ACR refresh token
+func TestAzureMSIProvide_ExchangesIMDSToken(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "test_tenant")
+	t.Setenv("AZURE_CLIENT_ID", "")
+
+	imdsClient := &mockIMDSClient{token: azcore.AccessToken{Token: "imds_token", ExpiresOn: time.Now().Add(time.Hour)}}
+	exchanger := &mockExchanger{refreshToken: "refresh_token"}
+	factory := &azureManagedIdentityProviderFactory{tokenClient: imdsClient, exchanger: exchanger}
+
+	authProvider, err := factory.Create(map[string]interface{}{
+		"name":     "azureManagedIdentity",
+		"clientID": "test_client",
+	})
+	if err != nil {
+		t.Fatalf("failed to create auth provider: %v", err)
+	}
+
+	authConfig, err := authProvider.Provide(context.Background(), "myregistry.azurecr.io/app:v1")
+	if err != nil {
+		t.Fatalf("failed to provide credentials: %v", err)
+	}
+	if authConfig.Username != dockerTokenLoginUsernameGUID || authConfig.Password != "refresh_token" {
+		t.Fatalf("unexpected credentials %s:%s", authConfig.Username, authConfig.Password)
+	}
+	if len(imdsClient.clientIDs) != 1 || imdsClient.clientIDs[0] != "test_client" {
+		t.Fatalf("expected a single IMDS token request for test_client, got %v", imdsClient.clientIDs)
+	}
+	if exchanger.registry != "myregistry.azurecr.io" || exchanger.tenantID != "test_tenant" || exchanger.aadToken != "imds_token" {
+		t.Fatalf("unexpected exchange of token %s for registry %s in tenant %s", exchanger.aadToken, exchanger.registry, exchanger.tenantID)
+	}
+}
+
+// Verifies that an expiring IMDS token is refreshed before the exchange
+func TestAzureMSIProvide_RefreshesExpiringToken(t *testing.T) {
+	imdsClient := &mockIMDSClient{token: azcore.AccessToken{Token: "new_token", ExpiresOn: time.Now().Add(time.Hour)}}
+	exchanger := &mockExchanger{refreshToken: "refresh_token"}
+	authProvider := azureManagedIdentityAuthProvider{
+		identityToken: azcore.AccessToken{Token: "old_token", ExpiresOn: time.Now().Add(time.Minute)},
+		clientID:      "test_client",
+		tenantID:      "test_tenant",
+		tokenClient:   imdsClient,
+		exchanger:     exchanger,
+	}
+
+	if _, err := authProvider.Provide(context.Background(), "myregistry.azurecr.io/app:v1"); err != nil {
+		t.Fatalf("failed to provide credentials: %v", err)
+	}
+	if exchanger.aadToken != "new_token" {
+		t.Fatalf("expected refreshed token to be exchanged, got %s", exchanger.aadToken)
+	}
+}
+
+// Verifies that IMDS and exchange failures are surfaced
+func TestAzureMSI_Failures(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "test_tenant")
+	t.Setenv("AZURE_CLIENT_ID", "test_client")
+	authProviderConfig := map[string]interface{}{"name": "azureManagedIdentity"}
+
+	factory := &azureManagedIdentityProviderFactory{
+		tokenClient: &mockIMDSClient{err: errors.New("imds unavailable")},
+		exchanger:   &mockExchanger{},
+	}
+	if _, err := factory.Create(authProviderConfig); err == nil {
+		t.Fatal("expected create to fail when the IMDS token cannot be acquired")
+	}
+
+	factory = &azureManagedIdentityProviderFactory{
+		tokenClient: &mockIMDSClient{token: azcore.AccessToken{Token: "imds_token", ExpiresOn: time.Now().Add(time.Hour)}},
+		exchanger:   &mockExchanger{err: errors.New("exchange denied")},
+	}
+	authProvider, err := factory.Create(authProviderConfig)
+	if err != nil {
+		t.Fatalf("failed to create auth provider: %v", err)
+	}
+	if _, err := authProvider.Provide(context.Background(), "myregistry.azurecr.io/app:v1"); err == nil {
+		t.Fatal("expected provide to fail when the token exchange fails")
+	}
+}
@@ -18,31 +18,89 @@ package azure
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
+	"slices"
+	"sort"
+	"sync"
 	"time"
 
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/internal/redact"
 	provider "github.com/ratify-project/ratify/pkg/common/oras/authprovider"
-	"github.com/ratify-project/ratify/pkg/metrics"
 	"github.com/ratify-project/ratify/pkg/utils/azureauth"
 
-	"github.com/Azure/azure-sdk-for-go/services/preview/containerregistry/runtime/2019-08-15-preview/containerregistry"
 	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
 )
 
-type AzureWIProviderFactory struct{} //nolint:revive // ignore linter to have unique type name
+type AzureWIProviderFactory struct { //nolint:revive // ignore linter to have unique type name
+	// tokenClient acquires AAD tokens with the federated workload identity
+	// token. The Azure AD Workload Identity credentials are used if nil.
+	tokenClient aadTokenClient
+	// exchanger exchanges AAD tokens for ACR refresh tokens. The registry
+	// exchange endpoint is used if nil.
+	exchanger refreshTokenExchanger
+	// clock drives the token prefetch loop. The system clock is used if nil.
+	clock clock
+}
 type azureWIAuthProvider struct {
-	aadToken confidential.AuthResult
-	tenantID string
-	clientID string
+	aadToken            confidential.AuthResult
+	tenantID            string
+	clientID            string
+	resource            string
+	tokenClient         aadTokenClient
+	exchanger           refreshTokenExchanger
+	anonymousRegistries map[string]struct{}
+	anonymousClient     anonymousTokenClient
+	registryIdentities  map[string]registryIdentity
+
+	mu sync.Mutex
+	// registryTokens holds the tokens of the registry identities by tenant
+	// and client ID.
+	registryTokens map[registryIdentity]confidential.AuthResult
+	// prefetchInterval is the period of the token prefetch loop, which is
+	// disabled if zero.
+	prefetchInterval time.Duration
+	// stopPrefetch stops the token prefetch loop, which closes prefetchDone
+	// once it returned.
+	stopPrefetch context.CancelFunc
+	prefetchDone chan struct{}
+	clock        clock
+	// acrTokens holds the credentials of the registries by host. They are
+	// only cached if the token prefetch loop is enabled.
+	acrTokens map[string]provider.AuthConfig
 }
 
 type azureWIAuthProviderConf struct {
 	Name     string `json:"name"`
 	ClientID string `json:"clientID,omitempty"`
+	// AnonymousRegistries are pulled from with anonymous tokens instead of
+	// AAD credentials.
+	AnonymousRegistries []string `json:"anonymousRegistries,omitempty"`
+	// RegistryIdentities maps a registry host to the workload identity used
+	// to authenticate to it. Other registries use the identity of ClientID.
+	RegistryIdentities map[string]registryIdentity `json:"registryIdentities,omitempty"`
+	// transportConfig configures the proxy and CA bundle of the requests sent
+	// to the registry to authenticate.
+	transportConfig
+	// Resource overrides the resource, or scope, the AAD token is acquired
+	// for, e.g. a custom ACR audience. Defaults to the ACR resource.
+	Resource string `json:"resource,omitempty"`
+	// PrefetchInterval, e.g. 1m, enables a background loop refreshing the AAD
+	// and ACR tokens before they expire. Disabled by default.
+	PrefetchInterval string `json:"prefetchInterval,omitempty"`
+}
+
+// aadTokenClient acquires AAD access tokens for the workload identity.
+type aadTokenClient interface {
+	GetAADAccessToken(ctx context.Context, tenantID, clientID, resource string) (confidential.AuthResult, error)
 }
 
+// workloadIdentityTokenClient acquires AAD access tokens with the federated
+// token injected by the Azure AD Workload Identity webhook.
+type workloadIdentityTokenClient struct{}
+
 const (
 	azureWIAuthProviderName string = "azureWorkloadIdentity"
 )
@@ -77,17 +135,63 @@ func (s *AzureWIProviderFactory) Create(authProviderConfig provider.AuthProvider
 		}
 	}
 
+	if err := validateRegistryIdentities(conf.RegistryIdentities); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, "invalid registry identities", re.HideStackTrace)
+	}
+
+	var prefetchInterval time.Duration
+	if conf.PrefetchInterval != "" {
+		if prefetchInterval, err = time.ParseDuration(conf.PrefetchInterval); err != nil || prefetchInterval <= 0 {
+			return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.AuthProvider).WithDetail(fmt.Sprintf("prefetchInterval must be a positive duration, got %s", conf.PrefetchInterval))
+		}
+	}
+
+	resource := conf.Resource
+	if resource == "" {
+		resource = AADResource
+	}
+	tokenClient := s.tokenClient
+	if tokenClient == nil {
+		tokenClient = workloadIdentityTokenClient{}
+	}
+	httpClient, err := conf.newHTTPClient()
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, "failed to configure the auth client transport", re.HideStackTrace)
+	}
+	exchanger := s.exchanger
+	if exchanger == nil {
+		exchanger = &acrRefreshTokenExchanger{httpClient: httpClient}
+	}
+
 	// retrieve an AAD Access token
-	token, err := azureauth.GetAADAccessToken(context.Background(), tenant, clientID, AADResource)
+	token, err := tokenClient.GetAADAccessToken(context.Background(), tenant, clientID, resource)
 	if err != nil {
-		return nil, re.ErrorCodeAuthDenied.NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, "", re.HideStackTrace)
+		return nil, classifyError(err).NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, "", re.HideStackTrace)
 	}
 
-	return &azureWIAuthProvider{
-		aadToken: token,
-		tenantID: tenant,
-		clientID: clientID,
-	}, nil
+	authProvider := &azureWIAuthProvider{
+		aadToken:            token,
+		tenantID:            tenant,
+		clientID:            clientID,
+		resource:            resource,
+		tokenClient:         tokenClient,
+		exchanger:           exchanger,
+		anonymousRegistries: anonymousRegistrySet(conf.AnonymousRegistries),
+		anonymousClient:     anonymousTokenClient{httpClient: httpClient},
+		registryIdentities:  conf.RegistryIdentities,
+		prefetchInterval:    prefetchInterval,
+		clock:               s.clock,
+	}
+	if prefetchInterval > 0 {
+		var prefetchCtx context.Context
+		prefetchCtx, authProvider.stopPrefetch = context.WithCancel(context.Background())
+		authProvider.prefetchDone = make(chan struct{})
+		go func() {
+			defer close(authProvider.prefetchDone)
+			authProvider.prefetchTokens(prefetchCtx)
+		}()
+	}
+	return authProvider, nil
 }
 
 // Enabled checks for non empty tenant ID and AAD access token
@@ -103,6 +207,41 @@ func (d *azureWIAuthProvider) Enabled(_ context.Context) bool {
 	return true
 }
 
+// Validate acquires an AAD token for the default identity and the identities of
+// the configured registries to check their federated credentials.
+func (d *azureWIAuthProvider) Validate(ctx context.Context) error {
+	identities := []registryIdentity{{ClientID: d.clientID, TenantID: d.tenantID}}
+	hosts := make([]string, 0, len(d.registryIdentities))
+	for host := range d.registryIdentities {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		clientID, tenantID := resolveIdentity(d.registryIdentities, host, d.clientID, d.tenantID)
+		if identity := (registryIdentity{ClientID: clientID, TenantID: tenantID}); !slices.Contains(identities, identity) {
+			identities = append(identities, identity)
+		}
+	}
+
+	for _, identity := range identities {
+		token, err := d.tokenClient.GetAADAccessToken(ctx, identity.TenantID, identity.ClientID, d.resource)
+		if err != nil {
+			return classifyError(err).NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, fmt.Sprintf("failed to acquire AAD token of client %s", identity.ClientID), re.HideStackTrace)
+		}
+		d.mu.Lock()
+		if identity.ClientID == d.clientID && identity.TenantID == d.tenantID {
+			d.aadToken = token
+		} else {
+			if d.registryTokens == nil {
+				d.registryTokens = make(map[registryIdentity]confidential.AuthResult)
+			}
+			d.registryTokens[identity] = token
+		}
+		d.mu.Unlock()
+	}
+	return nil
+}
+
 // Provide returns the credentials for a specified artifact.
 // Uses Azure Workload Identity to retrieve an AAD access token which can be
 // exchanged for a valid ACR refresh token for login.
@@ -116,39 +255,82 @@ func (d *azureWIAuthProvider) Provide(ctx context.Context, artifact string) (pro
 		return provider.AuthConfig{}, re.ErrorCodeHostNameInvalid.WithComponentType(re.AuthProvider)
 	}
 
-	// need to refresh AAD token if it's expired
-	if time.Now().Add(time.Minute * 5).After(d.aadToken.ExpiresOn) {
-		newToken, err := azureauth.GetAADAccessToken(ctx, d.tenantID, d.clientID, AADResource)
+	if _, ok := d.anonymousRegistries[artifactHostName]; ok {
+		authConfig, err := provideAnonymous(ctx, d.anonymousClient, artifact, d)
 		if err != nil {
-			return provider.AuthConfig{}, re.ErrorCodeAuthDenied.NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, nil, "could not refresh AAD token", re.HideStackTrace)
+			return provider.AuthConfig{}, classifyError(err).NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, "failed to get anonymous access token for container registry", re.HideStackTrace)
 		}
-		d.aadToken = newToken
-		logger.GetLogger(ctx, logOpt).Info("successfully refreshed AAD token")
+		return authConfig, nil
 	}
 
-	// add protocol to generate complete URI
-	serverURL := "https://" + artifactHostName
+	if authConfig, ok := d.cachedAuthConfig(artifactHostName, tokenRefreshMargin); ok {
+		return authConfig, nil
+	}
+	return d.provideRegistry(ctx, artifactHostName, tokenRefreshMargin)
+}
+
+// provideRegistry exchanges the AAD token of the identity of the registry,
+// refreshed if it expires within the margin, for registry credentials.
+func (d *azureWIAuthProvider) provideRegistry(ctx context.Context, artifactHostName string, margin time.Duration) (provider.AuthConfig, error) {
+	clientID, tenantID := resolveIdentity(d.registryIdentities, artifactHostName, d.clientID, d.tenantID)
+	aadToken, err := d.getToken(ctx, registryIdentity{ClientID: clientID, TenantID: tenantID}, margin)
+	if err != nil {
+		return provider.AuthConfig{}, classifyError(err).NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, "could not refresh AAD token", re.HideStackTrace)
+	}
 
-	// create registry client and exchange AAD token for registry refresh token
-	refreshTokenClient := containerregistry.NewRefreshTokensClient(serverURL)
-	startTime := time.Now()
-	rt, err := refreshTokenClient.GetFromExchange(context.Background(), "access_token", artifactHostName, d.tenantID, "", d.aadToken.AccessToken)
+	refreshToken, err := d.exchanger.ExchangeRefreshToken(ctx, artifactHostName, tenantID, aadToken.AccessToken)
 	if err != nil {
-		return provider.AuthConfig{}, re.ErrorCodeAuthDenied.NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, "failed to get refresh token for container registry", re.HideStackTrace)
+		return provider.AuthConfig{}, classifyError(err).NewError(re.AuthProvider, "", re.AzureWorkloadIdentityLink, err, "failed to get refresh token for container registry", re.HideStackTrace)
 	}
-	metrics.ReportACRExchangeDuration(ctx, time.Since(startTime).Milliseconds(), artifactHostName)
 
-	refreshTokenExpiry := getACRExpiryIfEarlier(d.aadToken.ExpiresOn)
+	redact.Register(aadToken.AccessToken, refreshToken)
+	refreshTokenExpiry := getACRExpiryIfEarlier(aadToken.ExpiresOn)
 	authConfig := provider.AuthConfig{
 		Username:  dockerTokenLoginUsernameGUID,
-		Password:  *rt.RefreshToken,
+		Password:  refreshToken,
 		Provider:  d,
 		ExpiresOn: refreshTokenExpiry,
 	}
+	d.cacheAuthConfig(artifactHostName, authConfig)
 
 	return authConfig, nil
 }
 
+// getToken returns the AAD token of the identity, refreshing it if it expires
+// within the margin.
+func (d *azureWIAuthProvider) getToken(ctx context.Context, identity registryIdentity, margin time.Duration) (confidential.AuthResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	isDefault := identity.ClientID == d.clientID && identity.TenantID == d.tenantID
+	token := d.aadToken
+	if !isDefault {
+		token = d.registryTokens[identity]
+	}
+	// need to refresh AAD token if it's expired
+	if d.now().Add(margin).Before(token.ExpiresOn) {
+		return token, nil
+	}
+	newToken, err := d.tokenClient.GetAADAccessToken(ctx, identity.TenantID, identity.ClientID, d.resource)
+	if err != nil {
+		return confidential.AuthResult{}, err
+	}
+	if isDefault {
+		d.aadToken = newToken
+	} else {
+		if d.registryTokens == nil {
+			d.registryTokens = make(map[registryIdentity]confidential.AuthResult)
+		}
+		d.registryTokens[identity] = newToken
+	}
+	logger.GetLogger(ctx, logOpt).Infof("successfully refreshed AAD token of client %s", identity.ClientID)
+	return newToken, nil
+}
+
+// GetAADAccessToken returns an AAD access token for the resource.
+func (workloadIdentityTokenClient) GetAADAccessToken(ctx context.Context, tenantID, clientID, resource string) (confidential.AuthResult, error) {
+	return azureauth.GetAADAccessToken(ctx, tenantID, clientID, resource)
+}
+
 // Compare addExpiry with default ACR refresh token expiry
 func getACRExpiryIfEarlier(aadExpiry time.Time) time.Time {
 	// set default refresh token expiry to default ACR expiry - 5 minutes
@@ -19,6 +19,7 @@ import (
 	"context"
 	"errors"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -131,3 +132,127 @@ func TestAzureWIValidation_EnvironmentVariables_ExpectedResults(t *testing.T) {
 		t.Fatalf("create auth provider should have failed: expected err %s, but got err %s", expectedErr, err)
 	}
 }
+
+type mockAADTokenClient struct {
+	token      confidential.AuthResult
+	err        error
+	resources  []string
+	identities []registryIdentity
+}
+
+func (c *mockAADTokenClient) GetAADAccessToken(_ context.Context, tenantID, clientID, resource string) (confidential.AuthResult, error) {
+	c.resources = append(c.resources, resource)
+	c.identities = append(c.identities, registryIdentity{ClientID: clientID, TenantID: tenantID})
+	return c.token, c.err
+}
+
+// Verifies that the configured resource, or the ACR resource by default, is
+// used to acquire and refresh the AAD token
+func TestAzureWI_Resource(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "test_tenant")
+	t.Setenv("AZURE_CLIENT_ID", "test_client")
+
+	tests := []struct {
+		name             string
+		config           map[string]interface{}
+		expectedResource string
+	}{
+		{
+			name:             "default resource",
+			config:           map[string]interface{}{"name": "azureWorkloadIdentity"},
+			expectedResource: AADResource,
+		},
+		{
+			name:             "configured resource",
+			config:           map[string]interface{}{"name": "azureWorkloadIdentity", "resource": "https://myregistry.privatelink.azurecr.io/.default"},
+			expectedResource: "https://myregistry.privatelink.azurecr.io/.default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the token expires soon so that Provide refreshes it
+			tokenClient := &mockAADTokenClient{token: confidential.AuthResult{AccessToken: "aad_token", ExpiresOn: time.Now().Add(time.Minute)}}
+			exchanger := &mockExchanger{refreshToken: "refresh_token"}
+			factory := &AzureWIProviderFactory{tokenClient: tokenClient, exchanger: exchanger}
+
+			authProvider, err := factory.Create(tt.config)
+			if err != nil {
+				t.Fatalf("failed to create auth provider: %v", err)
+			}
+			if _, err := authProvider.Provide(context.Background(), "myregistry.azurecr.io/app:v1"); err != nil {
+				t.Fatalf("failed to provide credentials: %v", err)
+			}
+			if len(tokenClient.resources) != 2 || tokenClient.resources[0] != tt.expectedResource || tokenClient.resources[1] != tt.expectedResource {
+				t.Fatalf("expected token requests for resource %s, got %v", tt.expectedResource, tokenClient.resources)
+			}
+			if exchanger.aadToken != "aad_token" {
+				t.Fatalf("expected the AAD token to be exchanged, got %s", exchanger.aadToken)
+			}
+		})
+	}
+}
+
+// Verifies that Validate acquires a token for the default identity and the
+// distinct registry identities, and fails if any acquisition fails
+func TestAzureWI_Validate(t *testing.T) {
+	tests := []struct {
+		name               string
+		tokenErr           error
+		registryIdentities map[string]registryIdentity
+		expectedIdentities []registryIdentity
+		expectedErr        bool
+	}{
+		{
+			name:               "default identity",
+			expectedIdentities: []registryIdentity{{ClientID: "test_client", TenantID: "test_tenant"}},
+		},
+		{
+			name: "registry identities",
+			registryIdentities: map[string]registryIdentity{
+				"a.azurecr.io": {ClientID: "registry_client"},
+				"b.azurecr.io": {ClientID: "registry_client"},
+				"c.azurecr.io": {ClientID: "test_client"},
+			},
+			expectedIdentities: []registryIdentity{
+				{ClientID: "test_client", TenantID: "test_tenant"},
+				{ClientID: "registry_client", TenantID: "test_tenant"},
+			},
+		},
+		{
+			name:               "token acquisition fails",
+			tokenErr:           errors.New("AADSTS700016: application not found"),
+			expectedIdentities: []registryIdentity{{ClientID: "test_client", TenantID: "test_tenant"}},
+			expectedErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := confidential.AuthResult{AccessToken: "validated_token", ExpiresOn: time.Now().Add(time.Hour)}
+			tokenClient := &mockAADTokenClient{token: token, err: tt.tokenErr}
+			authProvider := &azureWIAuthProvider{
+				tenantID:           "test_tenant",
+				clientID:           "test_client",
+				resource:           AADResource,
+				tokenClient:        tokenClient,
+				registryIdentities: tt.registryIdentities,
+			}
+
+			err := authProvider.Validate(context.Background())
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if !reflect.DeepEqual(tokenClient.identities, tt.expectedIdentities) {
+				t.Fatalf("expected token requests for identities %v, got %v", tt.expectedIdentities, tokenClient.identities)
+			}
+			if tt.expectedErr {
+				if authProvider.aadToken.AccessToken != "" {
+					t.Fatalf("expected no token to be cached after a failed validation")
+				}
+				return
+			}
+			if authProvider.aadToken.AccessToken != "validated_token" {
+				t.Fatalf("expected the validated token to be cached, got %q", authProvider.aadToken.AccessToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	msalerrors "github.com/AzureAD/microsoft-authentication-library-for-go/apps/errors"
+	re "github.com/ratify-project/ratify/errors"
+)
+
+// httpStatusError is returned when the registry responded to a token request
+// with an unexpected HTTP status.
+type httpStatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *httpStatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *httpStatusError) Unwrap() error {
+	return e.Err
+}
+
+// classifyError returns the error code of the category of a failed AAD or ACR
+// token request so that callers can decide whether to retry:
+//   - ErrorCodeThrottled if the service throttled the request.
+//   - ErrorCodeNetworkFailure if the request failed transiently, i.e. on a
+//     network error, a timeout or a server error.
+//   - ErrorCodeAuthDenied otherwise, e.g. if the credentials were rejected.
+func classifyError(err error) re.ErrorCode {
+	statusCode := responseStatusCode(err)
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return re.ErrorCodeThrottled
+	case statusCode >= http.StatusInternalServerError:
+		return re.ErrorCodeNetworkFailure
+	case statusCode != 0:
+		return re.ErrorCodeAuthDenied
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return re.ErrorCodeNetworkFailure
+	}
+	return re.ErrorCodeAuthDenied
+}
+
+// responseStatusCode returns the HTTP status of the response that caused the
+// error, or 0 if the error has no response.
+func responseStatusCode(err error) int {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.StatusCode
+	}
+	var authErr *azidentity.AuthenticationFailedError
+	if errors.As(err, &authErr) && authErr.RawResponse != nil {
+		return authErr.RawResponse.StatusCode
+	}
+	var callErr msalerrors.CallErr
+	if errors.As(err, &callErr) && callErr.Resp != nil {
+		return callErr.Resp.StatusCode
+	}
+	return 0
+}
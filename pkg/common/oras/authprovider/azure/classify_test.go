@@ -0,0 +1,156 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
+	msalerrors "github.com/AzureAD/microsoft-authentication-library-for-go/apps/errors"
+	ratifyerrors "github.com/ratify-project/ratify/errors"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ratifyerrors.ErrorCode
+	}{
+		{
+			name:     "exchange unauthorized",
+			err:      &httpStatusError{StatusCode: http.StatusUnauthorized, Err: errors.New("unauthorized")},
+			expected: ratifyerrors.ErrorCodeAuthDenied,
+		},
+		{
+			name:     "exchange forbidden",
+			err:      &httpStatusError{StatusCode: http.StatusForbidden, Err: errors.New("forbidden")},
+			expected: ratifyerrors.ErrorCodeAuthDenied,
+		},
+		{
+			name:     "exchange throttled",
+			err:      fmt.Errorf("exchange failed: %w", &httpStatusError{StatusCode: http.StatusTooManyRequests, Err: errors.New("too many requests")}),
+			expected: ratifyerrors.ErrorCodeThrottled,
+		},
+		{
+			name:     "exchange server error",
+			err:      &httpStatusError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("service unavailable")},
+			expected: ratifyerrors.ErrorCodeNetworkFailure,
+		},
+		{
+			name:     "managed identity throttled",
+			err:      &azcore.ResponseError{StatusCode: http.StatusTooManyRequests},
+			expected: ratifyerrors.ErrorCodeThrottled,
+		},
+		{
+			name:     "managed identity denied",
+			err:      &azidentity.AuthenticationFailedError{RawResponse: &http.Response{StatusCode: http.StatusBadRequest}},
+			expected: ratifyerrors.ErrorCodeAuthDenied,
+		},
+		{
+			name:     "workload identity server error",
+			err:      msalerrors.CallErr{Resp: &http.Response{StatusCode: http.StatusBadGateway}, Err: errors.New("bad gateway")},
+			expected: ratifyerrors.ErrorCodeNetworkFailure,
+		},
+		{
+			name:     "connection refused",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			expected: ratifyerrors.ErrorCodeNetworkFailure,
+		},
+		{
+			name:     "timeout",
+			err:      fmt.Errorf("token request failed: %w", context.DeadlineExceeded),
+			expected: ratifyerrors.ErrorCodeNetworkFailure,
+		},
+		{
+			name:     "unknown error",
+			err:      errors.New("required environment variables not set"),
+			expected: ratifyerrors.ErrorCodeAuthDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := classifyError(tt.err); actual != tt.expected {
+				t.Fatalf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+// Verifies that Provide surfaces the category of the failure
+func TestAzureWIProvide_ClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		expected ratifyerrors.ErrorCode
+	}{
+		{
+			name:     "anonymous token throttled",
+			status:   http.StatusTooManyRequests,
+			expected: ratifyerrors.ErrorCodeThrottled,
+		},
+		{
+			name:     "anonymous token denied",
+			status:   http.StatusUnauthorized,
+			expected: ratifyerrors.ErrorCodeAuthDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, registryHost := newAnonymousTokenServer(t, tt.status, `{}`)
+			authProvider := azureWIAuthProvider{
+				aadToken: confidential.AuthResult{
+					AccessToken: "aad_token",
+					ExpiresOn:   time.Now().Add(time.Hour),
+				},
+				tenantID:            "test_tenant",
+				clientID:            "test_client",
+				anonymousRegistries: anonymousRegistrySet([]string{registryHost}),
+				anonymousClient:     anonymousTokenClient{httpClient: server.Client()},
+			}
+
+			_, err := authProvider.Provide(context.Background(), registryHost+"/library/app:v1")
+			var ratifyErr ratifyerrors.Error
+			if !errors.As(err, &ratifyErr) || ratifyErr.ErrorCode() != tt.expected {
+				t.Fatalf("expected error code %s, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	// a network failure of the exchange
+	exchanger := &mockExchanger{err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	authProvider := azureWIAuthProvider{
+		aadToken: confidential.AuthResult{
+			AccessToken: "aad_token",
+			ExpiresOn:   time.Now().Add(time.Hour),
+		},
+		tenantID:  "test_tenant",
+		clientID:  "test_client",
+		exchanger: exchanger,
+	}
+	_, err := authProvider.Provide(context.Background(), "myregistry.azurecr.io/app:v1")
+	var ratifyErr ratifyerrors.Error
+	if !errors.As(err, &ratifyErr) || ratifyErr.ErrorCode() != ratifyerrors.ErrorCodeNetworkFailure {
+		t.Fatalf("expected error code %s, got %v", ratifyerrors.ErrorCodeNetworkFailure, err)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import "fmt"
+
+// registryIdentity is the identity used to authenticate to a registry instead
+// of the identity of the provider.
+type registryIdentity struct {
+	ClientID string `json:"clientID"`
+	// TenantID defaults to the tenant of the provider.
+	TenantID string `json:"tenantID,omitempty"`
+}
+
+// validateRegistryIdentities returns an error if an identity has no client ID.
+func validateRegistryIdentities(identities map[string]registryIdentity) error {
+	for registryHost, identity := range identities {
+		if identity.ClientID == "" {
+			return fmt.Errorf("no client ID provided for registry %s", registryHost)
+		}
+	}
+	return nil
+}
+
+// resolveIdentity returns the client and tenant IDs used to authenticate to
+// the registry, falling back to the given defaults if the registry has no
+// identity of its own.
+func resolveIdentity(identities map[string]registryIdentity, registryHost, clientID, tenantID string) (string, string) {
+	identity, ok := identities[registryHost]
+	if !ok {
+		return clientID, tenantID
+	}
+	if identity.TenantID != "" {
+		tenantID = identity.TenantID
+	}
+	return identity.ClientID, tenantID
+}
@@ -0,0 +1,141 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
+)
+
+var testRegistryIdentities = map[string]interface{}{
+	"first.azurecr.io":  map[string]interface{}{"clientID": "first_client", "tenantID": "first_tenant"},
+	"second.azurecr.io": map[string]interface{}{"clientID": "second_client"},
+}
+
+func TestResolveIdentity(t *testing.T) {
+	identities := map[string]registryIdentity{
+		"first.azurecr.io":  {ClientID: "first_client", TenantID: "first_tenant"},
+		"second.azurecr.io": {ClientID: "second_client"},
+	}
+	tests := []struct {
+		registryHost     string
+		expectedClientID string
+		expectedTenantID string
+	}{
+		{registryHost: "first.azurecr.io", expectedClientID: "first_client", expectedTenantID: "first_tenant"},
+		{registryHost: "second.azurecr.io", expectedClientID: "second_client", expectedTenantID: "test_tenant"},
+		{registryHost: "other.azurecr.io", expectedClientID: "test_client", expectedTenantID: "test_tenant"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.registryHost, func(t *testing.T) {
+			clientID, tenantID := resolveIdentity(identities, tt.registryHost, "test_client", "test_tenant")
+			if clientID != tt.expectedClientID || tenantID != tt.expectedTenantID {
+				t.Fatalf("expected identity %s/%s, got %s/%s", tt.expectedTenantID, tt.expectedClientID, tenantID, clientID)
+			}
+		})
+	}
+
+	if err := validateRegistryIdentities(map[string]registryIdentity{"first.azurecr.io": {TenantID: "first_tenant"}}); err == nil {
+		t.Fatalf("expected error for registry identity without client ID")
+	}
+}
+
+// Verifies that each registry is authenticated to with its own managed
+// identity, and other registries with the identity of the provider
+func TestAzureMSIProvide_RegistryIdentities(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "test_tenant")
+	t.Setenv("AZURE_CLIENT_ID", "")
+
+	imdsClient := &mockIMDSClient{token: azcore.AccessToken{Token: "imds_token", ExpiresOn: time.Now().Add(time.Hour)}}
+	exchanger := &mockExchanger{refreshToken: "refresh_token"}
+	factory := &azureManagedIdentityProviderFactory{tokenClient: imdsClient, exchanger: exchanger}
+	authProvider, err := factory.Create(map[string]interface{}{
+		"name":               "azureManagedIdentity",
+		"clientID":           "test_client",
+		"registryIdentities": testRegistryIdentities,
+	})
+	if err != nil {
+		t.Fatalf("failed to create auth provider: %v", err)
+	}
+
+	tests := []struct {
+		artifact         string
+		expectedTenantID string
+	}{
+		{artifact: "first.azurecr.io/app:v1", expectedTenantID: "first_tenant"},
+		{artifact: "second.azurecr.io/app:v1", expectedTenantID: "test_tenant"},
+		{artifact: "other.azurecr.io/app:v1", expectedTenantID: "test_tenant"},
+		{artifact: "first.azurecr.io/app:v2", expectedTenantID: "first_tenant"},
+	}
+	for _, tt := range tests {
+		if _, err := authProvider.Provide(context.Background(), tt.artifact); err != nil {
+			t.Fatalf("failed to provide credentials for %s: %v", tt.artifact, err)
+		}
+		if exchanger.tenantID != tt.expectedTenantID {
+			t.Fatalf("expected exchange in tenant %s for %s, got %s", tt.expectedTenantID, tt.artifact, exchanger.tenantID)
+		}
+	}
+	// tokens of the registry identities are acquired once and then reused
+	expectedClientIDs := []string{"test_client", "first_client", "second_client"}
+	if !reflect.DeepEqual(imdsClient.clientIDs, expectedClientIDs) {
+		t.Fatalf("expected token requests for %v, got %v", expectedClientIDs, imdsClient.clientIDs)
+	}
+
+	if _, err := factory.Create(map[string]interface{}{
+		"name":               "azureManagedIdentity",
+		"clientID":           "test_client",
+		"registryIdentities": map[string]interface{}{"first.azurecr.io": map[string]interface{}{"tenantID": "first_tenant"}},
+	}); err == nil {
+		t.Fatalf("expected error for registry identity without client ID")
+	}
+}
+
+// Verifies that each registry is authenticated to with its own workload
+// identity, and other registries with the identity of the provider
+func TestAzureWIProvide_RegistryIdentities(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "test_tenant")
+	t.Setenv("AZURE_CLIENT_ID", "test_client")
+
+	tokenClient := &mockAADTokenClient{token: confidential.AuthResult{AccessToken: "aad_token", ExpiresOn: time.Now().Add(time.Hour)}}
+	exchanger := &mockExchanger{refreshToken: "refresh_token"}
+	factory := &AzureWIProviderFactory{tokenClient: tokenClient, exchanger: exchanger}
+	authProvider, err := factory.Create(map[string]interface{}{
+		"name":               "azureWorkloadIdentity",
+		"registryIdentities": testRegistryIdentities,
+	})
+	if err != nil {
+		t.Fatalf("failed to create auth provider: %v", err)
+	}
+
+	for _, artifact := range []string{"first.azurecr.io/app:v1", "second.azurecr.io/app:v1", "other.azurecr.io/app:v1", "second.azurecr.io/app:v2"} {
+		if _, err := authProvider.Provide(context.Background(), artifact); err != nil {
+			t.Fatalf("failed to provide credentials for %s: %v", artifact, err)
+		}
+	}
+	expectedIdentities := []registryIdentity{
+		{ClientID: "test_client", TenantID: "test_tenant"},
+		{ClientID: "first_client", TenantID: "first_tenant"},
+		{ClientID: "second_client", TenantID: "test_tenant"},
+	}
+	if !reflect.DeepEqual(tokenClient.identities, expectedIdentities) {
+		t.Fatalf("expected token requests for %v, got %v", expectedIdentities, tokenClient.identities)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"time"
+
+	"github.com/ratify-project/ratify/internal/logger"
+	provider "github.com/ratify-project/ratify/pkg/common/oras/authprovider"
+)
+
+// tokenRefreshMargin is how long before their expiry tokens are refreshed on
+// demand.
+const tokenRefreshMargin = 5 * time.Minute
+
+// clock tells the time and waits for durations to elapse.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (d *azureWIAuthProvider) getClock() clock {
+	if d.clock == nil {
+		return systemClock{}
+	}
+	return d.clock
+}
+
+func (d *azureWIAuthProvider) now() time.Time {
+	return d.getClock().Now()
+}
+
+// prefetchTokens refreshes the AAD tokens and the cached registry credentials
+// every prefetch interval until the context is done. Tokens expiring before
+// the next iteration are refreshed so that Provide finds valid tokens.
+func (d *azureWIAuthProvider) prefetchTokens(ctx context.Context) {
+	margin := tokenRefreshMargin + d.prefetchInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.getClock().After(d.prefetchInterval):
+			d.refreshTokens(ctx, margin)
+		}
+	}
+}
+
+// Close stops the token prefetch loop, if enabled, and waits for it to return.
+func (d *azureWIAuthProvider) Close() {
+	if d.stopPrefetch != nil {
+		d.stopPrefetch()
+		<-d.prefetchDone
+	}
+}
+
+// refreshTokens refreshes the AAD tokens and the cached registry credentials
+// expiring within the margin.
+func (d *azureWIAuthProvider) refreshTokens(ctx context.Context, margin time.Duration) {
+	d.mu.Lock()
+	identities := []registryIdentity{{ClientID: d.clientID, TenantID: d.tenantID}}
+	for identity := range d.registryTokens {
+		identities = append(identities, identity)
+	}
+	var registryHosts []string
+	for registryHost := range d.acrTokens {
+		registryHosts = append(registryHosts, registryHost)
+	}
+	d.mu.Unlock()
+
+	for _, identity := range identities {
+		if _, err := d.getToken(ctx, identity, margin); err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to prefetch AAD token of client %s: %v", identity.ClientID, err)
+		}
+	}
+	for _, registryHost := range registryHosts {
+		if _, ok := d.cachedAuthConfig(registryHost, margin); ok {
+			continue
+		}
+		if _, err := d.provideRegistry(ctx, registryHost, margin); err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to prefetch credentials of registry %s: %v", registryHost, err)
+		}
+	}
+}
+
+// cachedAuthConfig returns the cached credentials of the registry if they are
+// valid beyond the margin.
+func (d *azureWIAuthProvider) cachedAuthConfig(registryHost string, margin time.Duration) (provider.AuthConfig, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	authConfig, ok := d.acrTokens[registryHost]
+	if !ok || !d.now().Add(margin).Before(authConfig.ExpiresOn) {
+		return provider.AuthConfig{}, false
+	}
+	return authConfig, true
+}
+
+// cacheAuthConfig caches the credentials of the registry if the token
+// prefetch loop is enabled.
+func (d *azureWIAuthProvider) cacheAuthConfig(registryHost string, authConfig provider.AuthConfig) {
+	if d.prefetchInterval <= 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.acrTokens == nil {
+		d.acrTokens = make(map[string]provider.AuthConfig)
+	}
+	d.acrTokens[registryHost] = authConfig
+}
@@ -0,0 +1,176 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
+)
+
+// fakeClock publishes the channel of every wait so that tests can tell when
+// the prefetch loop is idle and fire its timer.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters chan chan time.Time
+	pending chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiters: make(chan chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(_ time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.waiters <- ch
+	return ch
+}
+
+// advance moves the clock and fires the pending wait of the loop, returning
+// once the loop waits again.
+func (c *fakeClock) advance(d time.Duration) {
+	if c.pending == nil {
+		c.pending = <-c.waiters
+	}
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	c.mu.Unlock()
+	c.pending <- now
+	c.pending = <-c.waiters
+}
+
+func TestAzureWIPrefetchTokens(t *testing.T) {
+	start := time.Now()
+	clock := newFakeClock(start)
+	tokenClient := &mockAADTokenClient{token: confidential.AuthResult{AccessToken: "new_token", ExpiresOn: start.Add(time.Hour)}}
+	exchanger := &mockExchanger{refreshToken: "refresh_token"}
+	authProvider := &azureWIAuthProvider{
+		aadToken: confidential.AuthResult{
+			AccessToken: "aad_token",
+			ExpiresOn:   start.Add(20 * time.Minute),
+		},
+		tenantID:         "test_tenant",
+		clientID:         "test_client",
+		tokenClient:      tokenClient,
+		exchanger:        exchanger,
+		prefetchInterval: 10 * time.Minute,
+		clock:            clock,
+	}
+
+	// the first request exchanges the token and caches the credentials
+	authConfig, err := authProvider.Provide(context.Background(), "myregistry.azurecr.io/app:v1")
+	if err != nil {
+		t.Fatalf("failed to provide credentials: %v", err)
+	}
+	if exchanger.aadToken != "aad_token" || !authConfig.ExpiresOn.Equal(start.Add(20*time.Minute)) {
+		t.Fatalf("unexpected credentials %+v exchanged from %s", authConfig, exchanger.aadToken)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		authProvider.prefetchTokens(ctx)
+		close(done)
+	}()
+
+	// the token expires after the next iteration, nothing is refreshed yet
+	clock.advance(time.Minute)
+	if len(tokenClient.identities) != 0 {
+		t.Fatalf("expected no token refresh, got %v", tokenClient.identities)
+	}
+
+	// the token would expire before the next iteration, it is refreshed
+	// ahead of expiry along with the cached credentials
+	exchanger.aadToken = ""
+	clock.advance(5 * time.Minute)
+	if len(tokenClient.identities) != 1 || tokenClient.identities[0].ClientID != "test_client" {
+		t.Fatalf("expected a single token refresh, got %v", tokenClient.identities)
+	}
+	if exchanger.aadToken != "new_token" {
+		t.Fatalf("expected the cached credentials to be refreshed with the new token, got %s", exchanger.aadToken)
+	}
+
+	// requests are served from the warm credentials without an exchange
+	exchanger.aadToken = ""
+	authConfig, err = authProvider.Provide(context.Background(), "myregistry.azurecr.io/app:v2")
+	if err != nil {
+		t.Fatalf("failed to provide credentials: %v", err)
+	}
+	if exchanger.aadToken != "" || authConfig.Password != "refresh_token" || !authConfig.ExpiresOn.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected prefetched credentials, got %+v", authConfig)
+	}
+	if len(tokenClient.identities) != 1 {
+		t.Fatalf("expected no token refresh on Provide, got %v", tokenClient.identities)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestAzureWIPrefetchInterval(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "test_tenant")
+	t.Setenv("AZURE_CLIENT_ID", "test_client")
+
+	factory := &AzureWIProviderFactory{tokenClient: &mockAADTokenClient{}, exchanger: &mockExchanger{}}
+	for _, interval := range []string{"soon", "0s", "-1m"} {
+		if _, err := factory.Create(map[string]interface{}{"name": "azureWorkloadIdentity", "prefetchInterval": interval}); err == nil {
+			t.Fatalf("expected error for prefetch interval %s", interval)
+		}
+	}
+	authProvider, err := factory.Create(map[string]interface{}{"name": "azureWorkloadIdentity"})
+	if err != nil {
+		t.Fatalf("failed to create auth provider: %v", err)
+	}
+	if authProvider.(*azureWIAuthProvider).prefetchInterval != 0 {
+		t.Fatalf("expected token prefetch to be disabled by default")
+	}
+}
+
+func TestAzureWIPrefetchClose(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "test_tenant")
+	t.Setenv("AZURE_CLIENT_ID", "test_client")
+
+	clock := newFakeClock(time.Now())
+	factory := &AzureWIProviderFactory{tokenClient: &mockAADTokenClient{}, exchanger: &mockExchanger{}, clock: clock}
+	authProvider, err := factory.Create(map[string]interface{}{"name": "azureWorkloadIdentity", "prefetchInterval": "1m"})
+	if err != nil {
+		t.Fatalf("failed to create auth provider: %v", err)
+	}
+	// the loop waits for its first iteration
+	<-clock.waiters
+
+	closed := make(chan struct{})
+	go func() {
+		authProvider.(*azureWIAuthProvider).Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the prefetch loop to stop once the provider is closed")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+func TestTransportConfig_NewHTTPClient(t *testing.T) {
+	tmpDir := t.TempDir()
+	invalidBundle := filepath.Join(tmpDir, "invalid.pem")
+	if err := os.WriteFile(invalidBundle, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   transportConfig
+		isNil    bool
+		expected string
+		isErr    bool
+	}{
+		{
+			name:  "default transport",
+			isNil: true,
+		},
+		{
+			name:     "proxy",
+			config:   transportConfig{ProxyURL: "http://proxy.example.com:3128"},
+			expected: "http://proxy.example.com:3128",
+		},
+		{
+			name:   "invalid proxy",
+			config: transportConfig{ProxyURL: "proxy.example.com"},
+			isErr:  true,
+		},
+		{
+			name:   "missing CA bundle",
+			config: transportConfig{CABundle: filepath.Join(tmpDir, "missing.pem")},
+			isErr:  true,
+		},
+		{
+			name:   "CA bundle without certificates",
+			config: transportConfig{CABundle: invalidBundle},
+			isErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient, err := tt.config.newHTTPClient()
+			if tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+			if tt.isErr {
+				return
+			}
+			if tt.isNil != (httpClient == nil) {
+				t.Fatalf("expected nil client %v, got %v", tt.isNil, httpClient)
+			}
+			if tt.expected == "" {
+				return
+			}
+			req, _ := http.NewRequest(http.MethodPost, "https://myregistry.azurecr.io/oauth2/exchange", nil)
+			proxyURL, err := httpClient.Transport.(*http.Transport).Proxy(req)
+			if err != nil || proxyURL.String() != tt.expected {
+				t.Fatalf("expected proxy %s, got %v: %v", tt.expected, proxyURL, err)
+			}
+		})
+	}
+}
+
+// Verifies that the exchange request is sent with the configured transport,
+// trusting the CA bundle of the registry
+func TestAzureMSIProvide_CABundle(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "test_tenant")
+	t.Setenv("AZURE_CLIENT_ID", "")
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"refresh_token":"acr_refresh_token"}`))
+	}))
+	defer server.Close()
+	registryHost := strings.TrimPrefix(server.URL, "https://")
+
+	caBundle := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caBundle, pemBytes, 0600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	imdsClient := &mockIMDSClient{token: azcore.AccessToken{Token: "imds_token", ExpiresOn: time.Now().Add(time.Hour)}}
+	factory := &azureManagedIdentityProviderFactory{tokenClient: imdsClient}
+	authProvider, err := factory.Create(map[string]interface{}{
+		"name":     "azureManagedIdentity",
+		"clientID": "test_client",
+		"caBundle": caBundle,
+	})
+	if err != nil {
+		t.Fatalf("failed to create auth provider: %v", err)
+	}
+
+	// the certificate of the test server is only trusted through the CA bundle
+	authConfig, err := authProvider.Provide(context.Background(), registryHost+"/app:v1")
+	if err != nil {
+		t.Fatalf("failed to provide credentials: %v", err)
+	}
+	if authConfig.Password != "acr_refresh_token" {
+		t.Fatalf("expected refresh token from the registry, got %s", authConfig.Password)
+	}
+}
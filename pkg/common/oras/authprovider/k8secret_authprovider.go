@@ -26,6 +26,7 @@ import (
 
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/internal/redact"
 	"github.com/ratify-project/ratify/pkg/utils"
 
 	"github.com/docker/cli/cli/config"
@@ -111,6 +112,12 @@ func (d *k8SecretAuthProvider) Enabled(_ context.Context) bool {
 	return true
 }
 
+// Validate is a no-op for k8SecretAuthProvider since the secrets are read on
+// every Provide call.
+func (d *k8SecretAuthProvider) Validate(_ context.Context) error {
+	return nil
+}
+
 // Provide finds secret corresponding to artifact's registry host name, extracts
 // the authentication credentials from K8s secret, and returns AuthConfig
 func (d *k8SecretAuthProvider) Provide(ctx context.Context, artifact string) (AuthConfig, error) {
@@ -210,6 +217,7 @@ func (d *k8SecretAuthProvider) resolveCredentialFromSecret(ctx context.Context,
 		return AuthConfig{}, re.ErrorCodeNoMatchingCredential
 	}
 
+	redact.Register(authConfig.Password, authConfig.IdentityToken)
 	return AuthConfig{
 		Username:      authConfig.Username,
 		Password:      authConfig.Password,
@@ -0,0 +1,151 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authprovider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+)
+
+// RegistryAuthProviderConfig selects the auth provider of the registries whose
+// host matches Registry.
+type RegistryAuthProviderConfig struct {
+	// Registry is a registry host, e.g. myregistry.azurecr.io or
+	// localhost:5000, or a pattern matching the subdomains of a host, e.g.
+	// *.azurecr.io.
+	Registry string `json:"registry"`
+	// AuthProvider is the configuration of the auth provider of the matching
+	// registries.
+	AuthProvider AuthProviderConfig `json:"authProvider"`
+}
+
+// registryAuthProvider is the auth provider of the registries matching a host
+// pattern.
+type registryAuthProvider struct {
+	registry string
+	provider AuthProvider
+}
+
+// registryRoutingAuthProvider provides the credentials of a registry with the
+// provider of the first registry pattern matching its host, or with the
+// default provider if none matches.
+type registryRoutingAuthProvider struct {
+	registries      []registryAuthProvider
+	defaultProvider AuthProvider
+}
+
+// CreateRegistryAuthProvider creates the AuthProvider selecting, for each
+// registry, the provider of the first configured registry pattern matching
+// its host. Registries matching no pattern use the provider of the default
+// configuration.
+func CreateRegistryAuthProvider(defaultConfig AuthProviderConfig, registryConfigs []RegistryAuthProviderConfig) (AuthProvider, error) {
+	defaultProvider, err := CreateAuthProviderFromConfig(defaultConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(registryConfigs) == 0 {
+		return defaultProvider, nil
+	}
+	registries := make([]registryAuthProvider, 0, len(registryConfigs))
+	for _, registryConfig := range registryConfigs {
+		if err := validateRegistryPattern(registryConfig.Registry); err != nil {
+			return nil, errors.ErrorCodeConfigInvalid.WithError(err).WithComponentType(errors.AuthProvider)
+		}
+		if registryConfig.AuthProvider == nil {
+			return nil, errors.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("auth provider of registry %s must be set", registryConfig.Registry)).WithComponentType(errors.AuthProvider)
+		}
+		provider, err := CreateAuthProviderFromConfig(registryConfig.AuthProvider)
+		if err != nil {
+			return nil, err
+		}
+		registries = append(registries, registryAuthProvider{registry: registryConfig.Registry, provider: provider})
+	}
+	return &registryRoutingAuthProvider{registries: registries, defaultProvider: defaultProvider}, nil
+}
+
+// validateRegistryPattern returns an error if the pattern is neither a
+// registry host nor a wildcard of the subdomains of a host.
+func validateRegistryPattern(pattern string) error {
+	host := strings.TrimPrefix(pattern, "*.")
+	if host == "" || strings.ContainsAny(host, "*/") {
+		return fmt.Errorf("registry must be a registry host or a pattern like *.example.com, got %q", pattern)
+	}
+	return nil
+}
+
+// matchRegistry reports whether the registry host matches the pattern.
+func matchRegistry(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return len(host) > len(suffix) && strings.HasSuffix(strings.ToLower(host), strings.ToLower(suffix))
+	}
+	return strings.EqualFold(pattern, host)
+}
+
+// providerFor returns the auth provider of the registry host.
+func (r *registryRoutingAuthProvider) providerFor(host string) AuthProvider {
+	for _, registry := range r.registries {
+		if matchRegistry(registry.registry, host) {
+			return registry.provider
+		}
+	}
+	return r.defaultProvider
+}
+
+// Enabled returns true if any of the providers is enabled. Registries whose
+// provider is not enabled fail to be provided credentials.
+func (r *registryRoutingAuthProvider) Enabled(ctx context.Context) bool {
+	if r.defaultProvider.Enabled(ctx) {
+		return true
+	}
+	for _, registry := range r.registries {
+		if registry.provider.Enabled(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// Provide returns the credentials of the registry of the artifact from the
+// provider selected for its host.
+func (r *registryRoutingAuthProvider) Provide(ctx context.Context, artifact string) (AuthConfig, error) {
+	host, err := GetRegistryHostName(artifact)
+	if err != nil {
+		return AuthConfig{}, errors.ErrorCodeHostNameInvalid.WithError(err).WithComponentType(errors.AuthProvider)
+	}
+	provider := r.providerFor(host)
+	if !provider.Enabled(ctx) {
+		return AuthConfig{}, errors.ErrorCodeAuthDenied.WithDetail(fmt.Sprintf("auth provider of registry %s is not enabled", host)).WithComponentType(errors.AuthProvider)
+	}
+	logger.GetLogger(ctx, logOpt).Debugf("providing credentials of registry %s with provider %T", host, provider)
+	return provider.Provide(ctx, artifact)
+}
+
+// Validate checks the credentials of all the providers.
+func (r *registryRoutingAuthProvider) Validate(ctx context.Context) error {
+	if err := r.defaultProvider.Validate(ctx); err != nil {
+		return err
+	}
+	for _, registry := range r.registries {
+		if err := registry.provider.Validate(ctx); err != nil {
+			return fmt.Errorf("auth provider of registry %s: %w", registry.registry, err)
+		}
+	}
+	return nil
+}
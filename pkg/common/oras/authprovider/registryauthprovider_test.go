@@ -0,0 +1,134 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authprovider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// stubAuthProvider provides its name as the username of the credentials.
+type stubAuthProvider struct {
+	name     string
+	disabled bool
+}
+
+func (p *stubAuthProvider) Enabled(_ context.Context) bool {
+	return !p.disabled
+}
+
+func (p *stubAuthProvider) Validate(_ context.Context) error {
+	return nil
+}
+
+func (p *stubAuthProvider) Provide(_ context.Context, _ string) (AuthConfig, error) {
+	return AuthConfig{Username: p.name}, nil
+}
+
+type stubAuthProviderFactory struct{}
+
+func (f *stubAuthProviderFactory) Create(authProviderConfig AuthProviderConfig) (AuthProvider, error) {
+	disabled, _ := authProviderConfig["disabled"].(bool)
+	return &stubAuthProvider{name: fmt.Sprintf("%s", authProviderConfig["stub"]), disabled: disabled}, nil
+}
+
+func stubConfig(name string) AuthProviderConfig {
+	return AuthProviderConfig{"name": "stubAuthProvider", "stub": name}
+}
+
+func TestCreateRegistryAuthProvider_RoutesByRegistry(t *testing.T) {
+	builtInAuthProviders = map[string]AuthProviderFactory{
+		"stubAuthProvider": &stubAuthProviderFactory{},
+	}
+	authProvider, err := CreateRegistryAuthProvider(stubConfig("default"), []RegistryAuthProviderConfig{
+		{Registry: "*.azurecr.io", AuthProvider: stubConfig("workloadIdentity")},
+		{Registry: "docker.io", AuthProvider: stubConfig("static")},
+		{Registry: "registry.internal:5000", AuthProvider: stubConfig("basic")},
+	})
+	if err != nil {
+		t.Fatalf("CreateRegistryAuthProvider() error = %v", err)
+	}
+	if !authProvider.Enabled(context.Background()) {
+		t.Fatalf("expected the auth provider to be enabled")
+	}
+	if err := authProvider.Validate(context.Background()); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		artifact string
+		expected string
+	}{
+		{artifact: "myregistry.azurecr.io/app:v1", expected: "workloadIdentity"},
+		{artifact: "MyRegistry.AzureCR.io/app@sha256:6a5a5368e0c2d3e5909184fa28ddfd56072e7ff3ee9a945876f7eee5896ef5bb", expected: "workloadIdentity"},
+		{artifact: "docker.io/library/alpine:3.20", expected: "static"},
+		{artifact: "registry.internal:5000/team/app:v1", expected: "basic"},
+		{artifact: "registry.internal/team/app:v1", expected: "default"},
+		{artifact: "azurecr.io/app:v1", expected: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.artifact, func(t *testing.T) {
+			authConfig, err := authProvider.Provide(context.Background(), tt.artifact)
+			if err != nil {
+				t.Fatalf("Provide() error = %v", err)
+			}
+			if authConfig.Username != tt.expected {
+				t.Fatalf("expected provider %s to provide the credentials, got %s", tt.expected, authConfig.Username)
+			}
+		})
+	}
+}
+
+func TestCreateRegistryAuthProvider_DisabledRegistryProvider(t *testing.T) {
+	builtInAuthProviders = map[string]AuthProviderFactory{
+		"stubAuthProvider": &stubAuthProviderFactory{},
+	}
+	authProvider, err := CreateRegistryAuthProvider(stubConfig("default"), []RegistryAuthProviderConfig{
+		{Registry: "*.azurecr.io", AuthProvider: AuthProviderConfig{"name": "stubAuthProvider", "stub": "disabled", "disabled": true}},
+	})
+	if err != nil {
+		t.Fatalf("CreateRegistryAuthProvider() error = %v", err)
+	}
+	if _, err := authProvider.Provide(context.Background(), "myregistry.azurecr.io/app:v1"); err == nil {
+		t.Fatalf("expected error for a registry whose provider is not enabled")
+	}
+	if authConfig, err := authProvider.Provide(context.Background(), "docker.io/library/alpine:3.20"); err != nil || authConfig.Username != "default" {
+		t.Fatalf("expected the default provider to provide the credentials, got %v, %v", authConfig, err)
+	}
+}
+
+func TestCreateRegistryAuthProvider_InvalidConfig(t *testing.T) {
+	builtInAuthProviders = map[string]AuthProviderFactory{
+		"stubAuthProvider": &stubAuthProviderFactory{},
+	}
+	tests := []struct {
+		name       string
+		registries []RegistryAuthProviderConfig
+	}{
+		{name: "empty registry", registries: []RegistryAuthProviderConfig{{AuthProvider: stubConfig("stub")}}},
+		{name: "invalid pattern", registries: []RegistryAuthProviderConfig{{Registry: "*.azurecr.io/app", AuthProvider: stubConfig("stub")}}},
+		{name: "missing auth provider", registries: []RegistryAuthProviderConfig{{Registry: "docker.io"}}},
+		{name: "unknown auth provider", registries: []RegistryAuthProviderConfig{{Registry: "docker.io", AuthProvider: AuthProviderConfig{"name": "unknown"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateRegistryAuthProvider(stubConfig("default"), tt.registries); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authprovider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidCredentials is wrapped by the errors of components, e.g. stores,
+// failing to be created because the credentials of their auth provider failed
+// validation.
+var ErrInvalidCredentials = errors.New("auth provider credentials are invalid")
+
+// validations holds the failed credential validation of the auth provider of
+// each cluster-scoped resource by name.
+// layout:
+//
+//	map["<resource name>"] = error
+var validations sync.Map
+
+// RecordValidation records the result of creating the named cluster-scoped
+// resource, e.g. a store. Only failures wrapping ErrInvalidCredentials are
+// recorded. Namespaced resources must not be recorded so that the credentials
+// of a single namespace cannot make Ratify unready.
+func RecordValidation(resource string, err error) {
+	if !errors.Is(err, ErrInvalidCredentials) {
+		validations.Delete(resource)
+		return
+	}
+	validations.Store(resource, err)
+}
+
+// ReadyzCheck is a readiness check failing while the credentials of an auth
+// provider failed their last validation.
+func ReadyzCheck(_ *http.Request) error {
+	var failures []string
+	validations.Range(func(name, err any) bool {
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		return true
+	})
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Strings(failures)
+	return fmt.Errorf("credential validation failed: %s", strings.Join(failures, "; "))
+}
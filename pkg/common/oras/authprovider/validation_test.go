@@ -0,0 +1,48 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authprovider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadyzCheck(t *testing.T) {
+	t.Cleanup(func() {
+		RecordValidation("store-a", nil)
+		RecordValidation("store-b", nil)
+	})
+	if err := ReadyzCheck(nil); err != nil {
+		t.Fatalf("expected ready without validations, got %v", err)
+	}
+
+	RecordValidation("store-a", errors.New("invalid store configuration"))
+	RecordValidation("store-b", fmt.Errorf("%w: %w", ErrInvalidCredentials, errors.New("token acquisition failed")))
+	err := ReadyzCheck(nil)
+	if err == nil || !strings.Contains(err.Error(), "store-b: auth provider credentials are invalid: token acquisition failed") {
+		t.Fatalf("expected not ready for failed validation of store-b, got %v", err)
+	}
+	if strings.Contains(err.Error(), "store-a") {
+		t.Fatalf("expected failure of store-a other than validation not to be reported, got %v", err)
+	}
+
+	RecordValidation("store-b", nil)
+	if err := ReadyzCheck(nil); err != nil {
+		t.Fatalf("expected ready after successful validation, got %v", err)
+	}
+}
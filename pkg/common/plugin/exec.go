@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -34,12 +35,48 @@ import (
 const (
 	maxRetryCount = 5
 	waitDuration  = time.Second
+
+	// DefaultTimeout is the execution timeout of a plugin if none is configured.
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxOutputBytes is the maximum number of bytes a plugin may write to
+	// stdout or stderr if no limit is configured.
+	DefaultMaxOutputBytes int64 = 10 * 1024 * 1024
+	// killWaitDelay bounds the wait for the output of child processes of a
+	// killed plugin.
+	killWaitDelay = time.Second
+)
+
+var (
+	// ErrTimeout is returned when a plugin does not exit within its timeout.
+	ErrTimeout = errors.New("plugin execution timed out")
+	// ErrOutputLimitExceeded is returned when a plugin writes more output than
+	// allowed.
+	ErrOutputLimitExceeded = errors.New("plugin output exceeded the size limit")
 )
 
 var logOpt = logger.Option{
 	ComponentType: logger.Plugin,
 }
 
+// CrashError describes a plugin process that exited abnormally, e.g. by
+// panicking or exiting with a non-zero code, instead of returning a result.
+type CrashError struct {
+	Err error
+	// ExitCode is the exit code of the plugin process, or -1 if it did not
+	// exit on its own.
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+func (e *CrashError) Error() string {
+	return fmt.Sprintf("plugin failed with error: '%v', msg from stError '%v', msg from stdOut '%v'", e.Err, e.Stderr, e.Stdout)
+}
+
+func (e *CrashError) Unwrap() error {
+	return e.Err
+}
+
 // Executor is an interface that defines methods to lookup a plugin and execute it.
 type Executor interface {
 	// ExecutePlugin executes the plugin with the given parameters
@@ -51,17 +88,57 @@ type Executor interface {
 // DefaultExecutor finds the plugin executable and invokes it as a os command
 type DefaultExecutor struct {
 	Stderr io.Writer
+	// Timeout is the execution timeout of the plugin, DefaultTimeout if zero.
+	Timeout time.Duration
+	// MaxOutputBytes is the maximum number of bytes the plugin may write to
+	// stdout or stderr, DefaultMaxOutputBytes if zero.
+	MaxOutputBytes int64
+}
+
+// limitedBuffer is a buffer that stops the plugin once more than limit bytes
+// are written to it. It does not embed bytes.Buffer so that io.Copy cannot
+// bypass the limit through ReadFrom.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+	kill     context.CancelFunc
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.exceeded {
+		return len(p), nil
+	}
+	if remaining := b.limit - int64(b.buf.Len()); int64(len(p)) > remaining {
+		b.buf.Write(p[:remaining])
+		b.exceeded = true
+		b.kill()
+		return len(p), nil
+	}
+	return b.buf.Write(p)
 }
 
 // return the command output and the error
 func (e *DefaultExecutor) ExecutePlugin(ctx context.Context, pluginPath string, cmdArgs []string, stdinData []byte, environ []string) ([]byte, error) {
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-	c := exec.CommandContext(ctx, pluginPath, cmdArgs...)
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	maxOutputBytes := e.MaxOutputBytes
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = DefaultMaxOutputBytes
+	}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: maxOutputBytes, kill: cancel}
+	stderr := &limitedBuffer{limit: maxOutputBytes, kill: cancel}
+	c := exec.CommandContext(execCtx, pluginPath, cmdArgs...)
 	c.Env = environ
 	c.Stdin = bytes.NewBuffer(stdinData)
 	c.Stdout = stdout
 	c.Stderr = stderr
+	c.WaitDelay = killWaitDelay
 
 	// DEBUG: log the process details used to launch the binary plugin
 	if logrus.IsLevelEnabled(logrus.DebugLevel) {
@@ -96,11 +173,22 @@ func (e *DefaultExecutor) ExecutePlugin(ctx context.Context, pluginPath string,
 			continue
 		}
 
+		// The plugin was killed for exceeding its limits.
+		if stdout.exceeded || stderr.exceeded {
+			return nil, fmt.Errorf("%w: plugin %s wrote more than %d bytes", ErrOutputLimitExceeded, pluginPath, maxOutputBytes)
+		}
+		if ctx.Err() == nil && errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: plugin %s did not exit within %s", ErrTimeout, pluginPath, timeout)
+		}
+
 		// For all other errors return failed.
-		return nil, e.pluginErr(err, stdout.Bytes(), stderr.Bytes())
+		return nil, e.pluginErr(err, stdout.buf.Bytes(), stderr.buf.Bytes())
+	}
+	if stdout.exceeded || stderr.exceeded {
+		return nil, fmt.Errorf("%w: plugin %s wrote more than %d bytes", ErrOutputLimitExceeded, pluginPath, maxOutputBytes)
 	}
 
-	pluginOutputJSON, pluginOutputMsgs := parsePluginOutput(stdout, stderr)
+	pluginOutputJSON, pluginOutputMsgs := parsePluginOutput(&stdout.buf, &stderr.buf)
 
 	// Disregards plugin source stream and logs the plugin messages to stderr
 	for _, msg := range pluginOutputMsgs {
@@ -131,9 +219,17 @@ func (e *DefaultExecutor) pluginErr(err error, stdout, stderr []byte) error {
 	stdOutBuffer.Write(stdout)
 	stdErrBuffer.Write(stderr)
 
-	errCombined := Error{}
-	errCombined.Msg = fmt.Sprintf("plugin failed with error: '%v', msg from stError '%v', msg from stdOut '%v'", err.Error(), stdErrBuffer.String(), stdOutBuffer.String())
-	return &errCombined
+	exitCode := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+	return &CrashError{
+		Err:      err,
+		ExitCode: exitCode,
+		Stdout:   stdOutBuffer.String(),
+		Stderr:   stdErrBuffer.String(),
+	}
 }
 
 func (e *DefaultExecutor) FindInPaths(plugin string, paths []string) (string, error) {
@@ -17,8 +17,14 @@ package plugin
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestPluginErr(t *testing.T) {
@@ -107,3 +113,74 @@ func TestParsePluginOutput_InvalidJson(t *testing.T) {
 		t.Fatalf("unexpected json, expected empty, got '%s'", json)
 	}
 }
+
+func writeScriptPlugin(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "test-plugin")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0700); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return path
+}
+
+func TestExecutePlugin_Timeout(t *testing.T) {
+	pluginPath := writeScriptPlugin(t, "sleep 10\n")
+	e := DefaultExecutor{Timeout: 200 * time.Millisecond}
+
+	start := time.Now()
+	_, err := e.ExecutePlugin(context.Background(), pluginPath, nil, nil, nil)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected plugin to be killed after the timeout, took %v", elapsed)
+	}
+}
+
+func TestExecutePlugin_OutputLimit(t *testing.T) {
+	testCases := []struct {
+		name   string
+		script string
+	}{
+		{
+			name:   "oversized stdout",
+			script: "head -c 4096 /dev/zero\n",
+		},
+		{
+			name:   "oversized stderr",
+			script: "head -c 4096 /dev/zero >&2\n",
+		},
+		{
+			name:   "endless stdout",
+			script: "yes\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pluginPath := writeScriptPlugin(t, tc.script)
+			e := DefaultExecutor{MaxOutputBytes: 1024}
+
+			_, err := e.ExecutePlugin(context.Background(), pluginPath, nil, nil, nil)
+			if !errors.Is(err, ErrOutputLimitExceeded) {
+				t.Fatalf("expected output limit error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExecutePlugin_WithinLimits(t *testing.T) {
+	pluginPath := writeScriptPlugin(t, "echo '{\"isSuccess\":true}'\n")
+	e := DefaultExecutor{Timeout: 5 * time.Second, MaxOutputBytes: 1024}
+
+	output, err := e.ExecutePlugin(context.Background(), pluginPath, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("expected plugin to succeed, got %v", err)
+	}
+	if string(output) != `{"isSuccess":true}` {
+		t.Fatalf("unexpected plugin output %s", output)
+	}
+}
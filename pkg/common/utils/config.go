@@ -0,0 +1,238 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// FieldError describes a configuration field whose value does not have the
+// type the configuration expects.
+type FieldError struct {
+	// Path is the JSON path of the field, e.g. trustPolicyDoc.trustPolicies[0].name.
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: expected %s, got %s", e.Path, e.Expected, e.Actual)
+}
+
+// FieldErrors aggregates the errors of all the malformed fields of a
+// configuration.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// DecodeConfig decodes the configuration, e.g. the map of a verifier or auth
+// provider configuration, into the target struct through its JSON encoding.
+// Unlike json.Unmarshal, which reports a single type error without its
+// location, it returns FieldErrors listing every field whose value has an
+// unexpected type along with its path.
+func DecodeConfig(config interface{}, target interface{}) error {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(configBytes))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	var fieldErrs FieldErrors
+	checkConfigValue(value, reflect.TypeOf(target), "", &fieldErrs)
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+
+	return json.Unmarshal(configBytes, target)
+}
+
+// checkConfigValue appends an error for every value nested in the decoded JSON
+// value that cannot be unmarshalled into the corresponding field of the type.
+func checkConfigValue(value interface{}, t reflect.Type, path string, fieldErrs *FieldErrors) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil || t.Kind() == reflect.Interface || hasCustomUnmarshaler(t) {
+		return
+	}
+
+	mismatch := func(expected string) {
+		*fieldErrs = append(*fieldErrs, FieldError{Path: configPath(path), Expected: expected, Actual: jsonTypeName(value)})
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			mismatch("object")
+			return
+		}
+		checkConfigObject(object, t, path, fieldErrs)
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			mismatch("object")
+			return
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			checkConfigValue(object[key], t.Elem(), joinConfigPath(path, key), fieldErrs)
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if _, ok := value.(string); !ok {
+				mismatch("base64 string")
+			}
+			return
+		}
+		array, ok := value.([]interface{})
+		if !ok {
+			mismatch("array")
+			return
+		}
+		for i, element := range array {
+			checkConfigValue(element, t.Elem(), fmt.Sprintf("%s[%d]", path, i), fieldErrs)
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			mismatch("string")
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			mismatch("boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if number, ok := value.(json.Number); !ok {
+			mismatch("integer")
+		} else if _, err := strconv.ParseInt(number.String(), 10, t.Bits()); err != nil {
+			mismatch("integer")
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if number, ok := value.(json.Number); !ok {
+			mismatch("unsigned integer")
+		} else if _, err := strconv.ParseUint(number.String(), 10, t.Bits()); err != nil {
+			mismatch("unsigned integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(json.Number); !ok {
+			mismatch("number")
+		}
+	}
+}
+
+// checkConfigObject checks the members of the JSON object that match a field
+// of the struct type, following the field matching rules of encoding/json.
+func checkConfigObject(object map[string]interface{}, t reflect.Type, path string, fieldErrs *FieldErrors) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				checkConfigObject(object, embedded, path, fieldErrs)
+				continue
+			}
+		}
+		if !field.IsExported() || strings.Contains(options, "string") {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		value, ok := object[name]
+		if !ok {
+			for key, member := range object {
+				if strings.EqualFold(key, name) {
+					name, value, ok = key, member, true
+					break
+				}
+			}
+		}
+		if ok {
+			checkConfigValue(value, field.Type, joinConfigPath(path, name), fieldErrs)
+		}
+	}
+}
+
+func hasCustomUnmarshaler(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
+
+func joinConfigPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func configPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+// jsonTypeName returns the JSON type of a decoded value.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	}
+	return "null"
+}
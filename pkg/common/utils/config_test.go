@@ -0,0 +1,169 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testNestedConfig struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled,omitempty"`
+}
+
+type testEmbeddedConfig struct {
+	Timeout int `json:"timeout,omitempty"`
+}
+
+type testConfig struct {
+	testEmbeddedConfig
+	Name          string                      `json:"name"`
+	ArtifactTypes []string                    `json:"artifactTypes,omitempty"`
+	Threshold     float64                     `json:"threshold,omitempty"`
+	Policies      []testNestedConfig          `json:"policies,omitempty"`
+	Stores        map[string][]string         `json:"stores,omitempty"`
+	Nested        *testNestedConfig           `json:"nested,omitempty"`
+	Identities    map[string]testNestedConfig `json:"identities,omitempty"`
+	Expiry        time.Time                   `json:"expiry,omitempty"`
+	Extra         interface{}                 `json:"extra,omitempty"`
+}
+
+func TestDecodeConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       map[string]interface{}
+		expectErrors FieldErrors
+		expect       testConfig
+	}{
+		{
+			name: "valid config",
+			config: map[string]interface{}{
+				"name":          "test",
+				"artifactTypes": []string{"application/spdx+json"},
+				"threshold":     0.5,
+				"timeout":       10,
+				"policies":      []map[string]interface{}{{"name": "default", "enabled": true}},
+				"Stores":        map[string][]string{"ca": {"certs"}},
+				"extra":         []int{1},
+			},
+			expect: testConfig{
+				testEmbeddedConfig: testEmbeddedConfig{Timeout: 10},
+				Name:               "test",
+				ArtifactTypes:      []string{"application/spdx+json"},
+				Threshold:          0.5,
+				Policies:           []testNestedConfig{{Name: "default", Enabled: true}},
+				Stores:             map[string][]string{"ca": {"certs"}},
+				Extra:              []interface{}{float64(1)},
+			},
+		},
+		{
+			name: "string instead of list",
+			config: map[string]interface{}{
+				"name":          "test",
+				"artifactTypes": "application/spdx+json",
+			},
+			expectErrors: FieldErrors{
+				{Path: "artifactTypes", Expected: "array", Actual: "string"},
+			},
+		},
+		{
+			name: "nested malformed fields",
+			config: map[string]interface{}{
+				"name":     []string{"test"},
+				"timeout":  "10s",
+				"policies": []interface{}{map[string]interface{}{"name": "default"}, map[string]interface{}{"enabled": "yes"}},
+				"nested":   "default",
+			},
+			expectErrors: FieldErrors{
+				{Path: "timeout", Expected: "integer", Actual: "string"},
+				{Path: "name", Expected: "string", Actual: "array"},
+				{Path: "policies[1].enabled", Expected: "boolean", Actual: "string"},
+				{Path: "nested", Expected: "object", Actual: "string"},
+			},
+		},
+		{
+			name: "malformed map values",
+			config: map[string]interface{}{
+				"stores":     map[string]interface{}{"ca": []interface{}{"certs", 1}},
+				"identities": map[string]interface{}{"registry.io": map[string]interface{}{"name": true}},
+				"threshold":  "high",
+			},
+			expectErrors: FieldErrors{
+				{Path: "threshold", Expected: "number", Actual: "string"},
+				{Path: "stores.ca[1]", Expected: "string", Actual: "number"},
+				{Path: "identities.registry.io.name", Expected: "string", Actual: "boolean"},
+			},
+		},
+		{
+			name: "fractional integer",
+			config: map[string]interface{}{
+				"timeout": 1.5,
+			},
+			expectErrors: FieldErrors{
+				{Path: "timeout", Expected: "integer", Actual: "number"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conf testConfig
+			err := DecodeConfig(tt.config, &conf)
+			if tt.expectErrors == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if !reflect.DeepEqual(conf, tt.expect) {
+					t.Fatalf("expected config %+v, got %+v", tt.expect, conf)
+				}
+				return
+			}
+			var fieldErrs FieldErrors
+			if !errors.As(err, &fieldErrs) {
+				t.Fatalf("expected field errors, got %v", err)
+			}
+			if !reflect.DeepEqual(fieldErrs, tt.expectErrors) {
+				t.Fatalf("expected errors %v, got %v", tt.expectErrors, fieldErrs)
+			}
+		})
+	}
+}
+
+func TestDecodeConfig_InvalidExpiry(t *testing.T) {
+	// fields with custom unmarshalers are left to encoding/json
+	var conf testConfig
+	err := DecodeConfig(map[string]interface{}{"expiry": "tomorrow"}, &conf)
+	if err == nil {
+		t.Fatalf("expected error for an invalid time")
+	}
+	var fieldErrs FieldErrors
+	if errors.As(err, &fieldErrs) {
+		t.Fatalf("expected an encoding/json error, got %v", err)
+	}
+}
+
+func TestFieldErrors_Error(t *testing.T) {
+	err := FieldErrors{
+		{Path: "artifactTypes", Expected: "array", Actual: "string"},
+		{Path: "policies[0].name", Expected: "string", Actual: "number"},
+	}
+	expected := "artifactTypes: expected array, got string; policies[0].name: expected string, got number"
+	if err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+}
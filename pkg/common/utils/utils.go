@@ -21,16 +21,23 @@ import (
 )
 
 func OciManifestToReferenceManifest(ociManifest oci.Manifest) ocispecs.ReferenceManifest {
+	// artifacts packaged without a config, or with the empty config, are
+	// typed by the artifact type of the manifest
 	artifactType := ociManifest.Config.MediaType
-	if artifactType == oci.DescriptorEmptyJSON.MediaType {
+	if artifactType == "" || artifactType == oci.DescriptorEmptyJSON.MediaType {
 		artifactType = ociManifest.ArtifactType
 	}
 
-	return ocispecs.ReferenceManifest{
+	referenceManifest := ocispecs.ReferenceManifest{
 		MediaType:    ociManifest.MediaType,
 		ArtifactType: artifactType,
 		Blobs:        ociManifest.Layers,
 		Subject:      ociManifest.Subject,
 		Annotations:  ociManifest.Annotations,
 	}
+	if ociManifest.Config.Digest != "" {
+		config := ociManifest.Config
+		referenceManifest.Config = &config
+	}
+	return referenceManifest
 }
@@ -75,6 +75,41 @@ func TestOciManifestToReferenceManifest(t *testing.T) {
 				ArtifactType: TestArtifactType,
 			},
 		},
+		{
+			name: "absent config",
+			args: args{
+				ociManifest: oci.Manifest{
+					MediaType:    "application/vnd.oci.image.manifest.v1+json",
+					ArtifactType: TestArtifactType,
+				},
+			},
+			want: ocispecs.ReferenceManifest{
+				MediaType:    "application/vnd.oci.image.manifest.v1+json",
+				ArtifactType: TestArtifactType,
+			},
+		},
+		{
+			name: "image config",
+			args: args{
+				ociManifest: oci.Manifest{
+					MediaType: "application/vnd.oci.image.manifest.v1+json",
+					Config: oci.Descriptor{
+						MediaType: oci.MediaTypeImageConfig,
+						Digest:    "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+						Size:      2,
+					},
+				},
+			},
+			want: ocispecs.ReferenceManifest{
+				MediaType:    "application/vnd.oci.image.manifest.v1+json",
+				ArtifactType: oci.MediaTypeImageConfig,
+				Config: &oci.Descriptor{
+					MediaType: oci.MediaTypeImageConfig,
+					Digest:    "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+					Size:      2,
+				},
+			},
+		},
 		{
 			name: "layers",
 			args: args{
@@ -27,6 +27,7 @@ import (
 	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
 	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/azurekeyvault" // register azure key vault key management provider
 	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/inline"        // register inline key management provider
+	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/kubernetes"    // register kubernetes key management provider
 	"github.com/ratify-project/ratify/pkg/keymanagementprovider/refresh"
 	"github.com/sirupsen/logrus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -90,12 +91,15 @@ func (r *KeyManagementProviderReconciler) ReconcileWithType(ctx context.Context,
 		return ctrl.Result{}, kmpErr
 	}
 
+	kmp.SetProvider(resource, provider)
+
 	refresherConfig := refresh.RefresherConfig{
 		RefresherType:           refresherType,
 		Provider:                provider,
 		ProviderType:            keyManagementProvider.Spec.Type,
 		ProviderRefreshInterval: keyManagementProvider.Spec.RefreshInterval,
 		Resource:                resource,
+		ResourceVersion:         keyManagementProvider.ResourceVersion,
 	}
 
 	refresher, err := refresh.CreateRefresherFromConfig(refresherConfig)
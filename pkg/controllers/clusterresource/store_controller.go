@@ -26,6 +26,7 @@ import (
 	configv1beta1 "github.com/ratify-project/ratify/api/v1beta1"
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/constants"
+	"github.com/ratify-project/ratify/pkg/common/oras/authprovider"
 	"github.com/ratify-project/ratify/pkg/controllers"
 	"github.com/ratify-project/ratify/pkg/controllers/utils"
 	"github.com/sirupsen/logrus"
@@ -57,6 +58,7 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		if apierrors.IsNotFound(err) {
 			storeLogger.Infof("deletion detected, removing store %v", req.Name)
 			controllers.NamespacedStores.DeleteStore(constants.EmptyNamespace, resource)
+			authprovider.RecordValidation(resource, nil)
 		} else {
 			storeLogger.Error(err, "unable to fetch store")
 		}
@@ -64,7 +66,9 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	if err := storeAddOrReplace(store.Spec, resource); err != nil {
+	err := storeAddOrReplace(store.Spec, resource)
+	authprovider.RecordValidation(resource, err)
+	if err != nil {
 		storeErr := re.ErrorCodeReferrerStoreFailure.WithError(err).WithDetail("Unable to create store from store CR")
 		storeLogger.Error(err)
 		writeStoreStatus(ctx, r, &store, storeLogger, false, &storeErr)
@@ -22,6 +22,10 @@ type PolicyManager interface {
 	// GetPolicy returns the policy for the given scope.
 	GetPolicy(scope string) policyprovider.PolicyProvider
 
+	// GetPolicyScope returns the scope of the policy returned by GetPolicy for
+	// the given scope, and false if there is no such policy.
+	GetPolicyScope(scope string) (string, bool)
+
 	// AddPolicy adds the given policy under the given scope.
 	AddPolicy(scope, policyName string, policy policyprovider.PolicyProvider)
 
@@ -54,6 +54,18 @@ func (p *ActivePolicies) GetPolicy(scope string) policyprovider.PolicyProvider {
 	return nil
 }
 
+// GetPolicyScope fulfills the PolicyManager interface.
+// It returns the given scope if a policy is added under it, otherwise the cluster-wide scope if a cluster-wide policy is added.
+func (p *ActivePolicies) GetPolicyScope(scope string) (string, bool) {
+	if _, ok := p.scopedPolicies.Load(scope); ok {
+		return scope, true
+	}
+	if _, ok := p.scopedPolicies.Load(constants.EmptyNamespace); ok {
+		return constants.EmptyNamespace, true
+	}
+	return "", false
+}
+
 // AddPolicy fulfills the PolicyManager interface.
 // It adds the given policy under the given scope.
 func (p *ActivePolicies) AddPolicy(scope, policyName string, policy policyprovider.PolicyProvider) {
@@ -73,15 +73,27 @@ func TestPoliciesOperations(t *testing.T) {
 		t.Errorf("Expected policy2 to be returned")
 	}
 
+	if scope, ok := policies.GetPolicyScope(namespace2); !ok || scope != namespace2 {
+		t.Errorf("Expected the scope of policy2 to be returned, got %q", scope)
+	}
+
 	policies.DeletePolicy(namespace2, name1)
 
 	if policies.GetPolicy(namespace2) != policy1 {
 		t.Errorf("Expected policy1 to be returned")
 	}
 
+	if scope, ok := policies.GetPolicyScope(namespace2); !ok || scope != namespace1 {
+		t.Errorf("Expected the scope of policy1 to be returned, got %q", scope)
+	}
+
 	policies.DeletePolicy(namespace1, name1)
 
 	if policies.GetPolicy(namespace1) != nil {
 		t.Errorf("Expected no policy to be returned")
 	}
+
+	if _, ok := policies.GetPolicyScope(namespace2); ok {
+		t.Errorf("Expected no policy scope to be returned")
+	}
 }
@@ -62,16 +62,25 @@ func (s *ActiveStores) GetStores(scope string) []referrerstore.ReferrerStore {
 }
 
 // AddStore fulfills the ReferrerStoreManager interface.
-// It adds the given store under the given scope.
+// It adds the given store under the given scope and closes the store it
+// replaces.
 func (s *ActiveStores) AddStore(scope, storeName string, store referrerstore.ReferrerStore) {
 	scopedStore, _ := s.ScopedStores.LoadOrStore(scope, make(map[string]referrerstore.ReferrerStore))
-	scopedStore.(map[string]referrerstore.ReferrerStore)[storeName] = store
+	stores := scopedStore.(map[string]referrerstore.ReferrerStore)
+	if previous, ok := stores[storeName]; ok && previous != store {
+		referrerstore.Close(previous)
+	}
+	stores[storeName] = store
 }
 
 // DeleteStore fulfills the ReferrerStoreManager interface.
-// It deletes the store with the given name under the given scope.
+// It deletes and closes the store with the given name under the given scope.
 func (s *ActiveStores) DeleteStore(scope, storeName string) {
 	if scopedStore, ok := s.ScopedStores.Load(scope); ok {
-		delete(scopedStore.(map[string]referrerstore.ReferrerStore), storeName)
+		stores := scopedStore.(map[string]referrerstore.ReferrerStore)
+		if store, ok := stores[storeName]; ok {
+			referrerstore.Close(store)
+		}
+		delete(stores, storeName)
 	}
 }
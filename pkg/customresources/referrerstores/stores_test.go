@@ -101,3 +101,33 @@ func TestStoresOperations(t *testing.T) {
 		t.Fatalf("Expected 0 stores in namespace %s, got %d", namespace1, len(stores.GetStores(namespace1)))
 	}
 }
+
+type closingStore struct {
+	mockStore
+	closed bool
+}
+
+func (s *closingStore) Close() {
+	s.closed = true
+}
+
+func TestStoresClose(t *testing.T) {
+	stores := NewActiveStores()
+	replaced := &closingStore{mockStore: store1}
+	stores.AddStore(namespace2, name1, replaced)
+	stores.AddStore(namespace2, name1, replaced)
+	if replaced.closed {
+		t.Fatalf("expected a store added again not to be closed")
+	}
+
+	current := &closingStore{mockStore: store1}
+	stores.AddStore(namespace2, name1, current)
+	if !replaced.closed {
+		t.Fatalf("expected the replaced store to be closed")
+	}
+
+	stores.DeleteStore(namespace2, name1)
+	if !current.closed {
+		t.Fatalf("expected the deleted store to be closed")
+	}
+}
@@ -66,13 +66,26 @@ func (v *ActiveVerifiers) GetVerifiers(scope string) []vr.ReferenceVerifier {
 // It adds the given verifier under the given scope.
 func (v *ActiveVerifiers) AddVerifier(scope, verifierName string, verifier vr.ReferenceVerifier) {
 	scopedVerifier, _ := v.scopedVerifiers.LoadOrStore(scope, make(map[string]vr.ReferenceVerifier))
-	scopedVerifier.(map[string]vr.ReferenceVerifier)[verifierName] = verifier
+	verifiers := scopedVerifier.(map[string]vr.ReferenceVerifier)
+	if previous, ok := verifiers[verifierName]; ok {
+		// the same verifier may be added again, keep its hash
+		hash := vr.ConfigHash(verifier)
+		vr.DeleteConfigHash(previous)
+		if hash != "" {
+			vr.SetConfigHash(verifier, hash)
+		}
+	}
+	verifiers[verifierName] = verifier
 }
 
 // DeleteVerifier fulfills the VerifierManager interface.
 // It deletes the verfier of the given name under the given scope.
 func (v *ActiveVerifiers) DeleteVerifier(scope, verifierName string) {
 	if scopedVerifier, ok := v.scopedVerifiers.Load(scope); ok {
-		delete(scopedVerifier.(map[string]vr.ReferenceVerifier), verifierName)
+		verifiers := scopedVerifier.(map[string]vr.ReferenceVerifier)
+		if verifier, ok := verifiers[verifierName]; ok {
+			vr.DeleteConfigHash(verifier)
+		}
+		delete(verifiers, verifierName)
 	}
 }
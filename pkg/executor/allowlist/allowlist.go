@@ -0,0 +1,320 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package allowlist consults external feeds of approved subject digests.
+// Subjects whose digest is approved are allowed without verification.
+package allowlist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// SourceTypeHTTP fetches the allowlist from an HTTP feed.
+	SourceTypeHTTP = "http"
+
+	// OnFetchFailureStale keeps using the last fetched allowlist when the feed
+	// cannot be fetched.
+	OnFetchFailureStale = "stale"
+	// OnFetchFailureFail stops using the allowlist when the feed cannot be
+	// fetched, so that subjects are verified as usual.
+	OnFetchFailureFail = "fail"
+
+	defaultRefreshInterval = 5 * time.Minute
+	defaultTimeout         = 5 * time.Second
+	maxFeedSize            = 16 << 20
+)
+
+// Config describes the source of the allowlist.
+type Config struct {
+	// Type is the type of the source. Defaults to http.
+	Type string `json:"type,omitempty"`
+	// URL is the https endpoint of the feed, a JSON document of the form
+	// {"digests": ["sha256:..."]}. Plain http is rejected, as whoever can
+	// tamper with the feed can skip the verification of any subject.
+	URL string `json:"url"`
+	// RefreshInterval is how long a fetched allowlist is used before the feed
+	// is fetched again, e.g. 1m. Defaults to 5m.
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+	// OnFetchFailure is stale or fail and decides whether the last fetched
+	// allowlist is used or subjects are verified as usual when the feed cannot
+	// be fetched. Defaults to stale.
+	OnFetchFailure string `json:"onFetchFailure,omitempty"`
+	// Timeout bounds each fetch of the feed, e.g. 5s. Defaults to 5s.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Source reports whether subject digests are approved.
+type Source interface {
+	// Contains reports whether the digest is in the allowlist.
+	Contains(ctx context.Context, digest digest.Digest) (bool, error)
+}
+
+// CreateSource creates a Source from its configuration.
+type CreateSource func(conf Config) (Source, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]CreateSource{
+		SourceTypeHTTP: func(conf Config) (Source, error) {
+			return NewFeed(conf)
+		},
+	}
+
+	sharedMu sync.Mutex
+	shared   Source
+	// sharedConf is the configuration the shared source was created from.
+	sharedConf Config
+)
+
+// Register registers the factory of a source type.
+func Register(sourceType string, create CreateSource) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[sourceType] = create
+}
+
+// Validate returns an error if the allowlist configuration is invalid.
+func (c *Config) Validate() error {
+	_, err := New(*c)
+	return err
+}
+
+// New creates the Source of the configured type.
+func New(conf Config) (Source, error) {
+	sourceType := conf.Type
+	if sourceType == "" {
+		sourceType = SourceTypeHTTP
+	}
+	factoriesMu.RLock()
+	create, ok := factories[sourceType]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported allowlist type %s", sourceType)
+	}
+	return create(conf)
+}
+
+// Shared returns the source of the configuration shared by all verifications,
+// so that the fetched allowlist is reused across requests. A new source is
+// created once the configuration changes.
+func Shared(conf Config) (Source, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if shared != nil && sharedConf == conf {
+		return shared, nil
+	}
+	source, err := New(conf)
+	if err != nil {
+		return nil, err
+	}
+	shared, sharedConf = source, conf
+	return shared, nil
+}
+
+// feedDocument is the JSON document served by the feed.
+type feedDocument struct {
+	Digests []string `json:"digests"`
+}
+
+// Feed is a Source fetching the allowlist from an HTTPS feed. The fetched
+// allowlist is cached for the refresh interval and fetched again with the
+// ETag of the cached one, so that an unchanged feed is not downloaded again.
+// Expired allowlists are refreshed in the background while the cached one is
+// used, verifications only wait for the feed if no allowlist may be used.
+type Feed struct {
+	url             string
+	refreshInterval time.Duration
+	useStale        bool
+	client          *http.Client
+	now             func() time.Time
+
+	mu         sync.Mutex
+	digests    map[digest.Digest]struct{}
+	etag       string
+	fetched    bool
+	fetchedAt  time.Time
+	refreshing *refreshCall
+}
+
+// refreshCall is a fetch of the feed in progress.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewFeed creates a Feed from its configuration. The feed is fetched on first
+// use.
+func NewFeed(conf Config) (*Feed, error) {
+	parsedURL, err := url.Parse(conf.URL)
+	if err != nil || parsedURL.Scheme != "https" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("allowlist url must be an https URL, got %q", conf.URL)
+	}
+	refreshInterval, err := parsePositiveDuration("refreshInterval", conf.RefreshInterval, defaultRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parsePositiveDuration("timeout", conf.Timeout, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	switch conf.OnFetchFailure {
+	case "", OnFetchFailureStale, OnFetchFailureFail:
+	default:
+		return nil, fmt.Errorf("allowlist onFetchFailure must be %s or %s, got %s", OnFetchFailureStale, OnFetchFailureFail, conf.OnFetchFailure)
+	}
+	return &Feed{
+		url:             conf.URL,
+		refreshInterval: refreshInterval,
+		useStale:        conf.OnFetchFailure != OnFetchFailureFail,
+		client:          &http.Client{Timeout: timeout},
+		now:             time.Now,
+	}, nil
+}
+
+func parsePositiveDuration(name, value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid allowlist %s %s: %w", name, value, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("allowlist %s must be positive, got %s", name, value)
+	}
+	return duration, nil
+}
+
+// Contains reports whether the digest is in the allowlist. Once the cached
+// allowlist is older than the refresh interval, the feed is fetched in the
+// background and the cached allowlist is used meanwhile when configured to use
+// stale allowlists. Otherwise, and before the feed was first fetched, Contains
+// waits for the fetch and returns its error if it fails.
+func (f *Feed) Contains(ctx context.Context, subjectDigest digest.Digest) (bool, error) {
+	f.mu.Lock()
+	if f.fetched && f.now().Sub(f.fetchedAt) < f.refreshInterval {
+		_, ok := f.digests[subjectDigest]
+		f.mu.Unlock()
+		return ok, nil
+	}
+	call := f.startRefresh()
+	if f.fetched && f.useStale {
+		_, ok := f.digests[subjectDigest]
+		f.mu.Unlock()
+		return ok, nil
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	if call.err != nil {
+		return false, call.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.digests[subjectDigest]
+	return ok, nil
+}
+
+// startRefresh starts fetching the feed unless a fetch is in progress and
+// returns the fetch. f.mu must be held.
+func (f *Feed) startRefresh() *refreshCall {
+	if f.refreshing != nil {
+		return f.refreshing
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	f.refreshing = call
+	etag := f.etag
+	go func() {
+		// the fetch is shared by the verifications waiting for it, so it is
+		// bounded by the timeout of the client rather than by their contexts
+		digests, etag, err := f.fetch(context.Background(), etag)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case err != nil:
+			if f.useStale && f.fetched {
+				// use the stale allowlist until the feed is fetched again
+				// after the refresh interval
+				f.fetchedAt = f.now()
+			}
+		case digests == nil && !f.fetched:
+			err = fmt.Errorf("allowlist %s reported not modified before it was fetched", f.url)
+		case digests == nil:
+			f.fetchedAt = f.now()
+		default:
+			f.digests, f.etag = digests, etag
+			f.fetched = true
+			f.fetchedAt = f.now()
+		}
+		call.err = err
+		f.refreshing = nil
+		close(call.done)
+	}()
+	return call
+}
+
+// fetch fetches the feed, returning nil digests if the feed is not modified
+// since the allowlist with the ETag was fetched.
+func (f *Feed) fetch(ctx context.Context, etag string) (map[digest.Digest]struct{}, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch allowlist from %s: %w", f.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, "", nil
+	case http.StatusOK:
+	default:
+		return nil, "", fmt.Errorf("failed to fetch allowlist from %s, status code: %d", f.url, resp.StatusCode)
+	}
+
+	var document feedDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxFeedSize)).Decode(&document); err != nil {
+		return nil, "", fmt.Errorf("failed to parse allowlist from %s: %w", f.url, err)
+	}
+	digests := make(map[digest.Digest]struct{}, len(document.Digests))
+	for _, entry := range document.Digests {
+		parsed, err := digest.Parse(entry)
+		if err != nil {
+			return nil, "", fmt.Errorf("allowlist from %s contains invalid digest %q: %w", f.url, entry, err)
+		}
+		digests[parsed] = struct{}{}
+	}
+	return digests, resp.Header.Get("ETag"), nil
+}
@@ -0,0 +1,272 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package allowlist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+var (
+	approvedDigest = digest.FromString("approved")
+	otherDigest    = digest.FromString("other")
+)
+
+// feedServer serves the digests of the allowlist with an ETag derived from
+// its version and counts the fetches.
+type feedServer struct {
+	mu          sync.Mutex
+	digests     []digest.Digest
+	version     int
+	fail        bool
+	fetches     int
+	notModified int
+}
+
+func (s *feedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches++
+	if s.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	body := `{"digests": [`
+	for i, d := range s.digests {
+		if i > 0 {
+			body += ","
+		}
+		body += fmt.Sprintf("%q", d)
+	}
+	fmt.Fprint(w, body+"]}")
+}
+
+func (s *feedServer) update(f func(s *feedServer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s)
+}
+
+func newTestFeed(t *testing.T, ts *httptest.Server, conf Config) (*Feed, *time.Time) {
+	conf.URL = ts.URL
+	feed, err := NewFeed(conf)
+	if err != nil {
+		t.Fatalf("NewFeed() error = %v", err)
+	}
+	feed.client.Transport = ts.Client().Transport
+	now := time.Now()
+	feed.now = func() time.Time { return now }
+	return feed, &now
+}
+
+func assertContains(t *testing.T, feed *Feed, d digest.Digest, expected bool) {
+	t.Helper()
+	ok, err := feed.Contains(context.Background(), d)
+	if err != nil {
+		t.Fatalf("Contains() error = %v", err)
+	}
+	if ok != expected {
+		t.Fatalf("expected Contains(%s) = %v, got %v", d, expected, ok)
+	}
+}
+
+// waitRefresh waits for the fetch of the feed in the background.
+func waitRefresh(feed *Feed) {
+	feed.mu.Lock()
+	call := feed.refreshing
+	feed.mu.Unlock()
+	if call != nil {
+		<-call.done
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    Config
+		wantErr bool
+	}{
+		{name: "valid", conf: Config{URL: "https://allowlist.example.com/digests", RefreshInterval: "1m", OnFetchFailure: OnFetchFailureFail}},
+		{name: "missing url", conf: Config{}, wantErr: true},
+		{name: "unsupported scheme", conf: Config{URL: "file:///digests"}, wantErr: true},
+		{name: "plain http", conf: Config{URL: "http://allowlist.example.com/digests"}, wantErr: true},
+		{name: "invalid refresh interval", conf: Config{URL: "https://allowlist.example.com", RefreshInterval: "soon"}, wantErr: true},
+		{name: "invalid on fetch failure", conf: Config{URL: "https://allowlist.example.com", OnFetchFailure: "ignore"}, wantErr: true},
+		{name: "unsupported type", conf: Config{Type: "ldap", URL: "https://allowlist.example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.conf.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFeed_ETag(t *testing.T) {
+	server := &feedServer{digests: []digest.Digest{approvedDigest}}
+	ts := httptest.NewTLSServer(server)
+	defer ts.Close()
+	feed, now := newTestFeed(t, ts, Config{RefreshInterval: "1m"})
+
+	assertContains(t, feed, approvedDigest, true)
+	assertContains(t, feed, otherDigest, false)
+	if server.fetches != 1 {
+		t.Fatalf("expected the allowlist to be cached within the refresh interval, got %d fetches", server.fetches)
+	}
+
+	// the unchanged feed is not downloaded again
+	*now = now.Add(time.Minute)
+	assertContains(t, feed, approvedDigest, true)
+	waitRefresh(feed)
+	if server.fetches != 2 || server.notModified != 1 {
+		t.Fatalf("expected a not modified fetch, got %d fetches and %d not modified", server.fetches, server.notModified)
+	}
+
+	// the changed feed replaces the allowlist once fetched in the background
+	server.update(func(s *feedServer) {
+		s.digests = []digest.Digest{otherDigest}
+		s.version++
+	})
+	*now = now.Add(time.Minute)
+	assertContains(t, feed, approvedDigest, true)
+	waitRefresh(feed)
+	assertContains(t, feed, approvedDigest, false)
+	assertContains(t, feed, otherDigest, true)
+	if server.fetches != 3 || server.notModified != 1 {
+		t.Fatalf("expected a full fetch, got %d fetches and %d not modified", server.fetches, server.notModified)
+	}
+}
+
+func TestFeed_FetchFailure(t *testing.T) {
+	tests := []struct {
+		name           string
+		onFetchFailure string
+		wantErr        bool
+	}{
+		{name: "stale allowlist is used by default"},
+		{name: "stale allowlist is used", onFetchFailure: OnFetchFailureStale},
+		{name: "fetch failure fails", onFetchFailure: OnFetchFailureFail, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &feedServer{digests: []digest.Digest{approvedDigest}}
+			ts := httptest.NewTLSServer(server)
+			defer ts.Close()
+			feed, now := newTestFeed(t, ts, Config{RefreshInterval: "1m", OnFetchFailure: tt.onFetchFailure})
+			assertContains(t, feed, approvedDigest, true)
+
+			server.update(func(s *feedServer) { s.fail = true })
+			*now = now.Add(time.Minute)
+			ok, err := feed.Contains(context.Background(), approvedDigest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Contains() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !ok {
+				t.Fatalf("expected the stale allowlist to contain the digest")
+			}
+			waitRefresh(feed)
+
+			// the feed recovers
+			server.update(func(s *feedServer) { s.fail = false })
+			*now = now.Add(time.Minute)
+			_, _ = feed.Contains(context.Background(), approvedDigest)
+			waitRefresh(feed)
+			assertContains(t, feed, approvedDigest, true)
+		})
+	}
+}
+
+func TestFeed_FirstFetchFailure(t *testing.T) {
+	server := &feedServer{fail: true}
+	ts := httptest.NewTLSServer(server)
+	defer ts.Close()
+	feed, _ := newTestFeed(t, ts, Config{})
+	if _, err := feed.Contains(context.Background(), approvedDigest); err == nil {
+		t.Fatalf("expected error without a stale allowlist")
+	}
+}
+
+func TestFeed_InvalidDigest(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"digests": ["not-a-digest"]}`)
+	}))
+	defer ts.Close()
+	feed, _ := newTestFeed(t, ts, Config{})
+	if _, err := feed.Contains(context.Background(), approvedDigest); err == nil {
+		t.Fatalf("expected error for an invalid digest")
+	}
+}
+
+func TestFeed_FetchDoesNotBlockCachedLookups(t *testing.T) {
+	release := make(chan struct{})
+	var blocked bool
+	server := &feedServer{digests: []digest.Digest{approvedDigest}}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if blocked {
+			<-release
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	feed, now := newTestFeed(t, ts, Config{RefreshInterval: "1m"})
+	assertContains(t, feed, approvedDigest, true)
+
+	// the feed hangs while lookups use the cached allowlist
+	blocked = true
+	*now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		assertContains(t, feed, approvedDigest, true)
+	}
+	close(release)
+	waitRefresh(feed)
+	if server.fetches != 2 {
+		t.Fatalf("expected a single fetch in the background, got %d fetches", server.fetches)
+	}
+}
+
+type staticSource map[digest.Digest]bool
+
+func (s staticSource) Contains(_ context.Context, d digest.Digest) (bool, error) {
+	return s[d], nil
+}
+
+func TestRegister(t *testing.T) {
+	Register("static", func(Config) (Source, error) {
+		return staticSource{approvedDigest: true}, nil
+	})
+	source, err := Shared(Config{Type: "static"})
+	if err != nil {
+		t.Fatalf("Shared() error = %v", err)
+	}
+	if ok, _ := source.Contains(context.Background(), approvedDigest); !ok {
+		t.Fatalf("expected the registered source to be used")
+	}
+}
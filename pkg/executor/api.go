@@ -19,6 +19,7 @@ import (
 	"context"
 	"time"
 
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 )
 
@@ -26,6 +27,23 @@ import (
 type VerifyParameters struct {
 	Subject        string   `json:"subjectReference"`
 	ReferenceTypes []string `json:"referenceTypes,omitempty"`
+	// Explain indicates whether the result should include the derivation of
+	// the overall decision.
+	Explain bool `json:"explain,omitempty"`
+	// Since forces the re-verification of the subject if its cached
+	// verification result was produced before it, e.g. after an update of a
+	// vulnerability feed. It is honored by the callers caching verification
+	// results, such as the HTTP server, which replace the cached result with
+	// the fresh one.
+	Since time.Time `json:"since,omitempty"`
+	// RequestContext is the context of the admission request the subject is
+	// verified for. It is exposed to policies and passed on to the
+	// verification of nested artifacts.
+	RequestContext *types.RequestContext `json:"requestContext,omitempty"`
+	// Platform selects the manifest of an image index subject to verify,
+	// e.g. the platform of the node the image is pulled on. It takes
+	// precedence over the platform of the executor config.
+	Platform *oci.Platform `json:"platform,omitempty"`
 }
 
 // Executor is an interface that defines methods to verify a subject
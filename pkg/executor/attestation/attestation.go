@@ -0,0 +1,194 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/verifier/attestation"
+)
+
+const (
+	// StatementType is the type of the in-toto statements.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateType is the predicate type of the verification results.
+	PredicateType = "https://ratify.dev/verification-result/v1"
+	// PayloadType is the DSSE payload type of in-toto statements.
+	PayloadType = "application/vnd.in-toto+json"
+	// ArtifactType is the artifact type of the attestations pushed as
+	// referrers of the subject.
+	ArtifactType = "application/vnd.ratify.verification-result.v1+json"
+	// EnvelopeMediaType is the media type of the DSSE envelope layer of the
+	// pushed attestations.
+	EnvelopeMediaType = "application/vnd.dsse.envelope.v1+json"
+
+	verifierName = "ratify"
+)
+
+// Config describes the attestations of the verification results.
+type Config struct {
+	// KeyManagementProvider is the name of the kubernetes key management
+	// provider reading the private key signing the attestations from a Secret,
+	// i.e. with contentType 'privateKey'.
+	KeyManagementProvider string `json:"keyManagementProvider"`
+	// Push attaches the attestations to the subject as referrers using the
+	// first referrer store able to push them.
+	Push bool `json:"push,omitempty"`
+}
+
+// Predicate is the predicate of the verification result statements.
+type Predicate struct {
+	Verifier  string                       `json:"verifier"`
+	IsSuccess bool                         `json:"isSuccess"`
+	Reason    types.DecisionReason         `json:"reason"`
+	Verifiers []types.VerifierContribution `json:"verifiers"`
+	Timestamp time.Time                    `json:"timestamp"`
+}
+
+// Validate returns an error if the attestation configuration is invalid.
+func (c *Config) Validate() error {
+	if c.KeyManagementProvider == "" {
+		return fmt.Errorf("attestation keyManagementProvider must not be empty")
+	}
+	return nil
+}
+
+// NewStatement returns the in-toto statement describing the verification
+// result of the subject with the given name and digest.
+func NewStatement(name string, subjectDigest digest.Digest, isSuccess bool, reason types.DecisionReason, verifiers []types.VerifierContribution) (*attestation.Statement, error) {
+	predicate, err := json.Marshal(Predicate{
+		Verifier:  verifierName,
+		IsSuccess: isSuccess,
+		Reason:    reason,
+		Verifiers: verifiers,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verification result predicate: %w", err)
+	}
+	return &attestation.Statement{
+		Type: StatementType,
+		Subject: []attestation.Subject{{
+			Name:   name,
+			Digest: map[string]string{subjectDigest.Algorithm().String(): subjectDigest.Encoded()},
+		}},
+		PredicateType: PredicateType,
+		Predicate:     predicate,
+	}, nil
+}
+
+// Signer returns the signer of the key management provider and the ID of its
+// key. The first key by name and version is used if the provider holds
+// several.
+func Signer(ctx context.Context, provider string) (crypto.Signer, string, error) {
+	signers, err := kmp.GetSignersFromMap(ctx, provider)
+	if err != nil {
+		return nil, "", err
+	}
+	keys := make([]kmp.KMPMapKey, 0, len(signers))
+	for key := range signers {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, "", fmt.Errorf("key management provider %s does not hold a private key", provider)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Name != keys[j].Name {
+			return keys[i].Name < keys[j].Name
+		}
+		return keys[i].Version < keys[j].Version
+	})
+	return signers[keys[0]], keys[0].Name, nil
+}
+
+// Sign wraps the statement in a DSSE envelope signed by the signer.
+func Sign(statement *attestation.Statement, signer crypto.Signer, keyID string) (*attestation.Envelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal statement: %w", err)
+	}
+	message := attestation.PreAuthEncoding(PayloadType, payload)
+
+	var sig []byte
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		sig, err = signer.Sign(rand.Reader, message, crypto.Hash(0))
+	} else {
+		hash := sha256.Sum256(message)
+		sig, err = signer.Sign(rand.Reader, hash[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign statement: %w", err)
+	}
+	return &attestation.Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []attestation.Signature{{KeyID: keyID, Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// Verify returns the statement of the envelope if one of its signatures was
+// made by the key.
+func Verify(envelope *attestation.Envelope, publicKey crypto.PublicKey) (*attestation.Statement, error) {
+	if envelope.PayloadType != PayloadType {
+		return nil, fmt.Errorf("unexpected payload type %s", envelope.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode DSSE payload: %w", err)
+	}
+	message := attestation.PreAuthEncoding(envelope.PayloadType, payload)
+	hash := sha256.Sum256(message)
+
+	for _, signature := range envelope.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+		var verified bool
+		switch key := publicKey.(type) {
+		case *ecdsa.PublicKey:
+			verified = ecdsa.VerifyASN1(key, hash[:], sig)
+		case *rsa.PublicKey:
+			verified = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig) == nil
+		case ed25519.PublicKey:
+			verified = ed25519.Verify(key, message, sig)
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+		}
+		if verified {
+			var statement attestation.Statement
+			if err := json.Unmarshal(payload, &statement); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal statement: %w", err)
+			}
+			return &statement, nil
+		}
+	}
+	return nil, fmt.Errorf("no signature of the envelope was made by the key")
+}
@@ -0,0 +1,140 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
+)
+
+func generateSigners(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]crypto.Signer{"ecdsa": ecdsaKey, "rsa": rsaKey, "ed25519": ed25519Key}
+}
+
+func TestNewStatement(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	verifiers := []types.VerifierContribution{{VerifierName: "notation", ArtifactType: "application/vnd.cncf.notary.signature", IsSuccess: true}}
+	statement, err := NewStatement("localhost:5000/net-monitor", subjectDigest, true, types.ReasonVerified, verifiers)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if statement.Type != StatementType || statement.PredicateType != PredicateType {
+		t.Fatalf("unexpected statement type %s or predicate type %s", statement.Type, statement.PredicateType)
+	}
+	if len(statement.Subject) != 1 || statement.Subject[0].Name != "localhost:5000/net-monitor" || statement.Subject[0].Digest["sha256"] != subjectDigest.Encoded() {
+		t.Fatalf("unexpected statement subject %+v", statement.Subject)
+	}
+	var predicate Predicate
+	if err := json.Unmarshal(statement.Predicate, &predicate); err != nil {
+		t.Fatalf("failed to unmarshal predicate: %v", err)
+	}
+	if predicate.Verifier != verifierName || !predicate.IsSuccess || predicate.Reason != types.ReasonVerified || len(predicate.Verifiers) != 1 {
+		t.Fatalf("unexpected predicate %+v", predicate)
+	}
+	if _, ok := statement.Timestamp(); !ok {
+		t.Fatalf("expected the predicate to carry a timestamp")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	statement, err := NewStatement("localhost:5000/net-monitor", digest.FromString("subject"), false, types.ReasonSignatureInvalid, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	signers := generateSigners(t)
+	for name, signer := range signers {
+		t.Run(name, func(t *testing.T) {
+			envelope, err := Sign(statement, signer, "key1")
+			if err != nil {
+				t.Fatalf("failed to sign statement: %v", err)
+			}
+			if envelope.PayloadType != PayloadType || len(envelope.Signatures) != 1 || envelope.Signatures[0].KeyID != "key1" {
+				t.Fatalf("unexpected envelope %+v", envelope)
+			}
+
+			verified, err := Verify(envelope, signer.Public())
+			if err != nil {
+				t.Fatalf("expected the signature to be valid, got %v", err)
+			}
+			if verified.PredicateType != PredicateType || verified.Subject[0].Digest["sha256"] != statement.Subject[0].Digest["sha256"] {
+				t.Fatalf("unexpected verified statement %+v", verified)
+			}
+
+			// a signature of another key is invalid
+			for otherName, other := range signers {
+				if otherName != name {
+					if _, err := Verify(envelope, other.Public()); err == nil {
+						t.Fatalf("expected the signature to be invalid for the %s key", otherName)
+					}
+				}
+			}
+
+			// a tampered payload is invalid
+			tampered := *envelope
+			tampered.Payload = base64.StdEncoding.EncodeToString([]byte(`{"_type":"tampered"}`))
+			if _, err := Verify(&tampered, signer.Public()); err == nil {
+				t.Fatalf("expected the signature of a tampered payload to be invalid")
+			}
+		})
+	}
+}
+
+func TestSigner(t *testing.T) {
+	signers := generateSigners(t)
+	kmp.SaveSigners("attestation-signer", map[kmp.KMPMapKey]crypto.Signer{
+		{Name: "b"}: signers["rsa"],
+		{Name: "a"}: signers["ecdsa"],
+	})
+	defer kmp.DeleteResourceFromMap("attestation-signer")
+
+	signer, keyID, err := Signer(context.Background(), "attestation-signer")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if keyID != "a" || signer != signers["ecdsa"] {
+		t.Fatalf("expected the first key by name, got %s", keyID)
+	}
+
+	if _, _, err := Signer(context.Background(), "missing"); err == nil {
+		t.Fatalf("expected an error for a provider without signers")
+	}
+}
@@ -15,11 +15,332 @@ limitations under the License.
 
 package config
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/executor/allowlist"
+	"github.com/ratify-project/ratify/pkg/executor/attestation"
+	"github.com/ratify-project/ratify/pkg/executor/events"
+	"github.com/ratify-project/ratify/pkg/executor/membudget"
+	"github.com/ratify-project/ratify/pkg/executor/notification"
+	"github.com/ratify-project/ratify/pkg/executor/passcache"
+	"github.com/ratify-project/ratify/pkg/policyprovider/defaultpolicy"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/ratelimit"
+	"github.com/ratify-project/ratify/pkg/verifier/routing"
+)
+
+const (
+	// FailurePolicyClosed denies subjects whose verification failed because a
+	// dependency of Ratify is unavailable.
+	FailurePolicyClosed = "closed"
+	// FailurePolicyOpen allows, with a warning, subjects whose verification
+	// failed because a dependency of Ratify is unavailable.
+	FailurePolicyOpen = "open"
+
+	defaultRetryBackoff = 200 * time.Millisecond
+)
+
+// VerifierRetryConfig is the retry policy of verifications failing with a
+// transient error, such as a network failure or throttling while a verifier
+// fetches remote data. Verifier results failing the policy are never retried.
+type VerifierRetryConfig struct {
+	// MaxAttempts is the maximum number of times a referrer is verified,
+	// including the first attempt.
+	MaxAttempts int `json:"maxAttempts"`
+	// Backoff is the delay before the first retry, e.g. 500ms, doubled for
+	// each following retry. Defaults to 200ms.
+	Backoff string `json:"backoff,omitempty"`
+	// MaxBackoff caps the delay between retries. The delay is not capped if
+	// not set.
+	MaxBackoff string `json:"maxBackoff,omitempty"`
+}
+
+// Validate returns an error if the verifier retry configuration is invalid.
+func (c *VerifierRetryConfig) Validate() error {
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("verifierRetry maxAttempts must be positive, got %d", c.MaxAttempts)
+	}
+	for name, value := range map[string]string{"backoff": c.Backoff, "maxBackoff": c.MaxBackoff} {
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid verifierRetry %s %s: %w", name, value, err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("verifierRetry %s must be positive, got %s", name, value)
+		}
+	}
+	return nil
+}
+
+// Delay returns the delay before the given retry, starting at 1.
+func (c *VerifierRetryConfig) Delay(retry int) time.Duration {
+	delay := defaultRetryBackoff
+	if backoff, err := time.ParseDuration(c.Backoff); err == nil && backoff > 0 {
+		delay = backoff
+	}
+	maxDelay, err := time.ParseDuration(c.MaxBackoff)
+	if err != nil || maxDelay <= 0 {
+		maxDelay = 0
+	}
+	for i := 1; i < retry; i++ {
+		if maxDelay > 0 && delay >= maxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
 // ExecutorConfig represents the configuration for the executor
 type ExecutorConfig struct {
 	// Gatekeeper default verification webhook timeout is 3 seconds. 100ms network buffer added
 	VerificationRequestTimeout *int `json:"verificationRequestTimeout"`
 	// Gatekeeper default mutation webhook timeout is 1 seconds. 50ms network buffer added
 	MutationRequestTimeout *int `json:"mutationRequestTimeout"`
+	// Order lists verifier names in the order they run. Setting it verifies the
+	// referrers of a subject sequentially, starting with the referrers handled
+	// by the first listed verifier. Unlisted verifiers run last.
+	Order []string `json:"order,omitempty"`
+	// ShortCircuitOnFail verifies the referrers of a subject sequentially and
+	// skips the remaining verifiers once a verifier fails if the policy cannot
+	// allow the subject after the failure, e.g. the artifact type of the
+	// failed referrer requires all verifiers to pass. Policies that may still
+	// allow the subject, such as Rego and CEL policies, verify all referrers.
+	ShortCircuitOnFail bool `json:"shortCircuitOnFail,omitempty"`
+	// PassOnFirstTrusted lists the names or types of verifiers, e.g. a
+	// notation verifier of trusted signatures, that allow the subject as soon
+	// as one of them passes. Setting it verifies the referrers of a subject
+	// sequentially and skips the remaining referrers and the policy once a
+	// listed verifier passed.
+	PassOnFirstTrusted []string `json:"passOnFirstTrusted,omitempty"`
+	// ArtifactTypeMappings routes referrers of an artifact or media type to the
+	// verifier with the given name or type, taking precedence over the artifact
+	// types the verifiers declare.
+	ArtifactTypeMappings map[string]string `json:"artifactTypeMappings,omitempty"`
+	// SubjectArtifactTypeMappings restricts the verifiers of the referrers of
+	// subjects with an artifact type, e.g. Helm charts or WASM modules, to the
+	// verifiers with the given names or types.
+	SubjectArtifactTypeMappings map[string][]string `json:"subjectArtifactTypeMappings,omitempty"`
+	// ArtifactTypeAliases maps the artifact types some tools write for a
+	// concept, e.g. sbom/cyclonedx, to the artifact type the verifiers and the
+	// policy use for it, e.g. application/vnd.cyclonedx+json. The artifact
+	// types of the referrers are normalized before they are routed.
+	ArtifactTypeAliases map[string]string `json:"artifactTypeAliases,omitempty"`
+	// UnknownArtifactType is skip, warn or fail and decides the outcome of
+	// referrers no verifier is routed to. Defaults to skip.
+	UnknownArtifactType string `json:"unknownArtifactType,omitempty"`
+	// FailOnUnknownArtifactType is an alias of unknownArtifactType fail that
+	// is normalized to it when the configuration is loaded.
+	//
+	// Deprecated: set UnknownArtifactType to fail instead.
+	FailOnUnknownArtifactType bool `json:"failOnUnknownArtifactType,omitempty"`
+	// Notification posts a summary of verification decisions to a webhook.
+	// Notification failures never affect the decision.
+	Notification *notification.Config `json:"notification,omitempty"`
+	// Events records a Kubernetes warning event on the namespace of the
+	// request for every denied subject, rate limited per subject and reason.
+	Events *events.Config `json:"events,omitempty"`
+	// FailurePolicy is open or closed and decides the outcome of verifications
+	// that failed because a dependency, such as a registry or a key management
+	// provider, is unavailable, i.e. the request to it failed in transport or
+	// it answered with a server error or throttled the request. Policy
+	// denials, missing subjects, denied credentials and the expiry of the
+	// verification timeout always deny. Defaults to closed.
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+	// DefaultPolicy is applied to the subjects of scopes without a configured
+	// policy provider and is one of allow-all, deny-all or require-signature.
+	// Verifications without a policy provider fail if not set.
+	DefaultPolicy vt.DefaultPolicy `json:"defaultPolicy,omitempty"`
+	// MaxDepth is the maximum nesting depth of the subjects verified for a
+	// request, e.g. with 1 the signatures of an SBOM attached to the image are
+	// verified but verifying their own referrers fails the verification. Zero
+	// does not limit the depth.
+	MaxDepth int `json:"maxDepth,omitempty"`
+	// MaxReferrersPerNode is the maximum number of referrers listed for a
+	// subject or nested referrer. Zero does not limit the number.
+	MaxReferrersPerNode int `json:"maxReferrersPerNode,omitempty"`
+	// Attestation signs the verification results as in-toto statements that
+	// downstream consumers can verify. Attestation failures never affect the
+	// decision.
+	Attestation *attestation.Config `json:"attestation,omitempty"`
+	// MemoryBudget bounds the total size of the blobs held concurrently
+	// across all verifications. A verification holds the size of the blobs it
+	// fetched until it completes. Fetches wait for the budget and fail with a
+	// retryable busy error if it stays exhausted, which never fails open.
+	MemoryBudget *membudget.Config `json:"memoryBudget,omitempty"`
+	// AllowedRegistries lists the registry hosts, e.g. myregistry.azurecr.io or
+	// localhost:5000, subjects may be served from. Verifying a subject from any
+	// other host fails. An empty list allows all hosts.
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// PullThroughCaches maps the registry hosts of pull-through caches to the
+	// hosts of the upstream registries they serve, e.g. cache.example.com to
+	// docker.io. The allowed registries and the trust scopes of the verifiers
+	// are evaluated against the upstream host of subjects served from a cache.
+	PullThroughCaches map[string]string `json:"pullThroughCaches,omitempty"`
+	// Platform selects the manifest of an image index or Docker manifest list
+	// subject that is verified along with its referrers, e.g. os windows,
+	// architecture amd64 and os.version 10.0.20348. An os.version without the
+	// revision matches any revision of the build. It is the default of the
+	// requests that do not carry the platform of the node the image is pulled
+	// on. Subjects that are not indexes, and index subjects without platform,
+	// are verified as is.
+	Platform *oci.Platform `json:"platform,omitempty"`
+	// RequireIndexSignatures requires index subjects to pass verification as
+	// well as the manifest selected by the platform, so that a signature on
+	// only one of them does not allow the subject. Index subjects verified
+	// without platform are verified as is.
+	RequireIndexSignatures bool `json:"requireIndexSignatures,omitempty"`
+	// PassCache persists the subjects that passed verification, keyed by
+	// repository and digest and by a hash of the policy, of this configuration
+	// and of the trust material of the key management providers, so that they
+	// are not verified again after a restart. Changing any of them invalidates
+	// the persisted passes.
+	PassCache *passcache.Config `json:"passCache,omitempty"`
+	// RateLimit bounds the rate of verify requests served by the HTTP server.
+	// Requests beyond it fail with 429 Too Many Requests. Requests from the
+	// loopback interface are not limited.
+	RateLimit *ratelimit.Config `json:"rateLimit,omitempty"`
+	// VerifierRetry retries the verifications of referrers failing with a
+	// transient error. Verifications are not retried if not set.
+	VerifierRetry *VerifierRetryConfig `json:"verifierRetry,omitempty"`
+	// Allowlist is consulted before verification. Subjects whose digest is in
+	// the allowlist are allowed without verifying their referrers.
+	Allowlist *allowlist.Config `json:"allowlist,omitempty"`
+	// LatencyBudget is the maximum duration, e.g. 2s, of the verification of
+	// a subject, including its nested subjects. Verifiers still running once
+	// it elapsed are reported as timed out, which fails the artifact type they
+	// verify unless the policy ignores timed out verifiers and decides on the
+	// results of the completed verifiers. Verifications are not cut off if
+	// not set.
+	LatencyBudget string `json:"latencyBudget,omitempty"`
 	// TODO Add cache config
 }
+
+// Normalize replaces the deprecated settings of the configuration with the
+// settings they alias. It returns true if a deprecated setting was replaced.
+func (c *ExecutorConfig) Normalize() bool {
+	if !c.FailOnUnknownArtifactType {
+		return false
+	}
+	c.UnknownArtifactType = routing.UnknownArtifactTypeFail
+	c.FailOnUnknownArtifactType = false
+	return true
+}
+
+// Validate returns an error if the executor configuration is invalid.
+func (c *ExecutorConfig) Validate() error {
+	if err := routing.ValidateUnknownArtifactType(c.UnknownArtifactType); err != nil {
+		return err
+	}
+	if c.FailOnUnknownArtifactType && c.UnknownArtifactType != "" && c.UnknownArtifactType != routing.UnknownArtifactTypeFail {
+		return fmt.Errorf("failOnUnknownArtifactType conflicts with unknownArtifactType %s", c.UnknownArtifactType)
+	}
+	if err := routing.ValidateArtifactTypeAliases(c.ArtifactTypeAliases); err != nil {
+		return err
+	}
+	switch c.FailurePolicy {
+	case "", FailurePolicyClosed, FailurePolicyOpen:
+	default:
+		return fmt.Errorf("failurePolicy must be %s or %s, got %s", FailurePolicyOpen, FailurePolicyClosed, c.FailurePolicy)
+	}
+	if c.DefaultPolicy != "" {
+		if err := defaultpolicy.Validate(c.DefaultPolicy); err != nil {
+			return err
+		}
+	}
+	if c.MaxDepth < 0 {
+		return fmt.Errorf("maxDepth must not be negative, got %d", c.MaxDepth)
+	}
+	if c.MaxReferrersPerNode < 0 {
+		return fmt.Errorf("maxReferrersPerNode must not be negative, got %d", c.MaxReferrersPerNode)
+	}
+	for _, verifier := range c.PassOnFirstTrusted {
+		if strings.TrimSpace(verifier) == "" {
+			return fmt.Errorf("passOnFirstTrusted must only contain verifier names or types")
+		}
+	}
+	for _, registry := range c.AllowedRegistries {
+		if strings.TrimSpace(registry) == "" || strings.Contains(registry, "/") {
+			return fmt.Errorf("allowedRegistries must only contain registry hosts, got %q", registry)
+		}
+	}
+	for cache, upstream := range c.PullThroughCaches {
+		if strings.TrimSpace(cache) == "" || strings.Contains(cache, "/") || strings.TrimSpace(upstream) == "" || strings.Contains(upstream, "/") {
+			return fmt.Errorf("pullThroughCaches must map registry hosts to registry hosts, got %q to %q", cache, upstream)
+		}
+		if strings.EqualFold(strings.TrimSpace(cache), strings.TrimSpace(upstream)) {
+			return fmt.Errorf("pullThroughCaches must not map registry host %q to itself", cache)
+		}
+	}
+	if c.Platform != nil && (c.Platform.OS == "" || c.Platform.Architecture == "") {
+		return fmt.Errorf("platform must set os and architecture")
+	}
+	if c.Attestation != nil {
+		if err := c.Attestation.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.MemoryBudget != nil {
+		if err := c.MemoryBudget.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.PassCache != nil {
+		if err := c.PassCache.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Events != nil {
+		if err := c.Events.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.VerifierRetry != nil {
+		if err := c.VerifierRetry.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Allowlist != nil {
+		if err := c.Allowlist.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.LatencyBudget != "" {
+		budget, err := time.ParseDuration(c.LatencyBudget)
+		if err != nil {
+			return fmt.Errorf("invalid latencyBudget %s: %w", c.LatencyBudget, err)
+		}
+		if budget <= 0 {
+			return fmt.Errorf("latencyBudget must be positive, got %s", c.LatencyBudget)
+		}
+	}
+	if c.Notification != nil {
+		return c.Notification.Validate()
+	}
+	return nil
+}
+
+// GetLatencyBudget returns the latency budget of the verification of a
+// subject, or zero if verifications are not cut off.
+func (c *ExecutorConfig) GetLatencyBudget() time.Duration {
+	budget, err := time.ParseDuration(c.LatencyBudget)
+	if err != nil || budget <= 0 {
+		return 0
+	}
+	return budget
+}
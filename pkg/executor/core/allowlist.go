@@ -0,0 +1,68 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/executor/allowlist"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/utils"
+)
+
+// allowlisted reports whether the digest of the subject of the request is in
+// the configured allowlist. Nested subjects, subjects whose digest cannot be
+// resolved, and subjects for which the allowlist cannot be consulted are
+// verified as usual.
+func (executor Executor) allowlisted(ctx context.Context, subject string) (bool, error) {
+	if executor.Config == nil || executor.Config.Allowlist == nil || verificationDepth(ctx) > 0 {
+		return false, nil
+	}
+	source, err := allowlist.Shared(*executor.Config.Allowlist)
+	if err != nil {
+		return false, errors.ErrorCodeConfigInvalid.WithDetail("invalid allowlist configuration").WithError(err)
+	}
+	subjectReference, err := utils.ParseSubjectReference(subject)
+	if err != nil {
+		return false, nil
+	}
+	subjectDigest, err := executor.subjectDigest(ctx, subjectReference)
+	if err != nil {
+		return false, nil
+	}
+	ok, err := source.Contains(ctx, subjectDigest)
+	if err != nil {
+		// an unavailable allowlist never decides the verification, the
+		// subject is verified as if it was not in the allowlist
+		logger.GetLogger(ctx, logOpt).Warnf("failed to consult the allowlist for subject %s, verifying it: %v", subject, err)
+		return false, nil
+	}
+	if ok {
+		logger.GetLogger(ctx, logOpt).Infof("subject %s with digest %s is in the allowlist, skipping verification", subject, subjectDigest)
+	}
+	return ok, nil
+}
+
+// allowlistedResult returns the result of a subject allowed by the allowlist.
+func allowlistedResult() types.VerifyResult {
+	return types.VerifyResult{
+		IsSuccess:       true,
+		VerifierReports: []interface{}{},
+		Reason:          types.ReasonAllowlisted,
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/executor/attestation"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/utils"
+	"k8s.io/utils/lru"
+)
+
+const (
+	// maxAttestationPushes bounds the attestations pushed concurrently.
+	// Attestations beyond the bound are not pushed and are pushed by a later
+	// verification of the subject instead.
+	maxAttestationPushes = 8
+	// maxPushedAttestations bounds the attestations remembered as pushed.
+	maxPushedAttestations = 10000
+)
+
+var (
+	attestationPushes    = make(chan struct{}, maxAttestationPushes)
+	pushedAttestationsMu sync.Mutex
+	pushedAttestations   = lru.New(maxPushedAttestations)
+)
+
+// attest sets the signed in-toto statement of the verification result of the
+// subject if attestations are configured, and pushes it as a referrer of the
+// subject in the background if requested. Attestation failures are only
+// logged so that they never affect the verification result.
+func (executor Executor) attest(ctx context.Context, subject string, result *types.VerifyResult) {
+	if executor.Config == nil || executor.Config.Attestation == nil || verificationDepth(ctx) > 0 {
+		return
+	}
+	conf := executor.Config.Attestation
+
+	subjectReference, err := utils.ParseSubjectReference(subject)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to attest verification result of subject %s: %v", subject, err)
+		return
+	}
+	desc, err := executor.resolveSubject(ctx, subjectReference)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to attest verification result of subject %s: %v", subject, err)
+		return
+	}
+	signer, keyID, err := attestation.Signer(ctx, conf.KeyManagementProvider)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to get the key signing the verification result of subject %s: %v", subject, err)
+		return
+	}
+	statement, err := attestation.NewStatement(subjectReference.Path, desc.Digest, result.IsSuccess, result.Reason, verifierContributions(result.VerifierReports))
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to attest verification result of subject %s: %v", subject, err)
+		return
+	}
+	envelope, err := attestation.Sign(statement, signer, keyID)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to attest verification result of subject %s: %v", subject, err)
+		return
+	}
+	result.Attestation = envelope
+
+	if conf.Push {
+		// the same decision on a digest is only pushed once, however often
+		// the subject is verified
+		key := fmt.Sprintf("%s@%s|%t|%s", subjectReference.Path, desc.Digest, result.IsSuccess, result.Reason)
+		if !markAttestationPushed(key) {
+			return
+		}
+		blob, err := json.Marshal(envelope)
+		if err != nil {
+			unmarkAttestationPushed(key)
+			logger.GetLogger(ctx, logOpt).Warnf("failed to marshal attestation of subject %s: %v", subject, err)
+			return
+		}
+		select {
+		case attestationPushes <- struct{}{}:
+		default:
+			unmarkAttestationPushed(key)
+			logger.GetLogger(ctx, logOpt).Warnf("%d attestations are being pushed, not pushing the attestation of subject %s", maxAttestationPushes, subject)
+			return
+		}
+		// the push outlives the verification request
+		pushCtx := context.WithoutCancel(ctx)
+		go func() {
+			defer func() { <-attestationPushes }()
+			if err := executor.pushAttestation(pushCtx, subjectReference, desc, blob); err != nil {
+				unmarkAttestationPushed(key)
+				logger.GetLogger(pushCtx, logOpt).Warnf("failed to push attestation of subject %s: %v", subject, err)
+			}
+		}()
+	}
+}
+
+// markAttestationPushed records the attestation with the given key as pushed
+// and returns false if it was pushed, or is being pushed, already.
+func markAttestationPushed(key string) bool {
+	pushedAttestationsMu.Lock()
+	defer pushedAttestationsMu.Unlock()
+	if _, ok := pushedAttestations.Get(key); ok {
+		return false
+	}
+	pushedAttestations.Add(key, struct{}{})
+	return true
+}
+
+// unmarkAttestationPushed forgets the attestation with the given key so that
+// it is pushed again by the next verification.
+func unmarkAttestationPushed(key string) {
+	pushedAttestationsMu.Lock()
+	defer pushedAttestationsMu.Unlock()
+	pushedAttestations.Remove(key)
+}
+
+// pushAttestation pushes the attestation blob as a referrer of the subject
+// with the first referrer store able to push it.
+func (executor Executor) pushAttestation(ctx context.Context, subjectReference common.Reference, desc *ocispecs.SubjectDescriptor, blob []byte) error {
+	for _, referrerStore := range executor.ReferrerStores {
+		pusher, ok := referrerStore.(referrerstore.ReferrerPusher)
+		if !ok {
+			continue
+		}
+		manifestDesc, err := pusher.PushReferrer(ctx, subjectReference, desc.Descriptor, attestation.ArtifactType, blob, attestation.EnvelopeMediaType)
+		if err != nil {
+			return fmt.Errorf("store %s: %v", referrerStore.Name(), err)
+		}
+		logger.GetLogger(ctx, logOpt).Infof("pushed attestation %s of subject %s", manifestDesc.Digest, subjectReference.Original)
+		return nil
+	}
+	return fmt.Errorf("no referrer store can push it")
+}
@@ -0,0 +1,96 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+)
+
+type latencyBudgetKey struct{}
+
+// latencyBudget is the deadline of a verification, shared by its nested
+// subjects.
+type latencyBudget struct {
+	budget   time.Duration
+	deadline time.Time
+}
+
+// withLatencyBudget returns a context holding the deadline of the
+// verification if a latency budget is configured. The deadline of a context
+// already holding one is kept so that nested subjects share the budget.
+func (executor Executor) withLatencyBudget(ctx context.Context) context.Context {
+	if executor.Config == nil {
+		return ctx
+	}
+	budget := executor.Config.GetLatencyBudget()
+	if budget <= 0 {
+		return ctx
+	}
+	if _, ok := ctx.Value(latencyBudgetKey{}).(latencyBudget); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, latencyBudgetKey{}, latencyBudget{budget: budget, deadline: time.Now().Add(budget)})
+}
+
+// verifyWithinBudget verifies the referrer with the verifier and cuts the
+// verification off once the latency budget elapsed, in which case the
+// returned result is a timeout and timedOut is set.
+func (executor Executor) verifyWithinBudget(ctx context.Context, verifier vr.ReferenceVerifier, subjectRef common.Reference, referenceDesc ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (result vr.VerifierResult, timedOut bool, err error) {
+	budget, ok := ctx.Value(latencyBudgetKey{}).(latencyBudget)
+	if !ok {
+		result, err = executor.verifyWithRetry(ctx, verifier, subjectRef, referenceDesc, referrerStore)
+		return result, false, err
+	}
+
+	budgetCtx, cancel := context.WithDeadline(ctx, budget.deadline)
+	defer cancel()
+	type verification struct {
+		result vr.VerifierResult
+		err    error
+	}
+	// buffered for the verifier not to block once it was cut off
+	done := make(chan verification, 1)
+	go func() {
+		result, err := executor.verifyWithRetry(budgetCtx, verifier, subjectRef, referenceDesc, referrerStore)
+		done <- verification{result: result, err: err}
+	}()
+
+	select {
+	case v := <-done:
+		return v.result, false, v.err
+	case <-budgetCtx.Done():
+		if ctx.Err() != nil {
+			return vr.VerifierResult{}, false, ctx.Err()
+		}
+		logger.GetLogger(ctx, logOpt).Warnf("verifier %s did not verify referrer %s within the latency budget of %s", verifier.Name(), referenceDesc.Digest, budget.budget)
+		return timedOutVerifierResult(verifier, budget.budget), true, nil
+	}
+}
+
+// timedOutVerifierResult returns the result of a verifier cut off by the
+// latency budget.
+func timedOutVerifierResult(verifier vr.ReferenceVerifier, budget time.Duration) vr.VerifierResult {
+	result := vr.NewVerifierResult("", verifier.Name(), verifier.Type(), fmt.Sprintf("verifier did not complete within the latency budget of %s", budget), false, nil, nil)
+	result.Level = vr.LevelTimeout
+	return result
+}
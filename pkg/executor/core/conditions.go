@@ -0,0 +1,120 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/policyprovider"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+)
+
+type verifierConditionsKey struct{}
+
+// withVerifierConditions returns the context verifying the subject and its
+// nested subjects with the reasons the policy skips verifiers for the subject
+// of the request, keyed by verifier name. Conditions are only evaluated
+// against the subject of the request, nested subjects lack its labels and
+// annotations and inherit its decisions. The labels of the subject are
+// fetched into the subject for the conditions to read them.
+func (executor Executor) withVerifierConditions(ctx context.Context, subjectReference common.Reference, desc *ocispecs.SubjectDescriptor, subject *types.Subject) context.Context {
+	if verificationDepth(ctx) > 0 {
+		return ctx
+	}
+	conditionProvider, ok := executor.PolicyEnforcer.(policyprovider.VerifierConditionProvider)
+	if !ok || !conditionProvider.HasVerifierConditions(ctx) {
+		return ctx
+	}
+	subject.Labels = executor.getSubjectLabels(ctx, subjectReference, desc)
+
+	skipped := map[string]string{}
+	for _, verifier := range executor.Verifiers {
+		if skip, reason := conditionProvider.SkipVerifier(ctx, *subject, verifier.Name(), verifier.Type()); skip {
+			logger.GetLogger(ctx, logOpt).Infof("skipping verifier %s for subject %s: %s", verifier.Name(), subjectReference.String(), reason)
+			skipped[verifier.Name()] = fmt.Sprintf("verification skipped by policy: %s", reason)
+		}
+	}
+	return context.WithValue(ctx, verifierConditionsKey{}, skipped)
+}
+
+// skipVerifiers returns the reasons verifiers are skipped for the subject
+// verified with the context, keyed by verifier name. Verifiers are skipped if
+// they are disabled or the policy excludes the subject of the request from
+// their verification.
+func (executor Executor) skipVerifiers(ctx context.Context) map[string]string {
+	conditionSkips, _ := ctx.Value(verifierConditionsKey{}).(map[string]string)
+	skipped := maps.Clone(conditionSkips)
+	for _, verifier := range executor.Verifiers {
+		if vr.IsEnabled(verifier.Name()) {
+			continue
+		}
+		if skipped == nil {
+			skipped = map[string]string{}
+		}
+		skipped[verifier.Name()] = fmt.Sprintf("verifier %s is disabled", verifier.Name())
+	}
+	return skipped
+}
+
+// getSubjectLabels returns the labels of the subject image config. Failing to
+// fetch them is not fatal, conditions on labels are then not met.
+func (executor Executor) getSubjectLabels(ctx context.Context, subjectReference common.Reference, desc *ocispecs.SubjectDescriptor) map[string]string {
+	if desc.MediaType != oci.MediaTypeImageManifest && desc.MediaType != ocispecs.MediaTypeDockerManifest {
+		return nil
+	}
+
+	for _, referrerStore := range executor.ReferrerStores {
+		manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, ocispecs.ReferenceDescriptor{Descriptor: desc.Descriptor})
+		if err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to fetch manifest of subject %s from store %s: %v", subjectReference.String(), referrerStore.Name(), err)
+			continue
+		}
+		if manifest.Config == nil {
+			return nil
+		}
+		blob, _, err := su.ReadBlob(ctx, referrerStore, subjectReference, *manifest.Config)
+		if err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to fetch config of subject %s from store %s: %v", subjectReference.String(), referrerStore.Name(), err)
+			continue
+		}
+		var image oci.Image
+		if err := json.Unmarshal(blob, &image); err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to parse config of subject %s: %v", subjectReference.String(), err)
+			return nil
+		}
+		return image.Config.Labels
+	}
+	return nil
+}
+
+// skippedVerifierResult returns the report recording that the verifier did
+// not verify the referrer and why.
+func skippedVerifierResult(subjectRef common.Reference, referenceDesc ocispecs.ReferenceDescriptor, verifier vr.ReferenceVerifier, reason string) vr.VerifierResult {
+	result := vr.NewVerifierResult(subjectRef.String(), verifier.Name(), verifier.Type(), reason, true, nil, nil)
+	result.Level = vr.LevelSkip
+	result.ReferenceDigest = referenceDesc.Digest.String()
+	result.ArtifactType = referenceDesc.ArtifactType
+	return result
+}
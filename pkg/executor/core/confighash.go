@@ -0,0 +1,52 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+)
+
+// ConfigHash returns the hash of the policy, of the configuration of the
+// executor and of the configs of its verifiers. Verification results are
+// cached under the hash so that a reloaded config misses the stale results.
+func (executor Executor) ConfigHash() (string, error) {
+	policy, err := json.Marshal(executor.PolicyEnforcer)
+	if err != nil {
+		return "", err
+	}
+	config, err := json.Marshal(executor.Config)
+	if err != nil {
+		return "", err
+	}
+	// the verifiers of a scope are not ordered
+	verifiers := make([]string, 0, len(executor.Verifiers))
+	for _, verifier := range executor.Verifiers {
+		verifiers = append(verifiers, fmt.Sprintf("%s\t%s\t%s", verifier.Name(), verifier.Type(), vr.ConfigHash(verifier)))
+	}
+	sort.Strings(verifiers)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%T\n%s\n%s", executor.PolicyEnforcer, policy, config)
+	for _, verifier := range verifiers {
+		fmt.Fprintf(hash, "\n%s", verifier)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
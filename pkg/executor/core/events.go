@@ -0,0 +1,75 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+
+	configv1beta1 "github.com/ratify-project/ratify/api/v1beta1"
+	"github.com/ratify-project/ratify/internal/constants"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/executor/events"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// emitEvent records a Kubernetes event for the subject of the request if it
+// was denied and events are configured. The event is recorded on the admitted
+// resource, or on the Ratify policy the subject was verified against if the
+// request does not identify the resource.
+func (executor Executor) emitEvent(ctx context.Context, subject string, request *types.RequestContext, result types.VerifyResult) {
+	if executor.Config == nil || executor.Config.Events == nil || result.IsSuccess || verificationDepth(ctx) > 0 {
+		return
+	}
+	emitter, err := events.Shared(*executor.Config.Events)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to create verification event emitter: %v", err)
+		return
+	}
+	if emitter == nil {
+		return
+	}
+	if !emitter.EmitDenied(executor.involvedObject(request), subject, result.Reason) {
+		logger.GetLogger(ctx, logOpt).Debugf("rate limited the verification event of subject %s", subject)
+	}
+}
+
+// involvedObject returns the object the event of a denied subject is recorded
+// on: the admitted resource of the request if known, otherwise the policy
+// enforced by the executor.
+func (executor Executor) involvedObject(request *types.RequestContext) *corev1.ObjectReference {
+	if request != nil && request.Resource != nil && request.Resource.Kind != "" && request.Resource.Name != "" {
+		return &corev1.ObjectReference{
+			APIVersion: request.Resource.APIVersion,
+			Kind:       request.Resource.Kind,
+			Namespace:  request.Namespace,
+			Name:       request.Resource.Name,
+		}
+	}
+	if executor.PolicyNamespace != "" {
+		return &corev1.ObjectReference{
+			APIVersion: configv1beta1.GroupVersion.String(),
+			Kind:       "NamespacedPolicy",
+			Namespace:  executor.PolicyNamespace,
+			Name:       constants.RatifyPolicy,
+		}
+	}
+	return &corev1.ObjectReference{
+		APIVersion: configv1beta1.GroupVersion.String(),
+		Kind:       "Policy",
+		Name:       constants.RatifyPolicy,
+	}
+}
@@ -18,9 +18,11 @@ package core
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/common"
@@ -32,7 +34,6 @@ import (
 	"github.com/ratify-project/ratify/pkg/policyprovider"
 	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
-	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
 	"github.com/ratify-project/ratify/pkg/utils"
 	vr "github.com/ratify-project/ratify/pkg/verifier"
 	vt "github.com/ratify-project/ratify/pkg/verifier/types"
@@ -42,6 +43,9 @@ import (
 const (
 	defaultVerifyRequestTimeoutMilliseconds = 2900
 	defaultMutateRequestTimeoutMilliseconds = 950
+	// signedReferrersVerifierName names the result of a referrer that the
+	// policy requires to be signed but that no verifier verifies.
+	signedReferrersVerifierName = "signedReferrers"
 )
 
 var logOpt = logger.Option{
@@ -55,6 +59,17 @@ type Executor struct {
 	PolicyEnforcer policyprovider.PolicyProvider
 	Verifiers      []vr.ReferenceVerifier
 	Config         *config.ExecutorConfig
+	// PolicyNamespace is the namespace of the NamespacedPolicy enforced by the
+	// executor, empty for the cluster-wide Policy.
+	PolicyNamespace string
+
+	// subjectArtifactType is the artifact type of the subject being verified,
+	// set on the copy of the executor verifying the subject.
+	subjectArtifactType string
+	// skippedVerifiers maps the verifiers disabled or skipped by the policy for
+	// the subject being verified to the reason, set on the copy of the executor
+	// verifying the subject.
+	skippedVerifiers map[string]string
 }
 
 // TODO Logging within executor
@@ -63,11 +78,57 @@ func (executor Executor) VerifySubject(ctx context.Context, verifyParameters e.V
 	if executor.PolicyEnforcer == nil {
 		return types.VerifyResult{}, errors.ErrorCodePolicyProviderNotFound.WithDetail("Policy configuration not found")
 	}
-	result, err := executor.verifySubjectInternal(ctx, verifyParameters)
+	ctx = withVerificationGuards(ctx)
+	ctx = withResolvedSubjects(ctx)
+	ctx, releaseMemory := executor.withMemoryBudget(ctx)
+	defer releaseMemory()
+	ctx = executor.withLatencyBudget(ctx)
+	ctx = executor.withUpstreamRegistries(ctx)
+	passKey := executor.passCacheKey(ctx, verifyParameters)
+	if result, ok := passKey.getPass(verifyParameters.Since); ok {
+		logger.GetLogger(ctx, logOpt).Infof("subject %s passed verification under the current policy before, reusing the persisted pass", verifyParameters.Subject)
+		return result, nil
+	}
+	verifiedAt := time.Now()
+	var result types.VerifyResult
+	allowlisted, err := executor.allowlisted(ctx, verifyParameters.Subject)
+	if err == nil {
+		if allowlisted {
+			result = allowlistedResult()
+		} else {
+			result, err = executor.verifySubjectAndIndex(ctx, verifyParameters)
+		}
+	}
 	if err != nil {
 		// get the result for the error based on the policy.
 		// Do we need to consider no referrers as success or failure?
 		result = executor.PolicyEnforcer.ErrorToVerifyResult(ctx, verifyParameters.Subject, err)
+		result.Reason = errorDecisionReason(err)
+		if executor.failOpen() && isInfrastructureError(ctx, err) {
+			logger.GetLogger(ctx, logOpt).Errorf("failing open on verification of subject %s: %v", verifyParameters.Subject, err)
+			result.IsSuccess = true
+			result.Degraded = true
+			result.Warnings = append(result.Warnings, fmt.Sprintf("subject was not verified and is allowed by the open failure policy: %v", err))
+			err = nil
+		}
+	}
+	// a tripped guard fails the verification even if the nested subject that
+	// tripped it was only reported as a failed nested result
+	if guard := trippedGuard(ctx); guard != "" {
+		result.IsSuccess = false
+		result.Degraded = false
+		result.Reason = types.ReasonLimitExceeded
+		result.ExceededGuard = guard
+	}
+	postProcess(ctx, verifyParameters.Subject, &result)
+	executor.attest(ctx, verifyParameters.Subject, &result)
+	reportDecision(ctx, verifyParameters.Subject, result)
+	executor.notify(ctx, verifyParameters.Subject, result)
+	executor.emitEvent(ctx, verifyParameters.Subject, verifyParameters.RequestContext, result)
+	// allowlisted subjects are not persisted so that they are verified once
+	// they are removed from the allowlist, nor are partial results
+	if err == nil && !allowlisted && !result.Partial {
+		passKey.persistPass(ctx, result, verifiedAt)
 	}
 	if executor.PolicyEnforcer.GetPolicyType(ctx) == pt.ConfigPolicy {
 		return result, nil
@@ -77,77 +138,254 @@ func (executor Executor) VerifySubject(ctx context.Context, verifyParameters e.V
 
 // verifySubjectInternal verifies the subject with results.
 func (executor Executor) verifySubjectInternal(ctx context.Context, verifyParameters e.VerifyParameters) (types.VerifyResult, error) {
-	verifierReports, err := executor.verifySubjectInternalWithoutDecision(ctx, verifyParameters)
+	// nested subjects of the verification share the referrer listings
+	ctx = withReferrerListings(ctx)
+	if err := executor.checkDepth(ctx, verifyParameters.Subject); err != nil {
+		return types.VerifyResult{}, err
+	}
+	if err := executor.checkRegistry(verifyParameters.Subject); err != nil {
+		return types.VerifyResult{}, err
+	}
+	verifierReports, subject, earlyExit, err := executor.verifySubjectInternalWithoutDecision(ctx, verifyParameters)
 	if err != nil {
 		return types.VerifyResult{}, err
 	}
 	if executor.PolicyEnforcer.GetPolicyType(ctx) == pt.ConfigPolicy {
 		if len(verifierReports) == 0 {
-			return types.VerifyResult{}, errors.ErrorCodeNoVerifierReport.WithDetail(fmt.Sprintf("No verification results for the artifact %s. Ensure verifiers are properly configured and that artifact metadata is attached", verifyParameters.Subject))
+			// the default only applies to the subject of the request, nested
+			// subjects without reports still fail their parent
+			if noMatchPolicyProvider, ok := executor.PolicyEnforcer.(policyprovider.NoMatchPolicyProvider); ok && verificationDepth(ctx) == 0 {
+				if result, applied := noMatchPolicyProvider.NoMatchVerifyResult(ctx, verifyParameters.Subject); applied {
+					result.Reason = types.ReasonNoMatchingVerifier
+					return result, nil
+				}
+			}
+			return types.VerifyResult{}, errors.ErrorCodeNoVerifierReport.WithDetail(fmt.Sprintf("No verification results for the artifact %s. Ensure verifiers are properly configured and that artifact metadata is attached", verifyParameters.Subject)).WithRemediation(fmt.Sprintf("Sign %s, e.g. with notation or cosign, and push the signature to the registry as a referrer of the artifact, or configure a verifier for the artifact types of its referrers.", verifyParameters.Subject))
 		}
 	}
 	// If it requires embedded Rego Policy Engine make the decision, execute
 	// OverallVerifyResult to evaluate the overall result based on the policy.
 	// NOTE: if Passthrough Mode is enabled, executor will just return the
 	// VerifierReports without evaluating the policy.
-	overallVerifySuccess := executor.PolicyEnforcer.OverallVerifyResult(ctx, verifierReports)
-	return types.VerifyResult{IsSuccess: overallVerifySuccess, VerifierReports: verifierReports}, nil
+	var overallVerifySuccess bool
+	if earlyExit != nil {
+		// a verifier listed by passOnFirstTrusted passed, allow without
+		// evaluating the policy over the partial reports
+		overallVerifySuccess = true
+	} else if subjectPolicyProvider, ok := executor.PolicyEnforcer.(policyprovider.SubjectPolicyProvider); ok {
+		overallVerifySuccess = subjectPolicyProvider.OverallVerifySubjectResult(ctx, subject, verifierReports)
+	} else {
+		overallVerifySuccess = executor.PolicyEnforcer.OverallVerifyResult(ctx, verifierReports)
+	}
+	contributions := verifierContributions(verifierReports)
+	result := types.VerifyResult{
+		IsSuccess:       overallVerifySuccess,
+		VerifierReports: verifierReports,
+		Reason:          decisionReason(overallVerifySuccess, contributions),
+		EarlyExit:       earlyExit,
+	}
+	for _, contribution := range contributions {
+		switch contribution.Level {
+		case vr.LevelWarn:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", contribution.VerifierName, contribution.Message))
+		case vr.LevelTimeout:
+			result.Partial = true
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", contribution.VerifierName, contribution.Message))
+		}
+	}
+	if result.UnmatchedArtifactTypes = unmatchedArtifactTypes(contributions); len(result.UnmatchedArtifactTypes) > 0 {
+		logger.GetLogger(ctx, logOpt).Warnf("no verifier is configured for the artifact types %s of the referrers of subject %s", strings.Join(result.UnmatchedArtifactTypes, ", "), verifyParameters.Subject)
+	}
+	if scoringPolicyProvider, ok := executor.PolicyEnforcer.(policyprovider.ScoringPolicyProvider); ok {
+		score := scoringPolicyProvider.ScoreVerifyResult(ctx, subject, verifierReports)
+		result.Score = &score
+	}
+	if verifyParameters.Explain {
+		result.Explanation = executor.explain(ctx, overallVerifySuccess, subject, verifierReports, contributions)
+		if earlyExit != nil {
+			result.Explanation.Policy.Reason = fmt.Sprintf("verifier %s listed by passOnFirstTrusted passed reference %s", earlyExit.Verifier, earlyExit.ReferenceDigest)
+		}
+	}
+	return result, nil
+}
+
+// explain builds the derivation of the overall verification result from the
+// policy provider and the individual verifier reports.
+func (executor Executor) explain(ctx context.Context, isSuccess bool, subject types.Subject, verifierReports []interface{}, contributions []types.VerifierContribution) *types.Explanation {
+	var derivation types.PolicyDerivation
+	if explainer, ok := executor.PolicyEnforcer.(policyprovider.PolicyExplainer); ok {
+		derivation = explainer.ExplainVerifyResult(ctx, subject, verifierReports)
+	} else {
+		derivation = types.PolicyDerivation{
+			PolicyType: executor.PolicyEnforcer.GetPolicyType(ctx),
+			Input:      verifierReports,
+		}
+	}
+	return &types.Explanation{
+		IsSuccess:     isSuccess,
+		Policy:        derivation,
+		Contributions: contributions,
+	}
+}
+
+// verifierContributions flattens the verifier reports, including nested ones,
+// into a list of verifier contributions.
+func verifierContributions(verifierReports []interface{}) []types.VerifierContribution {
+	contributions := make([]types.VerifierContribution, 0, len(verifierReports))
+	for _, report := range verifierReports {
+		switch r := report.(type) {
+		case vr.VerifierResult:
+			contributions = appendVerifierResultContributions(contributions, r)
+		case types.NestedVerifierReport:
+			contributions = appendNestedReportContributions(contributions, r)
+		}
+	}
+	return contributions
+}
+
+func appendVerifierResultContributions(contributions []types.VerifierContribution, result vr.VerifierResult) []types.VerifierContribution {
+	contributions = append(contributions, types.VerifierContribution{
+		Subject:               result.Subject,
+		VerifierName:          result.VerifierName,
+		VerifierType:          result.VerifierType,
+		ArtifactType:          result.ArtifactType,
+		ReferenceDigest:       result.ReferenceDigest,
+		IsSuccess:             result.IsSuccess,
+		Level:                 result.GetLevel(),
+		Message:               result.Message,
+		UnmatchedArtifactType: isUnknownArtifactTypeResult(result.Extensions),
+	})
+	for _, nested := range result.NestedResults {
+		contributions = appendVerifierResultContributions(contributions, nested)
+	}
+	return contributions
+}
+
+func appendNestedReportContributions(contributions []types.VerifierContribution, report types.NestedVerifierReport) []types.VerifierContribution {
+	for _, result := range report.VerifierReports {
+		contributions = append(contributions, types.VerifierContribution{
+			Subject:               report.Subject,
+			VerifierName:          result.VerifierName,
+			VerifierType:          result.VerifierType,
+			ArtifactType:          report.ArtifactType,
+			ReferenceDigest:       report.ReferenceDigest,
+			IsSuccess:             result.IsSuccess,
+			Level:                 result.GetLevel(),
+			Message:               result.Message,
+			UnmatchedArtifactType: isUnknownArtifactTypeResult(result.Extensions),
+		})
+	}
+	for _, nested := range report.NestedReports {
+		contributions = appendNestedReportContributions(contributions, nested)
+	}
+	return contributions
 }
 
 // verifySubjectInternalWithoutDecision verifies the subject and returns result
-// without making decisions on the result.
-func (executor Executor) verifySubjectInternalWithoutDecision(ctx context.Context, verifyParameters e.VerifyParameters) ([]interface{}, error) {
+// without making decisions on the result, along with the subject metadata
+// exposed to the policy and the early exit of the verification, if any.
+func (executor Executor) verifySubjectInternalWithoutDecision(ctx context.Context, verifyParameters e.VerifyParameters) ([]interface{}, types.Subject, *types.EarlyExit, error) {
+	ctx = withRequestContext(ctx, verifyParameters.RequestContext)
 	subjectReference, err := utils.ParseSubjectReference(verifyParameters.Subject)
 	if err != nil {
-		return nil, err
+		return nil, types.Subject{}, nil, err
 	}
 
-	desc, err := su.ResolveSubjectDescriptor(ctx, &executor.ReferrerStores, subjectReference)
+	desc, err := executor.resolveSubject(ctx, subjectReference)
 	if err != nil {
-		return nil, err
+		return nil, types.Subject{}, nil, err
+	}
+	if desc, err = executor.resolvePlatformManifest(ctx, &subjectReference, desc, executor.platform(verifyParameters)); err != nil {
+		return nil, types.Subject{}, nil, err
 	}
 
 	logger.GetLogger(ctx, logOpt).Infof("Resolve of the image completed successfully the digest is %s", desc.Digest)
 
 	subjectReference.Digest = desc.Digest
+	annotations, artifactType := executor.getSubjectMetadata(ctx, subjectReference, desc)
+	subject := types.Subject{
+		Reference:    subjectReference.String(),
+		Digest:       desc.Digest.String(),
+		ArtifactType: artifactType,
+		Annotations:  annotations,
+		Request:      verifyParameters.RequestContext,
+		Nested:       verificationDepth(ctx) > 0,
+	}
+	// the referrers of the subject are routed by its artifact type
+	executor.subjectArtifactType = artifactType
+	ctx = executor.withVerifierConditions(ctx, subjectReference, desc, &subject)
+	executor.skippedVerifiers = executor.skipVerifiers(ctx)
+
+	if executor.isSequential() {
+		verifierReports, earlyExit, err := executor.verifyReferencesInOrder(ctx, subjectReference, desc, verifyParameters)
+		if err != nil {
+			return nil, types.Subject{}, nil, err
+		}
+		sortVerifierReports(verifierReports)
+		return verifierReports, subject, earlyExit, nil
+	}
 
 	verifierReports := make([]interface{}, 0)
 	eg, errCtx := errgroup.WithContext(ctx)
 	var mu sync.Mutex
+	var referrerCount int
+
+	verifyReference := func(verifyCtx context.Context, reference ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) error {
+		if executor.PolicyEnforcer.GetPolicyType(ctx) == pt.RegoPolicy {
+			verifyResult, err := executor.verifyReferenceForRegoPolicy(verifyCtx, subjectReference, reference, referrerStore)
+			if err != nil {
+				logger.GetLogger(ctx, logOpt).Errorf("error while verifying reference %+v, err: %v", reference, err)
+				return err
+			}
+			mu.Lock() // locks the verifierReports List for write safety
+			defer mu.Unlock()
+			verifierReports = append(verifierReports, verifyResult)
+		} else {
+			verifyResult := executor.verifyReferenceForJSONPolicy(verifyCtx, subjectReference, reference, referrerStore)
+			mu.Lock() // locks the verifierReports List for write safety
+			defer mu.Unlock()
+			verifierReports = append(verifierReports, verifyResult.VerifierReports...)
+		}
+		return nil
+	}
+
+	for _, ref := range executor.subjectReferences(ctx, desc, verifyParameters.ReferenceTypes) {
+		ref := ref
+		if !executor.PolicyEnforcer.VerifyNeeded(errCtx, subjectReference, ref.reference) {
+			continue
+		}
+		eg.Go(func() error {
+			return verifyReference(errCtx, ref.reference, ref.store)
+		})
+	}
 
+	listed := newListedReferrers()
 	for _, referrerStore := range executor.ReferrerStores {
 		referrerStore := referrerStore
 		eg.Go(func() error {
 			var continuationToken string
 			innerGroup, innerErrCtx := errgroup.WithContext(errCtx)
 			for {
-				referrersResult, err := referrerStore.ListReferrers(errCtx, subjectReference, verifyParameters.ReferenceTypes, continuationToken, desc)
+				referrersResult, err := listReferrers(errCtx, referrerStore, subjectReference, verifyParameters.ReferenceTypes, continuationToken, desc)
 				if err != nil {
 					return errors.ErrorCodeListReferrersFailure.NewError(errors.ReferrerStore, referrerStore.Name(), errors.EmptyLink, err, nil, errors.HideStackTrace)
 				}
 				continuationToken = referrersResult.NextToken
+				referrersResult.Referrers = listed.unseen(executor.normalizeArtifactTypes(referrersResult.Referrers))
+				mu.Lock()
+				referrerCount += len(referrersResult.Referrers)
+				count := referrerCount
+				mu.Unlock()
+				if err := executor.checkReferrerCount(ctx, subjectReference.String(), count); err != nil {
+					return err
+				}
 				for _, reference := range referrersResult.Referrers {
 					if !executor.PolicyEnforcer.VerifyNeeded(innerErrCtx, subjectReference, reference) {
 						continue
 					}
 					reference := reference
 					innerGroup.Go(func() error {
-						if executor.PolicyEnforcer.GetPolicyType(ctx) == pt.RegoPolicy {
-							verifyResult, err := executor.verifyReferenceForRegoPolicy(innerErrCtx, subjectReference, reference, referrerStore)
-							if err != nil {
-								logger.GetLogger(ctx, logOpt).Errorf("error while verifying reference %+v, err: %v", reference, err)
-								return err
-							}
-							mu.Lock() // locks the verifierReports List for write safety
-							defer mu.Unlock()
-							verifierReports = append(verifierReports, verifyResult)
-						} else {
-							verifyResult := executor.verifyReferenceForJSONPolicy(innerErrCtx, subjectReference, reference, referrerStore)
-							mu.Lock() // locks the verifierReports List for write safety
-							defer mu.Unlock()
-							verifierReports = append(verifierReports, verifyResult.VerifierReports...)
-						}
-						return nil
+						return verifyReference(innerErrCtx, reference, referrerStore)
 					})
 				}
 				if continuationToken == "" {
@@ -159,42 +397,110 @@ func (executor Executor) verifySubjectInternalWithoutDecision(ctx context.Contex
 	}
 
 	if err = eg.Wait(); err != nil {
-		return nil, err
+		return nil, types.Subject{}, nil, err
+	}
+	// referrers are verified concurrently, order the reports for them not to
+	// depend on which verification completed first
+	sortVerifierReports(verifierReports)
+
+	return verifierReports, subject, nil, nil
+}
+
+// getSubjectMetadata returns the annotations of the subject descriptor merged
+// with the annotations of the subject manifest, and the artifact type of the
+// subject. The manifest is only fetched if the policy or the routing of the
+// referrers reads them. Failing to fetch the manifest is not fatal since
+// policies may not depend on annotations.
+func (executor Executor) getSubjectMetadata(ctx context.Context, subjectReference common.Reference, desc *ocispecs.SubjectDescriptor) (map[string]string, string) {
+	annotations := map[string]string{}
+	for key, value := range desc.Annotations {
+		annotations[key] = value
+	}
+	artifactType := desc.ArtifactType
+	// only image and artifact manifests carry annotations the stores can parse
+	if desc.MediaType != oci.MediaTypeImageManifest && desc.MediaType != ocispecs.MediaTypeArtifactManifest {
+		return annotations, artifactType
+	}
+	if !executor.usesSubjectManifest(ctx) && (artifactType != "" || executor.Config == nil || len(executor.Config.SubjectArtifactTypeMappings) == 0) {
+		return annotations, artifactType
 	}
 
-	return verifierReports, nil
+	for _, referrerStore := range executor.ReferrerStores {
+		manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, ocispecs.ReferenceDescriptor{Descriptor: desc.Descriptor})
+		if err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to fetch manifest of subject %s from store %s: %v", subjectReference.String(), referrerStore.Name(), err)
+			continue
+		}
+		for key, value := range manifest.Annotations {
+			annotations[key] = value
+		}
+		if artifactType == "" {
+			artifactType = manifest.ArtifactType
+		}
+		break
+	}
+	return annotations, artifactType
+}
+
+// usesSubjectManifest returns true if the decision of the policy may depend on
+// the annotations or the artifact type of the subject manifest.
+func (executor Executor) usesSubjectManifest(ctx context.Context) bool {
+	provider, ok := executor.PolicyEnforcer.(policyprovider.SubjectManifestPolicyProvider)
+	return ok && provider.UsesSubjectManifest(ctx)
 }
 
 // verifyReferenceForJSONPolicy verifies the referenced artifact with results
 // used for the Json-based policy enforcer.
 func (executor Executor) verifyReferenceForJSONPolicy(ctx context.Context, subjectRef common.Reference, referenceDesc ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) types.VerifyResult {
-	var verifyResults []interface{}
-	var isSuccess = true
-
-	for _, verifier := range executor.Verifiers {
-		if verifier.CanVerify(ctx, referenceDesc) {
-			verifierStartTime := time.Now()
-			verifyResult, err := verifier.Verify(ctx, subjectRef, referenceDesc, referrerStore)
-			if err != nil {
-				verifierErr := errors.ErrorCodeVerifyReferenceFailure.WithError(err)
-				verifyResult = vr.NewVerifierResult("", verifier.Name(), verifier.Type(), "", false, &verifierErr, nil)
-			}
-
-			if len(verifier.GetNestedReferences()) > 0 {
-				executor.addNestedVerifierResult(ctx, referenceDesc, subjectRef, &verifyResult)
-			}
+	routedVerifiers := executor.routeVerifiers(ctx, referenceDesc)
+	if len(routedVerifiers) == 0 {
+		if executor.requiresSignedReferrer(ctx, referenceDesc) {
+			return types.VerifyResult{IsSuccess: true, VerifierReports: []interface{}{executor.signedReferrerResult(ctx, subjectRef, referenceDesc)}}
+		}
+		unknownResult, ok := executor.unknownArtifactTypeResult(referenceDesc)
+		if !ok {
+			return types.VerifyResult{IsSuccess: true}
+		}
+		unknownResult.Subject = subjectRef.String()
+		unknownResult.ReferenceDigest = referenceDesc.Digest.String()
+		unknownResult.ArtifactType = referenceDesc.ArtifactType
+		return types.VerifyResult{IsSuccess: unknownResult.IsSuccess, VerifierReports: []interface{}{unknownResult}}
+	}
 
-			verifyResult.Subject = subjectRef.String()
-			verifyResult.ReferenceDigest = referenceDesc.Digest.String()
-			verifyResult.ArtifactType = referenceDesc.ArtifactType
-			verifyResults = append(verifyResults, verifyResult)
-			isSuccess = verifyResult.IsSuccess
-			metrics.ReportVerifierDuration(ctx, time.Since(verifierStartTime).Milliseconds(), verifier.Name(), subjectRef.String(), isSuccess, err != nil)
-			break
+	// the first verifier the referrer is routed to and not skipped verifies it
+	var verifier vr.ReferenceVerifier
+	var skippedReports []interface{}
+	for _, routedVerifier := range routedVerifiers {
+		if reason, skipped := executor.skippedVerifiers[routedVerifier.Name()]; skipped {
+			skippedReports = append(skippedReports, skippedVerifierResult(subjectRef, referenceDesc, routedVerifier, reason))
+			continue
+		}
+		verifier = routedVerifier
+		break
+	}
+	if verifier == nil {
+		if executor.requiresSignedReferrer(ctx, referenceDesc) {
+			skippedReports = append(skippedReports, executor.signedReferrerResult(ctx, subjectRef, referenceDesc))
 		}
+		return types.VerifyResult{IsSuccess: true, VerifierReports: skippedReports}
+	}
+	verifierStartTime := time.Now()
+	verifyResult, timedOut, err := executor.verifyWithinBudget(ctx, verifier, subjectRef, referenceDesc, referrerStore)
+	if err != nil {
+		verifierErr := errors.ErrorCodeVerifyReferenceFailure.WithError(err)
+		verifyResult = vr.NewVerifierResult("", verifier.Name(), verifier.Type(), "", false, &verifierErr, nil)
+	}
+
+	if !timedOut && (len(verifier.GetNestedReferences()) > 0 || executor.requiresSignedReferrer(ctx, referenceDesc)) {
+		executor.addNestedVerifierResult(ctx, referenceDesc, subjectRef, &verifyResult)
 	}
 
-	return types.VerifyResult{IsSuccess: isSuccess, VerifierReports: verifyResults}
+	verifyResult.Subject = subjectRef.String()
+	verifyResult.ReferenceDigest = referenceDesc.Digest.String()
+	verifyResult.ArtifactType = referenceDesc.ArtifactType
+	metrics.ReportVerifierDuration(ctx, time.Since(verifierStartTime).Milliseconds(), verifier.Name(), verifier.Type(), subjectRef.String(), verifyResult.IsSuccess, err != nil)
+
+	return types.VerifyResult{IsSuccess: verifyResult.IsSuccess, VerifierReports: append(skippedReports, verifyResult)}
 }
 
 // verifyReferenceForRegoPolicy verifies the referenced artifact with results
@@ -214,27 +520,54 @@ func (executor Executor) verifyReferenceForRegoPolicy(ctx context.Context, subje
 		return executor.addNestedReports(errCtx, referenceDesc, subjectRef, &nestedReport)
 	})
 
-	for _, verifier := range executor.Verifiers {
-		if !verifier.CanVerify(ctx, referenceDesc) {
-			continue
+	verify := func(verifier vr.ReferenceVerifier) vt.VerifierResult {
+		var verifierReport vt.VerifierResult
+		verifierStartTime := time.Now()
+		verifierResult, _, err := executor.verifyWithinBudget(errCtx, verifier, subjectRef, referenceDesc, referrerStore)
+		if err != nil {
+			verifierErr := errors.ErrorCodeVerifyReferenceFailure.WithError(err)
+			verifierReport = vt.CreateVerifierResult(verifier.Name(), verifier.Type(), "", false, &verifierErr)
+		} else {
+			verifierReport = vt.NewVerifierResult(verifierResult)
 		}
-		verifier := verifier
-		eg.Go(func() error {
-			var verifierReport vt.VerifierResult
-			verifierStartTime := time.Now()
-			verifierResult, err := verifier.Verify(errCtx, subjectRef, referenceDesc, referrerStore)
-			if err != nil {
-				verifierErr := errors.ErrorCodeVerifyReferenceFailure.WithError(err)
-				verifierReport = vt.CreateVerifierResult(verifier.Name(), verifier.Type(), "", false, &verifierErr)
-			} else {
-				verifierReport = vt.NewVerifierResult(verifierResult)
-			}
 
+		mu.Lock()
+		nestedReport.VerifierReports = append(nestedReport.VerifierReports, verifierReport)
+		mu.Unlock()
+
+		metrics.ReportVerifierDuration(errCtx, time.Since(verifierStartTime).Milliseconds(), verifier.Name(), verifier.Type(), subjectRef.String(), verifierReport.IsSuccess, err != nil)
+		return verifierReport
+	}
+
+	routedVerifiers := executor.routeVerifiers(ctx, referenceDesc)
+	if len(routedVerifiers) == 0 {
+		if unknownResult, ok := executor.unknownArtifactTypeResult(referenceDesc); ok {
+			nestedReport.VerifierReports = append(nestedReport.VerifierReports, vt.NewVerifierResult(unknownResult))
+		}
+	}
+	for _, verifier := range routedVerifiers {
+		if reason, skipped := executor.skippedVerifiers[verifier.Name()]; skipped {
 			mu.Lock()
-			nestedReport.VerifierReports = append(nestedReport.VerifierReports, verifierReport)
+			nestedReport.VerifierReports = append(nestedReport.VerifierReports, vt.NewVerifierResult(skippedVerifierResult(subjectRef, referenceDesc, verifier, reason)))
 			mu.Unlock()
-
-			metrics.ReportVerifierDuration(errCtx, time.Since(verifierStartTime).Milliseconds(), verifier.Name(), subjectRef.String(), verifierReport.IsSuccess, err != nil)
+			continue
+		}
+		// run verifiers one at a time if their order is configured
+		if executor.isSequential() {
+			report := verify(verifier)
+			if !report.IsSuccess && executor.shortCircuitOnFail() {
+				break
+			}
+			// the verifiers after a trusted pass would be skipped with the
+			// remaining referrers
+			if report.IsSuccess && executor.isTrustedVerifier(verifier.Name(), verifier.Type()) {
+				break
+			}
+			continue
+		}
+		verifier := verifier
+		eg.Go(func() error {
+			verify(verifier)
 			return nil
 		})
 	}
@@ -245,15 +578,40 @@ func (executor Executor) verifyReferenceForRegoPolicy(ctx context.Context, subje
 	return nestedReport, nil
 }
 
+// requiresSignedReferrer reports whether the policy requires the referrer to be
+// signed, in which case its own referrers are verified as nested results.
+func (executor Executor) requiresSignedReferrer(ctx context.Context, referenceDesc ocispecs.ReferenceDescriptor) bool {
+	signedReferrerProvider, ok := executor.PolicyEnforcer.(policyprovider.SignedReferrerPolicyProvider)
+	return ok && signedReferrerProvider.RequiresSignedReferrer(ctx, referenceDesc)
+}
+
+// signedReferrerResult returns the result of a referrer the policy requires to
+// be signed that no verifier verifies. It carries the results of the
+// referrers of the referrer, so that the policy checks its signature as it
+// does for the referrers verified by a verifier.
+func (executor Executor) signedReferrerResult(ctx context.Context, subjectRef common.Reference, referenceDesc ocispecs.ReferenceDescriptor) vr.VerifierResult {
+	result := vr.NewVerifierResult("", signedReferrersVerifierName, signedReferrersVerifierName, fmt.Sprintf("referrer of type %s is required to be signed", referenceDesc.ArtifactType), true, nil, nil)
+	executor.addNestedVerifierResult(ctx, referenceDesc, subjectRef, &result)
+	result.Subject = subjectRef.String()
+	result.ReferenceDigest = referenceDesc.Digest.String()
+	result.ArtifactType = referenceDesc.ArtifactType
+	return result
+}
+
 // addNestedVerifierResult adds the nested verifier result to the parent verify
 // result used for Json-based policy enforcer.
 func (executor Executor) addNestedVerifierResult(ctx context.Context, referenceDesc ocispecs.ReferenceDescriptor, subjectRef common.Reference, verifyResult *vr.VerifierResult) {
+	// the referrers of the subject are verified by the subject verification
+	if isSubjectReference(referenceDesc) {
+		return
+	}
 	verifyParameters := e.VerifyParameters{
 		Subject:        fmt.Sprintf("%s@%s", subjectRef.Path, referenceDesc.Digest),
 		ReferenceTypes: []string{"*"},
+		RequestContext: requestContext(ctx),
 	}
 
-	nestedVerifyResult, err := executor.VerifySubject(ctx, verifyParameters)
+	nestedVerifyResult, err := executor.VerifySubject(withNestedDepth(ctx), verifyParameters)
 	if err != nil {
 		nestedVerifyResult = executor.PolicyEnforcer.ErrorToVerifyResult(ctx, verifyParameters.Subject, err)
 	}
@@ -272,13 +630,18 @@ func (executor Executor) addNestedVerifierResult(ctx context.Context, referenceD
 // addNestedReports adds the nested verifier reports to the parent report used
 // for Rego-based policy enforcer.
 func (executor Executor) addNestedReports(ctx context.Context, referenceDes ocispecs.ReferenceDescriptor, subjectRef common.Reference, verifierReport *types.NestedVerifierReport) error {
+	// the referrers of the subject are verified by the subject verification
+	if isSubjectReference(referenceDes) {
+		return nil
+	}
 	verifyParameters := e.VerifyParameters{
 		Subject:        fmt.Sprintf("%s@%s", subjectRef.Path, referenceDes.Digest),
 		ReferenceTypes: []string{"*"},
+		RequestContext: requestContext(ctx),
 	}
 
 	// get nested reports.
-	reports, err := executor.verifySubjectInternal(ctx, verifyParameters)
+	reports, err := executor.verifySubjectInternal(withNestedDepth(ctx), verifyParameters)
 	if err != nil {
 		return fmt.Errorf("failed to verify nested subject, param: %+v, err: %w", verifyParameters, err)
 	}
@@ -296,6 +659,12 @@ func (executor Executor) addNestedReports(ctx context.Context, referenceDes ocis
 	return nil
 }
 
+// failOpen reports whether subjects are allowed when their verification failed
+// because a dependency of Ratify is unavailable.
+func (executor Executor) failOpen() bool {
+	return executor.Config != nil && executor.Config.FailurePolicy == config.FailurePolicyOpen
+}
+
 func (executor Executor) GetVerifyRequestTimeout() time.Duration {
 	timeoutMilliSeconds := defaultVerifyRequestTimeoutMilliseconds
 	if executor.Config != nil && executor.Config.VerificationRequestTimeout != nil {
@@ -17,27 +17,55 @@ package core
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/opencontainers/go-digest"
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	ratifyerrors "github.com/ratify-project/ratify/errors"
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
 	"github.com/ratify-project/ratify/pkg/common"
 	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/allowlist"
+	"github.com/ratify-project/ratify/pkg/executor/attestation"
 	exConfig "github.com/ratify-project/ratify/pkg/executor/config"
+	"github.com/ratify-project/ratify/pkg/executor/events"
+	"github.com/ratify-project/ratify/pkg/executor/notification"
+	"github.com/ratify-project/ratify/pkg/executor/passcache"
 	"github.com/ratify-project/ratify/pkg/executor/types"
+	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/policyprovider"
 	policyConfig "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"
+	"github.com/ratify-project/ratify/pkg/policyprovider/defaultpolicy"
+	scorePolicy "github.com/ratify-project/ratify/pkg/policyprovider/scorepolicy"
 	policyTypes "github.com/ratify-project/ratify/pkg/policyprovider/types"
 	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	storeConfig "github.com/ratify-project/ratify/pkg/referrerstore/config"
 	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
 	"github.com/ratify-project/ratify/pkg/verifier"
+	ratifyattestation "github.com/ratify-project/ratify/pkg/verifier/attestation"
+	"k8s.io/client-go/tools/record"
+	"oras.land/oras-go/v2/registry/remote/errcode"
 )
 
 const (
@@ -52,6 +80,8 @@ const (
 type mockPolicyProvider struct {
 	result     bool
 	policyType string
+	// failureDecisive denies the subject on any failed referrer.
+	failureDecisive bool
 }
 
 func (p *mockPolicyProvider) VerifyNeeded(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor) bool {
@@ -59,7 +89,7 @@ func (p *mockPolicyProvider) VerifyNeeded(_ context.Context, _ common.Reference,
 }
 
 func (p *mockPolicyProvider) ContinueVerifyOnFailure(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor, _ types.VerifyResult) bool {
-	return true
+	return !p.failureDecisive
 }
 
 func (p *mockPolicyProvider) ErrorToVerifyResult(_ context.Context, _ string, _ error) types.VerifyResult {
@@ -79,6 +109,8 @@ func (p *mockPolicyProvider) GetPolicyType(_ context.Context) string {
 
 type mockStore struct {
 	referrers map[string][]ocispecs.ReferenceDescriptor
+	// listErr is returned by ListReferrers if referrers is nil.
+	listErr error
 }
 
 func (s *mockStore) Name() string {
@@ -87,6 +119,9 @@ func (s *mockStore) Name() string {
 
 func (s *mockStore) ListReferrers(_ context.Context, _ common.Reference, _ []string, _ string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
 	if s.referrers == nil {
+		if s.listErr != nil {
+			return referrerstore.ListReferrersResult{}, s.listErr
+		}
 		return referrerstore.ListReferrersResult{}, errors.New("some error happened")
 	}
 	if _, ok := s.referrers[subjectDesc.Digest.String()]; ok {
@@ -214,9 +249,14 @@ func TestVerifySubjectInternal_Verify_NoReferrers(t *testing.T) {
 		Subject: "localhost:5000/net-monitor:v1",
 	}
 
-	if _, err := ex.verifySubjectInternal(context.Background(), verifyParameters); !errors.Is(err, ratifyerrors.ErrorCodeNoVerifierReport.WithDetail("")) {
+	_, err := ex.verifySubjectInternal(context.Background(), verifyParameters)
+	if !errors.Is(err, ratifyerrors.ErrorCodeNoVerifierReport.WithDetail("")) {
 		t.Fatalf("expected ErrReferrersNotFound actual %v", err)
 	}
+	var ratifyErr ratifyerrors.Error
+	if !errors.As(err, &ratifyErr) || !strings.Contains(ratifyErr.GetRemediation(), "Sign localhost:5000/net-monitor:v1") {
+		t.Fatalf("expected remediation to sign the subject, got %v", err)
+	}
 }
 
 func TestVerifySubjectInternal_CanVerify_ExpectedResults(t *testing.T) {
@@ -401,6 +441,8 @@ func TestVerifySubjectInternalWithDecision_MultipleArtifacts_ExpectedResults(t *
 			"v1": testDigest,
 		},
 	}
+	var mu sync.Mutex
+	var completed []string
 	ver := &TestVerifier{
 		CanVerifyFunc: func(_ string) bool {
 			return true
@@ -409,6 +451,9 @@ func TestVerifySubjectInternalWithDecision_MultipleArtifacts_ExpectedResults(t *
 			if artifactType == testArtifactType1 {
 				time.Sleep(2 * time.Second)
 			}
+			mu.Lock()
+			defer mu.Unlock()
+			completed = append(completed, artifactType)
 			return true
 		},
 	}
@@ -441,8 +486,12 @@ func TestVerifySubjectInternalWithDecision_MultipleArtifacts_ExpectedResults(t *
 		t.Fatalf("verification expected to return two reports but actual count %d", len(result.VerifierReports))
 	}
 
-	if result.VerifierReports[0].(verifier.VerifierResult).ArtifactType != "test-type2" {
-		t.Fatalf("verification expected to return second artifact verifier report first")
+	if !reflect.DeepEqual(completed, []string{testArtifactType2, testArtifactType1}) {
+		t.Fatalf("expected the second artifact to be verified first, got %v", completed)
+	}
+
+	if result.VerifierReports[0].(verifier.VerifierResult).ArtifactType != testArtifactType1 {
+		t.Fatalf("verification expected to return the reports ordered by artifact type")
 	}
 }
 
@@ -867,7 +916,7 @@ func TestVerifySubject(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			ex := &Executor{tc.stores, tc.policyEnforcer, tc.verifiers, nil}
+			ex := &Executor{ReferrerStores: tc.stores, PolicyEnforcer: tc.policyEnforcer, Verifiers: tc.verifiers}
 
 			result, err := ex.VerifySubject(context.Background(), tc.params)
 			if (err != nil) != tc.expectErr {
@@ -879,3 +928,3647 @@ func TestVerifySubject(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifySubjectInternal_Explain(t *testing.T) {
+	testCases := []struct {
+		name           string
+		explain        bool
+		failedArtifact string
+		expectSuccess  bool
+		expectRule     string
+	}{
+		{
+			name:    "explain disabled",
+			explain: false,
+		},
+		{
+			name:           "failing result explained",
+			explain:        true,
+			failedArtifact: testArtifactType1,
+			expectSuccess:  false,
+			expectRule:     "artifactVerificationPolicies[test-type1]=all",
+		},
+		{
+			name:          "passing result explained",
+			explain:       true,
+			expectSuccess: true,
+			expectRule:    "artifactVerificationPolicies[test-type1]=all, artifactVerificationPolicies[test-type2]=any",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &mocks.TestStore{
+				References: []ocispecs.ReferenceDescriptor{
+					{ArtifactType: testArtifactType1},
+					{ArtifactType: testArtifactType2},
+				},
+				ResolveMap: map[string]digest.Digest{
+					"v1": digest.FromString("test"),
+				},
+			}
+			ver := &TestVerifier{
+				CanVerifyFunc: func(_ string) bool {
+					return true
+				},
+				VerifyResult: func(artifactType string) bool {
+					return artifactType != tc.failedArtifact
+				},
+			}
+			ex := &Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						testArtifactType1: policyTypes.AllVerifySuccess,
+						testArtifactType2: policyTypes.AnyVerifySuccess,
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers:      []verifier.ReferenceVerifier{ver},
+				Config:         &exConfig.ExecutorConfig{},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{
+				Subject: subject1,
+				Explain: tc.explain,
+			})
+			if err != nil {
+				t.Fatalf("verification failed with err %v", err)
+			}
+			if !tc.explain {
+				if result.Explanation != nil {
+					t.Fatalf("expected no explanation, got %+v", result.Explanation)
+				}
+				return
+			}
+			if result.IsSuccess != tc.expectSuccess {
+				t.Fatalf("expected result %v, got %v", tc.expectSuccess, result.IsSuccess)
+			}
+			if result.Explanation == nil {
+				t.Fatal("expected explanation to be populated")
+			}
+			if result.Explanation.IsSuccess != tc.expectSuccess {
+				t.Fatalf("expected explanation decision %v, got %v", tc.expectSuccess, result.Explanation.IsSuccess)
+			}
+			if result.Explanation.Policy.PolicyType != pt.ConfigPolicy {
+				t.Fatalf("expected policy type %s, got %s", pt.ConfigPolicy, result.Explanation.Policy.PolicyType)
+			}
+			if result.Explanation.Policy.MatchedRule != tc.expectRule {
+				t.Fatalf("expected matched rule %s, got %s", tc.expectRule, result.Explanation.Policy.MatchedRule)
+			}
+			if len(result.Explanation.Contributions) != 2 {
+				t.Fatalf("expected 2 verifier contributions, got %d", len(result.Explanation.Contributions))
+			}
+			for _, contribution := range result.Explanation.Contributions {
+				if contribution.IsSuccess != (contribution.ArtifactType != tc.failedArtifact) {
+					t.Fatalf("unexpected contribution %+v", contribution)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifySubjectInternal_Warnings(t *testing.T) {
+	testCases := []struct {
+		name             string
+		blockOnWarning   bool
+		expectSuccess    bool
+		expectedWarnings []string
+	}{
+		{
+			name:             "warning allowed",
+			expectSuccess:    true,
+			expectedWarnings: []string{"verifier-testVerifier: verified " + testArtifactType1},
+		},
+		{
+			name:             "warning blocks",
+			blockOnWarning:   true,
+			expectSuccess:    false,
+			expectedWarnings: []string{"verifier-testVerifier: verified " + testArtifactType1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &mocks.TestStore{
+				References: []ocispecs.ReferenceDescriptor{
+					{ArtifactType: testArtifactType1},
+					{ArtifactType: testArtifactType2},
+				},
+				ResolveMap: map[string]digest.Digest{
+					"v1": digest.FromString("test"),
+				},
+			}
+			ver := &TestVerifier{
+				CanVerifyFunc: func(_ string) bool {
+					return true
+				},
+				VerifyResult: func(_ string) bool {
+					return true
+				},
+				LevelFunc: func(artifactType string) string {
+					if artifactType == testArtifactType1 {
+						return verifier.LevelWarn
+					}
+					return ""
+				},
+			}
+			ex := &Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AllVerifySuccess,
+					},
+					BlockOnWarning: tc.blockOnWarning,
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers:      []verifier.ReferenceVerifier{ver},
+				Config:         &exConfig.ExecutorConfig{},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{
+				Subject: subject1,
+			})
+			if err != nil {
+				t.Fatalf("verification failed with err %v", err)
+			}
+			if result.IsSuccess != tc.expectSuccess {
+				t.Fatalf("expected result %v, got %v", tc.expectSuccess, result.IsSuccess)
+			}
+			if !reflect.DeepEqual(result.Warnings, tc.expectedWarnings) {
+				t.Fatalf("expected warnings %v, got %v", tc.expectedWarnings, result.Warnings)
+			}
+		})
+	}
+}
+
+func TestVerifySubjectInternal_SubjectAnnotations(t *testing.T) {
+	const sourceAnnotation = "org.opencontainers.image.source"
+	testCases := []struct {
+		name          string
+		annotations   map[string]string
+		expectSuccess bool
+	}{
+		{
+			name:          "annotation matches policy",
+			annotations:   map[string]string{sourceAnnotation: "https://github.com/myorg/net-monitor"},
+			expectSuccess: true,
+		},
+		{
+			name:          "annotation does not match policy",
+			annotations:   map[string]string{sourceAnnotation: "https://github.com/otherorg/net-monitor"},
+			expectSuccess: false,
+		},
+		{
+			name:          "annotation missing",
+			expectSuccess: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subjectDesc := oci.Descriptor{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    digest.Digest(subjectDigest),
+			}
+			store := &mocks.MemoryTestStore{
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDesc.Digest: {Descriptor: subjectDesc},
+				},
+				Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+					subjectDesc.Digest: {{ArtifactType: testArtifactType1}},
+				},
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					subjectDesc.Digest: {MediaType: oci.MediaTypeImageManifest, Annotations: tc.annotations},
+				},
+			}
+			ver := &TestVerifier{
+				CanVerifyFunc: func(_ string) bool {
+					return true
+				},
+				VerifyResult: func(_ string) bool {
+					return true
+				},
+			}
+			ex := &Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AllVerifySuccess,
+					},
+					SubjectAnnotations: map[string]*regexp.Regexp{
+						sourceAnnotation: regexp.MustCompile(`^https://github\.com/myorg/`),
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers:      []verifier.ReferenceVerifier{ver},
+				Config:         &exConfig.ExecutorConfig{},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{
+				Subject: "localhost:5000/net-monitor@" + subjectDigest,
+				Explain: true,
+			})
+			if err != nil {
+				t.Fatalf("verification failed with err %v", err)
+			}
+			if result.IsSuccess != tc.expectSuccess {
+				t.Fatalf("expected result %v, got %v", tc.expectSuccess, result.IsSuccess)
+			}
+			subject := result.Explanation.Policy.Input.(map[string]interface{})["subject"].(types.Subject)
+			if subject.Digest != subjectDigest {
+				t.Fatalf("expected subject digest %s, got %s", subjectDigest, subject.Digest)
+			}
+			if subject.Annotations[sourceAnnotation] != tc.annotations[sourceAnnotation] {
+				t.Fatalf("expected subject annotations %v, got %v", tc.annotations, subject.Annotations)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_SubjectAnnotations_NestedReferrer(t *testing.T) {
+	const sourceAnnotation = "org.opencontainers.image.source"
+	subjectDesc := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.Digest(subjectDigest)}
+	sbomDesc := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("sbom")}
+	store := &mocks.MemoryTestStore{
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			subjectDesc.Digest: {Descriptor: subjectDesc},
+			sbomDesc.Digest:    {Descriptor: sbomDesc},
+		},
+		Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+			subjectDesc.Digest: {{ArtifactType: mocks.SbomArtifactType, Descriptor: sbomDesc}},
+			sbomDesc.Digest:    {{ArtifactType: mocks.SignatureArtifactType, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}}},
+		},
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+			subjectDesc.Digest: {MediaType: oci.MediaTypeImageManifest, Annotations: map[string]string{sourceAnnotation: "https://github.com/myorg/net-monitor"}},
+			sbomDesc.Digest:    {MediaType: oci.MediaTypeImageManifest},
+		},
+	}
+	ex := Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": policyTypes.AllVerifySuccess,
+			},
+			SubjectAnnotations: map[string]*regexp.Regexp{
+				sourceAnnotation: regexp.MustCompile(`^https://github\.com/myorg/`),
+			},
+		},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc:    func(at string) bool { return at == mocks.SbomArtifactType },
+				VerifyResult:     func(_ string) bool { return true },
+				nestedReferences: []string{"string-content-does-not-matter"},
+			},
+			&TestVerifier{
+				CanVerifyFunc: func(at string) bool { return at == mocks.SignatureArtifactType },
+				VerifyResult:  func(_ string) bool { return true },
+			},
+		},
+		Config: &exConfig.ExecutorConfig{},
+	}
+
+	// the sbom manifest does not carry the annotation required of the image
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: "localhost:5000/net-monitor@" + subjectDigest})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.IsSuccess {
+		t.Fatalf("expected the verification to succeed, got %+v", result)
+	}
+}
+
+// manifestCountingStore counts the manifests fetched from it.
+type manifestCountingStore struct {
+	*mocks.MemoryTestStore
+	fetches atomic.Int32
+}
+
+func (s *manifestCountingStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	s.fetches.Add(1)
+	return s.MemoryTestStore.GetReferenceManifest(ctx, subjectReference, referenceDesc)
+}
+
+func TestVerifySubjectInternal_SubjectManifestFetchedForPolicy(t *testing.T) {
+	testCases := []struct {
+		name               string
+		subjectAnnotations map[string]*regexp.Regexp
+		expectFetched      bool
+	}{
+		{
+			name:          "policy does not read annotations",
+			expectFetched: false,
+		},
+		{
+			name: "policy reads annotations",
+			subjectAnnotations: map[string]*regexp.Regexp{
+				"org.opencontainers.image.source": regexp.MustCompile(`.*`),
+			},
+			expectFetched: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subjectDesc := oci.Descriptor{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    digest.Digest(subjectDigest),
+			}
+			store := &manifestCountingStore{MemoryTestStore: &mocks.MemoryTestStore{
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDesc.Digest: {Descriptor: subjectDesc},
+				},
+				Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+					subjectDesc.Digest: {{ArtifactType: testArtifactType1}},
+				},
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					subjectDesc.Digest: {MediaType: oci.MediaTypeImageManifest, Annotations: map[string]string{"org.opencontainers.image.source": "https://github.com/myorg/net-monitor"}},
+				},
+			}}
+			ver := &TestVerifier{
+				CanVerifyFunc: func(_ string) bool {
+					return true
+				},
+				VerifyResult: func(_ string) bool {
+					return true
+				},
+			}
+			ex := &Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AllVerifySuccess,
+					},
+					SubjectAnnotations: tc.subjectAnnotations,
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers:      []verifier.ReferenceVerifier{ver},
+				Config:         &exConfig.ExecutorConfig{},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{
+				Subject: "localhost:5000/net-monitor@" + subjectDigest,
+			})
+			if err != nil {
+				t.Fatalf("verification failed with err %v", err)
+			}
+			if !result.IsSuccess {
+				t.Fatalf("expected verification to succeed")
+			}
+			if fetched := store.fetches.Load() > 0; fetched != tc.expectFetched {
+				t.Fatalf("expected subject manifest fetched %v, got %v", tc.expectFetched, fetched)
+			}
+		})
+	}
+}
+
+func TestVerifySubjectInternal_Verify_NoReferrers_DefaultOnNoMatch(t *testing.T) {
+	testDigest := digest.FromString("test")
+	cases := []struct {
+		desc              string
+		defaultOnNoMatch  policyTypes.NoMatchVerifyPolicy
+		expectedIsSuccess bool
+	}{
+		{
+			desc:              "allow on empty",
+			defaultOnNoMatch:  policyTypes.AllowOnNoMatch,
+			expectedIsSuccess: true,
+		},
+		{
+			desc:             "fail closed",
+			defaultOnNoMatch: policyTypes.DenyOnNoMatch,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ex := &Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					DefaultOnNoMatch: tc.defaultOnNoMatch,
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{&mocks.TestStore{
+					ResolveMap: map[string]digest.Digest{
+						"v1": testDigest,
+					},
+				}},
+				Verifiers: []verifier.ReferenceVerifier{&TestVerifier{}},
+				Config: &exConfig.ExecutorConfig{
+					VerificationRequestTimeout: nil,
+					MutationRequestTimeout:     nil,
+				},
+			}
+
+			verifyParameters := e.VerifyParameters{
+				Subject: "localhost:5000/net-monitor:v1",
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), verifyParameters)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tc.expectedIsSuccess {
+				t.Fatalf("expected IsSuccess %v, got %v", tc.expectedIsSuccess, result.IsSuccess)
+			}
+			if len(result.VerifierReports) != 1 {
+				t.Fatalf("expected the report of the applied default, got %d reports", len(result.VerifierReports))
+			}
+		})
+	}
+}
+
+func TestVerifySubject_DefaultOnNoMatch_NestedReferrer(t *testing.T) {
+	sbomDigest := digest.FromString("sbom")
+	ex := Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": "all",
+			},
+			DefaultOnNoMatch: policyTypes.AllowOnNoMatch,
+		},
+		ReferrerStores: []referrerstore.ReferrerStore{&mockStore{
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				subjectDigest: {{ArtifactType: mocks.SbomArtifactType, Descriptor: oci.Descriptor{Digest: sbomDigest}}},
+			},
+		}},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc:    func(at string) bool { return at == mocks.SbomArtifactType },
+				VerifyResult:     func(_ string) bool { return true },
+				nestedReferences: []string{"string-content-does-not-matter"},
+			},
+		},
+		Config: &exConfig.ExecutorConfig{},
+	}
+
+	// the nested sbom has no signature, which the default must not allow
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.IsSuccess {
+		t.Fatalf("expected the verification to fail on the unsigned nested sbom, got %+v", result)
+	}
+	if len(result.VerifierReports) != 1 {
+		t.Fatalf("expected the report of the sbom, got %d reports", len(result.VerifierReports))
+	}
+	if report := result.VerifierReports[0].(verifier.VerifierResult); report.Message != "nested verification failed" {
+		t.Fatalf("expected the sbom to fail its nested verification, got %q", report.Message)
+	}
+}
+
+type orderedVerifier struct {
+	name         string
+	artifactType string
+	isSuccess    bool
+	mu           *sync.Mutex
+	calls        *[]string
+}
+
+func (v *orderedVerifier) Name() string {
+	return v.name
+}
+
+func (v *orderedVerifier) Type() string {
+	return "orderedVerifier"
+}
+
+func (v *orderedVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	return referenceDescriptor.ArtifactType == v.artifactType
+}
+
+func (v *orderedVerifier) Verify(_ context.Context,
+	_ common.Reference,
+	_ ocispecs.ReferenceDescriptor,
+	_ referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	*v.calls = append(*v.calls, v.name)
+	return verifier.VerifierResult{IsSuccess: v.isSuccess, VerifierName: v.name}, nil
+}
+
+func (v *orderedVerifier) GetNestedReferences() []string {
+	return nil
+}
+
+func TestVerifySubjectInternal_DuplicateReferrers(t *testing.T) {
+	signature := ocispecs.ReferenceDescriptor{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}}
+	for _, sequential := range []bool{false, true} {
+		var mu sync.Mutex
+		calls := []string{}
+		// the signature is listed twice by a store, e.g. under the signature
+		// tag and as a referrer, and once more by a second store
+		ex := Executor{
+			PolicyEnforcer: &mockPolicyProvider{result: true},
+			ReferrerStores: []referrerstore.ReferrerStore{
+				&mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{subjectDigest: {signature, signature}}},
+				&mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{subjectDigest: {signature}}},
+			},
+			Verifiers: []verifier.ReferenceVerifier{
+				&orderedVerifier{name: "verifier", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+			},
+			Config: &exConfig.ExecutorConfig{ShortCircuitOnFail: sequential},
+		}
+
+		result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(calls) != 1 || len(result.VerifierReports) != 1 {
+			t.Fatalf("expected the signature to be verified and reported once in sequential %t, got %d calls and %d reports", sequential, len(calls), len(result.VerifierReports))
+		}
+	}
+}
+
+func TestVerifySubjectInternal_VerifierOrder(t *testing.T) {
+	testCases := []struct {
+		name               string
+		policyType         string
+		order              []string
+		shortCircuitOnFail bool
+		failureDecisive    bool
+		cheapSucceeds      bool
+		expectedCalls      []string
+	}{
+		{
+			name:          "configured order is respected",
+			order:         []string{"cheap", "expensive"},
+			cheapSucceeds: true,
+			expectedCalls: []string{"cheap", "cheap", "expensive"},
+		},
+		{
+			name:          "failure does not skip without short circuit",
+			order:         []string{"cheap", "expensive"},
+			expectedCalls: []string{"cheap", "cheap", "expensive"},
+		},
+		{
+			name:               "later verifiers are skipped on short circuit",
+			order:              []string{"cheap", "expensive"},
+			shortCircuitOnFail: true,
+			failureDecisive:    true,
+			expectedCalls:      []string{"cheap"},
+		},
+		{
+			name:               "failure the policy tolerates does not short circuit",
+			order:              []string{"cheap", "expensive"},
+			shortCircuitOnFail: true,
+			expectedCalls:      []string{"cheap", "cheap", "expensive"},
+		},
+		{
+			name:               "verifiers keep configured list order without order option",
+			shortCircuitOnFail: true,
+			cheapSucceeds:      true,
+			expectedCalls:      []string{"expensive", "cheap", "cheap"},
+		},
+		{
+			name:               "rego policy verifiers are skipped on short circuit",
+			policyType:         pt.RegoPolicy,
+			order:              []string{"cheap", "expensive"},
+			shortCircuitOnFail: true,
+			failureDecisive:    true,
+			expectedCalls:      []string{"cheap"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := []string{}
+			store := &mockStore{
+				referrers: map[string][]ocispecs.ReferenceDescriptor{
+					subjectDigest: {
+						{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("expensive")}},
+						{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("cheap1")}},
+						{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("cheap2")}},
+					},
+				},
+			}
+			ex := Executor{
+				PolicyEnforcer: &mockPolicyProvider{result: true, policyType: tc.policyType, failureDecisive: tc.failureDecisive},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "expensive", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+					&orderedVerifier{name: "cheap", artifactType: testArtifactType2, isSuccess: tc.cheapSucceeds, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{
+					Order:              tc.order,
+					ShortCircuitOnFail: tc.shortCircuitOnFail,
+				},
+			}
+
+			if _, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Fatalf("expected verifier calls %v, got %v", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestVerifySubjectInternal_PassOnFirstTrusted(t *testing.T) {
+	testCases := []struct {
+		name              string
+		policyType        string
+		order             []string
+		trustedSucceeds   bool
+		expectedCalls     []string
+		expectedSuccess   bool
+		expectedEarlyExit *types.EarlyExit
+	}{
+		{
+			name:            "remaining verifiers are skipped after a trusted pass",
+			order:           []string{"notation", "sbom"},
+			trustedSucceeds: true,
+			expectedCalls:   []string{"notation"},
+			expectedSuccess: true,
+			expectedEarlyExit: &types.EarlyExit{
+				Verifier:          "notation",
+				ReferenceDigest:   digest.FromString("signature").String(),
+				SkippedReferences: 2,
+			},
+		},
+		{
+			name:          "all verifiers run if the trusted verifier fails",
+			order:         []string{"notation", "sbom"},
+			expectedCalls: []string{"notation", "sbom", "sbom"},
+		},
+		{
+			name:            "trusted verifier passes after the verifiers listed before it",
+			trustedSucceeds: true,
+			expectedCalls:   []string{"sbom", "sbom", "notation"},
+			expectedSuccess: true,
+			expectedEarlyExit: &types.EarlyExit{
+				Verifier:          "notation",
+				ReferenceDigest:   digest.FromString("signature").String(),
+				SkippedReferences: 0,
+			},
+		},
+		{
+			name:            "rego policy verifiers are skipped after a trusted pass",
+			policyType:      pt.RegoPolicy,
+			order:           []string{"notation", "sbom"},
+			trustedSucceeds: true,
+			expectedCalls:   []string{"notation"},
+			expectedSuccess: true,
+			expectedEarlyExit: &types.EarlyExit{
+				Verifier:          "notation",
+				ReferenceDigest:   digest.FromString("signature").String(),
+				SkippedReferences: 2,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := []string{}
+			store := &mockStore{
+				referrers: map[string][]ocispecs.ReferenceDescriptor{
+					subjectDigest: {
+						{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("sbom1")}},
+						{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}},
+						{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("sbom2")}},
+					},
+				},
+			}
+			ex := Executor{
+				// the policy denies the subject unless it is bypassed by the
+				// trusted pass
+				PolicyEnforcer: &mockPolicyProvider{result: false, policyType: tc.policyType},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "sbom", artifactType: testArtifactType2, isSuccess: true, mu: &mu, calls: &calls},
+					&orderedVerifier{name: "notation", artifactType: testArtifactType1, isSuccess: tc.trustedSucceeds, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{
+					Order:              tc.order,
+					PassOnFirstTrusted: []string{"notation"},
+				},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Fatalf("expected verifier calls %v, got %v", tc.expectedCalls, calls)
+			}
+			if result.IsSuccess != tc.expectedSuccess {
+				t.Fatalf("expected success %v, got %v", tc.expectedSuccess, result.IsSuccess)
+			}
+			if !reflect.DeepEqual(result.EarlyExit, tc.expectedEarlyExit) {
+				t.Fatalf("expected early exit %+v, got %+v", tc.expectedEarlyExit, result.EarlyExit)
+			}
+		})
+	}
+}
+
+func TestVerifySubjectInternal_UnknownArtifactType(t *testing.T) {
+	testCases := []struct {
+		name                string
+		unknownArtifactType string
+		mappings            map[string]string
+		expectErr           bool
+		expectedSuccess     bool
+		expectedReports     int
+		expectedCalls       []string
+		expectedUnmatched   []string
+	}{
+		{
+			name:          "unknown artifact type is skipped by default",
+			expectErr:     true,
+			expectedCalls: []string{},
+		},
+		{
+			name:                "unknown artifact type reports a warning",
+			unknownArtifactType: "warn",
+			expectedSuccess:     true,
+			expectedReports:     1,
+			expectedCalls:       []string{},
+			expectedUnmatched:   []string{"unknown-type"},
+		},
+		{
+			name:                "unknown artifact type fails verification",
+			unknownArtifactType: "fail",
+			expectedReports:     1,
+			expectedCalls:       []string{},
+			expectedUnmatched:   []string{"unknown-type"},
+		},
+		{
+			name:                "mapped artifact type is routed to verifier",
+			unknownArtifactType: "fail",
+			mappings:            map[string]string{"unknown-type": "routed"},
+			expectedSuccess:     true,
+			expectedReports:     1,
+			expectedCalls:       []string{"routed"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := []string{}
+			store := &mockStore{
+				referrers: map[string][]ocispecs.ReferenceDescriptor{
+					subjectDigest: {
+						{ArtifactType: "unknown-type", Descriptor: oci.Descriptor{Digest: digest.FromString("unknown")}},
+					},
+				},
+			}
+			ex := Executor{
+				PolicyEnforcer: &mockPolicyProvider{result: true},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "routed", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{
+					ArtifactTypeMappings: tc.mappings,
+					UnknownArtifactType:  tc.unknownArtifactType,
+				},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1})
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if len(result.VerifierReports) != tc.expectedReports {
+				t.Fatalf("expected %d reports, got %d", tc.expectedReports, len(result.VerifierReports))
+			}
+			for _, report := range result.VerifierReports {
+				if report.(verifier.VerifierResult).IsSuccess != tc.expectedSuccess {
+					t.Fatalf("expected report success %v, got %v", tc.expectedSuccess, !tc.expectedSuccess)
+				}
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Fatalf("expected verifier calls %v, got %v", tc.expectedCalls, calls)
+			}
+			if !reflect.DeepEqual(result.UnmatchedArtifactTypes, tc.expectedUnmatched) {
+				t.Fatalf("expected unmatched artifact types %v, got %v", tc.expectedUnmatched, result.UnmatchedArtifactTypes)
+			}
+		})
+	}
+}
+
+func TestVerifySubjectInternal_UnmatchedArtifactTypes_NestedFailure(t *testing.T) {
+	ex := Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": "all",
+			},
+		},
+		ReferrerStores: []referrerstore.ReferrerStore{&mockStore{
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				subjectDigest: {{ArtifactType: mocks.SbomArtifactType, Descriptor: oci.Descriptor{Digest: digest.FromString("sbom")}}},
+			},
+		}},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc:    func(at string) bool { return at == mocks.SbomArtifactType },
+				VerifyResult:     func(_ string) bool { return true },
+				nestedReferences: []string{"string-content-does-not-matter"},
+			},
+		},
+		Config: &exConfig.ExecutorConfig{UnknownArtifactType: "fail"},
+	}
+
+	// the failed verification of the unsigned sbom is reported without a
+	// verifier, yet a verifier is configured for the sbom
+	result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.IsSuccess {
+		t.Fatalf("expected the verification to fail on the unsigned nested sbom")
+	}
+	if len(result.UnmatchedArtifactTypes) != 0 {
+		t.Fatalf("expected no unmatched artifact types, got %q", result.UnmatchedArtifactTypes)
+	}
+}
+
+func TestVerifySubjectInternal_ArtifactTypeAliases(t *testing.T) {
+	const cycloneDX = "application/vnd.cyclonedx+json"
+	testCases := []struct {
+		name          string
+		aliases       map[string]string
+		order         []string
+		expectedCalls []string
+	}{
+		{
+			name:          "aliases are not routed without normalization",
+			expectedCalls: []string{"cyclonedx"},
+		},
+		{
+			name:          "aliased types are routed to the verifier of the artifact type",
+			aliases:       map[string]string{"sbom/cyclonedx": cycloneDX, "cyclonedx": cycloneDX},
+			expectedCalls: []string{"cyclonedx", "cyclonedx", "cyclonedx"},
+		},
+		{
+			name:          "aliased types are routed when verifying in order",
+			aliases:       map[string]string{"sbom/cyclonedx": cycloneDX, "cyclonedx": cycloneDX},
+			order:         []string{"spdx", "cyclonedx"},
+			expectedCalls: []string{"cyclonedx", "cyclonedx", "cyclonedx"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := []string{}
+			store := &mockStore{
+				referrers: map[string][]ocispecs.ReferenceDescriptor{
+					subjectDigest: {
+						{ArtifactType: cycloneDX, Descriptor: oci.Descriptor{Digest: digest.FromString("canonical")}},
+						{ArtifactType: "sbom/cyclonedx", Descriptor: oci.Descriptor{Digest: digest.FromString("sbom")}},
+						{ArtifactType: "cyclonedx", Descriptor: oci.Descriptor{Digest: digest.FromString("short")}},
+					},
+				},
+			}
+			ex := Executor{
+				PolicyEnforcer: &mockPolicyProvider{result: true},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "spdx", artifactType: "application/spdx+json", isSuccess: true, mu: &mu, calls: &calls},
+					&orderedVerifier{name: "cyclonedx", artifactType: cycloneDX, isSuccess: true, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{
+					ArtifactTypeAliases: tc.aliases,
+					Order:               tc.order,
+				},
+			}
+
+			if _, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Fatalf("expected verifier calls %v, got %v", tc.expectedCalls, calls)
+			}
+			// the listed referrers shared within the verification are not modified
+			if store.referrers[subjectDigest][1].ArtifactType != "sbom/cyclonedx" {
+				t.Fatalf("expected the listed referrers to be kept, got %+v", store.referrers[subjectDigest])
+			}
+		})
+	}
+}
+
+func TestVerifySubject_DecisionReason(t *testing.T) {
+	referrers := map[string][]ocispecs.ReferenceDescriptor{
+		subjectDigest: {
+			{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}},
+		},
+	}
+	testCases := []struct {
+		name             string
+		subject          string
+		referrers        map[string][]ocispecs.ReferenceDescriptor
+		noStores         bool
+		verifierSucceeds bool
+		policyResult     bool
+		expectedReason   types.DecisionReason
+	}{
+		{
+			name:             "verified",
+			subject:          subject1,
+			referrers:        referrers,
+			verifierSucceeds: true,
+			policyResult:     true,
+			expectedReason:   types.ReasonVerified,
+		},
+		{
+			name:           "verifier failure",
+			subject:        subject1,
+			referrers:      referrers,
+			expectedReason: types.ReasonVerifierFailed,
+		},
+		{
+			name:             "policy denies successful verifications",
+			subject:          subject1,
+			referrers:        referrers,
+			verifierSucceeds: true,
+			expectedReason:   types.ReasonPolicyDenied,
+		},
+		{
+			name:           "no referrers",
+			subject:        subject1,
+			referrers:      map[string][]ocispecs.ReferenceDescriptor{},
+			expectedReason: types.ReasonNoMatchingVerifier,
+		},
+		{
+			name:           "listing referrers fails",
+			subject:        subject1,
+			expectedReason: types.ReasonReferrerStoreError,
+		},
+		{
+			name:           "subject cannot be resolved",
+			subject:        subject1,
+			noStores:       true,
+			expectedReason: types.ReasonSubjectNotResolved,
+		},
+		{
+			name:           "invalid subject reference",
+			subject:        "localhost:5000/net-monitor:invalid:tag",
+			referrers:      referrers,
+			expectedReason: types.ReasonInvalidReference,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := []string{}
+			stores := []referrerstore.ReferrerStore{&mockStore{referrers: tc.referrers}}
+			if tc.noStores {
+				stores = nil
+			}
+			ex := Executor{
+				PolicyEnforcer: &mockPolicyProvider{result: tc.policyResult},
+				ReferrerStores: stores,
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "verifier", artifactType: testArtifactType1, isSuccess: tc.verifierSucceeds, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: tc.subject})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %s, got %s", tc.expectedReason, result.Reason)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_Notification(t *testing.T) {
+	referrers := map[string][]ocispecs.ReferenceDescriptor{
+		subjectDigest: {
+			{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}},
+		},
+	}
+	testCases := []struct {
+		name             string
+		mode             string
+		verifierSucceeds bool
+		expectNotified   bool
+	}{
+		{
+			name:           "failure is notified",
+			expectNotified: true,
+		},
+		{
+			name:             "success is not notified by default",
+			verifierSucceeds: true,
+		},
+		{
+			name:             "success is notified in always mode",
+			mode:             notification.ModeAlways,
+			verifierSucceeds: true,
+			expectNotified:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			received := make(chan notification.Summary, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var summary notification.Summary
+				if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+					t.Errorf("failed to decode notification: %v", err)
+				}
+				received <- summary
+				// a failing webhook must not affect the verification result
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			var mu sync.Mutex
+			calls := []string{}
+			maxRetries := 0
+			ex := Executor{
+				PolicyEnforcer: &mockPolicyProvider{result: tc.verifierSucceeds},
+				ReferrerStores: []referrerstore.ReferrerStore{&mockStore{referrers: referrers}},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "verifier", artifactType: testArtifactType1, isSuccess: tc.verifierSucceeds, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{
+					Notification: &notification.Config{URL: server.URL, Mode: tc.mode, MaxRetries: &maxRetries},
+				},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tc.verifierSucceeds {
+				t.Fatalf("expected success %v, got %v", tc.verifierSucceeds, result.IsSuccess)
+			}
+
+			select {
+			case summary := <-received:
+				if !tc.expectNotified {
+					t.Fatalf("unexpected notification %+v", summary)
+				}
+				if summary.Subject != subject1 || summary.IsSuccess != result.IsSuccess || summary.Reason != result.Reason {
+					t.Fatalf("unexpected notification %+v", summary)
+				}
+				if len(summary.Verifiers) != 1 || summary.Verifiers[0].VerifierName != "verifier" {
+					t.Fatalf("unexpected verifier summary %+v", summary.Verifiers)
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tc.expectNotified {
+					t.Fatalf("expected a notification")
+				}
+			}
+		})
+	}
+}
+
+func TestVerifySubject_NestedNotification(t *testing.T) {
+	var mu sync.Mutex
+	notified := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var summary notification.Summary
+		if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+		mu.Lock()
+		notified = append(notified, summary.Subject)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	maxRetries := 0
+	ex := Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": "all",
+			}},
+		ReferrerStores: []referrerstore.ReferrerStore{mocks.CreateNewTestStoreForNestedSbom()},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc:    func(at string) bool { return at == mocks.SbomArtifactType },
+				VerifyResult:     func(_ string) bool { return true },
+				nestedReferences: []string{"string-content-does-not-matter"},
+			},
+			&TestVerifier{
+				CanVerifyFunc: func(at string) bool { return at == mocks.SignatureArtifactType },
+				VerifyResult:  func(_ string) bool { return true },
+			},
+		},
+		Config: &exConfig.ExecutorConfig{
+			Notification: &notification.Config{URL: server.URL, Mode: notification.ModeAlways, MaxRetries: &maxRetries},
+		},
+	}
+
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: mocks.TestSubjectWithDigest})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.IsSuccess {
+		t.Fatalf("expected the verification to succeed, got %+v", result)
+	}
+
+	// the nested sbom signature must not be notified on its own
+	time.Sleep(200 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(notified) != 1 || notified[0] != mocks.TestSubjectWithDigest {
+		t.Fatalf("expected one notification of subject %s, got %v", mocks.TestSubjectWithDigest, notified)
+	}
+}
+
+func TestVerifySubject_FailurePolicy(t *testing.T) {
+	referrers := map[string][]ocispecs.ReferenceDescriptor{
+		subjectDigest: {
+			{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}},
+		},
+	}
+	testCases := []struct {
+		name             string
+		failurePolicy    string
+		subject          string
+		referrers        map[string][]ocispecs.ReferenceDescriptor
+		listErr          error
+		noStores         bool
+		verifierSucceeds bool
+		policyResult     bool
+		expectedSuccess  bool
+		expectedDegraded bool
+		expectedReason   types.DecisionReason
+	}{
+		{
+			name:             "registry unavailable with open policy",
+			failurePolicy:    exConfig.FailurePolicyOpen,
+			subject:          subject1,
+			listErr:          &errcode.ErrorResponse{StatusCode: http.StatusServiceUnavailable},
+			expectedSuccess:  true,
+			expectedDegraded: true,
+			expectedReason:   types.ReasonReferrerStoreError,
+		},
+		{
+			name:           "registry denies the request with open policy",
+			failurePolicy:  exConfig.FailurePolicyOpen,
+			subject:        subject1,
+			listErr:        &errcode.ErrorResponse{StatusCode: http.StatusUnauthorized},
+			expectedReason: types.ReasonReferrerStoreError,
+		},
+		{
+			name:           "listing referrers fails without a cause with open policy",
+			failurePolicy:  exConfig.FailurePolicyOpen,
+			subject:        subject1,
+			expectedReason: types.ReasonReferrerStoreError,
+		},
+		{
+			name:           "subject cannot be resolved with open policy",
+			failurePolicy:  exConfig.FailurePolicyOpen,
+			subject:        subject1,
+			noStores:       true,
+			expectedReason: types.ReasonSubjectNotResolved,
+		},
+		{
+			name:           "listing referrers fails with closed policy",
+			failurePolicy:  exConfig.FailurePolicyClosed,
+			subject:        subject1,
+			expectedReason: types.ReasonReferrerStoreError,
+		},
+		{
+			name:           "subject cannot be resolved by default",
+			subject:        subject1,
+			noStores:       true,
+			expectedReason: types.ReasonSubjectNotResolved,
+		},
+		{
+			name:           "verifier failure with open policy",
+			failurePolicy:  exConfig.FailurePolicyOpen,
+			subject:        subject1,
+			referrers:      referrers,
+			expectedReason: types.ReasonVerifierFailed,
+		},
+		{
+			name:             "policy denial with open policy",
+			failurePolicy:    exConfig.FailurePolicyOpen,
+			subject:          subject1,
+			referrers:        referrers,
+			verifierSucceeds: true,
+			expectedReason:   types.ReasonPolicyDenied,
+		},
+		{
+			name:           "no referrers with open policy",
+			failurePolicy:  exConfig.FailurePolicyOpen,
+			subject:        subject1,
+			referrers:      map[string][]ocispecs.ReferenceDescriptor{},
+			expectedReason: types.ReasonNoMatchingVerifier,
+		},
+		{
+			name:           "invalid subject reference with open policy",
+			failurePolicy:  exConfig.FailurePolicyOpen,
+			subject:        "localhost:5000/net-monitor:invalid:tag",
+			referrers:      referrers,
+			expectedReason: types.ReasonInvalidReference,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := []string{}
+			stores := []referrerstore.ReferrerStore{&mockStore{referrers: tc.referrers, listErr: tc.listErr}}
+			if tc.noStores {
+				stores = nil
+			}
+			ex := Executor{
+				PolicyEnforcer: &mockPolicyProvider{result: tc.policyResult},
+				ReferrerStores: stores,
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "verifier", artifactType: testArtifactType1, isSuccess: tc.verifierSucceeds, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{FailurePolicy: tc.failurePolicy},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: tc.subject})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tc.expectedSuccess {
+				t.Fatalf("expected success %v, got %v", tc.expectedSuccess, result.IsSuccess)
+			}
+			if result.Degraded != tc.expectedDegraded {
+				t.Fatalf("expected degraded %v, got %v", tc.expectedDegraded, result.Degraded)
+			}
+			if tc.expectedDegraded && len(result.Warnings) == 0 {
+				t.Fatalf("expected a warning for the degraded decision")
+			}
+			if result.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %s, got %s", tc.expectedReason, result.Reason)
+			}
+		})
+	}
+}
+
+func TestDecisionReason(t *testing.T) {
+	testCases := []struct {
+		name          string
+		contributions []types.VerifierContribution
+		expected      types.DecisionReason
+	}{
+		{
+			name:          "signature verifier failure",
+			contributions: []types.VerifierContribution{{VerifierType: "notation", ArtifactType: "application/vnd.cncf.notary.signature"}},
+			expected:      types.ReasonSignatureInvalid,
+		},
+		{
+			name:          "signature plugin failure",
+			contributions: []types.VerifierContribution{{VerifierType: "custom", ArtifactType: "application/vnd.dev.cosign.artifact.sig.v1+json"}},
+			expected:      types.ReasonSignatureInvalid,
+		},
+		{
+			name:          "sbom verifier failure",
+			contributions: []types.VerifierContribution{{VerifierType: "sbom", ArtifactType: "application/spdx+json"}},
+			expected:      types.ReasonSBOMRejected,
+		},
+		{
+			name:          "vulnerability report verifier failure",
+			contributions: []types.VerifierContribution{{VerifierType: "vulnerabilityreport", ArtifactType: "application/sarif+json"}},
+			expected:      types.ReasonVulnerabilityReportRejected,
+		},
+		{
+			name:          "other verifier failure",
+			contributions: []types.VerifierContribution{{VerifierType: "schemavalidator", ArtifactType: "application/vnd.aquasecurity.trivy.report.sarif.v1"}},
+			expected:      types.ReasonVerifierFailed,
+		},
+		{
+			name: "signature failure takes precedence",
+			contributions: []types.VerifierContribution{
+				{VerifierType: "sbom", ArtifactType: "application/spdx+json"},
+				{VerifierType: "cosign", ArtifactType: "application/vnd.dev.cosign.artifact.sig.v1+json"},
+			},
+			expected: types.ReasonSignatureInvalid,
+		},
+		{
+			name:          "timed out verifier",
+			contributions: []types.VerifierContribution{{VerifierType: "notation", Level: verifier.LevelTimeout}},
+			expected:      types.ReasonTimedOut,
+		},
+		{
+			name:          "no failure",
+			contributions: []types.VerifierContribution{{VerifierType: "notation", IsSuccess: true}},
+			expected:      types.ReasonPolicyDenied,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if reason := decisionReason(false, tc.contributions); reason != tc.expected {
+				t.Fatalf("expected reason %s, got %s", tc.expected, reason)
+			}
+		})
+	}
+}
+
+func TestIsInfrastructureError(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		expected bool
+	}{
+		{
+			name:     "registry unavailable",
+			err:      ratifyerrors.ErrorCodeRepositoryOperationFailure.WithDetail("failed to resolve subject").WithError(&errcode.ErrorResponse{StatusCode: http.StatusServiceUnavailable}),
+			expected: true,
+		},
+		{
+			name:     "registry throttled",
+			err:      ratifyerrors.ErrorCodeListReferrersFailure.WithError(&errcode.ErrorResponse{StatusCode: http.StatusTooManyRequests}),
+			expected: true,
+		},
+		{
+			name: "subject not found",
+			err:  ratifyerrors.ErrorCodeRepositoryOperationFailure.WithDetail("failed to resolve subject").WithError(&errcode.ErrorResponse{StatusCode: http.StatusNotFound}),
+		},
+		{
+			name: "registry unauthorized",
+			err:  ratifyerrors.ErrorCodeRepositoryOperationFailure.WithError(&errcode.ErrorResponse{StatusCode: http.StatusUnauthorized}),
+		},
+		{
+			name: "registry forbidden",
+			err:  ratifyerrors.ErrorCodeListReferrersFailure.WithError(&errcode.ErrorResponse{StatusCode: http.StatusForbidden}),
+		},
+		{
+			name:     "nested key management provider transport failure",
+			err:      ratifyerrors.ErrorCodeVerifyReferenceFailure.WithError(ratifyerrors.ErrorCodeKeyManagementProviderFailure.WithError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED})),
+			expected: true,
+		},
+		{
+			name: "store failure without a cause",
+			err:  ratifyerrors.ErrorCodeReferrerStoreFailure.WithDetail("failed to resolve subject"),
+		},
+		{
+			name: "memory budget exhausted",
+			err:  ratifyerrors.ErrorCodeResourceBusy.WithDetail("memory budget of 100 bytes is exhausted"),
+		},
+		{
+			name:     "throttled token request",
+			err:      ratifyerrors.ErrorCodeAuthDenied.WithError(ratifyerrors.ErrorCodeThrottled.WithDetail("token request throttled")),
+			expected: true,
+		},
+		{
+			name: "deadline of the verification",
+			ctx:  expired,
+			err:  ratifyerrors.ErrorCodeListReferrersFailure.WithError(&net.OpError{Op: "dial", Err: context.DeadlineExceeded}),
+		},
+		{
+			name: "invalid reference",
+			err:  ratifyerrors.ErrorCodeReferenceInvalid.WithDetail("invalid reference"),
+		},
+		{
+			name: "no verifier report",
+			err:  ratifyerrors.ErrorCodeNoVerifierReport.WithDetail("no verification results"),
+		},
+		{
+			name: "unknown error",
+			err:  fmt.Errorf("unexpected error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if actual := isInfrastructureError(ctx, tc.err); actual != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_DecisionPostProcessors(t *testing.T) {
+	postProcessors.mu.Lock()
+	registered := postProcessors.processors
+	postProcessors.processors = nil
+	postProcessors.mu.Unlock()
+	t.Cleanup(func() {
+		postProcessors.mu.Lock()
+		postProcessors.processors = registered
+		postProcessors.mu.Unlock()
+	})
+
+	var order []string
+	RegisterDecisionPostProcessor(DecisionPostProcessorFunc(func(_ context.Context, subject string, result *types.VerifyResult) error {
+		order = append(order, "audit")
+		if result.Annotations == nil {
+			result.Annotations = map[string]string{}
+		}
+		result.Annotations["audit.example.com/subject"] = subject
+		return nil
+	}))
+	RegisterDecisionPostProcessor(DecisionPostProcessorFunc(func(_ context.Context, _ string, result *types.VerifyResult) error {
+		order = append(order, "override")
+		result.Annotations["override.example.com/attempted"] = "true"
+		result.IsSuccess = true
+		result.Reason = types.ReasonVerified
+		return fmt.Errorf("post-processor failure")
+	}))
+
+	var mu sync.Mutex
+	calls := []string{}
+	ex := Executor{
+		PolicyEnforcer: &mockPolicyProvider{result: false},
+		ReferrerStores: []referrerstore.ReferrerStore{&mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{
+			subjectDigest: {
+				{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}},
+			},
+		}}},
+		Verifiers: []verifier.ReferenceVerifier{
+			&orderedVerifier{name: "verifier", artifactType: testArtifactType1, mu: &mu, calls: &calls},
+		},
+		Config: &exConfig.ExecutorConfig{},
+	}
+
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"audit", "override"}) {
+		t.Fatalf("expected post-processors to run in registration order, got %v", order)
+	}
+	expectedAnnotations := map[string]string{
+		"audit.example.com/subject":      subject1,
+		"override.example.com/attempted": "true",
+	}
+	if !reflect.DeepEqual(result.Annotations, expectedAnnotations) {
+		t.Fatalf("expected annotations %v, got %v", expectedAnnotations, result.Annotations)
+	}
+	if result.IsSuccess {
+		t.Fatalf("expected post-processors not to change the decision")
+	}
+	if result.Reason != types.ReasonVerifierFailed {
+		t.Fatalf("expected reason %s, got %s", types.ReasonVerifierFailed, result.Reason)
+	}
+}
+
+func TestVerifySubject_NestedDecisionPostProcessors(t *testing.T) {
+	postProcessors.mu.Lock()
+	registered := postProcessors.processors
+	postProcessors.processors = nil
+	postProcessors.mu.Unlock()
+	t.Cleanup(func() {
+		postProcessors.mu.Lock()
+		postProcessors.processors = registered
+		postProcessors.mu.Unlock()
+	})
+
+	var mu sync.Mutex
+	processed := []string{}
+	RegisterDecisionPostProcessor(DecisionPostProcessorFunc(func(_ context.Context, subject string, _ *types.VerifyResult) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, subject)
+		return nil
+	}))
+
+	ex := Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": "all",
+			}},
+		ReferrerStores: []referrerstore.ReferrerStore{mocks.CreateNewTestStoreForNestedSbom()},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc:    func(at string) bool { return at == mocks.SbomArtifactType },
+				VerifyResult:     func(_ string) bool { return true },
+				nestedReferences: []string{"string-content-does-not-matter"},
+			},
+			&TestVerifier{
+				CanVerifyFunc: func(at string) bool { return at == mocks.SignatureArtifactType },
+				VerifyResult:  func(_ string) bool { return true },
+			},
+		},
+		Config: &exConfig.ExecutorConfig{},
+	}
+
+	if _, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: mocks.TestSubjectWithDigest}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// the decision of the nested sbom must not be post-processed on its own
+	if !reflect.DeepEqual(processed, []string{mocks.TestSubjectWithDigest}) {
+		t.Fatalf("expected the decision of subject %s post-processed once, got %v", mocks.TestSubjectWithDigest, processed)
+	}
+}
+
+func TestVerifySubjectInternal_SubjectArtifactType(t *testing.T) {
+	const helmChartType = "application/vnd.cncf.helm.config.v1+json"
+	testCases := []struct {
+		name                string
+		subjectArtifactType string
+		expectedCalls       []string
+	}{
+		{
+			name:                "helm chart routed to mapped verifier",
+			subjectArtifactType: helmChartType,
+			expectedCalls:       []string{"helm-verifier"},
+		},
+		{
+			name:                "image routed to first verifier",
+			subjectArtifactType: oci.MediaTypeImageConfig,
+			expectedCalls:       []string{"image-verifier"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subjectDesc := oci.Descriptor{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    digest.Digest(subjectDigest),
+			}
+			store := &mocks.MemoryTestStore{
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDesc.Digest: {Descriptor: subjectDesc},
+				},
+				Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+					subjectDesc.Digest: {{ArtifactType: testArtifactType1}},
+				},
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					subjectDesc.Digest: {MediaType: oci.MediaTypeImageManifest, ArtifactType: tc.subjectArtifactType},
+				},
+			}
+			var mu sync.Mutex
+			calls := []string{}
+			ex := &Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AllVerifySuccess,
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "image-verifier", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+					&orderedVerifier{name: "helm-verifier", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{
+					SubjectArtifactTypeMappings: map[string][]string{
+						helmChartType: {"helm-verifier"},
+					},
+				},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{
+				Subject: "localhost:5000/net-monitor@" + subjectDigest,
+				Explain: true,
+			})
+			if err != nil {
+				t.Fatalf("verification failed with err %v", err)
+			}
+			if !result.IsSuccess {
+				t.Fatalf("expected verification to succeed")
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Fatalf("expected verifier calls %v, got %v", tc.expectedCalls, calls)
+			}
+			subject := result.Explanation.Policy.Input.(map[string]interface{})["subject"].(types.Subject)
+			if subject.ArtifactType != tc.subjectArtifactType {
+				t.Fatalf("expected subject artifact type %s, got %s", tc.subjectArtifactType, subject.ArtifactType)
+			}
+		})
+	}
+}
+
+func TestVerifySubjectInternal_VerifierConditions(t *testing.T) {
+	const roleLabel = "org.example.image-role"
+	testCases := []struct {
+		name          string
+		role          string
+		expectSuccess bool
+		expectedCalls []string
+		expectedLevel string
+	}{
+		{
+			name:          "base image skips vulnerability verification",
+			role:          "base",
+			expectSuccess: true,
+			expectedCalls: []string{},
+			expectedLevel: verifier.LevelSkip,
+		},
+		{
+			name:          "application image is verified",
+			role:          "application",
+			expectSuccess: false,
+			expectedCalls: []string{"vulnerability"},
+			expectedLevel: verifier.LevelFail,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subjectDesc := oci.Descriptor{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    digest.Digest(subjectDigest),
+			}
+			imageConfig, _ := json.Marshal(oci.Image{Config: oci.ImageConfig{Labels: map[string]string{roleLabel: tc.role}}})
+			configDigest := digest.FromBytes(imageConfig)
+			store := &mocks.MemoryTestStore{
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDesc.Digest: {Descriptor: subjectDesc},
+				},
+				Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+					subjectDesc.Digest: {{ArtifactType: testArtifactType1}},
+				},
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					subjectDesc.Digest: {MediaType: oci.MediaTypeImageManifest, Config: &oci.Descriptor{MediaType: oci.MediaTypeImageConfig, Digest: configDigest}},
+				},
+				Blobs: map[digest.Digest][]byte{
+					configDigest: imageConfig,
+				},
+			}
+			var mu sync.Mutex
+			calls := []string{}
+			ex := &Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AllVerifySuccess,
+					},
+					VerifierConditions: []policyConfig.VerifierCondition{
+						{
+							Verifiers: []string{"vulnerability"},
+							SkipWhen: &policyConfig.SubjectPredicate{
+								Labels: map[string]*regexp.Regexp{roleLabel: regexp.MustCompile("^(base|builder)$")},
+							},
+						},
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "vulnerability", artifactType: testArtifactType1, isSuccess: false, mu: &mu, calls: &calls},
+				},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{
+				Subject: "localhost:5000/net-monitor@" + subjectDigest,
+			})
+			if err != nil {
+				t.Fatalf("verification failed with err %v", err)
+			}
+			if result.IsSuccess != tc.expectSuccess {
+				t.Fatalf("expected success %v, got %v", tc.expectSuccess, result.IsSuccess)
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Fatalf("expected verifier calls %v, got %v", tc.expectedCalls, calls)
+			}
+			if len(result.VerifierReports) != 1 {
+				t.Fatalf("expected 1 verifier report, got %d", len(result.VerifierReports))
+			}
+			report := result.VerifierReports[0].(verifier.VerifierResult)
+			if report.GetLevel() != tc.expectedLevel {
+				t.Fatalf("expected report level %s, got %s", tc.expectedLevel, report.GetLevel())
+			}
+			if tc.expectedLevel == verifier.LevelSkip && !strings.Contains(report.Message, roleLabel) {
+				t.Fatalf("expected skip reason to mention label %s, got %q", roleLabel, report.Message)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_VerifierConditions_NestedReferrer(t *testing.T) {
+	const roleLabel = "org.example.image-role"
+	subjectDesc := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.Digest(subjectDigest)}
+	sbomDesc := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("sbom")}
+	imageConfig, _ := json.Marshal(oci.Image{Config: oci.ImageConfig{Labels: map[string]string{roleLabel: "application"}}})
+	configDigest := digest.FromBytes(imageConfig)
+	store := &mocks.MemoryTestStore{
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			subjectDesc.Digest: {Descriptor: subjectDesc},
+			sbomDesc.Digest:    {Descriptor: sbomDesc},
+		},
+		Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+			subjectDesc.Digest: {
+				{ArtifactType: mocks.SbomArtifactType, Descriptor: sbomDesc},
+				{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}},
+			},
+			sbomDesc.Digest: {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sbom signature")}}},
+		},
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+			subjectDesc.Digest: {MediaType: oci.MediaTypeImageManifest, Config: &oci.Descriptor{MediaType: oci.MediaTypeImageConfig, Digest: configDigest}},
+			sbomDesc.Digest:    {MediaType: oci.MediaTypeImageManifest},
+		},
+		Blobs: map[digest.Digest][]byte{
+			configDigest: imageConfig,
+		},
+	}
+	var mu sync.Mutex
+	calls := []string{}
+	ex := Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": policyTypes.AllVerifySuccess,
+			},
+			VerifierConditions: []policyConfig.VerifierCondition{
+				{
+					Verifiers: []string{"signature"},
+					ApplyWhen: &policyConfig.SubjectPredicate{
+						Labels: map[string]*regexp.Regexp{roleLabel: regexp.MustCompile("^application$")},
+					},
+				},
+			},
+		},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc:    func(at string) bool { return at == mocks.SbomArtifactType },
+				VerifyResult:     func(_ string) bool { return true },
+				nestedReferences: []string{"string-content-does-not-matter"},
+			},
+			&orderedVerifier{name: "signature", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+		},
+	}
+
+	// the sbom lacks the label of the image, its signature is verified since
+	// the conditions are evaluated against the image
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: "localhost:5000/net-monitor@" + subjectDigest})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.IsSuccess {
+		t.Fatalf("expected the verification to succeed, got %+v", result)
+	}
+	if !reflect.DeepEqual(calls, []string{"signature", "signature"}) {
+		t.Fatalf("expected the signatures of the image and of the sbom verified, got calls %v", calls)
+	}
+}
+
+func TestVerifySubjectInternal_ScorePolicy(t *testing.T) {
+	subjectDesc := oci.Descriptor{
+		MediaType: oci.MediaTypeImageManifest,
+		Digest:    digest.Digest(subjectDigest),
+	}
+	store := &mocks.MemoryTestStore{
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			subjectDesc.Digest: {Descriptor: subjectDesc},
+		},
+		Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+			subjectDesc.Digest: {{ArtifactType: testArtifactType1}, {ArtifactType: testArtifactType2}},
+		},
+	}
+	var mu sync.Mutex
+	calls := []string{}
+	ex := &Executor{
+		PolicyEnforcer: scorePolicy.PolicyEnforcer{
+			Threshold: 60,
+			Weights:   map[string]float64{"signature": 60, "vulnerability": 40},
+		},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers: []verifier.ReferenceVerifier{
+			&orderedVerifier{name: "signature", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+			&orderedVerifier{name: "vulnerability", artifactType: testArtifactType2, isSuccess: false, mu: &mu, calls: &calls},
+		},
+	}
+
+	result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{
+		Subject: "localhost:5000/net-monitor@" + subjectDigest,
+	})
+	if err != nil {
+		t.Fatalf("verification failed with err %v", err)
+	}
+	if !result.IsSuccess {
+		t.Fatalf("expected the subject to reach the threshold")
+	}
+	if result.Score == nil {
+		t.Fatalf("expected the score in the result")
+	}
+	if result.Score.Score != 60 || result.Score.Threshold != 60 || len(result.Score.Breakdown) != 2 {
+		t.Fatalf("unexpected score %+v", result.Score)
+	}
+}
+
+func TestVerifySubjectInternal_DisabledVerifier(t *testing.T) {
+	store := &mocks.TestStore{
+		References: []ocispecs.ReferenceDescriptor{{ArtifactType: testArtifactType1}},
+		ResolveMap: map[string]digest.Digest{"v1": digest.FromString("test")},
+	}
+	var mu sync.Mutex
+	calls := []string{}
+	ex := &Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": policyTypes.AllVerifySuccess,
+			},
+		},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers: []verifier.ReferenceVerifier{
+			&orderedVerifier{name: "flaky-scan", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+		},
+	}
+	verify := func() verifier.VerifierResult {
+		result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1})
+		if err != nil {
+			t.Fatalf("verification failed with err %v", err)
+		}
+		if !result.IsSuccess || len(result.VerifierReports) != 1 {
+			t.Fatalf("expected a single successful report, got %+v", result)
+		}
+		return result.VerifierReports[0].(verifier.VerifierResult)
+	}
+
+	verifier.SetEnabled("flaky-scan", false)
+	defer verifier.SetEnabled("flaky-scan", true)
+	report := verify()
+	if len(calls) != 0 {
+		t.Fatalf("expected the disabled verifier not to run, got calls %v", calls)
+	}
+	if report.GetLevel() != verifier.LevelSkip || !strings.Contains(report.Message, "disabled") {
+		t.Fatalf("expected a skip report noting the verifier is disabled, got %+v", report)
+	}
+
+	verifier.SetEnabled("flaky-scan", true)
+	report = verify()
+	if !reflect.DeepEqual(calls, []string{"flaky-scan"}) {
+		t.Fatalf("expected the re-enabled verifier to run, got calls %v", calls)
+	}
+	if report.GetLevel() != verifier.LevelPass {
+		t.Fatalf("expected a pass report, got %+v", report)
+	}
+}
+
+func TestVerifySubjectInternal_StableReportOrder(t *testing.T) {
+	var mu sync.Mutex
+	calls := []string{}
+	store := &mockStore{
+		referrers: map[string][]ocispecs.ReferenceDescriptor{
+			subjectDigest: {
+				{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("b")}},
+				{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("c")}},
+				{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("a")}},
+				{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("d")}},
+			},
+		},
+	}
+	ex := Executor{
+		PolicyEnforcer: &mockPolicyProvider{result: true},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers: []verifier.ReferenceVerifier{
+			&orderedVerifier{name: "verifier2", artifactType: testArtifactType2, isSuccess: true, mu: &mu, calls: &calls},
+			&orderedVerifier{name: "verifier1", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+		},
+		Config: &exConfig.ExecutorConfig{},
+	}
+
+	var expected []interface{}
+	for i := 0; i < 20; i++ {
+		result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(result.VerifierReports) != 4 {
+			t.Fatalf("expected 4 reports, got %d", len(result.VerifierReports))
+		}
+		for idx := 1; idx < len(result.VerifierReports); idx++ {
+			prev := result.VerifierReports[idx-1].(verifier.VerifierResult)
+			cur := result.VerifierReports[idx].(verifier.VerifierResult)
+			if prev.ArtifactType > cur.ArtifactType ||
+				(prev.ArtifactType == cur.ArtifactType && prev.ReferenceDigest > cur.ReferenceDigest) {
+				t.Fatalf("expected reports ordered by artifact type and digest, got %+v", result.VerifierReports)
+			}
+		}
+		if expected == nil {
+			expected = result.VerifierReports
+		} else if !reflect.DeepEqual(expected, result.VerifierReports) {
+			t.Fatalf("expected the report order to be stable across runs, got %+v and %+v", expected, result.VerifierReports)
+		}
+	}
+}
+
+// countingStore counts the referrer listings of every subject digest.
+type countingStore struct {
+	mockStore
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (s *countingStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	s.mu.Lock()
+	s.calls[subjectDesc.Digest.String()]++
+	s.mu.Unlock()
+	return s.mockStore.ListReferrers(ctx, subjectReference, artifactTypes, nextToken, subjectDesc)
+}
+
+func TestVerifySubjectInternal_SharedReferrerListing(t *testing.T) {
+	sbom1 := digest.FromString("sbom1")
+	sbom2 := digest.FromString("sbom2")
+	shared := digest.FromString("shared")
+	store := &countingStore{
+		mockStore: mockStore{
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				subjectDigest: {
+					{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: sbom1}},
+					{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: sbom2}},
+				},
+				// both sboms share a referrer whose referrers are listed once
+				sbom1.String(): {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: shared}}},
+				sbom2.String(): {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: shared}}},
+			},
+		},
+		calls: map[string]int{},
+	}
+	ex := Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": policyTypes.AllVerifySuccess,
+			},
+		},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc:    func(_ string) bool { return true },
+				VerifyResult:     func(_ string) bool { return true },
+				nestedReferences: []string{testArtifactType1},
+			},
+		},
+		Config: &exConfig.ExecutorConfig{},
+	}
+
+	result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result.VerifierReports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(result.VerifierReports))
+	}
+	for _, dgst := range []string{subjectDigest, sbom1.String(), sbom2.String(), shared.String()} {
+		if store.calls[dgst] != 1 {
+			t.Fatalf("expected referrers of %s to be listed once, got %d", dgst, store.calls[dgst])
+		}
+	}
+
+	// listings are not shared across verifications
+	if _, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: subject1}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if store.calls[subjectDigest] != 2 {
+		t.Fatalf("expected referrers of the subject to be listed again, got %d", store.calls[subjectDigest])
+	}
+}
+
+func TestListReferrers_ContextCanceled(t *testing.T) {
+	desc := &ocispecs.SubjectDescriptor{Descriptor: oci.Descriptor{Digest: subjectDigest}}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	store := &blockingStore{started: started, release: release}
+	ctx := withReferrerListings(context.Background())
+
+	go func() {
+		_, _ = listReferrers(ctx, store, common.Reference{}, nil, "", desc)
+	}()
+	<-started
+
+	waitCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := listReferrers(waitCtx, store, common.Reference{}, nil, "", desc); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the pending listing to be abandoned on cancellation, got %v", err)
+	}
+	close(release)
+}
+
+// blockingStore blocks listing referrers until released.
+type blockingStore struct {
+	mockStore
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) ListReferrers(_ context.Context, _ common.Reference, _ []string, _ string, _ *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	close(s.started)
+	<-s.release
+	return referrerstore.ListReferrersResult{}, nil
+}
+
+func TestVerifySubject_VerificationGuards(t *testing.T) {
+	sbom := digest.FromString("sbom")
+	// the subject has a single sbom with two signatures, the signatures are
+	// verified as subjects nested at depth 2
+	store := &mockStore{
+		referrers: map[string][]ocispecs.ReferenceDescriptor{
+			subjectDigest: {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: sbom}}},
+			sbom.String(): {
+				{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("sig1")}},
+				{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("sig2")}},
+			},
+		},
+	}
+	testCases := []struct {
+		name          string
+		config        exConfig.ExecutorConfig
+		expectedGuard string
+	}{
+		{
+			name: "no guards",
+		},
+		{
+			name:          "depth exceeded",
+			config:        exConfig.ExecutorConfig{MaxDepth: 1},
+			expectedGuard: GuardMaxDepth,
+		},
+		{
+			name:   "depth within the guard",
+			config: exConfig.ExecutorConfig{MaxDepth: 2},
+		},
+		{
+			name:          "referrers exceeded",
+			config:        exConfig.ExecutorConfig{MaxReferrersPerNode: 1},
+			expectedGuard: GuardMaxReferrersPerNode,
+		},
+		{
+			name:   "referrers within the guard",
+			config: exConfig.ExecutorConfig{MaxReferrersPerNode: 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := tc.config
+			ex := Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AnyVerifySuccess,
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&TestVerifier{
+						CanVerifyFunc:    func(_ string) bool { return true },
+						VerifyResult:     func(_ string) bool { return true },
+						nestedReferences: []string{testArtifactType1, testArtifactType2},
+					},
+				},
+				Config: &config,
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.ExceededGuard != tc.expectedGuard {
+				t.Fatalf("expected exceeded guard %q, got %q", tc.expectedGuard, result.ExceededGuard)
+			}
+			if tc.expectedGuard != "" && (result.IsSuccess || result.Reason != types.ReasonLimitExceeded) {
+				t.Fatalf("expected a failed verification with reason %s, got %+v", types.ReasonLimitExceeded, result)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_AllowedRegistries(t *testing.T) {
+	testCases := []struct {
+		name              string
+		allowedRegistries []string
+		pullThroughCaches map[string]string
+		expectedSuccess   bool
+		expectedReason    types.DecisionReason
+	}{
+		{
+			name:            "no allowed registries",
+			expectedSuccess: true,
+			expectedReason:  types.ReasonVerified,
+		},
+		{
+			name:              "approved host",
+			allowedRegistries: []string{"myregistry.azurecr.io", "LOCALHOST:5000"},
+			expectedSuccess:   true,
+			expectedReason:    types.ReasonVerified,
+		},
+		{
+			name:              "disapproved host",
+			allowedRegistries: []string{"myregistry.azurecr.io", "localhost:5001"},
+			expectedReason:    types.ReasonRegistryNotAllowed,
+		},
+		{
+			name:              "pull-through cache mapped to approved upstream",
+			allowedRegistries: []string{"myregistry.azurecr.io"},
+			pullThroughCaches: map[string]string{"localhost:5000": "myregistry.azurecr.io"},
+			expectedSuccess:   true,
+			expectedReason:    types.ReasonVerified,
+		},
+		{
+			name:              "pull-through cache evaluated as its upstream",
+			allowedRegistries: []string{"localhost:5000"},
+			pullThroughCaches: map[string]string{"localhost:5000": "myregistry.azurecr.io"},
+			expectedReason:    types.ReasonRegistryNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ex := Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AnyVerifySuccess,
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{&mockStore{
+					referrers: map[string][]ocispecs.ReferenceDescriptor{
+						subjectDigest: {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig")}}},
+					},
+				}},
+				Verifiers: []verifier.ReferenceVerifier{
+					&TestVerifier{
+						CanVerifyFunc: func(_ string) bool { return true },
+						VerifyResult:  func(_ string) bool { return true },
+					},
+				},
+				Config: &exConfig.ExecutorConfig{AllowedRegistries: tc.allowedRegistries, PullThroughCaches: tc.pullThroughCaches},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tc.expectedSuccess || result.Reason != tc.expectedReason {
+				t.Fatalf("expected success %t with reason %s, got %+v", tc.expectedSuccess, tc.expectedReason, result)
+			}
+		})
+	}
+}
+
+// countingExecutor counts its verifications and fails the subject once it was
+// verified before.
+type countingExecutor struct {
+	calls int
+}
+
+func (c *countingExecutor) VerifySubject(_ context.Context, _ e.VerifyParameters) (types.VerifyResult, error) {
+	c.calls++
+	return types.VerifyResult{IsSuccess: c.calls == 1}, nil
+}
+
+func (c *countingExecutor) GetVerifyRequestTimeout() time.Duration {
+	return time.Second
+}
+
+func (c *countingExecutor) GetMutationRequestTimeout() time.Duration {
+	return time.Second
+}
+
+// mapVerifierCache caches verify results without expiry.
+type mapVerifierCache struct {
+	results    map[string]types.VerifyResult
+	verifiedAt map[string]time.Time
+}
+
+func (c *mapVerifierCache) GetVerifyResult(_ context.Context, subject string) (types.VerifyResult, time.Time, bool) {
+	result, ok := c.results[subject]
+	return result, c.verifiedAt[subject], ok
+}
+
+func (c *mapVerifierCache) SetVerifyResult(_ context.Context, subject string, result types.VerifyResult, verifiedAt time.Time, _ time.Duration) {
+	c.results[subject] = result
+	c.verifiedAt[subject] = verifiedAt
+}
+
+func TestExecutorWithCache_Since(t *testing.T) {
+	base := &countingExecutor{}
+	cache := &mapVerifierCache{results: map[string]types.VerifyResult{}, verifiedAt: map[string]time.Time{}}
+	ex := ExecutorWithCache{base: base, verifierCache: cache, verfierCacheItemExpiry: time.Hour}
+
+	if result, _ := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1}); !result.IsSuccess {
+		t.Fatalf("expected the first verification to succeed")
+	}
+	verifiedAt := cache.verifiedAt[subject1]
+
+	// results verified after since are served from the cache
+	if result, _ := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1, Since: verifiedAt.Add(-time.Minute)}); !result.IsSuccess || base.calls != 1 {
+		t.Fatalf("expected the cached result, got %+v after %d verifications", result, base.calls)
+	}
+
+	// results verified before since are bypassed and refreshed
+	since := verifiedAt.Add(time.Nanosecond)
+	if result, _ := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1, Since: since}); result.IsSuccess || base.calls != 2 {
+		t.Fatalf("expected the subject to be verified again, got %+v after %d verifications", result, base.calls)
+	}
+	if cache.results[subject1].IsSuccess || cache.verifiedAt[subject1].Before(since) {
+		t.Fatalf("expected the cache to be refreshed with the fresh result")
+	}
+}
+
+func TestVerifySubject_Events(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	recorder.IncludeObject = true
+	events.SetRecorder(recorder)
+	t.Cleanup(func() { events.SetRecorder(nil) })
+
+	newExecutor := func(isSuccess bool) Executor {
+		return Executor{
+			PolicyEnforcer: policyConfig.PolicyEnforcer{
+				ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+					"default": policyTypes.AllVerifySuccess,
+				},
+			},
+			ReferrerStores: []referrerstore.ReferrerStore{&mockStore{
+				referrers: map[string][]ocispecs.ReferenceDescriptor{
+					subjectDigest: {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig")}}},
+				},
+			}},
+			Verifiers: []verifier.ReferenceVerifier{
+				&TestVerifier{
+					CanVerifyFunc: func(_ string) bool { return true },
+					VerifyResult:  func(_ string) bool { return isSuccess },
+				},
+			},
+			Config:          &exConfig.ExecutorConfig{Events: &events.Config{Interval: "1h"}},
+			PolicyNamespace: "workloads",
+		}
+	}
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), "workloads")
+	request := &types.RequestContext{
+		Namespace: "workloads",
+		Resource:  &types.ResourceReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "net-monitor"},
+	}
+
+	if _, err := newExecutor(true).VerifySubject(ctx, e.VerifyParameters{Subject: subject1, RequestContext: request}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no event for a verified subject, got %d", len(recorder.Events))
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := newExecutor(false).VerifySubject(ctx, e.VerifyParameters{Subject: subject1, RequestContext: request})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.IsSuccess {
+			t.Fatalf("expected the subject to be denied")
+		}
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected a single event for repeated denials, got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, subject1) || !strings.Contains(event, string(types.ReasonVerifierFailed)) {
+		t.Fatalf("expected the event to contain the subject and reason, got %q", event)
+	} else if !strings.Contains(event, "kind=Deployment") {
+		t.Fatalf("expected the event to be recorded on the admitted resource, got %q", event)
+	}
+
+	// the event of a request without a resource is recorded on the policy
+	if _, err := newExecutor(false).VerifySubject(ctx, e.VerifyParameters{Subject: subject1}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "kind=NamespacedPolicy") {
+		t.Fatalf("expected the event to be recorded on the namespaced policy, got %q", event)
+	}
+}
+
+func TestVerifySubject_DefaultPolicy(t *testing.T) {
+	signatureType := defaultpolicy.SignatureArtifactTypes[0]
+	newExecutor := func(policy policyTypes.DefaultPolicy, signatureValid bool) Executor {
+		enforcer, err := defaultpolicy.New(policy)
+		if err != nil {
+			t.Fatalf("failed to create default policy: %v", err)
+		}
+		return Executor{
+			PolicyEnforcer: enforcer,
+			ReferrerStores: []referrerstore.ReferrerStore{&mockStore{
+				referrers: map[string][]ocispecs.ReferenceDescriptor{
+					subjectDigest: {{ArtifactType: signatureType, Descriptor: oci.Descriptor{Digest: digest.FromString("sig")}}},
+				},
+			}},
+			Verifiers: []verifier.ReferenceVerifier{
+				&TestVerifier{
+					CanVerifyFunc: func(_ string) bool { return true },
+					VerifyResult:  func(_ string) bool { return signatureValid },
+				},
+			},
+			Config: &exConfig.ExecutorConfig{},
+		}
+	}
+
+	tests := []struct {
+		policy         policyTypes.DefaultPolicy
+		signatureValid bool
+		want           bool
+	}{
+		{policy: policyTypes.AllowAllDefaultPolicy, signatureValid: false, want: true},
+		{policy: policyTypes.DenyAllDefaultPolicy, signatureValid: true, want: false},
+		{policy: policyTypes.RequireSignatureDefaultPolicy, signatureValid: true, want: true},
+		{policy: policyTypes.RequireSignatureDefaultPolicy, signatureValid: false, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s signature valid %t", tt.policy, tt.signatureValid), func(t *testing.T) {
+			result, err := newExecutor(tt.policy, tt.signatureValid).VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tt.want {
+				t.Fatalf("expected IsSuccess %t, got %t", tt.want, result.IsSuccess)
+			}
+		})
+	}
+}
+
+// digestResolvingStore lists the referrers of mockStore and resolves the
+// descriptors of subjects referenced by digest to the given digest. It fails
+// to resolve tagged subjects or with err if set.
+type digestResolvingStore struct {
+	mockStore
+	resolved digest.Digest
+	err      error
+}
+
+func (s *digestResolvingStore) GetSubjectDescriptor(_ context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if subjectReference.Tag != "" {
+		return nil, fmt.Errorf("unexpected tag resolution of subject %s", subjectReference.Original)
+	}
+	return &ocispecs.SubjectDescriptor{Descriptor: oci.Descriptor{Digest: s.resolved, MediaType: oci.MediaTypeImageManifest}}, nil
+}
+
+func TestVerifySubject_DigestOnlyReference(t *testing.T) {
+	digestSubject := "localhost:5000/net-monitor@" + subjectDigest
+	testCases := []struct {
+		name          string
+		resolved      digest.Digest
+		storeErr      error
+		expectedError string
+	}{
+		{
+			name:     "digest is resolved by the store",
+			resolved: subjectDigest,
+		},
+		{
+			name:          "error of the store is returned",
+			storeErr:      errors.New("manifest unknown"),
+			expectedError: "manifest unknown",
+		},
+		{
+			name:          "mismatched descriptor of the store is rejected",
+			resolved:      digest.FromString("other"),
+			expectedError: "does not match the digest of the subject",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ex := Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AllVerifySuccess,
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{&digestResolvingStore{
+					mockStore: mockStore{
+						referrers: map[string][]ocispecs.ReferenceDescriptor{
+							subjectDigest: {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig")}}},
+						},
+					},
+					resolved: tc.resolved,
+					err:      tc.storeErr,
+				}},
+				Verifiers: []verifier.ReferenceVerifier{
+					&TestVerifier{
+						CanVerifyFunc: func(_ string) bool { return true },
+						VerifyResult:  func(_ string) bool { return true },
+					},
+				},
+				Config: &exConfig.ExecutorConfig{},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: digestSubject})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tc.expectedError != "" {
+				if result.IsSuccess || result.Reason != types.ReasonSubjectNotResolved {
+					t.Fatalf("expected the subject not to be resolved, got %+v", result)
+				}
+				if report := result.VerifierReports[0].(verifier.VerifierResult); !strings.Contains(report.ErrorReason, tc.expectedError) {
+					t.Fatalf("expected error reason containing %q, got %q", tc.expectedError, report.ErrorReason)
+				}
+				return
+			}
+			if !result.IsSuccess || result.Reason != types.ReasonVerified {
+				t.Fatalf("expected the subject to be verified, got %+v", result)
+			}
+			if len(result.VerifierReports) != 1 {
+				t.Fatalf("expected the referrer of the digest to be verified, got %+v", result.VerifierReports)
+			}
+			if report := result.VerifierReports[0].(verifier.VerifierResult); report.Subject != digestSubject {
+				t.Fatalf("expected report of subject %s, got %s", digestSubject, report.Subject)
+			}
+		})
+	}
+}
+
+// TestVerifySubject_SubjectVerifier tests that verifiers of the subject
+// artifact type verify the subject once, whether it has referrers or not.
+func TestVerifySubject_SubjectVerifier(t *testing.T) {
+	testCases := []struct {
+		name      string
+		referrers []ocispecs.ReferenceDescriptor
+		order     []string
+	}{
+		{
+			name: "subject without referrers",
+		},
+		{
+			name: "subject with referrers",
+			referrers: []ocispecs.ReferenceDescriptor{
+				{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig1")}},
+				{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig2")}},
+			},
+		},
+		{
+			name: "subject with referrers verified in order",
+			referrers: []ocispecs.ReferenceDescriptor{
+				{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig1")}},
+			},
+			order: []string{"verifier-testVerifier"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ex := Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AllVerifySuccess,
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{&mockStore{
+					referrers: map[string][]ocispecs.ReferenceDescriptor{subjectDigest: tc.referrers},
+				}},
+				Verifiers: []verifier.ReferenceVerifier{
+					&TestVerifier{
+						CanVerifyFunc:   func(at string) bool { return at == testArtifactType1 },
+						VerifyResult:    func(_ string) bool { return true },
+						VerifiesSubject: true,
+					},
+				},
+				Config: &exConfig.ExecutorConfig{Order: tc.order},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: "localhost:5000/net-monitor@" + subjectDigest})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !result.IsSuccess {
+				t.Fatalf("expected the subject to be verified, got %+v", result)
+			}
+			subjectReports := 0
+			for _, report := range result.VerifierReports {
+				castedReport := report.(verifier.VerifierResult)
+				if castedReport.ArtifactType != ocispecs.SubjectArtifactType {
+					continue
+				}
+				subjectReports++
+				if castedReport.ReferenceDigest != subjectDigest {
+					t.Fatalf("expected subject report of digest %s, got %s", subjectDigest, castedReport.ReferenceDigest)
+				}
+			}
+			if subjectReports != 1 {
+				t.Fatalf("expected 1 subject report, got %d", subjectReports)
+			}
+			if len(result.VerifierReports) != len(tc.referrers)+1 {
+				t.Fatalf("expected %d reports, got %d", len(tc.referrers)+1, len(result.VerifierReports))
+			}
+		})
+	}
+}
+
+// indexStore serves the subject as a multi-platform image index.
+type indexStore struct {
+	mockStore
+	index oci.Descriptor
+	// manifests are the platform manifests of the index
+	manifests []oci.Descriptor
+	// tagResolutions counts the subjects resolved by tag
+	tagResolutions int
+}
+
+func (s *indexStore) GetSubjectDescriptor(_ context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	if subjectReference.Digest == "" {
+		s.tagResolutions++
+	}
+	for _, manifest := range s.manifests {
+		if manifest.Digest == subjectReference.Digest {
+			return &ocispecs.SubjectDescriptor{Descriptor: manifest}, nil
+		}
+	}
+	return &ocispecs.SubjectDescriptor{Descriptor: s.index}, nil
+}
+
+func (s *indexStore) GetReferenceManifest(_ context.Context, _ common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	if referenceDesc.Digest == s.index.Digest {
+		return ocispecs.ReferenceManifest{MediaType: oci.MediaTypeImageIndex, Manifests: s.manifests}, nil
+	}
+	return ocispecs.ReferenceManifest{MediaType: oci.MediaTypeImageManifest}, nil
+}
+
+// windowsIndexManifests are the manifests of a multi-platform index with
+// Windows entries differentiated by os.version.
+func windowsIndexManifests() []oci.Descriptor {
+	manifest := func(name string, platform oci.Platform) oci.Descriptor {
+		return oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString(name), Platform: &platform}
+	}
+	return []oci.Descriptor{
+		manifest("linux-amd64", oci.Platform{OS: "linux", Architecture: "amd64"}),
+		manifest("linux-arm64", oci.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}),
+		manifest("ltsc2019", oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.5458"}),
+		manifest("ltsc2022-old", oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.2113"}),
+		manifest("ltsc2022", oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.2227"}),
+	}
+}
+
+func TestSelectPlatformManifest(t *testing.T) {
+	testCases := []struct {
+		name           string
+		platform       oci.Platform
+		expectedDigest digest.Digest
+		expectedErr    bool
+	}{
+		{
+			name:           "linux",
+			platform:       oci.Platform{OS: "linux", Architecture: "amd64"},
+			expectedDigest: digest.FromString("linux-amd64"),
+		},
+		{
+			name:           "linux with variant",
+			platform:       oci.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			expectedDigest: digest.FromString("linux-arm64"),
+		},
+		{
+			name:           "windows build",
+			platform:       oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763"},
+			expectedDigest: digest.FromString("ltsc2019"),
+		},
+		{
+			name:           "windows build selects the latest revision",
+			platform:       oci.Platform{OS: "Windows", Architecture: "amd64", OSVersion: "10.0.20348"},
+			expectedDigest: digest.FromString("ltsc2022"),
+		},
+		{
+			name:           "windows exact revision",
+			platform:       oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.2113"},
+			expectedDigest: digest.FromString("ltsc2022-old"),
+		},
+		{
+			name:        "windows without os.version is ambiguous",
+			platform:    oci.Platform{OS: "windows", Architecture: "amd64"},
+			expectedErr: true,
+		},
+		{
+			name:        "windows build not in the index",
+			platform:    oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.2034"},
+			expectedErr: true,
+		},
+		{
+			name:        "architecture not in the index",
+			platform:    oci.Platform{OS: "windows", Architecture: "arm64"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest, err := selectPlatformManifest(windowsIndexManifests(), tc.platform)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected an error, got manifest %s", manifest.Digest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if manifest.Digest != tc.expectedDigest {
+				t.Fatalf("expected manifest %s, got %s", tc.expectedDigest, manifest.Digest)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_WindowsIndex(t *testing.T) {
+	ltsc2022 := digest.FromString("ltsc2022")
+	store := &indexStore{
+		mockStore: mockStore{
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				// only the ltsc2022 manifest is signed
+				ltsc2022.String(): {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig")}}},
+			},
+		},
+		index:     oci.Descriptor{MediaType: oci.MediaTypeImageIndex, Digest: digest.FromString("index")},
+		manifests: windowsIndexManifests(),
+	}
+	testCases := []struct {
+		name            string
+		platform        *oci.Platform
+		requestPlatform *oci.Platform
+		expectedReason  types.DecisionReason
+	}{
+		{
+			name:           "ltsc2022 manifest and its referrers verified",
+			platform:       &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348"},
+			expectedReason: types.ReasonVerified,
+		},
+		{
+			name:           "ltsc2019 manifest has no referrers",
+			platform:       &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763"},
+			expectedReason: types.ReasonNoMatchingVerifier,
+		},
+		{
+			name:           "no matching platform",
+			platform:       &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.26100"},
+			expectedReason: types.ReasonSubjectNotResolved,
+		},
+		{
+			name:           "index verified without a platform",
+			expectedReason: types.ReasonNoMatchingVerifier,
+		},
+		{
+			name:            "platform of the request takes precedence",
+			platform:        &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763"},
+			requestPlatform: &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348"},
+			expectedReason:  types.ReasonVerified,
+		},
+		{
+			name:            "linux node pulling the same index",
+			platform:        &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348"},
+			requestPlatform: &oci.Platform{OS: "linux", Architecture: "amd64"},
+			expectedReason:  types.ReasonNoMatchingVerifier,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ex := Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AnyVerifySuccess,
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&TestVerifier{
+						CanVerifyFunc: func(_ string) bool { return true },
+						VerifyResult:  func(_ string) bool { return true },
+					},
+				},
+				Config: &exConfig.ExecutorConfig{Platform: tc.platform},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1, Platform: tc.requestPlatform})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %s, got %+v", tc.expectedReason, result)
+			}
+			if tc.expectedReason == types.ReasonVerified {
+				report := result.VerifierReports[0].(verifier.VerifierResult)
+				if expected := "localhost:5000/net-monitor@" + ltsc2022.String(); report.Subject != expected {
+					t.Fatalf("expected the referrers of %s to be verified, got subject %s", expected, report.Subject)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifySubject_RequireIndexSignatures(t *testing.T) {
+	index := oci.Descriptor{MediaType: oci.MediaTypeImageIndex, Digest: digest.FromString("index")}
+	ltsc2022 := digest.FromString("ltsc2022")
+	signature := []ocispecs.ReferenceDescriptor{{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig")}}}
+	testCases := []struct {
+		name                string
+		signed              []digest.Digest
+		requestPlatform     bool
+		expectedSuccess     bool
+		expectedReason      types.DecisionReason
+		expectedIndexReason types.DecisionReason
+	}{
+		{
+			name:                "index and child signed",
+			signed:              []digest.Digest{index.Digest, ltsc2022},
+			expectedSuccess:     true,
+			expectedReason:      types.ReasonVerified,
+			expectedIndexReason: types.ReasonVerified,
+		},
+		{
+			name:                "only index signed",
+			signed:              []digest.Digest{index.Digest},
+			expectedReason:      types.ReasonChildSignatureMissing,
+			expectedIndexReason: types.ReasonVerified,
+		},
+		{
+			name:                "only child signed",
+			signed:              []digest.Digest{ltsc2022},
+			expectedReason:      types.ReasonIndexSignatureMissing,
+			expectedIndexReason: types.ReasonNoMatchingVerifier,
+		},
+		{
+			name:                "neither signed",
+			expectedReason:      types.ReasonNoMatchingVerifier,
+			expectedIndexReason: types.ReasonNoMatchingVerifier,
+		},
+		{
+			name:                "only index signed, platform of the request",
+			signed:              []digest.Digest{index.Digest},
+			requestPlatform:     true,
+			expectedReason:      types.ReasonChildSignatureMissing,
+			expectedIndexReason: types.ReasonVerified,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			referrers := map[string][]ocispecs.ReferenceDescriptor{}
+			for _, signed := range tc.signed {
+				referrers[signed.String()] = signature
+			}
+			store := &indexStore{
+				mockStore: mockStore{referrers: referrers},
+				index:     index,
+				manifests: windowsIndexManifests(),
+			}
+			ex := Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AnyVerifySuccess,
+					},
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers: []verifier.ReferenceVerifier{
+					&TestVerifier{
+						CanVerifyFunc: func(_ string) bool { return true },
+						VerifyResult:  func(_ string) bool { return true },
+					},
+				},
+				Config: &exConfig.ExecutorConfig{RequireIndexSignatures: true},
+			}
+			platform := &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348"}
+			verifyParameters := e.VerifyParameters{Subject: subject1}
+			if tc.requestPlatform {
+				verifyParameters.Platform = platform
+			} else {
+				ex.Config.Platform = platform
+			}
+
+			result, err := ex.VerifySubject(context.Background(), verifyParameters)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tc.expectedSuccess || result.Reason != tc.expectedReason {
+				t.Fatalf("expected success %t with reason %s, got %+v", tc.expectedSuccess, tc.expectedReason, result)
+			}
+			if result.IndexResult == nil || result.IndexResult.Reason != tc.expectedIndexReason {
+				t.Fatalf("expected the index result with reason %s, got %+v", tc.expectedIndexReason, result.IndexResult)
+			}
+			// the index and the manifest are verified by the digests of a
+			// single resolution of the tag
+			if store.tagResolutions != 1 {
+				t.Fatalf("expected the tag of the subject to be resolved once, got %d", store.tagResolutions)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_RequireIndexSignatures_PartialIndex(t *testing.T) {
+	index := oci.Descriptor{MediaType: oci.MediaTypeImageIndex, Digest: digest.FromString("index")}
+	signature := ocispecs.ReferenceDescriptor{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig")}}
+	sbom := ocispecs.ReferenceDescriptor{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("sbom")}}
+	var mu sync.Mutex
+	calls := []string{}
+	ex := Executor{
+		PolicyEnforcer: policyConfig.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				"default": policyTypes.AllVerifySuccess,
+			},
+			IgnoreTimedOutVerifiers: true,
+		},
+		ReferrerStores: []referrerstore.ReferrerStore{&indexStore{
+			mockStore: mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{
+				index.Digest.String():                  {signature, sbom},
+				digest.FromString("ltsc2022").String(): {signature},
+			}},
+			index:     index,
+			manifests: windowsIndexManifests(),
+		}},
+		Verifiers: []verifier.ReferenceVerifier{
+			&orderedVerifier{name: "fast", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+			&slowVerifier{delay: 200 * time.Millisecond},
+		},
+		Config: &exConfig.ExecutorConfig{
+			Platform:               &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348"},
+			RequireIndexSignatures: true,
+			LatencyBudget:          "50ms",
+		},
+	}
+
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.IsSuccess || result.IndexResult == nil || !result.IndexResult.Partial {
+		t.Fatalf("expected the subject to pass on a partial verification of the index, got %+v", result)
+	}
+	if !result.Partial {
+		t.Fatalf("expected the partial verification of the index to mark the subject partial")
+	}
+}
+
+func TestVerifySubject_PassCache(t *testing.T) {
+	store := &mockStore{
+		referrers: map[string][]ocispecs.ReferenceDescriptor{
+			subjectDigest: {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("sig")}}},
+		},
+	}
+	passCacheConfig := &passcache.Config{Path: filepath.Join(t.TempDir(), "passes")}
+	verifications := 0
+	var allowedRegistries []string
+	newExecutor := func(policy policyTypes.ArtifactTypeVerifyPolicy, passes bool) Executor {
+		return Executor{
+			PolicyEnforcer: policyConfig.PolicyEnforcer{
+				ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+					"default": policy,
+				},
+			},
+			ReferrerStores: []referrerstore.ReferrerStore{store},
+			Verifiers: []verifier.ReferenceVerifier{
+				&TestVerifier{
+					CanVerifyFunc: func(_ string) bool { return true },
+					VerifyResult: func(_ string) bool {
+						verifications++
+						return passes
+					},
+				},
+			},
+			Config: &exConfig.ExecutorConfig{PassCache: passCacheConfig, AllowedRegistries: allowedRegistries},
+		}
+	}
+	subject := subject1
+	verify := func(ex Executor, expectedSuccess bool, expectedVerifications int) {
+		t.Helper()
+		result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.IsSuccess != expectedSuccess || verifications != expectedVerifications {
+			t.Fatalf("expected success %t after %d verifications, got %t after %d", expectedSuccess, expectedVerifications, result.IsSuccess, verifications)
+		}
+	}
+
+	// failures are not persisted
+	verify(newExecutor(policyTypes.AnyVerifySuccess, false), false, 1)
+	verify(newExecutor(policyTypes.AnyVerifySuccess, false), false, 2)
+	// the pass is reused by the executors created with the same policy
+	verify(newExecutor(policyTypes.AnyVerifySuccess, true), true, 3)
+	verify(newExecutor(policyTypes.AnyVerifySuccess, true), true, 3)
+	// a policy change invalidates the pass
+	verify(newExecutor(policyTypes.AllVerifySuccess, true), true, 4)
+	verify(newExecutor(policyTypes.AllVerifySuccess, true), true, 4)
+
+	// the pass of the digest in another repository is not reused
+	subject = "localhost:5000/other:v1"
+	verify(newExecutor(policyTypes.AllVerifySuccess, true), true, 5)
+	verify(newExecutor(policyTypes.AllVerifySuccess, true), true, 5)
+
+	// a change of the trust material invalidates the pass
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	kmp.SaveSecrets("pass-cache-test", "inline", map[kmp.KMPMapKey]crypto.PublicKey{{Name: "key"}: key.Public()}, nil)
+	defer kmp.DeleteResourceFromMap("pass-cache-test")
+	verify(newExecutor(policyTypes.AllVerifySuccess, true), true, 6)
+	verify(newExecutor(policyTypes.AllVerifySuccess, true), true, 6)
+
+	// a subject from a registry that is no longer allowed does not reuse its
+	// pass
+	allowedRegistries = []string{"myregistry.example.com"}
+	verify(newExecutor(policyTypes.AllVerifySuccess, true), false, 6)
+}
+
+func TestExecutor_ConfigHash(t *testing.T) {
+	newVerifier := func(configHash string) verifier.ReferenceVerifier {
+		testVerifier := &TestVerifier{}
+		verifier.SetConfigHash(testVerifier, configHash)
+		t.Cleanup(func() { verifier.DeleteConfigHash(testVerifier) })
+		return testVerifier
+	}
+	configHash := func(verifiers ...verifier.ReferenceVerifier) string {
+		t.Helper()
+		ex := Executor{
+			PolicyEnforcer: &mockPolicyProvider{result: true},
+			Verifiers:      verifiers,
+			Config:         &exConfig.ExecutorConfig{},
+		}
+		hash, err := ex.ConfigHash()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return hash
+	}
+
+	original := configHash(newVerifier("config-v1"))
+	if reloaded := configHash(newVerifier("config-v1")); reloaded != original {
+		t.Fatalf("expected a verifier reloaded with the same config to keep the hash")
+	}
+	if changed := configHash(newVerifier("config-v2")); changed == original {
+		t.Fatalf("expected a verifier reloaded with a changed config to change the hash")
+	}
+	patternHash := func(pattern string) string {
+		t.Helper()
+		ex := Executor{
+			PolicyEnforcer: &policyConfig.PolicyEnforcer{SubjectAnnotations: map[string]*regexp.Regexp{"team": regexp.MustCompile(pattern)}},
+			Config:         &exConfig.ExecutorConfig{},
+		}
+		hash, err := ex.ConfigHash()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return hash
+	}
+	if patternHash("^payments$") == patternHash("^.*$") {
+		t.Fatalf("expected a changed policy pattern to change the hash")
+	}
+	if configHash(newVerifier("config-v1"), newVerifier("config-v2")) != configHash(newVerifier("config-v2"), newVerifier("config-v1")) {
+		t.Fatalf("expected the hash not to depend on the order of the verifiers")
+	}
+}
+
+// pushingStore records the referrers pushed to it and counts the resolutions
+// of subjects.
+type pushingStore struct {
+	mockStore
+	pushed      chan []byte
+	resolutions atomic.Int32
+}
+
+func (s *pushingStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	s.resolutions.Add(1)
+	return s.mockStore.GetSubjectDescriptor(ctx, subjectReference)
+}
+
+func (s *pushingStore) PushReferrer(_ context.Context, _ common.Reference, subjectDesc oci.Descriptor, artifactType string, blob []byte, _ string) (oci.Descriptor, error) {
+	if subjectDesc.Digest != subjectDigest || artifactType != attestation.ArtifactType {
+		return oci.Descriptor{}, fmt.Errorf("unexpected referrer of type %s for subject %s", artifactType, subjectDesc.Digest)
+	}
+	s.pushed <- blob
+	return oci.Descriptor{Digest: digest.FromBytes(blob)}, nil
+}
+
+func TestVerifySubject_Attestation(t *testing.T) {
+	signer, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kmp.SaveSigners("attestation-kmp", map[kmp.KMPMapKey]crypto.Signer{{Name: "key1"}: signer})
+	defer kmp.DeleteResourceFromMap("attestation-kmp")
+
+	store := &pushingStore{
+		mockStore: mockStore{
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				subjectDigest: {{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: signatureDigest}}},
+			},
+		},
+		pushed: make(chan []byte, 1),
+	}
+	ex := Executor{
+		PolicyEnforcer: &mockPolicyProvider{result: true},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc: func(_ string) bool { return true },
+				VerifyResult:  func(_ string) bool { return true },
+			},
+		},
+		Config: &exConfig.ExecutorConfig{
+			Attestation: &attestation.Config{KeyManagementProvider: "attestation-kmp", Push: true},
+		},
+	}
+
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Attestation == nil {
+		t.Fatalf("expected the result to carry an attestation")
+	}
+	if resolutions := store.resolutions.Load(); resolutions != 1 {
+		t.Fatalf("expected the subject to be resolved once, got %d resolutions", resolutions)
+	}
+	statement, err := attestation.Verify(result.Attestation, signer.Public())
+	if err != nil {
+		t.Fatalf("expected a valid attestation signature, got %v", err)
+	}
+	if statement.Subject[0].Name != "localhost:5000/net-monitor" || "sha256:"+statement.Subject[0].Digest["sha256"] != subjectDigest {
+		t.Fatalf("unexpected attestation subject %+v", statement.Subject)
+	}
+	var predicate attestation.Predicate
+	if err := json.Unmarshal(statement.Predicate, &predicate); err != nil {
+		t.Fatalf("failed to unmarshal predicate: %v", err)
+	}
+	if predicate.IsSuccess != result.IsSuccess || predicate.Reason != result.Reason || len(predicate.Verifiers) != 1 {
+		t.Fatalf("expected the predicate to describe the result, got %+v", predicate)
+	}
+
+	select {
+	case blob := <-store.pushed:
+		var pushed ratifyattestation.Envelope
+		if err := json.Unmarshal(blob, &pushed); err != nil {
+			t.Fatalf("failed to unmarshal pushed attestation: %v", err)
+		}
+		if _, err := attestation.Verify(&pushed, signer.Public()); err != nil {
+			t.Fatalf("expected the pushed attestation to be valid, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the attestation to be pushed")
+	}
+
+	// the same decision on the digest is not pushed again
+	if _, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	select {
+	case <-store.pushed:
+		t.Fatalf("expected the attestation not to be pushed again")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// attestation failures do not affect the result
+	ex.Config.Attestation = &attestation.Config{KeyManagementProvider: "missing"}
+	result, err = ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil || !result.IsSuccess || result.Attestation != nil {
+		t.Fatalf("expected a successful result without attestation, got %+v, %v", result, err)
+	}
+}
+
+// TestVerifySubjectInternal_SignedReferrers tests that referrers required to be
+// signed by the policy are verified with their own signatures
+func TestVerifySubjectInternal_SignedReferrers(t *testing.T) {
+	configPolicy := policyConfig.PolicyEnforcer{
+		ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+			"default": "all",
+		},
+		SignedReferrers: map[string][]string{
+			mocks.SbomArtifactType: {mocks.SignatureArtifactType},
+		},
+	}
+	sbomDigest := digest.NewDigestFromEncoded("sha256", "9393779549fca5758811d7cf0444ddb1b254cb24b44fe1cf80fac6fd3199817f")
+
+	testcases := []struct {
+		name           string
+		unsignedSbom   bool
+		noSbomVerifier bool
+		expected       bool
+	}{
+		{
+			name:     "signed sbom",
+			expected: true,
+		},
+		{
+			name:         "unsigned sbom",
+			unsignedSbom: true,
+			expected:     false,
+		},
+		{
+			name:           "signed sbom without sbom verifier",
+			noSbomVerifier: true,
+			expected:       true,
+		},
+		{
+			name:           "unsigned sbom without sbom verifier",
+			unsignedSbom:   true,
+			noSbomVerifier: true,
+			expected:       false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := mocks.CreateNewTestStoreForNestedSbom()
+			if tc.unsignedSbom {
+				delete(store.(*mocks.MemoryTestStore).Referrers, sbomDigest)
+			}
+
+			// sbom verifier WITHOUT nested references in config
+			sbomVerifier := &TestVerifier{
+				CanVerifyFunc: func(at string) bool {
+					return at == mocks.SbomArtifactType
+				},
+				VerifyResult: func(_ string) bool {
+					return true
+				},
+			}
+			signatureVerifier := &TestVerifier{
+				CanVerifyFunc: func(at string) bool {
+					return at == mocks.SignatureArtifactType
+				},
+				VerifyResult: func(_ string) bool {
+					return true
+				},
+			}
+
+			verifiers := []verifier.ReferenceVerifier{sbomVerifier, signatureVerifier}
+			if tc.noSbomVerifier {
+				verifiers = []verifier.ReferenceVerifier{signatureVerifier}
+			}
+			ex := &Executor{
+				PolicyEnforcer: configPolicy,
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers:      verifiers,
+				Config: &exConfig.ExecutorConfig{
+					VerificationRequestTimeout: nil,
+					MutationRequestTimeout:     nil,
+				},
+			}
+
+			result, err := ex.verifySubjectInternal(context.Background(), e.VerifyParameters{Subject: mocks.TestSubjectWithDigest})
+			if err != nil {
+				t.Fatalf("verification failed with err %v", err)
+			}
+			if result.IsSuccess != tc.expected {
+				t.Fatalf("expected verification result %v, got %v", tc.expected, result.IsSuccess)
+			}
+
+			for _, report := range result.VerifierReports {
+				castedReport := report.(verifier.VerifierResult)
+				if castedReport.ArtifactType != mocks.SbomArtifactType {
+					continue
+				}
+				if len(castedReport.NestedResults) != 1 {
+					t.Fatalf("expected sbom report to have 1 nested result, got %d", len(castedReport.NestedResults))
+				}
+				if castedReport.NestedResults[0].IsSuccess == tc.unsignedSbom {
+					t.Fatalf("expected sbom nested result success to be %v", !tc.unsignedSbom)
+				}
+			}
+		})
+	}
+}
+
+// flakyVerifier fails with err on its first failures calls, or with a failed
+// result caused by resultErr if set, and then returns its result.
+type flakyVerifier struct {
+	err       error
+	resultErr *ratifyerrors.Error
+	failures  int
+	isSuccess bool
+	calls     int
+}
+
+func (v *flakyVerifier) Name() string {
+	return "flaky"
+}
+
+func (v *flakyVerifier) Type() string {
+	return "flakyVerifier"
+}
+
+func (v *flakyVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	return referenceDescriptor.ArtifactType == testArtifactType1
+}
+
+func (v *flakyVerifier) Verify(_ context.Context,
+	_ common.Reference,
+	_ ocispecs.ReferenceDescriptor,
+	_ referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	v.calls++
+	if v.calls <= v.failures {
+		if v.resultErr != nil {
+			return verifier.NewVerifierResult("", v.Name(), v.Type(), "", false, v.resultErr, nil), nil
+		}
+		return verifier.VerifierResult{}, v.err
+	}
+	return verifier.VerifierResult{IsSuccess: v.isSuccess, VerifierName: v.Name()}, nil
+}
+
+func (v *flakyVerifier) GetNestedReferences() []string {
+	return nil
+}
+
+func TestVerifyReference_VerifierRetry(t *testing.T) {
+	networkErr := ratifyerrors.ErrorCodeGetBlobContentFailure.WithError(ratifyerrors.ErrorCodeNetworkFailure.WithDetail("connection reset"))
+	invalidSignatureErr := ratifyerrors.ErrorCodeVerifyPluginFailure.WithDetail("invalid signature")
+	testCases := []struct {
+		name            string
+		retry           *exConfig.VerifierRetryConfig
+		err             error
+		resultErr       *ratifyerrors.Error
+		failures        int
+		isSuccess       bool
+		expectedSuccess bool
+		expectedCalls   int
+	}{
+		{
+			name:            "transient failure then success",
+			retry:           &exConfig.VerifierRetryConfig{MaxAttempts: 3, Backoff: "1ms"},
+			err:             networkErr,
+			failures:        2,
+			isSuccess:       true,
+			expectedSuccess: true,
+			expectedCalls:   3,
+		},
+		{
+			name:          "transient failures exhaust the attempts",
+			retry:         &exConfig.VerifierRetryConfig{MaxAttempts: 2, Backoff: "1ms"},
+			err:           ratifyerrors.ErrorCodeThrottled.WithDetail("too many requests"),
+			failures:      3,
+			isSuccess:     true,
+			expectedCalls: 2,
+		},
+		{
+			name:          "non-transient failure is not retried",
+			retry:         &exConfig.VerifierRetryConfig{MaxAttempts: 3, Backoff: "1ms"},
+			err:           ratifyerrors.ErrorCodeVerifyPluginFailure.WithDetail("invalid signature"),
+			failures:      1,
+			isSuccess:     true,
+			expectedCalls: 1,
+		},
+		{
+			name:            "transient failure through a result then success",
+			retry:           &exConfig.VerifierRetryConfig{MaxAttempts: 3, Backoff: "1ms"},
+			resultErr:       &networkErr,
+			failures:        1,
+			isSuccess:       true,
+			expectedSuccess: true,
+			expectedCalls:   2,
+		},
+		{
+			name:          "non-transient failure through a result is not retried",
+			retry:         &exConfig.VerifierRetryConfig{MaxAttempts: 3, Backoff: "1ms"},
+			resultErr:     &invalidSignatureErr,
+			failures:      1,
+			isSuccess:     true,
+			expectedCalls: 1,
+		},
+		{
+			name:          "policy failure is not retried",
+			retry:         &exConfig.VerifierRetryConfig{MaxAttempts: 3, Backoff: "1ms"},
+			expectedCalls: 1,
+		},
+		{
+			name:          "transient failure without retry policy",
+			err:           networkErr,
+			failures:      1,
+			isSuccess:     true,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ver := &flakyVerifier{err: tc.err, resultErr: tc.resultErr, failures: tc.failures, isSuccess: tc.isSuccess}
+			ex := Executor{
+				Verifiers: []verifier.ReferenceVerifier{ver},
+				Config:    &exConfig.ExecutorConfig{VerifierRetry: tc.retry},
+			}
+			subjectRef := common.Reference{Original: subject1, Path: "localhost:5000/net-monitor", Digest: subjectDigest}
+			referenceDesc := ocispecs.ReferenceDescriptor{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}}
+
+			result := ex.verifyReferenceForJSONPolicy(context.Background(), subjectRef, referenceDesc, &mockStore{})
+			if result.IsSuccess != tc.expectedSuccess {
+				t.Fatalf("expected success %v, got %v", tc.expectedSuccess, result.IsSuccess)
+			}
+			if ver.calls != tc.expectedCalls {
+				t.Fatalf("expected %d verify calls, got %d", tc.expectedCalls, ver.calls)
+			}
+		})
+	}
+}
+
+func TestVerifierRetryConfig_Delay(t *testing.T) {
+	retry := &exConfig.VerifierRetryConfig{MaxAttempts: 5, Backoff: "100ms", MaxBackoff: "300ms"}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := retry.Delay(i + 1); got != want {
+			t.Fatalf("expected delay %s before retry %d, got %s", want, i+1, got)
+		}
+	}
+	if got := (&exConfig.VerifierRetryConfig{MaxAttempts: 2}).Delay(1); got != 200*time.Millisecond {
+		t.Fatalf("expected default delay of 200ms, got %s", got)
+	}
+	if err := (&exConfig.VerifierRetryConfig{MaxAttempts: 0}).Validate(); err == nil {
+		t.Fatalf("expected error for non-positive maxAttempts")
+	}
+	if err := (&exConfig.VerifierRetryConfig{MaxAttempts: 2, Backoff: "soon"}).Validate(); err == nil {
+		t.Fatalf("expected error for invalid backoff")
+	}
+}
+
+func TestExecutorConfig_FailOnUnknownArtifactType(t *testing.T) {
+	conf := exConfig.ExecutorConfig{FailOnUnknownArtifactType: true, UnknownArtifactType: "fail"}
+	if err := conf.Validate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	conf.UnknownArtifactType = "warn"
+	if err := conf.Validate(); err == nil {
+		t.Fatalf("expected error for conflicting unknownArtifactType")
+	}
+
+	conf = exConfig.ExecutorConfig{FailOnUnknownArtifactType: true}
+	if !conf.Normalize() || conf.UnknownArtifactType != "fail" || conf.FailOnUnknownArtifactType {
+		t.Fatalf("expected failOnUnknownArtifactType to be normalized to unknownArtifactType fail, got %+v", conf)
+	}
+	if conf.Normalize() {
+		t.Fatalf("expected a normalized configuration to be unchanged")
+	}
+}
+
+func TestVerifySubject_Allowlist(t *testing.T) {
+	referrers := map[string][]ocispecs.ReferenceDescriptor{
+		subjectDigest: {
+			{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}},
+		},
+	}
+	testCases := []struct {
+		name            string
+		digests         []string
+		feedFails       bool
+		expectedSuccess bool
+		expectedReason  types.DecisionReason
+		expectedCalls   []string
+	}{
+		{
+			name:            "allowlisted subject is not verified",
+			digests:         []string{subjectDigest},
+			expectedSuccess: true,
+			expectedReason:  types.ReasonAllowlisted,
+			expectedCalls:   []string{},
+		},
+		{
+			name:           "subject not in the allowlist is verified",
+			digests:        []string{digest.FromString("other").String()},
+			expectedReason: types.ReasonVerifierFailed,
+			expectedCalls:  []string{"verifier"},
+		},
+		{
+			name:           "allowlist fetch failure verifies the subject",
+			feedFails:      true,
+			expectedReason: types.ReasonVerifierFailed,
+			expectedCalls:  []string{"verifier"},
+		},
+	}
+
+	allowlist.Register("test", func(conf allowlist.Config) (allowlist.Source, error) {
+		for _, tc := range testCases {
+			if tc.name == conf.URL {
+				return &testAllowlist{digests: tc.digests, fails: tc.feedFails}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown allowlist %s", conf.URL)
+	})
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := []string{}
+			ex := Executor{
+				PolicyEnforcer: &mockPolicyProvider{result: false},
+				ReferrerStores: []referrerstore.ReferrerStore{&mockStore{referrers: referrers}},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "verifier", artifactType: testArtifactType1, mu: &mu, calls: &calls},
+				},
+				Config: &exConfig.ExecutorConfig{
+					Allowlist: &allowlist.Config{Type: "test", URL: tc.name},
+				},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tc.expectedSuccess {
+				t.Fatalf("expected success %v, got %v", tc.expectedSuccess, result.IsSuccess)
+			}
+			if result.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %s, got %s", tc.expectedReason, result.Reason)
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Fatalf("expected verifier calls %v, got %v", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_AllowlistAndPassCache_NestedReferrer(t *testing.T) {
+	subjectDesc := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.Digest(subjectDigest)}
+	sbomDesc := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("sbom")}
+	store := &mocks.MemoryTestStore{
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			subjectDesc.Digest: {Descriptor: subjectDesc},
+			sbomDesc.Digest:    {Descriptor: sbomDesc},
+		},
+		Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+			subjectDesc.Digest: {
+				{ArtifactType: mocks.SbomArtifactType, Descriptor: sbomDesc},
+				{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}},
+			},
+			sbomDesc.Digest: {{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("sbom signature")}}},
+		},
+	}
+	allowlist.Register("nested-test", func(_ allowlist.Config) (allowlist.Source, error) {
+		return &testAllowlist{digests: []string{sbomDesc.Digest.String()}}, nil
+	})
+	var mu sync.Mutex
+	calls := []string{}
+	sbomSignature := &orderedVerifier{name: "sbom-signature", artifactType: testArtifactType2, mu: &mu, calls: &calls}
+	newExecutor := func(conf *exConfig.ExecutorConfig) Executor {
+		return Executor{
+			PolicyEnforcer: policyConfig.PolicyEnforcer{
+				ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+					"default": policyTypes.AllVerifySuccess,
+				},
+			},
+			ReferrerStores: []referrerstore.ReferrerStore{store},
+			Verifiers: []verifier.ReferenceVerifier{
+				&TestVerifier{
+					CanVerifyFunc:    func(at string) bool { return at == mocks.SbomArtifactType },
+					VerifyResult:     func(_ string) bool { return true },
+					nestedReferences: []string{"string-content-does-not-matter"},
+				},
+				&orderedVerifier{name: "signature", artifactType: testArtifactType1, isSuccess: true, mu: &mu, calls: &calls},
+				sbomSignature,
+			},
+			Config: conf,
+		}
+	}
+	verify := func(ex Executor, subject string, expectedSuccess bool, expectedCalls []string) {
+		t.Helper()
+		calls = []string{}
+		result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.IsSuccess != expectedSuccess {
+			t.Fatalf("expected success %v, got %+v", expectedSuccess, result)
+		}
+		// the referrers are verified concurrently
+		slices.Sort(calls)
+		if !reflect.DeepEqual(calls, expectedCalls) {
+			t.Fatalf("expected verifier calls %v, got %v", expectedCalls, calls)
+		}
+	}
+	image := "localhost:5000/net-monitor@" + subjectDigest
+	sbom := "localhost:5000/net-monitor@" + sbomDesc.Digest.String()
+
+	// the allowlisted sbom is still verified as a referrer of the image
+	allowlisted := newExecutor(&exConfig.ExecutorConfig{Allowlist: &allowlist.Config{Type: "nested-test"}})
+	verify(allowlisted, sbom, true, []string{})
+	verify(allowlisted, image, false, []string{"sbom-signature", "signature"})
+
+	// the persisted pass of the sbom is not reused for it as a referrer
+	persisting := newExecutor(&exConfig.ExecutorConfig{PassCache: &passcache.Config{Path: filepath.Join(t.TempDir(), "passes")}})
+	sbomSignature.isSuccess = true
+	verify(persisting, sbom, true, []string{"sbom-signature"})
+	verify(persisting, sbom, true, []string{})
+	sbomSignature.isSuccess = false
+	verify(persisting, image, false, []string{"sbom-signature", "signature"})
+}
+
+// testAllowlist is an allowlist source whose feed may be unavailable.
+type testAllowlist struct {
+	digests []string
+	fails   bool
+}
+
+func (a *testAllowlist) Contains(_ context.Context, d digest.Digest) (bool, error) {
+	if a.fails {
+		return false, fmt.Errorf("failed to fetch allowlist, status code: %d", http.StatusServiceUnavailable)
+	}
+	return slices.Contains(a.digests, d.String()), nil
+}
+
+type slowVerifier struct {
+	delay time.Duration
+}
+
+func (v *slowVerifier) Name() string {
+	return "slow"
+}
+
+func (v *slowVerifier) Type() string {
+	return "slowVerifier"
+}
+
+func (v *slowVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	return referenceDescriptor.ArtifactType == testArtifactType2
+}
+
+func (v *slowVerifier) Verify(ctx context.Context,
+	_ common.Reference,
+	_ ocispecs.ReferenceDescriptor,
+	_ referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	select {
+	case <-ctx.Done():
+		return verifier.VerifierResult{}, ctx.Err()
+	case <-time.After(v.delay):
+		return verifier.VerifierResult{IsSuccess: true, VerifierName: v.Name()}, nil
+	}
+}
+
+func (v *slowVerifier) GetNestedReferences() []string {
+	return nil
+}
+
+func TestVerifySubject_LatencyBudget(t *testing.T) {
+	testCases := []struct {
+		name            string
+		latencyBudget   string
+		fastSuccess     bool
+		onlySlow        bool
+		ignoreTimedOut  bool
+		expectedSuccess bool
+		expectedPartial bool
+		expectedReason  types.DecisionReason
+	}{
+		{
+			name:            "slow verifier is cut off and fails its artifact type",
+			latencyBudget:   "50ms",
+			fastSuccess:     true,
+			expectedPartial: true,
+			expectedReason:  types.ReasonTimedOut,
+		},
+		{
+			name:            "slow verifier is cut off and the completed verifier decides when ignored",
+			latencyBudget:   "50ms",
+			fastSuccess:     true,
+			ignoreTimedOut:  true,
+			expectedSuccess: true,
+			expectedPartial: true,
+			expectedReason:  types.ReasonVerified,
+		},
+		{
+			name:            "completed verifier failure denies a partial result",
+			latencyBudget:   "50ms",
+			expectedPartial: true,
+			expectedReason:  types.ReasonVerifierFailed,
+		},
+		{
+			name:            "no verifier completed",
+			latencyBudget:   "50ms",
+			onlySlow:        true,
+			expectedPartial: true,
+			expectedReason:  types.ReasonTimedOut,
+		},
+		{
+			name:            "slow verifier completes without a budget",
+			fastSuccess:     true,
+			expectedSuccess: true,
+			expectedReason:  types.ReasonVerified,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			referrers := []ocispecs.ReferenceDescriptor{
+				{ArtifactType: testArtifactType2, Descriptor: oci.Descriptor{Digest: digest.FromString("sbom")}},
+			}
+			if !tc.onlySlow {
+				referrers = append(referrers, ocispecs.ReferenceDescriptor{ArtifactType: testArtifactType1, Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}})
+			}
+			var mu sync.Mutex
+			calls := []string{}
+			ex := Executor{
+				PolicyEnforcer: policyConfig.PolicyEnforcer{
+					ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+						"default": policyTypes.AllVerifySuccess,
+					},
+					IgnoreTimedOutVerifiers: tc.ignoreTimedOut,
+				},
+				ReferrerStores: []referrerstore.ReferrerStore{&mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{subjectDigest: referrers}}},
+				Verifiers: []verifier.ReferenceVerifier{
+					&orderedVerifier{name: "fast", artifactType: testArtifactType1, isSuccess: tc.fastSuccess, mu: &mu, calls: &calls},
+					&slowVerifier{delay: 200 * time.Millisecond},
+				},
+				Config: &exConfig.ExecutorConfig{LatencyBudget: tc.latencyBudget},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tc.expectedSuccess {
+				t.Fatalf("expected success %v, got %v", tc.expectedSuccess, result.IsSuccess)
+			}
+			if result.Partial != tc.expectedPartial {
+				t.Fatalf("expected partial %v, got %v", tc.expectedPartial, result.Partial)
+			}
+			if result.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %s, got %s", tc.expectedReason, result.Reason)
+			}
+			for _, report := range result.VerifierReports {
+				r := report.(verifier.VerifierResult)
+				if r.VerifierName != "slow" {
+					continue
+				}
+				if timedOut := r.GetLevel() == verifier.LevelTimeout; timedOut != tc.expectedPartial {
+					t.Fatalf("expected the slow verifier timed out %v, got level %s", tc.expectedPartial, r.GetLevel())
+				}
+			}
+		})
+	}
+}
+
+func TestExecutorConfig_LatencyBudget(t *testing.T) {
+	conf := exConfig.ExecutorConfig{LatencyBudget: "2s"}
+	if err := conf.Validate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if budget := conf.GetLatencyBudget(); budget != 2*time.Second {
+		t.Fatalf("expected latency budget 2s, got %s", budget)
+	}
+	for _, invalid := range []string{"2", "-1s"} {
+		conf.LatencyBudget = invalid
+		if err := conf.Validate(); err == nil {
+			t.Fatalf("expected error for latencyBudget %s", invalid)
+		}
+	}
+}
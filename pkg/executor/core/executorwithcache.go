@@ -32,17 +32,21 @@ type ExecutorWithCache struct {
 }
 
 func (executor ExecutorWithCache) VerifySubject(ctx context.Context, verifyParameters executor.VerifyParameters) (types.VerifyResult, error) {
-	// check the cache for the existence of item
-	cachedResult, ok := executor.verifierCache.GetVerifyResult(ctx, verifyParameters.Subject)
+	// check the cache for the existence of item, results verified before
+	// since are bypassed and replaced by the fresh result
+	cachedResult, cachedAt, ok := executor.verifierCache.GetVerifyResult(ctx, verifyParameters.Subject)
 
-	if ok {
+	if ok && (verifyParameters.Since.IsZero() || !cachedAt.Before(verifyParameters.Since)) {
 		return cachedResult, nil
 	}
 
+	verifiedAt := time.Now()
 	result, err := executor.base.VerifySubject(ctx, verifyParameters)
 
-	if err == nil {
-		executor.verifierCache.SetVerifyResult(ctx, verifyParameters.Subject, result, executor.verfierCacheItemExpiry)
+	// degraded results are not cached so that the subject is verified again
+	// once the dependencies recover
+	if err == nil && !result.Degraded {
+		executor.verifierCache.SetVerifyResult(ctx, verifyParameters.Subject, result, verifiedAt, executor.verfierCacheItemExpiry)
 	}
 
 	return result, err
@@ -0,0 +1,134 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/executor/membudget"
+)
+
+const (
+	// GuardMaxDepth is the guard limiting the nesting depth of the verified
+	// referrers.
+	GuardMaxDepth = "maxDepth"
+	// GuardMaxReferrersPerNode is the guard limiting the number of referrers
+	// listed for a subject.
+	GuardMaxReferrersPerNode = "maxReferrersPerNode"
+)
+
+type (
+	verificationDepthKey  struct{}
+	verificationGuardsKey struct{}
+)
+
+// verificationGuards records the guards tripped within a verification,
+// including the verification of its nested subjects.
+type verificationGuards struct {
+	mu      sync.Mutex
+	tripped string
+}
+
+// withVerificationGuards returns a context recording the guards tripped by the
+// verification. The record of a context already holding one is reused so that
+// the nested subjects of a verification share it.
+func withVerificationGuards(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(verificationGuardsKey{}).(*verificationGuards); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, verificationGuardsKey{}, &verificationGuards{})
+}
+
+// trippedGuard returns the first guard tripped within the verification, if
+// any.
+func trippedGuard(ctx context.Context) string {
+	guards, ok := ctx.Value(verificationGuardsKey{}).(*verificationGuards)
+	if !ok {
+		return ""
+	}
+	guards.mu.Lock()
+	defer guards.mu.Unlock()
+	return guards.tripped
+}
+
+// tripGuard records the guard as tripped and returns the error stopping the
+// verification.
+func tripGuard(ctx context.Context, guard string, detail string) error {
+	if guards, ok := ctx.Value(verificationGuardsKey{}).(*verificationGuards); ok {
+		guards.mu.Lock()
+		if guards.tripped == "" {
+			guards.tripped = guard
+		}
+		guards.mu.Unlock()
+	}
+	return errors.ErrorCodeVerificationLimitExceeded.WithDetail(detail)
+}
+
+// verificationDepth returns the nesting depth of the subject verified with the
+// context, zero for the subject of the request.
+func verificationDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(verificationDepthKey{}).(int)
+	return depth
+}
+
+// withNestedDepth returns the context verifying the nested subjects of the
+// subject verified with ctx.
+func withNestedDepth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verificationDepthKey{}, verificationDepth(ctx)+1)
+}
+
+// checkDepth returns an error if the subject verified with the context is
+// nested deeper than the maxDepth guard allows.
+func (executor Executor) checkDepth(ctx context.Context, subject string) error {
+	if executor.Config == nil || executor.Config.MaxDepth <= 0 {
+		return nil
+	}
+	if depth := verificationDepth(ctx); depth > executor.Config.MaxDepth {
+		return tripGuard(ctx, GuardMaxDepth, fmt.Sprintf("nested subject %s at depth %d exceeds the %s guard of %d", subject, depth, GuardMaxDepth, executor.Config.MaxDepth))
+	}
+	return nil
+}
+
+// checkReferrerCount returns an error if more referrers were listed for the
+// subject than the maxReferrersPerNode guard allows.
+func (executor Executor) checkReferrerCount(ctx context.Context, subject string, count int) error {
+	if executor.Config == nil || executor.Config.MaxReferrersPerNode <= 0 {
+		return nil
+	}
+	if count > executor.Config.MaxReferrersPerNode {
+		return tripGuard(ctx, GuardMaxReferrersPerNode, fmt.Sprintf("subject %s has more than %d referrers allowed by the %s guard", subject, executor.Config.MaxReferrersPerNode, GuardMaxReferrersPerNode))
+	}
+	return nil
+}
+
+// withMemoryBudget returns a context whose blob fetches reserve their size from
+// the memory budget shared by all verifications, if one is configured, and the
+// function releasing the reservations once the verification completes.
+func (executor Executor) withMemoryBudget(ctx context.Context) (context.Context, func()) {
+	if executor.Config == nil || executor.Config.MemoryBudget == nil {
+		return ctx, func() {}
+	}
+	budget, err := membudget.Shared(*executor.Config.MemoryBudget)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to create memory budget: %v", err)
+		return ctx, func() {}
+	}
+	return membudget.WithBudget(ctx, budget)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+)
+
+type referrerListingsKey struct{}
+
+// referrerListings memoizes the referrers listed from the stores within a
+// single verification, e.g. the children of an image index sharing a
+// referrer, so that the listing of a digest is only computed once.
+type referrerListings struct {
+	mu       sync.Mutex
+	listings map[string]*referrerListing
+}
+
+// referrerListing is the listing of a page of referrers. done is closed once
+// the listing completed.
+type referrerListing struct {
+	done   chan struct{}
+	result referrerstore.ListReferrersResult
+	err    error
+}
+
+// withReferrerListings returns a context memoizing the referrer listings of
+// the verification. The listings of a context already memoizing them are
+// reused so that the nested subjects of a verification share them.
+func withReferrerListings(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(referrerListingsKey{}).(*referrerListings); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, referrerListingsKey{}, &referrerListings{listings: map[string]*referrerListing{}})
+}
+
+// listedReferrers records the manifest digests of the referrers listed for a
+// subject, so that a referrer listed more than once, e.g. a cosign signature
+// found both under the signature tag and as a referrer, on several pages or by
+// several stores, is only verified and reported once.
+type listedReferrers struct {
+	mu   sync.Mutex
+	seen map[digest.Digest]bool
+}
+
+func newListedReferrers() *listedReferrers {
+	return &listedReferrers{seen: map[digest.Digest]bool{}}
+}
+
+// unseen returns the referrers whose manifest digest was not listed before, in
+// order, and records them as listed. Referrers without a digest cannot be
+// identified and are always returned.
+func (l *listedReferrers) unseen(referrers []ocispecs.ReferenceDescriptor) []ocispecs.ReferenceDescriptor {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unseen := make([]ocispecs.ReferenceDescriptor, 0, len(referrers))
+	for _, referrer := range referrers {
+		if referrer.Digest != "" {
+			if l.seen[referrer.Digest] {
+				continue
+			}
+			l.seen[referrer.Digest] = true
+		}
+		unseen = append(unseen, referrer)
+	}
+	return unseen
+}
+
+// listReferrers lists a page of referrers of the subject from the store,
+// reusing the listing of the same page within the verification. Failed
+// listings are not memoized.
+func listReferrers(ctx context.Context, referrerStore referrerstore.ReferrerStore, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	listings, ok := ctx.Value(referrerListingsKey{}).(*referrerListings)
+	if !ok || subjectDesc == nil {
+		return referrerStore.ListReferrers(ctx, subjectReference, artifactTypes, nextToken, subjectDesc)
+	}
+	key := strings.Join([]string{referrerStore.Name(), subjectReference.Path, subjectDesc.Digest.String(), strings.Join(artifactTypes, ","), nextToken}, "|")
+
+	listings.mu.Lock()
+	listing, found := listings.listings[key]
+	if !found {
+		listing = &referrerListing{done: make(chan struct{})}
+		listings.listings[key] = listing
+	}
+	listings.mu.Unlock()
+
+	if !found {
+		listing.result, listing.err = referrerStore.ListReferrers(ctx, subjectReference, artifactTypes, nextToken, subjectDesc)
+		if listing.err != nil {
+			listings.mu.Lock()
+			delete(listings.listings, key)
+			listings.mu.Unlock()
+		}
+		close(listing.done)
+		return listing.result, listing.err
+	}
+
+	select {
+	case <-listing.done:
+	case <-ctx.Done():
+		return referrerstore.ListReferrersResult{}, ctx.Err()
+	}
+	if listing.err != nil {
+		// the listing failed for the caller that started it, e.g. since its
+		// context was canceled, list the referrers again
+		return listReferrers(ctx, referrerStore, subjectReference, artifactTypes, nextToken, subjectDesc)
+	}
+	return listing.result, nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/executor/notification"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+)
+
+// notify posts the summary of the decision to the configured webhook in the
+// background. Only the subject of the request is notified, not its nested
+// subjects. Notification failures are only logged so that they never affect
+// the verification result.
+func (executor Executor) notify(ctx context.Context, subject string, result types.VerifyResult) {
+	if executor.Config == nil || executor.Config.Notification == nil || verificationDepth(ctx) > 0 {
+		return
+	}
+	notifier, err := notification.Shared(*executor.Config.Notification)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to create verification notifier: %v", err)
+		return
+	}
+	if !notifier.ShouldNotify(result.IsSuccess) {
+		return
+	}
+
+	summary := notification.Summary{
+		Subject:   subject,
+		IsSuccess: result.IsSuccess,
+		Reason:    result.Reason,
+		Verifiers: verifierContributions(result.VerifierReports),
+		Timestamp: time.Now().UTC(),
+	}
+	// the notification outlives the verification request
+	if !notifier.Enqueue(context.WithoutCancel(ctx), summary) {
+		logger.GetLogger(ctx, logOpt).Warnf("dropped the verification notification of subject %s, the notification queue is full", subject)
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+)
+
+// storeReference is a referrer along with the store it was listed from and the
+// rank of the verifier handling it.
+type storeReference struct {
+	store     referrerstore.ReferrerStore
+	reference ocispecs.ReferenceDescriptor
+	rank      int
+}
+
+// isSequential returns true if the executor config requires verifiers to run
+// in order instead of concurrently.
+func (executor Executor) isSequential() bool {
+	return executor.Config != nil && (len(executor.Config.Order) > 0 || executor.Config.ShortCircuitOnFail || len(executor.Config.PassOnFirstTrusted) > 0)
+}
+
+// shortCircuitOnFail returns true if the remaining verifiers are skipped once a
+// verifier fails.
+func (executor Executor) shortCircuitOnFail() bool {
+	return executor.Config != nil && executor.Config.ShortCircuitOnFail
+}
+
+// isTrustedVerifier returns true if the subject is allowed as soon as the
+// verifier with the name and type passes.
+func (executor Executor) isTrustedVerifier(name, verifierType string) bool {
+	if executor.Config == nil {
+		return false
+	}
+	return slices.Contains(executor.Config.PassOnFirstTrusted, name) || slices.Contains(executor.Config.PassOnFirstTrusted, verifierType)
+}
+
+// trustedPass returns the name of the first verifier listed by the
+// passOnFirstTrusted option that passed within the verifier reports of a
+// referrer. Skipped verifiers never pass.
+func (executor Executor) trustedPass(verifierReports []interface{}) (string, bool) {
+	for _, report := range verifierReports {
+		switch r := report.(type) {
+		case vr.VerifierResult:
+			if r.IsSuccess && r.GetLevel() != vr.LevelSkip && executor.isTrustedVerifier(r.VerifierName, r.VerifierType) {
+				return r.VerifierName, true
+			}
+		case types.NestedVerifierReport:
+			for _, result := range r.VerifierReports {
+				if result.IsSuccess && result.GetLevel() != vr.LevelSkip && executor.isTrustedVerifier(result.VerifierName, result.VerifierType) {
+					return result.VerifierName, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// orderedVerifiers returns the verifiers sorted by their position in the
+// configured order. Verifiers not listed keep their relative order after the
+// listed ones.
+func (executor Executor) orderedVerifiers() []vr.ReferenceVerifier {
+	if executor.Config == nil || len(executor.Config.Order) == 0 {
+		return executor.Verifiers
+	}
+	positions := make(map[string]int, len(executor.Config.Order))
+	for idx, name := range executor.Config.Order {
+		if _, ok := positions[name]; !ok {
+			positions[name] = idx
+		}
+	}
+	position := func(verifier vr.ReferenceVerifier) int {
+		if idx, ok := positions[verifier.Name()]; ok {
+			return idx
+		}
+		return len(executor.Config.Order)
+	}
+
+	verifiers := make([]vr.ReferenceVerifier, len(executor.Verifiers))
+	copy(verifiers, executor.Verifiers)
+	sort.SliceStable(verifiers, func(i, j int) bool {
+		return position(verifiers[i]) < position(verifiers[j])
+	})
+	return verifiers
+}
+
+// verifyReferencesInOrder lists the referrers of the subject from all stores
+// and verifies them one at a time, ordered by the rank of the first verifier
+// able to verify them. Verification stops at the first failure if
+// shortCircuitOnFail is set and the policy cannot allow the subject after the
+// failure, and at the first pass of a verifier listed by passOnFirstTrusted,
+// which is returned as the early exit.
+func (executor Executor) verifyReferencesInOrder(ctx context.Context, subjectReference common.Reference, desc *ocispecs.SubjectDescriptor, verifyParameters e.VerifyParameters) ([]interface{}, *types.EarlyExit, error) {
+	verifiers := executor.orderedVerifiers()
+	var references []storeReference
+	var referrerCount int
+	listed := newListedReferrers()
+	for _, referrerStore := range executor.ReferrerStores {
+		var continuationToken string
+		for {
+			referrersResult, err := listReferrers(ctx, referrerStore, subjectReference, verifyParameters.ReferenceTypes, continuationToken, desc)
+			if err != nil {
+				return nil, nil, errors.ErrorCodeListReferrersFailure.NewError(errors.ReferrerStore, referrerStore.Name(), errors.EmptyLink, err, nil, errors.HideStackTrace)
+			}
+			continuationToken = referrersResult.NextToken
+			referrersResult.Referrers = listed.unseen(executor.normalizeArtifactTypes(referrersResult.Referrers))
+			referrerCount += len(referrersResult.Referrers)
+			if err := executor.checkReferrerCount(ctx, subjectReference.String(), referrerCount); err != nil {
+				return nil, nil, err
+			}
+			for _, reference := range referrersResult.Referrers {
+				if !executor.PolicyEnforcer.VerifyNeeded(ctx, subjectReference, reference) {
+					continue
+				}
+				references = append(references, storeReference{
+					store:     referrerStore,
+					reference: reference,
+					rank:      executor.verifierRank(ctx, verifiers, reference),
+				})
+			}
+			if continuationToken == "" {
+				break
+			}
+		}
+	}
+	for _, ref := range executor.subjectReferences(ctx, desc, verifyParameters.ReferenceTypes) {
+		if executor.PolicyEnforcer.VerifyNeeded(ctx, subjectReference, ref.reference) {
+			ref.rank = executor.verifierRank(ctx, verifiers, ref.reference)
+			references = append(references, ref)
+		}
+	}
+	sort.SliceStable(references, func(i, j int) bool {
+		return references[i].rank < references[j].rank
+	})
+
+	verifierReports := make([]interface{}, 0, len(references))
+	for idx, ref := range references {
+		var isSuccess bool
+		var referenceReports []interface{}
+		if executor.PolicyEnforcer.GetPolicyType(ctx) == pt.RegoPolicy {
+			verifyResult, err := executor.verifyReferenceForRegoPolicy(ctx, subjectReference, ref.reference, ref.store)
+			if err != nil {
+				logger.GetLogger(ctx, logOpt).Errorf("error while verifying reference %+v, err: %v", ref.reference, err)
+				return nil, nil, err
+			}
+			referenceReports = []interface{}{verifyResult}
+			isSuccess = nestedReportSucceeded(verifyResult)
+		} else {
+			verifyResult := executor.verifyReferenceForJSONPolicy(ctx, subjectReference, ref.reference, ref.store)
+			referenceReports = verifyResult.VerifierReports
+			isSuccess = verifyResult.IsSuccess
+		}
+		verifierReports = append(verifierReports, referenceReports...)
+		skipped := len(references) - idx - 1
+		// a failure only decides the subject if the policy cannot tolerate it,
+		// e.g. not under an any-success policy another referrer may satisfy
+		if !isSuccess && executor.shortCircuitOnFail() && !executor.PolicyEnforcer.ContinueVerifyOnFailure(ctx, subjectReference, ref.reference, types.VerifyResult{VerifierReports: verifierReports}) {
+			if skipped > 0 {
+				logger.GetLogger(ctx, logOpt).Infof("verification of reference %s failed, skipping verification of %d remaining references of subject %s", ref.reference.Digest, skipped, subjectReference.String())
+			}
+			break
+		}
+		if verifier, ok := executor.trustedPass(referenceReports); ok {
+			logger.GetLogger(ctx, logOpt).Infof("trusted verifier %s passed reference %s, skipping verification of %d remaining references of subject %s", verifier, ref.reference.Digest, skipped, subjectReference.String())
+			return verifierReports, &types.EarlyExit{
+				Verifier:          verifier,
+				ReferenceDigest:   ref.reference.Digest.String(),
+				SkippedReferences: skipped,
+			}, nil
+		}
+	}
+	return verifierReports, nil, nil
+}
+
+// verifierRank returns the position of the first verifier the reference is
+// routed to, or the number of verifiers if it is not routed to any.
+func (executor Executor) verifierRank(ctx context.Context, verifiers []vr.ReferenceVerifier, reference ocispecs.ReferenceDescriptor) int {
+	routed := executor.routeVerifiers(ctx, reference)
+	if len(routed) == 0 {
+		return len(verifiers)
+	}
+	for idx, verifier := range verifiers {
+		if verifier.Name() == routed[0].Name() {
+			return idx
+		}
+	}
+	return len(verifiers)
+}
+
+// nestedReportSucceeded returns true if all verifier reports of the nested
+// report succeeded.
+func nestedReportSucceeded(report types.NestedVerifierReport) bool {
+	for _, verifierReport := range report.VerifierReports {
+		if !verifierReport.IsSuccess {
+			return false
+		}
+	}
+	return true
+}
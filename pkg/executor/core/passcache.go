@@ -0,0 +1,107 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ratify-project/ratify/internal/logger"
+	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/passcache"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/utils"
+)
+
+// passCacheKey identifies the persisted pass of a subject.
+type passCacheKey struct {
+	cache      *passcache.Cache
+	subject    string
+	policyHash string
+}
+
+// passCacheKey returns the key of the persisted pass of the subject, nil if no
+// pass cache is configured, the subject is nested in the subject of the
+// request, the subject is served from a registry that is not allowed, or the
+// subject digest or the config hash could not be derived. Nested subjects are
+// always verified within the chain of their parent. The
+// passes of a subject are recorded per repository, since trust policies are
+// scoped by repository, per request context, since the policy may depend on
+// it, and per platform of the request, since it selects the manifest verified
+// for index subjects. The config hash covers the versions of the trust
+// material of the key management providers, so that passes are not reused
+// once it changed.
+func (executor Executor) passCacheKey(ctx context.Context, verifyParameters e.VerifyParameters) *passCacheKey {
+	subject := verifyParameters.Subject
+	if executor.Config == nil || executor.Config.PassCache == nil || verificationDepth(ctx) > 0 {
+		return nil
+	}
+	cache, err := passcache.Shared(*executor.Config.PassCache)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to load pass cache: %v", err)
+	}
+	if cache == nil {
+		return nil
+	}
+	if executor.checkRegistry(subject) != nil {
+		return nil
+	}
+	configHash, err := executor.ConfigHash()
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to hash the config, not using the pass cache: %v", err)
+		return nil
+	}
+	policyHash := fmt.Sprintf("%s/%d", configHash, kmp.TrustMaterialVersion())
+	subjectReference, err := utils.ParseSubjectReference(subject)
+	if err != nil {
+		return nil
+	}
+	subjectDigest, err := executor.subjectDigest(ctx, subjectReference)
+	if err != nil {
+		return nil
+	}
+	key := subjectReference.Path + "@" + subjectDigest.String()
+	if requestHash := verifyParameters.RequestContext.Hash(); requestHash != "" && executor.UsesRequestContext(ctx) {
+		key = key + "/" + requestHash
+	}
+	if verifyParameters.Platform != nil {
+		key = key + "/" + formatPlatform(*verifyParameters.Platform)
+	}
+	return &passCacheKey{cache: cache, subject: key, policyHash: policyHash}
+}
+
+// getPass returns the persisted result of the subject if it passed under the
+// current policy.
+func (key *passCacheKey) getPass(since time.Time) (types.VerifyResult, bool) {
+	if key == nil {
+		return types.VerifyResult{}, false
+	}
+	return key.cache.Get(key.subject, key.policyHash, since)
+}
+
+// persistPass persists the result of the subject if it passed. Degraded
+// results are not persisted so that the subject is verified again once the
+// dependencies recover.
+func (key *passCacheKey) persistPass(ctx context.Context, result types.VerifyResult, verifiedAt time.Time) {
+	if key == nil || !result.IsSuccess || result.Degraded {
+		return
+	}
+	if err := key.cache.Add(key.subject, key.policyHash, result, verifiedAt); err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to persist the pass of subject %s: %v", key.subject, err)
+	}
+}
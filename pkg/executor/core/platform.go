@@ -0,0 +1,256 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/utils"
+)
+
+// isIndex reports whether the media type is the one of an image index or a
+// Docker manifest list.
+func isIndex(mediaType string) bool {
+	return mediaType == oci.MediaTypeImageIndex || mediaType == ocispecs.MediaTypeDockerManifestList
+}
+
+// platform returns the platform selecting the manifest of index subjects, the
+// one of the request if set, the one of the executor config otherwise.
+func (executor Executor) platform(verifyParameters e.VerifyParameters) *oci.Platform {
+	if verifyParameters.Platform != nil {
+		return verifyParameters.Platform
+	}
+	if executor.Config == nil {
+		return nil
+	}
+	return executor.Config.Platform
+}
+
+// resolvePlatformManifest returns the descriptor of the manifest of an index
+// subject matching the platform and points the subject reference at it, so
+// that the manifest and its referrers are verified in place of the index.
+// Other subjects, or all subjects without platform, are returned as is.
+func (executor Executor) resolvePlatformManifest(ctx context.Context, subjectReference *common.Reference, desc *ocispecs.SubjectDescriptor, platform *oci.Platform) (*ocispecs.SubjectDescriptor, error) {
+	if platform == nil || !isIndex(desc.MediaType) {
+		return desc, nil
+	}
+	var index ocispecs.ReferenceManifest
+	var err error
+	for _, referrerStore := range executor.ReferrerStores {
+		index, err = referrerStore.GetReferenceManifest(ctx, *subjectReference, ocispecs.ReferenceDescriptor{Descriptor: desc.Descriptor})
+		if err == nil {
+			break
+		}
+		logger.GetLogger(ctx, logOpt).Warnf("failed to fetch index of subject %s from store %s: %v", subjectReference.String(), referrerStore.Name(), err)
+	}
+	if err != nil {
+		return nil, errors.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("failed to fetch the index of subject %s", subjectReference.String())).WithError(err)
+	}
+
+	manifest, err := selectPlatformManifest(index.Manifests, *platform)
+	if err != nil {
+		return nil, errors.ErrorCodePlatformNotFound.WithDetail(fmt.Sprintf("failed to select the manifest of subject %s: %v", subjectReference.String(), err))
+	}
+	logger.GetLogger(ctx, logOpt).Infof("selected manifest %s of platform %s of index subject %s", manifest.Digest, formatPlatform(*manifest.Platform), subjectReference.String())
+
+	subjectReference.Original = subjectReference.Path + "@" + manifest.Digest.String()
+	subjectReference.Digest = manifest.Digest
+	subjectReference.Tag = ""
+	return &ocispecs.SubjectDescriptor{Descriptor: manifest}, nil
+}
+
+// verifySubjectAndIndex verifies the subject and, if index signatures are
+// required and the subject is an index, verifies the index itself along with
+// the manifest selected by the platform. The subject passes only if both do,
+// the reason telling which of them did not.
+func (executor Executor) verifySubjectAndIndex(ctx context.Context, verifyParameters e.VerifyParameters) (types.VerifyResult, error) {
+	platform := executor.platform(verifyParameters)
+	if executor.Config == nil || platform == nil || !executor.Config.RequireIndexSignatures {
+		return executor.verifySubjectInternal(ctx, verifyParameters)
+	}
+	// the registry is checked before the subject is resolved
+	if err := executor.checkRegistry(verifyParameters.Subject); err != nil {
+		return types.VerifyResult{}, err
+	}
+	subjectReference, err := utils.ParseSubjectReference(verifyParameters.Subject)
+	if err != nil {
+		return types.VerifyResult{}, err
+	}
+	desc, err := executor.resolveSubject(ctx, subjectReference)
+	if err != nil {
+		return types.VerifyResult{}, err
+	}
+	if !isIndex(desc.MediaType) {
+		return executor.verifySubjectInternal(ctx, verifyParameters)
+	}
+
+	// both levels are verified by the digests resolved here, so that a tag
+	// moved in between does not pair the index with the child of another one
+	levelConfig := *executor.Config
+	levelConfig.Platform = nil
+	levelExecutor := executor
+	levelExecutor.Config = &levelConfig
+	indexParameters := verifyParameters
+	indexParameters.Platform = nil
+	indexParameters.Subject = subjectReference.Path + "@" + desc.Digest.String()
+
+	var childResult types.VerifyResult
+	childReference := subjectReference
+	childDesc, err := executor.resolvePlatformManifest(ctx, &childReference, desc, platform)
+	if err == nil {
+		childParameters := indexParameters
+		childParameters.Subject = subjectReference.Path + "@" + childDesc.Digest.String()
+		childResult, err = levelExecutor.verifyIndexLevel(ctx, childParameters)
+	} else {
+		childResult, err = levelExecutor.levelFailure(ctx, verifyParameters.Subject, err)
+	}
+	if err != nil {
+		return types.VerifyResult{}, err
+	}
+	indexResult, err := levelExecutor.verifyIndexLevel(ctx, indexParameters)
+	if err != nil {
+		return types.VerifyResult{}, err
+	}
+
+	childResult.IndexResult = &indexResult
+	// the subject passes without a complete verification of the index either
+	childResult.Partial = childResult.Partial || indexResult.Partial
+	childResult.Degraded = childResult.Degraded || indexResult.Degraded
+	if childResult.ExceededGuard == "" {
+		childResult.ExceededGuard = indexResult.ExceededGuard
+	}
+	switch {
+	case childResult.IsSuccess && !indexResult.IsSuccess:
+		logger.GetLogger(ctx, logOpt).Infof("index %s of subject %s failed verification", indexParameters.Subject, verifyParameters.Subject)
+		childResult.IsSuccess = false
+		childResult.Reason = types.ReasonIndexSignatureMissing
+	case !childResult.IsSuccess && indexResult.IsSuccess:
+		logger.GetLogger(ctx, logOpt).Infof("manifest of platform %s of subject %s failed verification", formatPlatform(*platform), verifyParameters.Subject)
+		childResult.Reason = types.ReasonChildSignatureMissing
+	}
+	return childResult, nil
+}
+
+// verifyIndexLevel verifies the index or the selected manifest of an index
+// subject. Failures that are not caused by an unavailable dependency, e.g.
+// a level without referrers, are reported as failed results for the other
+// level to still be verified.
+func (executor Executor) verifyIndexLevel(ctx context.Context, verifyParameters e.VerifyParameters) (types.VerifyResult, error) {
+	result, err := executor.verifySubjectInternal(ctx, verifyParameters)
+	if err != nil {
+		return executor.levelFailure(ctx, verifyParameters.Subject, err)
+	}
+	return result, nil
+}
+
+// levelFailure returns the failed result of a level of an index subject that
+// failed with the error, or the error if it is caused by an unavailable
+// dependency.
+func (executor Executor) levelFailure(ctx context.Context, subject string, err error) (types.VerifyResult, error) {
+	if isInfrastructureError(ctx, err) {
+		return types.VerifyResult{}, err
+	}
+	result := executor.PolicyEnforcer.ErrorToVerifyResult(ctx, subject, err)
+	result.Reason = errorDecisionReason(err)
+	return result, nil
+}
+
+// selectPlatformManifest returns the manifest of the index matching the
+// platform. If several manifests match, the one with the highest os.version is
+// selected, e.g. the latest revision of a Windows build. Manifests differing
+// in os.version are ambiguous if the platform does not set one.
+func selectPlatformManifest(manifests []oci.Descriptor, platform oci.Platform) (oci.Descriptor, error) {
+	var selected *oci.Descriptor
+	for i := range manifests {
+		manifest := &manifests[i]
+		if manifest.Platform == nil || !platformMatches(platform, *manifest.Platform) {
+			continue
+		}
+		if selected == nil {
+			selected = manifest
+			continue
+		}
+		if platform.OSVersion == "" && !strings.EqualFold(selected.Platform.OSVersion, manifest.Platform.OSVersion) {
+			return oci.Descriptor{}, fmt.Errorf("manifests of os.version %s and %s match platform %s, set the os.version of the platform to select one", selected.Platform.OSVersion, manifest.Platform.OSVersion, formatPlatform(platform))
+		}
+		if compareOSVersions(manifest.Platform.OSVersion, selected.Platform.OSVersion) > 0 {
+			selected = manifest
+		}
+	}
+	if selected == nil {
+		return oci.Descriptor{}, fmt.Errorf("no manifest matches platform %s", formatPlatform(platform))
+	}
+	return *selected, nil
+}
+
+// platformMatches reports whether the platform of a manifest matches the
+// wanted platform. The variant and os.version only need to match if wanted,
+// and an os.version matches the versions it is a prefix of, e.g. 10.0.20348
+// matches 10.0.20348.2227.
+func platformMatches(want, got oci.Platform) bool {
+	if !strings.EqualFold(want.OS, got.OS) || !strings.EqualFold(want.Architecture, got.Architecture) {
+		return false
+	}
+	if want.Variant != "" && !strings.EqualFold(want.Variant, got.Variant) {
+		return false
+	}
+	if want.OSVersion == "" || strings.EqualFold(want.OSVersion, got.OSVersion) {
+		return true
+	}
+	return strings.HasPrefix(got.OSVersion, want.OSVersion+".")
+}
+
+// compareOSVersions compares the dot separated os.versions component by
+// component, numerically where both components are numbers.
+func compareOSVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		if aErr == nil && bErr == nil {
+			if aNum != bNum {
+				return aNum - bNum
+			}
+			continue
+		}
+		if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+			return c
+		}
+	}
+	return len(aParts) - len(bParts)
+}
+
+// formatPlatform formats the platform as os/architecture[/variant][:os.version].
+func formatPlatform(platform oci.Platform) string {
+	formatted := platform.OS + "/" + platform.Architecture
+	if platform.Variant != "" {
+		formatted += "/" + platform.Variant
+	}
+	if platform.OSVersion != "" {
+		formatted += ":" + platform.OSVersion
+	}
+	return formatted
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+)
+
+// DecisionPostProcessor runs custom logic on the final decision of a
+// verification, e.g. to enrich the report or to emit an audit event. It may
+// augment the result, such as adding annotations or warnings, but cannot
+// change the outcome: the success, degraded state and reason of the decision
+// are restored after it runs. Errors are logged and never fail the
+// verification.
+type DecisionPostProcessor interface {
+	PostProcess(ctx context.Context, subject string, result *types.VerifyResult) error
+}
+
+// DecisionPostProcessorFunc adapts a function to a DecisionPostProcessor.
+type DecisionPostProcessorFunc func(ctx context.Context, subject string, result *types.VerifyResult) error
+
+// PostProcess calls f(ctx, subject, result).
+func (f DecisionPostProcessorFunc) PostProcess(ctx context.Context, subject string, result *types.VerifyResult) error {
+	return f(ctx, subject, result)
+}
+
+var postProcessors struct {
+	mu         sync.RWMutex
+	processors []DecisionPostProcessor
+}
+
+// RegisterDecisionPostProcessor adds a post-processor invoked, in
+// registration order, with the decision of every verification request.
+func RegisterDecisionPostProcessor(processor DecisionPostProcessor) {
+	if processor == nil {
+		panic("decision post-processor cannot be nil")
+	}
+	postProcessors.mu.Lock()
+	defer postProcessors.mu.Unlock()
+	postProcessors.processors = append(postProcessors.processors, processor)
+}
+
+// postProcess runs the registered post-processors on the decision. Only the
+// decision of the subject of the request is post-processed, not those of its
+// nested subjects.
+func postProcess(ctx context.Context, subject string, result *types.VerifyResult) {
+	if verificationDepth(ctx) > 0 {
+		return
+	}
+	postProcessors.mu.RLock()
+	processors := postProcessors.processors
+	postProcessors.mu.RUnlock()
+
+	for _, processor := range processors {
+		isSuccess, degraded, reason := result.IsSuccess, result.Degraded, result.Reason
+		if err := processor.PostProcess(ctx, subject, result); err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("decision post-processor failed for subject %s: %v", subject, err)
+		}
+		if result.IsSuccess != isSuccess || result.Degraded != degraded || result.Reason != reason {
+			logger.GetLogger(ctx, logOpt).Warnf("decision post-processor attempted to change the decision of subject %s, ignoring the change", subject)
+			result.IsSuccess, result.Degraded, result.Reason = isSuccess, degraded, reason
+		}
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/metrics"
+	"github.com/ratify-project/ratify/pkg/policyprovider/defaultpolicy"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// verifierTypeReasons maps the types of the built-in verifiers to the reason
+// code of their failures.
+var verifierTypeReasons = map[string]types.DecisionReason{
+	"notation":            types.ReasonSignatureInvalid,
+	"cosign":              types.ReasonSignatureInvalid,
+	"helmprovenance":      types.ReasonSignatureInvalid,
+	"sbom":                types.ReasonSBOMRejected,
+	"licensechecker":      types.ReasonSBOMRejected,
+	"vulnerabilityreport": types.ReasonVulnerabilityReportRejected,
+}
+
+// decisionReason returns the reason code of a decision made by the policy
+// from the verifier contributions. A signature failure takes precedence over
+// the failures of other verifiers.
+func decisionReason(isSuccess bool, contributions []types.VerifierContribution) types.DecisionReason {
+	if isSuccess {
+		return types.ReasonVerified
+	}
+	timedOut := false
+	var reason types.DecisionReason
+	for _, contribution := range contributions {
+		if contribution.Level == vr.LevelTimeout {
+			timedOut = true
+			continue
+		}
+		if contribution.IsSuccess {
+			continue
+		}
+		failure := failureReason(contribution)
+		if failure == types.ReasonSignatureInvalid {
+			return failure
+		}
+		if reason == "" {
+			reason = failure
+		}
+	}
+	if reason != "" {
+		return reason
+	}
+	if timedOut {
+		return types.ReasonTimedOut
+	}
+	return types.ReasonPolicyDenied
+}
+
+// failureReason returns the reason code of the failure of a verifier, derived
+// from its type or, for verifiers of other types, from the artifact type of
+// the referrer it verified.
+func failureReason(contribution types.VerifierContribution) types.DecisionReason {
+	if reason, ok := verifierTypeReasons[contribution.VerifierType]; ok {
+		return reason
+	}
+	if slices.Contains(defaultpolicy.SignatureArtifactTypes, contribution.ArtifactType) {
+		return types.ReasonSignatureInvalid
+	}
+	return types.ReasonVerifierFailed
+}
+
+// errorDecisionReason returns the reason code of a decision made because the
+// verification of the subject failed with the error.
+func errorDecisionReason(err error) types.DecisionReason {
+	var ratifyErr errors.Error
+	if !stderrors.As(err, &ratifyErr) {
+		return types.ReasonInternalError
+	}
+	switch ratifyErr.ErrorCode() {
+	case errors.ErrorCodeNoVerifierReport:
+		return types.ReasonNoMatchingVerifier
+	case errors.ErrorCodeReferenceInvalid:
+		return types.ReasonInvalidReference
+	case errors.ErrorCodeReferrerStoreFailure, errors.ErrorCodeGetSubjectDescriptorFailure, errors.ErrorCodePlatformNotFound:
+		return types.ReasonSubjectNotResolved
+	case errors.ErrorCodeListReferrersFailure:
+		return types.ReasonReferrerStoreError
+	case errors.ErrorCodeVerificationLimitExceeded:
+		return types.ReasonLimitExceeded
+	case errors.ErrorCodeRegistryNotAllowed:
+		return types.ReasonRegistryNotAllowed
+	default:
+		return types.ReasonInternalError
+	}
+}
+
+// infrastructureErrorCodes are the error codes of failures classified as an
+// unavailable dependency of Ratify where the failure was raised, e.g. a
+// throttled or failed token request.
+var infrastructureErrorCodes = map[errors.ErrorCode]bool{
+	errors.ErrorCodeThrottled:      true,
+	errors.ErrorCodeNetworkFailure: true,
+}
+
+// isInfrastructureError reports whether the verification of the subject failed
+// because a dependency, such as a registry or a key management provider, is
+// unavailable: the request to it failed in transport, or it answered with a
+// server error or throttled the request. Responses rejecting the request, e.g.
+// a subject that does not exist or credentials that are denied, never are, nor
+// is the expiry of the deadline of the verification itself.
+func isInfrastructureError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if statusCode := responseStatusCode(err); statusCode != 0 {
+		return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if stderrors.As(err, &netErr) || stderrors.Is(err, syscall.ECONNREFUSED) || stderrors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	for err != nil {
+		var ratifyErr errors.Error
+		if !stderrors.As(err, &ratifyErr) {
+			return false
+		}
+		if infrastructureErrorCodes[ratifyErr.ErrorCode()] {
+			return true
+		}
+		err = ratifyErr.Unwrap()
+	}
+	return false
+}
+
+// responseStatusCode returns the HTTP status of the registry or Azure response
+// that caused the error, or 0 if the error has no response.
+func responseStatusCode(err error) int {
+	var registryErr *errcode.ErrorResponse
+	if stderrors.As(err, &registryErr) {
+		return registryErr.StatusCode
+	}
+	var azureErr *azcore.ResponseError
+	if stderrors.As(err, &azureErr) {
+		return azureErr.StatusCode
+	}
+	return 0
+}
+
+// reportDecision logs the overall decision of the subject of the request and
+// records it in the decision metric. The nested subjects only contribute to the
+// decision of the subject of the request and are not reported.
+func reportDecision(ctx context.Context, subject string, result types.VerifyResult) {
+	if verificationDepth(ctx) > 0 {
+		return
+	}
+	switch {
+	case result.Degraded:
+		logger.GetLogger(ctx, logOpt).Warnf("verification of subject %s allowed in degraded mode by the open failure policy, reason: %s", subject, result.Reason)
+	case result.IsSuccess:
+		logger.GetLogger(ctx, logOpt).Infof("verification of subject %s succeeded, reason: %s", subject, result.Reason)
+	default:
+		logger.GetLogger(ctx, logOpt).Warnf("verification of subject %s failed, reason: %s", subject, result.Reason)
+	}
+	metrics.ReportVerificationDecision(ctx, result.IsSuccess, string(result.Reason), result.Degraded)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/utils"
+)
+
+// checkRegistry returns an error if the subject is served from a registry host
+// that is not listed in the allowed registries of the executor. Subjects served
+// from a pull-through cache are checked against the host of its upstream
+// registry.
+func (executor Executor) checkRegistry(subject string) error {
+	if executor.Config == nil || len(executor.Config.AllowedRegistries) == 0 {
+		return nil
+	}
+	named, err := reference.ParseDockerRef(subject)
+	if err != nil {
+		return errors.ErrorCodeReferenceInvalid.WithError(err).WithDetail(fmt.Sprintf("failed to parse subject reference %s", subject))
+	}
+	host := utils.UpstreamHost(executor.Config.PullThroughCaches, reference.Domain(named))
+	for _, registry := range executor.Config.AllowedRegistries {
+		if strings.EqualFold(strings.TrimSpace(registry), host) {
+			return nil
+		}
+	}
+	return errors.ErrorCodeRegistryNotAllowed.WithDetail(fmt.Sprintf("subject %s is served from registry %s which is not in the allowed registries %v", subject, host, executor.Config.AllowedRegistries))
+}
+
+// withUpstreamRegistries returns the context exposing the pull-through caches
+// of the executor to the verifiers, which evaluate their trust scopes against
+// the upstream registries.
+func (executor Executor) withUpstreamRegistries(ctx context.Context) context.Context {
+	if executor.Config == nil {
+		return ctx
+	}
+	return utils.WithUpstreamRegistries(ctx, executor.Config.PullThroughCaches)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/policyprovider"
+)
+
+type requestContextKey struct{}
+
+// withRequestContext returns the context verifying the subjects of the
+// admission request with the request context, so that the verification of
+// nested subjects is evaluated against the same request context.
+func withRequestContext(ctx context.Context, request *types.RequestContext) context.Context {
+	if request == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, requestContextKey{}, request)
+}
+
+// requestContext returns the request context the subject verified with ctx is
+// verified for, nil if there is none.
+func requestContext(ctx context.Context) *types.RequestContext {
+	request, _ := ctx.Value(requestContextKey{}).(*types.RequestContext)
+	return request
+}
+
+// UsesRequestContext returns true if the decision of the policy may depend on
+// the request context, so that results are only recorded per request context
+// for such policies and are shared across requests otherwise.
+func (executor Executor) UsesRequestContext(ctx context.Context) bool {
+	provider, ok := executor.PolicyEnforcer.(policyprovider.RequestContextPolicyProvider)
+	return ok && provider.UsesRequestContext(ctx)
+}
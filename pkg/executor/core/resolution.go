@@ -0,0 +1,84 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
+)
+
+type resolvedSubjectsKey struct{}
+
+// resolvedSubjects memoizes the descriptors of the subjects resolved within a
+// single verification, so that the pass cache, the allowlist, the
+// verification and the attestation of a subject share one resolution.
+type resolvedSubjects struct {
+	mu          sync.Mutex
+	descriptors map[string]*ocispecs.SubjectDescriptor
+}
+
+// withResolvedSubjects returns a context memoizing the subject resolutions of
+// the verification. The resolutions of a context already memoizing them are
+// reused so that the nested subjects of a verification share them.
+func withResolvedSubjects(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(resolvedSubjectsKey{}).(*resolvedSubjects); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, resolvedSubjectsKey{}, &resolvedSubjects{descriptors: map[string]*ocispecs.SubjectDescriptor{}})
+}
+
+// resolveSubject resolves the descriptor of the subject with the referrer
+// stores of the executor, reusing the resolution of the same reference within
+// the verification. Failed resolutions are not memoized.
+func (executor Executor) resolveSubject(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	resolved, ok := ctx.Value(resolvedSubjectsKey{}).(*resolvedSubjects)
+	if !ok {
+		return su.ResolveSubjectDescriptor(ctx, &executor.ReferrerStores, subjectReference)
+	}
+	resolved.mu.Lock()
+	desc, found := resolved.descriptors[subjectReference.Original]
+	resolved.mu.Unlock()
+	if found {
+		return desc, nil
+	}
+
+	desc, err := su.ResolveSubjectDescriptor(ctx, &executor.ReferrerStores, subjectReference)
+	if err != nil {
+		return nil, err
+	}
+	resolved.mu.Lock()
+	resolved.descriptors[subjectReference.Original] = desc
+	resolved.mu.Unlock()
+	return desc, nil
+}
+
+// subjectDigest returns the digest of the subject, resolving it only if the
+// reference does not pin it.
+func (executor Executor) subjectDigest(ctx context.Context, subjectReference common.Reference) (digest.Digest, error) {
+	if subjectReference.Digest != "" {
+		return subjectReference.Digest, nil
+	}
+	desc, err := executor.resolveSubject(ctx, subjectReference)
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest, nil
+}
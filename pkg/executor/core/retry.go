@@ -0,0 +1,96 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+)
+
+// transientErrorCodes are the error codes of failures that may succeed when
+// retried.
+var transientErrorCodes = map[errors.ErrorCode]bool{
+	errors.ErrorCodeThrottled:      true,
+	errors.ErrorCodeNetworkFailure: true,
+	errors.ErrorCodeResourceBusy:   true,
+}
+
+// isTransientError reports whether the verifier failed with an error that may
+// not occur again, such as a throttled request, a network timeout or a
+// connection reset.
+func isTransientError(err error) bool {
+	var netErr net.Error
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if stderrors.Is(err, syscall.ECONNRESET) || stderrors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	for err != nil {
+		var ratifyErr errors.Error
+		if !stderrors.As(err, &ratifyErr) {
+			return false
+		}
+		if transientErrorCodes[ratifyErr.ErrorCode()] {
+			return true
+		}
+		err = ratifyErr.Unwrap()
+	}
+	return false
+}
+
+// verifyWithRetry verifies the referrer with the verifier and retries the
+// verification as configured while it fails with a transient error, returned
+// by the verifier or recorded as the cause of the failed result it returned,
+// e.g. a failed fetch of a blob or of a transparency log entry. Successful
+// results and failures for other reasons are never retried.
+func (executor Executor) verifyWithRetry(ctx context.Context, verifier vr.ReferenceVerifier, subjectRef common.Reference, referenceDesc ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (vr.VerifierResult, error) {
+	result, err := verifier.Verify(ctx, subjectRef, referenceDesc, referrerStore)
+	if executor.Config == nil || executor.Config.VerifierRetry == nil {
+		return result, err
+	}
+	retryConfig := executor.Config.VerifierRetry
+	for attempt := 1; attempt < retryConfig.MaxAttempts; attempt++ {
+		transientErr := err
+		if transientErr == nil && !result.IsSuccess {
+			transientErr = result.Cause()
+		}
+		if transientErr == nil || !isTransientError(transientErr) {
+			break
+		}
+		delay := retryConfig.Delay(attempt)
+		logger.GetLogger(ctx, logOpt).Warnf("verifier %s failed to verify referrer %s with a transient error, retrying in %s: %v", verifier.Name(), referenceDesc.Digest, delay, transientErr)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+		result, err = verifier.Verify(ctx, subjectRef, referenceDesc, referrerStore)
+	}
+	return result, err
+}
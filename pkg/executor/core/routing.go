@@ -0,0 +1,135 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/routing"
+)
+
+// routeVerifiers returns the verifiers, in execution order, the referrer is
+// routed to.
+func (executor Executor) routeVerifiers(ctx context.Context, referenceDesc ocispecs.ReferenceDescriptor) []vr.ReferenceVerifier {
+	var mappings map[string]string
+	if executor.Config != nil {
+		mappings = executor.Config.ArtifactTypeMappings
+	}
+	return routing.Route(ctx, referenceDesc, executor.subjectVerifiers(), mappings)
+}
+
+// normalizeArtifactTypes returns the referrers with their artifact types
+// normalized by the configured aliases. The listed referrers are shared
+// within the verification and are copied rather than modified.
+func (executor Executor) normalizeArtifactTypes(referrers []ocispecs.ReferenceDescriptor) []ocispecs.ReferenceDescriptor {
+	if executor.Config == nil || len(executor.Config.ArtifactTypeAliases) == 0 {
+		return referrers
+	}
+	normalized := make([]ocispecs.ReferenceDescriptor, len(referrers))
+	for i, referrer := range referrers {
+		referrer.ArtifactType = routing.NormalizeArtifactType(referrer.ArtifactType, executor.Config.ArtifactTypeAliases)
+		normalized[i] = referrer
+	}
+	return normalized
+}
+
+// subjectVerifiers returns the verifiers, in execution order, that apply to
+// the referrers of the subject. They are restricted to the verifiers mapped to
+// the artifact type of the subject, if any.
+func (executor Executor) subjectVerifiers() []vr.ReferenceVerifier {
+	verifiers := executor.orderedVerifiers()
+	if executor.Config == nil || executor.subjectArtifactType == "" {
+		return verifiers
+	}
+	names, ok := executor.Config.SubjectArtifactTypeMappings[executor.subjectArtifactType]
+	if !ok {
+		return verifiers
+	}
+	var mapped []vr.ReferenceVerifier
+	for _, verifier := range verifiers {
+		if slices.Contains(names, verifier.Name()) || slices.Contains(names, verifier.Type()) {
+			mapped = append(mapped, verifier)
+		}
+	}
+	return mapped
+}
+
+// unknownArtifactTypeAction returns the configured action for referrers no
+// verifier is routed to.
+func (executor Executor) unknownArtifactTypeAction() string {
+	switch {
+	case executor.Config == nil:
+		return routing.UnknownArtifactTypeSkip
+	case executor.Config.UnknownArtifactType != "":
+		return executor.Config.UnknownArtifactType
+	default:
+		return routing.UnknownArtifactTypeSkip
+	}
+}
+
+// unknownArtifactTypeExtension is the extension key marking the result of a
+// referrer no verifier is routed to, set to the artifact type of the referrer.
+const unknownArtifactTypeExtension = "unknownArtifactType"
+
+// unmatchedArtifactTypes returns the distinct artifact types of the referrers
+// no verifier was routed to.
+func unmatchedArtifactTypes(contributions []types.VerifierContribution) []string {
+	var artifactTypes []string
+	for _, contribution := range contributions {
+		if !contribution.UnmatchedArtifactType {
+			continue
+		}
+		if !slices.Contains(artifactTypes, contribution.ArtifactType) {
+			artifactTypes = append(artifactTypes, contribution.ArtifactType)
+		}
+	}
+	return artifactTypes
+}
+
+// isUnknownArtifactTypeResult reports whether the extensions of a result mark
+// it as the result of a referrer no verifier is routed to.
+func isUnknownArtifactTypeResult(extensions interface{}) bool {
+	marked, ok := extensions.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = marked[unknownArtifactTypeExtension]
+	return ok
+}
+
+// unknownArtifactTypeResult returns the verifier result of a referrer no
+// verifier is routed to, or false if such referrers are skipped.
+func (executor Executor) unknownArtifactTypeResult(referenceDesc ocispecs.ReferenceDescriptor) (vr.VerifierResult, bool) {
+	message := fmt.Sprintf("No verifier is configured for artifact type %s of reference %s", referenceDesc.ArtifactType, referenceDesc.Digest)
+	extensions := map[string]interface{}{unknownArtifactTypeExtension: referenceDesc.ArtifactType}
+	switch executor.unknownArtifactTypeAction() {
+	case routing.UnknownArtifactTypeWarn:
+		result := vr.NewVerifierResult("", "", "", message, true, nil, extensions)
+		result.Level = vr.LevelWarn
+		return result, true
+	case routing.UnknownArtifactTypeFail:
+		verifierErr := errors.ErrorCodeVerifyReferenceFailure.WithDetail(message)
+		return vr.NewVerifierResult("", "", "", message, false, &verifierErr, extensions), true
+	default:
+		return vr.VerifierResult{}, false
+	}
+}
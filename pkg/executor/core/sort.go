@@ -0,0 +1,68 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+	vt "github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+// sortVerifierReports orders the verifier reports, including the nested ones,
+// by artifact type, reference digest and verifier name so that the report does
+// not depend on the order referrers were listed and verified in.
+func sortVerifierReports(verifierReports []interface{}) {
+	for idx, report := range verifierReports {
+		if nestedReport, ok := report.(types.NestedVerifierReport); ok {
+			sortNestedReport(&nestedReport)
+			verifierReports[idx] = nestedReport
+		}
+	}
+	sort.SliceStable(verifierReports, func(i, j int) bool {
+		return slices.Compare(reportSortKey(verifierReports[i]), reportSortKey(verifierReports[j])) < 0
+	})
+}
+
+func sortNestedReport(report *types.NestedVerifierReport) {
+	sort.SliceStable(report.VerifierReports, func(i, j int) bool {
+		return slices.Compare(pluginResultSortKey(report.VerifierReports[i]), pluginResultSortKey(report.VerifierReports[j])) < 0
+	})
+	for idx := range report.NestedReports {
+		sortNestedReport(&report.NestedReports[idx])
+	}
+	sort.SliceStable(report.NestedReports, func(i, j int) bool {
+		return slices.Compare(reportSortKey(report.NestedReports[i]), reportSortKey(report.NestedReports[j])) < 0
+	})
+}
+
+// reportSortKey returns the fields a verifier report is ordered by. Reports of
+// unknown types are ordered first.
+func reportSortKey(report interface{}) []string {
+	switch r := report.(type) {
+	case vr.VerifierResult:
+		return []string{r.ArtifactType, r.ReferenceDigest, r.VerifierName, r.VerifierType, r.Subject}
+	case types.NestedVerifierReport:
+		return []string{r.ArtifactType, r.ReferenceDigest, "", "", r.Subject}
+	}
+	return nil
+}
+
+func pluginResultSortKey(result vt.VerifierResult) []string {
+	return []string{result.VerifierName, result.VerifierType, result.Message}
+}
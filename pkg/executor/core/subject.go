@@ -0,0 +1,51 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+)
+
+// subjectReferences returns the subject as a reference of the
+// ocispecs.SubjectArtifactType artifact type if a verifier verifies that
+// artifact type, so that verifiers of the subject itself, rather than of its
+// referrers, run once per subject. Nested subjects are not verified this way.
+func (executor Executor) subjectReferences(ctx context.Context, desc *ocispecs.SubjectDescriptor, referenceTypes []string) []storeReference {
+	if verificationDepth(ctx) > 0 || len(executor.ReferrerStores) == 0 {
+		return nil
+	}
+	if len(referenceTypes) > 0 && !slices.Contains(referenceTypes, "*") && !slices.Contains(referenceTypes, ocispecs.SubjectArtifactType) {
+		return nil
+	}
+	reference := ocispecs.ReferenceDescriptor{Descriptor: desc.Descriptor, ArtifactType: ocispecs.SubjectArtifactType}
+	for _, verifier := range executor.Verifiers {
+		if verifier.CanVerify(ctx, reference) {
+			// the subject was resolved through the stores, the first one
+			// serves its content
+			return []storeReference{{store: executor.ReferrerStores[0], reference: reference}}
+		}
+	}
+	return nil
+}
+
+// isSubjectReference returns true if the reference is the subject itself
+// verified by the verifiers of the subject.
+func isSubjectReference(referenceDesc ocispecs.ReferenceDescriptor) bool {
+	return referenceDesc.ArtifactType == ocispecs.SubjectArtifactType
+}
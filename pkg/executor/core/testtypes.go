@@ -25,8 +25,12 @@ import (
 )
 
 type TestVerifier struct {
-	CanVerifyFunc    func(artifactType string) bool
-	VerifyResult     func(artifactType string) bool
+	CanVerifyFunc func(artifactType string) bool
+	VerifyResult  func(artifactType string) bool
+	LevelFunc     func(artifactType string) string
+	// VerifiesSubject makes the verifier verify the subject itself, like
+	// verifiers configured with ocispecs.SubjectArtifactType.
+	VerifiesSubject  bool
 	nestedReferences []string
 }
 
@@ -39,6 +43,9 @@ func (s *TestVerifier) Type() string {
 }
 
 func (s *TestVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	if referenceDescriptor.ArtifactType == ocispecs.SubjectArtifactType {
+		return s.VerifiesSubject
+	}
 	return s.CanVerifyFunc(referenceDescriptor.ArtifactType)
 }
 
@@ -46,9 +53,15 @@ func (s *TestVerifier) Verify(_ context.Context,
 	_ common.Reference,
 	referenceDescriptor ocispecs.ReferenceDescriptor,
 	_ referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
-	return verifier.VerifierResult{
-		IsSuccess: s.VerifyResult(referenceDescriptor.ArtifactType),
-	}, nil
+	result := verifier.VerifierResult{
+		IsSuccess:    s.VerifyResult(referenceDescriptor.ArtifactType),
+		VerifierName: s.Name(),
+		Message:      "verified " + referenceDescriptor.ArtifactType,
+	}
+	if s.LevelFunc != nil {
+		result.Level = s.LevelFunc(referenceDescriptor.ArtifactType)
+	}
+	return result, nil
 }
 
 func (s *TestVerifier) GetNestedReferences() []string {
@@ -0,0 +1,163 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events records Kubernetes events for the subjects denied by Ratify
+// so that operators see verification failures with kubectl get events.
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/lru"
+)
+
+const (
+	// ReasonVerificationDenied is the reason of the events recorded for denied
+	// subjects.
+	ReasonVerificationDenied = "VerificationDenied"
+
+	defaultInterval = time.Minute
+	// maxTrackedEvents bounds the events remembered for rate limiting, the
+	// least recently emitted ones are forgotten first.
+	maxTrackedEvents = 1024
+)
+
+// Config describes the Kubernetes events recorded for denied subjects.
+type Config struct {
+	// Interval is the minimum duration between two events of the same subject
+	// and reason on a resource, e.g. 5m. Defaults to 1m.
+	Interval string `json:"interval,omitempty"`
+}
+
+// eventKey identifies the events that are rate limited together.
+type eventKey struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+	subject    string
+	reason     types.DecisionReason
+}
+
+// Emitter records events for denied subjects, at most one per subject, reason
+// and resource within the interval.
+type Emitter struct {
+	recorder record.EventRecorder
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	emitted *lru.Cache
+}
+
+var (
+	sharedMu       sync.Mutex
+	sharedRecorder record.EventRecorder
+	shared         *Emitter
+	sharedConf     Config
+)
+
+// Validate returns an error if the events configuration is invalid.
+func (c *Config) Validate() error {
+	_, err := parseInterval(*c)
+	return err
+}
+
+func parseInterval(conf Config) (time.Duration, error) {
+	if conf.Interval == "" {
+		return defaultInterval, nil
+	}
+	interval, err := time.ParseDuration(conf.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid events interval %s: %w", conf.Interval, err)
+	}
+	if interval < 0 {
+		return 0, fmt.Errorf("events interval must not be negative, got %s", conf.Interval)
+	}
+	return interval, nil
+}
+
+// NewEmitter creates an Emitter recording events with the recorder.
+func NewEmitter(recorder record.EventRecorder, conf Config) (*Emitter, error) {
+	interval, err := parseInterval(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &Emitter{
+		recorder: recorder,
+		interval: interval,
+		now:      time.Now,
+		emitted:  lru.New(maxTrackedEvents),
+	}, nil
+}
+
+// SetRecorder registers the recorder of the process, e.g. the one of the
+// controller manager. No events are recorded before a recorder is registered.
+func SetRecorder(recorder record.EventRecorder) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	sharedRecorder = recorder
+	shared = nil
+}
+
+// Shared returns the emitter of the process for the configuration, or nil if
+// no recorder is registered. The emitter is replaced if the configuration
+// changed.
+func Shared(conf Config) (*Emitter, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if sharedRecorder == nil {
+		return nil, nil
+	}
+	if shared != nil && sharedConf == conf {
+		return shared, nil
+	}
+	emitter, err := NewEmitter(sharedRecorder, conf)
+	if err != nil {
+		return nil, err
+	}
+	shared, sharedConf = emitter, conf
+	return shared, nil
+}
+
+// EmitDenied records a warning event with the subject and the reason of its
+// denial on the object, and returns false if an event of the subject and
+// reason was already recorded on the object within the interval.
+func (e *Emitter) EmitDenied(object *corev1.ObjectReference, subject string, reason types.DecisionReason) bool {
+	key := eventKey{
+		apiVersion: object.APIVersion,
+		kind:       object.Kind,
+		namespace:  object.Namespace,
+		name:       object.Name,
+		subject:    subject,
+		reason:     reason,
+	}
+	now := e.now()
+	e.mu.Lock()
+	if last, ok := e.emitted.Get(key); ok && now.Sub(last.(time.Time)) < e.interval {
+		e.mu.Unlock()
+		return false
+	}
+	e.emitted.Add(key, now)
+	e.mu.Unlock()
+
+	e.recorder.Eventf(object, corev1.EventTypeWarning, ReasonVerificationDenied, "subject %s was denied with reason %s", subject, reason)
+	return true
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const testSubject = "localhost:5000/net-monitor@sha256:6a5a5368e0c2d3e5909184fa28ddfd56072e7ff3ee9a945876f7eee5896ef5bb"
+
+var (
+	testDeployment  = &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "net-monitor"}
+	otherDeployment = &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "net-monitor-canary"}
+)
+
+func TestEmitDenied(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	emitter, err := NewEmitter(recorder, Config{Interval: "5m"})
+	if err != nil {
+		t.Fatalf("failed to create emitter: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	emitter.now = func() time.Time { return now }
+
+	if !emitter.EmitDenied(testDeployment, testSubject, types.ReasonSignatureInvalid) {
+		t.Fatalf("expected the first event to be emitted")
+	}
+	event := <-recorder.Events
+	for _, expected := range []string{"Warning", ReasonVerificationDenied, testSubject, string(types.ReasonSignatureInvalid)} {
+		if !strings.Contains(event, expected) {
+			t.Fatalf("expected event %q to contain %q", event, expected)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if emitter.EmitDenied(testDeployment, testSubject, types.ReasonSignatureInvalid) {
+		t.Fatalf("expected the repeated event to be rate limited")
+	}
+	// events of another reason or resource are limited separately
+	if !emitter.EmitDenied(testDeployment, testSubject, types.ReasonPolicyDenied) {
+		t.Fatalf("expected the event of another reason to be emitted")
+	}
+	if !emitter.EmitDenied(otherDeployment, testSubject, types.ReasonSignatureInvalid) {
+		t.Fatalf("expected the event of another resource to be emitted")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if !emitter.EmitDenied(testDeployment, testSubject, types.ReasonSignatureInvalid) {
+		t.Fatalf("expected the event to be emitted after the interval")
+	}
+	if len(recorder.Events) != 3 {
+		t.Fatalf("expected 3 more events, got %d", len(recorder.Events))
+	}
+}
+
+func TestEmitDenied_Bounded(t *testing.T) {
+	recorder := record.NewFakeRecorder(maxTrackedEvents + 2)
+	emitter, err := NewEmitter(recorder, Config{})
+	if err != nil {
+		t.Fatalf("failed to create emitter: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	emitter.now = func() time.Time { return now }
+	emitter.EmitDenied(testDeployment, testSubject, types.ReasonSignatureInvalid)
+	for i := 0; i < maxTrackedEvents; i++ {
+		emitter.EmitDenied(testDeployment, strings.Repeat("a", i+1), types.ReasonSignatureInvalid)
+	}
+	if emitter.emitted.Len() != maxTrackedEvents {
+		t.Fatalf("expected %d tracked events, got %d", maxTrackedEvents, emitter.emitted.Len())
+	}
+
+	// the least recently emitted event is forgotten and emitted again
+	if !emitter.EmitDenied(testDeployment, testSubject, types.ReasonSignatureInvalid) {
+		t.Fatalf("expected the forgotten event to be emitted")
+	}
+}
+
+func TestShared(t *testing.T) {
+	t.Cleanup(func() { SetRecorder(nil) })
+
+	SetRecorder(nil)
+	if emitter, err := Shared(Config{}); emitter != nil || err != nil {
+		t.Fatalf("expected no emitter without a recorder, got %v, %v", emitter, err)
+	}
+
+	SetRecorder(record.NewFakeRecorder(1))
+	emitter, err := Shared(Config{})
+	if err != nil || emitter == nil {
+		t.Fatalf("expected an emitter, got %v, %v", emitter, err)
+	}
+	if again, _ := Shared(Config{}); again != emitter {
+		t.Fatalf("expected the emitter to be shared")
+	}
+	if other, _ := Shared(Config{Interval: "1h"}); other == emitter {
+		t.Fatalf("expected the emitter to be replaced for another configuration")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		interval  string
+		expectErr bool
+	}{
+		{interval: ""},
+		{interval: "30s"},
+		{interval: "soon", expectErr: true},
+		{interval: "-1m", expectErr: true},
+	} {
+		conf := Config{Interval: tc.interval}
+		if err := conf.Validate(); (err != nil) != tc.expectErr {
+			t.Fatalf("expected error %v for interval %q, got %v", tc.expectErr, tc.interval, err)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package membudget limits the memory held by the blobs fetched concurrently
+// across verifications.
+package membudget
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ratify-project/ratify/errors"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	defaultMaxWait = time.Second
+	readChunkSize  = 32 * 1024
+)
+
+type budgetKey struct{}
+
+// Config describes the memory budget shared by all verifications.
+type Config struct {
+	// MaxBytes is the total size of the blobs that may be fetched
+	// concurrently.
+	MaxBytes int64 `json:"maxBytes"`
+	// MaxWait bounds how long a fetch waits for the budget before failing with
+	// a busy error, e.g. 500ms. Defaults to 1s.
+	MaxWait string `json:"maxWait,omitempty"`
+}
+
+// Budget is a semaphore sized by bytes. Fetches reserve the size of their blob
+// and wait while the budget is exhausted.
+type Budget struct {
+	conf     Config
+	maxBytes int64
+	maxWait  time.Duration
+	sem      *semaphore.Weighted
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   *Budget
+)
+
+// Validate returns an error if the memory budget configuration is invalid.
+func (c *Config) Validate() error {
+	_, err := NewBudget(*c)
+	return err
+}
+
+// NewBudget creates a Budget from its configuration.
+func NewBudget(conf Config) (*Budget, error) {
+	if conf.MaxBytes <= 0 {
+		return nil, fmt.Errorf("memoryBudget maxBytes must be positive, got %d", conf.MaxBytes)
+	}
+	maxWait := defaultMaxWait
+	if conf.MaxWait != "" {
+		var err error
+		if maxWait, err = time.ParseDuration(conf.MaxWait); err != nil {
+			return nil, fmt.Errorf("invalid memoryBudget maxWait %s: %w", conf.MaxWait, err)
+		}
+		if maxWait <= 0 {
+			return nil, fmt.Errorf("memoryBudget maxWait must be positive, got %s", conf.MaxWait)
+		}
+	}
+	return &Budget{
+		conf:     conf,
+		maxBytes: conf.MaxBytes,
+		maxWait:  maxWait,
+		sem:      semaphore.NewWeighted(conf.MaxBytes),
+	}, nil
+}
+
+// Shared returns the budget of the process for the configuration. The budget
+// is replaced if the configuration changed, in which case fetches holding a
+// reservation release it to the previous budget.
+func Shared(conf Config) (*Budget, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if shared != nil && shared.conf == conf {
+		return shared, nil
+	}
+	budget, err := NewBudget(conf)
+	if err != nil {
+		return nil, err
+	}
+	shared = budget
+	return shared, nil
+}
+
+// WithBudget returns a context whose fetches reserve their size from the
+// budget until the returned function is called. The verifiers hold the fetched
+// blobs until the verification completes, so the reservations are released
+// together once it does. A context that already has a budget is returned
+// as is, nested verifications share the reservations of the outer one.
+func WithBudget(ctx context.Context, budget *Budget) (context.Context, func()) {
+	if budget == nil || FromContext(ctx) != nil {
+		return ctx, func() {}
+	}
+	l := &lease{budget: budget}
+	return context.WithValue(ctx, budgetKey{}, l), l.release
+}
+
+// FromContext returns the budget of the context, nil if it has none.
+func FromContext(ctx context.Context) *Budget {
+	if l := leaseFromContext(ctx); l != nil {
+		return l.budget
+	}
+	return nil
+}
+
+// Reserve reserves size bytes of the budget of the context until the
+// verification completes. Reserving from a context without a budget never
+// blocks.
+func Reserve(ctx context.Context, size int64) error {
+	return leaseFromContext(ctx).reserve(ctx, size)
+}
+
+// ReadAll reads r until EOF, reserving the bytes read from the budget of the
+// context until the verification completes. It reads content whose size is
+// not known in advance, content of a known size reserves it with Reserve.
+func ReadAll(ctx context.Context, r io.Reader) ([]byte, error) {
+	var content []byte
+	chunk := make([]byte, readChunkSize)
+	for {
+		n, err := r.Read(chunk)
+		if n > 0 {
+			if reserveErr := Reserve(ctx, int64(n)); reserveErr != nil {
+				return nil, reserveErr
+			}
+			content = append(content, chunk[:n]...)
+		}
+		if err == io.EOF {
+			return content, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func leaseFromContext(ctx context.Context) *lease {
+	l, _ := ctx.Value(budgetKey{}).(*lease)
+	return l
+}
+
+// lease holds the reservations of a verification.
+type lease struct {
+	budget   *Budget
+	mu       sync.Mutex
+	reserved int64
+	releases []func()
+	released bool
+}
+
+// reserve reserves size bytes for the verification. The reservations of a
+// verification never exceed the budget, a verification holding all of it
+// proceeds without reserving more.
+func (l *lease) reserve(ctx context.Context, size int64) error {
+	if l == nil || size <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	if l.reserved+size > l.budget.maxBytes {
+		size = l.budget.maxBytes - l.reserved
+	}
+	l.reserved += size
+	l.mu.Unlock()
+	if size <= 0 {
+		return nil
+	}
+
+	release, err := l.budget.Acquire(ctx, size)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		l.reserved -= size
+		return err
+	}
+	if l.released {
+		release()
+		return nil
+	}
+	l.releases = append(l.releases, release)
+	return nil
+}
+
+func (l *lease) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, release := range l.releases {
+		release()
+	}
+	l.releases = nil
+	l.reserved = 0
+	l.released = true
+}
+
+// Acquire reserves size bytes of the budget and returns the function releasing
+// them. A blob larger than the budget reserves all of it. If the budget stays
+// exhausted for longer than the maximum wait, a retryable busy error is
+// returned. Acquiring from a nil budget never blocks.
+func (b *Budget) Acquire(ctx context.Context, size int64) (func(), error) {
+	if b == nil || size <= 0 {
+		return func() {}, nil
+	}
+	if size > b.maxBytes {
+		size = b.maxBytes
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, b.maxWait)
+	defer cancel()
+	if err := b.sem.Acquire(waitCtx, size); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.ErrorCodeResourceBusy.WithDetail(fmt.Sprintf("memory budget of %d bytes is exhausted, failed to reserve %d bytes within %s", b.maxBytes, size, b.maxWait))
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.sem.Release(size) })
+	}, nil
+}
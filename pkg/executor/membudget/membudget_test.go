@@ -0,0 +1,285 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package membudget
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ratify-project/ratify/errors"
+)
+
+func TestNewBudget(t *testing.T) {
+	tests := []struct {
+		name            string
+		conf            Config
+		expectedErr     bool
+		expectedMaxWait time.Duration
+	}{
+		{
+			name:            "default max wait",
+			conf:            Config{MaxBytes: 1024},
+			expectedMaxWait: defaultMaxWait,
+		},
+		{
+			name:            "custom max wait",
+			conf:            Config{MaxBytes: 1024, MaxWait: "250ms"},
+			expectedMaxWait: 250 * time.Millisecond,
+		},
+		{
+			name:        "zero max bytes",
+			conf:        Config{},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid max wait",
+			conf:        Config{MaxBytes: 1024, MaxWait: "soon"},
+			expectedErr: true,
+		},
+		{
+			name:        "negative max wait",
+			conf:        Config{MaxBytes: 1024, MaxWait: "-1s"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget, err := NewBudget(tt.conf)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil && budget.maxWait != tt.expectedMaxWait {
+				t.Fatalf("expected max wait %s, got %s", tt.expectedMaxWait, budget.maxWait)
+			}
+		})
+	}
+}
+
+func TestAcquire_ConcurrentFetchesRespectBudget(t *testing.T) {
+	const maxBytes = 100
+	const blobSize = 40
+	budget, err := NewBudget(Config{MaxBytes: maxBytes, MaxWait: "5s"})
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+
+	var inUse, peak atomic.Int64
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := budget.Acquire(context.Background(), blobSize)
+			if err != nil {
+				errs <- err
+				return
+			}
+			current := inUse.Add(blobSize)
+			for {
+				observed := peak.Load()
+				if current <= observed || peak.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			// simulate reading the blob
+			time.Sleep(5 * time.Millisecond)
+			inUse.Add(-blobSize)
+			release()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("expected fetches to wait for the budget, got %v", err)
+	}
+	if peak.Load() > maxBytes {
+		t.Fatalf("expected at most %d bytes in use, got %d", maxBytes, peak.Load())
+	}
+	if peak.Load() < 2*blobSize {
+		t.Fatalf("expected fetches to run concurrently within the budget, peak was %d bytes", peak.Load())
+	}
+}
+
+func TestAcquire_BusyWhenExhausted(t *testing.T) {
+	budget, err := NewBudget(Config{MaxBytes: 100, MaxWait: "20ms"})
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+	release, err := budget.Acquire(context.Background(), 80)
+	if err != nil {
+		t.Fatalf("failed to acquire budget: %v", err)
+	}
+
+	_, err = budget.Acquire(context.Background(), 40)
+	var ratifyErr errors.Error
+	if !stderrors.As(err, &ratifyErr) || ratifyErr.ErrorCode() != errors.ErrorCodeResourceBusy {
+		t.Fatalf("expected error code %s, got %v", errors.ErrorCodeResourceBusy, err)
+	}
+
+	// releasing twice must not return the bytes twice
+	release()
+	release()
+	first, err := budget.Acquire(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("expected budget to be released, got %v", err)
+	}
+	if _, err := budget.Acquire(context.Background(), 1); err == nil {
+		t.Fatalf("expected budget to be exhausted")
+	}
+	first()
+}
+
+func TestAcquire_LargerThanBudget(t *testing.T) {
+	budget, err := NewBudget(Config{MaxBytes: 100, MaxWait: "20ms"})
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+	release, err := budget.Acquire(context.Background(), 1000)
+	if err != nil {
+		t.Fatalf("expected blob larger than the budget to reserve all of it, got %v", err)
+	}
+	if _, err := budget.Acquire(context.Background(), 1); err == nil {
+		t.Fatalf("expected budget to be exhausted")
+	}
+	release()
+}
+
+func TestAcquire_ContextCanceled(t *testing.T) {
+	budget, err := NewBudget(Config{MaxBytes: 100, MaxWait: "5s"})
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+	release, err := budget.Acquire(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("failed to acquire budget: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := budget.Acquire(ctx, 1); !stderrors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, got %v", err)
+	}
+}
+
+func TestAcquire_NilBudget(t *testing.T) {
+	var budget *Budget
+	release, err := budget.Acquire(context.Background(), 1<<40)
+	if err != nil {
+		t.Fatalf("expected nil budget not to block, got %v", err)
+	}
+	release()
+	if FromContext(context.Background()) != nil {
+		t.Fatalf("expected no budget in context")
+	}
+}
+
+func TestShared(t *testing.T) {
+	first, err := Shared(Config{MaxBytes: 100})
+	if err != nil {
+		t.Fatalf("failed to get shared budget: %v", err)
+	}
+	second, err := Shared(Config{MaxBytes: 100})
+	if err != nil {
+		t.Fatalf("failed to get shared budget: %v", err)
+	}
+	if first != second {
+		t.Fatalf("expected the same budget for the same configuration")
+	}
+	third, err := Shared(Config{MaxBytes: 200})
+	if err != nil {
+		t.Fatalf("failed to get shared budget: %v", err)
+	}
+	if third == first {
+		t.Fatalf("expected a new budget for a changed configuration")
+	}
+	ctx, release := WithBudget(context.Background(), third)
+	defer release()
+	if FromContext(ctx) != third {
+		t.Fatalf("expected budget from context")
+	}
+}
+
+func TestWithBudget_HoldsReservationsUntilReleased(t *testing.T) {
+	budget, err := NewBudget(Config{MaxBytes: 100, MaxWait: "20ms"})
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+	ctx, release := WithBudget(context.Background(), budget)
+	if err := Reserve(ctx, 60); err != nil {
+		t.Fatalf("failed to reserve: %v", err)
+	}
+	content, err := ReadAll(ctx, bytes.NewReader(make([]byte, 30)))
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if len(content) != 30 {
+		t.Fatalf("expected 30 bytes, got %d", len(content))
+	}
+
+	// nested verifications share the reservations of the outer one
+	nested, releaseNested := WithBudget(ctx, budget)
+	if err := Reserve(nested, 5); err != nil {
+		t.Fatalf("failed to reserve: %v", err)
+	}
+	releaseNested()
+
+	if releaseOther, err := budget.Acquire(context.Background(), 10); err == nil {
+		releaseOther()
+		t.Fatalf("expected the reservations to be held")
+	}
+	release()
+	releaseOther, err := budget.Acquire(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("expected the reservations to be released, got %v", err)
+	}
+	releaseOther()
+	// reservations after the verification completed are not held
+	if err := Reserve(ctx, 100); err != nil {
+		t.Fatalf("failed to reserve: %v", err)
+	}
+	releaseOther, err = budget.Acquire(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("expected reservations after the release not to be held, got %v", err)
+	}
+	releaseOther()
+}
+
+func TestReserve_NeverExceedsBudget(t *testing.T) {
+	budget, err := NewBudget(Config{MaxBytes: 100, MaxWait: "20ms"})
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+	ctx, release := WithBudget(context.Background(), budget)
+	defer release()
+	for i := 0; i < 3; i++ {
+		if err := Reserve(ctx, 60); err != nil {
+			t.Fatalf("expected a verification holding the whole budget to proceed, got %v", err)
+		}
+	}
+}
+
+func TestReserve_NoBudget(t *testing.T) {
+	if err := Reserve(context.Background(), 1<<40); err != nil {
+		t.Fatalf("expected no budget not to block, got %v", err)
+	}
+}
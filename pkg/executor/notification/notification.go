@@ -0,0 +1,254 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+)
+
+const (
+	// ModeFailure posts a summary only for failed verifications.
+	ModeFailure = "failure"
+	// ModeAlways posts a summary for every verification.
+	ModeAlways = "always"
+
+	defaultMaxRetries = 3
+	defaultTimeout    = 5 * time.Second
+	retryBackoff      = 500 * time.Millisecond
+	// queueSize bounds the summaries waiting to be posted, further summaries
+	// are dropped until the webhook catches up.
+	queueSize = 256
+)
+
+var (
+	logOpt = logger.Option{ComponentType: logger.Executor}
+
+	sharedMu sync.Mutex
+	shared   *Notifier
+	// sharedConf is the configuration the shared notifier was created from.
+	sharedConf Config
+)
+
+// Config describes the webhook receiving verification summaries.
+type Config struct {
+	// URL is the http(s) endpoint the summaries are posted to.
+	URL string `json:"url"`
+	// Mode is failure or always and decides which decisions are posted.
+	// Defaults to failure.
+	Mode string `json:"mode,omitempty"`
+	// MaxRetries is the number of retries after a failed attempt. Defaults to 3.
+	MaxRetries *int `json:"maxRetries,omitempty"`
+	// Timeout bounds each attempt, e.g. 5s. Defaults to 5s.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Summary is the JSON document posted to the webhook.
+type Summary struct {
+	Subject   string                       `json:"subject"`
+	IsSuccess bool                         `json:"isSuccess"`
+	Reason    types.DecisionReason         `json:"reason"`
+	Verifiers []types.VerifierContribution `json:"verifiers"`
+	Timestamp time.Time                    `json:"timestamp"`
+}
+
+// Notifier posts verification summaries to a webhook.
+type Notifier struct {
+	url        string
+	mode       string
+	maxRetries int
+	backoff    time.Duration
+	client     *http.Client
+
+	// queue holds the summaries posted in the background by a single worker
+	// started with the first queued summary.
+	queue    chan queuedSummary
+	startRun sync.Once
+	mu       sync.RWMutex
+	closed   bool
+}
+
+type queuedSummary struct {
+	ctx     context.Context
+	summary Summary
+}
+
+// Validate returns an error if the notification configuration is invalid.
+func (c *Config) Validate() error {
+	_, err := NewNotifier(*c)
+	return err
+}
+
+// NewNotifier creates a Notifier from its configuration.
+func NewNotifier(conf Config) (*Notifier, error) {
+	endpoint, err := url.Parse(conf.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("notification url %q must be an absolute http(s) url", conf.URL)
+	}
+
+	notifier := &Notifier{
+		url:        conf.URL,
+		mode:       ModeFailure,
+		maxRetries: defaultMaxRetries,
+		backoff:    retryBackoff,
+		queue:      make(chan queuedSummary, queueSize),
+	}
+	switch conf.Mode {
+	case "":
+	case ModeFailure, ModeAlways:
+		notifier.mode = conf.Mode
+	default:
+		return nil, fmt.Errorf("notification mode must be %s or %s, got %s", ModeFailure, ModeAlways, conf.Mode)
+	}
+	if conf.MaxRetries != nil {
+		if *conf.MaxRetries < 0 {
+			return nil, fmt.Errorf("notification maxRetries must not be negative, got %d", *conf.MaxRetries)
+		}
+		notifier.maxRetries = *conf.MaxRetries
+	}
+	timeout := defaultTimeout
+	if conf.Timeout != "" {
+		if timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse notification timeout %s: %w", conf.Timeout, err)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("notification timeout must be positive, got %s", conf.Timeout)
+		}
+	}
+	notifier.client = &http.Client{Timeout: timeout}
+	return notifier, nil
+}
+
+// Shared returns the notifier of the configuration shared by all
+// verifications, so that summaries are posted by one client and queue. The
+// notifier is replaced, and the previous one closed, once the configuration
+// changes.
+func Shared(conf Config) (*Notifier, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if shared != nil && reflect.DeepEqual(sharedConf, conf) {
+		return shared, nil
+	}
+	notifier, err := NewNotifier(conf)
+	if err != nil {
+		return nil, err
+	}
+	if shared != nil {
+		shared.Close()
+	}
+	shared, sharedConf = notifier, conf
+	return shared, nil
+}
+
+// Enqueue queues the summary to be posted in the background and returns false
+// if it was dropped because the queue is full or the notifier is closed.
+// Failures to post it are logged with the context of the summary.
+func (n *Notifier) Enqueue(ctx context.Context, summary Summary) bool {
+	n.startRun.Do(func() { go n.run() })
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return false
+	}
+	select {
+	case n.queue <- queuedSummary{ctx: ctx, summary: summary}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops queueing summaries. The summaries already queued are still
+// posted.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+}
+
+func (n *Notifier) run() {
+	for queued := range n.queue {
+		if err := n.Notify(queued.ctx, queued.summary); err != nil {
+			logger.GetLogger(queued.ctx, logOpt).Warnf("failed to notify verification decision of subject %s: %v", queued.summary.Subject, err)
+		}
+	}
+}
+
+// ShouldNotify reports whether a decision with the given outcome is posted.
+func (n *Notifier) ShouldNotify(isSuccess bool) bool {
+	return n.mode == ModeAlways || !isSuccess
+}
+
+// Notify posts the summary to the webhook, retrying failed attempts. It
+// returns the error of the last attempt if all attempts failed.
+func (n *Notifier) Notify(ctx context.Context, summary Summary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryable, err := n.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= n.maxRetries {
+			return fmt.Errorf("failed to post notification to %s after %d attempt(s): %w", n.url, attempt+1, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to post notification to %s: %w", n.url, ctx.Err())
+		case <-time.After(n.backoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+// post sends a single notification and reports whether a failure is worth
+// retrying.
+func (n *Notifier) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
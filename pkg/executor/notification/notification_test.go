@@ -0,0 +1,260 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		conf  Config
+		isErr bool
+	}{
+		{
+			name: "defaults",
+			conf: Config{URL: "https://example.com/hook"},
+		},
+		{
+			name: "full config",
+			conf: Config{URL: "http://example.com/hook", Mode: ModeAlways, MaxRetries: intPtr(0), Timeout: "1s"},
+		},
+		{
+			name:  "missing url",
+			conf:  Config{},
+			isErr: true,
+		},
+		{
+			name:  "relative url",
+			conf:  Config{URL: "/hook"},
+			isErr: true,
+		},
+		{
+			name:  "invalid mode",
+			conf:  Config{URL: "https://example.com/hook", Mode: "sometimes"},
+			isErr: true,
+		},
+		{
+			name:  "negative retries",
+			conf:  Config{URL: "https://example.com/hook", MaxRetries: intPtr(-1)},
+			isErr: true,
+		},
+		{
+			name:  "invalid timeout",
+			conf:  Config{URL: "https://example.com/hook", Timeout: "soon"},
+			isErr: true,
+		},
+		{
+			name:  "non-positive timeout",
+			conf:  Config{URL: "https://example.com/hook", Timeout: "0s"},
+			isErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.conf.Validate(); tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+		})
+	}
+}
+
+func TestShouldNotify(t *testing.T) {
+	failureOnly, _ := NewNotifier(Config{URL: "https://example.com/hook"})
+	if failureOnly.ShouldNotify(true) || !failureOnly.ShouldNotify(false) {
+		t.Fatalf("expected failure mode to notify failures only")
+	}
+	always, _ := NewNotifier(Config{URL: "https://example.com/hook", Mode: ModeAlways})
+	if !always.ShouldNotify(true) || !always.ShouldNotify(false) {
+		t.Fatalf("expected always mode to notify every decision")
+	}
+}
+
+func TestNotify(t *testing.T) {
+	summary := Summary{
+		Subject: "localhost:5000/net-monitor:v1",
+		Reason:  types.ReasonSignatureInvalid,
+		Verifiers: []types.VerifierContribution{
+			{VerifierName: "notation", ArtifactType: "application/vnd.cncf.notary.signature", Message: "signature invalid"},
+		},
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name             string
+		statusCodes      []int
+		maxRetries       int
+		expectedAttempts int32
+		isErr            bool
+	}{
+		{
+			name:             "delivered",
+			statusCodes:      []int{http.StatusOK},
+			maxRetries:       2,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "delivered after retries",
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusNoContent},
+			maxRetries:       2,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "retries exhausted",
+			statusCodes:      []int{http.StatusInternalServerError},
+			maxRetries:       2,
+			expectedAttempts: 3,
+			isErr:            true,
+		},
+		{
+			name:             "client error is not retried",
+			statusCodes:      []int{http.StatusBadRequest},
+			maxRetries:       2,
+			expectedAttempts: 1,
+			isErr:            true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			var received Summary
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := int(attempts.Add(1)) - 1
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("unexpected request %s with content type %s", r.Method, r.Header.Get("Content-Type"))
+				}
+				if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+					t.Errorf("failed to decode notification: %v", err)
+				}
+				w.WriteHeader(tt.statusCodes[min(attempt, len(tt.statusCodes)-1)])
+			}))
+			defer server.Close()
+
+			notifier, err := NewNotifier(Config{URL: server.URL, MaxRetries: intPtr(tt.maxRetries)})
+			if err != nil {
+				t.Fatalf("failed to create notifier: %v", err)
+			}
+			notifier.backoff = time.Millisecond
+
+			err = notifier.Notify(context.Background(), summary)
+			if tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+			if attempts.Load() != tt.expectedAttempts {
+				t.Fatalf("expected %d attempts, got %d", tt.expectedAttempts, attempts.Load())
+			}
+			if received.Subject != summary.Subject || received.Reason != summary.Reason || len(received.Verifiers) != 1 || received.Verifiers[0].VerifierName != "notation" {
+				t.Fatalf("unexpected notification %+v", received)
+			}
+		})
+	}
+}
+
+func TestNotify_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	notifier, err := NewNotifier(Config{URL: server.URL, MaxRetries: intPtr(1)})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	notifier.backoff = time.Millisecond
+	if err := notifier.Notify(context.Background(), Summary{}); err == nil {
+		t.Fatalf("expected error for unreachable webhook")
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan string, queueSize+1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		var summary Summary
+		if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+		received <- summary.Subject
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(Config{URL: server.URL, MaxRetries: intPtr(0)})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	if !notifier.Enqueue(context.Background(), Summary{Subject: "first"}) {
+		t.Fatalf("expected the summary to be queued")
+	}
+	// the worker holds the first summary while the webhook is blocked
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < queueSize; i++ {
+		if !notifier.Enqueue(context.Background(), Summary{Subject: "queued"}) {
+			t.Fatalf("expected summary %d to be queued", i)
+		}
+	}
+	if notifier.Enqueue(context.Background(), Summary{Subject: "dropped"}) {
+		t.Fatalf("expected the summary to be dropped once the queue is full")
+	}
+
+	notifier.Close()
+	if notifier.Enqueue(context.Background(), Summary{Subject: "closed"}) {
+		t.Fatalf("expected no summary to be queued once the notifier is closed")
+	}
+	close(release)
+	// the queued summaries are still posted after the notifier is closed
+	for i := 0; i <= queueSize; i++ {
+		select {
+		case subject := <-received:
+			if subject == "dropped" || subject == "closed" {
+				t.Fatalf("unexpected notification of %s", subject)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %d notifications, got %d", queueSize+1, i)
+		}
+	}
+}
+
+func TestShared(t *testing.T) {
+	conf := Config{URL: "https://example.com/hook", MaxRetries: intPtr(1)}
+	notifier, err := Shared(conf)
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	if again, _ := Shared(Config{URL: conf.URL, MaxRetries: intPtr(1)}); again != notifier {
+		t.Fatalf("expected the notifier to be shared for an equal configuration")
+	}
+	other, err := Shared(Config{URL: conf.URL, Mode: ModeAlways})
+	if err != nil || other == notifier {
+		t.Fatalf("expected the notifier to be replaced for another configuration, got %v", err)
+	}
+	if notifier.Enqueue(context.Background(), Summary{}) {
+		t.Fatalf("expected the replaced notifier to be closed")
+	}
+	if _, err := Shared(Config{URL: "not a url"}); err == nil {
+		t.Fatalf("expected an error for an invalid configuration")
+	}
+}
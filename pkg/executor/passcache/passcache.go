@@ -0,0 +1,260 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package passcache persists the subjects that passed verification so that
+// they are not verified again after a restart of Ratify.
+package passcache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+)
+
+const defaultTTL = 24 * time.Hour
+
+// Config describes the persistent cache of passed subjects.
+type Config struct {
+	// Path is the file the passed subjects are persisted to. It is loaded
+	// when the cache is created.
+	Path string `json:"path"`
+	// TTL bounds how long a pass is reused, e.g. 12h. Defaults to 24h.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// record is a passed subject as persisted, one JSON record per line.
+type record struct {
+	Subject    string             `json:"subject"`
+	PolicyHash string             `json:"policyHash"`
+	VerifiedAt time.Time          `json:"verifiedAt"`
+	ExpiresAt  time.Time          `json:"expiresAt"`
+	Result     types.VerifyResult `json:"result"`
+}
+
+// Cache holds the passed subjects keyed by their fully qualified repository
+// and digest, e.g. myregistry.io/app@sha256:..., since the policy may treat
+// the same digest differently in another repository. Passes are only reused
+// under the policy hash they were recorded with, and all passes recorded under
+// another hash are dropped once the cache is used with a new one.
+type Cache struct {
+	conf Config
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu         sync.Mutex
+	policyHash string
+	entries    map[string]record
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   *Cache
+)
+
+// Validate returns an error if the pass cache configuration is invalid.
+func (c *Config) Validate() error {
+	_, err := parseTTL(*c)
+	return err
+}
+
+func parseTTL(conf Config) (time.Duration, error) {
+	if conf.Path == "" {
+		return 0, fmt.Errorf("passCache path must be set")
+	}
+	if conf.TTL == "" {
+		return defaultTTL, nil
+	}
+	ttl, err := time.ParseDuration(conf.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid passCache ttl %s: %w", conf.TTL, err)
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("passCache ttl must be positive, got %s", conf.TTL)
+	}
+	return ttl, nil
+}
+
+// New creates a Cache from its configuration and loads the passes persisted
+// to its file, dropping the expired ones. An unreadable file is reported along
+// with the empty cache, which overwrites it.
+func New(conf Config) (*Cache, error) {
+	ttl, err := parseTTL(conf)
+	if err != nil {
+		return nil, err
+	}
+	cache := &Cache{
+		conf:    conf,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]record{},
+	}
+	return cache, cache.load()
+}
+
+// Shared returns the cache of the process for the configuration, loading it
+// on first use. The cache is replaced if the configuration changed.
+func Shared(conf Config) (*Cache, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if shared != nil && shared.conf == conf {
+		return shared, nil
+	}
+	cache, err := New(conf)
+	if cache == nil {
+		return nil, err
+	}
+	shared = cache
+	return shared, err
+}
+
+// Get returns the result of the subject if it passed under the policy hash,
+// has not expired and was verified after since, if set.
+func (c *Cache) Get(subject, policyHash string, since time.Time) (types.VerifyResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usePolicy(policyHash)
+	entry, ok := c.entries[subject]
+	if !ok {
+		return types.VerifyResult{}, false
+	}
+	if !c.now().Before(entry.ExpiresAt) {
+		delete(c.entries, subject)
+		return types.VerifyResult{}, false
+	}
+	if !since.IsZero() && entry.VerifiedAt.Before(since) {
+		return types.VerifyResult{}, false
+	}
+	return entry.Result, true
+}
+
+// Add records that the subject passed under the policy hash and persists it.
+func (c *Cache) Add(subject, policyHash string, result types.VerifyResult, verifiedAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usePolicy(policyHash)
+	entry := record{
+		Subject:    subject,
+		PolicyHash: policyHash,
+		VerifiedAt: verifiedAt,
+		ExpiresAt:  verifiedAt.Add(c.ttl),
+		Result:     result,
+	}
+	c.entries[subject] = entry
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode pass of subject %s: %w", subject, err)
+	}
+	file, err := os.OpenFile(c.conf.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open pass cache %s: %w", c.conf.Path, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to persist pass of subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// usePolicy drops the passes recorded under another policy hash once the
+// policy changed.
+func (c *Cache) usePolicy(policyHash string) {
+	if c.policyHash == policyHash {
+		return
+	}
+	c.policyHash = policyHash
+	dropped := false
+	for subject, entry := range c.entries {
+		if entry.PolicyHash != policyHash {
+			delete(c.entries, subject)
+			dropped = true
+		}
+	}
+	if dropped {
+		// the dropped passes are never reused, failing to remove them from
+		// the file only delays it to the next compaction
+		_ = c.compact()
+	}
+}
+
+// load reads the persisted passes, keeping the latest unexpired pass of each
+// subject, and compacts the file.
+func (c *Cache) load() error {
+	file, err := os.Open(c.conf.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open pass cache %s: %w", c.conf.Path, err)
+	}
+	defer file.Close()
+
+	now := c.now()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var entry record
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Subject == "" {
+			// a partially written last line is dropped, as are the passes
+			// recorded by digest only
+			continue
+		}
+		if now.Before(entry.ExpiresAt) {
+			c.entries[entry.Subject] = entry
+		} else {
+			delete(c.entries, entry.Subject)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read pass cache %s: %w", c.conf.Path, err)
+	}
+	return c.compact()
+}
+
+// compact rewrites the file with the passes held by the cache.
+func (c *Cache) compact() error {
+	temp, err := os.CreateTemp(filepath.Dir(c.conf.Path), filepath.Base(c.conf.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to compact pass cache %s: %w", c.conf.Path, err)
+	}
+	defer os.Remove(temp.Name())
+	writer := bufio.NewWriter(temp)
+	for _, entry := range c.entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			temp.Close()
+			return fmt.Errorf("failed to encode pass of subject %s: %w", entry.Subject, err)
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			temp.Close()
+			return fmt.Errorf("failed to compact pass cache %s: %w", c.conf.Path, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to compact pass cache %s: %w", c.conf.Path, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to compact pass cache %s: %w", c.conf.Path, err)
+	}
+	return os.Rename(temp.Name(), c.conf.Path)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package passcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+)
+
+const (
+	testSubject = "registry.example.com/app@sha256:6a5a5368e0c2d3e5909184fa28ddfd56072e7ff3ee9a945876f7eee5896ef5bb"
+	testHash    = "policy1"
+)
+
+var passed = types.VerifyResult{IsSuccess: true, Reason: types.ReasonVerified}
+
+func newTestCache(t *testing.T, conf Config) *Cache {
+	t.Helper()
+	cache, err := New(conf)
+	if err != nil {
+		t.Fatalf("failed to create pass cache: %v", err)
+	}
+	return cache
+}
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		conf        Config
+		expectedErr bool
+	}{
+		{name: "default ttl", conf: Config{Path: "passes"}},
+		{name: "ttl", conf: Config{Path: "passes", TTL: "12h"}},
+		{name: "missing path", conf: Config{TTL: "12h"}, expectedErr: true},
+		{name: "invalid ttl", conf: Config{Path: "passes", TTL: "a day"}, expectedErr: true},
+		{name: "negative ttl", conf: Config{Path: "passes", TTL: "-1h"}, expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.conf.Validate(); (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCache_PersistsAcrossRestart(t *testing.T) {
+	conf := Config{Path: filepath.Join(t.TempDir(), "passes")}
+	cache := newTestCache(t, conf)
+	if err := cache.Add(testSubject, testHash, passed, time.Now()); err != nil {
+		t.Fatalf("failed to add pass: %v", err)
+	}
+
+	// a restart loads the passes from the file
+	restarted := newTestCache(t, conf)
+	result, ok := restarted.Get(testSubject, testHash, time.Time{})
+	if !ok || !result.IsSuccess || result.Reason != types.ReasonVerified {
+		t.Fatalf("expected the persisted pass, got %+v, %t", result, ok)
+	}
+	if _, ok := restarted.Get("sha256:unknown", testHash, time.Time{}); ok {
+		t.Fatalf("expected no pass of an unknown subject")
+	}
+	if _, ok := restarted.Get(testSubject, testHash, time.Now().Add(time.Minute)); ok {
+		t.Fatalf("expected the pass verified before since to be bypassed")
+	}
+}
+
+func TestCache_InvalidatedOnPolicyChange(t *testing.T) {
+	conf := Config{Path: filepath.Join(t.TempDir(), "passes")}
+	cache := newTestCache(t, conf)
+	if err := cache.Add(testSubject, testHash, passed, time.Now()); err != nil {
+		t.Fatalf("failed to add pass: %v", err)
+	}
+
+	restarted := newTestCache(t, conf)
+	if _, ok := restarted.Get(testSubject, "policy2", time.Time{}); ok {
+		t.Fatalf("expected no pass under a changed policy")
+	}
+	// the passes of the previous policy are dropped, also from the file
+	if _, ok := restarted.Get(testSubject, testHash, time.Time{}); ok {
+		t.Fatalf("expected the pass of the previous policy to be dropped")
+	}
+	if _, ok := newTestCache(t, conf).Get(testSubject, testHash, time.Time{}); ok {
+		t.Fatalf("expected the pass of the previous policy to be dropped from the file")
+	}
+}
+
+func TestCache_Expiry(t *testing.T) {
+	conf := Config{Path: filepath.Join(t.TempDir(), "passes"), TTL: "1h"}
+	cache := newTestCache(t, conf)
+	if err := cache.Add(testSubject, testHash, passed, time.Now().Add(-2*time.Hour)); err != nil {
+		t.Fatalf("failed to add pass: %v", err)
+	}
+	if _, ok := cache.Get(testSubject, testHash, time.Time{}); ok {
+		t.Fatalf("expected the expired pass to be dropped")
+	}
+	if _, ok := newTestCache(t, conf).Get(testSubject, testHash, time.Time{}); ok {
+		t.Fatalf("expected the expired pass not to be loaded")
+	}
+}
+
+func TestCache_CorruptFile(t *testing.T) {
+	conf := Config{Path: filepath.Join(t.TempDir(), "passes")}
+	cache := newTestCache(t, conf)
+	if err := cache.Add(testSubject, testHash, passed, time.Now()); err != nil {
+		t.Fatalf("failed to add pass: %v", err)
+	}
+	// simulate a partially written record
+	file, err := os.OpenFile(conf.Path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"digest":"sha256:`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if _, ok := newTestCache(t, conf).Get(testSubject, testHash, time.Time{}); !ok {
+		t.Fatalf("expected the pass to survive a partially written record")
+	}
+}
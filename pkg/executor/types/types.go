@@ -16,15 +16,251 @@ limitations under the License.
 package types
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 
+	"github.com/ratify-project/ratify/pkg/verifier/attestation"
 	"github.com/ratify-project/ratify/pkg/verifier/types"
 )
 
+// DecisionReason is a machine-readable code explaining the overall
+// verification decision. The set of reasons is bounded so that it can be used
+// as a metric label.
+type DecisionReason string
+
+const (
+	// ReasonVerified is set when the subject passed verification.
+	ReasonVerified DecisionReason = "verified"
+	// ReasonSignatureInvalid is set when a signature verifier reported a
+	// failure.
+	ReasonSignatureInvalid DecisionReason = "signature-invalid"
+	// ReasonSBOMRejected is set when an SBOM or license verifier reported a
+	// failure.
+	ReasonSBOMRejected DecisionReason = "sbom-rejected"
+	// ReasonVulnerabilityReportRejected is set when a vulnerability report
+	// verifier reported a failure.
+	ReasonVulnerabilityReportRejected DecisionReason = "vulnerability-report-rejected"
+	// ReasonVerifierFailed is set when any other verifier reported a failure.
+	ReasonVerifierFailed DecisionReason = "verifier-failed"
+	// ReasonPolicyDenied is set when the policy denied the subject although no
+	// verifier reported a failure.
+	ReasonPolicyDenied DecisionReason = "policy-denied"
+	// ReasonNoMatchingVerifier is set when no verifier produced a report for
+	// the subject.
+	ReasonNoMatchingVerifier DecisionReason = "no-matching-verifier"
+	// ReasonInvalidReference is set when the subject reference is malformed.
+	ReasonInvalidReference DecisionReason = "invalid-reference"
+	// ReasonSubjectNotResolved is set when no referrer store could resolve the
+	// subject.
+	ReasonSubjectNotResolved DecisionReason = "subject-not-resolved"
+	// ReasonReferrerStoreError is set when the referrers of the subject could
+	// not be listed.
+	ReasonReferrerStoreError DecisionReason = "referrer-store-error"
+	// ReasonLimitExceeded is set when the verification of the subject exceeded
+	// a depth or breadth guard of the executor.
+	ReasonLimitExceeded DecisionReason = "limit-exceeded"
+	// ReasonRegistryNotAllowed is set when the subject is served from a
+	// registry host the executor does not allow.
+	ReasonRegistryNotAllowed DecisionReason = "registry-not-allowed"
+	// ReasonAllowlisted is set when the subject digest is approved by the
+	// allowlist feed and the subject was allowed without verification.
+	ReasonAllowlisted DecisionReason = "allowlisted"
+	// ReasonTimedOut is set when the policy denied the subject and no
+	// completed verifier reported a failure but a verifier did not complete
+	// within the latency budget.
+	ReasonTimedOut DecisionReason = "timed-out"
+	// ReasonIndexSignatureMissing is set when index signatures are required
+	// and the selected manifest passed verification but the index did not.
+	ReasonIndexSignatureMissing DecisionReason = "index-signature-missing"
+	// ReasonChildSignatureMissing is set when index signatures are required
+	// and the index passed verification but the selected manifest did not.
+	ReasonChildSignatureMissing DecisionReason = "child-signature-missing"
+	// ReasonInternalError is set for any other failure.
+	ReasonInternalError DecisionReason = "internal-error"
+)
+
 // VerifyResult describes the results of verifying a subject
 type VerifyResult struct {
 	IsSuccess       bool          `json:"isSuccess,omitempty"`
 	VerifierReports []interface{} `json:"verifierReports"`
+	Explanation     *Explanation  `json:"explanation,omitempty"`
+	// Reason is the reason code of the overall decision.
+	Reason DecisionReason `json:"reason,omitempty"`
+	// Warnings lists the messages of verifiers that reported a warning level.
+	Warnings []string `json:"warnings,omitempty"`
+	// UnmatchedArtifactTypes lists the artifact types of the referrers no
+	// verifier is routed to, if they are reported by the unknownArtifactType
+	// option.
+	UnmatchedArtifactTypes []string `json:"unmatchedArtifactTypes,omitempty"`
+	// Degraded is set when the subject was allowed without verification
+	// because a dependency of Ratify is unavailable and the failure policy is
+	// open. Reason holds the cause of the failure.
+	Degraded bool `json:"degraded,omitempty"`
+	// Annotations are set by decision post-processors to enrich the report.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Score is the score of the subject if the policy gates on a minimum
+	// score.
+	Score *ScoreReport `json:"score,omitempty"`
+	// ExceededGuard is maxDepth or maxReferrersPerNode if the verification
+	// was stopped by the guard.
+	ExceededGuard string `json:"exceededGuard,omitempty"`
+	// Attestation is the signed in-toto statement of the verification result
+	// if attestations are configured.
+	Attestation *attestation.Envelope `json:"attestation,omitempty"`
+	// EarlyExit is set when the subject was allowed as soon as a verifier
+	// listed by the passOnFirstTrusted option passed.
+	EarlyExit *EarlyExit `json:"earlyExit,omitempty"`
+	// Partial is set when verifiers did not complete within the latency
+	// budget. They are reported with the timeout level and the decision is
+	// made by the policy on the results of the completed verifiers.
+	Partial bool `json:"partial,omitempty"`
+	// IndexResult is the result of the verification of the index the
+	// verified manifest was selected from, if index signatures are required.
+	IndexResult *VerifyResult `json:"indexResult,omitempty"`
+}
+
+// EarlyExit describes a verification stopped once a verifier listed by the
+// passOnFirstTrusted option passed.
+type EarlyExit struct {
+	// Verifier is the name of the verifier that passed.
+	Verifier        string `json:"verifier"`
+	ReferenceDigest string `json:"referenceDigest"`
+	// SkippedReferences is the number of referrers left unverified.
+	SkippedReferences int `json:"skippedReferences"`
+}
+
+// ScoreReport describes the score computed by a scoring policy from the
+// verifier reports of a subject.
+type ScoreReport struct {
+	Score     float64 `json:"score"`
+	Threshold float64 `json:"threshold"`
+	// Breakdown lists the points awarded for every weighted verifier.
+	Breakdown []ScoreContribution `json:"breakdown"`
+}
+
+// ScoreContribution describes the points a weighted verifier contributed to
+// the score.
+type ScoreContribution struct {
+	// Verifier is the name or type of the weighted verifier.
+	Verifier string  `json:"verifier"`
+	Weight   float64 `json:"weight"`
+	Awarded  float64 `json:"awarded"`
+	Reason   string  `json:"reason,omitempty"`
+}
+
+// Subject describes the verified subject as exposed to policies.
+type Subject struct {
+	Reference string `json:"reference"`
+	Digest    string `json:"digest,omitempty"`
+	// ArtifactType is the artifact type of the subject manifest, falling back
+	// to its config media type, e.g. application/vnd.cncf.helm.config.v1+json.
+	ArtifactType string `json:"artifactType,omitempty"`
+	// Annotations are the annotations of the subject manifest, e.g.
+	// org.opencontainers.image.source. Those of the manifest are only fetched
+	// if the policy reads them.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Labels are the labels of the subject image config. They are only
+	// fetched if the policy has verifier conditions.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Request is the context of the admission request the subject is verified
+	// for. It is exposed to policies as input.request rather than as part of
+	// the subject.
+	Request *RequestContext `json:"-"`
+	// Nested is set for the subjects verified as referrers of the subject of
+	// the request. Requirements on the subject of the request, such as its
+	// annotations, do not apply to them.
+	Nested bool `json:"-"`
+}
+
+// RequestContext describes the admission request a subject is verified for,
+// e.g. to apply stricter rules to images deployed to production namespaces.
+// The Gatekeeper external data requests only carry the namespace, the labels
+// and the user are only set by authenticated batch verification clients.
+// Policies should therefore require stricter verification when labels or the
+// user are present rather than relax it when they are missing.
+type RequestContext struct {
+	// Namespace is the namespace of the admitted resource.
+	Namespace string `json:"namespace,omitempty"`
+	// Labels are the labels of the admitted resource.
+	Labels map[string]string `json:"labels,omitempty"`
+	// User is the name of the user requesting the admission.
+	User string `json:"user,omitempty"`
+	// Resource is the admitted resource, on which the events of denied
+	// subjects are recorded.
+	Resource *ResourceReference `json:"resource,omitempty"`
+}
+
+// ResourceReference identifies the resource admitted by a request within the
+// namespace of the request.
+type ResourceReference struct {
+	// APIVersion is the API version of the resource, e.g. apps/v1.
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind is the kind of the resource, e.g. Deployment.
+	Kind string `json:"kind"`
+	// Name is the name of the resource.
+	Name string `json:"name"`
+}
+
+// IsEmpty returns true if the request context carries no information.
+func (r *RequestContext) IsEmpty() bool {
+	return r == nil || (r.Namespace == "" && len(r.Labels) == 0 && r.User == "" && r.Resource == nil)
+}
+
+// Hash returns a digest of the request context so that results derived from
+// it can be cached per request context. It returns an empty string for an
+// empty request context.
+func (r *RequestContext) Hash() string {
+	if r.IsEmpty() {
+		return ""
+	}
+	// the admitted resource only identifies where events are recorded, so
+	// results are shared across the resources of a request context
+	hashed := *r
+	hashed.Resource = nil
+	// json.Marshal sorts the keys of the labels, so the hash is stable.
+	content, err := json.Marshal(hashed)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// Explanation describes how the overall verification result of a subject was
+// derived. It is only populated when explain mode is requested.
+type Explanation struct {
+	IsSuccess     bool                   `json:"isSuccess"`
+	Policy        PolicyDerivation       `json:"policy"`
+	Contributions []VerifierContribution `json:"verifierContributions"`
+}
+
+// PolicyDerivation describes the policy evaluation that produced a decision.
+type PolicyDerivation struct {
+	PolicyType string `json:"policyType"`
+	// Input is the document the policy was evaluated against.
+	Input interface{} `json:"input"`
+	// MatchedRule is the policy rule that drove the decision.
+	MatchedRule string `json:"matchedRule"`
+	// RulePath is the fully qualified path of the evaluated rule, e.g. the
+	// Rego query path.
+	RulePath string `json:"rulePath,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// VerifierContribution describes the outcome of a single verifier that was
+// considered by the policy.
+type VerifierContribution struct {
+	Subject         string `json:"subject,omitempty"`
+	VerifierName    string `json:"verifierName,omitempty"`
+	VerifierType    string `json:"verifierType,omitempty"`
+	ArtifactType    string `json:"artifactType,omitempty"`
+	ReferenceDigest string `json:"referenceDigest,omitempty"`
+	IsSuccess       bool   `json:"isSuccess"`
+	Level           string `json:"level,omitempty"`
+	Message         string `json:"message,omitempty"`
+	// UnmatchedArtifactType is set for the referrers no verifier is
+	// configured for.
+	UnmatchedArtifactType bool `json:"unmatchedArtifactType,omitempty"`
 }
 
 // NestedVerifierReport describes the results of verifying an artifact and its
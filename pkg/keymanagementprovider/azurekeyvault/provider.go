@@ -24,6 +24,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -38,6 +39,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/keymanagementprovider/factory"
 	"github.com/ratify-project/ratify/pkg/metrics"
 	"golang.org/x/crypto/pkcs12"
+	"golang.org/x/sync/errgroup"
 
 	kv "github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
 	"github.com/Azure/go-autorest/autorest/azure"
@@ -47,6 +49,9 @@ const (
 	ProviderName      string = "azurekeyvault"
 	PKCS12ContentType string = "application/x-pkcs12"
 	PEMContentType    string = "application/x-pem-file"
+	// DefaultMaxConcurrency is the default number of certificates fetched from
+	// the key vault concurrently.
+	DefaultMaxConcurrency int = 5
 )
 
 var logOpt = logger.Option{
@@ -61,18 +66,28 @@ type AKVKeyManagementProviderConfig struct {
 	CloudName    string                `json:"cloudName,omitempty"`
 	Certificates []types.KeyVaultValue `json:"certificates,omitempty"`
 	Keys         []types.KeyVaultValue `json:"keys,omitempty"`
+	// MaxConcurrency is the maximum number of certificates fetched from the
+	// key vault concurrently. Defaults to DefaultMaxConcurrency.
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+}
+
+// keyVaultClient fetches secrets and keys from a key vault.
+type keyVaultClient interface {
+	GetSecret(ctx context.Context, vaultBaseURL string, secretName string, secretVersion string) (kv.SecretBundle, error)
+	GetKey(ctx context.Context, vaultBaseURL string, keyName string, keyVersion string) (kv.KeyBundle, error)
 }
 
 type akvKMProvider struct {
-	provider     string
-	vaultURI     string
-	tenantID     string
-	clientID     string
-	cloudName    string
-	certificates []types.KeyVaultValue
-	keys         []types.KeyVaultValue
-	cloudEnv     *azure.Environment
-	kvClient     *kv.BaseClient
+	provider       string
+	vaultURI       string
+	tenantID       string
+	clientID       string
+	cloudName      string
+	certificates   []types.KeyVaultValue
+	keys           []types.KeyVaultValue
+	maxConcurrency int
+	cloudEnv       *azure.Environment
+	kvClient       keyVaultClient
 }
 type akvKMProviderFactory struct{}
 
@@ -103,19 +118,27 @@ func (f *akvKMProviderFactory) Create(_ string, keyManagementProviderConfig conf
 		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("cloudName %s is not valid", conf.CloudName), re.HideStackTrace)
 	}
 
+	if conf.MaxConcurrency < 0 {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, "maxConcurrency must not be negative", re.HideStackTrace)
+	}
+	if conf.MaxConcurrency == 0 {
+		conf.MaxConcurrency = DefaultMaxConcurrency
+	}
+
 	if len(conf.Certificates) == 0 && len(conf.Keys) == 0 {
 		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, "no keyvault certificates or keys configured", re.HideStackTrace)
 	}
 
 	provider := &akvKMProvider{
-		provider:     ProviderName,
-		vaultURI:     strings.TrimSpace(conf.VaultURI),
-		tenantID:     strings.TrimSpace(conf.TenantID),
-		clientID:     strings.TrimSpace(conf.ClientID),
-		cloudName:    strings.TrimSpace(conf.CloudName),
-		certificates: conf.Certificates,
-		keys:         conf.Keys,
-		cloudEnv:     azureCloudEnv,
+		provider:       ProviderName,
+		vaultURI:       strings.TrimSpace(conf.VaultURI),
+		tenantID:       strings.TrimSpace(conf.TenantID),
+		clientID:       strings.TrimSpace(conf.ClientID),
+		cloudName:      strings.TrimSpace(conf.CloudName),
+		certificates:   conf.Certificates,
+		keys:           conf.Keys,
+		maxConcurrency: conf.MaxConcurrency,
+		cloudEnv:       azureCloudEnv,
 	}
 	if err := provider.validate(); err != nil {
 		return nil, err
@@ -134,32 +157,79 @@ func (f *akvKMProviderFactory) Create(_ string, keyManagementProviderConfig conf
 
 // GetCertificates returns an array of certificates based on certificate properties defined in config
 // get certificate retrieve the entire cert chain using getSecret API call
+// The certificates are fetched concurrently, bounded by maxConcurrency. A
+// certificate failing to load is reported in the status and skipped, an error
+// is only returned if no certificate could be loaded.
 func (s *akvKMProvider) GetCertificates(ctx context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+	results := make([]certificateFetchResult, len(s.certificates))
+	eg := errgroup.Group{}
+	eg.SetLimit(s.concurrency())
+	for i, keyVaultCert := range s.certificates {
+		i, keyVaultCert := i, keyVaultCert
+		eg.Go(func() error {
+			results[i] = s.fetchCertificate(ctx, keyVaultCert)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
 	certsMap := map[keymanagementprovider.KMPMapKey][]*x509.Certificate{}
 	certsStatus := []map[string]string{}
-	for _, keyVaultCert := range s.certificates {
-		logger.GetLogger(ctx, logOpt).Debugf("fetching secret from key vault, certName %v,  keyvault %v", keyVaultCert.Name, s.vaultURI)
-
-		// fetch the object from Key Vault
-		// GetSecret is required so we can fetch the entire cert chain. See issue https://github.com/ratify-project/ratify/issues/695 for details
-		startTime := time.Now()
-		secretBundle, err := s.kvClient.GetSecret(ctx, s.vaultURI, keyVaultCert.Name, keyVaultCert.Version)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to get secret objectName:%s, objectVersion:%s, error: %w", keyVaultCert.Name, keyVaultCert.Version, err)
+	var fetchErrs []error
+	for i, result := range results {
+		keyVaultCert := s.certificates[i]
+		if result.err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("skipping certificate %s version %s of keyvault %s: %v", keyVaultCert.Name, keyVaultCert.Version, s.vaultURI, result.err)
+			fetchErrs = append(fetchErrs, result.err)
+			failedProperty := getStatusProperty(keyVaultCert.Name, keyVaultCert.Version, time.Now().Format(time.RFC3339))
+			failedProperty[types.StatusError] = result.err.Error()
+			certsStatus = append(certsStatus, failedProperty)
+			continue
 		}
+		certsStatus = append(certsStatus, result.properties...)
+		certsMap[keymanagementprovider.KMPMapKey{Name: keyVaultCert.Name, Version: keyVaultCert.Version}] = result.certs
+	}
+	if len(s.certificates) > 0 && len(certsMap) == 0 {
+		return nil, nil, errors.Join(fetchErrs...)
+	}
 
-		certResult, certProperty, err := getCertsFromSecretBundle(ctx, secretBundle, keyVaultCert.Name)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to get certificates from secret bundle:%w", err)
-		}
+	return certsMap, getStatusMap(certsStatus, types.CertificatesStatus), nil
+}
+
+// certificateFetchResult is the outcome of fetching a single certificate.
+type certificateFetchResult struct {
+	certs      []*x509.Certificate
+	properties []map[string]string
+	err        error
+}
 
-		metrics.ReportAKVCertificateDuration(ctx, time.Since(startTime).Milliseconds(), keyVaultCert.Name)
-		certsStatus = append(certsStatus, certProperty...)
-		certMapKey := keymanagementprovider.KMPMapKey{Name: keyVaultCert.Name, Version: keyVaultCert.Version}
-		certsMap[certMapKey] = certResult
+// fetchCertificate fetches the certificate chain of a single certificate.
+func (s *akvKMProvider) fetchCertificate(ctx context.Context, keyVaultCert types.KeyVaultValue) certificateFetchResult {
+	logger.GetLogger(ctx, logOpt).Debugf("fetching secret from key vault, certName %v,  keyvault %v", keyVaultCert.Name, s.vaultURI)
+
+	// fetch the object from Key Vault
+	// GetSecret is required so we can fetch the entire cert chain. See issue https://github.com/ratify-project/ratify/issues/695 for details
+	startTime := time.Now()
+	secretBundle, err := s.kvClient.GetSecret(ctx, s.vaultURI, keyVaultCert.Name, keyVaultCert.Version)
+	if err != nil {
+		return certificateFetchResult{err: fmt.Errorf("failed to get secret objectName:%s, objectVersion:%s, error: %w", keyVaultCert.Name, keyVaultCert.Version, err)}
 	}
 
-	return certsMap, getStatusMap(certsStatus, types.CertificatesStatus), nil
+	certResult, certProperty, err := getCertsFromSecretBundle(ctx, secretBundle, keyVaultCert.Name)
+	if err != nil {
+		return certificateFetchResult{err: fmt.Errorf("failed to get certificates from secret bundle:%w", err)}
+	}
+
+	metrics.ReportAKVCertificateDuration(ctx, time.Since(startTime).Milliseconds(), keyVaultCert.Name)
+	return certificateFetchResult{certs: certResult, properties: certProperty}
+}
+
+// concurrency returns the number of certificates fetched concurrently.
+func (s *akvKMProvider) concurrency() int {
+	if s.maxConcurrency <= 0 {
+		return DefaultMaxConcurrency
+	}
+	return s.maxConcurrency
 }
 
 // GetKeys returns an array of keys based on key properties defined in config
@@ -20,13 +20,23 @@ package azurekeyvault
 import (
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	kv "github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/ratify-project/ratify/internal/version"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
 	"github.com/ratify-project/ratify/pkg/keymanagementprovider/azurekeyvault/types"
 	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
 	"github.com/stretchr/testify/assert"
@@ -94,6 +104,21 @@ func TestCreate(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "negative maxConcurrency",
+			config: config.KeyManagementProviderConfig{
+				"vaultURI":       "https://testkv.vault.azure.net/",
+				"tenantID":       "tid",
+				"clientID":       "clientid",
+				"maxConcurrency": -1,
+				"certificates": []map[string]interface{}{
+					{
+						"name": "cert1",
+					},
+				},
+			},
+			expectErr: true,
+		},
 		{
 			name:      "keyvault uri not provided",
 			config:    config.KeyManagementProviderConfig{},
@@ -239,6 +264,121 @@ func TestGetKeys(t *testing.T) {
 	assert.Nil(t, keyStatus)
 }
 
+// fakeKeyVaultClient serves PEM certificates from memory and tracks the
+// number of concurrent fetches.
+type fakeKeyVaultClient struct {
+	certPEM  string
+	failing  map[string]bool
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (c *fakeKeyVaultClient) GetSecret(_ context.Context, vaultBaseURL, secretName, secretVersion string) (kv.SecretBundle, error) {
+	inFlight := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if inFlight <= peak || c.peak.CompareAndSwap(peak, inFlight) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	if c.failing[secretName] {
+		return kv.SecretBundle{}, fmt.Errorf("secret %s not found", secretName)
+	}
+	contentType := PEMContentType
+	id := fmt.Sprintf("%s/secrets/%s/%s", vaultBaseURL, secretName, secretVersion)
+	return kv.SecretBundle{Value: &c.certPEM, ContentType: &contentType, ID: &id}, nil
+}
+
+func (c *fakeKeyVaultClient) GetKey(_ context.Context, _, keyName, _ string) (kv.KeyBundle, error) {
+	return kv.KeyBundle{}, fmt.Errorf("key %s not found", keyName)
+}
+
+func newTestCertPEM(t *testing.T) string {
+	t.Helper()
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ratify.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, privKey.Public(), privKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// TestGetCertificates_Concurrent tests that the certificates are fetched with
+// bounded concurrency and that a failing certificate does not fail the others
+func TestGetCertificates_Concurrent(t *testing.T) {
+	const certCount = 20
+	const maxConcurrency = 4
+	client := &fakeKeyVaultClient{
+		certPEM: newTestCertPEM(t),
+		failing: map[string]bool{"cert7": true},
+	}
+	certificates := make([]types.KeyVaultValue, 0, certCount)
+	for i := 0; i < certCount; i++ {
+		certificates = append(certificates, types.KeyVaultValue{Name: fmt.Sprintf("cert%d", i), Version: "v1"})
+	}
+	provider := &akvKMProvider{
+		provider:       ProviderName,
+		vaultURI:       "https://testkv.vault.azure.net",
+		certificates:   certificates,
+		maxConcurrency: maxConcurrency,
+		kvClient:       client,
+	}
+
+	certs, certStatus, err := provider.GetCertificates(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(certs) != certCount-1 {
+		t.Fatalf("expected %d certificates, got %d", certCount-1, len(certs))
+	}
+	if _, ok := certs[keymanagementprovider.KMPMapKey{Name: "cert7", Version: "v1"}]; ok {
+		t.Fatalf("expected the failing certificate to be skipped")
+	}
+	if peak := client.peak.Load(); peak > maxConcurrency || peak < 2 {
+		t.Fatalf("expected between 2 and %d concurrent fetches, got %d", maxConcurrency, peak)
+	}
+	statuses := certStatus[types.CertificatesStatus].([]map[string]string)
+	if len(statuses) != certCount {
+		t.Fatalf("expected %d certificate statuses, got %d", certCount, len(statuses))
+	}
+	// the statuses keep the order of the configured certificates
+	if statuses[7][types.StatusName] != "cert7" || !strings.Contains(statuses[7][types.StatusError], "secret cert7 not found") {
+		t.Fatalf("expected the error of cert7 in its status, got %+v", statuses[7])
+	}
+	if statuses[8][types.StatusName] != "cert8" || statuses[8][types.StatusError] != "" {
+		t.Fatalf("expected the status of cert8 without error, got %+v", statuses[8])
+	}
+}
+
+// TestGetCertificates_AllFailing tests that an error is returned if no
+// certificate could be loaded
+func TestGetCertificates_AllFailing(t *testing.T) {
+	provider := &akvKMProvider{
+		provider:     ProviderName,
+		vaultURI:     "https://testkv.vault.azure.net",
+		certificates: []types.KeyVaultValue{{Name: "cert1"}, {Name: "cert2"}},
+		kvClient:     &fakeKeyVaultClient{failing: map[string]bool{"cert1": true, "cert2": true}},
+	}
+
+	certs, certStatus, err := provider.GetCertificates(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "secret cert1 not found") || !strings.Contains(err.Error(), "secret cert2 not found") {
+		t.Fatalf("expected the errors of both certificates, got %v", err)
+	}
+	assert.Nil(t, certs)
+	assert.Nil(t, certStatus)
+}
+
 func TestIsRefreshable(t *testing.T) {
 	factory := &akvKMProviderFactory{}
 	config := config.KeyManagementProviderConfig{
@@ -27,6 +27,8 @@ const (
 	StatusVersion = "Version"
 	// Last refreshed string for the certificate status property
 	StatusLastRefreshed = "LastRefreshed"
+	// Error string for the status property of a certificate that failed to load
+	StatusError = "Error"
 )
 
 // KeyVaultValue holds keyvault certificate/key related config
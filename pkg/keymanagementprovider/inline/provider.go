@@ -35,6 +35,7 @@ const (
 	certificateContentType string = "certificate"
 	certificatesMapKey     string = "certs"
 	keyContentType         string = "key"
+	pgpKeyringContentType  string = "pgpKeyring"
 )
 
 //nolint:revive
@@ -98,6 +99,11 @@ func (f *inlineKMProviderFactory) Create(_ string, keyManagementProviderConfig c
 		keyMap = map[keymanagementprovider.KMPMapKey]crypto.PublicKey{
 			{}: key,
 		}
+	case pgpKeyringContentType:
+		keyMap, err = keymanagementprovider.DecodePGPKeyring([]byte(conf.Value))
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.ErrorCodeConfigInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("content type %s is not supported", conf.ContentType))
 	}
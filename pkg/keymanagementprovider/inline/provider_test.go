@@ -63,6 +63,15 @@ func TestCreate(t *testing.T) {
 			},
 			expectedErr: true,
 		},
+		{
+			desc: "invalid pgp keyring",
+			config: config.KeyManagementProviderConfig{
+				"type":        "inline",
+				"contentType": "pgpKeyring",
+				"value":       "-----BEGIN PGP PUBLIC KEY BLOCK-----\nbaddata\n-----END PGP PUBLIC KEY BLOCK-----\n",
+			},
+			expectedErr: true,
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
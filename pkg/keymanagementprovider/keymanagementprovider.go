@@ -16,14 +16,20 @@ limitations under the License.
 package keymanagementprovider
 
 import (
+	"bytes"
 	"context"
 	"crypto"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/constants"
 	ctxUtils "github.com/ratify-project/ratify/internal/context"
@@ -58,6 +64,27 @@ type KeyManagementProvider interface {
 	IsRefreshable() bool
 }
 
+// SigningKeyManagementProvider is implemented by key management providers
+// holding private keys, e.g. the key signing the verification attestations of
+// Ratify.
+type SigningKeyManagementProvider interface {
+	// Returns the signers of the private keys held by the provider
+	GetSigners(ctx context.Context) (map[KMPMapKey]crypto.Signer, error)
+}
+
+// Closer is implemented by key management providers holding resources, e.g.
+// watches, that must be released once the provider is replaced or deleted.
+type Closer interface {
+	// Close releases the resources held by the provider
+	Close()
+}
+
+// static concurrency-safe map to store the current provider of each resource
+// layout:
+//
+//	map["<namespace>/<name>"] = KeyManagementProvider
+var providerMap sync.Map
+
 // static concurrency-safe map to store certificates fetched from key management provider
 // layout:
 //
@@ -74,6 +101,12 @@ var certificatesMap sync.Map
 //	 where PublicKey is a struct containing the public key and the provider type
 var keyMap sync.Map
 
+// static concurrency-safe map to store signers fetched from key management provider
+// layout:
+//
+//	map["<namespace>/<name>"] = map[KMPMapKey]crypto.Signer
+var signerMap sync.Map
+
 // static concurrency-safe map to store errors while fetching certificates from key management provider.
 // layout:
 //
@@ -86,6 +119,18 @@ var certificateErrMap sync.Map
 //	map["<namespace>/<name>"] = error
 var keyErrMap sync.Map
 
+// static concurrency-safe map to store the fingerprints of the certificates
+// and keys of each resource, used to version the trust material.
+// layout:
+//
+//	map["certificates/<namespace>/<name>"] = fingerprint
+//	map["keys/<namespace>/<name>"] = fingerprint
+var trustMaterialFingerprints sync.Map
+
+// trustMaterialVersion is incremented whenever the certificates or keys of a
+// resource change.
+var trustMaterialVersion atomic.Uint64
+
 // DecodeCertificates decodes PEM-encoded bytes into an x509.Certificate chain.
 func DecodeCertificates(value []byte) ([]*x509.Certificate, error) {
 	var certs []*x509.Certificate
@@ -126,10 +171,44 @@ func DecodeKey(value []byte) (crypto.PublicKey, error) {
 	return pk, nil
 }
 
+// DecodePrivateKey takes in a PEM encoded unencrypted private key and returns
+// its signer.
+func DecodePrivateKey(value []byte) (crypto.Signer, error) {
+	pk, err := cryptoutils.UnmarshalPEMToPrivateKey(value, cryptoutils.SkipPassword)
+	if err != nil {
+		return nil, errors.ErrorCodeKeyInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail("error parsing private key").WithError(err)
+	}
+	signer, ok := pk.(crypto.Signer)
+	if !ok {
+		return nil, errors.ErrorCodeKeyInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("private key of type %T cannot sign", pk))
+	}
+	return signer, nil
+}
+
+// DecodePGPKeyring takes in an ASCII armored PGP public keyring and returns
+// its entities keyed by their uppercase hex fingerprint, e.g. the keyring
+// trusted to sign Helm chart provenance files.
+func DecodePGPKeyring(value []byte) (map[KMPMapKey]crypto.PublicKey, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(value))
+	if err != nil {
+		return nil, errors.ErrorCodeKeyInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail("error parsing PGP keyring").WithError(err)
+	}
+	if len(entities) == 0 {
+		return nil, errors.ErrorCodeKeyInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail("no keys found in the PGP keyring")
+	}
+
+	keys := make(map[KMPMapKey]crypto.PublicKey, len(entities))
+	for _, entity := range entities {
+		keys[KMPMapKey{Name: fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)}] = entity
+	}
+	return keys, nil
+}
+
 // setCertificatesInMap sets the certificates in the map
 // it is concurrency-safe
 func setCertificatesInMap(resource string, certs map[KMPMapKey][]*x509.Certificate) {
 	certificatesMap.Store(resource, certs)
+	updateTrustMaterial("certificates/"+resource, certificatesFingerprint(certs))
 	certificateErrMap.Delete(resource)
 }
 
@@ -147,13 +226,93 @@ func GetCertificatesFromMap(ctx context.Context, resource string) (map[KMPMapKey
 	return map[KMPMapKey][]*x509.Certificate{}, errors.ErrorCodeNotFound.WithDetail(fmt.Sprintf("The key management provider [%s] does not exist", resource)).WithRemediation(fmt.Sprintf("Make sure the key management provider: %s is created in the namespace: [%s] or as a cluster-wide resource.", resource, ctxUtils.GetNamespace(ctx)))
 }
 
-// DeleteResourceFromMap deletes the certificates, keys and errors from the map
-// it is concurrency-safe
+// SetProvider records the provider of the given resource and closes the
+// provider it replaces, if that provider implements Closer.
+func SetProvider(resource string, provider KeyManagementProvider) {
+	previous, ok := providerMap.Swap(resource, provider)
+	if ok && previous != provider {
+		closeProvider(previous)
+	}
+}
+
+// closeProvider closes the provider if it implements Closer.
+func closeProvider(provider any) {
+	if closer, ok := provider.(Closer); ok {
+		closer.Close()
+	}
+}
+
+// DeleteResourceFromMap deletes the provider, certificates, keys and errors from the map
+// and closes the provider. It is concurrency-safe
 func DeleteResourceFromMap(resource string) {
+	if provider, ok := providerMap.LoadAndDelete(resource); ok {
+		closeProvider(provider)
+	}
 	certificatesMap.Delete(resource)
 	keyMap.Delete(resource)
+	signerMap.Delete(resource)
 	certificateErrMap.Delete(resource)
 	keyErrMap.Delete(resource)
+	for _, key := range []string{"certificates/" + resource, "keys/" + resource} {
+		if _, ok := trustMaterialFingerprints.LoadAndDelete(key); ok {
+			trustMaterialVersion.Add(1)
+		}
+	}
+}
+
+// TrustMaterialVersion returns the version of the certificates and keys of all
+// resources. The version changes whenever the certificates or keys of a
+// resource change, so that results verified against previous trust material
+// are not reused.
+func TrustMaterialVersion() uint64 {
+	return trustMaterialVersion.Load()
+}
+
+// updateTrustMaterial records the fingerprint of the trust material under the
+// key and increments the version if it changed. Trust material that cannot be
+// fingerprinted is always considered changed.
+func updateTrustMaterial(key, fingerprint string) {
+	previous, ok := trustMaterialFingerprints.Swap(key, fingerprint)
+	if !ok || fingerprint == "" || previous != fingerprint {
+		trustMaterialVersion.Add(1)
+	}
+}
+
+// certificatesFingerprint returns the hash of the certificates.
+func certificatesFingerprint(certs map[KMPMapKey][]*x509.Certificate) string {
+	entries := make([]string, 0, len(certs))
+	for key, chain := range certs {
+		hash := sha256.New()
+		fmt.Fprintf(hash, "%s\t%s", key.Name, key.Version)
+		for _, cert := range chain {
+			hash.Write(cert.Raw)
+		}
+		entries = append(entries, hex.EncodeToString(hash.Sum(nil)))
+	}
+	return fingerprint(entries)
+}
+
+// keysFingerprint returns the hash of the keys, empty if a key cannot be
+// marshaled.
+func keysFingerprint(keys map[KMPMapKey]crypto.PublicKey) string {
+	entries := make([]string, 0, len(keys))
+	for key, value := range keys {
+		der, err := x509.MarshalPKIXPublicKey(value)
+		if err != nil {
+			return ""
+		}
+		hash := sha256.New()
+		fmt.Fprintf(hash, "%s\t%s", key.Name, key.Version)
+		hash.Write(der)
+		entries = append(entries, hex.EncodeToString(hash.Sum(nil)))
+	}
+	return fingerprint(entries)
+}
+
+func fingerprint(entries []string) string {
+	sort.Strings(entries)
+	hash := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return hex.EncodeToString(hash[:])
 }
 
 // FlattenKMPMap flattens the map of certificates fetched for a single key management provider resource and returns a single array
@@ -173,6 +332,7 @@ func setKeysInMap(resource string, providerType string, keys map[KMPMapKey]crypt
 	}
 	keyMap.Store(resource, typedMap)
 	keyErrMap.Delete(resource)
+	updateTrustMaterial("keys/"+resource, keysFingerprint(keys))
 }
 
 // SaveSecrets saves the keys and certificates in the map.
@@ -197,6 +357,26 @@ func GetKeysFromMap(ctx context.Context, resource string) (map[KMPMapKey]PublicK
 	return map[KMPMapKey]PublicKey{}, errors.ErrorCodeNotFound.WithDetail(fmt.Sprintf("The key management provider [%s] does not exist", resource)).WithRemediation(fmt.Sprintf("Make sure the key management provider: %s is created in the namespace: [%s] or as a cluster-wide resource.", resource, ctxUtils.GetNamespace(ctx)))
 }
 
+// SaveSigners saves the signers in the map.
+func SaveSigners(resource string, signers map[KMPMapKey]crypto.Signer) {
+	signerMap.Store(resource, signers)
+}
+
+// GetSignersFromMap gets the signers from the map and returns an empty map if
+// not found.
+func GetSignersFromMap(ctx context.Context, resource string) (map[KMPMapKey]crypto.Signer, error) {
+	if !hasAccessToProvider(ctx, resource) {
+		return map[KMPMapKey]crypto.Signer{}, errors.ErrorCodeForbidden.WithDetail(fmt.Sprintf("The resources in namespace [%s] do not have access to key management provider [%s]", ctxUtils.GetNamespace(ctx), resource)).WithRemediation(fmt.Sprintf("Make sure the key management provider [%s] is created in the namespace [%s] or as a cluster-wide resource.", resource, ctxUtils.GetNamespace(ctx)))
+	}
+	if err, ok := keyErrMap.Load(resource); ok && err != nil {
+		return map[KMPMapKey]crypto.Signer{}, err.(error)
+	}
+	if signers, ok := signerMap.Load(resource); ok {
+		return signers.(map[KMPMapKey]crypto.Signer), nil
+	}
+	return map[KMPMapKey]crypto.Signer{}, errors.ErrorCodeNotFound.WithDetail(fmt.Sprintf("The key management provider [%s] does not hold a private key", resource)).WithRemediation(fmt.Sprintf("Make sure the key management provider: %s holds a private key and is created in the namespace: [%s] or as a cluster-wide resource.", resource, ctxUtils.GetNamespace(ctx)))
+}
+
 // SetCertificateError sets the error while fetching certificates from key management provider.
 func SetCertificateError(resource string, err error) {
 	certificateErrMap.Store(resource, err)
@@ -16,13 +16,18 @@ limitations under the License.
 package keymanagementprovider
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/rsa"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	ratifyerrors "github.com/ratify-project/ratify/errors"
 	ctxUtils "github.com/ratify-project/ratify/internal/context"
 	"github.com/stretchr/testify/assert"
@@ -216,6 +221,29 @@ func TestSaveSecrets(t *testing.T) {
 	DeleteResourceFromMap("test")
 }
 
+// TestTrustMaterialVersion checks that the version changes only when the
+// certificates of a resource change
+func TestTrustMaterialVersion(t *testing.T) {
+	DeleteResourceFromMap("versioned")
+	defer DeleteResourceFromMap("versioned")
+
+	setCertificatesInMap("versioned", map[KMPMapKey][]*x509.Certificate{{Name: "cert", Version: "1"}: {{Raw: []byte("cert-v1")}}})
+	version := TrustMaterialVersion()
+	setCertificatesInMap("versioned", map[KMPMapKey][]*x509.Certificate{{Name: "cert", Version: "1"}: {{Raw: []byte("cert-v1")}}})
+	if TrustMaterialVersion() != version {
+		t.Fatalf("expected unchanged certificates to keep the version")
+	}
+	setCertificatesInMap("versioned", map[KMPMapKey][]*x509.Certificate{{Name: "cert", Version: "2"}: {{Raw: []byte("cert-v2")}}})
+	if TrustMaterialVersion() == version {
+		t.Fatalf("expected changed certificates to change the version")
+	}
+	version = TrustMaterialVersion()
+	DeleteResourceFromMap("versioned")
+	if TrustMaterialVersion() == version {
+		t.Fatalf("expected deleted certificates to change the version")
+	}
+}
+
 // TestGetKeysFromMap checks if keys are fetched from the map
 func TestGetKeysFromMap(t *testing.T) {
 	DeleteResourceFromMap("test")
@@ -289,3 +317,36 @@ OSeWVmPjy6J5x8+6OIpmTs8PKQB1vTF0gErwa1gS/QaOElLaxDKy0GS9Jg==
 		})
 	}
 }
+
+// TestDecodePGPKeyring checks if the entities are decoded from an armored keyring
+func TestDecodePGPKeyring(t *testing.T) {
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("failed to armor key: %v", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("failed to serialize key: %v", err)
+	}
+	w.Close()
+
+	keys, err := DecodePGPKeyring(buf.Bytes())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	key, ok := keys[KMPMapKey{Name: fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)}]
+	if !ok {
+		t.Fatalf("expected key keyed by fingerprint, got %v", keys)
+	}
+	if _, ok := key.(*openpgp.Entity); !ok {
+		t.Fatalf("expected PGP entity, got %T", key)
+	}
+
+	if _, err := DecodePGPKeyring([]byte("foo")); err == nil {
+		t.Fatalf("expected error for invalid keyring")
+	}
+}
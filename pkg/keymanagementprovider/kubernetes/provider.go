@@ -0,0 +1,270 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/factory"
+	"github.com/ratify-project/ratify/pkg/utils"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	providerName           string = "kubernetes"
+	certificateContentType string = "certificate"
+	keyContentType         string = "key"
+	privateKeyContentType  string = "privateKey"
+	configMapKind          string = "ConfigMap"
+	secretKind             string = "Secret"
+)
+
+// KubernetesKMProviderConfig describes the ConfigMap or Secret that holds the
+// PEM encoded certificates or keys.
+//
+//nolint:revive
+type KubernetesKMProviderConfig struct {
+	Type string `json:"type"`
+	// Kind is the kind of the resource, either ConfigMap or Secret.
+	Kind string `json:"kind"`
+	// Name is the name of the resource.
+	Name string `json:"name"`
+	// Namespace is the namespace of the resource. Resources are only read from
+	// the namespace Ratify is running in, which Ratify is granted access to, so
+	// Namespace must be that namespace if it is set.
+	Namespace string `json:"namespace,omitempty"`
+	// Keys are the data keys to read from the resource. All keys are read if
+	// not provided.
+	Keys []string `json:"keys,omitempty"`
+	// ContentType is either 'certificate', 'key' or 'privateKey'. Defaults to
+	// 'certificate'. Private keys, e.g. the key signing the verification
+	// attestations, are only read from a Secret.
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type kubernetesKMProvider struct {
+	watcher     *resourceWatcher
+	keys        []string
+	contentType string
+	closeOnce   sync.Once
+}
+
+type kubernetesKMProviderFactory struct{}
+
+// newClientset creates the clientset used to watch resources. It is a variable
+// so that tests can replace it with a fake clientset.
+var newClientset = func() (k8s.Interface, error) {
+	clusterConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return k8s.NewForConfig(clusterConfig)
+}
+
+// init calls to register the provider
+func init() {
+	factory.Register(providerName, &kubernetesKMProviderFactory{})
+}
+
+// Create creates a new instance of the kubernetes key management provider.
+// The referenced ConfigMap or Secret is watched by an informer so that changes
+// to the resource are picked up without polling the API server.
+func (f *kubernetesKMProviderFactory) Create(_ string, keyManagementProviderConfig config.KeyManagementProviderConfig, _ string) (keymanagementprovider.KeyManagementProvider, error) {
+	conf := KubernetesKMProviderConfig{}
+
+	keyManagementProviderConfigBytes, err := json.Marshal(keyManagementProviderConfig)
+	if err != nil {
+		return nil, errors.ErrorCodeConfigInvalid.WithError(err).WithComponentType(errors.KeyManagementProvider)
+	}
+
+	if err := json.Unmarshal(keyManagementProviderConfigBytes, &conf); err != nil {
+		return nil, errors.ErrorCodeConfigInvalid.NewError(errors.KeyManagementProvider, "", errors.EmptyLink, err, "failed to parse kubernetes key management provider configuration", errors.HideStackTrace)
+	}
+
+	if conf.Kind != configMapKind && conf.Kind != secretKind {
+		return nil, errors.ErrorCodeConfigInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("kind %q is not supported, must be %s or %s", conf.Kind, configMapKind, secretKind))
+	}
+
+	if conf.Name == "" {
+		return nil, errors.ErrorCodeConfigInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail("name parameter is not set")
+	}
+
+	if conf.ContentType == "" {
+		conf.ContentType = certificateContentType
+	}
+	if conf.ContentType != certificateContentType && conf.ContentType != keyContentType && conf.ContentType != privateKeyContentType {
+		return nil, errors.ErrorCodeConfigInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("content type %s is not supported", conf.ContentType))
+	}
+	if conf.ContentType == privateKeyContentType && conf.Kind != secretKind {
+		return nil, errors.ErrorCodeConfigInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("content type %s requires kind %s", privateKeyContentType, secretKind)).WithRemediation("Store the private key in a Secret instead of a ConfigMap.")
+	}
+
+	ratifyNamespace := os.Getenv(utils.RatifyNamespaceEnvVar)
+	if ratifyNamespace == "" {
+		return nil, errors.ErrorCodeEnvNotSet.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("environment variable %s is not set", utils.RatifyNamespaceEnvVar))
+	}
+	if conf.Namespace != "" && conf.Namespace != ratifyNamespace {
+		return nil, errors.ErrorCodeConfigInvalid.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("namespace %s is not supported, resources are only read from the namespace %s Ratify is running in", conf.Namespace, ratifyNamespace))
+	}
+	conf.Namespace = ratifyNamespace
+
+	watcher, err := getWatcher(conf.Kind, conf.Namespace, conf.Name)
+	if err != nil {
+		return nil, errors.ErrorCodeKeyManagementProviderFailure.WithError(err).WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("failed to watch %s %s/%s", conf.Kind, conf.Namespace, conf.Name))
+	}
+
+	return &kubernetesKMProvider{watcher: watcher, keys: conf.Keys, contentType: conf.ContentType}, nil
+}
+
+// GetCertificates returns the certificates currently held by the watched resource
+func (p *kubernetesKMProvider) GetCertificates(_ context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+	if p.contentType != certificateContentType {
+		return nil, nil, nil
+	}
+	data, status, err := p.getData()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certsMap := make(map[keymanagementprovider.KMPMapKey][]*x509.Certificate, len(data))
+	for key, value := range data {
+		certs, err := keymanagementprovider.DecodeCertificates(value)
+		if err != nil {
+			return nil, nil, errors.ErrorCodeCertInvalid.WithError(err).WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("failed to decode certificates from key %s of %s", key, p.watcher.resource()))
+		}
+		certsMap[keymanagementprovider.KMPMapKey{Name: key, Version: status[resourceVersionStatus].(string)}] = certs
+	}
+	return certsMap, status, nil
+}
+
+// GetKeys returns the public keys currently held by the watched resource, or
+// the public keys of its private keys
+func (p *kubernetesKMProvider) GetKeys(_ context.Context) (map[keymanagementprovider.KMPMapKey]crypto.PublicKey, keymanagementprovider.KeyManagementProviderStatus, error) {
+	if p.contentType == privateKeyContentType {
+		signers, status, err := p.getSigners()
+		if err != nil {
+			return nil, nil, err
+		}
+		keysMap := make(map[keymanagementprovider.KMPMapKey]crypto.PublicKey, len(signers))
+		for key, signer := range signers {
+			keysMap[key] = signer.Public()
+		}
+		return keysMap, status, nil
+	}
+	if p.contentType != keyContentType {
+		return nil, nil, nil
+	}
+	data, status, err := p.getData()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keysMap := make(map[keymanagementprovider.KMPMapKey]crypto.PublicKey, len(data))
+	for key, value := range data {
+		publicKey, err := keymanagementprovider.DecodeKey(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		keysMap[keymanagementprovider.KMPMapKey{Name: key, Version: status[resourceVersionStatus].(string)}] = publicKey
+	}
+	return keysMap, status, nil
+}
+
+// GetSigners returns the signers of the private keys currently held by the
+// watched Secret
+func (p *kubernetesKMProvider) GetSigners(_ context.Context) (map[keymanagementprovider.KMPMapKey]crypto.Signer, error) {
+	if p.contentType != privateKeyContentType {
+		return nil, nil
+	}
+	signers, _, err := p.getSigners()
+	return signers, err
+}
+
+// Close releases the watcher of the provider. The watcher is stopped once no
+// provider uses it anymore.
+func (p *kubernetesKMProvider) Close() {
+	p.closeOnce.Do(func() {
+		releaseWatcher(p.watcher)
+	})
+}
+
+// IsRefreshable returns true since the watched resource may change at any
+// time. Refreshing only reads the informer cache.
+func (p *kubernetesKMProvider) IsRefreshable() bool {
+	return true
+}
+
+// getSigners decodes the private keys of the watched Secret.
+func (p *kubernetesKMProvider) getSigners() (map[keymanagementprovider.KMPMapKey]crypto.Signer, keymanagementprovider.KeyManagementProviderStatus, error) {
+	data, status, err := p.getData()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signers := make(map[keymanagementprovider.KMPMapKey]crypto.Signer, len(data))
+	for key, value := range data {
+		signer, err := keymanagementprovider.DecodePrivateKey(value)
+		if err != nil {
+			return nil, nil, errors.ErrorCodeKeyManagementProviderFailure.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("failed to decode the private key from key %s of %s: %v", key, p.watcher.resource(), err))
+		}
+		signers[keymanagementprovider.KMPMapKey{Name: key, Version: status[resourceVersionStatus].(string)}] = signer
+	}
+	return signers, status, nil
+}
+
+// getData returns the configured keys of the watched resource along with the
+// provider status.
+func (p *kubernetesKMProvider) getData() (map[string][]byte, keymanagementprovider.KeyManagementProviderStatus, error) {
+	snapshot, err := p.watcher.get()
+	if err != nil {
+		return nil, nil, errors.ErrorCodeKeyManagementProviderFailure.WithError(err).WithComponentType(errors.KeyManagementProvider)
+	}
+
+	keys := p.keys
+	if len(keys) == 0 {
+		for key := range snapshot.data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+
+	data := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		value, ok := snapshot.data[key]
+		if !ok {
+			return nil, nil, errors.ErrorCodeKeyManagementProviderFailure.WithComponentType(errors.KeyManagementProvider).WithDetail(fmt.Sprintf("key %s not found in %s", key, p.watcher.resource()))
+		}
+		data[key] = value
+	}
+
+	status := keymanagementprovider.KeyManagementProviderStatus{
+		resourceStatus:        p.watcher.resource(),
+		resourceVersionStatus: snapshot.resourceVersion,
+		keysStatus:            keys,
+	}
+	return data, status, nil
+}
@@ -0,0 +1,447 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+	"github.com/ratify-project/ratify/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testNamespace = "gatekeeper-system"
+	testName      = "trust-material"
+)
+
+// useFakeClientset replaces the clientset with a fake one holding the given
+// objects, runs Ratify in the test namespace and resets the watchers.
+func useFakeClientset(t *testing.T, objects ...runtime.Object) *fake.Clientset {
+	t.Helper()
+	t.Setenv(utils.RatifyNamespaceEnvVar, testNamespace)
+	clientset := fake.NewSimpleClientset(objects...)
+	oldNewClientset := newClientset
+	newClientset = func() (k8s.Interface, error) {
+		return clientset, nil
+	}
+	t.Cleanup(func() {
+		newClientset = oldNewClientset
+		watchersMu.Lock()
+		defer watchersMu.Unlock()
+		for key, watcher := range watchers {
+			close(watcher.stopCh)
+			delete(watchers, key)
+		}
+	})
+	return clientset
+}
+
+func generateCertPEM(t *testing.T, commonName string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func generateKeyPEM(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		desc        string
+		config      config.KeyManagementProviderConfig
+		namespace   string
+		expectedErr bool
+	}{
+		{
+			desc: "unsupported kind",
+			config: config.KeyManagementProviderConfig{
+				"type": providerName,
+				"kind": "Pod",
+				"name": testName,
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "name not provided",
+			config: config.KeyManagementProviderConfig{
+				"type": providerName,
+				"kind": configMapKind,
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "unsupported contentType",
+			config: config.KeyManagementProviderConfig{
+				"type":        providerName,
+				"kind":        configMapKind,
+				"name":        testName,
+				"contentType": "unsupported",
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "private key in a ConfigMap",
+			config: config.KeyManagementProviderConfig{
+				"type":        providerName,
+				"kind":        configMapKind,
+				"name":        testName,
+				"contentType": privateKeyContentType,
+			},
+			namespace:   testNamespace,
+			expectedErr: true,
+		},
+		{
+			desc: "namespace other than the Ratify namespace",
+			config: config.KeyManagementProviderConfig{
+				"type":      providerName,
+				"kind":      configMapKind,
+				"name":      testName,
+				"namespace": "other",
+			},
+			namespace:   testNamespace,
+			expectedErr: true,
+		},
+		{
+			desc: "namespace not set in environment",
+			config: config.KeyManagementProviderConfig{
+				"type": providerName,
+				"kind": configMapKind,
+				"name": testName,
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "namespace from environment",
+			config: config.KeyManagementProviderConfig{
+				"type": providerName,
+				"kind": configMapKind,
+				"name": testName,
+			},
+			namespace: testNamespace,
+		},
+		{
+			desc: "valid secret",
+			config: config.KeyManagementProviderConfig{
+				"type":      providerName,
+				"kind":      secretKind,
+				"name":      testName,
+				"namespace": testNamespace,
+			},
+			namespace: testNamespace,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			useFakeClientset(t)
+			t.Setenv(utils.RatifyNamespaceEnvVar, tc.namespace)
+			factory := &kubernetesKMProviderFactory{}
+			_, err := factory.Create("v1.0", tc.config, "")
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestGetCertificates_ConfigMap(t *testing.T) {
+	cases := []struct {
+		desc          string
+		keys          []string
+		expectedKeys  []string
+		expectedCerts int
+		expectedErr   bool
+	}{
+		{
+			desc:          "all keys",
+			expectedKeys:  []string{"ca.crt", "root.crt"},
+			expectedCerts: 2,
+		},
+		{
+			desc:          "selected key",
+			keys:          []string{"root.crt"},
+			expectedKeys:  []string{"root.crt"},
+			expectedCerts: 1,
+		},
+		{
+			desc:        "missing key",
+			keys:        []string{"missing.crt"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			useFakeClientset(t, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: testName, Namespace: testNamespace, ResourceVersion: "1"},
+				Data: map[string]string{
+					"ca.crt": generateCertPEM(t, "ca"),
+				},
+				BinaryData: map[string][]byte{
+					"root.crt": []byte(generateCertPEM(t, "root")),
+				},
+			})
+			providerConfig := config.KeyManagementProviderConfig{
+				"type":      providerName,
+				"kind":      configMapKind,
+				"name":      testName,
+				"namespace": testNamespace,
+			}
+			if tc.keys != nil {
+				providerConfig["keys"] = tc.keys
+			}
+			provider, err := (&kubernetesKMProviderFactory{}).Create("v1.0", providerConfig, "")
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+
+			certs, status, err := provider.GetCertificates(context.Background())
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if tc.expectedErr {
+				return
+			}
+			if len(keymanagementprovider.FlattenKMPMap(certs)) != tc.expectedCerts {
+				t.Fatalf("expected %d certificates, got %d", tc.expectedCerts, len(keymanagementprovider.FlattenKMPMap(certs)))
+			}
+			for _, key := range tc.expectedKeys {
+				if _, ok := certs[keymanagementprovider.KMPMapKey{Name: key, Version: "1"}]; !ok {
+					t.Fatalf("expected certificates for key %s", key)
+				}
+			}
+			if status[resourceVersionStatus] != "1" {
+				t.Fatalf("expected resource version 1, got %v", status[resourceVersionStatus])
+			}
+		})
+	}
+}
+
+func TestGetCertificates_RefreshOnUpdate(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testName, Namespace: testNamespace, ResourceVersion: "1"},
+		Data: map[string][]byte{
+			"ca.crt": []byte(generateCertPEM(t, "ca")),
+		},
+	}
+	clientset := useFakeClientset(t, secret)
+	provider, err := (&kubernetesKMProviderFactory{}).Create("v1.0", config.KeyManagementProviderConfig{
+		"type":      providerName,
+		"kind":      secretKind,
+		"name":      testName,
+		"namespace": testNamespace,
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	if !provider.IsRefreshable() {
+		t.Fatal("expected provider to be refreshable")
+	}
+
+	certs, _, err := provider.GetCertificates(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get certificates: %v", err)
+	}
+	if len(certs) != 1 {
+		t.Fatalf("expected 1 certificate, got %d", len(certs))
+	}
+
+	updated := secret.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Data["rotated.crt"] = []byte(generateCertPEM(t, "rotated"))
+	if _, err := clientset.CoreV1().Secrets(testNamespace).Update(context.Background(), updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		certs, status, err := provider.GetCertificates(context.Background())
+		if err == nil && len(certs) == 2 && status[resourceVersionStatus] == "2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("certificates were not refreshed after update, got %d certificate(s), err: %v", len(certs), err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := clientset.CoreV1().Secrets(testNamespace).Delete(context.Background(), testName, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete secret: %v", err)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		if _, _, err := provider.GetCertificates(context.Background()); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected error after secret was deleted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGetKeys(t *testing.T) {
+	useFakeClientset(t, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: testName, Namespace: testNamespace, ResourceVersion: "1"},
+		Data: map[string]string{
+			"key1.pub": generateKeyPEM(t),
+			"key2.pub": generateKeyPEM(t),
+		},
+	})
+	provider, err := (&kubernetesKMProviderFactory{}).Create("v1.0", config.KeyManagementProviderConfig{
+		"type":        providerName,
+		"kind":        configMapKind,
+		"name":        testName,
+		"namespace":   testNamespace,
+		"contentType": keyContentType,
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	keys, _, err := provider.GetKeys(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get keys: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(keys))
+	}
+	certs, _, err := provider.GetCertificates(context.Background())
+	if err != nil || len(certs) != 0 {
+		t.Fatalf("expected no certificates for key content type, got %d, err: %v", len(certs), err)
+	}
+}
+
+func TestGetSigners(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	useFakeClientset(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testName, Namespace: testNamespace, ResourceVersion: "1"},
+		Data: map[string][]byte{
+			"signing.key": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+		},
+	})
+	provider, err := (&kubernetesKMProviderFactory{}).Create("v1.0", config.KeyManagementProviderConfig{
+		"type":        providerName,
+		"kind":        secretKind,
+		"name":        testName,
+		"namespace":   testNamespace,
+		"contentType": privateKeyContentType,
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	signers, err := provider.(keymanagementprovider.SigningKeyManagementProvider).GetSigners(context.Background())
+	if err != nil || len(signers) != 1 {
+		t.Fatalf("expected 1 signer, got %d, err: %v", len(signers), err)
+	}
+	keys, _, err := provider.GetKeys(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get keys: %v", err)
+	}
+	if !privateKey.PublicKey.Equal(keys[keymanagementprovider.KMPMapKey{Name: "signing.key", Version: "1"}]) {
+		t.Fatal("expected the public key of the private key")
+	}
+}
+
+func TestClose(t *testing.T) {
+	useFakeClientset(t)
+	providerConfig := config.KeyManagementProviderConfig{
+		"type": providerName,
+		"kind": configMapKind,
+		"name": testName,
+	}
+	factory := &kubernetesKMProviderFactory{}
+	first, err := factory.Create("v1.0", providerConfig, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	second, err := factory.Create("v1.0", providerConfig, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	watcher := first.(*kubernetesKMProvider).watcher
+	if second.(*kubernetesKMProvider).watcher != watcher {
+		t.Fatal("expected providers of the same resource to share the watcher")
+	}
+
+	// the resource is replaced by the second provider
+	keymanagementprovider.SetProvider("test/kmp", first)
+	keymanagementprovider.SetProvider("test/kmp", second)
+	first.(*kubernetesKMProvider).Close()
+	watchersMu.Lock()
+	_, ok := watchers[watcherKey(configMapKind, testNamespace, testName)]
+	watchersMu.Unlock()
+	if !ok {
+		t.Fatal("expected watcher to run while a provider uses it")
+	}
+
+	keymanagementprovider.DeleteResourceFromMap("test/kmp")
+	watchersMu.Lock()
+	_, ok = watchers[watcherKey(configMapKind, testNamespace, testName)]
+	watchersMu.Unlock()
+	if ok {
+		t.Fatal("expected watcher to stop once no provider uses it")
+	}
+	select {
+	case <-watcher.stopCh:
+	default:
+		t.Fatal("expected informer of the watcher to be stopped")
+	}
+}
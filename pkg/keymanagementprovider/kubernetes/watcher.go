@@ -0,0 +1,208 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	resourceStatus        = "resource"
+	resourceVersionStatus = "resourceVersion"
+	keysStatus            = "keys"
+)
+
+// cacheSyncTimeout bounds the wait for the initial list of the watched resource.
+var cacheSyncTimeout = 30 * time.Second
+
+// watchers holds one informer per watched resource so that recreating a
+// provider on every reconcile does not start a new informer. A watcher is
+// stopped once every provider using it is closed.
+// layout:
+//
+//	map["<kind>/<namespace>/<name>"] = *resourceWatcher
+var (
+	watchers   = map[string]*resourceWatcher{}
+	watchersMu sync.Mutex
+)
+
+// resourceSnapshot is the content of the watched resource at a given version.
+type resourceSnapshot struct {
+	data            map[string][]byte
+	resourceVersion string
+}
+
+// resourceWatcher keeps the latest content of a single ConfigMap or Secret up
+// to date using an informer.
+type resourceWatcher struct {
+	kind      string
+	namespace string
+	name      string
+
+	mu       sync.RWMutex
+	snapshot *resourceSnapshot
+	stopCh   chan struct{}
+	// refs is the number of providers using the watcher, guarded by watchersMu.
+	refs int
+}
+
+// getWatcher returns the watcher for the given resource, starting one if it
+// does not exist yet. The watcher must be released with releaseWatcher.
+func getWatcher(kind, namespace, name string) (*resourceWatcher, error) {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+
+	if watcher, ok := watchers[watcherKey(kind, namespace, name)]; ok {
+		watcher.refs++
+		return watcher, nil
+	}
+
+	watcher := &resourceWatcher{kind: kind, namespace: namespace, name: name}
+	if err := watcher.start(); err != nil {
+		return nil, err
+	}
+	watcher.refs = 1
+	watchers[watcherKey(kind, namespace, name)] = watcher
+	return watcher, nil
+}
+
+// releaseWatcher releases a watcher returned by getWatcher and stops it once
+// it is no longer used.
+func releaseWatcher(w *resourceWatcher) {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+
+	w.refs--
+	if w.refs > 0 {
+		return
+	}
+	delete(watchers, watcherKey(w.kind, w.namespace, w.name))
+	close(w.stopCh)
+	logrus.Infof("stopped watching %s", w.resource())
+}
+
+func watcherKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// start runs an informer scoped to the watched resource and waits for the
+// initial list to complete.
+func (w *resourceWatcher) start() error {
+	clientset, err := newClientset()
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client set: %w", err)
+	}
+
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(w.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.name).String()
+		}))
+
+	var informer cache.SharedIndexInformer
+	if w.kind == secretKind {
+		informer = informerFactory.Core().V1().Secrets().Informer()
+	} else {
+		informer = informerFactory.Core().V1().ConfigMaps().Informer()
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.update,
+		UpdateFunc: func(_, newObj interface{}) { w.update(newObj) },
+		DeleteFunc: w.delete,
+	}); err != nil {
+		return fmt.Errorf("failed to register event handler: %w", err)
+	}
+
+	w.stopCh = make(chan struct{})
+	informerFactory.Start(w.stopCh)
+
+	timeout := time.AfterFunc(cacheSyncTimeout, func() { close(w.stopCh) })
+	synced := cache.WaitForCacheSync(w.stopCh, informer.HasSynced)
+	if !timeout.Stop() || !synced {
+		return fmt.Errorf("timed out waiting for the informer cache of %s to sync", w.resource())
+	}
+	return nil
+}
+
+// update stores the content of the resource if it is the watched one.
+func (w *resourceWatcher) update(obj interface{}) {
+	var meta metav1.ObjectMeta
+	data := map[string][]byte{}
+	switch resource := obj.(type) {
+	case *corev1.ConfigMap:
+		meta = resource.ObjectMeta
+		for key, value := range resource.Data {
+			data[key] = []byte(value)
+		}
+		for key, value := range resource.BinaryData {
+			data[key] = value
+		}
+	case *corev1.Secret:
+		meta = resource.ObjectMeta
+		for key, value := range resource.Data {
+			data[key] = value
+		}
+	default:
+		return
+	}
+	if meta.Name != w.name || meta.Namespace != w.namespace {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.snapshot = &resourceSnapshot{data: data, resourceVersion: meta.ResourceVersion}
+	logrus.Infof("refreshed %d key(s) from %s at resource version %s", len(data), w.resource(), meta.ResourceVersion)
+}
+
+// delete clears the stored content once the watched resource is deleted.
+func (w *resourceWatcher) delete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if accessor, ok := obj.(metav1.Object); !ok || accessor.GetName() != w.name || accessor.GetNamespace() != w.namespace {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.snapshot = nil
+	logrus.Infof("%s was deleted", w.resource())
+}
+
+// get returns the latest content of the watched resource.
+func (w *resourceWatcher) get() (*resourceSnapshot, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.snapshot == nil {
+		return nil, fmt.Errorf("%s not found", w.resource())
+	}
+	return w.snapshot, nil
+}
+
+// resource returns a human readable reference to the watched resource.
+func (w *resourceWatcher) resource() string {
+	return fmt.Sprintf("%s %s/%s", w.kind, w.namespace, w.name)
+}
@@ -18,21 +18,42 @@ package refresh
 
 import (
 	"context"
+	"crypto"
+	"crypto/x509"
 	"fmt"
 	"maps"
+	"math/rand"
 	"time"
 
 	re "github.com/ratify-project/ratify/errors"
 	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// maxJitterFactor is the maximum fraction of the refresh interval added as
+// random jitter so that providers sharing an interval do not refresh at once.
+const maxJitterFactor = 0.1
+
+// refreshGroup coalesces concurrent refreshes of the same version of a
+// resource into a single call to the key management provider.
+var refreshGroup singleflight.Group
+
+// fetchResult holds the certificates and keys fetched in a single refresh.
+type fetchResult struct {
+	certificates   map[kmp.KMPMapKey][]*x509.Certificate
+	certAttributes kmp.KeyManagementProviderStatus
+	keys           map[kmp.KMPMapKey]crypto.PublicKey
+	keyAttributes  kmp.KeyManagementProviderStatus
+}
+
 type KubeRefresher struct {
 	Provider                kmp.KeyManagementProvider
 	ProviderType            string
 	ProviderRefreshInterval string
 	Resource                string
+	ResourceVersion         string
 	Result                  ctrl.Result
 	Status                  kmp.KeyManagementProviderStatus
 }
@@ -46,28 +67,31 @@ func init() {
 func (kr *KubeRefresher) Refresh(ctx context.Context) error {
 	logger := logrus.WithContext(ctx)
 
-	// fetch certificates and store in map
-	certificates, certAttributes, err := kr.Provider.GetCertificates(ctx)
+	// concurrent refreshes of the same resource share a single fetch, unless
+	// the resource was updated: the refresh of the updated spec must not
+	// return the material fetched by the provider of the previous spec
+	v, err, shared := refreshGroup.Do(kr.Resource+"@"+kr.ResourceVersion, func() (interface{}, error) {
+		return kr.fetch(ctx)
+	})
 	if err != nil {
-		kmpErr := re.ErrorCodeKeyManagementProviderFailure.WithError(err).WithDetail(fmt.Sprintf("Unable to fetch certificates from key management provider [%s] of type [%s]", kr.Resource, kr.ProviderType))
-		kmp.SetCertificateError(kr.Resource, err)
-		return kmpErr
+		return err
 	}
-
-	// fetch keys and store in map
-	keys, keyAttributes, err := kr.Provider.GetKeys(ctx)
-	if err != nil {
-		kmpErr := re.ErrorCodeKeyManagementProviderFailure.WithError(err).WithDetail(fmt.Sprintf("Unable to fetch keys from key management provider [%s] of type [%s]", kr.Resource, kr.ProviderType))
-		kmp.SetKeyError(kr.Resource, err)
-		return kmpErr
+	fetched := v.(*fetchResult)
+
+	// merge certificates and keys status into one. The fetched status is
+	// shared by coalesced refreshes so it is copied rather than modified.
+	status := maps.Clone(fetched.keyAttributes)
+	if status == nil {
+		status = maps.Clone(fetched.certAttributes)
+	} else {
+		maps.Copy(status, fetched.certAttributes)
 	}
+	kr.Status = status
 
-	kmp.SaveSecrets(kr.Resource, kr.ProviderType, keys, certificates)
-	// merge certificates and keys status into one
-	maps.Copy(keyAttributes, certAttributes)
-	kr.Status = keyAttributes
-
-	logger.Infof("%v certificate(s) & %v key(s) fetched for key management provider %v", len(certificates), len(keys), kr.Resource)
+	if shared {
+		logger.Debugf("coalesced refresh of key management provider %v", kr.Resource)
+	}
+	logger.Infof("%v certificate(s) & %v key(s) fetched for key management provider %v", len(fetched.certificates), len(fetched.keys), kr.Resource)
 
 	// Resource is not refreshable, returning empty result and no error to indicate we’ve successfully reconciled this object
 	// will not reconcile again unless resource is recreated
@@ -87,12 +111,62 @@ func (kr *KubeRefresher) Refresh(ctx context.Context) error {
 		return kmpErr
 	}
 
-	logger.Info("Reconciled KeyManagementProvider", "intervalDuration", intervalDuration)
-	kr.Result = ctrl.Result{RequeueAfter: intervalDuration}
+	requeueAfter := jitter(intervalDuration)
+	logger.Info("Reconciled KeyManagementProvider", "intervalDuration", intervalDuration, "requeueAfter", requeueAfter)
+	kr.Result = ctrl.Result{RequeueAfter: requeueAfter}
 
 	return nil
 }
 
+// fetch gets the certificates and keys from the provider and stores them in
+// the map.
+func (kr *KubeRefresher) fetch(ctx context.Context) (*fetchResult, error) {
+	// fetch certificates and store in map
+	certificates, certAttributes, err := kr.Provider.GetCertificates(ctx)
+	if err != nil {
+		kmpErr := re.ErrorCodeKeyManagementProviderFailure.WithError(err).WithDetail(fmt.Sprintf("Unable to fetch certificates from key management provider [%s] of type [%s]", kr.Resource, kr.ProviderType))
+		kmp.SetCertificateError(kr.Resource, err)
+		return nil, kmpErr
+	}
+
+	// fetch keys and store in map
+	keys, keyAttributes, err := kr.Provider.GetKeys(ctx)
+	if err != nil {
+		kmpErr := re.ErrorCodeKeyManagementProviderFailure.WithError(err).WithDetail(fmt.Sprintf("Unable to fetch keys from key management provider [%s] of type [%s]", kr.Resource, kr.ProviderType))
+		kmp.SetKeyError(kr.Resource, err)
+		return nil, kmpErr
+	}
+
+	// fetch the signers of providers holding private keys
+	if signingProvider, ok := kr.Provider.(kmp.SigningKeyManagementProvider); ok {
+		signers, err := signingProvider.GetSigners(ctx)
+		if err != nil {
+			kmpErr := re.ErrorCodeKeyManagementProviderFailure.WithError(err).WithDetail(fmt.Sprintf("Unable to fetch signers from key management provider [%s] of type [%s]", kr.Resource, kr.ProviderType))
+			kmp.SetKeyError(kr.Resource, err)
+			return nil, kmpErr
+		}
+		kmp.SaveSigners(kr.Resource, signers)
+	}
+
+	kmp.SaveSecrets(kr.Resource, kr.ProviderType, keys, certificates)
+	return &fetchResult{
+		certificates:   certificates,
+		certAttributes: certAttributes,
+		keys:           keys,
+		keyAttributes:  keyAttributes,
+	}, nil
+}
+
+// jitter returns the interval extended by a random duration of up to
+// maxJitterFactor of the interval.
+func jitter(interval time.Duration) time.Duration {
+	maxJitter := int64(float64(interval) * maxJitterFactor)
+	if maxJitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int63n(maxJitter)) // #nosec G404 - jitter does not need a secure random source
+}
+
 // GetResult returns the result of the refresh as a ctrl.Result
 func (kr *KubeRefresher) GetResult() interface{} {
 	return kr.Result
@@ -109,5 +183,6 @@ func (kr *KubeRefresher) Create(config RefresherConfig) (Refresher, error) {
 		ProviderType:            config.ProviderType,
 		ProviderRefreshInterval: config.ProviderRefreshInterval,
 		Resource:                config.Resource,
+		ResourceVersion:         config.ResourceVersion,
 	}, nil
 }
@@ -22,6 +22,8 @@ import (
 	"crypto/x509"
 	"errors"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -134,7 +136,14 @@ func TestKubeRefresher_Refresh(t *testing.T) {
 
 			err := kr.Refresh(context.Background())
 			result := kr.GetResult()
-			if !reflect.DeepEqual(result, tt.expectedResult) {
+			if tt.expectedResult.RequeueAfter > 0 {
+				// the requeue interval is jittered
+				requeueAfter := result.(ctrl.Result).RequeueAfter
+				maxRequeueAfter := tt.expectedResult.RequeueAfter + time.Duration(float64(tt.expectedResult.RequeueAfter)*maxJitterFactor)
+				if requeueAfter < tt.expectedResult.RequeueAfter || requeueAfter >= maxRequeueAfter {
+					t.Fatalf("Expected requeue after in [%v, %v) but got %v with error %v", tt.expectedResult.RequeueAfter, maxRequeueAfter, requeueAfter, err)
+				}
+			} else if !reflect.DeepEqual(result, tt.expectedResult) {
 				t.Fatalf("Expected nil but got %v with error %v", result, err)
 			}
 			if tt.expectedError && err == nil {
@@ -144,6 +153,120 @@ func TestKubeRefresher_Refresh(t *testing.T) {
 	}
 }
 
+func TestKubeRefresher_Refresh_Coalesced(t *testing.T) {
+	const concurrentRefreshes = 10
+	var calls atomic.Int32
+	release := make(chan struct{})
+	provider := &mock.TestKeyManagementProvider{
+		GetCertificatesFunc: func(_ context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+			calls.Add(1)
+			<-release
+			return map[keymanagementprovider.KMPMapKey][]*x509.Certificate{}, keymanagementprovider.KeyManagementProviderStatus{"certs": 1}, nil
+		},
+		IsRefreshableFunc: func() bool { return true },
+	}
+
+	var started, done sync.WaitGroup
+	errs := make(chan error, concurrentRefreshes)
+	refreshers := make([]*KubeRefresher, concurrentRefreshes)
+	for i := range refreshers {
+		refreshers[i] = &KubeRefresher{
+			Provider:                provider,
+			ProviderType:            "test-kmp",
+			ProviderRefreshInterval: "1m",
+			Resource:                "coalesced",
+		}
+		started.Add(1)
+		done.Add(1)
+		go func(kr *KubeRefresher) {
+			defer done.Done()
+			started.Done()
+			errs <- kr.Refresh(context.Background())
+		}(refreshers[i])
+	}
+	started.Wait()
+	// wait for the first refresh to reach the provider before releasing it
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected 1 call to the provider, got %d", calls.Load())
+	}
+	for _, kr := range refreshers {
+		if kr.Status["certs"] != 1 {
+			t.Fatalf("expected status to be shared with coalesced refresh, got %v", kr.Status)
+		}
+	}
+}
+
+func TestKubeRefresher_Refresh_NotCoalescedAcrossVersions(t *testing.T) {
+	release := make(chan struct{})
+	fetching := make(chan struct{})
+	previous := &mock.TestKeyManagementProvider{
+		GetCertificatesFunc: func(_ context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+			close(fetching)
+			<-release
+			return map[keymanagementprovider.KMPMapKey][]*x509.Certificate{}, keymanagementprovider.KeyManagementProviderStatus{"version": "1"}, nil
+		},
+	}
+	updated := &mock.TestKeyManagementProvider{
+		GetCertificatesFunc: func(_ context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+			return map[keymanagementprovider.KMPMapKey][]*x509.Certificate{}, keymanagementprovider.KeyManagementProviderStatus{"version": "2"}, nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- (&KubeRefresher{Provider: previous, ProviderType: "test-kmp", Resource: "updated", ResourceVersion: "1"}).Refresh(context.Background())
+	}()
+	<-fetching
+	defer func() {
+		close(release)
+		if err := <-done; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}()
+
+	kr := &KubeRefresher{Provider: updated, ProviderType: "test-kmp", Resource: "updated", ResourceVersion: "2"}
+	if err := kr.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kr.Status["version"] != "2" {
+		t.Fatalf("expected the status of the updated provider, got %v", kr.Status)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	interval := time.Minute
+	maxInterval := interval + time.Duration(float64(interval)*maxJitterFactor)
+	jittered := false
+	for i := 0; i < 1000; i++ {
+		got := jitter(interval)
+		if got < interval || got >= maxInterval {
+			t.Fatalf("expected jittered interval in [%v, %v), got %v", interval, maxInterval, got)
+		}
+		if got != interval {
+			jittered = true
+		}
+	}
+	if !jittered {
+		t.Fatal("expected interval to be jittered")
+	}
+	if got := jitter(0); got != 0 {
+		t.Fatalf("expected no jitter for zero interval, got %v", got)
+	}
+}
+
 func TestKubeRefresher_GetResult(t *testing.T) {
 	kr := &KubeRefresher{
 		Result: ctrl.Result{RequeueAfter: time.Minute},
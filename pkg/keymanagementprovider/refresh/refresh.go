@@ -43,4 +43,5 @@ type RefresherConfig struct {
 	ProviderType            string                                      // ProviderType is the type of the provider
 	ProviderRefreshInterval string                                      // ProviderRefreshInterval is the refresh interval for the provider
 	Resource                string                                      // Resource is the resource to be refreshed
+	ResourceVersion         string                                      // ResourceVersion is the version of the resource spec the provider is created from
 }
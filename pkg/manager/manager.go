@@ -30,12 +30,17 @@ import (
 	"github.com/open-policy-agent/cert-controller/pkg/rotator"
 	"github.com/ratify-project/ratify/config"
 	"github.com/ratify-project/ratify/httpserver"
+	"github.com/ratify-project/ratify/pkg/common/oras/authprovider"
 	"github.com/ratify-project/ratify/pkg/featureflag"
+	_ "github.com/ratify-project/ratify/pkg/policyprovider/celpolicy"    // register CEL policy provider
 	_ "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy" // register config policy provider
 	_ "github.com/ratify-project/ratify/pkg/policyprovider/regopolicy"   // register rego policy provider
+	_ "github.com/ratify-project/ratify/pkg/policyprovider/scorepolicy"  // register score policy provider
 	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"          // register ORAS referrer store
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/router"        // register router referrer store
 	"github.com/ratify-project/ratify/pkg/utils"
-	_ "github.com/ratify-project/ratify/pkg/verifier/notation" // register notation verifier
+	_ "github.com/ratify-project/ratify/pkg/verifier/helmprovenance" // register helm provenance verifier
+	_ "github.com/ratify-project/ratify/pkg/verifier/notation"       // register notation verifier
 	"github.com/sirupsen/logrus"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // import additional authentication methods
 
@@ -53,6 +58,9 @@ import (
 	"github.com/ratify-project/ratify/pkg/controllers/clusterresource"
 	"github.com/ratify-project/ratify/pkg/controllers/namespaceresource"
 	ef "github.com/ratify-project/ratify/pkg/executor/core"
+	"github.com/ratify-project/ratify/pkg/executor/events"
+	"github.com/ratify-project/ratify/pkg/policyprovider"
+	"github.com/ratify-project/ratify/pkg/policyprovider/defaultpolicy"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -74,7 +82,7 @@ func init() {
 	//+kubebuilder:scaffold:scheme
 }
 
-func StartServer(httpServerAddress, configFilePath, certDirectory, caCertFile string, cacheTTL time.Duration, metricsEnabled bool, metricsType string, metricsPort int, certRotatorReady chan struct{}) {
+func StartServer(httpServerAddress, adminAddress string, maxBatchSize, batchVerifyConcurrency int, configFilePath, certDirectory, caCertFile string, cacheTTL time.Duration, metricsEnabled bool, metricsType string, metricsPort int, certRotatorReady chan struct{}) {
 	logrus.Info("initializing executor with config file at default config path")
 
 	cf, err := config.Load(configFilePath)
@@ -82,6 +90,16 @@ func StartServer(httpServerAddress, configFilePath, certDirectory, caCertFile st
 		logrus.Errorf("server start failed %v", fmt.Errorf("error loading config %w", err))
 		os.Exit(1)
 	}
+	var defaultPolicy policyprovider.PolicyProvider
+	if cf.ExecutorConfig.DefaultPolicy != "" {
+		defaultPolicyEnforcer, err := defaultpolicy.New(cf.ExecutorConfig.DefaultPolicy)
+		if err != nil {
+			logrus.Errorf("server start failed %v", fmt.Errorf("error loading default policy %w", err))
+			os.Exit(1)
+		}
+		defaultPolicy = defaultPolicyEnforcer
+		logrus.Warnf("the default policy %s is applied to the verifications of namespaces without a policy", cf.ExecutorConfig.DefaultPolicy)
+	}
 
 	// initialize server
 	server, err := httpserver.NewServer(context.Background(), httpServerAddress, func(ctx context.Context) *ef.Executor {
@@ -89,14 +107,19 @@ func StartServer(httpServerAddress, configFilePath, certDirectory, caCertFile st
 
 		activeVerifiers := controllers.NamespacedVerifiers.GetVerifiers(namespace)
 		activePolicyEnforcer := controllers.NamespacedPolicies.GetPolicy(namespace)
+		policyNamespace, _ := controllers.NamespacedPolicies.GetPolicyScope(namespace)
+		if activePolicyEnforcer == nil {
+			activePolicyEnforcer = defaultPolicy
+		}
 		activeStores := controllers.NamespacedStores.GetStores(namespace)
 
 		// return executor with latest configuration
 		executor := ef.Executor{
-			Verifiers:      activeVerifiers,
-			ReferrerStores: activeStores,
-			PolicyEnforcer: activePolicyEnforcer,
-			Config:         &cf.ExecutorConfig,
+			Verifiers:       activeVerifiers,
+			ReferrerStores:  activeStores,
+			PolicyEnforcer:  activePolicyEnforcer,
+			Config:          &cf.ExecutorConfig,
+			PolicyNamespace: policyNamespace,
 		}
 		return &executor
 	}, certDirectory, caCertFile, cacheTTL, metricsEnabled, metricsType, metricsPort)
@@ -105,6 +128,9 @@ func StartServer(httpServerAddress, configFilePath, certDirectory, caCertFile st
 		logrus.Errorf("initialize server failed with error %v, exiting..", err)
 		os.Exit(1)
 	}
+	server.AdminAddress = adminAddress
+	server.MaxBatchSize = maxBatchSize
+	server.BatchVerifyConcurrency = batchVerifyConcurrency
 	logrus.Infof("starting server at" + httpServerAddress)
 	if err := server.Run(certRotatorReady); err != nil {
 		logrus.Errorf("starting server failed with error %v, exiting..", err)
@@ -150,6 +176,9 @@ func StartManager(certRotatorReady chan struct{}, probeAddr string) {
 
 	setupLog.Debugf("setting up probeAddr at %s", probeAddr)
 
+	// the executor records the events of denied subjects if configured
+	events.SetRecorder(mgr.GetEventRecorderFor(utils.GetServiceName()))
+
 	// Make sure certs are generated and valid if cert rotation is enabled.
 	if featureflag.CertRotation.Enabled {
 		// Make sure TLS cert watcher is already set up.
@@ -266,6 +295,10 @@ func StartManager(certRotatorReady chan struct{}, probeAddr string) {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("authproviders", authprovider.ReadyzCheck); err != nil {
+		setupLog.Error(err, "unable to set up auth provider ready check")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
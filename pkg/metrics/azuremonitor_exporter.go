@@ -0,0 +1,290 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const (
+	azureMonitorExporter = "azuremonitor"
+
+	// AzureMonitorConnectionStringEnv is the environment variable holding the
+	// connection string of the Application Insights resource the metrics are
+	// exported to.
+	AzureMonitorConnectionStringEnv = "APPLICATIONINSIGHTS_CONNECTION_STRING"
+
+	azureMonitorExportInterval     = 60 * time.Second
+	azureMonitorExportTimeout      = 30 * time.Second
+	defaultAzureMonitorIngestion   = "https://dc.services.visualstudio.com"
+	azureMonitorTrackPath          = "/v2.1/track"
+	azureMonitorMetricEnvelopeName = "Microsoft.ApplicationInsights.Metric"
+)
+
+// appInsightsEnvelope is the telemetry item accepted by the track endpoint of
+// Application Insights.
+type appInsightsEnvelope struct {
+	Name string          `json:"name"`
+	Time string          `json:"time"`
+	IKey string          `json:"iKey"`
+	Data appInsightsData `json:"data"`
+}
+
+type appInsightsData struct {
+	BaseType string                `json:"baseType"`
+	BaseData appInsightsMetricData `json:"baseData"`
+}
+
+type appInsightsMetricData struct {
+	Ver        int                 `json:"ver"`
+	Metrics    []appInsightsMetric `json:"metrics"`
+	Properties map[string]string   `json:"properties,omitempty"`
+}
+
+type appInsightsMetric struct {
+	Name  string   `json:"name"`
+	Value float64  `json:"value"`
+	Count uint64   `json:"count"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// azureMonitorMetricExporter exports the metrics collected in each interval to
+// Application Insights as metric telemetry, one item per data point.
+type azureMonitorMetricExporter struct {
+	instrumentationKey string
+	trackURL           string
+	client             *http.Client
+}
+
+// parseAzureMonitorConnectionString returns the instrumentation key and the
+// ingestion endpoint of an Application Insights connection string.
+func parseAzureMonitorConnectionString(connectionString string) (string, string, error) {
+	var instrumentationKey string
+	ingestionEndpoint := defaultAzureMonitorIngestion
+	for _, part := range strings.Split(connectionString, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "instrumentationkey":
+			instrumentationKey = value
+		case "ingestionendpoint":
+			ingestionEndpoint = strings.TrimSuffix(value, "/")
+		}
+	}
+	if instrumentationKey == "" {
+		return "", "", fmt.Errorf("connection string does not contain an InstrumentationKey")
+	}
+	return instrumentationKey, ingestionEndpoint, nil
+}
+
+func newAzureMonitorMetricExporter(connectionString string) (*azureMonitorMetricExporter, error) {
+	instrumentationKey, ingestionEndpoint, err := parseAzureMonitorConnectionString(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	return &azureMonitorMetricExporter{
+		instrumentationKey: instrumentationKey,
+		trackURL:           ingestionEndpoint + azureMonitorTrackPath,
+		client:             &http.Client{Timeout: azureMonitorExportTimeout},
+	}, nil
+}
+
+// newAzureMonitorReader returns the reader periodically exporting the metrics
+// with the exporter.
+func newAzureMonitorReader(exporter sdkmetric.Exporter) *sdkmetric.PeriodicReader {
+	return sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(azureMonitorExportInterval))
+}
+
+// initAzureMonitorExporter creates the reader exporting the metrics to the
+// Application Insights resource of the configured connection string.
+func initAzureMonitorExporter() (sdkmetric.Reader, error) {
+	connectionString := os.Getenv(AzureMonitorConnectionStringEnv)
+	if connectionString == "" {
+		return nil, fmt.Errorf("%s must be set to export metrics to Azure Monitor", AzureMonitorConnectionStringEnv)
+	}
+	exporter, err := newAzureMonitorMetricExporter(connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", AzureMonitorConnectionStringEnv, err)
+	}
+	return newAzureMonitorReader(exporter), nil
+}
+
+// Temporality returns delta temporality as Application Insights aggregates the
+// metrics reported for each interval.
+func (e *azureMonitorMetricExporter) Temporality(sdkmetric.InstrumentKind) metricdata.Temporality {
+	return metricdata.DeltaTemporality
+}
+
+func (e *azureMonitorMetricExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+// Export sends the data points of the metrics to the track endpoint.
+func (e *azureMonitorMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	envelopes := e.envelopes(rm)
+	if len(envelopes) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(envelopes)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.trackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export metrics to Azure Monitor: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to export metrics to Azure Monitor, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *azureMonitorMetricExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (e *azureMonitorMetricExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// envelopes converts the data points of the metrics to metric telemetry.
+func (e *azureMonitorMetricExporter) envelopes(rm *metricdata.ResourceMetrics) []appInsightsEnvelope {
+	var envelopes []appInsightsEnvelope
+	add := func(name string, point metricPoint) {
+		envelopes = append(envelopes, appInsightsEnvelope{
+			Name: azureMonitorMetricEnvelopeName,
+			Time: point.time.UTC().Format(time.RFC3339Nano),
+			IKey: e.instrumentationKey,
+			Data: appInsightsData{
+				BaseType: "MetricData",
+				BaseData: appInsightsMetricData{
+					Ver:        2,
+					Metrics:    []appInsightsMetric{point.metric(name)},
+					Properties: point.properties,
+				},
+			},
+		})
+	}
+	for _, scopeMetrics := range rm.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			for _, point := range metricPoints(m.Data) {
+				add(m.Name, point)
+			}
+		}
+	}
+	return envelopes
+}
+
+// metricPoint is a data point of any aggregation as reported to Application
+// Insights.
+type metricPoint struct {
+	time       time.Time
+	value      float64
+	count      uint64
+	min, max   *float64
+	properties map[string]string
+}
+
+func (p metricPoint) metric(name string) appInsightsMetric {
+	return appInsightsMetric{Name: name, Value: p.value, Count: p.count, Min: p.min, Max: p.max}
+}
+
+func metricPoints(data metricdata.Aggregation) []metricPoint {
+	switch data := data.(type) {
+	case metricdata.Sum[int64]:
+		return numberPoints(data.DataPoints)
+	case metricdata.Sum[float64]:
+		return numberPoints(data.DataPoints)
+	case metricdata.Gauge[int64]:
+		return numberPoints(data.DataPoints)
+	case metricdata.Gauge[float64]:
+		return numberPoints(data.DataPoints)
+	case metricdata.Histogram[int64]:
+		return histogramPoints(data.DataPoints)
+	case metricdata.Histogram[float64]:
+		return histogramPoints(data.DataPoints)
+	default:
+		return nil
+	}
+}
+
+func numberPoints[N int64 | float64](dataPoints []metricdata.DataPoint[N]) []metricPoint {
+	points := make([]metricPoint, 0, len(dataPoints))
+	for _, dp := range dataPoints {
+		points = append(points, metricPoint{
+			time:       dp.Time,
+			value:      float64(dp.Value),
+			count:      1,
+			properties: attributeProperties(dp.Attributes.ToSlice()),
+		})
+	}
+	return points
+}
+
+func histogramPoints[N int64 | float64](dataPoints []metricdata.HistogramDataPoint[N]) []metricPoint {
+	points := make([]metricPoint, 0, len(dataPoints))
+	for _, dp := range dataPoints {
+		point := metricPoint{
+			time:       dp.Time,
+			value:      float64(dp.Sum),
+			count:      dp.Count,
+			properties: attributeProperties(dp.Attributes.ToSlice()),
+		}
+		if minValue, ok := dp.Min.Value(); ok {
+			v := float64(minValue)
+			point.min = &v
+		}
+		if maxValue, ok := dp.Max.Value(); ok {
+			v := float64(maxValue)
+			point.max = &v
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+func attributeProperties(attrs []attribute.KeyValue) map[string]string {
+	if len(attrs) == 0 {
+		return nil
+	}
+	properties := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		properties[string(attr.Key)] = attr.Value.Emit()
+	}
+	return properties
+}
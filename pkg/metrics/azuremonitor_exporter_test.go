@@ -0,0 +1,241 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// fakeExporter records the names of the metrics it exports.
+type fakeExporter struct {
+	mu      sync.Mutex
+	metrics map[string]bool
+}
+
+func (e *fakeExporter) Temporality(sdkmetric.InstrumentKind) metricdata.Temporality {
+	return metricdata.DeltaTemporality
+}
+
+func (e *fakeExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *fakeExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, scopeMetrics := range rm.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			e.metrics[m.Name] = true
+		}
+	}
+	return nil
+}
+
+func (e *fakeExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (e *fakeExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestParseAzureMonitorConnectionString(t *testing.T) {
+	tests := []struct {
+		name             string
+		connectionString string
+		wantKey          string
+		wantEndpoint     string
+		wantErr          bool
+	}{
+		{
+			name:             "key and endpoint",
+			connectionString: "InstrumentationKey=00000000-0000-0000-0000-000000000000;IngestionEndpoint=https://westus2-0.in.applicationinsights.azure.com/;LiveEndpoint=https://westus2.livediagnostics.monitor.azure.com/",
+			wantKey:          "00000000-0000-0000-0000-000000000000",
+			wantEndpoint:     "https://westus2-0.in.applicationinsights.azure.com",
+		},
+		{
+			name:             "default endpoint",
+			connectionString: "InstrumentationKey=key",
+			wantKey:          "key",
+			wantEndpoint:     defaultAzureMonitorIngestion,
+		},
+		{
+			name:             "missing key",
+			connectionString: "IngestionEndpoint=https://westus2-0.in.applicationinsights.azure.com/",
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, endpoint, err := parseAzureMonitorConnectionString(tt.connectionString)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAzureMonitorConnectionString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if key != tt.wantKey || endpoint != tt.wantEndpoint {
+				t.Fatalf("parseAzureMonitorConnectionString() = %s, %s, want %s, %s", key, endpoint, tt.wantKey, tt.wantEndpoint)
+			}
+		})
+	}
+}
+
+func TestInitMetricsExporter_AzureMonitor(t *testing.T) {
+	t.Setenv(AzureMonitorConnectionStringEnv, "")
+	if err := InitMetricsExporter(azureMonitorExporter, 8888); err == nil {
+		t.Fatalf("expected error without a connection string")
+	}
+
+	t.Setenv(AzureMonitorConnectionStringEnv, "InstrumentationKey=key;IngestionEndpoint=http://localhost:1")
+	if err := InitMetricsExporter(azureMonitorExporter, 8888); err != nil {
+		t.Fatalf("InitMetricsExporter() error = %v", err)
+	}
+	defer func() {
+		_ = MetricReader.Shutdown(context.Background())
+		MetricReader = nil
+	}()
+	if verifierDuration == nil {
+		t.Fatalf("expected the instruments to be created")
+	}
+}
+
+func TestAzureMonitorReader_ForwardsMetrics(t *testing.T) {
+	exporter := &fakeExporter{metrics: map[string]bool{}}
+	reader := newAzureMonitorReader(exporter)
+	MetricReader = reader
+	defer func() {
+		_ = reader.Shutdown(context.Background())
+		MetricReader = nil
+	}()
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	ctx := context.Background()
+	ReportVerificationRequest(ctx, 10)
+	ReportVerifierDuration(ctx, 5, "notation", "notation", "localhost:5000/net-monitor:v1", true, false)
+	ReportAADExchangeDuration(ctx, 20, "acr")
+	if err := reader.ForceFlush(ctx); err != nil {
+		t.Fatalf("ForceFlush() error = %v", err)
+	}
+
+	for _, name := range []string{metricNameVerificationDuration, metricNameVerifierDuration, metricNameAADExchangeDuration} {
+		if !exporter.metrics[name] {
+			t.Fatalf("expected metric %s to be forwarded, got %v", name, exporter.metrics)
+		}
+	}
+}
+
+func TestAzureMonitorMetricExporter_Export(t *testing.T) {
+	var envelopes []appInsightsEnvelope
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != azureMonitorTrackPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&envelopes); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}))
+	defer server.Close()
+
+	exporter, err := newAzureMonitorMetricExporter("InstrumentationKey=key;IngestionEndpoint=" + server.URL + "/")
+	if err != nil {
+		t.Fatalf("newAzureMonitorMetricExporter() error = %v", err)
+	}
+	now := time.Now()
+	rm := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{
+				{
+					Name: metricNameVerifierDuration,
+					Data: metricdata.Histogram[int64]{
+						Temporality: metricdata.DeltaTemporality,
+						DataPoints: []metricdata.HistogramDataPoint[int64]{{
+							Attributes: attribute.NewSet(attribute.String("verifier", "notation")),
+							Time:       now,
+							Count:      2,
+							Sum:        30,
+							Min:        metricdata.NewExtrema[int64](10),
+							Max:        metricdata.NewExtrema[int64](20),
+						}},
+					},
+				},
+				{
+					Name: metricNameBlobCacheCount,
+					Data: metricdata.Sum[int64]{
+						Temporality: metricdata.DeltaTemporality,
+						DataPoints:  []metricdata.DataPoint[int64]{{Time: now, Value: 3}},
+					},
+				},
+			},
+		}},
+	}
+	if err := exporter.Export(context.Background(), rm); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	if len(envelopes) != 2 {
+		t.Fatalf("expected 2 telemetry items, got %d", len(envelopes))
+	}
+	histogram := envelopes[0]
+	if histogram.IKey != "key" || histogram.Name != azureMonitorMetricEnvelopeName || histogram.Data.BaseType != "MetricData" {
+		t.Fatalf("unexpected telemetry item %+v", histogram)
+	}
+	metric := histogram.Data.BaseData.Metrics[0]
+	if metric.Name != metricNameVerifierDuration || metric.Value != 30 || metric.Count != 2 || metric.Min == nil || *metric.Min != 10 || metric.Max == nil || *metric.Max != 20 {
+		t.Fatalf("unexpected histogram metric %+v", metric)
+	}
+	if histogram.Data.BaseData.Properties["verifier"] != "notation" {
+		t.Fatalf("expected the attributes as properties, got %v", histogram.Data.BaseData.Properties)
+	}
+	counter := envelopes[1].Data.BaseData.Metrics[0]
+	if counter.Name != metricNameBlobCacheCount || counter.Value != 3 || counter.Count != 1 {
+		t.Fatalf("unexpected counter metric %+v", counter)
+	}
+}
+
+func TestAzureMonitorMetricExporter_ExportFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exporter, err := newAzureMonitorMetricExporter("InstrumentationKey=key;IngestionEndpoint=" + server.URL)
+	if err != nil {
+		t.Fatalf("newAzureMonitorMetricExporter() error = %v", err)
+	}
+	rm := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{{
+				Name: metricNameSystemErrorCount,
+				Data: metricdata.Sum[int64]{DataPoints: []metricdata.DataPoint[int64]{{Time: time.Now(), Value: 1}}},
+			}},
+		}},
+	}
+	if err := exporter.Export(context.Background(), rm); err == nil {
+		t.Fatalf("expected error when the ingestion endpoint rejects the metrics")
+	}
+}
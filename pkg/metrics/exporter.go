@@ -36,7 +36,6 @@ func InitMetricsExporter(metricsBackend string, port int) error {
 	mb := strings.ToLower(metricsBackend)
 	logrus.Info("intializing metrics backend: ", mb)
 	switch mb {
-	// Prometheus is the only exporter for now
 	case prometheusExporter:
 		var err error
 		MetricReader, err = prometheus.New()
@@ -47,6 +46,13 @@ func InitMetricsExporter(metricsBackend string, port int) error {
 		if err := initPrometheusExporter(port); err != nil {
 			return err
 		}
+	case azureMonitorExporter:
+		var err error
+		MetricReader, err = initAzureMonitorExporter()
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
 	default:
 		return fmt.Errorf("unsupported metrics backend %v", metricsBackend)
 	}
@@ -0,0 +1,127 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	instrument "go.opentelemetry.io/otel/metric"
+)
+
+// OtherLabelValue is reported in place of a label value that is not in the
+// configured allowlist for that label.
+const OtherLabelValue = "other"
+
+// LabelConfig controls the label sets attached to the emitted metrics so that
+// deployments with many verifiers or subjects can bound series cardinality.
+type LabelConfig struct {
+	// DropLabels lists labels that are never emitted, e.g. "verifier" (the
+	// verifier instance name) or "subject".
+	DropLabels []string `json:"dropLabels,omitempty"`
+	// AllowedValues maps a label to the values that may be emitted for it.
+	// Any other value is reported as OtherLabelValue.
+	AllowedValues map[string][]string `json:"allowedValues,omitempty"`
+}
+
+// Validate checks that the label configuration is well formed.
+func (c LabelConfig) Validate() error {
+	for _, label := range c.DropLabels {
+		if label == "" {
+			return fmt.Errorf("metrics dropLabels must not contain an empty label")
+		}
+	}
+	for label, values := range c.AllowedValues {
+		if label == "" {
+			return fmt.Errorf("metrics allowedValues must not contain an empty label")
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("metrics allowedValues for label %s must not be empty, use dropLabels to remove the label", label)
+		}
+	}
+	return nil
+}
+
+// labelFilter is the compiled form of a LabelConfig.
+type labelFilter struct {
+	drop    map[attribute.Key]struct{}
+	allowed map[attribute.Key]map[string]struct{}
+}
+
+var (
+	labelMu     sync.RWMutex
+	activeLabel *labelFilter
+)
+
+// SetLabelConfig installs the label configuration applied to all metrics
+// reported afterwards.
+func SetLabelConfig(conf LabelConfig) error {
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+	filter := &labelFilter{
+		drop:    make(map[attribute.Key]struct{}, len(conf.DropLabels)),
+		allowed: make(map[attribute.Key]map[string]struct{}, len(conf.AllowedValues)),
+	}
+	for _, label := range conf.DropLabels {
+		filter.drop[attribute.Key(label)] = struct{}{}
+	}
+	for label, values := range conf.AllowedValues {
+		set := make(map[string]struct{}, len(values))
+		for _, value := range values {
+			set[value] = struct{}{}
+		}
+		filter.allowed[attribute.Key(label)] = set
+	}
+	if len(filter.drop) == 0 && len(filter.allowed) == 0 {
+		filter = nil
+	}
+
+	labelMu.Lock()
+	activeLabel = filter
+	labelMu.Unlock()
+	return nil
+}
+
+// apply removes dropped labels and replaces values outside the allowlist.
+func (f *labelFilter) apply(kvs []attribute.KeyValue) []attribute.KeyValue {
+	if f == nil {
+		return kvs
+	}
+	pruned := make([]attribute.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		if _, ok := f.drop[kv.Key]; ok {
+			continue
+		}
+		if allowed, ok := f.allowed[kv.Key]; ok {
+			if _, ok := allowed[kv.Value.Emit()]; !ok {
+				kv = attribute.String(string(kv.Key), OtherLabelValue)
+			}
+		}
+		pruned = append(pruned, kv)
+	}
+	return pruned
+}
+
+// withAttributes returns the measurement option for the given labels after
+// applying the configured label filter.
+func withAttributes(kvs ...attribute.KeyValue) instrument.MeasurementOption {
+	labelMu.RLock()
+	filter := activeLabel
+	labelMu.RUnlock()
+	return instrument.WithAttributes(filter.apply(kvs)...)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+)
+
+func TestLabelConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    LabelConfig
+		wantErr bool
+	}{
+		{name: "empty config", conf: LabelConfig{}},
+		{name: "valid config", conf: LabelConfig{DropLabels: []string{"verifier"}, AllowedValues: map[string][]string{"verifier_type": {"notation"}}}},
+		{name: "empty drop label", conf: LabelConfig{DropLabels: []string{""}}, wantErr: true},
+		{name: "empty allowed label", conf: LabelConfig{AllowedValues: map[string][]string{"": {"notation"}}}, wantErr: true},
+		{name: "empty allowlist", conf: LabelConfig{AllowedValues: map[string][]string{"verifier_type": {}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.conf.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetLabelConfig_InvalidConfig(t *testing.T) {
+	if err := SetLabelConfig(LabelConfig{DropLabels: []string{""}}); err == nil {
+		t.Fatalf("expected SetLabelConfig() to fail for an empty label")
+	}
+}
+
+func TestReportVerifierDuration_LabelsPruned(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+	conf := LabelConfig{
+		DropLabels: []string{"verifier", "subject"},
+		AllowedValues: map[string][]string{
+			"verifier_type":      {"notation", "cosign"},
+			"workload_namespace": {"default"},
+		},
+	}
+	if err := SetLabelConfig(conf); err != nil {
+		t.Fatalf("SetLabelConfig() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = SetLabelConfig(LabelConfig{})
+	})
+
+	mockDuration := &MockInt64Histogram{Attributes: make(map[string]string)}
+	verifierDuration = mockDuration
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportVerifierDuration(ctx, 5, "verifier-1234", "notation", "test_subject", true, false)
+
+	if len(mockDuration.Attributes) != 4 {
+		t.Fatalf("expected 4 attributes but got %d: %v", len(mockDuration.Attributes), mockDuration.Attributes)
+	}
+	for _, label := range conf.DropLabels {
+		if _, ok := mockDuration.Attributes[label]; ok {
+			t.Fatalf("expected %s attribute to be dropped", label)
+		}
+	}
+	if mockDuration.Attributes["verifier_type"] != "notation" {
+		t.Fatalf("expected verifier_type attribute to be notation but got %s", mockDuration.Attributes["verifier_type"])
+	}
+	if mockDuration.Attributes["workload_namespace"] != OtherLabelValue {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", OtherLabelValue, mockDuration.Attributes["workload_namespace"])
+	}
+	if mockDuration.Attributes["success"] != "true" {
+		t.Fatalf("expected success attribute to be true but got %s", mockDuration.Attributes["success"])
+	}
+}
+
+func TestReportRegistryRequestCount_NonStringAllowlist(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+	if err := SetLabelConfig(LabelConfig{AllowedValues: map[string][]string{"status_code": {"200", "429"}}}); err != nil {
+		t.Fatalf("SetLabelConfig() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = SetLabelConfig(LabelConfig{})
+	})
+
+	mockCount := &MockInt64Counter{Attributes: make(map[string]string)}
+	registryRequestCount = mockCount
+	ReportRegistryRequestCount(context.Background(), 429, "test_registry")
+	if mockCount.Attributes["status_code"] != "429" {
+		t.Fatalf("expected status_code attribute to be 429 but got %s", mockCount.Attributes["status_code"])
+	}
+	ReportRegistryRequestCount(context.Background(), 503, "test_registry")
+	if mockCount.Attributes["status_code"] != OtherLabelValue {
+		t.Fatalf("expected status_code attribute to be %s but got %s", OtherLabelValue, mockCount.Attributes["status_code"])
+	}
+}
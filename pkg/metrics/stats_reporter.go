@@ -34,6 +34,8 @@ var (
 	systemErrorCount     instrument.Int64Counter
 	registryRequestCount instrument.Int64Counter
 	cacheBlobCount       instrument.Int64Counter
+	decisionCount        instrument.Int64Counter
+	certExpiryCount      instrument.Int64Counter
 
 	// Azure Metrics
 	aadExchangeDuration    instrument.Int64Histogram
@@ -51,6 +53,8 @@ const (
 	metricNameSystemErrorCount     = "ratify_system_error_count"
 	metricNameRegistryRequestCount = "ratify_registry_request_count"
 	metricNameBlobCacheCount       = "ratify_blob_cache_count"
+	metricNameDecisionCount        = "ratify_verification_decision_count"
+	metricNameCertExpiryCount      = "ratify_certificate_expiry_warning_count"
 
 	// Azure Metrics
 	metricNameAADExchangeDuration    = "ratify_aad_exchange_duration"
@@ -179,6 +183,16 @@ func initStatsReporter() error {
 		logrus.Error(err)
 		return err
 	}
+	decisionCount, err = meter.Int64Counter(metricNameDecisionCount, instrument.WithDescription("verification decision count by reason"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	certExpiryCount, err = meter.Int64Counter(metricNameCertExpiryCount, instrument.WithDescription("count of verifications with a certificate expiring soon"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
 	return nil
 }
 
@@ -198,18 +212,23 @@ func ReportMutationRequest(ctx context.Context, duration int64) {
 
 // ReportVerifierDuration reports the duration of a single verifier's execution
 // Attributes:
-// verifierName: the name of the verifier
+// verifierName: the name of the verifier instance
+// verifierType: the type of the verifier
 // subjectReference: the subject reference of the verification
 // success: whether the verification succeeded
 // isError: whether the verification failed due to an error
 // workload_namespace: the namespace where workload is deployed
-func ReportVerifierDuration(ctx context.Context, duration int64, veriferName string, subjectReference string, success bool, isError bool) {
+func ReportVerifierDuration(ctx context.Context, duration int64, veriferName string, verifierType string, subjectReference string, success bool, isError bool) {
 	if verifierDuration != nil {
-		verifierDuration.Record(ctx, duration, instrument.WithAttributes(
+		verifierDuration.Record(ctx, duration, withAttributes(
 			attribute.KeyValue{
 				Key:   "verifier",
 				Value: attribute.StringValue(veriferName),
 			},
+			attribute.KeyValue{
+				Key:   "verifier_type",
+				Value: attribute.StringValue(verifierType),
+			},
 			attribute.KeyValue{
 				Key:   "subject",
 				Value: attribute.StringValue(subjectReference),
@@ -236,7 +255,7 @@ func ReportVerifierDuration(ctx context.Context, duration int64, veriferName str
 // workload_namespace: the namespace where workload is deployed
 func ReportSystemError(ctx context.Context, errorString string) {
 	if systemErrorCount != nil {
-		systemErrorCount.Add(ctx, 1, instrument.WithAttributes(
+		systemErrorCount.Add(ctx, 1, withAttributes(
 			attribute.KeyValue{Key: "error", Value: attribute.StringValue(errorString)},
 			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
 	}
@@ -249,7 +268,7 @@ func ReportSystemError(ctx context.Context, errorString string) {
 // workload_namespace: the namespace where workload is deployed
 func ReportRegistryRequestCount(ctx context.Context, statusCode int, registryHost string) {
 	if registryRequestCount != nil {
-		registryRequestCount.Add(ctx, 1, instrument.WithAttributes(
+		registryRequestCount.Add(ctx, 1, withAttributes(
 			attribute.KeyValue{Key: "status_code", Value: attribute.IntValue(statusCode)},
 			attribute.KeyValue{Key: "registry_host", Value: attribute.StringValue(registryHost)},
 			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
@@ -262,7 +281,7 @@ func ReportRegistryRequestCount(ctx context.Context, statusCode int, registryHos
 // workload_namespace: the namespace where workload is deployed
 func ReportAADExchangeDuration(ctx context.Context, duration int64, resourceType string) {
 	if aadExchangeDuration != nil {
-		aadExchangeDuration.Record(ctx, duration, instrument.WithAttributes(
+		aadExchangeDuration.Record(ctx, duration, withAttributes(
 			attribute.KeyValue{Key: "resource_type", Value: attribute.StringValue(resourceType)},
 			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
 	}
@@ -274,7 +293,7 @@ func ReportAADExchangeDuration(ctx context.Context, duration int64, resourceType
 // workload_namespace: the namespace where workload is deployed
 func ReportACRExchangeDuration(ctx context.Context, duration int64, repository string) {
 	if acrExchangeDuration != nil {
-		acrExchangeDuration.Record(ctx, duration, instrument.WithAttributes(
+		acrExchangeDuration.Record(ctx, duration, withAttributes(
 			attribute.KeyValue{Key: "repository", Value: attribute.StringValue(repository)},
 			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
 	}
@@ -286,7 +305,7 @@ func ReportACRExchangeDuration(ctx context.Context, duration int64, repository s
 // workload_namespace: the namespace where workload is deployed
 func ReportAKVCertificateDuration(ctx context.Context, duration int64, certificateName string) {
 	if akvCertificateDuration != nil {
-		akvCertificateDuration.Record(ctx, duration, instrument.WithAttributes(
+		akvCertificateDuration.Record(ctx, duration, withAttributes(
 			attribute.KeyValue{Key: "certificate_name", Value: attribute.StringValue(certificateName)},
 			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
 	}
@@ -298,8 +317,36 @@ func ReportAKVCertificateDuration(ctx context.Context, duration int64, certifica
 // workload_namespace: the namespace where workload is deployed
 func ReportBlobCacheCount(ctx context.Context, hit bool) {
 	if cacheBlobCount != nil {
-		cacheBlobCount.Add(ctx, 1, instrument.WithAttributes(
+		cacheBlobCount.Add(ctx, 1, withAttributes(
 			attribute.KeyValue{Key: "hit", Value: attribute.BoolValue(hit)},
 			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
 	}
 }
+
+// ReportVerificationDecision reports the final decision of a verification
+// Attributes:
+// success: whether the subject passed verification
+// reason: the reason code of the decision, one of a bounded set of values
+// degraded: whether the subject was allowed without verification by the open failure policy
+// workload_namespace: the namespace where workload is deployed
+func ReportVerificationDecision(ctx context.Context, success bool, reason string, degraded bool) {
+	if decisionCount != nil {
+		decisionCount.Add(ctx, 1, withAttributes(
+			attribute.KeyValue{Key: "success", Value: attribute.BoolValue(success)},
+			attribute.KeyValue{Key: "reason", Value: attribute.StringValue(reason)},
+			attribute.KeyValue{Key: "degraded", Value: attribute.BoolValue(degraded)},
+			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
+	}
+}
+
+// ReportCertificateExpiryWarning reports a verification whose certificate expires within the warning window
+// Attributes:
+// verifier: the name of the verifier
+// workload_namespace: the namespace where workload is deployed
+func ReportCertificateExpiryWarning(ctx context.Context, verifierName string) {
+	if certExpiryCount != nil {
+		certExpiryCount.Add(ctx, 1, withAttributes(
+			attribute.KeyValue{Key: "verifier", Value: attribute.StringValue(verifierName)},
+			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
+	}
+}
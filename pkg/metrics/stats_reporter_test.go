@@ -99,16 +99,19 @@ func TestReportVerifierDuration(t *testing.T) {
 	mockDuration := &MockInt64Histogram{Attributes: make(map[string]string)}
 	verifierDuration = mockDuration
 	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
-	ReportVerifierDuration(ctx, 5, "test_verifier", "test_subject", true, true)
+	ReportVerifierDuration(ctx, 5, "test_verifier", "test_type", "test_subject", true, true)
 	if mockDuration.Value != 5 {
 		t.Fatalf("ReportVerifierDuration() mockDuration.Value = %v, expected %v", mockDuration.Value, 5)
 	}
-	if len(mockDuration.Attributes) != 5 {
-		t.Fatalf("ReportVerifierDuration() len(mockDuration.Attributes) = %v, expected %v", len(mockDuration.Attributes), 2)
+	if len(mockDuration.Attributes) != 6 {
+		t.Fatalf("ReportVerifierDuration() len(mockDuration.Attributes) = %v, expected %v", len(mockDuration.Attributes), 6)
 	}
 	if mockDuration.Attributes["verifier"] != "test_verifier" {
 		t.Fatalf("expected verifer attribute to be test_verifier but got %s", mockDuration.Attributes["verifier"])
 	}
+	if mockDuration.Attributes["verifier_type"] != "test_type" {
+		t.Fatalf("expected verifier_type attribute to be test_type but got %s", mockDuration.Attributes["verifier_type"])
+	}
 	if mockDuration.Attributes["subject"] != "test_subject" {
 		t.Fatalf("expected subject attribute to be test_subject but got %s", mockDuration.Attributes["subject"])
 	}
@@ -260,3 +263,48 @@ func TestReportBlobCacheCount(t *testing.T) {
 		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespac"])
 	}
 }
+
+func TestReportVerificationDecision(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	decisionCount = mockCounter
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportVerificationDecision(ctx, false, "signature-invalid", false)
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportVerificationDecision() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if len(mockCounter.Attributes) != 4 {
+		t.Fatalf("ReportVerificationDecision() len(mockCounter.Attributes) = %v, expected %v", len(mockCounter.Attributes), 4)
+	}
+	if mockCounter.Attributes["success"] != "false" {
+		t.Fatalf("expected success attribute to be false but got %s", mockCounter.Attributes["success"])
+	}
+	if mockCounter.Attributes["reason"] != "signature-invalid" {
+		t.Fatalf("expected reason attribute to be signature-invalid but got %s", mockCounter.Attributes["reason"])
+	}
+	if mockCounter.Attributes["degraded"] != "false" {
+		t.Fatalf("expected degraded attribute to be false but got %s", mockCounter.Attributes["degraded"])
+	}
+	if mockCounter.Attributes["workload_namespace"] != testNamespace {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespace"])
+	}
+}
+
+func TestReportCertificateExpiryWarning(t *testing.T) {
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	certExpiryCount = mockCounter
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportCertificateExpiryWarning(ctx, "notation")
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportCertificateExpiryWarning() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["verifier"] != "notation" {
+		t.Fatalf("expected verifier attribute to be notation but got %s", mockCounter.Attributes["verifier"])
+	}
+	if mockCounter.Attributes["workload_namespace"] != testNamespace {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespace"])
+	}
+}
@@ -21,6 +21,16 @@ import (
 
 const MediaTypeArtifactManifest = "application/vnd.oci.artifact.manifest.v1+json"
 
+// MediaTypeDockerManifest is the media type of Docker image manifests (schema 2)
+const MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+const MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+// SubjectArtifactType is the artifact type verifiers are configured with to
+// verify the subject itself once per subject, e.g. its image config, instead
+// of its referrers. The verifiers are passed the descriptor of the subject.
+const SubjectArtifactType = "application/vnd.ratify.subject.v1"
+
 // ReferenceDescriptor represents a descriptor for an artifact manifest
 type ReferenceDescriptor struct {
 	oci.Descriptor
@@ -35,6 +45,11 @@ type ReferenceManifest struct {
 	Blobs        []oci.Descriptor  `json:"blobs"`
 	Subject      *oci.Descriptor   `json:"subject,omitempty"`
 	Annotations  map[string]string `json:"annotations,omitempty"`
+	// Config is the config descriptor of image manifests.
+	Config *oci.Descriptor `json:"config,omitempty"`
+	// Manifests are the platform manifests of image indexes and Docker
+	// manifest lists.
+	Manifests []oci.Descriptor `json:"manifests,omitempty"`
 }
 
 type SubjectDescriptor struct {
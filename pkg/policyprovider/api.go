@@ -37,3 +37,86 @@ type PolicyProvider interface {
 	// GetPolicyType returns the type of the policy.
 	GetPolicyType(ctx context.Context) string
 }
+
+// SubjectPolicyProvider is an optional interface implemented by policy providers
+// that also evaluate the metadata of the verified subject, such as its manifest
+// annotations.
+type SubjectPolicyProvider interface {
+	// OverallVerifySubjectResult determines the final outcome of verification
+	// from the subject metadata and the results of individual verifications.
+	OverallVerifySubjectResult(ctx context.Context, subject types.Subject, verifierReports []interface{}) bool
+}
+
+// NoMatchPolicyProvider is an optional interface implemented by policy providers
+// that decide the outcome of a subject no verifier produced a report for. It
+// is only applied to the subject of the request, not to nested subjects.
+type NoMatchPolicyProvider interface {
+	// NoMatchVerifyResult returns the verify result for a subject without any
+	// verifier report and whether a default outcome is configured.
+	NoMatchVerifyResult(ctx context.Context, subjectRefString string) (types.VerifyResult, bool)
+}
+
+// PolicyExplainer is an optional interface implemented by policy providers that
+// can describe how the overall verification result was derived.
+type PolicyExplainer interface {
+	// ExplainVerifyResult returns the derivation of the overall verification
+	// result for the given subject and verifier reports.
+	ExplainVerifyResult(ctx context.Context, subject types.Subject, verifierReports []interface{}) types.PolicyDerivation
+}
+
+// VerifierConditionProvider is an optional interface implemented by policy
+// providers that apply or skip verifiers depending on the metadata of the
+// verified subject, such as its labels or annotations.
+type VerifierConditionProvider interface {
+	// HasVerifierConditions returns true if any verifier condition is
+	// configured.
+	HasVerifierConditions(ctx context.Context) bool
+	// SkipVerifier returns true and the reason if the verifier must not verify
+	// the referrers of the subject.
+	SkipVerifier(ctx context.Context, subject types.Subject, verifierName, verifierType string) (bool, string)
+}
+
+// ScoringPolicyProvider is an optional interface implemented by policy
+// providers that gate on a minimum score computed from the verifier reports.
+type ScoringPolicyProvider interface {
+	// ScoreVerifyResult returns the score of the subject and its breakdown.
+	ScoreVerifyResult(ctx context.Context, subject types.Subject, verifierReports []interface{}) types.ScoreReport
+}
+
+// RequestContextPolicyProvider is an optional interface implemented by policy
+// providers whose decision may depend on the context of the admission request.
+// Results of policies not implementing it are independent of the request
+// context.
+type RequestContextPolicyProvider interface {
+	// UsesRequestContext returns true if the policy may read the request
+	// context.
+	UsesRequestContext(ctx context.Context) bool
+}
+
+// SubjectManifestPolicyProvider is an optional interface implemented by policy
+// providers whose decision may depend on the annotations or the artifact type
+// of the subject manifest. The subject manifest is not fetched for policies
+// not implementing it.
+type SubjectManifestPolicyProvider interface {
+	// UsesSubjectManifest returns true if the policy may read the annotations
+	// or the artifact type of the subject.
+	UsesSubjectManifest(ctx context.Context) bool
+}
+
+// SignedReferrerPolicyProvider is an optional interface implemented by policy
+// providers that require referrers of some artifact types to be signed
+// themselves.
+type SignedReferrerPolicyProvider interface {
+	// RequiresSignedReferrer returns true if the referrers of the given
+	// referrer must be verified to check its signature.
+	RequiresSignedReferrer(ctx context.Context, referenceDesc ocispecs.ReferenceDescriptor) bool
+}
+
+// VerifierNamesPolicyProvider is an optional interface implemented by policy
+// providers referencing verifiers by name, so that references to verifiers
+// that are not configured are rejected when the configuration is loaded.
+type VerifierNamesPolicyProvider interface {
+	// ValidateVerifierNames returns an error if the policy references a
+	// verifier that is not among the given verifier names.
+	ValidateVerifierNames(ctx context.Context, verifierNames []string) error
+}
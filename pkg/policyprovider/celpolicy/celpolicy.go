@@ -0,0 +1,335 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package celpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	celtypes "github.com/google/cel-go/common/types"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/policyprovider"
+	"github.com/ratify-project/ratify/pkg/policyprovider/config"
+	pf "github.com/ratify-project/ratify/pkg/policyprovider/factory"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+const (
+	subjectVariable = "subject"
+	reportsVariable = "reports"
+	requestVariable = "request"
+)
+
+// PolicyEnforcer passes the subject if the CEL expression evaluates to true
+// over the subject metadata, the verifier reports and the admission request
+// context, e.g. reports.exists(r, r.verifierType == "notation" && r.isSuccess)
+// || request.namespace != "prod".
+type PolicyEnforcer struct {
+	// Expression is the CEL expression deciding the verification result.
+	Expression string
+	// Message is the reason reported when the expression denies the subject.
+	Message string
+	program cel.Program
+	// usesSubjectManifest is set if the expression may read the annotations or
+	// the artifact type of the subject.
+	usesSubjectManifest bool
+}
+
+// subjectManifestFields are the fields of the subject variable read from the
+// subject manifest.
+var subjectManifestFields = []string{"annotations", "artifactType"}
+
+type celPolicyEnforcerConf struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+}
+
+type celPolicyFactory struct{}
+
+// init calls Register for our CEL policy provider
+func init() {
+	pf.Register(vt.CELPolicy, &celPolicyFactory{})
+}
+
+// Create initializes a new CEL policy provider from the policy config
+func (f *celPolicyFactory) Create(policyConfig config.PolicyPluginConfig) (policyprovider.PolicyProvider, error) {
+	conf := celPolicyEnforcerConf{}
+	policyProviderConfigBytes, err := json.Marshal(policyConfig)
+	if err != nil {
+		return nil, re.ErrorCodeDataEncodingFailure.NewError(re.PolicyProvider, vt.CELPolicy, re.PolicyProviderLink, err, "failed to marshal policy config", re.HideStackTrace)
+	}
+	if err := json.Unmarshal(policyProviderConfigBytes, &conf); err != nil {
+		return nil, re.ErrorCodeDataDecodingFailure.NewError(re.PolicyProvider, vt.CELPolicy, re.PolicyProviderLink, err, "failed to unmarshal policy config", re.HideStackTrace)
+	}
+	if conf.Expression == "" {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.CELPolicy, re.PolicyProviderLink, nil, "expression must not be empty", re.HideStackTrace)
+	}
+
+	ast, program, err := compile(conf.Expression)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.CELPolicy, re.PolicyProviderLink, err, "failed to compile the CEL expression", re.HideStackTrace)
+	}
+	return &PolicyEnforcer{
+		Expression:          conf.Expression,
+		Message:             conf.Message,
+		program:             program,
+		usesSubjectManifest: readsSubjectManifest(ast),
+	}, nil
+}
+
+// compile type checks the expression against the policy input and returns its
+// checked AST and the program evaluating it.
+func compile(expression string) (*cel.Ast, cel.Program, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(subjectVariable, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(reportsVariable, cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+		cel.Variable(requestVariable, cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, nil, fmt.Errorf("expression must evaluate to a bool, got %s", ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ast, program, nil
+}
+
+// VerifyNeeded determines if the given subject/reference artifact should be verified
+func (enforcer PolicyEnforcer) VerifyNeeded(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor) bool {
+	return true
+}
+
+// ContinueVerifyOnFailure always continues verification since the expression
+// is evaluated over all the verifier reports.
+func (enforcer PolicyEnforcer) ContinueVerifyOnFailure(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor, _ types.VerifyResult) bool {
+	return true
+}
+
+// ErrorToVerifyResult converts an error to a properly formatted verify result
+func (enforcer PolicyEnforcer) ErrorToVerifyResult(_ context.Context, subjectRefString string, verifyError error) types.VerifyResult {
+	verifierErr := re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("failed to verify artifact: %s", subjectRefString)).WithError(verifyError)
+	errorReport := verifier.NewVerifierResult(subjectRefString, "", "", "", false, &verifierErr, nil)
+	return types.VerifyResult{IsSuccess: false, VerifierReports: []interface{}{errorReport}}
+}
+
+// OverallVerifyResult evaluates the expression over the verifier reports with
+// an empty subject.
+func (enforcer PolicyEnforcer) OverallVerifyResult(ctx context.Context, verifierReports []interface{}) bool {
+	return enforcer.OverallVerifySubjectResult(ctx, types.Subject{}, verifierReports)
+}
+
+// OverallVerifySubjectResult evaluates the expression over the subject
+// metadata and the verifier reports.
+func (enforcer PolicyEnforcer) OverallVerifySubjectResult(ctx context.Context, subject types.Subject, verifierReports []interface{}) bool {
+	isSuccess, _ := enforcer.evaluate(ctx, subject, verifierReports)
+	return isSuccess
+}
+
+// ExplainVerifyResult returns the expression and the reason of the overall
+// verification result.
+func (enforcer PolicyEnforcer) ExplainVerifyResult(ctx context.Context, subject types.Subject, verifierReports []interface{}) types.PolicyDerivation {
+	_, reason := enforcer.evaluate(ctx, subject, verifierReports)
+	return types.PolicyDerivation{
+		PolicyType:  vt.CELPolicy,
+		Input:       policyInput(subject, verifierReports),
+		MatchedRule: enforcer.Expression,
+		Reason:      reason,
+	}
+}
+
+// GetPolicyType returns the type of the policy.
+func (enforcer PolicyEnforcer) GetPolicyType(_ context.Context) string {
+	return vt.CELPolicy
+}
+
+// evaluate returns the decision of the expression and a human-readable
+// reason. The subject is denied if the evaluation fails.
+func (enforcer PolicyEnforcer) evaluate(ctx context.Context, subject types.Subject, verifierReports []interface{}) (bool, string) {
+	if enforcer.program == nil {
+		return false, "the CEL expression is not compiled"
+	}
+	out, _, err := enforcer.program.ContextEval(ctx, policyInput(subject, verifierReports))
+	if err != nil {
+		return false, fmt.Sprintf("failed to evaluate expression %q: %v", enforcer.Expression, err)
+	}
+	isSuccess, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Sprintf("expression %q evaluated to a non-bool value %v", enforcer.Expression, out.Value())
+	}
+	if isSuccess {
+		return true, fmt.Sprintf("expression %q evaluated to true", enforcer.Expression)
+	}
+	if enforcer.Message != "" {
+		return false, enforcer.Message
+	}
+	return false, fmt.Sprintf("expression %q evaluated to false", enforcer.Expression)
+}
+
+// UsesRequestContext returns true if the expression may read the request
+// variable. The expression is checked conservatively for any mention of it.
+func (enforcer PolicyEnforcer) UsesRequestContext(_ context.Context) bool {
+	return strings.Contains(enforcer.Expression, requestVariable)
+}
+
+// UsesSubjectManifest returns true if the expression may read the annotations
+// or the artifact type of the subject.
+func (enforcer PolicyEnforcer) UsesSubjectManifest(_ context.Context) bool {
+	return enforcer.usesSubjectManifest
+}
+
+// readsSubjectManifest returns true if the checked expression selects the
+// annotations or the artifact type of the subject variable. Uses of the
+// subject as a whole, e.g. iterating over it, or of a field selected by a
+// computed key may read them too.
+func readsSubjectManifest(ast *cel.Ast) bool {
+	reads := false
+	// the subject identifiers whose selected field is known not to be read
+	// from the subject manifest
+	selected := map[int64]bool{}
+	celast.PreOrderVisit(ast.NativeRep().Expr(), celast.NewExprVisitor(func(expr celast.Expr) {
+		switch expr.Kind() {
+		case celast.SelectKind:
+			sel := expr.AsSelect()
+			if isSubject(sel.Operand()) {
+				reads = reads || slices.Contains(subjectManifestFields, sel.FieldName())
+				selected[sel.Operand().ID()] = true
+			}
+		case celast.CallKind:
+			call := expr.AsCall()
+			switch call.FunctionName() {
+			case operators.Index, operators.OptIndex, operators.OptSelect:
+				if args := call.Args(); len(args) == 2 && isSubject(args[0]) {
+					field, ok := fieldName(args[1])
+					reads = reads || !ok || slices.Contains(subjectManifestFields, field)
+					selected[args[0].ID()] = true
+				}
+			}
+		case celast.IdentKind:
+			reads = reads || (isSubject(expr) && !selected[expr.ID()])
+		}
+	}))
+	return reads
+}
+
+// isSubject returns true if the expression is the subject variable.
+func isSubject(expr celast.Expr) bool {
+	return expr.Kind() == celast.IdentKind && expr.AsIdent() == subjectVariable
+}
+
+// fieldName returns the field a constant string key selects.
+func fieldName(expr celast.Expr) (string, bool) {
+	if expr.Kind() != celast.LiteralKind {
+		return "", false
+	}
+	field, ok := expr.AsLiteral().(celtypes.String)
+	return string(field), ok
+}
+
+// policyInput returns the variables the expression is evaluated against. The
+// reports of nested artifacts are flattened into the list of reports. The
+// request context fields are always set, empty if the subject is not verified
+// for an admission request.
+func policyInput(subject types.Subject, verifierReports []interface{}) map[string]interface{} {
+	annotations := map[string]interface{}{}
+	for key, value := range subject.Annotations {
+		annotations[key] = value
+	}
+	labels := map[string]interface{}{}
+	for key, value := range subject.Labels {
+		labels[key] = value
+	}
+	reports := []interface{}{}
+	for _, report := range verifierReports {
+		reports = appendReports(reports, report)
+	}
+	return map[string]interface{}{
+		subjectVariable: map[string]interface{}{
+			"reference":    subject.Reference,
+			"digest":       subject.Digest,
+			"artifactType": subject.ArtifactType,
+			"annotations":  annotations,
+			"labels":       labels,
+		},
+		reportsVariable: reports,
+		requestVariable: requestInput(subject.Request),
+	}
+}
+
+func requestInput(request *types.RequestContext) map[string]interface{} {
+	if request == nil {
+		request = &types.RequestContext{}
+	}
+	labels := map[string]interface{}{}
+	for key, value := range request.Labels {
+		labels[key] = value
+	}
+	return map[string]interface{}{
+		"namespace": request.Namespace,
+		"labels":    labels,
+		"user":      request.User,
+	}
+}
+
+func appendReports(reports []interface{}, report interface{}) []interface{} {
+	switch r := report.(type) {
+	case verifier.VerifierResult:
+		reports = append(reports, reportInput(r.Subject, r.ArtifactType, r.ReferenceDigest, r.VerifierName, r.VerifierType, r.IsSuccess, r.GetLevel(), r.Message, r.ErrorReason))
+		for _, nested := range r.NestedResults {
+			reports = appendReports(reports, nested)
+		}
+	case types.NestedVerifierReport:
+		for _, result := range r.VerifierReports {
+			reports = append(reports, reportInput(r.Subject, r.ArtifactType, r.ReferenceDigest, result.VerifierName, result.VerifierType, result.IsSuccess, result.GetLevel(), result.Message, result.ErrorReason))
+		}
+		for _, nested := range r.NestedReports {
+			reports = appendReports(reports, nested)
+		}
+	}
+	return reports
+}
+
+func reportInput(subject, artifactType, referenceDigest, verifierName, verifierType string, isSuccess bool, level, message, errorReason string) map[string]interface{} {
+	return map[string]interface{}{
+		"subject":         subject,
+		"artifactType":    artifactType,
+		"referenceDigest": referenceDigest,
+		"verifierName":    verifierName,
+		"verifierType":    verifierType,
+		"isSuccess":       isSuccess,
+		"level":           level,
+		"message":         message,
+		"errorReason":     errorReason,
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package celpolicy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	pc "github.com/ratify-project/ratify/pkg/policyprovider/config"
+	pf "github.com/ratify-project/ratify/pkg/policyprovider/factory"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+	verifierTypes "github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+func newReport(name, verifierType, artifactType string, isSuccess bool) vr.VerifierResult {
+	return vr.VerifierResult{VerifierName: name, VerifierType: verifierType, ArtifactType: artifactType, IsSuccess: isSuccess}
+}
+
+func newEnforcer(t *testing.T, expression, message string) PolicyEnforcer {
+	t.Helper()
+	provider, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{PolicyPlugin: pc.PolicyPluginConfig{
+		"name":       vt.CELPolicy,
+		"expression": expression,
+		"message":    message,
+	}})
+	if err != nil {
+		t.Fatalf("failed to create the policy provider: %v", err)
+	}
+	return *provider.(*PolicyEnforcer)
+}
+
+func TestOverallVerifySubjectResult(t *testing.T) {
+	subject := types.Subject{
+		Reference:    "localhost:5000/net-monitor:v1",
+		Digest:       "sha256:1234",
+		ArtifactType: "application/vnd.oci.image.config.v1+json",
+		Annotations:  map[string]string{"org.opencontainers.image.source": "https://github.com/example/net-monitor"},
+		Labels:       map[string]string{"tier": "base"},
+	}
+	tests := []struct {
+		name          string
+		expression    string
+		reports       []interface{}
+		expectSuccess bool
+	}{
+		{
+			name:       "any successful notation signature",
+			expression: `reports.exists(r, r.verifierType == "notation" && r.isSuccess)`,
+			reports: []interface{}{
+				newReport("signature", "notation", "application/vnd.cncf.notary.signature", false),
+				newReport("signature", "notation", "application/vnd.cncf.notary.signature", true),
+			},
+			expectSuccess: true,
+		},
+		{
+			name:       "no successful notation signature",
+			expression: `reports.exists(r, r.verifierType == "notation" && r.isSuccess)`,
+			reports: []interface{}{
+				newReport("signature", "notation", "application/vnd.cncf.notary.signature", false),
+				newReport("sbom", "sbom", "application/spdx+json", true),
+			},
+			expectSuccess: false,
+		},
+		{
+			name:       "all reports succeed",
+			expression: `size(reports) > 0 && reports.all(r, r.isSuccess)`,
+			reports: []interface{}{
+				newReport("signature", "notation", "application/vnd.cncf.notary.signature", true),
+				newReport("sbom", "sbom", "application/spdx+json", true),
+			},
+			expectSuccess: true,
+		},
+		{
+			name:          "no reports",
+			expression:    `size(reports) > 0 && reports.all(r, r.isSuccess)`,
+			expectSuccess: false,
+		},
+		{
+			name:          "subject annotation",
+			expression:    `subject.annotations["org.opencontainers.image.source"].startsWith("https://github.com/example/")`,
+			expectSuccess: true,
+		},
+		{
+			name:       "base images only need a signature",
+			expression: `(has(subject.labels.tier) && subject.labels.tier == "base") || reports.exists(r, r.verifierName == "vulnerability" && r.isSuccess)`,
+			reports: []interface{}{
+				newReport("vulnerability", "vulnerabilityreport", "application/sarif+json", false),
+			},
+			expectSuccess: true,
+		},
+		{
+			name:       "nested reports are flattened",
+			expression: `reports.exists(r, r.artifactType == "application/spdx+json" && r.verifierType == "notation" && r.isSuccess)`,
+			reports: []interface{}{
+				types.NestedVerifierReport{
+					ArtifactType: "application/spdx+json",
+					VerifierReports: []verifierTypes.VerifierResult{
+						{VerifierName: "signature", VerifierType: "notation", IsSuccess: true},
+					},
+				},
+			},
+			expectSuccess: true,
+		},
+		{
+			name:          "evaluation error denies the subject",
+			expression:    `subject.annotations["missing"] == "value"`,
+			expectSuccess: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enforcer := newEnforcer(t, tt.expression, "")
+			if success := enforcer.OverallVerifySubjectResult(context.Background(), subject, tt.reports); success != tt.expectSuccess {
+				t.Fatalf("expected success %v, got %v", tt.expectSuccess, success)
+			}
+		})
+	}
+}
+
+func TestOverallVerifySubjectResult_RequestContext(t *testing.T) {
+	expression := `(request.namespace != "prod" && !(has(request.labels.tier) && request.labels.tier == "critical")) || (size(reports) > 0 && reports.all(r, r.isSuccess))`
+	tests := []struct {
+		name          string
+		request       *types.RequestContext
+		reports       []interface{}
+		expectSuccess bool
+	}{
+		{
+			name:          "unsigned subject in dev namespace",
+			request:       &types.RequestContext{Namespace: "dev", Labels: map[string]string{"tier": "web"}},
+			expectSuccess: true,
+		},
+		{
+			name:          "unsigned subject in prod namespace",
+			request:       &types.RequestContext{Namespace: "prod"},
+			expectSuccess: false,
+		},
+		{
+			name:          "unsigned subject with critical label",
+			request:       &types.RequestContext{Namespace: "dev", Labels: map[string]string{"tier": "critical"}},
+			expectSuccess: false,
+		},
+		{
+			name:    "signed subject in prod namespace",
+			request: &types.RequestContext{Namespace: "prod", Labels: map[string]string{"tier": "critical"}},
+			reports: []interface{}{
+				newReport("signature", "notation", "application/vnd.cncf.notary.signature", true),
+			},
+			expectSuccess: true,
+		},
+		{
+			name:          "no request context",
+			expectSuccess: true,
+		},
+	}
+	enforcer := newEnforcer(t, expression, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := types.Subject{Reference: "localhost:5000/net-monitor:v1", Request: tt.request}
+			if success := enforcer.OverallVerifySubjectResult(context.Background(), subject, tt.reports); success != tt.expectSuccess {
+				t.Fatalf("expected success %v, got %v", tt.expectSuccess, success)
+			}
+		})
+	}
+}
+
+func TestUsesRequestContext(t *testing.T) {
+	if !newEnforcer(t, `request.namespace != "prod"`, "").UsesRequestContext(context.Background()) {
+		t.Fatalf("expected an expression reading the request to use the request context")
+	}
+	if newEnforcer(t, `reports.all(r, r.isSuccess)`, "").UsesRequestContext(context.Background()) {
+		t.Fatalf("expected an expression not reading the request not to use the request context")
+	}
+}
+
+func TestUsesSubjectManifest(t *testing.T) {
+	testCases := []struct {
+		expression string
+		expected   bool
+	}{
+		{expression: `subject.annotations["org.example.team"] == "payments"`, expected: true},
+		{expression: `subject.artifactType == "application/vnd.oci.image.manifest.v1+json"`, expected: true},
+		{expression: `subject["annotations"].size() > 0`, expected: true},
+		{expression: `has(subject.annotations) && reports.all(r, r.isSuccess)`, expected: true},
+		{expression: `subject.exists(k, k == "digest")`, expected: true},
+		{expression: `reports.all(r, r.isSuccess)`, expected: false},
+		{expression: `reports.exists(r, r.artifactType == "application/vnd.cncf.notary.signature" && r.isSuccess)`, expected: false},
+		{expression: `subject.labels["annotations"] == "artifactType" && subject["digest"] != ""`, expected: false},
+	}
+	for _, tc := range testCases {
+		if uses := newEnforcer(t, tc.expression, "").UsesSubjectManifest(context.Background()); uses != tc.expected {
+			t.Fatalf("expected expression %s to use the subject manifest %v, got %v", tc.expression, tc.expected, uses)
+		}
+	}
+}
+
+func TestExplainVerifyResult(t *testing.T) {
+	expression := `reports.exists(r, r.verifierType == "cosign" && r.isSuccess)`
+	reports := []interface{}{newReport("signature", "notation", "application/vnd.cncf.notary.signature", true)}
+
+	enforcer := newEnforcer(t, expression, "")
+	derivation := enforcer.ExplainVerifyResult(context.Background(), types.Subject{}, reports)
+	if derivation.PolicyType != vt.CELPolicy || derivation.MatchedRule != expression {
+		t.Fatalf("unexpected derivation %+v", derivation)
+	}
+	if !strings.Contains(derivation.Reason, "evaluated to false") {
+		t.Fatalf("expected the reason to state the expression evaluated to false, got %q", derivation.Reason)
+	}
+
+	enforcer = newEnforcer(t, expression, "a valid cosign signature is required")
+	if derivation = enforcer.ExplainVerifyResult(context.Background(), types.Subject{}, reports); derivation.Reason != "a valid cosign signature is required" {
+		t.Fatalf("expected the configured message as the reason, got %q", derivation.Reason)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      pc.PolicyPluginConfig
+		expectError bool
+	}{
+		{
+			name: "valid config",
+			config: pc.PolicyPluginConfig{
+				"name":       vt.CELPolicy,
+				"expression": "reports.all(r, r.isSuccess)",
+			},
+		},
+		{
+			name: "missing expression",
+			config: pc.PolicyPluginConfig{
+				"name": vt.CELPolicy,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid syntax",
+			config: pc.PolicyPluginConfig{
+				"name":       vt.CELPolicy,
+				"expression": "reports.all(r, ",
+			},
+			expectError: true,
+		},
+		{
+			name: "undeclared variable",
+			config: pc.PolicyPluginConfig{
+				"name":       vt.CELPolicy,
+				"expression": "input.isSuccess",
+			},
+			expectError: true,
+		},
+		{
+			name: "non-bool expression",
+			config: pc.PolicyPluginConfig{
+				"name":       vt.CELPolicy,
+				"expression": "size(reports)",
+			},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{PolicyPlugin: tt.config})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
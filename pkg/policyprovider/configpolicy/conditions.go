@@ -0,0 +1,199 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+)
+
+// VerifierCondition applies or skips verifiers depending on the labels and
+// annotations of the subject.
+type VerifierCondition struct {
+	// Verifiers are the names or types of the verifiers the condition applies
+	// to.
+	Verifiers []string
+	// SkipWhen skips the verifiers for the subjects it matches.
+	SkipWhen *SubjectPredicate
+	// ApplyWhen skips the verifiers for the subjects it does not match.
+	ApplyWhen *SubjectPredicate
+}
+
+// SubjectPredicate maps subject label and annotation keys to the pattern
+// their value must match.
+type SubjectPredicate struct {
+	Labels      map[string]*regexp.Regexp
+	Annotations map[string]*regexp.Regexp
+}
+
+// MarshalJSON marshals the predicate with the source of its patterns, which
+// compiled patterns do not marshal.
+func (predicate SubjectPredicate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Labels      map[string]string
+		Annotations map[string]string
+	}{patternSources(predicate.Labels), patternSources(predicate.Annotations)})
+}
+
+// patternSources returns the source of the patterns.
+func patternSources(patterns map[string]*regexp.Regexp) map[string]string {
+	if patterns == nil {
+		return nil
+	}
+	sources := make(map[string]string, len(patterns))
+	for key, pattern := range patterns {
+		sources[key] = pattern.String()
+	}
+	return sources
+}
+
+// newVerifierCondition validates and compiles the verifier condition.
+func newVerifierCondition(condition vt.VerifierCondition) (VerifierCondition, error) {
+	if len(condition.Verifiers) == 0 {
+		return VerifierCondition{}, fmt.Errorf("verifier condition has no verifiers")
+	}
+	if condition.SkipWhen == nil && condition.ApplyWhen == nil {
+		return VerifierCondition{}, fmt.Errorf("verifier condition for verifiers %s has neither skipWhen nor applyWhen", strings.Join(condition.Verifiers, ", "))
+	}
+	compiled := VerifierCondition{Verifiers: condition.Verifiers}
+	var err error
+	if condition.SkipWhen != nil {
+		if compiled.SkipWhen, err = newSubjectPredicate(*condition.SkipWhen); err != nil {
+			return VerifierCondition{}, fmt.Errorf("invalid skipWhen of verifier condition for verifiers %s: %w", strings.Join(condition.Verifiers, ", "), err)
+		}
+	}
+	if condition.ApplyWhen != nil {
+		if compiled.ApplyWhen, err = newSubjectPredicate(*condition.ApplyWhen); err != nil {
+			return VerifierCondition{}, fmt.Errorf("invalid applyWhen of verifier condition for verifiers %s: %w", strings.Join(condition.Verifiers, ", "), err)
+		}
+	}
+	return compiled, nil
+}
+
+func newSubjectPredicate(predicate vt.SubjectPredicate) (*SubjectPredicate, error) {
+	if len(predicate.Labels) == 0 && len(predicate.Annotations) == 0 {
+		return nil, fmt.Errorf("predicate has no labels or annotations")
+	}
+	compiled := &SubjectPredicate{}
+	var err error
+	if compiled.Labels, err = compilePatterns("label", predicate.Labels); err != nil {
+		return nil, err
+	}
+	if compiled.Annotations, err = compilePatterns("annotation", predicate.Annotations); err != nil {
+		return nil, err
+	}
+	return compiled, nil
+}
+
+func compilePatterns(kind string, patterns map[string]string) (map[string]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	compiled := make(map[string]*regexp.Regexp, len(patterns))
+	for key, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for %s %s: %w", kind, key, err)
+		}
+		compiled[key] = re
+	}
+	return compiled, nil
+}
+
+// match returns true if the labels and annotations of the subject match all
+// the patterns, along with a description of the matched values or of the
+// first mismatch.
+func (predicate *SubjectPredicate) match(subject types.Subject) (bool, string) {
+	var matched []string
+	for _, values := range []struct {
+		kind     string
+		patterns map[string]*regexp.Regexp
+		values   map[string]string
+	}{
+		{kind: "label", patterns: predicate.Labels, values: subject.Labels},
+		{kind: "annotation", patterns: predicate.Annotations, values: subject.Annotations},
+	} {
+		keys := make([]string, 0, len(values.patterns))
+		for key := range values.patterns {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := values.values[key]
+			if !ok {
+				return false, fmt.Sprintf("subject %s %s is missing", values.kind, key)
+			}
+			if !values.patterns[key].MatchString(value) {
+				return false, fmt.Sprintf("subject %s %s=%q does not match %s", values.kind, key, value, values.patterns[key])
+			}
+			matched = append(matched, fmt.Sprintf("%s %s=%q", values.kind, key, value))
+		}
+	}
+	return true, "subject " + strings.Join(matched, ", ")
+}
+
+// HasVerifierConditions returns true if any verifier condition is configured.
+func (enforcer PolicyEnforcer) HasVerifierConditions(_ context.Context) bool {
+	return len(enforcer.VerifierConditions) > 0
+}
+
+// UsesSubjectManifest returns true if subject annotations are required or a
+// verifier condition depends on them.
+func (enforcer PolicyEnforcer) UsesSubjectManifest(_ context.Context) bool {
+	if len(enforcer.SubjectAnnotations) > 0 {
+		return true
+	}
+	for _, condition := range enforcer.VerifierConditions {
+		if condition.SkipWhen.readsAnnotations() || condition.ApplyWhen.readsAnnotations() {
+			return true
+		}
+	}
+	return false
+}
+
+// readsAnnotations returns true if the predicate matches subject annotations.
+func (predicate *SubjectPredicate) readsAnnotations() bool {
+	return predicate != nil && len(predicate.Annotations) > 0
+}
+
+// SkipVerifier returns true and the reason if a verifier condition excludes
+// the subject from the verification of the verifier.
+func (enforcer PolicyEnforcer) SkipVerifier(_ context.Context, subject types.Subject, verifierName, verifierType string) (bool, string) {
+	for _, condition := range enforcer.VerifierConditions {
+		if !slices.Contains(condition.Verifiers, verifierName) && !slices.Contains(condition.Verifiers, verifierType) {
+			continue
+		}
+		if condition.SkipWhen != nil {
+			if matched, description := condition.SkipWhen.match(subject); matched {
+				return true, fmt.Sprintf("skipWhen condition is met: %s", description)
+			}
+		}
+		if condition.ApplyWhen != nil {
+			if matched, description := condition.ApplyWhen.match(subject); !matched {
+				return true, fmt.Sprintf("applyWhen condition is not met: %s", description)
+			}
+		}
+	}
+	return false, ""
+}
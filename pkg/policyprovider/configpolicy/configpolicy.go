@@ -19,6 +19,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/pkg/common"
@@ -34,11 +38,46 @@ import (
 // PolicyEnforcer describes different polices that are enforced during verification
 type PolicyEnforcer struct {
 	ArtifactTypePolicies map[string]vt.ArtifactTypeVerifyPolicy
+	// BlockOnWarning treats verifier results with a warning level as failures.
+	BlockOnWarning bool
+	// SubjectAnnotations maps subject manifest annotation keys to the pattern
+	// their value must match.
+	SubjectAnnotations map[string]*regexp.Regexp
+	// DefaultOnNoMatch is the outcome applied to subjects that no verifier
+	// produced a report for. Such subjects fail with an error if not set.
+	DefaultOnNoMatch vt.NoMatchVerifyPolicy
+	// SignatureGroups maps the name of an approval gate to the verifiers
+	// trusting its keys. Each group must be satisfied by a successful report
+	// of one of its verifiers. Since signatures of one group fail the
+	// verifiers of the others, the artifact type of the signatures is expected
+	// to use the "any" policy. The verifiers are validated against the
+	// configured verifiers with ValidateVerifierNames.
+	SignatureGroups map[string]vt.SignatureGroup
+	// VerifierConditions apply or skip verifiers depending on the labels and
+	// annotations of the subject.
+	VerifierConditions []VerifierCondition
+	// SignedReferrers maps the artifact type of referrers that must be signed
+	// themselves to the artifact types of the signatures accepted for them. A
+	// referrer of such a type fails unless one of its own referrers of an
+	// accepted type is verified successfully.
+	SignedReferrers map[string][]string
+	// IgnoreTimedOutVerifiers leaves the reports of the verifiers cut off by
+	// the latency budget out of the decision, which is made on the results of
+	// the completed verifiers. Timed out verifiers fail the artifact type they
+	// verify otherwise.
+	IgnoreTimedOutVerifiers bool
 }
 
 type configPolicyEnforcerConf struct {
 	Name                         string                                 `json:"name"`
 	ArtifactVerificationPolicies map[string]vt.ArtifactTypeVerifyPolicy `json:"artifactVerificationPolicies,omitempty"`
+	BlockOnWarning               bool                                   `json:"blockOnWarning,omitempty"`
+	SubjectAnnotations           map[string]string                      `json:"subjectAnnotations,omitempty"`
+	DefaultOnNoMatch             vt.NoMatchVerifyPolicy                 `json:"defaultOnNoMatch,omitempty"`
+	SignatureGroups              map[string]vt.SignatureGroup           `json:"signatureGroups,omitempty"`
+	VerifierConditions           []vt.VerifierCondition                 `json:"verifierConditions,omitempty"`
+	SignedReferrers              map[string][]string                    `json:"signedReferrers,omitempty"`
+	IgnoreTimedOutVerifiers      bool                                   `json:"ignoreTimedOutVerifiers,omitempty"`
 }
 
 const (
@@ -74,14 +113,68 @@ func (f *configPolicyFactory) Create(policyConfig config.PolicyPluginConfig) (po
 	if policyEnforcer.ArtifactTypePolicies[defaultPolicyName] == "" {
 		policyEnforcer.ArtifactTypePolicies[defaultPolicyName] = vt.AllVerifySuccess
 	}
+	policyEnforcer.BlockOnWarning = conf.BlockOnWarning
+	policyEnforcer.IgnoreTimedOutVerifiers = conf.IgnoreTimedOutVerifiers
+	switch conf.DefaultOnNoMatch {
+	case "", vt.AllowOnNoMatch, vt.DenyOnNoMatch:
+		policyEnforcer.DefaultOnNoMatch = conf.DefaultOnNoMatch
+	default:
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ConfigPolicy, re.PolicyProviderLink, nil, fmt.Sprintf("defaultOnNoMatch must be %s or %s, got %s", vt.AllowOnNoMatch, vt.DenyOnNoMatch, conf.DefaultOnNoMatch), re.HideStackTrace)
+	}
+	if len(conf.SubjectAnnotations) > 0 {
+		policyEnforcer.SubjectAnnotations = make(map[string]*regexp.Regexp, len(conf.SubjectAnnotations))
+		for key, pattern := range conf.SubjectAnnotations {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ConfigPolicy, re.PolicyProviderLink, err, fmt.Sprintf("invalid pattern for subject annotation %s", key), re.HideStackTrace)
+			}
+			policyEnforcer.SubjectAnnotations[key] = compiled
+		}
+	}
+	for name, group := range conf.SignatureGroups {
+		if len(group.Verifiers) == 0 {
+			return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ConfigPolicy, re.PolicyProviderLink, nil, fmt.Sprintf("signature group %s has no verifiers", name), re.HideStackTrace)
+		}
+	}
+	policyEnforcer.SignatureGroups = conf.SignatureGroups
+	for _, condition := range conf.VerifierConditions {
+		compiled, err := newVerifierCondition(condition)
+		if err != nil {
+			return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ConfigPolicy, re.PolicyProviderLink, err, "invalid verifier condition", re.HideStackTrace)
+		}
+		policyEnforcer.VerifierConditions = append(policyEnforcer.VerifierConditions, compiled)
+	}
+	for artifactType, signatureTypes := range conf.SignedReferrers {
+		if len(signatureTypes) == 0 {
+			return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ConfigPolicy, re.PolicyProviderLink, nil, fmt.Sprintf("signed referrers of type %s have no signature artifact types", artifactType), re.HideStackTrace)
+		}
+	}
+	policyEnforcer.SignedReferrers = conf.SignedReferrers
 	return &policyEnforcer, nil
 }
 
+// MarshalJSON marshals the enforcer with the source of its patterns, so that
+// policies differing only in their patterns do not marshal alike.
+func (enforcer PolicyEnforcer) MarshalJSON() ([]byte, error) {
+	type policyEnforcer PolicyEnforcer
+	return json.Marshal(struct {
+		policyEnforcer
+		SubjectAnnotations map[string]string
+	}{policyEnforcer(enforcer), patternSources(enforcer.SubjectAnnotations)})
+}
+
 // VerifyNeeded determines if the given subject/reference artifact should be verified
 func (enforcer PolicyEnforcer) VerifyNeeded(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor) bool {
 	return true
 }
 
+// RequiresSignedReferrer returns true if the referrers of the given referrer
+// must be verified since it has to be signed itself.
+func (enforcer PolicyEnforcer) RequiresSignedReferrer(_ context.Context, referenceDesc ocispecs.ReferenceDescriptor) bool {
+	_, ok := enforcer.SignedReferrers[referenceDesc.ArtifactType]
+	return ok
+}
+
 // ContinueVerifyOnFailure determines if the given error can be ignored and verification can be continued.
 func (enforcer PolicyEnforcer) ContinueVerifyOnFailure(_ context.Context, _ common.Reference, referenceDesc ocispecs.ReferenceDescriptor, _ types.VerifyResult) bool {
 	artifactType := referenceDesc.ArtifactType
@@ -104,15 +197,89 @@ func (enforcer PolicyEnforcer) ErrorToVerifyResult(_ context.Context, subjectRef
 	return types.VerifyResult{IsSuccess: false, VerifierReports: reports}
 }
 
+// NoMatchVerifyResult applies the configured defaultOnNoMatch outcome to a
+// subject that no verifier produced a report for. The returned report states
+// that the default was applied.
+func (enforcer PolicyEnforcer) NoMatchVerifyResult(_ context.Context, subjectRefString string) (types.VerifyResult, bool) {
+	if enforcer.DefaultOnNoMatch == "" {
+		return types.VerifyResult{}, false
+	}
+	isSuccess := enforcer.DefaultOnNoMatch == vt.AllowOnNoMatch
+	message := fmt.Sprintf("No verifier matched the artifact %s, applied defaultOnNoMatch policy %q", subjectRefString, enforcer.DefaultOnNoMatch)
+	var report verifier.VerifierResult
+	if isSuccess {
+		report = verifier.NewVerifierResult(subjectRefString, "", "", message, true, nil, nil)
+	} else {
+		verifierErr := re.ErrorCodeNoVerifierReport.WithDetail(message)
+		report = verifier.NewVerifierResult(subjectRefString, "", "", message, false, &verifierErr, nil)
+	}
+	report.Extensions = map[string]interface{}{"defaultOnNoMatch": enforcer.DefaultOnNoMatch}
+	return types.VerifyResult{IsSuccess: isSuccess, VerifierReports: []interface{}{report}}, true
+}
+
 // OverallVerifyResult determines the final outcome of verification that is constructed using the results from
 // individual verifications
 func (enforcer PolicyEnforcer) OverallVerifyResult(_ context.Context, verifierReports []interface{}) bool {
+	result, _, _ := enforcer.evaluate(nil, verifierReports)
+	return result
+}
+
+// OverallVerifySubjectResult determines the final outcome of verification from
+// the subject annotations and the results of individual verifications.
+func (enforcer PolicyEnforcer) OverallVerifySubjectResult(_ context.Context, subject types.Subject, verifierReports []interface{}) bool {
+	result, _, _ := enforcer.evaluate(&subject, verifierReports)
+	return result
+}
+
+// ExplainVerifyResult returns the artifact type policy that drove the overall
+// verification result.
+func (enforcer PolicyEnforcer) ExplainVerifyResult(_ context.Context, subject types.Subject, verifierReports []interface{}) types.PolicyDerivation {
+	_, rule, reason := enforcer.evaluate(&subject, verifierReports)
+	input := map[string]interface{}{
+		"artifactVerificationPolicies": enforcer.ArtifactTypePolicies,
+		"subject":                      subject,
+		"verifierReports":              verifierReports,
+	}
+	if len(enforcer.SubjectAnnotations) > 0 {
+		subjectAnnotations := make(map[string]string, len(enforcer.SubjectAnnotations))
+		for key, pattern := range enforcer.SubjectAnnotations {
+			subjectAnnotations[key] = pattern.String()
+		}
+		input["subjectAnnotations"] = subjectAnnotations
+	}
+	if len(enforcer.SignatureGroups) > 0 {
+		input["signatureGroups"] = enforcer.SignatureGroups
+	}
+	if len(enforcer.SignedReferrers) > 0 {
+		input["signedReferrers"] = enforcer.SignedReferrers
+	}
+	if enforcer.IgnoreTimedOutVerifiers {
+		input["ignoreTimedOutVerifiers"] = true
+	}
+	return types.PolicyDerivation{
+		PolicyType:  vt.ConfigPolicy,
+		Input:       input,
+		MatchedRule: rule,
+		Reason:      reason,
+	}
+}
+
+// evaluate returns the overall verification result together with the policy
+// rule that decided it and a human readable reason. The subject may be nil if
+// it is unknown, in which case subject annotation requirements are not met.
+func (enforcer PolicyEnforcer) evaluate(subject *types.Subject, verifierReports []interface{}) (bool, string, string) {
+	if ok, rule, reason := enforcer.evaluateSubjectAnnotations(subject); !ok {
+		return false, rule, reason
+	}
+
 	if len(verifierReports) <= 0 {
-		return false
+		return false, "", "no verifier reports"
 	}
 
 	// use boolean map to track if each artifact type policy constraint is satisfied
 	verifySuccess := map[string]bool{}
+	// track the verifiers that reported a success for the signature groups
+	verifierSuccess := map[string]bool{}
 	for artifactType := range enforcer.ArtifactTypePolicies {
 		// add all policies except for default
 		if artifactType != defaultPolicyName {
@@ -120,12 +287,20 @@ func (enforcer PolicyEnforcer) OverallVerifyResult(_ context.Context, verifierRe
 		}
 	}
 
+	completed := 0
 	for _, report := range verifierReports {
 		castedReport := report.(verifier.VerifierResult)
+		if castedReport.GetLevel() != verifier.LevelTimeout {
+			completed++
+		} else if enforcer.IgnoreTimedOutVerifiers {
+			continue
+		}
 		// extract the policy for the artifact type of the verified artifact if specified
+		policyName := castedReport.ArtifactType
 		policyType, ok := enforcer.ArtifactTypePolicies[castedReport.ArtifactType]
 		// if artifact type policy not specified, set policy to be default policy and add artifact type to success map
 		if !ok {
+			policyName = defaultPolicyName
 			policyType = enforcer.ArtifactTypePolicies[defaultPolicyName]
 		}
 		// set the artifact type success field in map to false to start
@@ -133,26 +308,146 @@ func (enforcer PolicyEnforcer) OverallVerifyResult(_ context.Context, verifierRe
 			verifySuccess[castedReport.ArtifactType] = false
 		}
 
-		if policyType == vt.AnyVerifySuccess && castedReport.IsSuccess {
+		isSuccess := enforcer.isReportSuccess(castedReport)
+		if ok, rule, reason := enforcer.evaluateReferrerSignature(castedReport); isSuccess && !ok {
+			return false, rule, reason
+		}
+		// a skipped verifier does not provide a valid signature
+		if isSuccess && castedReport.GetLevel() != verifier.LevelSkip {
+			verifierSuccess[castedReport.VerifierName] = true
+		}
+		if policyType == vt.AnyVerifySuccess && isSuccess {
 			// if policy is 'any' and report is successful
 			verifySuccess[castedReport.ArtifactType] = true
 		} else if policyType == vt.AllVerifySuccess {
 			// if policy is 'all'
-			if !castedReport.IsSuccess {
+			if !isSuccess {
 				// return false after first failure
-				return false
+				return false, policyRule(policyName, policyType), fmt.Sprintf("verifier %s reported %s for artifact %s of type %s", castedReport.VerifierName, castedReport.GetLevel(), castedReport.ReferenceDigest, castedReport.ArtifactType)
 			}
 			verifySuccess[castedReport.ArtifactType] = true
 		}
 	}
 
+	if completed == 0 {
+		return false, "", "no verifier completed within the latency budget"
+	}
+
 	// all booleans in map must be true for overall success to be true
+	artifactTypes := make([]string, 0, len(verifySuccess))
 	for artifactType := range verifySuccess {
+		artifactTypes = append(artifactTypes, artifactType)
+	}
+	sort.Strings(artifactTypes)
+	rules := make([]string, 0, len(artifactTypes))
+	for _, artifactType := range artifactTypes {
+		policyName := artifactType
+		policyType, ok := enforcer.ArtifactTypePolicies[artifactType]
+		if !ok {
+			policyName = defaultPolicyName
+			policyType = enforcer.ArtifactTypePolicies[defaultPolicyName]
+		}
 		if !verifySuccess[artifactType] {
-			return false
+			return false, policyRule(policyName, policyType), fmt.Sprintf("no successful verification for artifact type %s", artifactType)
+		}
+		if rule := policyRule(policyName, policyType); !slices.Contains(rules, rule) {
+			rules = append(rules, rule)
 		}
 	}
-	return true
+	if len(enforcer.SignatureGroups) == 0 {
+		return true, strings.Join(rules, ", "), "all artifact type policies are satisfied"
+	}
+
+	groupNames := make([]string, 0, len(enforcer.SignatureGroups))
+	for name := range enforcer.SignatureGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		group := enforcer.SignatureGroups[name]
+		rule := fmt.Sprintf("signatureGroups[%s]", name)
+		if !slices.ContainsFunc(group.Verifiers, func(verifierName string) bool { return verifierSuccess[verifierName] }) {
+			return false, rule, fmt.Sprintf("no valid signature for signature group %s from verifiers %s", name, strings.Join(group.Verifiers, ", "))
+		}
+		rules = append(rules, rule)
+	}
+	return true, strings.Join(rules, ", "), "all artifact type policies and signature groups are satisfied"
+}
+
+// ValidateVerifierNames returns an error if a signature group references a
+// verifier that is not among the given verifier names.
+func (enforcer PolicyEnforcer) ValidateVerifierNames(_ context.Context, verifierNames []string) error {
+	groupNames := make([]string, 0, len(enforcer.SignatureGroups))
+	for name := range enforcer.SignatureGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		for _, verifierName := range enforcer.SignatureGroups[name].Verifiers {
+			if !slices.Contains(verifierNames, verifierName) {
+				return re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ConfigPolicy, re.PolicyProviderLink, nil, fmt.Sprintf("signature group %s references verifier %s that is not configured", name, verifierName), re.HideStackTrace)
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateSubjectAnnotations checks the subject annotations against the
+// configured patterns. Nested subjects, such as the referrers of the subject
+// of the request, are not required to carry them.
+func (enforcer PolicyEnforcer) evaluateSubjectAnnotations(subject *types.Subject) (bool, string, string) {
+	if subject != nil && subject.Nested {
+		return true, "", ""
+	}
+	keys := make([]string, 0, len(enforcer.SubjectAnnotations))
+	for key := range enforcer.SubjectAnnotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		rule := fmt.Sprintf("subjectAnnotations[%s]=%s", key, enforcer.SubjectAnnotations[key])
+		var value string
+		var ok bool
+		if subject != nil {
+			value, ok = subject.Annotations[key]
+		}
+		if !ok {
+			return false, rule, fmt.Sprintf("subject annotation %s is missing", key)
+		}
+		if !enforcer.SubjectAnnotations[key].MatchString(value) {
+			return false, rule, fmt.Sprintf("subject annotation %s=%q does not match %s", key, value, enforcer.SubjectAnnotations[key])
+		}
+	}
+	return true, "", ""
+}
+
+// evaluateReferrerSignature checks that a referrer required to be signed has a
+// successfully verified signature among its nested results. A skipped
+// verifier does not verify the referrer, the executor reports the signature of
+// such a referrer separately.
+func (enforcer PolicyEnforcer) evaluateReferrerSignature(report verifier.VerifierResult) (bool, string, string) {
+	signatureTypes, ok := enforcer.SignedReferrers[report.ArtifactType]
+	if !ok || report.GetLevel() == verifier.LevelSkip {
+		return true, "", ""
+	}
+	for _, nested := range report.NestedResults {
+		if slices.Contains(signatureTypes, nested.ArtifactType) && enforcer.isReportSuccess(nested) && nested.GetLevel() != verifier.LevelSkip {
+			return true, "", ""
+		}
+	}
+	rule := fmt.Sprintf("signedReferrers[%s]", report.ArtifactType)
+	return false, rule, fmt.Sprintf("referrer %s of type %s has no valid signature of type %s", report.ReferenceDigest, report.ArtifactType, strings.Join(signatureTypes, ", "))
+}
+
+// isReportSuccess determines if a verifier report satisfies the policy. A
+// successful warning only fails verification if BlockOnWarning is enabled.
+func (enforcer PolicyEnforcer) isReportSuccess(report verifier.VerifierResult) bool {
+	return report.IsSuccess && (report.GetLevel() != verifier.LevelWarn || !enforcer.BlockOnWarning)
+}
+
+// policyRule formats an artifact type policy as a rule name.
+func policyRule(policyName string, policyType vt.ArtifactTypeVerifyPolicy) string {
+	return fmt.Sprintf("artifactVerificationPolicies[%s]=%s", policyName, policyType)
 }
 
 // GetPolicyType returns the type of the policy.
@@ -17,6 +17,7 @@ package configpolicy
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
@@ -311,3 +312,718 @@ func TestGetPolicyType(t *testing.T) {
 		t.Fatalf("expected policy type: configpolicy, got %v", policyType)
 	}
 }
+
+func TestPolicyEnforcer_ExplainVerifyResult(t *testing.T) {
+	testcases := []struct {
+		name            string
+		policies        map[string]types.ArtifactTypeVerifyPolicy
+		verifierReports []interface{}
+		expectedRule    string
+		expectedReason  string
+	}{
+		{
+			name: "failing report under all policy",
+			policies: map[string]types.ArtifactTypeVerifyPolicy{
+				"application/vnd.cncf.notary.signature": "all",
+			},
+			verifierReports: []interface{}{
+				vr.VerifierResult{
+					IsSuccess:       false,
+					VerifierName:    "notation",
+					ReferenceDigest: "sha256:abc",
+					ArtifactType:    "application/vnd.cncf.notary.signature",
+				},
+			},
+			expectedRule:   "artifactVerificationPolicies[application/vnd.cncf.notary.signature]=all",
+			expectedReason: "verifier notation reported fail for artifact sha256:abc of type application/vnd.cncf.notary.signature",
+		},
+		{
+			name: "unsatisfied any policy",
+			policies: map[string]types.ArtifactTypeVerifyPolicy{
+				"application/vnd.cncf.notary.signature": "any",
+				"application/spdx+json":                 "any",
+			},
+			verifierReports: []interface{}{
+				vr.VerifierResult{
+					IsSuccess:    true,
+					ArtifactType: "application/vnd.cncf.notary.signature",
+				},
+			},
+			expectedRule:   "artifactVerificationPolicies[application/spdx+json]=any",
+			expectedReason: "no successful verification for artifact type application/spdx+json",
+		},
+		{
+			name: "passing reports",
+			policies: map[string]types.ArtifactTypeVerifyPolicy{
+				"application/vnd.cncf.notary.signature": "any",
+			},
+			verifierReports: []interface{}{
+				vr.VerifierResult{
+					IsSuccess:    true,
+					ArtifactType: "application/vnd.cncf.notary.signature",
+				},
+				vr.VerifierResult{
+					IsSuccess:    true,
+					ArtifactType: "application/spdx+json",
+				},
+			},
+			expectedRule:   "artifactVerificationPolicies[default]=all, artifactVerificationPolicies[application/vnd.cncf.notary.signature]=any",
+			expectedReason: "all artifact type policies are satisfied",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			policyEnforcer, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+				Version: "1.0.0",
+				PolicyPlugin: map[string]interface{}{
+					"name":                         "configPolicy",
+					"artifactVerificationPolicies": tc.policies,
+				},
+			})
+			if err != nil {
+				t.Fatalf("PolicyEnforcer should create from PoliciesConfig")
+			}
+
+			derivation := policyEnforcer.(*PolicyEnforcer).ExplainVerifyResult(context.Background(), vt.Subject{}, tc.verifierReports)
+			if derivation.PolicyType != types.ConfigPolicy {
+				t.Fatalf("expected policy type %s, got %s", types.ConfigPolicy, derivation.PolicyType)
+			}
+			if derivation.MatchedRule != tc.expectedRule {
+				t.Fatalf("expected matched rule %q, got %q", tc.expectedRule, derivation.MatchedRule)
+			}
+			if derivation.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %q, got %q", tc.expectedReason, derivation.Reason)
+			}
+			if derivation.Input.(map[string]interface{})["verifierReports"] == nil {
+				t.Fatalf("expected verifier reports in policy input")
+			}
+		})
+	}
+}
+
+func TestPolicyEnforcer_OverallVerifyResult_Warnings(t *testing.T) {
+	testcases := []struct {
+		name           string
+		blockOnWarning bool
+		policy         types.ArtifactTypeVerifyPolicy
+		reports        []interface{}
+		expected       bool
+	}{
+		{
+			name:     "warning allowed",
+			policy:   types.AllVerifySuccess,
+			reports:  []interface{}{vr.VerifierResult{IsSuccess: true, Level: vr.LevelWarn, ArtifactType: "application/spdx+json"}},
+			expected: true,
+		},
+		{
+			name:           "warning blocks",
+			blockOnWarning: true,
+			policy:         types.AllVerifySuccess,
+			reports:        []interface{}{vr.VerifierResult{IsSuccess: true, Level: vr.LevelWarn, ArtifactType: "application/spdx+json"}},
+			expected:       false,
+		},
+		{
+			name:           "warning blocks but another report passes under any policy",
+			blockOnWarning: true,
+			policy:         types.AnyVerifySuccess,
+			reports: []interface{}{
+				vr.VerifierResult{IsSuccess: true, Level: vr.LevelWarn, ArtifactType: "application/spdx+json"},
+				vr.VerifierResult{IsSuccess: true, ArtifactType: "application/spdx+json"},
+			},
+			expected: true,
+		},
+		{
+			name:     "failure is not affected by warning configuration",
+			policy:   types.AllVerifySuccess,
+			reports:  []interface{}{vr.VerifierResult{IsSuccess: false, ArtifactType: "application/spdx+json"}},
+			expected: false,
+		},
+		{
+			name:     "failed report at warning level fails",
+			policy:   types.AllVerifySuccess,
+			reports:  []interface{}{vr.VerifierResult{IsSuccess: false, Level: vr.LevelWarn, ArtifactType: "application/spdx+json"}},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			policyEnforcer, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+				Version: "1.0.0",
+				PolicyPlugin: map[string]interface{}{
+					"name":           "configPolicy",
+					"blockOnWarning": tc.blockOnWarning,
+					"artifactVerificationPolicies": map[string]types.ArtifactTypeVerifyPolicy{
+						"application/spdx+json": tc.policy,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("PolicyEnforcer should create from PoliciesConfig")
+			}
+			if result := policyEnforcer.OverallVerifyResult(context.Background(), tc.reports); result != tc.expected {
+				t.Fatalf("expected %v from OverallVerifyResult but got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestPolicyEnforcer_OverallVerifyResult_TimedOut(t *testing.T) {
+	testcases := []struct {
+		name           string
+		reports        []interface{}
+		ignoreTimedOut bool
+		expected       bool
+	}{
+		{
+			name: "timed out report fails its artifact type",
+			reports: []interface{}{
+				vr.VerifierResult{IsSuccess: true, ArtifactType: "application/vnd.cncf.notary.signature"},
+				vr.VerifierResult{IsSuccess: false, Level: vr.LevelTimeout, ArtifactType: "application/spdx+json"},
+			},
+			expected: false,
+		},
+		{
+			name: "timed out report is left out when ignored",
+			reports: []interface{}{
+				vr.VerifierResult{IsSuccess: true, ArtifactType: "application/vnd.cncf.notary.signature"},
+				vr.VerifierResult{IsSuccess: false, Level: vr.LevelTimeout, ArtifactType: "application/spdx+json"},
+			},
+			ignoreTimedOut: true,
+			expected:       true,
+		},
+		{
+			name: "completed failure denies",
+			reports: []interface{}{
+				vr.VerifierResult{IsSuccess: false, ArtifactType: "application/vnd.cncf.notary.signature"},
+				vr.VerifierResult{IsSuccess: false, Level: vr.LevelTimeout, ArtifactType: "application/spdx+json"},
+			},
+			expected: false,
+		},
+		{
+			name: "no completed report denies",
+			reports: []interface{}{
+				vr.VerifierResult{IsSuccess: false, Level: vr.LevelTimeout, ArtifactType: "application/spdx+json"},
+			},
+			ignoreTimedOut: true,
+			expected:       false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			policyEnforcer, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+				Version: "1.0.0",
+				PolicyPlugin: map[string]interface{}{
+					"name": "configPolicy",
+					"artifactVerificationPolicies": map[string]types.ArtifactTypeVerifyPolicy{
+						"default": types.AllVerifySuccess,
+					},
+					"ignoreTimedOutVerifiers": tc.ignoreTimedOut,
+				},
+			})
+			if err != nil {
+				t.Fatalf("PolicyEnforcer should create from PoliciesConfig")
+			}
+			if result := policyEnforcer.OverallVerifyResult(context.Background(), tc.reports); result != tc.expected {
+				t.Fatalf("expected %v from OverallVerifyResult but got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestPolicyEnforcer_OverallVerifySubjectResult(t *testing.T) {
+	const sourceAnnotation = "org.opencontainers.image.source"
+	reports := []interface{}{vr.VerifierResult{IsSuccess: true, ArtifactType: "application/spdx+json"}}
+	testcases := []struct {
+		name           string
+		annotations    map[string]string
+		expected       bool
+		expectedReason string
+	}{
+		{
+			name:           "matching annotation",
+			annotations:    map[string]string{sourceAnnotation: "https://github.com/myorg/app"},
+			expected:       true,
+			expectedReason: "all artifact type policies are satisfied",
+		},
+		{
+			name:           "mismatching annotation",
+			annotations:    map[string]string{sourceAnnotation: "https://github.com/otherorg/app"},
+			expected:       false,
+			expectedReason: `subject annotation org.opencontainers.image.source="https://github.com/otherorg/app" does not match ^https://github\.com/myorg/`,
+		},
+		{
+			name:           "missing annotation",
+			expected:       false,
+			expectedReason: "subject annotation org.opencontainers.image.source is missing",
+		},
+	}
+
+	policyEnforcer, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+		Version: "1.0.0",
+		PolicyPlugin: map[string]interface{}{
+			"name": "configPolicy",
+			"subjectAnnotations": map[string]string{
+				sourceAnnotation: `^https://github\.com/myorg/`,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PolicyEnforcer should create from PoliciesConfig: %v", err)
+	}
+	enforcer := policyEnforcer.(*PolicyEnforcer)
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := vt.Subject{Reference: "localhost:5000/app@sha256:abc", Annotations: tc.annotations}
+			if result := enforcer.OverallVerifySubjectResult(context.Background(), subject, reports); result != tc.expected {
+				t.Fatalf("expected %v from OverallVerifySubjectResult but got %v", tc.expected, result)
+			}
+			derivation := enforcer.ExplainVerifyResult(context.Background(), subject, reports)
+			if derivation.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %q, got %q", tc.expectedReason, derivation.Reason)
+			}
+			if derivation.Input.(map[string]interface{})["subject"].(vt.Subject).Annotations[sourceAnnotation] != tc.annotations[sourceAnnotation] {
+				t.Fatalf("expected subject annotations in policy input")
+			}
+		})
+	}
+
+	// annotations are unknown without the subject
+	if enforcer.OverallVerifyResult(context.Background(), reports) {
+		t.Fatalf("expected OverallVerifyResult to fail when subject annotations are required")
+	}
+}
+
+func TestCreate_InvalidSubjectAnnotationPattern(t *testing.T) {
+	_, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+		Version: "1.0.0",
+		PolicyPlugin: map[string]interface{}{
+			"name": "configPolicy",
+			"subjectAnnotations": map[string]string{
+				"org.opencontainers.image.source": "(",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error for invalid subject annotation pattern")
+	}
+}
+
+func TestPolicyEnforcer_NoMatchVerifyResult(t *testing.T) {
+	subject := "localhost:5000/net-monitor:v1"
+	cases := []struct {
+		desc              string
+		defaultOnNoMatch  string
+		expectedApplied   bool
+		expectedIsSuccess bool
+	}{
+		{
+			desc: "not configured",
+		},
+		{
+			desc:              "allow on empty",
+			defaultOnNoMatch:  "allow",
+			expectedApplied:   true,
+			expectedIsSuccess: true,
+		},
+		{
+			desc:             "fail closed",
+			defaultOnNoMatch: "deny",
+			expectedApplied:  true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			provider, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+				Version: "1.0.0",
+				PolicyPlugin: map[string]interface{}{
+					"name":             "configPolicy",
+					"defaultOnNoMatch": tc.defaultOnNoMatch,
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to create policy provider: %v", err)
+			}
+			result, applied := provider.(*PolicyEnforcer).NoMatchVerifyResult(context.Background(), subject)
+			if applied != tc.expectedApplied {
+				t.Fatalf("expected applied %v, got %v", tc.expectedApplied, applied)
+			}
+			if !applied {
+				return
+			}
+			if result.IsSuccess != tc.expectedIsSuccess {
+				t.Fatalf("expected IsSuccess %v, got %v", tc.expectedIsSuccess, result.IsSuccess)
+			}
+			if len(result.VerifierReports) != 1 {
+				t.Fatalf("expected 1 verifier report, got %d", len(result.VerifierReports))
+			}
+			report := result.VerifierReports[0].(vr.VerifierResult)
+			if report.IsSuccess != tc.expectedIsSuccess {
+				t.Fatalf("expected report IsSuccess %v, got %v", tc.expectedIsSuccess, report.IsSuccess)
+			}
+			if !strings.Contains(report.Message, "applied defaultOnNoMatch policy") {
+				t.Fatalf("expected report to state the default was applied, got %q", report.Message)
+			}
+		})
+	}
+}
+
+func TestCreate_InvalidDefaultOnNoMatch(t *testing.T) {
+	_, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+		Version: "1.0.0",
+		PolicyPlugin: map[string]interface{}{
+			"name":             "configPolicy",
+			"defaultOnNoMatch": "skip",
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error for invalid defaultOnNoMatch")
+	}
+}
+
+func TestPolicyEnforcer_SignatureGroups(t *testing.T) {
+	const signatureType = "application/vnd.cncf.notary.signature"
+	devSigned := vr.VerifierResult{IsSuccess: true, VerifierName: "notation-dev", ArtifactType: signatureType}
+	devRejected := vr.VerifierResult{IsSuccess: false, VerifierName: "notation-dev", ArtifactType: signatureType}
+	securitySigned := vr.VerifierResult{IsSuccess: true, VerifierName: "notation-security", ArtifactType: signatureType}
+	securityRejected := vr.VerifierResult{IsSuccess: false, VerifierName: "notation-security", ArtifactType: signatureType}
+	testcases := []struct {
+		name           string
+		reports        []interface{}
+		expected       bool
+		expectedRule   string
+		expectedReason string
+	}{
+		{
+			name:           "only dev group satisfied",
+			reports:        []interface{}{devSigned, securityRejected},
+			expected:       false,
+			expectedRule:   "signatureGroups[security]",
+			expectedReason: "no valid signature for signature group security from verifiers notation-security, cosign-security",
+		},
+		{
+			name:           "only security group satisfied",
+			reports:        []interface{}{devRejected, securitySigned},
+			expected:       false,
+			expectedRule:   "signatureGroups[dev]",
+			expectedReason: "no valid signature for signature group dev from verifiers notation-dev",
+		},
+		{
+			name:           "both groups satisfied",
+			reports:        []interface{}{devSigned, securityRejected, devRejected, securitySigned},
+			expected:       true,
+			expectedRule:   "artifactVerificationPolicies[application/vnd.cncf.notary.signature]=any, signatureGroups[dev], signatureGroups[security]",
+			expectedReason: "all artifact type policies and signature groups are satisfied",
+		},
+	}
+
+	policyEnforcer, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+		Version: "1.0.0",
+		PolicyPlugin: map[string]interface{}{
+			"name": "configPolicy",
+			"artifactVerificationPolicies": map[string]types.ArtifactTypeVerifyPolicy{
+				signatureType: types.AnyVerifySuccess,
+			},
+			"signatureGroups": map[string]interface{}{
+				"dev":      map[string]interface{}{"verifiers": []string{"notation-dev"}},
+				"security": map[string]interface{}{"verifiers": []string{"notation-security", "cosign-security"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PolicyEnforcer should create from PoliciesConfig: %v", err)
+	}
+	enforcer := policyEnforcer.(*PolicyEnforcer)
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := enforcer.OverallVerifyResult(context.Background(), tc.reports); result != tc.expected {
+				t.Fatalf("expected %v from OverallVerifyResult but got %v", tc.expected, result)
+			}
+			derivation := enforcer.ExplainVerifyResult(context.Background(), vt.Subject{}, tc.reports)
+			if derivation.MatchedRule != tc.expectedRule {
+				t.Fatalf("expected rule %q, got %q", tc.expectedRule, derivation.MatchedRule)
+			}
+			if derivation.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %q, got %q", tc.expectedReason, derivation.Reason)
+			}
+		})
+	}
+}
+
+func TestCreate_SignatureGroupWithoutVerifiers(t *testing.T) {
+	_, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+		Version: "1.0.0",
+		PolicyPlugin: map[string]interface{}{
+			"name": "configPolicy",
+			"signatureGroups": map[string]interface{}{
+				"dev": map[string]interface{}{},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error for signature group without verifiers")
+	}
+}
+
+func TestValidateVerifierNames(t *testing.T) {
+	enforcer := PolicyEnforcer{SignatureGroups: map[string]types.SignatureGroup{
+		"dev":      {Verifiers: []string{"notation-dev"}},
+		"security": {Verifiers: []string{"notation-security", "cosign-security"}},
+	}}
+	if err := enforcer.ValidateVerifierNames(context.Background(), []string{"notation-dev", "notation-security", "cosign-security"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	err := enforcer.ValidateVerifierNames(context.Background(), []string{"notation-dev", "notation-security"})
+	if err == nil || !strings.Contains(err.Error(), "signature group security references verifier cosign-security that is not configured") {
+		t.Fatalf("expected an error for the verifier that is not configured, got %v", err)
+	}
+	if err := (PolicyEnforcer{}).ValidateVerifierNames(context.Background(), nil); err != nil {
+		t.Fatalf("expected no error without signature groups, got %v", err)
+	}
+}
+
+func TestPolicyEnforcer_SkipVerifier(t *testing.T) {
+	provider, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+		Version: "1.0.0",
+		PolicyPlugin: map[string]interface{}{
+			"name": "configPolicy",
+			"verifierConditions": []interface{}{
+				map[string]interface{}{
+					"verifiers": []string{"vulnerabilityreport"},
+					"skipWhen": map[string]interface{}{
+						"labels": map[string]string{"org.example.image-role": "^(base|builder)$"},
+					},
+				},
+				map[string]interface{}{
+					"verifiers": []string{"sbom"},
+					"applyWhen": map[string]interface{}{
+						"annotations": map[string]string{"org.example.team": "^payments$"},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create policy provider: %v", err)
+	}
+	enforcer := provider.(*PolicyEnforcer)
+	if !enforcer.HasVerifierConditions(context.Background()) {
+		t.Fatalf("expected verifier conditions")
+	}
+
+	tests := []struct {
+		name         string
+		verifierName string
+		verifierType string
+		subject      vt.Subject
+		expectSkip   bool
+	}{
+		{
+			name:         "base image skips vulnerability verifier by type",
+			verifierName: "vuln",
+			verifierType: "vulnerabilityreport",
+			subject:      vt.Subject{Labels: map[string]string{"org.example.image-role": "builder"}},
+			expectSkip:   true,
+		},
+		{
+			name:         "application image runs vulnerability verifier",
+			verifierName: "vuln",
+			verifierType: "vulnerabilityreport",
+			subject:      vt.Subject{Labels: map[string]string{"org.example.image-role": "app"}},
+		},
+		{
+			name:         "unlabeled image runs vulnerability verifier",
+			verifierName: "vuln",
+			verifierType: "vulnerabilityreport",
+		},
+		{
+			name:         "sbom verifier applied to matching annotation",
+			verifierName: "sbom",
+			subject:      vt.Subject{Annotations: map[string]string{"org.example.team": "payments"}},
+		},
+		{
+			name:         "sbom verifier skipped without annotation",
+			verifierName: "sbom",
+			expectSkip:   true,
+		},
+		{
+			name:         "unconditioned verifier runs",
+			verifierName: "notation",
+			verifierType: "notation",
+			subject:      vt.Subject{Labels: map[string]string{"org.example.image-role": "base"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skip, reason := enforcer.SkipVerifier(context.Background(), tt.subject, tt.verifierName, tt.verifierType)
+			if skip != tt.expectSkip {
+				t.Fatalf("expected skip %v, got %v: %s", tt.expectSkip, skip, reason)
+			}
+			if skip && reason == "" {
+				t.Fatalf("expected a skip reason")
+			}
+		})
+	}
+}
+
+func TestCreate_InvalidVerifierCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition map[string]interface{}
+	}{
+		{
+			name:      "no verifiers",
+			condition: map[string]interface{}{"skipWhen": map[string]interface{}{"labels": map[string]string{"role": "base"}}},
+		},
+		{
+			name:      "no predicate",
+			condition: map[string]interface{}{"verifiers": []string{"sbom"}},
+		},
+		{
+			name:      "empty predicate",
+			condition: map[string]interface{}{"verifiers": []string{"sbom"}, "skipWhen": map[string]interface{}{}},
+		},
+		{
+			name:      "invalid pattern",
+			condition: map[string]interface{}{"verifiers": []string{"sbom"}, "applyWhen": map[string]interface{}{"labels": map[string]string{"role": "("}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+				Version: "1.0.0",
+				PolicyPlugin: map[string]interface{}{
+					"name":               "configPolicy",
+					"verifierConditions": []interface{}{tt.condition},
+				},
+			})
+			if err == nil {
+				t.Fatalf("expected error for invalid verifier condition")
+			}
+		})
+	}
+}
+
+func TestPolicyEnforcer_SignedReferrers(t *testing.T) {
+	const sbomType = "application/spdx+json"
+	const signatureType = "application/vnd.cncf.notary.signature"
+	imageSignature := vr.VerifierResult{IsSuccess: true, VerifierName: "notation", ArtifactType: signatureType, ReferenceDigest: "sha256:sig"}
+	sbomSignature := vr.VerifierResult{IsSuccess: true, VerifierName: "notation", ArtifactType: signatureType, ReferenceDigest: "sha256:sbomsig"}
+	testcases := []struct {
+		name           string
+		reports        []interface{}
+		expected       bool
+		expectedRule   string
+		expectedReason string
+	}{
+		{
+			name: "signed sbom",
+			reports: []interface{}{
+				imageSignature,
+				vr.VerifierResult{IsSuccess: true, VerifierName: "sbom", ArtifactType: sbomType, ReferenceDigest: "sha256:sbom", NestedResults: []vr.VerifierResult{sbomSignature}},
+			},
+			expected:       true,
+			expectedRule:   "artifactVerificationPolicies[default]=all",
+			expectedReason: "all artifact type policies are satisfied",
+		},
+		{
+			name: "unsigned sbom",
+			reports: []interface{}{
+				imageSignature,
+				vr.VerifierResult{IsSuccess: true, VerifierName: "sbom", ArtifactType: sbomType, ReferenceDigest: "sha256:sbom"},
+			},
+			expected:       false,
+			expectedRule:   "signedReferrers[application/spdx+json]",
+			expectedReason: "referrer sha256:sbom of type application/spdx+json has no valid signature of type application/vnd.cncf.notary.signature",
+		},
+		{
+			name: "sbom with failed signature",
+			reports: []interface{}{
+				imageSignature,
+				vr.VerifierResult{IsSuccess: true, VerifierName: "sbom", ArtifactType: sbomType, ReferenceDigest: "sha256:sbom", NestedResults: []vr.VerifierResult{
+					{IsSuccess: false, VerifierName: "notation", ArtifactType: signatureType},
+				}},
+			},
+			expected:       false,
+			expectedRule:   "signedReferrers[application/spdx+json]",
+			expectedReason: "referrer sha256:sbom of type application/spdx+json has no valid signature of type application/vnd.cncf.notary.signature",
+		},
+		{
+			name: "skipped sbom verifier",
+			reports: []interface{}{
+				imageSignature,
+				vr.VerifierResult{IsSuccess: true, VerifierName: "sbom", ArtifactType: sbomType, ReferenceDigest: "sha256:sbom", Level: vr.LevelSkip},
+				vr.VerifierResult{IsSuccess: true, VerifierName: "signedReferrers", ArtifactType: sbomType, ReferenceDigest: "sha256:sbom", NestedResults: []vr.VerifierResult{sbomSignature}},
+			},
+			expected:       true,
+			expectedRule:   "artifactVerificationPolicies[default]=all",
+			expectedReason: "all artifact type policies are satisfied",
+		},
+		{
+			name: "sbom with signature of other type",
+			reports: []interface{}{
+				imageSignature,
+				vr.VerifierResult{IsSuccess: true, VerifierName: "sbom", ArtifactType: sbomType, ReferenceDigest: "sha256:sbom", NestedResults: []vr.VerifierResult{
+					{IsSuccess: true, VerifierName: "vulnerability", ArtifactType: "application/sarif+json"},
+				}},
+			},
+			expected:       false,
+			expectedRule:   "signedReferrers[application/spdx+json]",
+			expectedReason: "referrer sha256:sbom of type application/spdx+json has no valid signature of type application/vnd.cncf.notary.signature",
+		},
+	}
+
+	policyEnforcer, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+		Version: "1.0.0",
+		PolicyPlugin: map[string]interface{}{
+			"name": "configPolicy",
+			"signedReferrers": map[string][]string{
+				sbomType: {signatureType},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PolicyEnforcer should create from PoliciesConfig: %v", err)
+	}
+	enforcer := policyEnforcer.(*PolicyEnforcer)
+	if !enforcer.RequiresSignedReferrer(context.Background(), ocispecs.ReferenceDescriptor{ArtifactType: sbomType}) {
+		t.Fatalf("expected sbom referrers to require a signature")
+	}
+	if enforcer.RequiresSignedReferrer(context.Background(), ocispecs.ReferenceDescriptor{ArtifactType: signatureType}) {
+		t.Fatalf("expected signature referrers not to require a signature")
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := enforcer.OverallVerifyResult(context.Background(), tc.reports); result != tc.expected {
+				t.Fatalf("expected %v from OverallVerifyResult but got %v", tc.expected, result)
+			}
+			derivation := enforcer.ExplainVerifyResult(context.Background(), vt.Subject{}, tc.reports)
+			if derivation.MatchedRule != tc.expectedRule {
+				t.Fatalf("expected rule %q, got %q", tc.expectedRule, derivation.MatchedRule)
+			}
+			if derivation.Reason != tc.expectedReason {
+				t.Fatalf("expected reason %q, got %q", tc.expectedReason, derivation.Reason)
+			}
+		})
+	}
+}
+
+func TestCreate_SignedReferrersWithoutSignatureTypes(t *testing.T) {
+	_, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{
+		Version: "1.0.0",
+		PolicyPlugin: map[string]interface{}{
+			"name": "configPolicy",
+			"signedReferrers": map[string][]string{
+				"application/spdx+json": {},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error for signed referrers without signature artifact types")
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultpolicy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+// SignatureArtifactTypes are the artifact types of the referrers accepted as
+// signatures by the require-signature policy.
+var SignatureArtifactTypes = []string{
+	"application/vnd.cncf.notary.signature",
+	"application/vnd.dev.cosign.artifact.sig.v1+json",
+}
+
+// PolicyEnforcer applies a built-in default policy to subjects of a scope
+// without a configured policy provider.
+type PolicyEnforcer struct {
+	Policy vt.DefaultPolicy
+}
+
+// New returns the policy enforcer of the given default policy.
+func New(policy vt.DefaultPolicy) (*PolicyEnforcer, error) {
+	if err := Validate(policy); err != nil {
+		return nil, err
+	}
+	return &PolicyEnforcer{Policy: policy}, nil
+}
+
+// Validate returns an error if the policy is not a known default policy.
+func Validate(policy vt.DefaultPolicy) error {
+	switch policy {
+	case vt.AllowAllDefaultPolicy, vt.DenyAllDefaultPolicy, vt.RequireSignatureDefaultPolicy:
+		return nil
+	default:
+		return fmt.Errorf("defaultPolicy must be %s, %s or %s, got %s", vt.AllowAllDefaultPolicy, vt.DenyAllDefaultPolicy, vt.RequireSignatureDefaultPolicy, policy)
+	}
+}
+
+// VerifyNeeded skips verifying the referrers of subjects that are denied
+// anyway.
+func (enforcer PolicyEnforcer) VerifyNeeded(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor) bool {
+	return enforcer.Policy != vt.DenyAllDefaultPolicy
+}
+
+// ContinueVerifyOnFailure always continues so that all referrers are reported.
+func (enforcer PolicyEnforcer) ContinueVerifyOnFailure(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor, _ types.VerifyResult) bool {
+	return true
+}
+
+// ErrorToVerifyResult converts an error to a verify result that only passes
+// under the allow-all policy.
+func (enforcer PolicyEnforcer) ErrorToVerifyResult(_ context.Context, subjectRefString string, verifyError error) types.VerifyResult {
+	verifierErr := re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("failed to verify artifact: %s", subjectRefString)).WithError(verifyError)
+	isSuccess := enforcer.Policy == vt.AllowAllDefaultPolicy
+	errorReport := verifier.NewVerifierResult(subjectRefString, "", "", "", isSuccess, &verifierErr, nil)
+	return types.VerifyResult{IsSuccess: isSuccess, VerifierReports: []interface{}{errorReport}}
+}
+
+// NoMatchVerifyResult applies the default policy to a subject that no verifier
+// produced a report for.
+func (enforcer PolicyEnforcer) NoMatchVerifyResult(_ context.Context, subjectRefString string) (types.VerifyResult, bool) {
+	isSuccess := enforcer.Policy == vt.AllowAllDefaultPolicy
+	message := fmt.Sprintf("No verifier report for the artifact %s, applied default policy %q", subjectRefString, enforcer.Policy)
+	var report verifier.VerifierResult
+	if isSuccess {
+		report = verifier.NewVerifierResult(subjectRefString, "", "", message, true, nil, nil)
+	} else {
+		verifierErr := re.ErrorCodeNoVerifierReport.WithDetail(message)
+		report = verifier.NewVerifierResult(subjectRefString, "", "", message, false, &verifierErr, nil)
+	}
+	report.Extensions = map[string]interface{}{"defaultPolicy": enforcer.Policy}
+	return types.VerifyResult{IsSuccess: isSuccess, VerifierReports: []interface{}{report}}, true
+}
+
+// OverallVerifyResult determines the final outcome of verification under the
+// default policy.
+func (enforcer PolicyEnforcer) OverallVerifyResult(_ context.Context, verifierReports []interface{}) bool {
+	switch enforcer.Policy {
+	case vt.AllowAllDefaultPolicy:
+		return true
+	case vt.RequireSignatureDefaultPolicy:
+		for _, report := range verifierReports {
+			castedReport, ok := report.(verifier.VerifierResult)
+			if !ok {
+				continue
+			}
+			// a skipped verifier does not provide a valid signature
+			if castedReport.IsSuccess && castedReport.GetLevel() != verifier.LevelSkip && slices.Contains(SignatureArtifactTypes, castedReport.ArtifactType) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// GetPolicyType returns the config policy type since the default policy is
+// evaluated by the executor like a config policy.
+func (enforcer PolicyEnforcer) GetPolicyType(_ context.Context) string {
+	return vt.ConfigPolicy
+}
@@ -0,0 +1,103 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultpolicy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+const (
+	testSubject          = "localhost:5000/net-monitor@sha256:f7d4f6f5b8c1c2b9b1e7e3e29a2d9f8f7c1a5d3d5b1e4d6f8f9c2a1b3c4d5e6f"
+	testSBOMArtifactType = "application/spdx+json"
+)
+
+func TestNew(t *testing.T) {
+	for _, policy := range []vt.DefaultPolicy{vt.AllowAllDefaultPolicy, vt.DenyAllDefaultPolicy, vt.RequireSignatureDefaultPolicy} {
+		if _, err := New(policy); err != nil {
+			t.Fatalf("New(%s) unexpected error: %v", policy, err)
+		}
+	}
+	for _, policy := range []vt.DefaultPolicy{"", "allow"} {
+		if _, err := New(policy); err == nil {
+			t.Fatalf("expected New(%q) to fail", policy)
+		}
+	}
+}
+
+func TestOverallVerifyResult(t *testing.T) {
+	signature := verifier.VerifierResult{IsSuccess: true, ArtifactType: SignatureArtifactTypes[0]}
+	failedSignature := verifier.VerifierResult{IsSuccess: false, ArtifactType: SignatureArtifactTypes[1]}
+	skippedSignature := verifier.VerifierResult{IsSuccess: true, ArtifactType: SignatureArtifactTypes[0], Level: verifier.LevelSkip}
+	sbom := verifier.VerifierResult{IsSuccess: true, ArtifactType: testSBOMArtifactType}
+
+	tests := []struct {
+		name    string
+		policy  vt.DefaultPolicy
+		reports []interface{}
+		want    bool
+	}{
+		{name: "allow-all without reports", policy: vt.AllowAllDefaultPolicy, want: true},
+		{name: "allow-all with failed signature", policy: vt.AllowAllDefaultPolicy, reports: []interface{}{failedSignature}, want: true},
+		{name: "deny-all with verified signature", policy: vt.DenyAllDefaultPolicy, reports: []interface{}{signature}, want: false},
+		{name: "require-signature with verified signature", policy: vt.RequireSignatureDefaultPolicy, reports: []interface{}{failedSignature, sbom, signature}, want: true},
+		{name: "require-signature with failed signature", policy: vt.RequireSignatureDefaultPolicy, reports: []interface{}{failedSignature, sbom}, want: false},
+		{name: "require-signature with skipped signature", policy: vt.RequireSignatureDefaultPolicy, reports: []interface{}{skippedSignature}, want: false},
+		{name: "require-signature without reports", policy: vt.RequireSignatureDefaultPolicy, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enforcer := PolicyEnforcer{Policy: tt.policy}
+			if got := enforcer.OverallVerifyResult(context.Background(), tt.reports); got != tt.want {
+				t.Fatalf("OverallVerifyResult() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecisionsWithoutReports(t *testing.T) {
+	tests := []struct {
+		policy       vt.DefaultPolicy
+		verifyNeeded bool
+		isSuccess    bool
+	}{
+		{policy: vt.AllowAllDefaultPolicy, verifyNeeded: true, isSuccess: true},
+		{policy: vt.DenyAllDefaultPolicy, verifyNeeded: false, isSuccess: false},
+		{policy: vt.RequireSignatureDefaultPolicy, verifyNeeded: true, isSuccess: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			ctx := context.Background()
+			enforcer := PolicyEnforcer{Policy: tt.policy}
+			if got := enforcer.VerifyNeeded(ctx, common.Reference{}, ocispecs.ReferenceDescriptor{}); got != tt.verifyNeeded {
+				t.Fatalf("VerifyNeeded() = %v, want %v", got, tt.verifyNeeded)
+			}
+			if result := enforcer.ErrorToVerifyResult(ctx, testSubject, errors.New("no referrers")); result.IsSuccess != tt.isSuccess {
+				t.Fatalf("ErrorToVerifyResult() IsSuccess = %v, want %v", result.IsSuccess, tt.isSuccess)
+			}
+			result, applied := enforcer.NoMatchVerifyResult(ctx, testSubject)
+			if !applied || result.IsSuccess != tt.isSuccess {
+				t.Fatalf("NoMatchVerifyResult() = %v, %v, want %v, true", result.IsSuccess, applied, tt.isSuccess)
+			}
+		})
+	}
+}
@@ -22,6 +22,8 @@ import (
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/pkg/policyprovider"
 	"github.com/ratify-project/ratify/pkg/policyprovider/config"
+	"github.com/ratify-project/ratify/pkg/policyprovider/defaultpolicy"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
 	"github.com/ratify-project/ratify/pkg/verifier/types"
 	"github.com/sirupsen/logrus"
 )
@@ -72,3 +74,18 @@ func CreatePolicyProviderFromConfig(policyConfig config.PoliciesConfig) (policyp
 	logrus.Infof("selected policy provider: %s", providerNameStr)
 	return policyProvider, nil
 }
+
+// CreatePolicyProviderOrDefault creates a policy provider from the provided
+// configuration, or the provider of the default policy if no policy provider is
+// configured and a default policy is set.
+func CreatePolicyProviderOrDefault(policyConfig config.PoliciesConfig, defaultPolicy vt.DefaultPolicy) (policyprovider.PolicyProvider, error) {
+	if policyConfig.PolicyPlugin != nil || defaultPolicy == "" {
+		return CreatePolicyProviderFromConfig(policyConfig)
+	}
+	policyProvider, err := defaultpolicy.New(defaultPolicy)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.PolicyProvider).WithError(err)
+	}
+	logrus.Warnf("no policy provider is configured, applying the default policy %s to all verifications", defaultPolicy)
+	return policyProvider, nil
+}
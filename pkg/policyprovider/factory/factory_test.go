@@ -20,7 +20,9 @@ import (
 
 	"github.com/ratify-project/ratify/pkg/policyprovider"
 	"github.com/ratify-project/ratify/pkg/policyprovider/config"
+	"github.com/ratify-project/ratify/pkg/policyprovider/defaultpolicy"
 	"github.com/ratify-project/ratify/pkg/policyprovider/mocks"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
 )
 
 type TestPolicyProviderFactory struct{}
@@ -68,3 +70,35 @@ func TestCreatePolicyProvidersFromConfig_NonexistentPolicyProviders_ReturnsExpec
 		t.Fatalf("create policy provider should have failed for non existent provider")
 	}
 }
+
+// Checks the default policy is applied only if no policy provider is configured
+func TestCreatePolicyProviderOrDefault(t *testing.T) {
+	builtInPolicyProviders = map[string]PolicyFactory{
+		"testpolicyprovider": &TestPolicyProviderFactory{},
+	}
+
+	for _, policy := range []vt.DefaultPolicy{vt.AllowAllDefaultPolicy, vt.DenyAllDefaultPolicy, vt.RequireSignatureDefaultPolicy} {
+		provider, err := CreatePolicyProviderOrDefault(config.PoliciesConfig{}, policy)
+		if err != nil {
+			t.Fatalf("create default policy provider %s failed with err %v", policy, err)
+		}
+		if enforcer, ok := provider.(*defaultpolicy.PolicyEnforcer); !ok || enforcer.Policy != policy {
+			t.Fatalf("expected default policy provider %s, got %#v", policy, provider)
+		}
+	}
+
+	provider, err := CreatePolicyProviderOrDefault(config.PoliciesConfig{PolicyPlugin: map[string]interface{}{"name": "testpolicyprovider"}}, vt.DenyAllDefaultPolicy)
+	if err != nil {
+		t.Fatalf("create policy provider failed with err %v", err)
+	}
+	if _, ok := provider.(*mocks.TestPolicyProvider); !ok {
+		t.Fatalf("expected the configured policy provider, got %#v", provider)
+	}
+
+	if _, err := CreatePolicyProviderOrDefault(config.PoliciesConfig{}, "allow"); err == nil {
+		t.Fatalf("create policy provider should have failed for an invalid default policy")
+	}
+	if _, err := CreatePolicyProviderOrDefault(config.PoliciesConfig{}, ""); err == nil {
+		t.Fatalf("create policy provider should have failed without a policy provider or default policy")
+	}
+}
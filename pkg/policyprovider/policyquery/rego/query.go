@@ -25,7 +25,8 @@ import (
 )
 
 const (
-	query = "data.ratify.policy.valid"
+	// DecisionRule is the path of the Rego rule that decides the overall result.
+	DecisionRule = "data.ratify.policy.valid"
 	// RegoName is a constant for "rego"
 	RegoName = "rego"
 )
@@ -45,7 +46,7 @@ func init() {
 // Create creates a new Rego query object.
 func (f *RegoFactory) Create(policy string) (policyquery.PolicyQuery, error) {
 	query, err := rego.New(
-		rego.Query(query),
+		rego.Query(DecisionRule),
 		rego.Module("policy.rego", policy),
 	).PrepareForEval(context.Background())
 	if err != nil {
@@ -20,6 +20,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+
+	"github.com/open-policy-agent/opa/ast"
 
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
@@ -39,8 +42,17 @@ type policyEnforcer struct {
 	Policy             string
 	OpaEngine          policyengine.PolicyEngine
 	passthroughEnabled bool
+	// usesRequestContext is set if the policy may read input.request.
+	usesRequestContext bool
+	// usesSubjectManifest is set if the policy may read the annotations or the
+	// artifact type of input.subject.
+	usesSubjectManifest bool
 }
 
+// subjectManifestFields are the fields of input.subject read from the subject
+// manifest.
+var subjectManifestFields = []string{"annotations", "artifactType"}
+
 type policyEnforcerConf struct {
 	Name               string `json:"name"`
 	Policy             string `json:"policy"`
@@ -91,10 +103,13 @@ func (f *Factory) Create(policyConfig config.PolicyPluginConfig) (policyprovider
 		return nil, re.ErrorCodePluginInitFailure.NewError(re.PolicyProvider, policyTypes.RegoPolicy, re.PolicyProviderLink, err, "failed to create OPA engine", re.HideStackTrace)
 	}
 
+	compiler := compilePolicy(conf.Policy)
 	policyEnforcer := &policyEnforcer{
-		Policy:             conf.Policy,
-		OpaEngine:          engine,
-		passthroughEnabled: conf.PassthroughEnabled,
+		Policy:              conf.Policy,
+		OpaEngine:           engine,
+		passthroughEnabled:  conf.PassthroughEnabled,
+		usesRequestContext:  readsInput(compiler, refReadsRequestContext),
+		usesSubjectManifest: readsInput(compiler, refReadsSubjectManifest),
 	}
 
 	return policyEnforcer, nil
@@ -117,13 +132,25 @@ func (e *policyEnforcer) ErrorToVerifyResult(_ context.Context, _ string, _ erro
 
 // OverallVerifyResult determines if the overall verification result should be a success or failure.
 func (e *policyEnforcer) OverallVerifyResult(ctx context.Context, verifierReports []interface{}) bool {
+	nestedReports := map[string]interface{}{}
+	nestedReports["verifierReports"] = verifierReports
+	return e.evaluate(ctx, nestedReports)
+}
+
+// OverallVerifySubjectResult determines if the overall verification result
+// should be a success or failure. The subject metadata is exposed to the policy
+// as input.subject and the request context as input.request.
+func (e *policyEnforcer) OverallVerifySubjectResult(ctx context.Context, subject types.Subject, verifierReports []interface{}) bool {
+	return e.evaluate(ctx, policyInput(subject, verifierReports))
+}
+
+// evaluate evaluates the policy against the given input document.
+func (e *policyEnforcer) evaluate(ctx context.Context, input map[string]interface{}) bool {
 	if e.passthroughEnabled {
 		return false
 	}
 
-	nestedReports := map[string]interface{}{}
-	nestedReports["verifierReports"] = verifierReports
-	result, err := e.OpaEngine.Evaluate(ctx, nestedReports)
+	result, err := e.OpaEngine.Evaluate(ctx, input)
 	if err != nil {
 		logger.GetLogger(ctx, logOpt).Errorf("failed to evaluate policy: %v", err)
 		return false
@@ -131,6 +158,116 @@ func (e *policyEnforcer) OverallVerifyResult(ctx context.Context, verifierReport
 	return result
 }
 
+// ExplainVerifyResult returns the Rego rule evaluated for the overall
+// verification result along with the input document it was evaluated against.
+func (e *policyEnforcer) ExplainVerifyResult(ctx context.Context, subject types.Subject, verifierReports []interface{}) types.PolicyDerivation {
+	input := policyInput(subject, verifierReports)
+	derivation := types.PolicyDerivation{
+		PolicyType:  policyTypes.RegoPolicy,
+		Input:       input,
+		MatchedRule: query.DecisionRule,
+		RulePath:    query.DecisionRule,
+	}
+	if e.passthroughEnabled {
+		derivation.Reason = "passthrough mode is enabled, policy is not evaluated"
+		return derivation
+	}
+
+	result, err := e.OpaEngine.Evaluate(ctx, input)
+	if err != nil {
+		derivation.Reason = fmt.Sprintf("failed to evaluate policy: %v", err)
+		return derivation
+	}
+	derivation.Reason = fmt.Sprintf("%s evaluated to %t", query.DecisionRule, result)
+	return derivation
+}
+
+// UsesRequestContext returns true if the policy may read input.request.
+func (e *policyEnforcer) UsesRequestContext(_ context.Context) bool {
+	return e.usesRequestContext
+}
+
+// UsesSubjectManifest returns true if the policy may read the annotations or
+// the artifact type of input.subject.
+func (e *policyEnforcer) UsesSubjectManifest(_ context.Context) bool {
+	return e.usesSubjectManifest
+}
+
+// compilePolicy compiles the policy to inspect the parts of the input it
+// reads. It returns nil if the policy fails to compile.
+func compilePolicy(policy string) *ast.Compiler {
+	compiler, err := ast.CompileModules(map[string]string{"policy.rego": policy})
+	if err != nil {
+		return nil
+	}
+	return compiler
+}
+
+// readsInput returns true if any reference to input of the compiled policy
+// reads the part of the input checked by reads. A policy failing to compile
+// is assumed to read it.
+func readsInput(compiler *ast.Compiler, reads func(ast.Ref) bool) bool {
+	if compiler == nil {
+		return true
+	}
+	found := false
+	for _, module := range compiler.Modules {
+		ast.WalkRefs(module, func(ref ast.Ref) bool {
+			if !found && ref.HasPrefix(ast.InputRootRef) {
+				found = reads(ref)
+			}
+			return found
+		})
+	}
+	return found
+}
+
+// refReadsRequestContext returns true if the reference to input may read
+// input.request. References to input as a whole, or by a computed key, may
+// read it too.
+func refReadsRequestContext(ref ast.Ref) bool {
+	if len(ref) < 2 {
+		return true
+	}
+	key, ok := ref[1].Value.(ast.String)
+	return !ok || key == "request"
+}
+
+// refReadsSubjectManifest returns true if the reference to input may read a
+// field of input.subject set from the subject manifest. References to input
+// or input.subject as a whole, or by a computed key, may read them too.
+func refReadsSubjectManifest(ref ast.Ref) bool {
+	if len(ref) < 2 {
+		return true
+	}
+	key, ok := ref[1].Value.(ast.String)
+	if !ok {
+		return true
+	}
+	if key != "subject" {
+		return false
+	}
+	if len(ref) < 3 {
+		return true
+	}
+	field, ok := ref[2].Value.(ast.String)
+	return !ok || slices.Contains(subjectManifestFields, string(field))
+}
+
+// policyInput builds the input document the policy is evaluated against. The
+// context of the admission request, if any, is exposed as input.request, e.g.
+// input.request.namespace.
+func policyInput(subject types.Subject, verifierReports []interface{}) map[string]interface{} {
+	input := map[string]interface{}{
+		"subject":         subject,
+		"verifierReports": verifierReports,
+	}
+	if subject.Request != nil {
+		input["request"] = subject.Request
+	}
+	return input
+}
+
 // GetPolicyType returns the type of the policy.
 func (e *policyEnforcer) GetPolicyType(_ context.Context) string {
 	return policyTypes.RegoPolicy
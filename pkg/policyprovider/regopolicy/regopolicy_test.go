@@ -38,6 +38,32 @@ valid {
 }
 `
 	policy2 = "package"
+	policy3 = `
+package ratify.policy
+
+default valid := false
+
+valid {
+    startswith(input.subject.annotations["org.opencontainers.image.source"], "https://github.com/myorg/")
+    count(input.verifierReports) > 0
+}
+`
+	policy4 = `
+package ratify.policy
+
+default valid := false
+
+valid {
+    input.request.namespace != "prod"
+    input.request.labels.tier != "critical"
+}
+
+valid {
+    count(input.verifierReports) > 0
+    failed := [report | report := input.verifierReports[_]; not report.isSuccess]
+    count(failed) == 0
+}
+`
 )
 
 type policyEngine struct {
@@ -187,9 +213,332 @@ func TestOverallVerifyResult(t *testing.T) {
 	}
 }
 
+func TestOverallVerifySubjectResult(t *testing.T) {
+	testcases := []struct {
+		name         string
+		annotations  map[string]string
+		expectResult bool
+	}{
+		{
+			name:         "matching source annotation",
+			annotations:  map[string]string{"org.opencontainers.image.source": "https://github.com/myorg/app"},
+			expectResult: true,
+		},
+		{
+			name:         "source annotation from another org",
+			annotations:  map[string]string{"org.opencontainers.image.source": "https://github.com/otherorg/app"},
+			expectResult: false,
+		},
+		{
+			name:         "missing source annotation",
+			expectResult: false,
+		},
+	}
+
+	enforcer, err := (&Factory{}).Create(map[string]interface{}{
+		"name":   "test",
+		"policy": policy3,
+	})
+	if err != nil {
+		t.Fatalf("failed to create policy enforcer: %v", err)
+	}
+	reports := []interface{}{types.NestedVerifierReport{}}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := types.Subject{Reference: "localhost:5000/app@sha256:abc", Digest: "sha256:abc", Annotations: tc.annotations}
+			result := enforcer.(*policyEnforcer).OverallVerifySubjectResult(context.Background(), subject, reports)
+			if result != tc.expectResult {
+				t.Fatalf("result = %v, expectResult = %v", result, tc.expectResult)
+			}
+		})
+	}
+}
+
+func TestOverallVerifySubjectResult_RequestContext(t *testing.T) {
+	testcases := []struct {
+		name         string
+		request      *types.RequestContext
+		reports      []interface{}
+		expectResult bool
+	}{
+		{
+			name:         "unsigned subject in dev namespace",
+			request:      &types.RequestContext{Namespace: "dev", Labels: map[string]string{"tier": "web"}},
+			expectResult: true,
+		},
+		{
+			name:         "unsigned subject in prod namespace",
+			request:      &types.RequestContext{Namespace: "prod", Labels: map[string]string{"tier": "web"}},
+			expectResult: false,
+		},
+		{
+			name:         "unsigned subject with critical label",
+			request:      &types.RequestContext{Namespace: "dev", Labels: map[string]string{"tier": "critical"}},
+			expectResult: false,
+		},
+		{
+			name:         "signed subject in prod namespace",
+			request:      &types.RequestContext{Namespace: "prod", Labels: map[string]string{"tier": "critical"}},
+			reports:      []interface{}{map[string]interface{}{"isSuccess": true}},
+			expectResult: true,
+		},
+		{
+			name:         "no request context",
+			expectResult: false,
+		},
+	}
+
+	enforcer, err := (&Factory{}).Create(map[string]interface{}{
+		"name":   "test",
+		"policy": policy4,
+	})
+	if err != nil {
+		t.Fatalf("failed to create policy enforcer: %v", err)
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := types.Subject{Reference: "localhost:5000/app@sha256:abc", Digest: "sha256:abc", Request: tc.request}
+			if result := enforcer.(*policyEnforcer).OverallVerifySubjectResult(context.Background(), subject, tc.reports); result != tc.expectResult {
+				t.Fatalf("result = %v, expectResult = %v", result, tc.expectResult)
+			}
+		})
+	}
+}
+
+func TestUsesRequestContext(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy string
+		reads  bool
+	}{
+		{
+			name:   "request fields",
+			policy: policy4,
+			reads:  true,
+		},
+		{
+			name:   "no request",
+			policy: policy1,
+		},
+		{
+			name: "request mentioned outside of the input",
+			policy: `
+package ratify.policy
+
+valid {
+    input.subject.digest != "request"
+}
+`,
+		},
+		{
+			name: "computed key",
+			policy: `
+package ratify.policy
+
+valid {
+    some k
+    k == concat("", ["req", "uest"])
+    input[k].namespace != "prod"
+}
+`,
+			reads: true,
+		},
+		{
+			name: "whole input",
+			policy: `
+package ratify.policy
+
+allowed(doc) {
+    doc.request.namespace != "prod"
+}
+
+valid {
+    allowed(input)
+}
+`,
+			reads: true,
+		},
+		{
+			name:   "invalid policy",
+			policy: policy2,
+			reads:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if reads := readsInput(compilePolicy(tc.policy), refReadsRequestContext); reads != tc.reads {
+				t.Fatalf("expected reads request context %v, got %v", tc.reads, reads)
+			}
+		})
+	}
+
+	provider, err := (&Factory{}).Create(map[string]interface{}{"name": "regopolicy", "policy": policy4})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !provider.(*policyEnforcer).UsesRequestContext(context.Background()) {
+		t.Fatalf("expected a policy reading input.request to use the request context")
+	}
+}
+
+func TestReadsSubjectManifest(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy string
+		reads  bool
+	}{
+		{
+			name:   "subject annotations",
+			policy: policy3,
+			reads:  true,
+		},
+		{
+			name: "artifact type of verifier reports",
+			policy: `
+package ratify.policy
+
+default valid := false
+
+valid {
+    report := input.verifierReports[_]
+    report.artifactType == "application/vnd.cncf.notary.signature"
+    report.isSuccess
+}
+`,
+		},
+		{
+			name:   "request context",
+			policy: policy4,
+		},
+		{
+			name: "subject digest",
+			policy: `
+package ratify.policy
+
+valid {
+    input.subject.digest != ""
+}
+`,
+		},
+		{
+			name: "subject artifact type",
+			policy: `
+package ratify.policy
+
+valid {
+    input.subject.artifactType == "application/vnd.cncf.helm.config.v1+json"
+}
+`,
+			reads: true,
+		},
+		{
+			name: "whole subject",
+			policy: `
+package ratify.policy
+
+valid {
+    subject := input.subject
+    subject.annotations["org.opencontainers.image.source"] != ""
+}
+`,
+			reads: true,
+		},
+		{
+			name: "imported subject",
+			policy: `
+package ratify.policy
+
+import input.subject
+
+valid {
+    subject.annotations["org.opencontainers.image.source"] != ""
+}
+`,
+			reads: true,
+		},
+		{
+			name:   "invalid policy",
+			policy: policy2,
+			reads:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if reads := readsInput(compilePolicy(tc.policy), refReadsSubjectManifest); reads != tc.reads {
+				t.Fatalf("expected reads subject manifest %v, got %v", tc.reads, reads)
+			}
+		})
+	}
+}
+
+func TestPolicyInput_RequestContext(t *testing.T) {
+	request := &types.RequestContext{Namespace: "prod", Labels: map[string]string{"tier": "critical"}, User: "alice"}
+	input := policyInput(types.Subject{Reference: "localhost:5000/app:v1", Request: request}, nil)
+	if input["request"] != request {
+		t.Fatalf("expected request context %+v in the input, got %+v", request, input["request"])
+	}
+	if _, ok := policyInput(types.Subject{Reference: "localhost:5000/app:v1"}, nil)["request"]; ok {
+		t.Fatalf("expected no request context in the input without a request")
+	}
+}
+
 func TestGetPolicyType(t *testing.T) {
 	enforcer := policyEnforcer{}
 	if policyType := enforcer.GetPolicyType(context.Background()); policyType != "regopolicy" {
 		t.Fatalf("expected policy type: regopolicy, got %v", policyType)
 	}
 }
+
+func TestExplainVerifyResult(t *testing.T) {
+	testcases := []struct {
+		name               string
+		passthroughEnabled bool
+		returnErr          bool
+		expectReason       string
+	}{
+		{
+			name:               "passthrough enabled",
+			passthroughEnabled: true,
+			expectReason:       "passthrough mode is enabled, policy is not evaluated",
+		},
+		{
+			name:         "opa engine returns error",
+			returnErr:    true,
+			expectReason: "failed to evaluate policy: error",
+		},
+		{
+			name:         "opa engine returns result",
+			expectReason: "data.ratify.policy.valid evaluated to true",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			policyEnforcer := &policyEnforcer{
+				OpaEngine: policyEngine{
+					ReturnErr: tc.returnErr,
+				},
+				passthroughEnabled: tc.passthroughEnabled,
+			}
+			reports := []interface{}{types.NestedVerifierReport{}}
+			derivation := policyEnforcer.ExplainVerifyResult(context.Background(), types.Subject{}, reports)
+			if derivation.RulePath != "data.ratify.policy.valid" {
+				t.Fatalf("expected rule path data.ratify.policy.valid, got %s", derivation.RulePath)
+			}
+			if derivation.PolicyType != "regopolicy" {
+				t.Fatalf("expected policy type regopolicy, got %s", derivation.PolicyType)
+			}
+			if derivation.Reason != tc.expectReason {
+				t.Fatalf("expected reason %q, got %q", tc.expectReason, derivation.Reason)
+			}
+			input := derivation.Input.(map[string]interface{})
+			if !reflect.DeepEqual(input["verifierReports"], reports) {
+				t.Fatalf("expected verifier reports in policy input, got %v", input)
+			}
+		})
+	}
+}
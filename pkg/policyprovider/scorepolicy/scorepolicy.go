@@ -0,0 +1,179 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorepolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/policyprovider"
+	"github.com/ratify-project/ratify/pkg/policyprovider/config"
+	pf "github.com/ratify-project/ratify/pkg/policyprovider/factory"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+// PolicyEnforcer scores the subject by adding the weights of the verifiers
+// that reported a success and passes the subject if the score reaches the
+// threshold.
+type PolicyEnforcer struct {
+	// Threshold is the minimum score of a subject to pass verification.
+	Threshold float64
+	// Weights maps verifier names or types to the points awarded if any of
+	// their reports succeeds.
+	Weights map[string]float64
+}
+
+type scorePolicyEnforcerConf struct {
+	Name      string             `json:"name"`
+	Threshold float64            `json:"threshold"`
+	Weights   map[string]float64 `json:"weights"`
+}
+
+type scorePolicyFactory struct{}
+
+// init calls Register for our score policy provider
+func init() {
+	pf.Register(vt.ScorePolicy, &scorePolicyFactory{})
+}
+
+// Create initializes a new score policy provider from the policy config
+func (f *scorePolicyFactory) Create(policyConfig config.PolicyPluginConfig) (policyprovider.PolicyProvider, error) {
+	conf := scorePolicyEnforcerConf{}
+	policyProviderConfigBytes, err := json.Marshal(policyConfig)
+	if err != nil {
+		return nil, re.ErrorCodeDataEncodingFailure.NewError(re.PolicyProvider, vt.ScorePolicy, re.PolicyProviderLink, err, "failed to marshal policy config", re.HideStackTrace)
+	}
+	if err := json.Unmarshal(policyProviderConfigBytes, &conf); err != nil {
+		return nil, re.ErrorCodeDataDecodingFailure.NewError(re.PolicyProvider, vt.ScorePolicy, re.PolicyProviderLink, err, "failed to unmarshal policy config", re.HideStackTrace)
+	}
+
+	if len(conf.Weights) == 0 {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ScorePolicy, re.PolicyProviderLink, nil, "weights must not be empty", re.HideStackTrace)
+	}
+	var maxScore float64
+	for name, weight := range conf.Weights {
+		if weight <= 0 {
+			return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ScorePolicy, re.PolicyProviderLink, nil, fmt.Sprintf("weight of verifier %s must be positive, got %v", name, weight), re.HideStackTrace)
+		}
+		maxScore += weight
+	}
+	if conf.Threshold < 0 || conf.Threshold > maxScore {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.PolicyProvider, vt.ScorePolicy, re.PolicyProviderLink, nil, fmt.Sprintf("threshold must be between 0 and the sum of the weights %v, got %v", maxScore, conf.Threshold), re.HideStackTrace)
+	}
+
+	return &PolicyEnforcer{
+		Threshold: conf.Threshold,
+		Weights:   conf.Weights,
+	}, nil
+}
+
+// VerifyNeeded determines if the given subject/reference artifact should be verified
+func (enforcer PolicyEnforcer) VerifyNeeded(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor) bool {
+	return true
+}
+
+// ContinueVerifyOnFailure always continues verification since the remaining
+// verifiers may still raise the score above the threshold.
+func (enforcer PolicyEnforcer) ContinueVerifyOnFailure(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor, _ types.VerifyResult) bool {
+	return true
+}
+
+// ErrorToVerifyResult converts an error to a properly formatted verify result
+func (enforcer PolicyEnforcer) ErrorToVerifyResult(_ context.Context, subjectRefString string, verifyError error) types.VerifyResult {
+	verifierErr := re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("failed to verify artifact: %s", subjectRefString)).WithError(verifyError)
+	errorReport := verifier.NewVerifierResult(subjectRefString, "", "", "", false, &verifierErr, nil)
+	return types.VerifyResult{IsSuccess: false, VerifierReports: []interface{}{errorReport}}
+}
+
+// OverallVerifyResult passes the subject if its score reaches the threshold.
+func (enforcer PolicyEnforcer) OverallVerifyResult(ctx context.Context, verifierReports []interface{}) bool {
+	return enforcer.ScoreVerifyResult(ctx, types.Subject{}, verifierReports).Score >= enforcer.Threshold
+}
+
+// ScoreVerifyResult returns the score of the subject along with the points
+// awarded for every weighted verifier.
+func (enforcer PolicyEnforcer) ScoreVerifyResult(_ context.Context, _ types.Subject, verifierReports []interface{}) types.ScoreReport {
+	report := types.ScoreReport{
+		Threshold: enforcer.Threshold,
+		Breakdown: make([]types.ScoreContribution, 0, len(enforcer.Weights)),
+	}
+	names := make([]string, 0, len(enforcer.Weights))
+	for name := range enforcer.Weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		contribution := types.ScoreContribution{
+			Verifier: name,
+			Weight:   enforcer.Weights[name],
+			Reason:   "no report from the verifier",
+		}
+		for _, verifierReport := range verifierReports {
+			result, ok := verifierReport.(verifier.VerifierResult)
+			if !ok || (result.VerifierName != name && result.VerifierType != name) {
+				continue
+			}
+			// a skipped verifier verified nothing and earns no weight
+			if result.GetLevel() == verifier.LevelSkip {
+				contribution.Reason = fmt.Sprintf("verifier %s was skipped for artifact %s", result.VerifierName, result.ReferenceDigest)
+				continue
+			}
+			if result.IsSuccess {
+				contribution.Awarded = contribution.Weight
+				contribution.Reason = fmt.Sprintf("verifier %s reported success for artifact %s", result.VerifierName, result.ReferenceDigest)
+				break
+			}
+			contribution.Reason = fmt.Sprintf("verifier %s reported failure for artifact %s", result.VerifierName, result.ReferenceDigest)
+		}
+		report.Score += contribution.Awarded
+		report.Breakdown = append(report.Breakdown, contribution)
+	}
+	return report
+}
+
+// ExplainVerifyResult returns the score that drove the overall verification
+// result.
+func (enforcer PolicyEnforcer) ExplainVerifyResult(ctx context.Context, subject types.Subject, verifierReports []interface{}) types.PolicyDerivation {
+	score := enforcer.ScoreVerifyResult(ctx, subject, verifierReports)
+	reason := fmt.Sprintf("score %v reaches the threshold %v", score.Score, score.Threshold)
+	if score.Score < score.Threshold {
+		reason = fmt.Sprintf("score %v is below the threshold %v", score.Score, score.Threshold)
+	}
+	return types.PolicyDerivation{
+		PolicyType: vt.ScorePolicy,
+		Input: map[string]interface{}{
+			"threshold":       enforcer.Threshold,
+			"weights":         enforcer.Weights,
+			"subject":         subject,
+			"verifierReports": verifierReports,
+		},
+		MatchedRule: fmt.Sprintf("threshold=%v", enforcer.Threshold),
+		Reason:      reason,
+	}
+}
+
+// GetPolicyType returns the type of the policy.
+func (enforcer PolicyEnforcer) GetPolicyType(_ context.Context) string {
+	return vt.ScorePolicy
+}
@@ -0,0 +1,229 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorepolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	pc "github.com/ratify-project/ratify/pkg/policyprovider/config"
+	pf "github.com/ratify-project/ratify/pkg/policyprovider/factory"
+	vt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+)
+
+func newReport(name, verifierType string, isSuccess bool) vr.VerifierResult {
+	return vr.VerifierResult{VerifierName: name, VerifierType: verifierType, IsSuccess: isSuccess}
+}
+
+func newSkippedReport(name, verifierType string) vr.VerifierResult {
+	report := newReport(name, verifierType, true)
+	report.Level = vr.LevelSkip
+	return report
+}
+
+func TestScoreVerifyResult(t *testing.T) {
+	enforcer := PolicyEnforcer{
+		Threshold: 70,
+		Weights: map[string]float64{
+			"notation":      50,
+			"sbom":          20,
+			"vulnerability": 30,
+		},
+	}
+	tests := []struct {
+		name          string
+		reports       []interface{}
+		expectScore   float64
+		expectSuccess bool
+	}{
+		{
+			name:          "no reports",
+			expectScore:   0,
+			expectSuccess: false,
+		},
+		{
+			name: "all verifiers pass",
+			reports: []interface{}{
+				newReport("signature", "notation", true),
+				newReport("sbom", "sbom", true),
+				newReport("vulnerability", "vulnerabilityreport", true),
+			},
+			expectScore:   100,
+			expectSuccess: true,
+		},
+		{
+			name: "score exactly at the threshold",
+			reports: []interface{}{
+				newReport("signature", "notation", true),
+				newReport("sbom", "sbom", true),
+				newReport("vulnerability", "vulnerabilityreport", false),
+			},
+			expectScore:   70,
+			expectSuccess: true,
+		},
+		{
+			name: "score just below the threshold",
+			reports: []interface{}{
+				newReport("signature", "notation", false),
+				newReport("sbom", "sbom", true),
+				newReport("vulnerability", "vulnerabilityreport", true),
+			},
+			expectScore:   50,
+			expectSuccess: false,
+		},
+		{
+			name: "one successful report of a verifier awards its weight once",
+			reports: []interface{}{
+				newReport("signature", "notation", false),
+				newReport("signature", "notation", true),
+				newReport("signature", "notation", true),
+				newReport("sbom", "sbom", true),
+			},
+			expectScore:   70,
+			expectSuccess: true,
+		},
+		{
+			name: "skipped verifiers do not contribute",
+			reports: []interface{}{
+				newSkippedReport("signature", "notation"),
+				newSkippedReport("sbom", "sbom"),
+				newReport("vulnerability", "vulnerabilityreport", true),
+			},
+			expectScore:   30,
+			expectSuccess: false,
+		},
+		{
+			name: "unweighted verifiers do not contribute",
+			reports: []interface{}{
+				newReport("cosign", "cosign", true),
+				newReport("vulnerability", "vulnerabilityreport", true),
+			},
+			expectScore:   30,
+			expectSuccess: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := enforcer.ScoreVerifyResult(context.Background(), types.Subject{}, tt.reports)
+			if score.Score != tt.expectScore {
+				t.Fatalf("expected score %v, got %v", tt.expectScore, score.Score)
+			}
+			if len(score.Breakdown) != len(enforcer.Weights) {
+				t.Fatalf("expected %d contributions, got %d", len(enforcer.Weights), len(score.Breakdown))
+			}
+			var awarded float64
+			for _, contribution := range score.Breakdown {
+				awarded += contribution.Awarded
+			}
+			if awarded != score.Score {
+				t.Fatalf("expected the breakdown to add up to %v, got %v", score.Score, awarded)
+			}
+			if success := enforcer.OverallVerifyResult(context.Background(), tt.reports); success != tt.expectSuccess {
+				t.Fatalf("expected success %v, got %v", tt.expectSuccess, success)
+			}
+		})
+	}
+}
+
+func TestScoreVerifyResult_Breakdown(t *testing.T) {
+	enforcer := PolicyEnforcer{
+		Threshold: 1,
+		Weights:   map[string]float64{"sbom": 1, "notation": 2},
+	}
+	score := enforcer.ScoreVerifyResult(context.Background(), types.Subject{}, []interface{}{newReport("signature", "notation", true)})
+	expected := []types.ScoreContribution{
+		{Verifier: "notation", Weight: 2, Awarded: 2},
+		{Verifier: "sbom", Weight: 1, Awarded: 0},
+	}
+	for i, contribution := range score.Breakdown {
+		if contribution.Verifier != expected[i].Verifier || contribution.Weight != expected[i].Weight || contribution.Awarded != expected[i].Awarded {
+			t.Fatalf("expected contribution %+v, got %+v", expected[i], contribution)
+		}
+		if contribution.Reason == "" {
+			t.Fatalf("expected a reason for contribution %s", contribution.Verifier)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      pc.PolicyPluginConfig
+		expectError bool
+	}{
+		{
+			name: "valid config",
+			config: pc.PolicyPluginConfig{
+				"name":      vt.ScorePolicy,
+				"threshold": 50,
+				"weights":   map[string]float64{"notation": 50, "sbom": 10},
+			},
+		},
+		{
+			name: "threshold equal to the maximum score",
+			config: pc.PolicyPluginConfig{
+				"name":      vt.ScorePolicy,
+				"threshold": 60,
+				"weights":   map[string]float64{"notation": 50, "sbom": 10},
+			},
+		},
+		{
+			name: "threshold above the maximum score",
+			config: pc.PolicyPluginConfig{
+				"name":      vt.ScorePolicy,
+				"threshold": 61,
+				"weights":   map[string]float64{"notation": 50, "sbom": 10},
+			},
+			expectError: true,
+		},
+		{
+			name: "negative threshold",
+			config: pc.PolicyPluginConfig{
+				"name":      vt.ScorePolicy,
+				"threshold": -1,
+				"weights":   map[string]float64{"notation": 50},
+			},
+			expectError: true,
+		},
+		{
+			name: "missing weights",
+			config: pc.PolicyPluginConfig{
+				"name":      vt.ScorePolicy,
+				"threshold": 0,
+			},
+			expectError: true,
+		},
+		{
+			name: "non-positive weight",
+			config: pc.PolicyPluginConfig{
+				"name":      vt.ScorePolicy,
+				"threshold": 10,
+				"weights":   map[string]float64{"notation": 50, "sbom": 0},
+			},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{PolicyPlugin: tt.config})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
@@ -15,6 +15,60 @@ limitations under the License.
 
 package types
 
+// NoMatchVerifyPolicy represents the outcome applied to a subject without any
+// verifier report
+type NoMatchVerifyPolicy string
+
+const (
+	// AllowOnNoMatch passes subjects that no verifier produced a report for.
+	AllowOnNoMatch NoMatchVerifyPolicy = "allow"
+	// DenyOnNoMatch fails subjects that no verifier produced a report for.
+	DenyOnNoMatch NoMatchVerifyPolicy = "deny"
+)
+
+// DefaultPolicy represents the built-in policy applied when no policy provider
+// is configured
+type DefaultPolicy string
+
+const (
+	// AllowAllDefaultPolicy passes all subjects regardless of the verifier
+	// reports.
+	AllowAllDefaultPolicy DefaultPolicy = "allow-all"
+	// DenyAllDefaultPolicy fails all subjects without verifying them.
+	DenyAllDefaultPolicy DefaultPolicy = "deny-all"
+	// RequireSignatureDefaultPolicy passes subjects with at least one
+	// successfully verified signature.
+	RequireSignatureDefaultPolicy DefaultPolicy = "require-signature"
+)
+
+// SignatureGroup is a named trust requirement satisfied by at least one valid
+// signature verified with the key set of the group.
+type SignatureGroup struct {
+	// Verifiers are the names of the verifiers configured with the key set of
+	// the group.
+	Verifiers []string `json:"verifiers"`
+}
+
+// SubjectPredicate matches the subjects whose labels and annotations match
+// all the configured patterns, keyed by label or annotation key.
+type SubjectPredicate struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// VerifierCondition applies or skips verifiers depending on the labels and
+// annotations of the subject, e.g. to skip vulnerability verification of base
+// images.
+type VerifierCondition struct {
+	// Verifiers are the names or types of the verifiers the condition applies
+	// to.
+	Verifiers []string `json:"verifiers"`
+	// SkipWhen skips the verifiers for the subjects it matches.
+	SkipWhen *SubjectPredicate `json:"skipWhen,omitempty"`
+	// ApplyWhen skips the verifiers for the subjects it does not match.
+	ApplyWhen *SubjectPredicate `json:"applyWhen,omitempty"`
+}
+
 // ArtifactTypeVerifyPolicy represents an artifact type policy
 type ArtifactTypeVerifyPolicy string
 
@@ -25,4 +79,8 @@ const (
 	RegoPolicy = "regopolicy"
 	// ConfigPolicy is the name of the config policy provider.
 	ConfigPolicy = "configpolicy"
+	// ScorePolicy is the name of the score policy provider.
+	ScorePolicy = "scorepolicy"
+	// CELPolicy is the name of the CEL expression policy provider.
+	CELPolicy = "celpolicy"
 )
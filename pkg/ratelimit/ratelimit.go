@@ -0,0 +1,131 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ratelimit bounds the rate of requests served by Ratify with token
+// buckets shared by all clients or kept for each client.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// pruneThreshold is the number of tracked clients above which the clients
+// with a full bucket are forgotten.
+const pruneThreshold = 1024
+
+// Config describes the rate limit of requests.
+type Config struct {
+	// RequestsPerSecond is the sustained rate of requests allowed.
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	// Burst is the number of requests allowed at once before the sustained
+	// rate applies. Defaults to RequestsPerSecond rounded up.
+	Burst int `json:"burst,omitempty"`
+	// PerClient limits the requests of each client address separately instead
+	// of all requests together.
+	PerClient bool `json:"perClient,omitempty"`
+}
+
+// Validate returns an error if the rate limit configuration is invalid.
+func (c *Config) Validate() error {
+	if c.RequestsPerSecond <= 0 {
+		return fmt.Errorf("rateLimit requestsPerSecond must be positive, got %v", c.RequestsPerSecond)
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("rateLimit burst must not be negative, got %d", c.Burst)
+	}
+	return nil
+}
+
+// Limiter allows requests at the configured rate, globally or per client.
+type Limiter struct {
+	limit     rate.Limit
+	burst     int
+	perClient bool
+	now       func() time.Time
+
+	mu      sync.Mutex
+	global  *rate.Limiter
+	clients map[string]*rate.Limiter
+}
+
+// New returns a limiter for the given configuration.
+func New(conf Config) (*Limiter, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	burst := conf.Burst
+	if burst == 0 {
+		burst = int(math.Ceil(conf.RequestsPerSecond))
+	}
+	limiter := &Limiter{
+		limit:     rate.Limit(conf.RequestsPerSecond),
+		burst:     burst,
+		perClient: conf.PerClient,
+		now:       time.Now,
+		clients:   make(map[string]*rate.Limiter),
+	}
+	limiter.global = rate.NewLimiter(limiter.limit, burst)
+	return limiter, nil
+}
+
+// Allow reports whether a request of the client is allowed now. If it is not,
+// it also returns the duration after which the request would be allowed.
+func (l *Limiter) Allow(client string) (bool, time.Duration) {
+	now := l.now()
+	bucket := l.bucket(client, now)
+	reservation := bucket.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// bucket returns the token bucket of the client, or the global one if the
+// requests are not limited per client.
+func (l *Limiter) bucket(client string, now time.Time) *rate.Limiter {
+	if !l.perClient {
+		return l.global
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket, ok := l.clients[client]
+	if !ok {
+		if len(l.clients) >= pruneThreshold {
+			l.prune(now)
+		}
+		bucket = rate.NewLimiter(l.limit, l.burst)
+		l.clients[client] = bucket
+	}
+	return bucket
+}
+
+// prune forgets the clients whose bucket is full, since a new bucket behaves
+// the same.
+func (l *Limiter) prune(now time.Time) {
+	for client, bucket := range l.clients {
+		if bucket.TokensAt(now) >= float64(l.burst) {
+			delete(l.clients, client)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    Config
+		wantErr bool
+	}{
+		{name: "valid", conf: Config{RequestsPerSecond: 10, Burst: 20}},
+		{name: "default burst", conf: Config{RequestsPerSecond: 0.5}},
+		{name: "zero rate", conf: Config{}, wantErr: true},
+		{name: "negative burst", conf: Config{RequestsPerSecond: 1, Burst: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.conf.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newTestLimiter(t *testing.T, conf Config) (*Limiter, *time.Time) {
+	limiter, err := New(conf)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestLimiter_Global(t *testing.T) {
+	limiter, now := newTestLimiter(t, Config{RequestsPerSecond: 1, Burst: 2})
+	for i := 0; i < 2; i++ {
+		if allowed, _ := limiter.Allow(fmt.Sprintf("client-%d", i)); !allowed {
+			t.Fatalf("expected request %d within the burst to be allowed", i)
+		}
+	}
+	allowed, retryAfter := limiter.Allow("client-3")
+	if allowed {
+		t.Fatalf("expected the request beyond the burst to be rejected")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Fatalf("expected a retry after of at most 1s, got %v", retryAfter)
+	}
+
+	*now = now.Add(time.Second)
+	if allowed, _ := limiter.Allow("client-3"); !allowed {
+		t.Fatalf("expected the request to be allowed once a token is refilled")
+	}
+}
+
+func TestLimiter_PerClient(t *testing.T) {
+	limiter, _ := newTestLimiter(t, Config{RequestsPerSecond: 1, PerClient: true})
+	if allowed, _ := limiter.Allow("10.0.0.1"); !allowed {
+		t.Fatalf("expected the first request of the client to be allowed")
+	}
+	if allowed, _ := limiter.Allow("10.0.0.1"); allowed {
+		t.Fatalf("expected the second request of the client to be rejected")
+	}
+	if allowed, _ := limiter.Allow("10.0.0.2"); !allowed {
+		t.Fatalf("expected the request of another client to be allowed")
+	}
+}
+
+func TestLimiter_PruneClients(t *testing.T) {
+	limiter, now := newTestLimiter(t, Config{RequestsPerSecond: 1, PerClient: true})
+	for i := 0; i < pruneThreshold; i++ {
+		limiter.Allow(fmt.Sprintf("client-%d", i))
+	}
+	*now = now.Add(time.Second)
+	limiter.Allow("client-new")
+	if len(limiter.clients) != 1 {
+		t.Fatalf("expected the clients with a full bucket to be pruned, got %d clients", len(limiter.clients))
+	}
+}
@@ -17,8 +17,10 @@ package referrerstore
 
 import (
 	"context"
+	"io"
 
 	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/ratify-project/ratify/pkg/common"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore/config"
@@ -52,3 +54,35 @@ type ReferrerStore interface {
 	// GetSubjectDescriptor returns the descriptor for the given subject.
 	GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error)
 }
+
+// Closer is implemented by stores holding background work, e.g. the token
+// prefetch loop of their auth provider, that must be stopped once the store
+// is replaced or deleted.
+type Closer interface {
+	// Close stops the background work of the store
+	Close()
+}
+
+// Close closes the store if it implements Closer.
+func Close(store ReferrerStore) {
+	if closer, ok := store.(Closer); ok {
+		closer.Close()
+	}
+}
+
+// ReferrerPusher is implemented by stores that can attach artifacts to a
+// subject, e.g. the verification attestations of Ratify.
+type ReferrerPusher interface {
+	// PushReferrer pushes the blob as the single layer of an artifact of the
+	// given type referring to the subject and returns the artifact manifest
+	// descriptor.
+	PushReferrer(ctx context.Context, subjectReference common.Reference, subjectDesc oci.Descriptor, artifactType string, blob []byte, blobMediaType string) (oci.Descriptor, error)
+}
+
+// BlobStreamer is implemented by stores that can stream blobs too large to be
+// held in memory, e.g. SBOMs.
+type BlobStreamer interface {
+	// GetBlobReader returns a reader streaming the blob with the given digest.
+	// The caller must close the reader.
+	GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error)
+}
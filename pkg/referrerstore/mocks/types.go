@@ -59,6 +59,11 @@ func (s *TestStore) GetSubjectDescriptor(_ context.Context, subjectReference com
 		time.Sleep(2 * time.Second)
 	}
 
+	// subjects referenced by digest are not resolved by tag
+	if subjectReference.Digest != "" {
+		return &ocispecs.SubjectDescriptor{Descriptor: v1.Descriptor{Digest: subjectReference.Digest}}, nil
+	}
+
 	if s.ResolveMap != nil {
 		if result, ok := s.ResolveMap[subjectReference.Tag]; ok {
 			return &ocispecs.SubjectDescriptor{Descriptor: v1.Descriptor{Digest: result}}, nil
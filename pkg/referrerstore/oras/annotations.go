@@ -0,0 +1,146 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+// annotatedManifest holds the fields of a manifest needed to discover the
+// references it declares, its artifact type and its subject.
+type annotatedManifest struct {
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Config       *oci.Descriptor   `json:"config,omitempty"`
+	Subject      *oci.Descriptor   `json:"subject,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// getAnnotationReferences returns the referrers declared by the reference
+// annotations of the subject manifest. The value of each annotation is a comma
+// separated list of digests of manifests in the repository of the subject.
+// Referenced manifests that do not exist are skipped.
+func getAnnotationReferences(ctx context.Context, subjectReference common.Reference, subjectDesc oci.Descriptor, repository registry.Repository, annotationKeys []string) ([]ocispecs.ReferenceDescriptor, error) {
+	subjectManifest, err := fetchAnnotatedManifest(ctx, repository, subjectDesc)
+	if err != nil {
+		evictOnError(ctx, err, subjectReference.Original)
+		return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to fetch the manifest of the artifact %s to discover annotated references", subjectReference)).WithError(err)
+	}
+
+	var references []ocispecs.ReferenceDescriptor
+	for _, key := range annotationKeys {
+		value, ok := subjectManifest.Annotations[key]
+		if !ok {
+			continue
+		}
+		for _, entry := range strings.Split(value, ",") {
+			referenceDigest, err := digest.Parse(strings.TrimSpace(entry))
+			if err != nil {
+				logger.GetLogger(ctx, logOpt).Warnf("ignoring invalid digest %q in annotation %s of artifact %s: %v", entry, key, subjectReference, err)
+				continue
+			}
+			if referenceDigest == subjectDesc.Digest {
+				continue
+			}
+			reference, err := resolveAnnotationReference(ctx, repository, referenceDigest)
+			if err != nil {
+				if errors.Is(err, errdef.ErrNotFound) {
+					logger.GetLogger(ctx, logOpt).Warnf("manifest %s referenced by annotation %s of artifact %s does not exist", referenceDigest, key, subjectReference)
+					continue
+				}
+				evictOnError(ctx, err, subjectReference.Original)
+				return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to resolve the manifest %s referenced by annotation %s of the artifact %s", referenceDigest, key, subjectReference)).WithError(err)
+			}
+			references = append(references, reference)
+		}
+	}
+	return references, nil
+}
+
+// resolveAnnotationReference returns the reference descriptor of the manifest
+// with the digest. The artifact type falls back to the config media type as
+// for image manifests packaging artifacts.
+func resolveAnnotationReference(ctx context.Context, repository registry.Repository, referenceDigest digest.Digest) (ocispecs.ReferenceDescriptor, error) {
+	desc, err := repository.Resolve(ctx, referenceDigest.String())
+	if err != nil {
+		return ocispecs.ReferenceDescriptor{}, err
+	}
+	manifest, err := fetchAnnotatedManifest(ctx, repository, desc)
+	if err != nil {
+		return ocispecs.ReferenceDescriptor{}, err
+	}
+	artifactType := manifest.ArtifactType
+	if artifactType == "" && manifest.Config != nil {
+		artifactType = manifest.Config.MediaType
+	}
+	return ocispecs.ReferenceDescriptor{
+		ArtifactType: artifactType,
+		Descriptor: oci.Descriptor{
+			MediaType:    desc.MediaType,
+			Digest:       desc.Digest,
+			Size:         desc.Size,
+			ArtifactType: artifactType,
+		},
+	}, nil
+}
+
+func fetchAnnotatedManifest(ctx context.Context, repository registry.Repository, desc oci.Descriptor) (annotatedManifest, error) {
+	reader, err := repository.Fetch(ctx, desc)
+	if err != nil {
+		return annotatedManifest{}, err
+	}
+	defer reader.Close()
+	manifestBytes, err := io.ReadAll(reader)
+	if err != nil {
+		return annotatedManifest{}, err
+	}
+	var manifest annotatedManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return annotatedManifest{}, fmt.Errorf("failed to parse manifest %s: %w", desc.Digest, err)
+	}
+	return manifest, nil
+}
+
+// mergeReferences appends the additional references to the referrers, skipping
+// the ones whose digest is already listed.
+func mergeReferences(referrers []ocispecs.ReferenceDescriptor, additional []ocispecs.ReferenceDescriptor) []ocispecs.ReferenceDescriptor {
+	seen := make(map[digest.Digest]bool, len(referrers))
+	for _, referrer := range referrers {
+		seen[referrer.Digest] = true
+	}
+	for _, reference := range additional {
+		if seen[reference.Digest] {
+			continue
+		}
+		seen[reference.Digest] = true
+		referrers = append(referrers, reference)
+	}
+	return referrers
+}
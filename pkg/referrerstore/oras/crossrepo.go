@@ -0,0 +1,87 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+// attestationRepository returns the repository holding the attestations of
+// the subject and the reference of the subject digest in it, or a nil
+// repository if none is configured for the repository of the subject.
+func (store *orasStore) attestationRepository(ctx context.Context, subjectReference common.Reference, subjectDigest digest.Digest) (registry.Repository, common.Reference, error) {
+	path, ok := store.config.AttestationRepositories[subjectReference.Path]
+	if !ok {
+		return nil, common.Reference{}, nil
+	}
+	attestationRef := common.Reference{
+		Path:     path,
+		Digest:   subjectDigest,
+		Original: fmt.Sprintf("%s@%s", path, subjectDigest),
+	}
+	repository, err := store.createRepository(ctx, store, attestationRef)
+	if err != nil {
+		return nil, common.Reference{}, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to connect to the attestation repository %s", path)).WithError(err)
+	}
+	return repository, attestationRef, nil
+}
+
+// getAttestationReferrers returns the referrers of the subject stored in the
+// attestation repository configured for the repository of the subject.
+// Referrers whose manifest does not declare the subject digest as its subject
+// are skipped so that only attestations linked to the subject are listed.
+func (store *orasStore) getAttestationReferrers(ctx context.Context, subjectReference common.Reference, subjectDesc oci.Descriptor) ([]ocispecs.ReferenceDescriptor, error) {
+	repository, attestationRef, err := store.attestationRepository(ctx, subjectReference, subjectDesc.Digest)
+	if err != nil || repository == nil {
+		return nil, err
+	}
+
+	var referrerDescriptors []oci.Descriptor
+	if err := repository.Referrers(ctx, subjectDesc, "", func(referrers []oci.Descriptor) error {
+		referrerDescriptors = append(referrerDescriptors, referrers...)
+		return nil
+	}); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+		evictOnError(ctx, err, attestationRef.Original)
+		return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to list the referrers of the artifact %s in the attestation repository %s", subjectReference, attestationRef.Path)).WithError(err)
+	}
+
+	var referrers []ocispecs.ReferenceDescriptor
+	for _, referrer := range referrerDescriptors {
+		manifest, err := fetchAnnotatedManifest(ctx, repository, referrer)
+		if err != nil {
+			evictOnError(ctx, err, attestationRef.Original)
+			return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to fetch the manifest %s in the attestation repository %s", referrer.Digest, attestationRef.Path)).WithError(err)
+		}
+		if manifest.Subject == nil || manifest.Subject.Digest != subjectDesc.Digest {
+			logger.GetLogger(ctx, logOpt).Warnf("ignoring referrer %s in attestation repository %s not linked to artifact %s", referrer.Digest, attestationRef.Path, subjectReference)
+			continue
+		}
+		referrers = append(referrers, OciDescriptorToReferenceDescriptor(referrer))
+	}
+	return referrers, nil
+}
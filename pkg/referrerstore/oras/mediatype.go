@@ -0,0 +1,74 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oras
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/internal/logger"
+	"oras.land/oras-go/v2/registry"
+)
+
+const (
+	// MediaTypeMismatchNegotiate fetches a manifest returned with a media
+	// type other than the requested one again, accepting all the OCI and
+	// Docker v2 manifest media types.
+	MediaTypeMismatchNegotiate = "negotiate"
+	// MediaTypeMismatchFail fails to fetch a manifest returned with a media
+	// type other than the requested one.
+	MediaTypeMismatchFail = "fail"
+
+	// mediaTypeMismatchError is the message of the error oras-go returns when
+	// the media type of a fetched manifest is not the requested one.
+	mediaTypeMismatchError = "mismatch response Content-Type"
+)
+
+// validateManifestMediaTypeMismatch validates the handling of manifest media
+// type mismatches.
+func validateManifestMediaTypeMismatch(mismatch string) error {
+	switch mismatch {
+	case "", MediaTypeMismatchNegotiate, MediaTypeMismatchFail:
+		return nil
+	default:
+		return fmt.Errorf("manifestMediaTypeMismatch must be %s or %s, got %s", MediaTypeMismatchNegotiate, MediaTypeMismatchFail, mismatch)
+	}
+}
+
+// fetchManifest fetches the manifest of the descriptor and returns it along
+// with its media type. Some registries answer with a media type other than
+// the requested one, e.g. a Docker v2 manifest for an OCI image manifest of
+// the same digest. Unless configured to fail, the manifest is then fetched
+// again accepting all manifest media types and the media type of the
+// response is returned.
+func (store *orasStore) fetchManifest(ctx context.Context, repository registry.Repository, desc oci.Descriptor) (io.ReadCloser, string, error) {
+	manifestReader, err := repository.Fetch(ctx, desc)
+	if err == nil || store.config.ManifestMediaTypeMismatch == MediaTypeMismatchFail || !strings.Contains(err.Error(), mediaTypeMismatchError) {
+		return manifestReader, desc.MediaType, err
+	}
+	logger.GetLogger(ctx, logOpt).Infof("manifest %s was not returned with the requested media type %s, fetching it with any manifest media type: %v", desc.Digest, desc.MediaType, err)
+	negotiatedDesc, manifestReader, err := repository.FetchReference(ctx, desc.Digest.String())
+	if err != nil {
+		return nil, "", err
+	}
+	if negotiatedDesc.Digest != desc.Digest {
+		manifestReader.Close()
+		return nil, "", fmt.Errorf("manifest %s was returned with digest %s", desc.Digest, negotiatedDesc.Digest)
+	}
+	return manifestReader, negotiatedDesc.MediaType, nil
+}
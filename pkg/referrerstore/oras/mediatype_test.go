@@ -0,0 +1,134 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oras
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/config"
+)
+
+// newDockerManifestRegistry returns a fake registry serving the manifest as a
+// Docker v2 manifest whatever media type is requested.
+func newDockerManifestRegistry(manifestDigest digest.Digest, manifest []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/test/manifests/"+manifestDigest.String() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispecs.MediaTypeDockerManifest)
+		w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+		w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(manifest)
+		}
+	}))
+}
+
+func TestORASGetReferenceManifest_MediaTypeMismatch(t *testing.T) {
+	layer := oci.Descriptor{MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", Digest: digest.FromString("layer"), Size: 5}
+	manifest, err := json.Marshal(oci.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispecs.MediaTypeDockerManifest,
+		Config:    oci.Descriptor{MediaType: "application/vnd.docker.container.image.v1+json", Digest: digest.FromString("config"), Size: 6},
+		Layers:    []oci.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	manifestDigest := digest.FromBytes(manifest)
+	subjectDigest := digest.FromString("subject")
+
+	tests := []struct {
+		name      string
+		mismatch  string
+		expectErr bool
+	}{
+		{
+			name: "negotiated by default",
+		},
+		{
+			name:     "negotiated",
+			mismatch: MediaTypeMismatchNegotiate,
+		},
+		{
+			name:      "failed",
+			mismatch:  MediaTypeMismatchFail,
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newDockerManifestRegistry(manifestDigest, manifest)
+			defer server.Close()
+			uri, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+
+			storeConfig := config.StorePluginConfig{
+				"name":           "oras",
+				"useHttp":        true,
+				"localCachePath": t.TempDir(),
+			}
+			if tt.mismatch != "" {
+				storeConfig["manifestMediaTypeMismatch"] = tt.mismatch
+			}
+			store, err := createBaseStore("1.0.0", storeConfig)
+			if err != nil {
+				t.Fatalf("failed to create oras store: %v", err)
+			}
+			// the OCI image manifest media type is requested
+			referenceManifest, err := store.GetReferenceManifest(context.Background(), common.Reference{
+				Original: uri.Host + "/test@" + subjectDigest.String(),
+				Digest:   subjectDigest,
+				Path:     uri.Host + "/test",
+			}, ocispecs.ReferenceDescriptor{Descriptor: oci.Descriptor{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    manifestDigest,
+				Size:      int64(len(manifest)),
+			}})
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected the media type mismatch to fail the fetch")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the Docker v2 manifest to be negotiated, got %v", err)
+			}
+			if referenceManifest.MediaType != ocispecs.MediaTypeDockerManifest || len(referenceManifest.Blobs) != 1 || referenceManifest.Blobs[0].Digest != layer.Digest {
+				t.Fatalf("unexpected reference manifest %+v", referenceManifest)
+			}
+		})
+	}
+}
+
+func TestValidateManifestMediaTypeMismatch(t *testing.T) {
+	if _, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras", "manifestMediaTypeMismatch": "ignore"}); err == nil {
+		t.Fatalf("expected an error for an unknown manifestMediaTypeMismatch")
+	}
+}
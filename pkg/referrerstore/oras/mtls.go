@@ -0,0 +1,159 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+// RegistryTLSConfig describes the client certificate presented to a registry
+// that requires mutual TLS authentication. The certificate and its key are
+// either read from files or from key management providers.
+type RegistryTLSConfig struct {
+	// ClientCertPath is the path to the PEM encoded client certificate chain.
+	ClientCertPath string `json:"clientCertPath,omitempty"`
+	// ClientKeyPath is the path to the PEM encoded private key of the client certificate.
+	ClientKeyPath string `json:"clientKeyPath,omitempty"`
+	// ClientCertProvider is the name of the cluster-wide key management
+	// provider holding the client certificate chain.
+	ClientCertProvider string `json:"clientCertProvider,omitempty"`
+	// ClientKeyProvider is the name of the cluster-wide key management provider
+	// holding the private key of the client certificate, e.g. a kubernetes
+	// provider reading a Secret with contentType privateKey.
+	ClientKeyProvider string `json:"clientKeyProvider,omitempty"`
+	// CACertPath is the optional path to the PEM encoded CA bundle used to verify
+	// the registry. The system roots are used if not set.
+	CACertPath string `json:"caCertPath,omitempty"`
+}
+
+// createMTLSClients loads the client certificates of every registry configured
+// for mutual TLS and returns an http client per registry host.
+func createMTLSClients(conf *OrasStoreConf, retryPolicy func() retry.Policy) (map[string]*http.Client, error) {
+	if len(conf.RegistryTLS) == 0 {
+		return nil, nil
+	}
+	clients := make(map[string]*http.Client, len(conf.RegistryTLS))
+	for registryHost, tlsConf := range conf.RegistryTLS {
+		tlsConfig, err := loadClientTLSConfig(tlsConf)
+		if err != nil {
+			return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, storeName, re.EmptyLink, err, fmt.Sprintf("failed to load mutual TLS configuration for registry %s", registryHost), re.HideStackTrace)
+		}
+		if isInsecureRegistry(registryHost, conf) {
+			tlsConfig.InsecureSkipVerify = true //nolint:gosec
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = HTTPMaxIdleConns
+		transport.MaxConnsPerHost = HTTPMaxConnsPerHost
+		transport.MaxIdleConnsPerHost = HTTPMaxIdleConnsPerHost
+		transport.TLSClientConfig = tlsConfig
+		retryTransport := retry.NewTransport(configureProxy(transport, conf.Proxy))
+		retryTransport.Policy = retryPolicy
+		clients[registryHost] = &http.Client{Transport: retryTransport}
+	}
+	return clients, nil
+}
+
+func loadClientTLSConfig(conf RegistryTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	fromFiles := conf.ClientCertPath != "" || conf.ClientKeyPath != ""
+	fromProviders := conf.ClientCertProvider != "" || conf.ClientKeyProvider != ""
+	switch {
+	case fromFiles && fromProviders:
+		return nil, fmt.Errorf("the client certificate must either be read from files or from key management providers")
+	case fromProviders:
+		if conf.ClientCertProvider == "" || conf.ClientKeyProvider == "" {
+			return nil, fmt.Errorf("both clientCertProvider and clientKeyProvider must be specified")
+		}
+		// the key management providers are read on every handshake so that
+		// rotated certificates are presented without restarting
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			clientCert, err := providerClientCertificate(context.Background(), conf.ClientCertProvider, conf.ClientKeyProvider)
+			if err != nil {
+				logger.GetLogger(context.Background(), logOpt).Errorf("failed to load the client certificate for mutual TLS: %v", err)
+				return nil, err
+			}
+			return clientCert, nil
+		}
+	default:
+		if conf.ClientCertPath == "" || conf.ClientKeyPath == "" {
+			return nil, fmt.Errorf("both clientCertPath and clientKeyPath must be specified")
+		}
+		clientCert, err := tls.LoadX509KeyPair(conf.ClientCertPath, conf.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s and key %s: %w", conf.ClientCertPath, conf.ClientKeyPath, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	if conf.CACertPath != "" {
+		caBytes, err := os.ReadFile(conf.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate %s: %w", conf.CACertPath, err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caBytes) {
+			return nil, fmt.Errorf("no valid PEM certificate found in CA certificate %s", conf.CACertPath)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	return tlsConfig, nil
+}
+
+// providerClientCertificate returns the client certificate held by the
+// certificate provider whose leaf matches a private key held by the key
+// provider.
+func providerClientCertificate(ctx context.Context, certProvider, keyProvider string) (*tls.Certificate, error) {
+	certs, err := kmp.GetCertificatesFromMap(ctx, certProvider)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := kmp.GetSignersFromMap(ctx, keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range certs {
+		if len(chain) == 0 {
+			continue
+		}
+		leafKey, ok := chain[0].PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+		if !ok {
+			continue
+		}
+		for _, signer := range signers {
+			if !leafKey.Equal(signer.Public()) {
+				continue
+			}
+			clientCert := &tls.Certificate{PrivateKey: signer, Leaf: chain[0]}
+			for _, cert := range chain {
+				clientCert.Certificate = append(clientCert.Certificate, cert.Raw)
+			}
+			return clientCert, nil
+		}
+	}
+	return nil, fmt.Errorf("key management provider %s holds no certificate matching a private key of key management provider %s", certProvider, keyProvider)
+}
@@ -0,0 +1,299 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/referrerstore/config"
+)
+
+// writeClientCertificate generates a CA and a client certificate issued by it,
+// writes the client certificate and key to dir and returns the CA certificate
+// with the paths of the written files.
+func writeClientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate client key: %v", err)
+	}
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, caCert, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create client certificate: %v", err)
+	}
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("failed to marshal client key: %v", err)
+	}
+
+	certPath := filepath.Join(dir, "client.crt")
+	keyPath := filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER}), 0600); err != nil {
+		t.Fatalf("failed to write client certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: clientKeyDER}), 0600); err != nil {
+		t.Fatalf("failed to write client key: %v", err)
+	}
+	return caCert, certPath, keyPath
+}
+
+// saveClientCertificateProviders saves the client certificate and key as the
+// material of the client-cert and client-key key management providers.
+func saveClientCertificateProviders(t *testing.T, certPath, keyPath string) {
+	t.Helper()
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatalf("failed to read client certificate: %v", err)
+	}
+	certs, err := kmp.DecodeCertificates(certPEM)
+	if err != nil {
+		t.Fatalf("failed to decode client certificate: %v", err)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatalf("failed to read client key: %v", err)
+	}
+	signer, err := kmp.DecodePrivateKey(keyPEM)
+	if err != nil {
+		t.Fatalf("failed to decode client key: %v", err)
+	}
+	kmp.SaveSecrets("client-cert", "inline", nil, map[kmp.KMPMapKey][]*x509.Certificate{{Name: "client.crt"}: certs})
+	kmp.SaveSigners("client-key", map[kmp.KMPMapKey]crypto.Signer{{Name: "client.key"}: signer})
+	t.Cleanup(func() {
+		kmp.DeleteResourceFromMap("client-cert")
+		kmp.DeleteResourceFromMap("client-key")
+	})
+}
+
+// TestORASMutualTLS tests that the client certificate configured for a registry
+// is presented to a server requiring and verifying client certificates
+func TestORASMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	caCert, certPath, keyPath := writeClientCertificate(t, dir)
+	manifestDigest := digest.FromString("test")
+	saveClientCertificateProviders(t, certPath, keyPath)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && r.URL.Path == "/v2/test/manifests/latest" {
+			w.Header().Set("Content-Type", oci.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+			w.Header().Set("Content-Length", "10")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCert)
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	ref := common.Reference{
+		Original: uri.Host + "/test:latest",
+		Tag:      "latest",
+		Path:     uri.Host + "/test",
+	}
+
+	tests := []struct {
+		name      string
+		conf      config.StorePluginConfig
+		expectErr bool
+	}{
+		{
+			name: "client certificate presented",
+			conf: config.StorePluginConfig{
+				"name": "oras",
+				"registryTLS": map[string]interface{}{
+					uri.Host: map[string]interface{}{
+						"clientCertPath": certPath,
+						"clientKeyPath":  keyPath,
+					},
+				},
+			},
+		},
+		{
+			name: "client certificate from key management providers",
+			conf: config.StorePluginConfig{
+				"name": "oras",
+				"registryTLS": map[string]interface{}{
+					uri.Host: map[string]interface{}{
+						"clientCertProvider": "client-cert",
+						"clientKeyProvider":  "client-key",
+					},
+				},
+			},
+		},
+		{
+			name: "key management provider without the private key",
+			conf: config.StorePluginConfig{
+				"name": "oras",
+				"registryTLS": map[string]interface{}{
+					uri.Host: map[string]interface{}{
+						"clientCertProvider": "client-cert",
+						"clientKeyProvider":  "missing-key",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no client certificate",
+			conf: config.StorePluginConfig{
+				"name": "oras",
+			},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := createBaseStore("1.0.0", tt.conf)
+			if err != nil {
+				t.Fatalf("failed to create oras store: %v", err)
+			}
+			desc, err := store.GetSubjectDescriptor(context.Background(), ref)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error without client certificate")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if desc.Digest != manifestDigest {
+				t.Fatalf("expected digest %s, got %s", manifestDigest, desc.Digest)
+			}
+		})
+	}
+}
+
+func TestORASMutualTLS_InvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	_, certPath, _ := writeClientCertificate(t, dir)
+	tests := []struct {
+		name      string
+		tlsConfig map[string]interface{}
+	}{
+		{
+			name: "missing key path",
+			tlsConfig: map[string]interface{}{
+				"clientCertPath": certPath,
+			},
+		},
+		{
+			name: "key file not found",
+			tlsConfig: map[string]interface{}{
+				"clientCertPath": certPath,
+				"clientKeyPath":  filepath.Join(dir, "missing.key"),
+			},
+		},
+		{
+			name: "missing key provider",
+			tlsConfig: map[string]interface{}{
+				"clientCertProvider": "client-cert",
+			},
+		},
+		{
+			name: "files and key management providers",
+			tlsConfig: map[string]interface{}{
+				"clientCertPath":     certPath,
+				"clientKeyPath":      filepath.Join(dir, "client.key"),
+				"clientCertProvider": "client-cert",
+				"clientKeyProvider":  "client-key",
+			},
+		},
+		{
+			name: "invalid CA bundle",
+			tlsConfig: map[string]interface{}{
+				"clientCertPath": certPath,
+				"clientKeyPath":  filepath.Join(dir, "client.key"),
+				"caCertPath":     filepath.Join(dir, "client.key"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.StorePluginConfig{
+				"name": "oras",
+				"registryTLS": map[string]interface{}{
+					"registry.example.com": tt.tlsConfig,
+				},
+			}
+			if _, err := createBaseStore("1.0.0", conf); err == nil {
+				t.Fatalf("expected error loading mutual TLS configuration")
+			}
+		})
+	}
+}
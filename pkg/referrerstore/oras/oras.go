@@ -28,6 +28,7 @@ import (
 	"time"
 
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	orasgo "oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	ocitarget "oras.land/oras-go/v2/content/oci"
 	"oras.land/oras-go/v2/errdef"
@@ -48,6 +49,7 @@ import (
 	_ "github.com/ratify-project/ratify/pkg/common/oras/authprovider/aws"   // register aws auth provider
 	_ "github.com/ratify-project/ratify/pkg/common/oras/authprovider/azure" // register azure auth provider
 	commonutils "github.com/ratify-project/ratify/pkg/common/utils"
+	"github.com/ratify-project/ratify/pkg/executor/membudget"
 	"github.com/ratify-project/ratify/pkg/homedir"
 	"github.com/ratify-project/ratify/pkg/metrics"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
@@ -70,6 +72,10 @@ const (
 	defaultLocalCachePath = "local_oras_cache"
 	dockerConfigFileName  = "config.json"
 	ratifyUserAgent       = "ratify"
+	// validateAuthTimeout bounds the validation of the auth provider
+	// credentials so that an unreachable identity provider does not block the
+	// creation of the store.
+	validateAuthTimeout = 30 * time.Second
 )
 
 var logOpt = logger.Option{ComponentType: logger.ReferrerStore}
@@ -81,6 +87,43 @@ type OrasStoreConf struct { //nolint:revive // ignore linter to have unique type
 	CosignEnabled  bool                            `json:"cosignEnabled,omitempty"`
 	AuthProvider   authprovider.AuthProviderConfig `json:"authProvider,omitempty"`
 	LocalCachePath string                          `json:"localCachePath,omitempty"`
+	// RegistryAuthProviders selects the auth provider of the registries
+	// matching a host pattern, e.g. *.azurecr.io. The first matching pattern
+	// wins and registries matching none use AuthProvider.
+	RegistryAuthProviders []authprovider.RegistryAuthProviderConfig `json:"registryAuthProviders,omitempty"`
+	// RegistryTLS maps a registry host to the client certificate presented to it
+	// for mutual TLS authentication.
+	RegistryTLS map[string]RegistryTLSConfig `json:"registryTLS,omitempty"`
+	// ReferenceAnnotations lists the annotation keys of a subject manifest
+	// whose values declare referrers by the digests of other manifests in the
+	// repository. They are listed along with the referrers API results.
+	ReferenceAnnotations []string `json:"referenceAnnotations,omitempty"`
+	// AttestationRepositories maps the repository of an image, e.g.
+	// myregistry.io/app, to the repository its attestations are stored in.
+	// Referrers of the image in the attestation repository are listed along
+	// with the ones in the repository of the image.
+	AttestationRepositories map[string]string `json:"attestationRepositories,omitempty"`
+	// Proxy is the forward proxy requests to registries are sent through. The
+	// proxy of the environment is used if not set.
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+	// TokenScopes maps an operation of the store, e.g. listReferrers, to the
+	// additional scopes requested with the bearer tokens of the operation.
+	// Scopes are either full scopes, e.g. registry:catalog:*, or actions on
+	// the repository of the subject, e.g. metadata_read.
+	TokenScopes map[string][]string `json:"tokenScopes,omitempty"`
+	// ValidateAuth validates the credentials of the auth provider when the
+	// store is created. The store fails to be created if they are invalid, and
+	// Ratify is not ready while a cluster-scoped store fails validation.
+	ValidateAuth bool `json:"validateAuth,omitempty"`
+	// RegistryReferrers maps a registry host to the override of how the
+	// referrers of its subjects are discovered. Registries not listed use the
+	// referrers API of the distribution spec.
+	RegistryReferrers map[string]ReferrersConfig `json:"registryReferrers,omitempty"`
+	// ManifestMediaTypeMismatch is how manifests returned with a media type
+	// other than the requested one are handled: negotiate (default) fetches
+	// them again accepting any OCI or Docker v2 manifest media type, fail
+	// fails the fetch.
+	ManifestMediaTypeMismatch string `json:"manifestMediaTypeMismatch,omitempty"`
 }
 
 type orasStoreFactory struct{}
@@ -92,6 +135,7 @@ type orasStore struct {
 	authProvider       authprovider.AuthProvider
 	httpClient         *http.Client
 	httpClientInsecure *http.Client
+	mtlsHTTPClients    map[string]*http.Client
 	createRepository   func(ctx context.Context, store *orasStore, targetRef common.Reference) (registry.Repository, error)
 }
 
@@ -132,11 +176,35 @@ func createBaseStore(version string, storeConfig config.StorePluginConfig) (*ora
 		return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, "", re.EmptyLink, err, "failed to parse oras store configuration", re.HideStackTrace)
 	}
 
-	authenticationProvider, err := authprovider.CreateAuthProviderFromConfig(conf.AuthProvider)
+	authenticationProvider, err := authprovider.CreateRegistryAuthProvider(conf.AuthProvider, conf.RegistryAuthProviders)
 	if err != nil {
 		return nil, re.ErrorCodePluginInitFailure.NewError(re.ReferrerStore, "", re.EmptyLink, err, "failed to create auth provider from configuration", re.HideStackTrace)
 	}
 
+	if conf.ValidateAuth {
+		ctx, cancel := context.WithTimeout(context.Background(), validateAuthTimeout)
+		err := authenticationProvider.Validate(ctx)
+		cancel()
+		if err != nil {
+			return nil, re.ErrorCodePluginInitFailure.NewError(re.ReferrerStore, storeName, re.EmptyLink, fmt.Errorf("%w: %w", authprovider.ErrInvalidCredentials, err), "failed to validate auth provider credentials", re.HideStackTrace)
+		}
+	}
+
+	if err := validateProxyConfig(conf.Proxy); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, storeName, re.EmptyLink, err, "invalid proxy configuration", re.HideStackTrace)
+	}
+
+	if err := validateTokenScopes(conf.TokenScopes); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, storeName, re.EmptyLink, err, "invalid token scopes configuration", re.HideStackTrace)
+	}
+
+	if err := validateRegistryReferrers(conf.RegistryReferrers); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, storeName, re.EmptyLink, err, "invalid registry referrers configuration", re.HideStackTrace)
+	}
+	if err := validateManifestMediaTypeMismatch(conf.ManifestMediaTypeMismatch); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, storeName, re.EmptyLink, err, "invalid manifest media type mismatch configuration", re.HideStackTrace)
+	}
+
 	// Set up the local cache where content will land when we pull
 	if conf.LocalCachePath == "" {
 		conf.LocalCachePath = paths.Join(homedir.Get(), ratifyconfig.ConfigFileDir, defaultLocalCachePath)
@@ -173,7 +241,7 @@ func createBaseStore(version string, storeConfig config.StorePluginConfig) (*ora
 	secureTransport.MaxIdleConns = HTTPMaxIdleConns
 	secureTransport.MaxConnsPerHost = HTTPMaxConnsPerHost
 	secureTransport.MaxIdleConnsPerHost = HTTPMaxIdleConnsPerHost
-	secureRetryTransport := retry.NewTransport(secureTransport)
+	secureRetryTransport := retry.NewTransport(configureProxy(secureTransport, conf.Proxy))
 	secureRetryTransport.Policy = customRetryPolicy
 
 	// define the http client for TLS disabled
@@ -185,18 +253,32 @@ func createBaseStore(version string, storeConfig config.StorePluginConfig) (*ora
 	insecureTransport.TLSClientConfig = &tls.Config{
 		InsecureSkipVerify: true, //nolint:gosec
 	}
-	insecureRetryTransport := retry.NewTransport(insecureTransport)
+	insecureRetryTransport := retry.NewTransport(configureProxy(insecureTransport, conf.Proxy))
 	insecureRetryTransport.Policy = customRetryPolicy
 
+	// define the http clients for registries requiring mutual TLS
+	mtlsHTTPClients, err := createMTLSClients(&conf, customRetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+
 	return &orasStore{config: &conf,
 		rawConfig:          config.StoreConfig{Version: version, Store: storeConfig},
 		localCache:         localRegistry,
 		authProvider:       authenticationProvider,
 		httpClient:         &http.Client{Transport: secureRetryTransport},
 		httpClientInsecure: &http.Client{Transport: insecureRetryTransport},
+		mtlsHTTPClients:    mtlsHTTPClients,
 		createRepository:   createDefaultRepository}, nil
 }
 
+// Close stops the background work of the auth provider of the store, if any.
+func (store *orasStore) Close() {
+	if closer, ok := store.authProvider.(authprovider.Closer); ok {
+		closer.Close()
+	}
+}
+
 func (store *orasStore) Name() string {
 	return storeName
 }
@@ -206,6 +288,7 @@ func (store *orasStore) GetConfig() *config.StoreConfig {
 }
 
 func (store *orasStore) ListReferrers(ctx context.Context, subjectReference common.Reference, _ []string, _ string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	ctx = store.withTokenScopes(ctx, subjectReference, OperationListReferrers)
 	repository, err := store.createRepository(ctx, store, subjectReference)
 	if err != nil {
 		return referrerstore.ListReferrersResult{}, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to connect to the remote registry").WithError(err)
@@ -240,21 +323,38 @@ func (store *orasStore) ListReferrers(ctx context.Context, subjectReference comm
 	}
 
 	if store.config.CosignEnabled {
-		// add cosign descriptor if exists
+		// add cosign descriptor if exists. Signatures may be stored both as
+		// referrers and under the legacy tag scheme, the union is listed with
+		// a signature manifest found in both locations listed once.
 		cosignReferences, err := getCosignReferences(ctx, subjectReference, repository)
 		if err != nil {
 			return referrerstore.ListReferrersResult{}, err
 		}
 
 		if cosignReferences != nil {
-			referrers = append(referrers, *cosignReferences...)
+			referrers = mergeReferences(referrers, *cosignReferences)
 		}
 	}
 
+	if len(store.config.ReferenceAnnotations) > 0 {
+		annotationReferences, err := getAnnotationReferences(ctx, subjectReference, resolvedSubjectDesc.Descriptor, repository, store.config.ReferenceAnnotations)
+		if err != nil {
+			return referrerstore.ListReferrersResult{}, err
+		}
+		referrers = mergeReferences(referrers, annotationReferences)
+	}
+
+	attestationReferrers, err := store.getAttestationReferrers(ctx, subjectReference, resolvedSubjectDesc.Descriptor)
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, err
+	}
+	referrers = mergeReferences(referrers, attestationReferrers)
+
 	return referrerstore.ListReferrersResult{Referrers: referrers}, nil
 }
 
 func (store *orasStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	ctx = store.withTokenScopes(ctx, subjectReference, OperationFetchBlob)
 	var err error
 	var blobContent []byte
 
@@ -277,7 +377,7 @@ func (store *orasStore) GetBlobContent(ctx context.Context, subjectReference com
 	metrics.ReportBlobCacheCount(ctx, isCached)
 
 	if isCached {
-		blobContent, err = store.getRawContentFromCache(ctx, blobDescriptor)
+		blobContent, err = store.getBlobFromCache(ctx, blobDescriptor)
 		if err != nil {
 			isCached = false
 			logger.GetLogger(ctx, logOpt).Warnf("failed to get blob [%s] from cache: %v", blobDescriptor.Digest.String(), err)
@@ -285,18 +385,22 @@ func (store *orasStore) GetBlobContent(ctx context.Context, subjectReference com
 	}
 
 	if !isCached {
-		// generate the reference path with digest
-		ref := fmt.Sprintf("%s@%s", subjectReference.Path, digest)
-
-		// fetch blob content from remote repository
-		blobDesc, rc, err := repository.Blobs().FetchReference(ctx, ref)
+		blobDesc, rc, err := store.fetchBlob(ctx, repository, subjectReference, digest)
 		if err != nil {
-			evictOnError(ctx, err, subjectReference.Original)
-			return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to fetch the artifact metadata from the registry").WithError(err)
+			return nil, err
 		}
-		if blobContent, err = io.ReadAll(rc); err != nil {
+		defer rc.Close()
+		// the size of the blob is reserved from the memory budget until the
+		// verification holding it completes
+		if err = membudget.Reserve(ctx, blobDesc.Size); err != nil {
+			return nil, err
+		}
+		if blobContent, err = io.ReadAll(io.LimitReader(rc, blobDesc.Size+1)); err != nil {
 			return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to parse the artifact metadata").WithError(err)
 		}
+		if int64(len(blobContent)) > blobDesc.Size {
+			return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Blob %s exceeds its size of %d bytes", blobDesc.Digest, blobDesc.Size))
+		}
 
 		// push fetched content to local ORAS cache
 		// If multiple goroutines try to push the same blob to the cache, oras-go
@@ -310,12 +414,74 @@ func (store *orasStore) GetBlobContent(ctx context.Context, subjectReference com
 	return blobContent, nil
 }
 
+// GetBlobReader returns a reader streaming the blob with the given digest from
+// the local cache, or from the registry otherwise. Streamed blobs are neither
+// added to the local cache nor reserved from the memory budget since they are
+// never held in memory as a whole.
+func (store *orasStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	ctx = store.withTokenScopes(ctx, subjectReference, OperationFetchBlob)
+	repository, err := store.createRepository(ctx, store, subjectReference)
+	if err != nil {
+		return nil, re.ErrorCodeGetBlobContentFailure.WithDetail("Failed to connect to the remote registry").WithError(err)
+	}
+
+	blobDescriptor := oci.Descriptor{Digest: digest}
+	isCached, err := store.localCache.Exists(ctx, blobDescriptor)
+	if err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to check if blob [%s] exists in cache: %v", blobDescriptor.Digest.String(), err)
+	}
+	metrics.ReportBlobCacheCount(ctx, isCached)
+	if isCached {
+		reader, err := store.localCache.Fetch(ctx, blobDescriptor)
+		if err == nil {
+			return reader, nil
+		}
+		logger.GetLogger(ctx, logOpt).Warnf("failed to get blob [%s] from cache: %v", blobDescriptor.Digest.String(), err)
+	}
+
+	blobDesc, rc, err := store.fetchBlob(ctx, repository, subjectReference, digest)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, blobDesc.Size), rc}, nil
+}
+
+// fetchBlob fetches the blob with the given digest from the repository of the
+// subject, or from the repository of its attestations if it is not found.
+func (store *orasStore) fetchBlob(ctx context.Context, repository registry.Repository, subjectReference common.Reference, digest digest.Digest) (oci.Descriptor, io.ReadCloser, error) {
+	// generate the reference path with digest
+	ref := fmt.Sprintf("%s@%s", subjectReference.Path, digest)
+
+	// fetch blob content from remote repository
+	blobDesc, rc, err := repository.Blobs().FetchReference(ctx, ref)
+	if errors.Is(err, errdef.ErrNotFound) {
+		// the blob may belong to an attestation stored in another repository
+		attestationRepository, attestationRef, attestationErr := store.attestationRepository(ctx, subjectReference, subjectReference.Digest)
+		if attestationErr != nil {
+			return oci.Descriptor{}, nil, attestationErr
+		}
+		if attestationRepository != nil {
+			blobDesc, rc, err = attestationRepository.Blobs().FetchReference(ctx, fmt.Sprintf("%s@%s", attestationRef.Path, digest))
+		}
+	}
+	if err != nil {
+		evictOnError(ctx, err, subjectReference.Original)
+		return oci.Descriptor{}, nil, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to fetch the artifact metadata from the registry").WithError(err)
+	}
+	return blobDesc, rc, nil
+}
+
 func (store *orasStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	ctx = store.withTokenScopes(ctx, subjectReference, OperationFetchManifest)
 	repository, err := store.createRepository(ctx, store, subjectReference)
 	if err != nil {
 		return ocispecs.ReferenceManifest{}, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to connect to the remote registry").WithError(err)
 	}
 	var manifestBytes []byte
+	mediaType := referenceDesc.Descriptor.MediaType
 	// check if manifest exists in local ORAS cache
 	isCached, err := store.localCache.Exists(ctx, referenceDesc.Descriptor)
 	if err != nil {
@@ -333,7 +499,18 @@ func (store *orasStore) GetReferenceManifest(ctx context.Context, subjectReferen
 
 	if !isCached {
 		// fetch manifest content from repository
-		manifestReader, err := repository.Fetch(ctx, referenceDesc.Descriptor)
+		var manifestReader io.ReadCloser
+		manifestReader, mediaType, err = store.fetchManifest(ctx, repository, referenceDesc.Descriptor)
+		if errors.Is(err, errdef.ErrNotFound) {
+			// the manifest may be an attestation stored in another repository
+			attestationRepository, _, attestationErr := store.attestationRepository(ctx, subjectReference, subjectReference.Digest)
+			if attestationErr != nil {
+				return ocispecs.ReferenceManifest{}, attestationErr
+			}
+			if attestationRepository != nil {
+				manifestReader, mediaType, err = store.fetchManifest(ctx, attestationRepository, referenceDesc.Descriptor)
+			}
+		}
 		if err != nil {
 			evictOnError(ctx, err, subjectReference.Original)
 			return ocispecs.ReferenceManifest{}, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to fetch the artifact metadata from the registry").WithError(err)
@@ -357,24 +534,39 @@ func (store *orasStore) GetReferenceManifest(ctx context.Context, subjectReferen
 	referenceManifest := ocispecs.ReferenceManifest{}
 
 	// marshal manifest bytes into reference manifest descriptor
-	if referenceDesc.Descriptor.MediaType == oci.MediaTypeImageManifest {
+	// Docker image manifests share the layout of OCI image manifests
+	if mediaType == oci.MediaTypeImageManifest || mediaType == ocispecs.MediaTypeDockerManifest {
 		var imageManifest oci.Manifest
 		if err := json.Unmarshal(manifestBytes, &imageManifest); err != nil {
 			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.image.manifest.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
 		}
 		referenceManifest = commonutils.OciManifestToReferenceManifest(imageManifest)
-	} else if referenceDesc.Descriptor.MediaType == ocispecs.MediaTypeArtifactManifest {
+	} else if mediaType == ocispecs.MediaTypeArtifactManifest {
 		if err := json.Unmarshal(manifestBytes, &referenceManifest); err != nil {
 			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.artifact.manifest.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
 		}
+	} else if mediaType == oci.MediaTypeImageIndex || mediaType == ocispecs.MediaTypeDockerManifestList {
+		// Docker manifest lists share the layout of OCI image indexes
+		var index oci.Index
+		if err := json.Unmarshal(manifestBytes, &index); err != nil {
+			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.image.index.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
+		}
+		referenceManifest = ocispecs.ReferenceManifest{
+			MediaType:    index.MediaType,
+			ArtifactType: index.ArtifactType,
+			Subject:      index.Subject,
+			Annotations:  index.Annotations,
+			Manifests:    index.Manifests,
+		}
 	} else {
-		return ocispecs.ReferenceManifest{}, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Unsupported artifact metadata of media type %s", referenceDesc.Descriptor.MediaType)).WithRemediation("Please check if the artifact metadata was created correctly.")
+		return ocispecs.ReferenceManifest{}, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Unsupported artifact metadata of media type %s", mediaType)).WithRemediation("Please check if the artifact metadata was created correctly.")
 	}
 
 	return referenceManifest, nil
 }
 
 func (store *orasStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	ctx = store.withTokenScopes(ctx, subjectReference, OperationResolve)
 	repository, err := store.createRepository(ctx, store, subjectReference)
 	if err != nil {
 		return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to connect to remote registry").WithError(err)
@@ -389,6 +581,29 @@ func (store *orasStore) GetSubjectDescriptor(ctx context.Context, subjectReferen
 	return &ocispecs.SubjectDescriptor{Descriptor: desc}, nil
 }
 
+// PushReferrer pushes the blob as an artifact referring to the subject in the
+// repository of the subject.
+func (store *orasStore) PushReferrer(ctx context.Context, subjectReference common.Reference, subjectDesc oci.Descriptor, artifactType string, blob []byte, blobMediaType string) (oci.Descriptor, error) {
+	ctx = store.withTokenScopes(ctx, subjectReference, OperationPushReferrer)
+	repository, err := store.createRepository(ctx, store, subjectReference)
+	if err != nil {
+		return oci.Descriptor{}, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to connect to the remote registry").WithError(err)
+	}
+
+	blobDesc := content.NewDescriptorFromBytes(blobMediaType, blob)
+	if err := repository.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return oci.Descriptor{}, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to push the referrer blob of %s", subjectReference.Original)).WithError(err)
+	}
+	manifestDesc, err := orasgo.PackManifest(ctx, repository, orasgo.PackManifestVersion1_1, artifactType, orasgo.PackManifestOptions{
+		Subject: &subjectDesc,
+		Layers:  []oci.Descriptor{blobDesc},
+	})
+	if err != nil {
+		return oci.Descriptor{}, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to push the referrer manifest of %s", subjectReference.Original)).WithError(err)
+	}
+	return manifestDesc, nil
+}
+
 // evict from cache on non retry-able errors including 401 and 403
 func evictOnError(ctx context.Context, err error, subjectReference string) {
 	cacheProvider := cache.GetCacheProvider()
@@ -439,6 +654,9 @@ func createDefaultRepository(ctx context.Context, store *orasStore, targetRef co
 			logger.GetLogger(ctx, logOpt).Debug("attempting to use anonymous credentials")
 		} else if authConfig == (authprovider.AuthConfig{}) {
 			logger.GetLogger(ctx, logOpt).Debug("no credentials found, attempting to use anonymous credentials")
+		} else if authConfig.RegistryToken != "" {
+			// registry tokens are scoped to a repository and cannot be cached per registry
+			logger.GetLogger(ctx, logOpt).Debug("using registry token, skipping auth cache")
 		} else {
 			if cacheProvider != nil {
 				success := cacheProvider.SetWithTTL(ctx, fmt.Sprintf(cache.CacheKeyOrasAuth, artifactRef.Registry), authConfig, time.Until(authConfig.ExpiresOn))
@@ -457,11 +675,12 @@ func createDefaultRepository(ctx context.Context, store *orasStore, targetRef co
 
 	// set the provider to return the resolved credentials
 	credentialProvider := func(_ context.Context, _ string) (auth.Credential, error) {
-		if authConfig.Username != "" || authConfig.Password != "" || authConfig.IdentityToken != "" {
+		if authConfig.Username != "" || authConfig.Password != "" || authConfig.IdentityToken != "" || authConfig.RegistryToken != "" {
 			return auth.Credential{
 				Username:     authConfig.Username,
 				Password:     authConfig.Password,
 				RefreshToken: authConfig.IdentityToken,
+				AccessToken:  authConfig.RegistryToken,
 			}, nil
 		}
 		return auth.EmptyCredential, nil
@@ -482,6 +701,20 @@ func createDefaultRepository(ctx context.Context, store *orasStore, targetRef co
 	if isInsecureRegistry(targetRef.Original, store.config) {
 		repoClient.Client = store.httpClientInsecure
 	}
+	// present the client certificate if the registry requires mutual TLS
+	if mtlsClient, ok := store.mtlsHTTPClients[artifactRef.Registry]; ok {
+		repoClient.Client = mtlsClient
+	}
+	// discover the referrers as overridden for non-standard registries
+	if referrersConf, ok := store.config.RegistryReferrers[artifactRef.Registry]; ok {
+		if referrersConf.TagSchemaOnly {
+			if err := repository.SetReferrersCapability(false); err != nil {
+				return nil, err
+			}
+		} else if referrersConf.Path != "" {
+			repoClient.Client = withReferrersPath(repoClient.Client, referrersConf.Path)
+		}
+	}
 
 	repository.Client = repoClient
 	// enable plain HTTP if specified in config
@@ -490,6 +723,17 @@ func createDefaultRepository(ctx context.Context, store *orasStore, targetRef co
 	return repository, nil
 }
 
+// getBlobFromCache reads a blob from the local cache, reserving its size from
+// the memory budget like a blob fetched from the registry.
+func (store *orasStore) getBlobFromCache(ctx context.Context, descriptor oci.Descriptor) ([]byte, error) {
+	reader, err := store.localCache.Fetch(ctx, descriptor)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return membudget.ReadAll(ctx, reader)
+}
+
 func (store *orasStore) getRawContentFromCache(ctx context.Context, descriptor oci.Descriptor) ([]byte, error) {
 	reader, err := store.localCache.Fetch(ctx, descriptor)
 	if err != nil {
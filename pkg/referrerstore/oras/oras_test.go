@@ -31,12 +31,16 @@ import (
 
 	"github.com/opencontainers/go-digest"
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/pkg/cache"
 	"github.com/ratify-project/ratify/pkg/common"
+	_ "github.com/ratify-project/ratify/pkg/common/oras/authprovider/aws"
+	"github.com/ratify-project/ratify/pkg/executor/membudget"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore/config"
 	"github.com/ratify-project/ratify/pkg/referrerstore/oras/mocks"
 	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote/errcode"
 )
@@ -236,6 +240,311 @@ func TestORASListReferrers_NoSubjectDesc(t *testing.T) {
 	}
 }
 
+func TestORASListReferrers_AnnotationReferences(t *testing.T) {
+	conf := config.StorePluginConfig{
+		"name":                 "oras",
+		"referenceAnnotations": []string{"org.example.reference.sbom", "org.example.reference.signature"},
+	}
+	ctx := context.Background()
+	apiReferrerDigest := digest.FromString("apiReferrer")
+	sbomDigest := digest.FromString("sbom")
+	missingDigest := digest.FromString("missing")
+	store, err := createBaseStore("1.0.0", conf)
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+
+	subjectManifest, _ := json.Marshal(oci.Manifest{
+		MediaType: oci.MediaTypeImageManifest,
+		Annotations: map[string]string{
+			"org.example.reference.sbom":      sbomDigest.String() + ", " + missingDigest.String(),
+			"org.example.reference.signature": apiReferrerDigest.String(),
+			"org.example.unrelated":           digest.FromString("unrelated").String(),
+		},
+	})
+	sbomManifest, _ := json.Marshal(oci.Manifest{
+		MediaType: oci.MediaTypeImageManifest,
+		Config:    oci.Descriptor{MediaType: "application/spdx+json"},
+	})
+	apiReferrerManifest, _ := json.Marshal(oci.Manifest{
+		MediaType:    oci.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.cncf.notary.signature",
+	})
+	subjectDesc := ocispecs.SubjectDescriptor{
+		Descriptor: oci.Descriptor{
+			MediaType: oci.MediaTypeImageManifest,
+			Digest:    firstDigest,
+			Size:      int64(len(subjectManifest)),
+		},
+	}
+	testRepo := mocks.TestRepository{
+		ResolveMap: map[string]oci.Descriptor{
+			sbomDigest.String():        {MediaType: oci.MediaTypeImageManifest, Digest: sbomDigest, Size: int64(len(sbomManifest))},
+			apiReferrerDigest.String(): {MediaType: oci.MediaTypeImageManifest, Digest: apiReferrerDigest, Size: int64(len(apiReferrerManifest))},
+		},
+		ReferrersList: []oci.Descriptor{
+			{
+				MediaType:    oci.MediaTypeImageManifest,
+				Digest:       apiReferrerDigest,
+				ArtifactType: "application/vnd.cncf.notary.signature",
+			},
+		},
+		FetchMap: map[digest.Digest]io.ReadCloser{
+			firstDigest:       io.NopCloser(bytes.NewReader(subjectManifest)),
+			sbomDigest:        io.NopCloser(bytes.NewReader(sbomManifest)),
+			apiReferrerDigest: io.NopCloser(bytes.NewReader(apiReferrerManifest)),
+		},
+	}
+	store.createRepository = func(_ context.Context, _ *orasStore, _ common.Reference) (registry.Repository, error) {
+		return testRepo, nil
+	}
+	inputRef := common.Reference{
+		Original: inputOriginalPath,
+		Digest:   firstDigest,
+	}
+	referrers, err := store.ListReferrers(ctx, inputRef, []string{}, "", &subjectDesc)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+
+	// the annotated signature is also returned by the referrers API and the
+	// missing manifest is skipped
+	if len(referrers.Referrers) != 2 {
+		t.Fatalf("expected 2 referrers, got %d: %+v", len(referrers.Referrers), referrers.Referrers)
+	}
+	if referrers.Referrers[0].Digest != apiReferrerDigest || referrers.Referrers[0].ArtifactType != "application/vnd.cncf.notary.signature" {
+		t.Fatalf("expected API referrer %s first, got %+v", apiReferrerDigest, referrers.Referrers[0])
+	}
+	if referrers.Referrers[1].Digest != sbomDigest || referrers.Referrers[1].ArtifactType != "application/spdx+json" {
+		t.Fatalf("expected annotated referrer %s, got %+v", sbomDigest, referrers.Referrers[1])
+	}
+	if referrers.Referrers[1].MediaType != oci.MediaTypeImageManifest {
+		t.Fatalf("expected media type %s, got %s", oci.MediaTypeImageManifest, referrers.Referrers[1].MediaType)
+	}
+}
+
+func TestORASListReferrers_AnnotationReferencesFailure(t *testing.T) {
+	conf := config.StorePluginConfig{
+		"name":                 "oras",
+		"referenceAnnotations": []string{"org.example.reference.sbom"},
+	}
+	store, err := createBaseStore("1.0.0", conf)
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+	subjectDesc := ocispecs.SubjectDescriptor{
+		Descriptor: oci.Descriptor{
+			MediaType: oci.MediaTypeImageManifest,
+			Digest:    firstDigest,
+		},
+	}
+	// the subject manifest cannot be fetched
+	store.createRepository = func(_ context.Context, _ *orasStore, _ common.Reference) (registry.Repository, error) {
+		return mocks.TestRepository{}, nil
+	}
+	inputRef := common.Reference{
+		Original: inputOriginalPath,
+		Digest:   firstDigest,
+	}
+	if _, err := store.ListReferrers(context.Background(), inputRef, []string{}, "", &subjectDesc); err == nil {
+		t.Fatalf("expected error when the subject manifest cannot be fetched")
+	}
+}
+
+func TestORASListReferrers_CosignSignatureLocations(t *testing.T) {
+	referrerSigDigest := digest.FromString("referrerSignature")
+	tagSigDigest := digest.FromString("tagSignature")
+	signatureTag := fmt.Sprintf("localhost:5000/net-monitor:%s-%s.sig", firstDigest.Algorithm(), firstDigest.Hex())
+	referrerSig := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: referrerSigDigest, ArtifactType: CosignArtifactType}
+	testcases := []struct {
+		name            string
+		referrers       []oci.Descriptor
+		resolveMap      map[string]oci.Descriptor
+		expectedDigests []digest.Digest
+	}{
+		{
+			name:            "signature listed as referrer",
+			referrers:       []oci.Descriptor{referrerSig},
+			expectedDigests: []digest.Digest{referrerSigDigest},
+		},
+		{
+			name: "signature stored under tag",
+			resolveMap: map[string]oci.Descriptor{
+				signatureTag: {MediaType: oci.MediaTypeImageManifest, Digest: tagSigDigest},
+			},
+			expectedDigests: []digest.Digest{tagSigDigest},
+		},
+		{
+			name:      "signatures in both locations",
+			referrers: []oci.Descriptor{referrerSig},
+			resolveMap: map[string]oci.Descriptor{
+				signatureTag: {MediaType: oci.MediaTypeImageManifest, Digest: tagSigDigest},
+			},
+			expectedDigests: []digest.Digest{referrerSigDigest, tagSigDigest},
+		},
+		{
+			name:      "same signature in both locations",
+			referrers: []oci.Descriptor{referrerSig},
+			resolveMap: map[string]oci.Descriptor{
+				signatureTag: {MediaType: oci.MediaTypeImageManifest, Digest: referrerSigDigest},
+			},
+			expectedDigests: []digest.Digest{referrerSigDigest},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := config.StorePluginConfig{
+				"name":          "oras",
+				"cosignEnabled": true,
+			}
+			store, err := createBaseStore("1.0.0", conf)
+			if err != nil {
+				t.Fatalf("failed to create oras store: %v", err)
+			}
+			store.createRepository = func(_ context.Context, _ *orasStore, _ common.Reference) (registry.Repository, error) {
+				return mocks.TestRepository{
+					ReferrersList: tc.referrers,
+					ResolveMap:    tc.resolveMap,
+				}, nil
+			}
+			subjectDesc := ocispecs.SubjectDescriptor{
+				Descriptor: oci.Descriptor{
+					MediaType: oci.MediaTypeImageManifest,
+					Digest:    firstDigest,
+				},
+			}
+			inputRef := common.Reference{
+				Original: inputOriginalPath,
+				Path:     "localhost:5000/net-monitor",
+				Digest:   firstDigest,
+			}
+			result, err := store.ListReferrers(context.Background(), inputRef, []string{}, "", &subjectDesc)
+			if err != nil {
+				t.Fatalf("failed to list referrers: %v", err)
+			}
+			digests := []digest.Digest{}
+			for _, referrer := range result.Referrers {
+				if referrer.ArtifactType != CosignArtifactType {
+					t.Fatalf("expected artifact type %s, got %s", CosignArtifactType, referrer.ArtifactType)
+				}
+				digests = append(digests, referrer.Digest)
+			}
+			if !reflect.DeepEqual(digests, tc.expectedDigests) {
+				t.Fatalf("expected signatures %v, got %v", tc.expectedDigests, digests)
+			}
+		})
+	}
+}
+
+func TestORASListReferrers_AttestationRepository(t *testing.T) {
+	conf := config.StorePluginConfig{
+		"name": "oras",
+		"attestationRepositories": map[string]string{
+			"localhost:5000/net-monitor": "localhost:5000/net-monitor-attestations",
+		},
+	}
+	ctx := context.Background()
+	store, err := createBaseStore("1.0.0", conf)
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+
+	linkedDigest := digest.FromString("cross-repo-linked-signature")
+	unlinkedDigest := digest.FromString("cross-repo-unlinked-signature")
+	imageReferrerDigest := digest.FromString("cross-repo-sbom")
+	signatureBlob := []byte("cross-repo-signature-blob")
+	signatureBlobDigest := digest.FromBytes(signatureBlob)
+	subjectDesc := ocispecs.SubjectDescriptor{
+		Descriptor: oci.Descriptor{
+			MediaType: oci.MediaTypeImageManifest,
+			Digest:    firstDigest,
+		},
+	}
+	linkedManifest, _ := json.Marshal(oci.Manifest{
+		MediaType:    oci.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.cncf.notary.signature",
+		Layers:       []oci.Descriptor{{MediaType: "application/jose+json", Digest: signatureBlobDigest, Size: int64(len(signatureBlob))}},
+		Subject:      &subjectDesc.Descriptor,
+	})
+	unlinkedManifest, _ := json.Marshal(oci.Manifest{
+		MediaType:    oci.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.cncf.notary.signature",
+		Subject:      &oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("other subject")},
+	})
+	linkedDesc := oci.Descriptor{
+		MediaType:    oci.MediaTypeImageManifest,
+		Digest:       linkedDigest,
+		Size:         int64(len(linkedManifest)),
+		ArtifactType: "application/vnd.cncf.notary.signature",
+	}
+	imageRepo := mocks.TestRepository{
+		ReferrersList: []oci.Descriptor{
+			{MediaType: oci.MediaTypeImageManifest, Digest: imageReferrerDigest, ArtifactType: "application/spdx+json"},
+		},
+	}
+	attestationRepo := mocks.TestRepository{
+		ReferrersList: []oci.Descriptor{
+			linkedDesc,
+			{MediaType: oci.MediaTypeImageManifest, Digest: unlinkedDigest, ArtifactType: "application/vnd.cncf.notary.signature"},
+		},
+		FetchMap: map[digest.Digest]io.ReadCloser{
+			linkedDigest:   io.NopCloser(bytes.NewReader(linkedManifest)),
+			unlinkedDigest: io.NopCloser(bytes.NewReader(unlinkedManifest)),
+		},
+		BlobStoreTest: mocks.TestBlobStore{
+			BlobMap: map[string]mocks.BlobPair{
+				fmt.Sprintf("localhost:5000/net-monitor-attestations@%s", signatureBlobDigest): {
+					Descriptor: oci.Descriptor{MediaType: "application/jose+json", Digest: signatureBlobDigest, Size: int64(len(signatureBlob))},
+					Reader:     io.NopCloser(bytes.NewReader(signatureBlob)),
+				},
+			},
+		},
+	}
+	store.createRepository = func(_ context.Context, _ *orasStore, targetRef common.Reference) (registry.Repository, error) {
+		if targetRef.Path == "localhost:5000/net-monitor-attestations" {
+			return attestationRepo, nil
+		}
+		return imageRepo, nil
+	}
+	inputRef := common.Reference{
+		Path:     "localhost:5000/net-monitor",
+		Original: inputOriginalPath,
+		Digest:   firstDigest,
+	}
+
+	referrers, err := store.ListReferrers(ctx, inputRef, []string{}, "", &subjectDesc)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	// the attestation not linked to the subject is skipped
+	if len(referrers.Referrers) != 2 {
+		t.Fatalf("expected 2 referrers, got %d: %+v", len(referrers.Referrers), referrers.Referrers)
+	}
+	if referrers.Referrers[0].Digest != imageReferrerDigest {
+		t.Fatalf("expected referrer %s of the image repository first, got %+v", imageReferrerDigest, referrers.Referrers[0])
+	}
+	if referrers.Referrers[1].Digest != linkedDigest {
+		t.Fatalf("expected referrer %s of the attestation repository, got %+v", linkedDigest, referrers.Referrers[1])
+	}
+
+	// the content of the attestation is fetched from the attestation repository
+	attestationRepo.FetchMap[linkedDigest] = io.NopCloser(bytes.NewReader(linkedManifest))
+	manifest, err := store.GetReferenceManifest(ctx, inputRef, referrers.Referrers[1])
+	if err != nil {
+		t.Fatalf("failed to get reference manifest: %v", err)
+	}
+	if len(manifest.Blobs) != 1 || manifest.Blobs[0].Digest != signatureBlobDigest {
+		t.Fatalf("expected blob %s, got %+v", signatureBlobDigest, manifest.Blobs)
+	}
+	blob, err := store.GetBlobContent(ctx, inputRef, signatureBlobDigest)
+	if err != nil {
+		t.Fatalf("failed to get blob content: %v", err)
+	}
+	if !bytes.Equal(blob, signatureBlob) {
+		t.Fatalf("expected blob content %s, got %s", signatureBlob, blob)
+	}
+}
+
 // TODO: add cosign test for List Referrers
 
 func TestORASGetReferenceManifest(t *testing.T) {
@@ -445,6 +754,31 @@ func TestORASGetReferenceManifest(t *testing.T) {
 			},
 			expectedErr: true,
 		},
+		{
+			name: "docker manifest is parsed as image manifest",
+			inputRef: common.Reference{
+				Original: inputOriginalPath,
+				Digest:   firstDigest,
+			},
+			referenceDesc: ocispecs.ReferenceDescriptor{
+				Descriptor: oci.Descriptor{
+					MediaType: ocispecs.MediaTypeDockerManifest,
+					Digest:    artifactDigest,
+				},
+			},
+			repo: mocks.TestRepository{
+				FetchMap: map[digest.Digest]io.ReadCloser{
+					artifactDigest: io.NopCloser(bytes.NewReader(manifestNotCachedBytes)),
+				},
+			},
+			localCache: mocks.TestStorage{
+				ExistsMap: map[digest.Digest]io.Reader{
+					artifactDigestNotCached: bytes.NewReader(manifestCachedBytesWithWrongType),
+				},
+			},
+			expectedErr:       false,
+			expectedMediaType: validReferenceMediatype,
+		},
 		{
 			name: "unsupported manifest media type",
 			inputRef: common.Reference{
@@ -528,6 +862,47 @@ func TestORASGetReferenceManifest(t *testing.T) {
 	}
 }
 
+func TestORASGetReferenceManifest_Index(t *testing.T) {
+	platformDigest := digest.FromString("windows")
+	index := oci.Index{
+		MediaType: oci.MediaTypeImageIndex,
+		Manifests: []oci.Descriptor{
+			{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    platformDigest,
+				Platform:  &oci.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.2227"},
+			},
+		},
+	}
+	indexBytes, _ := json.Marshal(index)
+	for _, mediaType := range []string{oci.MediaTypeImageIndex, ocispecs.MediaTypeDockerManifestList} {
+		t.Run(mediaType, func(t *testing.T) {
+			store, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras"})
+			if err != nil {
+				t.Fatalf("failed to create oras store: %v", err)
+			}
+			store.createRepository = func(_ context.Context, _ *orasStore, _ common.Reference) (registry.Repository, error) {
+				return mocks.TestRepository{
+					FetchMap: map[digest.Digest]io.ReadCloser{
+						artifactDigest: io.NopCloser(bytes.NewReader(indexBytes)),
+					},
+				}, nil
+			}
+			store.localCache = mocks.TestStorage{ExistsMap: map[digest.Digest]io.Reader{}}
+
+			manifest, err := store.GetReferenceManifest(context.Background(), common.Reference{Original: inputOriginalPath, Digest: firstDigest}, ocispecs.ReferenceDescriptor{
+				Descriptor: oci.Descriptor{MediaType: mediaType, Digest: artifactDigest},
+			})
+			if err != nil {
+				t.Fatalf("failed to get reference manifest: %v", err)
+			}
+			if len(manifest.Manifests) != 1 || manifest.Manifests[0].Digest != platformDigest || manifest.Manifests[0].Platform.OSVersion != "10.0.20348.2227" {
+				t.Fatalf("expected the platform manifests of the index, got %+v", manifest.Manifests)
+			}
+		})
+	}
+}
+
 func TestORASGetBlobContent(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -553,6 +928,7 @@ func TestORASGetBlobContent(t *testing.T) {
 						fmt.Sprintf("%s@%s", inputOriginalPath, blobDigest.String()): {
 							Descriptor: oci.Descriptor{
 								Digest: blobDigest,
+								Size:   int64(len("test content")),
 							},
 							Reader: io.NopCloser(bytes.NewReader([]byte("test content"))),
 						},
@@ -580,6 +956,7 @@ func TestORASGetBlobContent(t *testing.T) {
 						fmt.Sprintf("%s@%s", inputOriginalPath, blobDigest.String()): {
 							Descriptor: oci.Descriptor{
 								Digest: blobDigest,
+								Size:   int64(len("test content")),
 							},
 							Reader: io.NopCloser(bytes.NewReader([]byte("test content"))),
 						},
@@ -652,6 +1029,7 @@ func TestORASGetBlobContent(t *testing.T) {
 						fmt.Sprintf("%s@%s", inputOriginalPath, blobDigest.String()): {
 							Descriptor: oci.Descriptor{
 								Digest: blobDigest,
+								Size:   int64(len("test content")),
 							},
 							Reader: io.NopCloser(bytes.NewReader([]byte("test content"))),
 						},
@@ -679,6 +1057,7 @@ func TestORASGetBlobContent(t *testing.T) {
 						fmt.Sprintf("%s@%s", inputOriginalPath, blobDigest.String()): {
 							Descriptor: oci.Descriptor{
 								Digest: blobDigest,
+								Size:   int64(len("test content")),
 							},
 							Reader: io.NopCloser(bytes.NewReader([]byte("test content"))),
 						},
@@ -707,6 +1086,7 @@ func TestORASGetBlobContent(t *testing.T) {
 						fmt.Sprintf("%s@%s", inputOriginalPath, blobDigest.String()): {
 							Descriptor: oci.Descriptor{
 								Digest: blobDigest,
+								Size:   int64(len("test content")),
 							},
 							Reader: io.NopCloser(bytes.NewReader([]byte("test content"))),
 						},
@@ -757,6 +1137,164 @@ func TestORASGetBlobContent(t *testing.T) {
 	}
 }
 
+func TestORASGetBlobContent_MemoryBudget(t *testing.T) {
+	blobContent := []byte("test content")
+	budget, err := membudget.NewBudget(membudget.Config{MaxBytes: 20, MaxWait: "20ms"})
+	if err != nil {
+		t.Fatalf("failed to create memory budget: %v", err)
+	}
+
+	getBlobContent := func(ctx context.Context, size int64, localCache mocks.TestStorage) ([]byte, error) {
+		store, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras"})
+		if err != nil {
+			t.Fatalf("failed to create oras store: %v", err)
+		}
+		store.createRepository = func(_ context.Context, _ *orasStore, _ common.Reference) (registry.Repository, error) {
+			return mocks.TestRepository{
+				BlobStoreTest: mocks.TestBlobStore{
+					BlobMap: map[string]mocks.BlobPair{
+						fmt.Sprintf("%s@%s", inputOriginalPath, blobDigest.String()): {
+							Descriptor: oci.Descriptor{
+								Digest: blobDigest,
+								Size:   size,
+							},
+							Reader: io.NopCloser(bytes.NewReader(blobContent)),
+						},
+					},
+				},
+			}, nil
+		}
+		store.localCache = localCache
+		return store.GetBlobContent(ctx, common.Reference{Original: inputOriginalPath, Path: inputOriginalPath, Digest: firstDigest}, blobDigest)
+	}
+	emptyCache := func() mocks.TestStorage {
+		return mocks.TestStorage{ExistsMap: map[digest.Digest]io.Reader{}}
+	}
+	expectBusy := func(t *testing.T, size int64) {
+		t.Helper()
+		release, err := budget.Acquire(context.Background(), size)
+		if err == nil {
+			release()
+			t.Fatalf("expected the verification to hold the memory budget")
+		}
+	}
+	expectFree := func(t *testing.T) {
+		t.Helper()
+		release, err := budget.Acquire(context.Background(), 20)
+		if err != nil {
+			t.Fatalf("expected the memory budget to be released, got %v", err)
+		}
+		release()
+	}
+
+	t.Run("busy budget", func(t *testing.T) {
+		ctx, releaseLease := membudget.WithBudget(context.Background(), budget)
+		defer releaseLease()
+		// another verification holds most of the budget
+		release, err := budget.Acquire(context.Background(), 10)
+		if err != nil {
+			t.Fatalf("failed to acquire memory budget: %v", err)
+		}
+		defer release()
+		_, err = getBlobContent(ctx, int64(len(blobContent)), emptyCache())
+		var ratifyErr re.Error
+		if !errors.As(err, &ratifyErr) || ratifyErr.ErrorCode() != re.ErrorCodeResourceBusy {
+			t.Fatalf("expected error code %s, got %v", re.ErrorCodeResourceBusy, err)
+		}
+	})
+
+	t.Run("fetched blob is held until the verification completes", func(t *testing.T) {
+		ctx, releaseLease := membudget.WithBudget(context.Background(), budget)
+		content, err := getBlobContent(ctx, int64(len(blobContent)), emptyCache())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !bytes.Equal(content, blobContent) {
+			t.Fatalf("expected content %s, got %s", blobContent, content)
+		}
+		expectBusy(t, 20)
+		releaseLease()
+		expectFree(t)
+	})
+
+	t.Run("cached blob is held until the verification completes", func(t *testing.T) {
+		ctx, releaseLease := membudget.WithBudget(context.Background(), budget)
+		localCache := mocks.TestStorage{ExistsMap: map[digest.Digest]io.Reader{blobDigest: bytes.NewReader(blobContent)}}
+		content, err := getBlobContent(ctx, int64(len(blobContent)), localCache)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !bytes.Equal(content, blobContent) {
+			t.Fatalf("expected content %s, got %s", blobContent, content)
+		}
+		expectBusy(t, 20)
+		releaseLease()
+		expectFree(t)
+	})
+
+	t.Run("blob larger than its descriptor", func(t *testing.T) {
+		ctx, releaseLease := membudget.WithBudget(context.Background(), budget)
+		defer releaseLease()
+		if _, err := getBlobContent(ctx, 4, emptyCache()); err == nil {
+			t.Fatalf("expected error reading past the size of the blob")
+		}
+	})
+}
+
+func TestORASGetBlobReader(t *testing.T) {
+	blobContent := []byte("test content")
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras"})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+	store.createRepository = func(_ context.Context, _ *orasStore, _ common.Reference) (registry.Repository, error) {
+		return mocks.TestRepository{
+			BlobStoreTest: mocks.TestBlobStore{
+				BlobMap: map[string]mocks.BlobPair{
+					fmt.Sprintf("%s@%s", inputOriginalPath, blobDigest.String()): {
+						Descriptor: oci.Descriptor{
+							Digest: blobDigest,
+							Size:   int64(len(blobContent)),
+						},
+						Reader: io.NopCloser(bytes.NewReader(append(blobContent, "beyond the size"...))),
+					},
+				},
+			},
+		}, nil
+	}
+	localCache := mocks.TestStorage{ExistsMap: map[digest.Digest]io.Reader{}}
+	store.localCache = localCache
+	subjectReference := common.Reference{Original: inputOriginalPath, Path: inputOriginalPath, Digest: firstDigest}
+
+	reader, err := store.GetBlobReader(context.Background(), subjectReference, blobDigest)
+	if err != nil {
+		t.Fatalf("expected the blob to be streamed, got %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || !bytes.Equal(content, blobContent) {
+		t.Fatalf("expected the content to be limited to the blob size, got %q, err: %v", content, err)
+	}
+	if len(localCache.ExistsMap) != 0 {
+		t.Fatalf("expected streamed blobs not to be cached")
+	}
+
+	// cached blobs are streamed from the cache
+	localCache.ExistsMap[blobDigest] = bytes.NewReader([]byte("cached content"))
+	reader, err = store.GetBlobReader(context.Background(), subjectReference, blobDigest)
+	if err != nil {
+		t.Fatalf("expected the blob to be streamed, got %v", err)
+	}
+	defer reader.Close()
+	if content, err = io.ReadAll(reader); err != nil || string(content) != "cached content" {
+		t.Fatalf("expected the cached content, got %q, err: %v", content, err)
+	}
+
+	if _, err := store.GetBlobReader(context.Background(), subjectReference, digest.FromString("missing")); err == nil {
+		t.Fatalf("expected an error for a missing blob")
+	}
+}
+
 func Test_EvictOnError(t *testing.T) {
 	ctx := context.Background()
 	var err error
@@ -884,3 +1422,142 @@ func TestORASCreate_CacheProvider_Nil(t *testing.T) {
 		t.Fatalf("expected oras store")
 	}
 }
+
+// pushableRepository stores the pushed content in memory.
+type pushableRepository struct {
+	mocks.TestRepository
+	storage *memory.Store
+}
+
+func (r pushableRepository) Push(ctx context.Context, expected oci.Descriptor, content io.Reader) error {
+	return r.storage.Push(ctx, expected, content)
+}
+
+func (r pushableRepository) Exists(ctx context.Context, target oci.Descriptor) (bool, error) {
+	return r.storage.Exists(ctx, target)
+}
+
+func TestORASPushReferrer(t *testing.T) {
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras"})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+	storage := memory.New()
+	store.createRepository = func(_ context.Context, _ *orasStore, _ common.Reference) (registry.Repository, error) {
+		return pushableRepository{storage: storage}, nil
+	}
+	subjectDesc := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("subject"), Size: 7}
+	blob := []byte(`{"payloadType":"application/vnd.in-toto+json"}`)
+
+	manifestDesc, err := store.PushReferrer(context.Background(), common.Reference{Original: inputOriginalPath}, subjectDesc, "application/vnd.test.attestation", blob, "application/vnd.dsse.envelope.v1+json")
+	if err != nil {
+		t.Fatalf("failed to push referrer: %v", err)
+	}
+	manifestBytes, err := content.FetchAll(context.Background(), storage, manifestDesc)
+	if err != nil {
+		t.Fatalf("failed to fetch pushed manifest: %v", err)
+	}
+	var manifest oci.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		t.Fatalf("failed to unmarshal pushed manifest: %v", err)
+	}
+	if manifest.ArtifactType != "application/vnd.test.attestation" || manifest.Subject == nil || manifest.Subject.Digest != subjectDesc.Digest {
+		t.Fatalf("expected an artifact manifest referring to the subject, got %+v", manifest)
+	}
+	if len(manifest.Layers) != 1 || manifest.Layers[0].Digest != digest.FromBytes(blob) {
+		t.Fatalf("expected the blob as the single layer, got %+v", manifest.Layers)
+	}
+	if exists, _ := storage.Exists(context.Background(), manifest.Layers[0]); !exists {
+		t.Fatalf("expected the blob to be pushed")
+	}
+}
+
+// newECRPublicStyleRegistry returns a fake registry serving content
+// anonymously behind an ECR Public style auth challenge: the token endpoint
+// is at /token/, the challenge scope is aws and tokens are returned in the
+// token field.
+func newECRPublicStyleRegistry(t *testing.T, manifestDigest digest.Digest, tokenRequests *int) *httptest.Server {
+	t.Helper()
+	const anonymousToken = "anonymous-token"
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token/" {
+			*tokenRequests++
+			if r.Header.Get("Authorization") != "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"token":%q}`, anonymousToken)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+anonymousToken {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token/",service="public.ecr.aws",scope="aws"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodHead && r.URL.Path == "/v2/test/app/manifests/latest" {
+			w.Header().Set("Content-Type", oci.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+			w.Header().Set("Content-Length", "10")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	return server
+}
+
+func TestORASGetSubjectDescriptor_AnonymousTokenChallenge(t *testing.T) {
+	manifestDigest := digest.FromString("test")
+	tests := []struct {
+		name         string
+		authProvider map[string]interface{}
+	}{
+		{
+			name:         "docker config",
+			authProvider: map[string]interface{}{"name": "dockerConfig"},
+		},
+		{
+			name:         "AWS ECR provider without AWS credentials",
+			authProvider: map[string]interface{}{"name": "awsEcrBasic"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_REGION", "")
+			t.Setenv("AWS_ROLE_ARN", "")
+			t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+			t.Setenv("DOCKER_CONFIG", t.TempDir())
+			tokenRequests := 0
+			server := newECRPublicStyleRegistry(t, manifestDigest, &tokenRequests)
+			defer server.Close()
+			uri, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+
+			store, err := createBaseStore("1.0.0", config.StorePluginConfig{
+				"name":         "oras",
+				"useHttp":      true,
+				"authProvider": tt.authProvider,
+			})
+			if err != nil {
+				t.Fatalf("failed to create oras store: %v", err)
+			}
+			desc, err := store.GetSubjectDescriptor(context.Background(), common.Reference{
+				Original: uri.Host + "/test/app:latest",
+				Tag:      "latest",
+				Path:     uri.Host + "/test/app",
+			})
+			if err != nil {
+				t.Fatalf("expected the subject to be resolved anonymously, got %v", err)
+			}
+			if desc.Digest != manifestDigest {
+				t.Fatalf("expected digest %s, got %s", manifestDigest, desc.Digest)
+			}
+			if tokenRequests == 0 {
+				t.Fatalf("expected an anonymous token to be acquired through the auth challenge")
+			}
+		})
+	}
+}
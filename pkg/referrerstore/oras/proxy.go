@@ -0,0 +1,142 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const proxyAuthorizationHeader = "Proxy-Authorization"
+
+// ProxyConfig describes the forward proxy the requests to registries are sent
+// through. Its credentials are separate from the credentials of the
+// registries.
+type ProxyConfig struct {
+	// URL is the URL of the proxy, e.g. http://proxy.example.com:3128. If not
+	// set, the proxy is read from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	// environment variables.
+	URL string `json:"url,omitempty"`
+	// NoProxy is a comma separated list of hosts and domains reached without
+	// the proxy set by URL, "*" bypasses the proxy for all hosts.
+	NoProxy string `json:"noProxy,omitempty"`
+	// Username and Password are the basic auth credentials of the proxy.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// BearerToken is the token authenticating to the proxy with the bearer
+	// scheme. It cannot be set along with Username and Password.
+	BearerToken string `json:"bearerToken,omitempty"`
+}
+
+// proxyAuthTransport sets the proxy credentials on plain HTTP requests sent
+// through the proxy. Requests to HTTPS registries are tunneled and
+// authenticate with the CONNECT request instead.
+type proxyAuthTransport struct {
+	base          http.RoundTripper
+	proxy         func(*http.Request) (*url.URL, error)
+	authorization string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *proxyAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		if proxyURL, err := t.proxy(req); err == nil && proxyURL != nil {
+			req = req.Clone(req.Context())
+			req.Header.Set(proxyAuthorizationHeader, t.authorization)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// validateProxyConfig returns an error if the proxy configuration is invalid.
+func validateProxyConfig(conf *ProxyConfig) error {
+	if conf == nil {
+		return nil
+	}
+	if conf.URL != "" {
+		proxyURL, err := url.Parse(conf.URL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url: %w", err)
+		}
+		if (proxyURL.Scheme != "http" && proxyURL.Scheme != "https") || proxyURL.Host == "" {
+			return fmt.Errorf("proxy url %s must be an absolute http or https url", proxyURL.Redacted())
+		}
+		if proxyURL.User != nil && (conf.Username != "" || conf.BearerToken != "") {
+			return fmt.Errorf("proxy credentials must not be set both in the url and in the configuration")
+		}
+	}
+	if conf.BearerToken != "" && (conf.Username != "" || conf.Password != "") {
+		return fmt.Errorf("proxy bearerToken cannot be set along with username and password")
+	}
+	if conf.Password != "" && conf.Username == "" {
+		return fmt.Errorf("proxy password requires a username")
+	}
+	return nil
+}
+
+// configureProxy sets the proxy of the transport and returns the round tripper
+// authenticating to the proxy. The proxy of the environment is kept if the
+// configuration does not set a URL. The configuration must be validated.
+func configureProxy(transport *http.Transport, conf *ProxyConfig) http.RoundTripper {
+	if conf == nil {
+		return transport
+	}
+	if conf.URL != "" {
+		proxyURL, _ := url.Parse(conf.URL)
+		noProxy := conf.NoProxy
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if bypassProxy(req.URL.Hostname(), noProxy) {
+				return nil, nil
+			}
+			return proxyURL, nil
+		}
+	}
+
+	var authorization string
+	switch {
+	case conf.BearerToken != "":
+		authorization = "Bearer " + conf.BearerToken
+	case conf.Username != "":
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(conf.Username+":"+conf.Password))
+	default:
+		return transport
+	}
+	transport.ProxyConnectHeader = http.Header{proxyAuthorizationHeader: []string{authorization}}
+	proxy := transport.Proxy
+	if proxy == nil {
+		return transport
+	}
+	return &proxyAuthTransport{base: transport, proxy: proxy, authorization: authorization}
+}
+
+// bypassProxy returns true if the host matches an entry of the comma separated
+// noProxy list, either exactly or as a subdomain.
+func bypassProxy(host, noProxy string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(entry), "."))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" || host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
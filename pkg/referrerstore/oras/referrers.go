@@ -0,0 +1,114 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oras
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Placeholders of a referrers path override.
+const (
+	referrersPathRepository = "{repository}"
+	referrersPathDigest     = "{digest}"
+)
+
+const (
+	referrersSpecPathPrefix = "/v2/"
+	referrersSpecPathInfix  = "/referrers/"
+)
+
+// ReferrersConfig overrides how the referrers of the subjects in a registry
+// are discovered, for registries not serving the referrers API at the path
+// of the distribution spec.
+type ReferrersConfig struct {
+	// Path is the path of the referrers endpoint of the registry, e.g.
+	// /v2/{repository}/_oci/referrers/{digest}. The {repository} and {digest}
+	// placeholders are replaced by the repository and the digest of the
+	// subject. Defaults to the spec path /v2/{repository}/referrers/{digest}.
+	Path string `json:"path,omitempty"`
+	// TagSchemaOnly skips the referrers API and discovers the referrers with
+	// the referrers tag schema only.
+	TagSchemaOnly bool `json:"tagSchemaOnly,omitempty"`
+}
+
+// validateRegistryReferrers returns an error if a referrers override is
+// malformed.
+func validateRegistryReferrers(registryReferrers map[string]ReferrersConfig) error {
+	for registryHost, referrersConf := range registryReferrers {
+		if referrersConf.Path == "" {
+			continue
+		}
+		if referrersConf.TagSchemaOnly {
+			return fmt.Errorf("referrers path and tagSchemaOnly of registry %s cannot be set together", registryHost)
+		}
+		if !strings.HasPrefix(referrersConf.Path, "/") {
+			return fmt.Errorf("referrers path %s of registry %s must be absolute", referrersConf.Path, registryHost)
+		}
+		if !strings.Contains(referrersConf.Path, referrersPathRepository) || !strings.Contains(referrersConf.Path, referrersPathDigest) {
+			return fmt.Errorf("referrers path %s of registry %s must contain the %s and %s placeholders", referrersConf.Path, registryHost, referrersPathRepository, referrersPathDigest)
+		}
+	}
+	return nil
+}
+
+// withReferrersPath returns a client sending the referrers API requests of
+// client to the overridden path.
+func withReferrersPath(client *http.Client, path string) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	overridden := *client
+	overridden.Transport = &referrersPathTransport{base: base, path: path}
+	return &overridden
+}
+
+// referrersPathTransport rewrites the spec path of the referrers API requests
+// to the overridden path.
+type referrersPathTransport struct {
+	base http.RoundTripper
+	path string
+}
+
+func (t *referrersPathTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	repository, digest, ok := parseReferrersSpecPath(req.URL.Path)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Path = strings.NewReplacer(referrersPathRepository, repository, referrersPathDigest, digest).Replace(t.path)
+	req.URL.RawPath = ""
+	return t.base.RoundTrip(req)
+}
+
+// parseReferrersSpecPath returns the repository and the digest of a referrers
+// API request path of the distribution spec.
+func parseReferrersSpecPath(path string) (string, string, bool) {
+	if !strings.HasPrefix(path, referrersSpecPathPrefix) {
+		return "", "", false
+	}
+	index := strings.LastIndex(path, referrersSpecPathInfix)
+	if index <= len(referrersSpecPathPrefix) {
+		return "", "", false
+	}
+	repository := path[len(referrersSpecPathPrefix):index]
+	digest := path[index+len(referrersSpecPathInfix):]
+	if digest == "" || strings.Contains(digest, "/") {
+		return "", "", false
+	}
+	return repository, digest, true
+}
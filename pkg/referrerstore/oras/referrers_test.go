@@ -0,0 +1,203 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oras
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/config"
+)
+
+// newNonStandardReferrersRegistry returns a fake registry serving the
+// referrers of the subject at /v2/test/_oci/referrers/<digest> instead of the
+// spec path, and optionally under the referrers tag schema.
+func newNonStandardReferrersRegistry(t *testing.T, subjectDigest digest.Digest, referrer oci.Descriptor, serveTagSchema bool) *httptest.Server {
+	t.Helper()
+	index, err := json.Marshal(oci.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: oci.MediaTypeImageIndex,
+		Manifests: []oci.Descriptor{referrer},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal referrers index: %v", err)
+	}
+	tagSchemaPath := "/v2/test/manifests/" + strings.Replace(subjectDigest.String(), ":", "-", 1)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/test/_oci/referrers/" + subjectDigest.String():
+			w.Header().Set("Content-Type", oci.MediaTypeImageIndex)
+			_, _ = w.Write(index)
+		case tagSchemaPath:
+			if !serveTagSchema {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", oci.MediaTypeImageIndex)
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(index).String())
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(index)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestORASListReferrers_RegistryReferrers(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	referrer := oci.Descriptor{
+		MediaType:    oci.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.cncf.notary.signature",
+		Digest:       digest.FromString("signature"),
+		Size:         10,
+	}
+	tests := []struct {
+		name              string
+		registryReferrers func(host string) map[string]interface{}
+		serveTagSchema    bool
+		expectedReferrers int
+	}{
+		{
+			name:              "spec path",
+			expectedReferrers: 0,
+		},
+		{
+			name: "overridden path",
+			registryReferrers: func(host string) map[string]interface{} {
+				return map[string]interface{}{host: map[string]interface{}{"path": "/v2/{repository}/_oci/referrers/{digest}"}}
+			},
+			expectedReferrers: 1,
+		},
+		{
+			name: "overridden path of another registry",
+			registryReferrers: func(_ string) map[string]interface{} {
+				return map[string]interface{}{"other.registry.io": map[string]interface{}{"path": "/v2/{repository}/_oci/referrers/{digest}"}}
+			},
+			expectedReferrers: 0,
+		},
+		{
+			name: "tag schema only",
+			registryReferrers: func(host string) map[string]interface{} {
+				return map[string]interface{}{host: map[string]interface{}{"tagSchemaOnly": true}}
+			},
+			serveTagSchema:    true,
+			expectedReferrers: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newNonStandardReferrersRegistry(t, subjectDigest, referrer, tt.serveTagSchema)
+			defer server.Close()
+			uri, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+
+			storeConfig := config.StorePluginConfig{
+				"name":    "oras",
+				"useHttp": true,
+			}
+			if tt.registryReferrers != nil {
+				storeConfig["registryReferrers"] = tt.registryReferrers(uri.Host)
+			}
+			store, err := createBaseStore("1.0.0", storeConfig)
+			if err != nil {
+				t.Fatalf("failed to create oras store: %v", err)
+			}
+			subjectDesc := &ocispecs.SubjectDescriptor{Descriptor: oci.Descriptor{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    subjectDigest,
+				Size:      10,
+			}}
+			result, err := store.ListReferrers(context.Background(), common.Reference{
+				Original: uri.Host + "/test@" + subjectDigest.String(),
+				Digest:   subjectDigest,
+				Path:     uri.Host + "/test",
+			}, nil, "", subjectDesc)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(result.Referrers) != tt.expectedReferrers {
+				t.Fatalf("expected %d referrers, got %+v", tt.expectedReferrers, result.Referrers)
+			}
+			if tt.expectedReferrers > 0 && result.Referrers[0].Digest != referrer.Digest {
+				t.Fatalf("expected referrer %s, got %s", referrer.Digest, result.Referrers[0].Digest)
+			}
+		})
+	}
+}
+
+func TestValidateRegistryReferrers(t *testing.T) {
+	tests := []struct {
+		name              string
+		registryReferrers map[string]ReferrersConfig
+		expectErr         bool
+	}{
+		{
+			name: "no override",
+		},
+		{
+			name:              "path override",
+			registryReferrers: map[string]ReferrersConfig{"registry.io": {Path: "/v2/{repository}/_oci/referrers/{digest}"}},
+		},
+		{
+			name:              "tag schema only",
+			registryReferrers: map[string]ReferrersConfig{"registry.io": {TagSchemaOnly: true}},
+		},
+		{
+			name:              "relative path",
+			registryReferrers: map[string]ReferrersConfig{"registry.io": {Path: "v2/{repository}/referrers/{digest}"}},
+			expectErr:         true,
+		},
+		{
+			name:              "path without placeholders",
+			registryReferrers: map[string]ReferrersConfig{"registry.io": {Path: "/v2/referrers"}},
+			expectErr:         true,
+		},
+		{
+			name:              "path and tag schema only",
+			registryReferrers: map[string]ReferrersConfig{"registry.io": {Path: "/v2/{repository}/_oci/referrers/{digest}", TagSchemaOnly: true}},
+			expectErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRegistryReferrers(tt.registryReferrers); (err != nil) != tt.expectErr {
+				t.Fatalf("validateRegistryReferrers() error = %v, expectErr = %v", err, tt.expectErr)
+			}
+		})
+	}
+}
+
+func TestParseReferrersSpecPath(t *testing.T) {
+	repository, dgst, ok := parseReferrersSpecPath("/v2/org/app/referrers/sha256:abc")
+	if !ok || repository != "org/app" || dgst != "sha256:abc" {
+		t.Fatalf("unexpected parse result %s %s %v", repository, dgst, ok)
+	}
+	if _, _, ok := parseReferrersSpecPath("/v2/org/app/manifests/latest"); ok {
+		t.Fatalf("expected a manifest path not to be parsed as a referrers path")
+	}
+}
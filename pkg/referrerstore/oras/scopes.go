@@ -0,0 +1,91 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ratify-project/ratify/pkg/common"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// The operations of the store the bearer token scopes are configured for.
+const (
+	OperationResolve       = "resolve"
+	OperationListReferrers = "listReferrers"
+	OperationFetchManifest = "fetchManifest"
+	OperationFetchBlob     = "fetchBlob"
+	OperationPushReferrer  = "pushReferrer"
+)
+
+const scopeResourceSeparator = ":"
+
+var tokenScopeOperations = []string{OperationResolve, OperationListReferrers, OperationFetchManifest, OperationFetchBlob, OperationPushReferrer}
+
+// validateTokenScopes returns an error if the bearer token scopes are
+// configured for an unknown operation or are malformed.
+func validateTokenScopes(tokenScopes map[string][]string) error {
+	for operation, scopes := range tokenScopes {
+		known := false
+		for _, tokenScopeOperation := range tokenScopeOperations {
+			known = known || operation == tokenScopeOperation
+		}
+		if !known {
+			return fmt.Errorf("unknown token scope operation %s, must be one of %s", operation, strings.Join(tokenScopeOperations, ", "))
+		}
+		for _, scope := range scopes {
+			if strings.TrimSpace(scope) == "" {
+				return fmt.Errorf("token scopes of operation %s must not be empty", operation)
+			}
+			if strings.Contains(scope, scopeResourceSeparator) && strings.Count(scope, scopeResourceSeparator) < 2 {
+				return fmt.Errorf("token scope %s of operation %s must be an action or a resourcetype:resourcename:actions scope", scope, operation)
+			}
+		}
+	}
+	return nil
+}
+
+// withTokenScopes returns the context requesting the bearer token scopes
+// configured for the operation from the registry of the subject, along with
+// the scopes the registry challenges for. Scopes given as actions, e.g.
+// metadata_read, apply to the repository of the subject.
+func (store *orasStore) withTokenScopes(ctx context.Context, subjectReference common.Reference, operation string) context.Context {
+	configured := store.config.TokenScopes[operation]
+	if len(configured) == 0 {
+		return ctx
+	}
+	ref, err := registry.ParseReference(subjectReference.Original)
+	if err != nil {
+		return ctx
+	}
+
+	var actions []string
+	scopes := make([]string, 0, len(configured))
+	for _, scope := range configured {
+		if strings.Contains(scope, scopeResourceSeparator) {
+			scopes = append(scopes, scope)
+		} else {
+			actions = append(actions, scope)
+		}
+	}
+	if len(actions) > 0 {
+		scopes = append(scopes, auth.ScopeRepository(ref.Repository, actions...))
+	}
+	return auth.AppendScopesForHost(ctx, ref.Host(), scopes...)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/referrerstore/config"
+)
+
+// newScopedRegistry returns a fake registry challenging for a bearer token
+// with the given scope and recording the scopes requested from its token
+// endpoint. Only tokens granting all the required scopes are accepted.
+func newScopedRegistry(t *testing.T, challengeScope string, requiredScopes []string, manifestDigest digest.Digest, requested *[]string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			scopes := r.URL.Query()["scope"]
+			mu.Lock()
+			*requested = append(*requested, scopes...)
+			mu.Unlock()
+			fmt.Fprintf(w, `{"token":%q}`, strings.Join(scopes, " "))
+			return
+		}
+
+		granted := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), " ")
+		for _, scope := range requiredScopes {
+			if !slices.Contains(granted, scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake-registry",scope=%q`, server.URL, challengeScope))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		if r.Method == http.MethodHead && r.URL.Path == "/v2/test/manifests/latest" {
+			w.Header().Set("Content-Type", oci.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+			w.Header().Set("Content-Length", "10")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	return server
+}
+
+func TestORASTokenScopes(t *testing.T) {
+	manifestDigest := digest.FromString("test")
+	tests := []struct {
+		name           string
+		tokenScopes    map[string][]string
+		challengeScope string
+		requiredScopes []string
+		expectedScopes []string
+		expectErr      bool
+	}{
+		{
+			name:           "challenged scope",
+			challengeScope: "repository:test:pull",
+			requiredScopes: []string{"repository:test:pull"},
+			expectedScopes: []string{"repository:test:pull"},
+		},
+		{
+			name:           "configured actions on the subject repository",
+			tokenScopes:    map[string][]string{OperationResolve: {"metadata_read"}},
+			challengeScope: "repository:test:pull",
+			requiredScopes: []string{"repository:test:metadata_read,pull"},
+			expectedScopes: []string{"repository:test:metadata_read,pull"},
+		},
+		{
+			name:           "configured full scope",
+			tokenScopes:    map[string][]string{OperationResolve: {"registry:catalog:*"}},
+			challengeScope: "repository:test:pull",
+			requiredScopes: []string{"registry:catalog:*", "repository:test:pull"},
+			expectedScopes: []string{"registry:catalog:*", "repository:test:pull"},
+		},
+		{
+			name:           "scopes of another operation are not requested",
+			tokenScopes:    map[string][]string{OperationListReferrers: {"metadata_read"}},
+			challengeScope: "repository:test:pull",
+			requiredScopes: []string{"repository:test:metadata_read,pull"},
+			expectErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []string
+			server := newScopedRegistry(t, tt.challengeScope, tt.requiredScopes, manifestDigest, &requested)
+			defer server.Close()
+			uri, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+
+			tokenScopes := map[string]interface{}{}
+			for operation, scopes := range tt.tokenScopes {
+				tokenScopes[operation] = scopes
+			}
+			store, err := createBaseStore("1.0.0", config.StorePluginConfig{
+				"name":        "oras",
+				"useHttp":     true,
+				"tokenScopes": tokenScopes,
+			})
+			if err != nil {
+				t.Fatalf("failed to create oras store: %v", err)
+			}
+			desc, err := store.GetSubjectDescriptor(context.Background(), common.Reference{
+				Original: uri.Host + "/test:latest",
+				Tag:      "latest",
+				Path:     uri.Host + "/test",
+			})
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected the registry to reject the token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if desc.Digest != manifestDigest {
+				t.Fatalf("expected digest %s, got %s", manifestDigest, desc.Digest)
+			}
+			slices.Sort(requested)
+			if !slices.Equal(requested, tt.expectedScopes) {
+				t.Fatalf("expected requested scopes %v, got %v", tt.expectedScopes, requested)
+			}
+		})
+	}
+}
+
+func TestValidateTokenScopes(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokenScopes map[string][]string
+		expectErr   bool
+	}{
+		{
+			name:        "valid scopes",
+			tokenScopes: map[string][]string{OperationListReferrers: {"metadata_read", "registry:catalog:*"}},
+		},
+		{
+			name:        "unknown operation",
+			tokenScopes: map[string][]string{"delete": {"delete"}},
+			expectErr:   true,
+		},
+		{
+			name:        "empty scope",
+			tokenScopes: map[string][]string{OperationFetchBlob: {" "}},
+			expectErr:   true,
+		},
+		{
+			name:        "malformed scope",
+			tokenScopes: map[string][]string{OperationFetchBlob: {"repository:pull"}},
+			expectErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTokenScopes(tt.tokenScopes); (err != nil) != tt.expectErr {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
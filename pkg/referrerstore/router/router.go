@@ -0,0 +1,213 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	commonutils "github.com/ratify-project/ratify/pkg/common/utils"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/config"
+	"github.com/ratify-project/ratify/pkg/referrerstore/factory"
+)
+
+const (
+	storeName = "router"
+	// schemeSeparator ends the patterns matching the scheme of a reference,
+	// e.g. oci-layout://.
+	schemeSeparator = "://"
+)
+
+// RouteConfig maps the references matching the pattern to a store.
+type RouteConfig struct {
+	// Pattern matches either the scheme of the reference if it ends with ://,
+	// e.g. oci-layout://, the registry host if it has no /, e.g.
+	// *.azurecr.io, or else the repository, e.g. registry.example.com/team/*.
+	Pattern string `json:"pattern"`
+	// Store is the config of the store handling the matching references.
+	Store config.StorePluginConfig `json:"store"`
+}
+
+// RouterStoreConfig is the configuration of the router store.
+type RouterStoreConfig struct { //nolint:revive // ignore linter to have unique type name
+	Name string `json:"name"`
+	// Routes are matched in order, the first matching route selects the store.
+	Routes []RouteConfig `json:"routes"`
+	// Default is the config of the store handling the references no route
+	// matches. References no route matches fail if it is not set.
+	Default config.StorePluginConfig `json:"default,omitempty"`
+	// PluginBinDirs are the directories the plugin stores of the routes are
+	// looked up in.
+	PluginBinDirs []string `json:"pluginBinDirs,omitempty"`
+}
+
+type route struct {
+	pattern string
+	store   referrerstore.ReferrerStore
+}
+
+// routerStore selects the store handling a subject based on the scheme, host
+// or repository of its reference so that a single executor verifies artifacts
+// hosted on different backends.
+type routerStore struct {
+	name         string
+	rawConfig    config.StoreConfig
+	routes       []route
+	defaultStore referrerstore.ReferrerStore
+}
+
+type routerStoreFactory struct{}
+
+func init() {
+	factory.Register(storeName, &routerStoreFactory{})
+}
+
+// Create creates the stores of the routes from the router store config.
+func (f *routerStoreFactory) Create(version string, storeConfig config.StorePluginConfig) (referrerstore.ReferrerStore, error) {
+	conf := RouterStoreConfig{}
+	if err := commonutils.DecodeConfig(storeConfig, &conf); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.ReferrerStore).WithDetail("failed to parse the router store configuration").WithError(err)
+	}
+	if len(conf.Routes) == 0 {
+		return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.ReferrerStore).WithDetail("the router store requires at least one route")
+	}
+
+	store := &routerStore{
+		name:      conf.Name,
+		rawConfig: config.StoreConfig{Version: version, Store: storeConfig, PluginBinDirs: conf.PluginBinDirs},
+	}
+	for _, routeConf := range conf.Routes {
+		if routeConf.Pattern == "" {
+			return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.ReferrerStore).WithDetail("the pattern of a router store route must not be empty")
+		}
+		if _, err := path.Match(routeConf.Pattern, ""); err != nil {
+			return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.ReferrerStore).WithDetail(fmt.Sprintf("invalid router store route pattern %s", routeConf.Pattern)).WithError(err)
+		}
+		routeStore, err := createRouteStore(routeConf.Store, version, conf.PluginBinDirs)
+		if err != nil {
+			return nil, err
+		}
+		store.routes = append(store.routes, route{pattern: routeConf.Pattern, store: routeStore})
+	}
+	if conf.Default != nil {
+		defaultStore, err := createRouteStore(conf.Default, version, conf.PluginBinDirs)
+		if err != nil {
+			return nil, err
+		}
+		store.defaultStore = defaultStore
+	}
+	return store, nil
+}
+
+func createRouteStore(storeConfig config.StorePluginConfig, version string, pluginBinDirs []string) (referrerstore.ReferrerStore, error) {
+	if fmt.Sprintf("%v", storeConfig["name"]) == storeName {
+		return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.ReferrerStore).WithDetail("router store routes cannot be routed to another router store")
+	}
+	return factory.CreateStoreFromConfig(storeConfig, version, pluginBinDirs)
+}
+
+// matches returns true if the pattern matches the scheme, host or repository
+// of the reference.
+func matches(pattern string, subjectReference common.Reference) bool {
+	if strings.HasSuffix(pattern, schemeSeparator) {
+		return strings.HasPrefix(subjectReference.Original, pattern) || strings.HasPrefix(subjectReference.Path, pattern)
+	}
+	repository := subjectReference.Path
+	if idx := strings.Index(repository, schemeSeparator); idx >= 0 {
+		repository = repository[idx+len(schemeSeparator):]
+	}
+	candidate := repository
+	if !strings.Contains(pattern, "/") {
+		candidate, _, _ = strings.Cut(repository, "/")
+	}
+	matched, _ := path.Match(pattern, candidate)
+	return matched
+}
+
+// route returns the store handling the subject.
+func (store *routerStore) route(subjectReference common.Reference) (referrerstore.ReferrerStore, error) {
+	for _, r := range store.routes {
+		if matches(r.pattern, subjectReference) {
+			return r.store, nil
+		}
+	}
+	if store.defaultStore != nil {
+		return store.defaultStore, nil
+	}
+	return nil, re.ErrorCodeReferrerStoreFailure.WithDetail(fmt.Sprintf("no route of store %s matches the subject %s", store.name, subjectReference.Original)).WithRemediation("Please add a route or a default store matching the subject to the router store configuration.")
+}
+
+func (store *routerStore) Name() string {
+	return store.name
+}
+
+func (store *routerStore) GetConfig() *config.StoreConfig {
+	return &store.rawConfig
+}
+
+func (store *routerStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	routed, err := store.route(subjectReference)
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, err
+	}
+	return routed.ListReferrers(ctx, subjectReference, artifactTypes, nextToken, subjectDesc)
+}
+
+func (store *routerStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	routed, err := store.route(subjectReference)
+	if err != nil {
+		return nil, err
+	}
+	return routed.GetBlobContent(ctx, subjectReference, digest)
+}
+
+func (store *routerStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	routed, err := store.route(subjectReference)
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, err
+	}
+	return routed.GetReferenceManifest(ctx, subjectReference, referenceDesc)
+}
+
+func (store *routerStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	routed, err := store.route(subjectReference)
+	if err != nil {
+		return nil, err
+	}
+	return routed.GetSubjectDescriptor(ctx, subjectReference)
+}
+
+// PushReferrer pushes the referrer with the store handling the subject if it
+// supports pushing referrers.
+func (store *routerStore) PushReferrer(ctx context.Context, subjectReference common.Reference, subjectDesc oci.Descriptor, artifactType string, blob []byte, blobMediaType string) (oci.Descriptor, error) {
+	routed, err := store.route(subjectReference)
+	if err != nil {
+		return oci.Descriptor{}, err
+	}
+	pusher, ok := routed.(referrerstore.ReferrerPusher)
+	if !ok {
+		return oci.Descriptor{}, re.ErrorCodeReferrerStoreFailure.WithDetail(fmt.Sprintf("store %s routed to by subject %s does not support pushing referrers", routed.Name(), subjectReference.Original))
+	}
+	return pusher.PushReferrer(ctx, subjectReference, subjectDesc, artifactType, blob, blobMediaType)
+}
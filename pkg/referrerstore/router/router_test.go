@@ -0,0 +1,202 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/config"
+	"github.com/ratify-project/ratify/pkg/referrerstore/factory"
+)
+
+const (
+	acrStoreName    = "routerTestACRStore"
+	layoutStoreName = "routerTestLayoutStore"
+	s3StoreName     = "routerTestS3Store"
+)
+
+// stubStore reports its name as the digest of every subject.
+type stubStore struct {
+	name string
+}
+
+type stubStoreFactory struct {
+	name string
+}
+
+func (f *stubStoreFactory) Create(_ string, _ config.StorePluginConfig) (referrerstore.ReferrerStore, error) {
+	return &stubStore{name: f.name}, nil
+}
+
+func (s *stubStore) Name() string {
+	return s.name
+}
+
+func (s *stubStore) ListReferrers(_ context.Context, _ common.Reference, _ []string, _ string, _ *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	return referrerstore.ListReferrersResult{Referrers: []ocispecs.ReferenceDescriptor{{ArtifactType: s.name}}}, nil
+}
+
+func (s *stubStore) GetBlobContent(_ context.Context, _ common.Reference, _ digest.Digest) ([]byte, error) {
+	return []byte(s.name), nil
+}
+
+func (s *stubStore) GetReferenceManifest(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	return ocispecs.ReferenceManifest{ArtifactType: s.name}, nil
+}
+
+func (s *stubStore) GetConfig() *config.StoreConfig {
+	return &config.StoreConfig{}
+}
+
+func (s *stubStore) GetSubjectDescriptor(_ context.Context, _ common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	return &ocispecs.SubjectDescriptor{}, nil
+}
+
+func init() {
+	for _, name := range []string{acrStoreName, layoutStoreName, s3StoreName} {
+		factory.Register(name, &stubStoreFactory{name: name})
+	}
+}
+
+func newRouterStore(t *testing.T, storeConfig config.StorePluginConfig) referrerstore.ReferrerStore {
+	t.Helper()
+	store, err := factory.CreateStoreFromConfig(storeConfig, "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("failed to create the router store: %v", err)
+	}
+	return store
+}
+
+func TestRoute(t *testing.T) {
+	store := newRouterStore(t, config.StorePluginConfig{
+		"name": storeName,
+		"routes": []interface{}{
+			map[string]interface{}{"pattern": "*.azurecr.io", "store": map[string]interface{}{"name": acrStoreName}},
+			map[string]interface{}{"pattern": "oci-layout://", "store": map[string]interface{}{"name": layoutStoreName}},
+			map[string]interface{}{"pattern": "s3.registry.internal/team/*", "store": map[string]interface{}{"name": s3StoreName}},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		reference   common.Reference
+		expectStore string
+		expectError bool
+	}{
+		{
+			name:        "registry host",
+			reference:   common.Reference{Original: "myregistry.azurecr.io/net-monitor:v1", Path: "myregistry.azurecr.io/net-monitor"},
+			expectStore: acrStoreName,
+		},
+		{
+			name:        "reference scheme",
+			reference:   common.Reference{Original: "oci-layout:///var/layouts/net-monitor:v1", Path: "oci-layout:///var/layouts/net-monitor"},
+			expectStore: layoutStoreName,
+		},
+		{
+			name:        "repository",
+			reference:   common.Reference{Original: "s3.registry.internal/team/net-monitor:v1", Path: "s3.registry.internal/team/net-monitor"},
+			expectStore: s3StoreName,
+		},
+		{
+			name:        "no matching route",
+			reference:   common.Reference{Original: "s3.registry.internal/other/net-monitor:v1", Path: "s3.registry.internal/other/net-monitor"},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			result, err := store.ListReferrers(ctx, tt.reference, nil, "", nil)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if tt.expectError {
+				return
+			}
+			if len(result.Referrers) != 1 || result.Referrers[0].ArtifactType != tt.expectStore {
+				t.Fatalf("expected the referrers of store %s, got %+v", tt.expectStore, result.Referrers)
+			}
+			blob, err := store.GetBlobContent(ctx, tt.reference, "")
+			if err != nil || string(blob) != tt.expectStore {
+				t.Fatalf("expected the blob of store %s, got %s, %v", tt.expectStore, blob, err)
+			}
+			manifest, err := store.GetReferenceManifest(ctx, tt.reference, ocispecs.ReferenceDescriptor{})
+			if err != nil || manifest.ArtifactType != tt.expectStore {
+				t.Fatalf("expected the manifest of store %s, got %+v, %v", tt.expectStore, manifest, err)
+			}
+		})
+	}
+}
+
+func TestRoute_Default(t *testing.T) {
+	store := newRouterStore(t, config.StorePluginConfig{
+		"name": storeName,
+		"routes": []interface{}{
+			map[string]interface{}{"pattern": "*.azurecr.io", "store": map[string]interface{}{"name": acrStoreName}},
+		},
+		"default": map[string]interface{}{"name": s3StoreName},
+	})
+	result, err := store.ListReferrers(context.Background(), common.Reference{Original: "localhost:5000/net-monitor:v1", Path: "localhost:5000/net-monitor"}, nil, "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result.Referrers) != 1 || result.Referrers[0].ArtifactType != s3StoreName {
+		t.Fatalf("expected the referrers of the default store, got %+v", result.Referrers)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config config.StorePluginConfig
+	}{
+		{
+			name:   "no routes",
+			config: config.StorePluginConfig{"name": storeName},
+		},
+		{
+			name: "empty pattern",
+			config: config.StorePluginConfig{"name": storeName, "routes": []interface{}{
+				map[string]interface{}{"store": map[string]interface{}{"name": acrStoreName}},
+			}},
+		},
+		{
+			name: "invalid pattern",
+			config: config.StorePluginConfig{"name": storeName, "routes": []interface{}{
+				map[string]interface{}{"pattern": "[", "store": map[string]interface{}{"name": acrStoreName}},
+			}},
+		},
+		{
+			name: "nested router",
+			config: config.StorePluginConfig{"name": storeName, "routes": []interface{}{
+				map[string]interface{}{"pattern": "*", "store": map[string]interface{}{"name": storeName}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := factory.CreateStoreFromConfig(tt.config, "1.0.0", nil); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
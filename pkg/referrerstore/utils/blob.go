@@ -0,0 +1,80 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+)
+
+// OpenBlob returns a reader streaming the decompressed content of the blob,
+// along with its media type without the compression suffix. The blob is
+// streamed from stores implementing referrerstore.BlobStreamer and read whole
+// with GetBlobContent from other stores. The caller must close the reader.
+func OpenBlob(ctx context.Context, store referrerstore.ReferrerStore, subjectReference common.Reference, blobDesc oci.Descriptor) (io.ReadCloser, string, error) {
+	var blob io.ReadCloser
+	if streamer, ok := store.(referrerstore.BlobStreamer); ok {
+		reader, err := streamer.GetBlobReader(ctx, subjectReference, blobDesc.Digest)
+		if err != nil {
+			return nil, "", err
+		}
+		blob = reader
+	} else {
+		content, err := store.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
+		if err != nil {
+			return nil, "", err
+		}
+		blob = io.NopCloser(bytes.NewReader(content))
+	}
+
+	reader, mediaType, err := DecompressReader(blobDesc.MediaType, blob)
+	if err != nil {
+		blob.Close()
+		return nil, "", err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, closers{reader, blob}}, mediaType, nil
+}
+
+// ReadBlob returns the decompressed content of the blob read whole with
+// GetBlobContent, along with its media type without the compression suffix.
+func ReadBlob(ctx context.Context, store referrerstore.ReferrerStore, subjectReference common.Reference, blobDesc oci.Descriptor) ([]byte, string, error) {
+	blob, err := store.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
+	if err != nil {
+		return nil, "", err
+	}
+	return decompressBlob(blobDesc.MediaType, blob)
+}
+
+// closers closes all of its closers.
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var firstErr error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
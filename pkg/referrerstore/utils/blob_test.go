@@ -0,0 +1,107 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+)
+
+// streamingStore streams its blobs and fails reading them whole.
+type streamingStore struct {
+	mocks.MemoryTestStore
+	closed bool
+}
+
+func (s *streamingStore) GetBlobContent(_ context.Context, _ common.Reference, _ digest.Digest) ([]byte, error) {
+	return nil, fmt.Errorf("blob must be streamed")
+}
+
+func (s *streamingStore) GetBlobReader(_ context.Context, _ common.Reference, blobDigest digest.Digest) (io.ReadCloser, error) {
+	blob, ok := s.Blobs[blobDigest]
+	if !ok {
+		return nil, fmt.Errorf("blob not found")
+	}
+	return &trackingReader{Reader: bytes.NewReader(blob), closed: &s.closed}, nil
+}
+
+type trackingReader struct {
+	io.Reader
+	closed *bool
+}
+
+func (r *trackingReader) Close() error {
+	*r.closed = true
+	return nil
+}
+
+func TestOpenBlob(t *testing.T) {
+	compressed := zstdCompress(t, []byte(testBlobContent))
+	blobDigest := digest.FromBytes(compressed)
+	blobDesc := oci.Descriptor{MediaType: "application/spdx+json+zstd", Digest: blobDigest}
+	blobs := map[digest.Digest][]byte{blobDigest: compressed}
+
+	streaming := &streamingStore{MemoryTestStore: mocks.MemoryTestStore{Blobs: blobs}}
+	reader, mediaType, err := OpenBlob(context.Background(), streaming, common.Reference{}, blobDesc)
+	if err != nil {
+		t.Fatalf("expected the blob to be streamed, got %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil || string(content) != testBlobContent || mediaType != "application/spdx+json" {
+		t.Fatalf("unexpected content %s of media type %s, err: %v", content, mediaType, err)
+	}
+	reader.Close()
+	if !streaming.closed {
+		t.Fatalf("expected the blob reader of the store to be closed")
+	}
+
+	// stores unable to stream blobs are read whole
+	reader, _, err = OpenBlob(context.Background(), &mocks.MemoryTestStore{Blobs: blobs}, common.Reference{}, blobDesc)
+	if err != nil {
+		t.Fatalf("expected the blob to be read, got %v", err)
+	}
+	defer reader.Close()
+	if content, err = io.ReadAll(reader); err != nil || string(content) != testBlobContent {
+		t.Fatalf("unexpected content %s, err: %v", content, err)
+	}
+
+	if _, _, err := OpenBlob(context.Background(), streaming, common.Reference{}, oci.Descriptor{Digest: digest.FromString("missing")}); err == nil {
+		t.Fatalf("expected an error for a missing blob")
+	}
+}
+
+func TestReadBlob(t *testing.T) {
+	compressed := zstdCompress(t, []byte(testBlobContent))
+	blobDigest := digest.FromBytes(compressed)
+	store := &mocks.MemoryTestStore{Blobs: map[digest.Digest][]byte{blobDigest: compressed}}
+
+	content, mediaType, err := ReadBlob(context.Background(), store, common.Reference{}, oci.Descriptor{MediaType: "application/spdx+json+zstd", Digest: blobDigest})
+	if err != nil || string(content) != testBlobContent || mediaType != "application/spdx+json" {
+		t.Fatalf("unexpected content %s of media type %s, err: %v", content, mediaType, err)
+	}
+
+	if _, _, err := ReadBlob(context.Background(), store, common.Reference{}, oci.Descriptor{Digest: digest.FromString("missing")}); err == nil {
+		t.Fatalf("expected an error for a missing blob")
+	}
+}
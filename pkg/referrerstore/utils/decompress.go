@@ -0,0 +1,117 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	gzipSuffix = "+gzip"
+	zstdSuffix = "+zstd"
+
+	// maxDecompressedBlobSize bounds the decompressed size of a blob so that
+	// highly compressed content cannot exhaust memory.
+	maxDecompressedBlobSize = 256 << 20
+)
+
+// decompressBlob decompresses blob content according to the compression
+// suffix of its media type, e.g. "+gzip" or "+zstd". It returns the
+// decompressed content along with the media type without the compression
+// suffix. Content of other media types is returned unchanged.
+func decompressBlob(mediaType string, blob []byte) ([]byte, string, error) {
+	reader, decompressedMediaType, err := DecompressReader(mediaType, bytes.NewReader(blob))
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+	if decompressedMediaType == mediaType {
+		return blob, mediaType, nil
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, decompressedMediaType, nil
+}
+
+// DecompressReader returns a reader decompressing the content read from r
+// according to the compression suffix of its media type, along with the media
+// type without the compression suffix. The decompressed content is bounded so
+// that highly compressed content cannot be inflated without limit. Content of
+// other media types is read unchanged.
+func DecompressReader(mediaType string, r io.Reader) (io.ReadCloser, string, error) {
+	switch {
+	case strings.HasSuffix(mediaType, zstdSuffix):
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create zstd reader for media type %s: %w", mediaType, err)
+		}
+		return &decompressingReader{reader: decoder, close: decoder.Close, mediaType: mediaType, remaining: maxDecompressedBlobSize}, mediaType[:len(mediaType)-len(zstdSuffix)], nil
+	case strings.HasSuffix(mediaType, gzipSuffix):
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create gzip reader for media type %s: %w", mediaType, err)
+		}
+		return &decompressingReader{reader: gzipReader, close: func() { gzipReader.Close() }, mediaType: mediaType, remaining: maxDecompressedBlobSize}, mediaType[:len(mediaType)-len(gzipSuffix)], nil
+	default:
+		return io.NopCloser(r), mediaType, nil
+	}
+}
+
+// decompressingReader reads the decompressed content of a blob and fails once
+// it exceeds maxDecompressedBlobSize.
+type decompressingReader struct {
+	reader    io.Reader
+	close     func()
+	mediaType string
+	remaining int64
+}
+
+func (r *decompressingReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// the content ends at the bound unless more can be read
+		var probe [1]byte
+		if n, err := io.ReadAtLeast(r.reader, probe[:], 1); n > 0 {
+			return 0, fmt.Errorf("decompressed blob of media type %s exceeds the maximum size of %d bytes", r.mediaType, maxDecompressedBlobSize)
+		} else if !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("failed to decompress blob of media type %s: %w", r.mediaType, err)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = fmt.Errorf("failed to decompress blob of media type %s: %w", r.mediaType, err)
+	}
+	return n, err
+}
+
+func (r *decompressingReader) Close() error {
+	r.close()
+	return nil
+}
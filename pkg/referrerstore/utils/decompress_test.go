@@ -0,0 +1,105 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const testBlobContent = `{"spdxVersion":"SPDX-2.3"}`
+
+func zstdCompress(t *testing.T, content []byte) []byte {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("failed to create zstd writer: %v", err)
+	}
+	defer encoder.Close()
+	return encoder.EncodeAll(content, nil)
+}
+
+func gzipCompress(t *testing.T, content []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(content); err != nil {
+		t.Fatalf("failed to write gzip content: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressBlob(t *testing.T) {
+	tests := []struct {
+		name          string
+		mediaType     string
+		blob          []byte
+		wantMediaType string
+		wantErr       bool
+	}{
+		{
+			name:          "zstd compressed blob",
+			mediaType:     "application/spdx+json+zstd",
+			blob:          zstdCompress(t, []byte(testBlobContent)),
+			wantMediaType: "application/spdx+json",
+		},
+		{
+			name:          "gzip compressed blob",
+			mediaType:     "application/spdx+json+gzip",
+			blob:          gzipCompress(t, []byte(testBlobContent)),
+			wantMediaType: "application/spdx+json",
+		},
+		{
+			name:          "uncompressed blob",
+			mediaType:     "application/spdx+json",
+			blob:          []byte(testBlobContent),
+			wantMediaType: "application/spdx+json",
+		},
+		{
+			name:      "invalid zstd content",
+			mediaType: "application/spdx+json+zstd",
+			blob:      []byte(testBlobContent),
+			wantErr:   true,
+		},
+		{
+			name:      "invalid gzip content",
+			mediaType: "application/spdx+json+gzip",
+			blob:      []byte(testBlobContent),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, mediaType, err := decompressBlob(tt.mediaType, tt.blob)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decompressBlob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(content) != testBlobContent {
+				t.Fatalf("expected content %s, got %s", testBlobContent, content)
+			}
+			if mediaType != tt.wantMediaType {
+				t.Fatalf("expected media type %s, got %s", tt.wantMediaType, mediaType)
+			}
+		})
+	}
+}
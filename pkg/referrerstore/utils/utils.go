@@ -17,7 +17,10 @@ package utils
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/common"
@@ -29,14 +32,39 @@ var logOpt = logger.Option{
 	ComponentType: logger.ReferrerStore,
 }
 
+// ResolveSubjectDescriptor returns the descriptor of the subject from the
+// first store able to resolve it. The digest of a subject referenced by digest
+// is authoritative: descriptors with another digest are ignored. The errors of
+// the stores are returned if none resolves the subject.
 func ResolveSubjectDescriptor(ctx context.Context, stores *[]referrerstore.ReferrerStore, subRef common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	var errs []error
 	for _, referrerStore := range *stores {
 		desc, err := referrerStore.GetSubjectDescriptor(ctx, subRef)
+		if err == nil && subRef.Digest != "" && desc.Digest != subRef.Digest {
+			err = fmt.Errorf("resolved digest %s does not match the digest of the subject %s", desc.Digest, subRef.Digest)
+		}
 		if err == nil {
 			return desc, nil
 		}
 		logger.GetLogger(ctx, logOpt).Warn(errors.ErrorCodeGetSubjectDescriptorFailure.NewError(errors.ReferrerStore, referrerStore.Name(), errors.EmptyLink, err, "failed to resolve the subject descriptor", errors.HideStackTrace))
+		// the errors of the stores are not wrapped so that the error code
+		// of the returned error stays the referrer store failure
+		errs = append(errs, fmt.Errorf("store %s: %v", referrerStore.Name(), err))
 	}
 
-	return nil, errors.ErrorCodeReferrerStoreFailure.WithDetail("could not resolve descriptor for a subject from any stores").WithComponentType(errors.ReferrerStore)
+	return nil, errors.ErrorCodeReferrerStoreFailure.WithDetail("could not resolve descriptor for a subject from any stores").WithError(stderrors.Join(errs...)).WithComponentType(errors.ReferrerStore)
+}
+
+// SubjectDigest returns the digest of the subject. The digest of a subject
+// referenced by digest is returned without querying the stores, the tag of
+// any other subject is resolved.
+func SubjectDigest(ctx context.Context, stores *[]referrerstore.ReferrerStore, subRef common.Reference) (digest.Digest, error) {
+	if subRef.Digest != "" {
+		return subRef.Digest, nil
+	}
+	desc, err := ResolveSubjectDescriptor(ctx, stores, subRef)
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest, nil
 }
@@ -17,9 +17,13 @@ package utils
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
 	"github.com/ratify-project/ratify/pkg/utils"
@@ -63,3 +67,68 @@ func TestResolveSubjectDescriptor_Failure(t *testing.T) {
 		t.Fatalf("expected resolve to fail but didnot get any error")
 	}
 }
+
+// mismatchedStore resolves every subject to the same digest.
+type mismatchedStore struct {
+	mocks.TestStore
+	digest digest.Digest
+}
+
+func (s *mismatchedStore) GetSubjectDescriptor(_ context.Context, _ common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	return &ocispecs.SubjectDescriptor{Descriptor: oci.Descriptor{Digest: s.digest}}, nil
+}
+
+func TestResolveSubjectDescriptor_DigestOnly(t *testing.T) {
+	testDigest := digest.FromString("test")
+	subjectReference, err := utils.ParseSubjectReference("localhost:5000/net-monitor@" + testDigest.String())
+	if err != nil {
+		t.Fatalf("failed to parse the subject %v", err)
+	}
+	if subjectReference.Tag != "" {
+		t.Fatalf("expected no tag, got %s", subjectReference.Tag)
+	}
+
+	stores := &[]referrerstore.ReferrerStore{&mismatchedStore{digest: digest.FromString("other")}}
+	_, err = ResolveSubjectDescriptor(context.Background(), stores, subjectReference)
+	if err == nil || !strings.Contains(err.Error(), "does not match the digest of the subject") {
+		t.Fatalf("expected the error of the store resolving another digest, got %v", err)
+	}
+
+	stores = &[]referrerstore.ReferrerStore{&mismatchedStore{digest: testDigest}}
+	result, err := ResolveSubjectDescriptor(context.Background(), stores, subjectReference)
+	if err != nil {
+		t.Fatalf("failed to get the subject descriptor %v", err)
+	}
+	if result.Digest != testDigest {
+		t.Fatalf("digest mismatch expected %v actual %v", testDigest, result.Digest)
+	}
+
+	subjectDigest, err := SubjectDigest(context.Background(), &[]referrerstore.ReferrerStore{}, subjectReference)
+	if err != nil {
+		t.Fatalf("failed to get the subject digest %v", err)
+	}
+	if subjectDigest != testDigest {
+		t.Fatalf("digest mismatch expected %v actual %v", testDigest, subjectDigest)
+	}
+}
+
+func TestSubjectDigest_Tagged(t *testing.T) {
+	testDigest := digest.FromString("test")
+	store := &mocks.TestStore{
+		ResolveMap: map[string]digest.Digest{
+			"v1": testDigest,
+		},
+	}
+	subjectReference, err := utils.ParseSubjectReference("localhost:5000/net-monitor:v1")
+	if err != nil {
+		t.Fatalf("failed to parse the subject %v", err)
+	}
+
+	result, err := SubjectDigest(context.Background(), &[]referrerstore.ReferrerStore{store}, subjectReference)
+	if err != nil {
+		t.Fatalf("failed to get the subject digest %v", err)
+	}
+	if result != testDigest {
+		t.Fatalf("digest mismatch expected %v actual %v", testDigest, result)
+	}
+}
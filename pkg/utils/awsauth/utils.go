@@ -17,9 +17,20 @@ package awsauth
 
 import (
 	"net/url"
+	"regexp"
 	"strings"
 )
 
+// privateECRRegistryPattern matches the hosts of private ECR registries, e.g.
+// 123456789012.dkr.ecr.us-east-2.amazonaws.com.
+var privateECRRegistryPattern = regexp.MustCompile(`^[0-9]{12}\.dkr\.ecr(-fips)?\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// IsPrivateECRRegistry reports whether the registry host is a private ECR
+// registry. ECR Public, e.g. public.ecr.aws, is not.
+func IsPrivateECRRegistry(registry string) bool {
+	return privateECRRegistryPattern.MatchString(registry)
+}
+
 // RegionFromRegistry parses AWS region ID from registry url
 func RegionFromRegistry(registry string) string {
 	a := strings.Split(registry, ".")
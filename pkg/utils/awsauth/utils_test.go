@@ -41,3 +41,22 @@ func TestRegionFromRegistry_ReturnsExpected(t *testing.T) {
 		t.Fatalf("incorrect region returned, expected %s, but received %s", region, reg)
 	}
 }
+
+func TestIsPrivateECRRegistry(t *testing.T) {
+	tests := []struct {
+		registry string
+		expected bool
+	}{
+		{registry: registry, expected: true},
+		{registry: "123456789012.dkr.ecr-fips.us-gov-west-1.amazonaws.com", expected: true},
+		{registry: "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn", expected: true},
+		{registry: "public.ecr.aws", expected: false},
+		{registry: "ghcr.io", expected: false},
+		{registry: "123456789012.dkr.ecr.us-east-2.amazonaws.com.example.com", expected: false},
+	}
+	for _, tt := range tests {
+		if actual := IsPrivateECRRegistry(tt.registry); actual != tt.expected {
+			t.Fatalf("IsPrivateECRRegistry(%s) = %v, expected %v", tt.registry, actual, tt.expected)
+		}
+	}
+}
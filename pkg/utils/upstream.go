@@ -0,0 +1,57 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"strings"
+)
+
+type upstreamRegistriesKey struct{}
+
+// WithUpstreamRegistries returns the context mapping the registry hosts of
+// pull-through caches to the hosts of the upstream registries they serve.
+func WithUpstreamRegistries(ctx context.Context, upstreams map[string]string) context.Context {
+	if len(upstreams) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, upstreamRegistriesKey{}, upstreams)
+}
+
+// UpstreamHost returns the host of the upstream registry served by the
+// pull-through cache at host, or host if it is not a pull-through cache. Hosts
+// are compared case-insensitively.
+func UpstreamHost(upstreams map[string]string, host string) string {
+	for cache, upstream := range upstreams {
+		if strings.EqualFold(strings.TrimSpace(cache), host) {
+			return strings.TrimSpace(upstream)
+		}
+	}
+	return host
+}
+
+// UpstreamReference returns the reference with the registry host of a
+// pull-through cache mapped in the context replaced by the host of its
+// upstream registry, e.g. cache.example.com/library/nginx:1.25 is evaluated as
+// docker.io/library/nginx:1.25 against trust scopes.
+func UpstreamReference(ctx context.Context, ref string) string {
+	upstreams, _ := ctx.Value(upstreamRegistriesKey{}).(map[string]string)
+	host, path, found := strings.Cut(ref, "/")
+	if len(upstreams) == 0 || !found {
+		return ref
+	}
+	return UpstreamHost(upstreams, host) + "/" + path
+}
@@ -0,0 +1,82 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUpstreamHost(t *testing.T) {
+	upstreams := map[string]string{"Cache.Example.com": "docker.io"}
+	tests := []struct {
+		name     string
+		host     string
+		expected string
+	}{
+		{name: "mapped cache host", host: "cache.example.com", expected: "docker.io"},
+		{name: "unmapped host", host: "ghcr.io", expected: "ghcr.io"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := UpstreamHost(upstreams, tt.host); actual != tt.expected {
+				t.Errorf("expected host %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpstreamReference(t *testing.T) {
+	ctx := WithUpstreamRegistries(context.Background(), map[string]string{"cache.example.com": "docker.io"})
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		ref      string
+		expected string
+	}{
+		{
+			name:     "cache host mapped to upstream",
+			ctx:      ctx,
+			ref:      "cache.example.com/library/nginx@sha256:abc",
+			expected: "docker.io/library/nginx@sha256:abc",
+		},
+		{
+			name:     "host not mapped",
+			ctx:      ctx,
+			ref:      "ghcr.io/ratify-project/ratify:v1",
+			expected: "ghcr.io/ratify-project/ratify:v1",
+		},
+		{
+			name:     "no mapping in context",
+			ctx:      context.Background(),
+			ref:      "cache.example.com/library/nginx:1.25",
+			expected: "cache.example.com/library/nginx:1.25",
+		},
+		{
+			name:     "reference without host",
+			ctx:      ctx,
+			ref:      "nginx",
+			expected: "nginx",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := UpstreamReference(tt.ctx, tt.ref); actual != tt.expected {
+				t.Errorf("expected reference %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	_ "crypto/sha256" // required package for digest.Parse
 
@@ -95,3 +96,17 @@ func ParseRequestKey(key string) (RequestKey, error) {
 		Subject:   match[3],
 	}, nil
 }
+
+// ParseSince parses the time before which cached verification results are
+// bypassed, either an RFC 3339 timestamp or a duration before now, e.g. 1h, or
+// 0s to bypass any cached result.
+func ParseSince(since string, now time.Time) (time.Time, error) {
+	if timestamp, err := time.Parse(time.RFC3339, since); err == nil {
+		return timestamp, nil
+	}
+	duration, err := time.ParseDuration(since)
+	if err != nil || duration < 0 {
+		return time.Time{}, fmt.Errorf("invalid since %q: must be an RFC 3339 timestamp or a non-negative duration", since)
+	}
+	return now.Add(-duration), nil
+}
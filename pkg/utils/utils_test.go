@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	"github.com/ratify-project/ratify/pkg/common"
@@ -199,3 +200,51 @@ func TestParseRequestKey(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		since     string
+		expected  time.Time
+		expectErr bool
+	}{
+		{
+			name:     "timestamp",
+			since:    "2024-05-01T10:00:00Z",
+			expected: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "duration",
+			since:    "1h",
+			expected: now.Add(-time.Hour),
+		},
+		{
+			name:     "zero duration",
+			since:    "0s",
+			expected: now,
+		},
+		{
+			name:      "negative duration",
+			since:     "-1h",
+			expectErr: true,
+		},
+		{
+			name:      "invalid value",
+			since:     "yesterday",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			since, err := ParseSince(tc.since, now)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if !since.Equal(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, since)
+			}
+		})
+	}
+}
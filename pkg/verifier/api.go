@@ -43,3 +43,14 @@ type ReferenceVerifier interface {
 
 	GetNestedReferences() []string
 }
+
+// MatchArtifactType returns true if an artifact type a verifier is configured
+// with matches the artifact type of a reference. The "*" wildcard matches the
+// referrers of any artifact type but not ocispecs.SubjectArtifactType, which
+// verifiers must be configured with explicitly to verify the subject itself.
+func MatchArtifactType(configured, artifactType string) bool {
+	if configured == "*" {
+		return artifactType != ocispecs.SubjectArtifactType
+	}
+	return configured == artifactType
+}
@@ -0,0 +1,101 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// PredicateCheckConfig describes the checks applied to the predicate of a
+// verified attestation.
+type PredicateCheckConfig struct {
+	// PredicateTypes lists the accepted predicate types. Any predicate type is
+	// accepted if empty.
+	PredicateTypes []string `json:"predicateTypes,omitempty"`
+	// Schemas maps a predicate type to the JSON schema its predicate must
+	// satisfy, given as a URL or file:// path. This allows reusing the SBOM and
+	// vulnerability report schemas for the matching predicate types.
+	Schemas map[string]string `json:"schemas,omitempty"`
+	// ReplayProtection rejects attestations replayed from another subject or
+	// from the past.
+	ReplayProtection ReplayProtectionConfig `json:"replayProtection,omitempty"`
+}
+
+// PredicateCheck validates attestation predicates against a
+// PredicateCheckConfig.
+type PredicateCheck struct {
+	predicateTypes []string
+	schemas        map[string]gojsonschema.JSONLoader
+	replayCheck    *ReplayCheck
+}
+
+// NewPredicateCheck creates a PredicateCheck from its configuration.
+func NewPredicateCheck(conf PredicateCheckConfig) (*PredicateCheck, error) {
+	check := &PredicateCheck{
+		predicateTypes: conf.PredicateTypes,
+		schemas:        make(map[string]gojsonschema.JSONLoader, len(conf.Schemas)),
+	}
+	for predicateType, schema := range conf.Schemas {
+		if strings.TrimSpace(schema) == "" {
+			return nil, fmt.Errorf("schema for predicate type %s must not be empty", predicateType)
+		}
+		check.schemas[predicateType] = gojsonschema.NewReferenceLoader(schema)
+	}
+	replayCheck, err := NewReplayCheck(conf.ReplayProtection)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replayProtection: %w", err)
+	}
+	check.replayCheck = replayCheck
+	return check, nil
+}
+
+// Check returns an error if the statement carries a predicate type that is not
+// accepted, is replayed from another subject than the one with subjectDigest
+// or from the past, or carries a predicate that does not satisfy the
+// configured schema. The statement must be authenticated beforehand for the
+// checks to be meaningful.
+func (c *PredicateCheck) Check(statement *Statement, subjectDigest digest.Digest) error {
+	if len(c.predicateTypes) > 0 && !slices.Contains(c.predicateTypes, statement.PredicateType) {
+		return fmt.Errorf("predicate type %s is not accepted", statement.PredicateType)
+	}
+	if err := c.replayCheck.Check(statement, subjectDigest); err != nil {
+		return err
+	}
+	schema, ok := c.schemas[statement.PredicateType]
+	if !ok {
+		return nil
+	}
+	if len(statement.Predicate) == 0 {
+		return fmt.Errorf("attestation of predicate type %s has no predicate", statement.PredicateType)
+	}
+	result, err := gojsonschema.Validate(schema, gojsonschema.NewBytesLoader(statement.Predicate))
+	if err != nil {
+		return fmt.Errorf("failed to validate predicate of type %s: %w", statement.PredicateType, err)
+	}
+	if !result.Valid() {
+		descriptions := make([]string, 0, len(result.Errors()))
+		for _, desc := range result.Errors() {
+			descriptions = append(descriptions, desc.String())
+		}
+		return fmt.Errorf("predicate of type %s does not match its schema: %s", statement.PredicateType, strings.Join(descriptions, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	vulnPredicateType = "https://cosign.sigstore.dev/attestation/vuln/v1"
+	vulnSchema        = `{
+	"type": "object",
+	"required": ["scanner"],
+	"properties": {"scanner": {"type": "object", "required": ["uri"]}}
+}`
+)
+
+func TestNewPredicateCheck(t *testing.T) {
+	if _, err := NewPredicateCheck(PredicateCheckConfig{Schemas: map[string]string{vulnPredicateType: " "}}); err == nil {
+		t.Fatalf("expected error for empty schema")
+	}
+	if _, err := NewPredicateCheck(PredicateCheckConfig{ReplayProtection: ReplayProtectionConfig{MaxAge: "one day"}}); err == nil {
+		t.Fatalf("expected error for invalid max age")
+	}
+	if _, err := NewPredicateCheck(PredicateCheckConfig{PredicateTypes: []string{vulnPredicateType}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPredicateCheck_Check(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "vuln.json")
+	if err := os.WriteFile(schemaPath, []byte(vulnSchema), 0600); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+	subjectDigest := digest.FromString("subject")
+	subjects := []Subject{{Digest: map[string]string{"sha256": subjectDigest.Encoded()}}}
+	tests := []struct {
+		name      string
+		conf      PredicateCheckConfig
+		statement Statement
+		isErr     bool
+	}{
+		{
+			name:      "no checks configured",
+			statement: Statement{PredicateType: "https://slsa.dev/provenance/v1"},
+		},
+		{
+			name:      "accepted predicate type",
+			conf:      PredicateCheckConfig{PredicateTypes: []string{vulnPredicateType}},
+			statement: Statement{PredicateType: vulnPredicateType},
+		},
+		{
+			name:      "predicate type not accepted",
+			conf:      PredicateCheckConfig{PredicateTypes: []string{vulnPredicateType}},
+			statement: Statement{PredicateType: "https://slsa.dev/provenance/v1"},
+			isErr:     true,
+		},
+		{
+			name:      "predicate matches schema",
+			conf:      PredicateCheckConfig{Schemas: map[string]string{vulnPredicateType: "file://" + schemaPath}},
+			statement: Statement{PredicateType: vulnPredicateType, Predicate: json.RawMessage(`{"scanner":{"uri":"pkg:github/aquasecurity/trivy"}}`)},
+		},
+		{
+			name:      "predicate does not match schema",
+			conf:      PredicateCheckConfig{Schemas: map[string]string{vulnPredicateType: "file://" + schemaPath}},
+			statement: Statement{PredicateType: vulnPredicateType, Predicate: json.RawMessage(`{"scanner":{}}`)},
+			isErr:     true,
+		},
+		{
+			name:      "missing predicate",
+			conf:      PredicateCheckConfig{Schemas: map[string]string{vulnPredicateType: "file://" + schemaPath}},
+			statement: Statement{PredicateType: vulnPredicateType},
+			isErr:     true,
+		},
+		{
+			name:      "fresh attestation",
+			conf:      PredicateCheckConfig{ReplayProtection: ReplayProtectionConfig{RequireSubjectMatch: true, MaxAge: "24h"}},
+			statement: Statement{Subject: subjects, PredicateType: "https://slsa.dev/provenance/v1", Predicate: json.RawMessage(`{"runDetails":{"metadata":{"finishedOn":"` + time.Now().UTC().Format(time.RFC3339) + `"}}}`)},
+		},
+		{
+			name:      "stale attestation",
+			conf:      PredicateCheckConfig{ReplayProtection: ReplayProtectionConfig{MaxAge: "24h"}},
+			statement: Statement{Subject: subjects, PredicateType: "https://slsa.dev/provenance/v1", Predicate: json.RawMessage(`{"runDetails":{"metadata":{"finishedOn":"2020-01-01T00:00:00Z"}}}`)},
+			isErr:     true,
+		},
+		{
+			name:      "attestation of another subject",
+			conf:      PredicateCheckConfig{ReplayProtection: ReplayProtectionConfig{RequireSubjectMatch: true}},
+			statement: Statement{Subject: []Subject{{Digest: map[string]string{"sha256": digest.FromString("other").Encoded()}}}, PredicateType: "https://slsa.dev/provenance/v1"},
+			isErr:     true,
+		},
+		{
+			name:      "schema not configured for predicate type",
+			conf:      PredicateCheckConfig{Schemas: map[string]string{vulnPredicateType: "file://" + schemaPath}},
+			statement: Statement{PredicateType: "https://slsa.dev/provenance/v1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := NewPredicateCheck(tt.conf)
+			if err != nil {
+				t.Fatalf("failed to create predicate check: %v", err)
+			}
+			if err := check.Check(&tt.statement, subjectDigest); tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// maxClockSkew is the tolerated drift of attestation timestamps into the future.
+const maxClockSkew = 5 * time.Minute
+
+// ReplayProtectionConfig describes the checks rejecting attestations replayed
+// from another subject or from the past.
+type ReplayProtectionConfig struct {
+	// RequireSubjectMatch rejects attestations whose subjects do not include the
+	// digest of the verified subject.
+	RequireSubjectMatch bool `json:"requireSubjectMatch,omitempty"`
+	// MaxAge rejects attestations whose embedded timestamp is older than the
+	// duration, e.g. 24h. Attestations without timestamp are not rejected.
+	MaxAge string `json:"maxAge,omitempty"`
+}
+
+// ReplayCheck validates attestations against a ReplayProtectionConfig.
+type ReplayCheck struct {
+	requireSubjectMatch bool
+	maxAge              time.Duration
+	now                 func() time.Time
+}
+
+// NewReplayCheck creates a ReplayCheck from its configuration.
+func NewReplayCheck(conf ReplayProtectionConfig) (*ReplayCheck, error) {
+	check := &ReplayCheck{
+		requireSubjectMatch: conf.RequireSubjectMatch,
+		now:                 time.Now,
+	}
+	if conf.MaxAge != "" {
+		maxAge, err := time.ParseDuration(conf.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse maxAge %s: %w", conf.MaxAge, err)
+		}
+		if maxAge <= 0 {
+			return nil, fmt.Errorf("maxAge must be positive, got %s", conf.MaxAge)
+		}
+		check.maxAge = maxAge
+	}
+	return check, nil
+}
+
+// Check returns an error if the statement does not reference the subject
+// digest or was produced outside the configured window.
+func (c *ReplayCheck) Check(statement *Statement, subjectDigest digest.Digest) error {
+	if c.requireSubjectMatch && !referencesDigest(statement, subjectDigest) {
+		return fmt.Errorf("attestation does not reference the subject digest %s", subjectDigest)
+	}
+	if c.maxAge == 0 {
+		return nil
+	}
+	timestamp, ok := statement.Timestamp()
+	if !ok {
+		return nil
+	}
+	now := c.now()
+	if timestamp.Before(now.Add(-c.maxAge)) {
+		return fmt.Errorf("attestation timestamp %s is older than the max age %s", timestamp.Format(time.RFC3339), c.maxAge)
+	}
+	if timestamp.After(now.Add(maxClockSkew)) {
+		return fmt.Errorf("attestation timestamp %s is in the future", timestamp.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func referencesDigest(statement *Statement, subjectDigest digest.Digest) bool {
+	for _, subject := range statement.Subject {
+		if subject.Digest[subjectDigest.Algorithm().String()] == subjectDigest.Encoded() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const statementFormat = `{
+	"_type": "https://in-toto.io/Statement/v1",
+	"subject": [{"name": "test", "digest": {"sha256": "%s"}}],
+	"predicateType": "https://slsa.dev/provenance/v1",
+	"predicate": %s
+}`
+
+func TestDecodeStatement(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	statement := fmt.Sprintf(statementFormat, subjectDigest.Encoded(), `{}`)
+	tests := []struct {
+		name  string
+		blob  string
+		isErr bool
+	}{
+		{
+			name: "statement",
+			blob: statement,
+		},
+		{
+			name: "dsse envelope",
+			blob: fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":"%s"}`, base64.StdEncoding.EncodeToString([]byte(statement))),
+		},
+		{
+			name:  "invalid dsse payload",
+			blob:  `{"payloadType":"application/vnd.in-toto+json","payload":"%%%"}`,
+			isErr: true,
+		},
+		{
+			name:  "invalid json",
+			blob:  "invalid",
+			isErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeStatement([]byte(tt.blob))
+			if tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+			if tt.isErr {
+				return
+			}
+			if len(decoded.Subject) != 1 || decoded.Subject[0].Digest["sha256"] != subjectDigest.Encoded() {
+				t.Fatalf("unexpected subject %v", decoded.Subject)
+			}
+		})
+	}
+}
+
+func TestNewReplayCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge string
+		isErr  bool
+	}{
+		{name: "no max age"},
+		{name: "valid max age", maxAge: "24h"},
+		{name: "invalid max age", maxAge: "one day", isErr: true},
+		{name: "negative max age", maxAge: "-1h", isErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewReplayCheck(ReplayProtectionConfig{MaxAge: tt.maxAge}); tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+		})
+	}
+}
+
+func TestReplayCheck_Check(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	otherDigest := digest.FromString("other")
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name             string
+		conf             ReplayProtectionConfig
+		statementSubject digest.Digest
+		predicate        string
+		isErr            bool
+	}{
+		{
+			name:             "matching subject digest",
+			conf:             ReplayProtectionConfig{RequireSubjectMatch: true},
+			statementSubject: subjectDigest,
+			predicate:        `{}`,
+		},
+		{
+			name:             "mismatching subject digest",
+			conf:             ReplayProtectionConfig{RequireSubjectMatch: true},
+			statementSubject: otherDigest,
+			predicate:        `{}`,
+			isErr:            true,
+		},
+		{
+			name:             "mismatching subject digest not required",
+			statementSubject: otherDigest,
+			predicate:        `{}`,
+		},
+		{
+			name:             "slsa v1 timestamp in window",
+			conf:             ReplayProtectionConfig{MaxAge: "24h"},
+			statementSubject: subjectDigest,
+			predicate:        `{"runDetails":{"metadata":{"finishedOn":"2024-06-01T08:00:00Z"}}}`,
+		},
+		{
+			name:             "slsa v0.2 timestamp out of window",
+			conf:             ReplayProtectionConfig{MaxAge: "24h"},
+			statementSubject: subjectDigest,
+			predicate:        `{"metadata":{"buildFinishedOn":"2024-05-30T12:00:00Z"}}`,
+			isErr:            true,
+		},
+		{
+			name:             "generic timestamp in the future",
+			conf:             ReplayProtectionConfig{MaxAge: "24h"},
+			statementSubject: subjectDigest,
+			predicate:        `{"timestamp":"2024-06-01T13:00:00Z"}`,
+			isErr:            true,
+		},
+		{
+			name:             "no timestamp",
+			conf:             ReplayProtectionConfig{MaxAge: "24h"},
+			statementSubject: subjectDigest,
+			predicate:        `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := NewReplayCheck(tt.conf)
+			if err != nil {
+				t.Fatalf("failed to create replay check: %v", err)
+			}
+			check.now = func() time.Time { return now }
+			statement, err := DecodeStatement([]byte(fmt.Sprintf(statementFormat, tt.statementSubject.Encoded(), tt.predicate)))
+			if err != nil {
+				t.Fatalf("failed to decode statement: %v", err)
+			}
+			if err := check.Check(statement, subjectDigest); tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Statement is an in-toto attestation statement.
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// Subject is an artifact an in-toto statement is about.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Envelope is a DSSE envelope wrapping an in-toto statement.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures,omitempty"`
+}
+
+// Signature is a DSSE signature over the pre-authentication encoding of the
+// envelope payload.
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   string `json:"sig"`
+}
+
+// PreAuthEncoding returns the DSSE pre-authentication encoding of the payload
+// that is signed.
+func PreAuthEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// timestampClaims holds the predicate fields recording when the attestation
+// was produced.
+type timestampClaims struct {
+	// generic predicates
+	Timestamp *time.Time `json:"timestamp"`
+	// SLSA v0.2
+	Metadata struct {
+		BuildFinishedOn *time.Time `json:"buildFinishedOn"`
+	} `json:"metadata"`
+	// SLSA v1
+	RunDetails struct {
+		Metadata struct {
+			FinishedOn *time.Time `json:"finishedOn"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+// DecodeStatement parses an in-toto statement, optionally wrapped in a DSSE
+// envelope.
+func DecodeStatement(blob []byte) (*Statement, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(blob, &envelope); err == nil && envelope.PayloadType != "" {
+		if blob, err = base64.StdEncoding.DecodeString(envelope.Payload); err != nil {
+			return nil, fmt.Errorf("failed to decode DSSE payload: %w", err)
+		}
+	}
+
+	var statement Statement
+	if err := json.Unmarshal(blob, &statement); err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// Timestamp returns the time the attestation claims it was produced at. It
+// returns false if the predicate carries no timestamp.
+func (s *Statement) Timestamp() (time.Time, bool) {
+	if len(s.Predicate) == 0 {
+		return time.Time{}, false
+	}
+	var claims timestampClaims
+	if err := json.Unmarshal(s.Predicate, &claims); err != nil {
+		return time.Time{}, false
+	}
+	for _, timestamp := range []*time.Time{claims.RunDetails.Metadata.FinishedOn, claims.Metadata.BuildFinishedOn, claims.Timestamp} {
+		if timestamp != nil {
+			return *timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
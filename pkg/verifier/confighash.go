@@ -0,0 +1,65 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package verifier
+
+import (
+	"reflect"
+	"sync"
+)
+
+// configHashes maps the verifiers to the hash of the config they were
+// created from. The entries of verifiers replaced or discarded must be deleted
+// with DeleteConfigHash or DeleteConfigHashes, the map would keep them alive
+// otherwise.
+var configHashes sync.Map
+
+// SetConfigHash records the hash of the config the verifier was created from.
+// Only verifiers implemented by pointers are tracked.
+func SetConfigHash(verifier ReferenceVerifier, hash string) {
+	if isTrackable(verifier) {
+		configHashes.Store(verifier, hash)
+	}
+}
+
+// ConfigHash returns the hash of the config the verifier was created from,
+// empty if unknown.
+func ConfigHash(verifier ReferenceVerifier) string {
+	if !isTrackable(verifier) {
+		return ""
+	}
+	hash, _ := configHashes.Load(verifier)
+	hashStr, _ := hash.(string)
+	return hashStr
+}
+
+// DeleteConfigHash forgets the config hash of a verifier no longer in use.
+func DeleteConfigHash(verifier ReferenceVerifier) {
+	if isTrackable(verifier) {
+		configHashes.Delete(verifier)
+	}
+}
+
+// DeleteConfigHashes forgets the config hashes of a set of verifiers no longer
+// in use, e.g. the verifiers of a reloaded config.
+func DeleteConfigHashes(verifiers []ReferenceVerifier) {
+	for _, verifier := range verifiers {
+		DeleteConfigHash(verifier)
+	}
+}
+
+// isTrackable reports whether the verifier can be used as a map key.
+func isTrackable(verifier ReferenceVerifier) bool {
+	return verifier != nil && reflect.TypeOf(verifier).Kind() == reflect.Pointer
+}
@@ -29,6 +29,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/common"
@@ -38,9 +39,11 @@ import (
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	"github.com/ratify-project/ratify/pkg/utils"
 	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/attestation"
 	"github.com/ratify-project/ratify/pkg/verifier/config"
 	"github.com/ratify-project/ratify/pkg/verifier/factory"
 	"github.com/ratify-project/ratify/pkg/verifier/types"
+	verifierutils "github.com/ratify-project/ratify/pkg/verifier/utils"
 	"golang.org/x/crypto/cryptobyte"
 	"golang.org/x/crypto/cryptobyte/asn1"
 
@@ -49,12 +52,14 @@ import (
 	imgspec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	re "github.com/ratify-project/ratify/errors"
+	commonutils "github.com/ratify-project/ratify/pkg/common/utils"
 	"github.com/sigstore/cosign/v2/cmd/cosign/cli/fulcio"
 	"github.com/sigstore/cosign/v2/cmd/cosign/cli/rekor"
 	"github.com/sigstore/cosign/v2/pkg/cosign"
 	"github.com/sigstore/cosign/v2/pkg/cosign/bundle"
 	"github.com/sigstore/cosign/v2/pkg/oci"
 	"github.com/sigstore/cosign/v2/pkg/oci/static"
+	ctypes "github.com/sigstore/cosign/v2/pkg/types"
 	"github.com/sigstore/sigstore/pkg/signature"
 )
 
@@ -66,6 +71,18 @@ type PluginConfig struct {
 	RekorURL         string              `json:"rekorURL,omitempty"`
 	NestedReferences []string            `json:"nestedArtifactTypes,omitempty"`
 	TrustPolicies    []TrustPolicyConfig `json:"trustPolicies,omitempty"`
+	// Attestations configures the checks applied to the predicate of DSSE
+	// attestations, e.g. provenance, and their replay protection once their
+	// envelope signature is verified.
+	Attestations attestation.PredicateCheckConfig `json:"attestations,omitempty"`
+	// VerifySigningTime fails the verification of a keyless signature if its
+	// transparency log integration time is outside the validity window of the
+	// signing certificate.
+	VerifySigningTime bool `json:"verifySigningTime,omitempty"`
+	// AllowedSignatureAlgorithms are the key and digest algorithms the
+	// signatures and certificate chains may use, e.g. RSA-3072, ECDSA-P256 or
+	// SHA-256. Any algorithm is allowed if empty.
+	AllowedSignatureAlgorithms []string `json:"allowedSignatureAlgorithms,omitempty"`
 }
 
 // LegacyExtension is the structure for the verifier result extensions
@@ -86,6 +103,7 @@ type Extension struct {
 // per signature found in the image manifest
 type cosignExtensionList struct {
 	Signature     string            `json:"signature"`
+	PredicateType string            `json:"predicateType,omitempty"`
 	Verifications []cosignExtension `json:"verifications"`
 }
 
@@ -109,6 +127,8 @@ type cosignVerifier struct {
 	config           *PluginConfig
 	isLegacy         bool
 	trustPolicies    *TrustPolicies
+	predicateCheck   *attestation.PredicateCheck
+	algorithmPolicy  *verifierutils.AlgorithmPolicy
 	namespace        string
 }
 
@@ -168,6 +188,16 @@ func (f *cosignVerifierFactory) Create(_ string, verifierConfig config.VerifierC
 		legacy = false
 	}
 
+	predicateCheck, err := attestation.NewPredicateCheck(config.Attestations)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail("Failed to create the Cosign Verifier").WithError(err)
+	}
+
+	algorithmPolicy, err := verifierutils.NewAlgorithmPolicy(config.AllowedSignatureAlgorithms)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail("Failed to create the Cosign Verifier").WithError(err)
+	}
+
 	return &cosignVerifier{
 		name:             verifierName,
 		verifierType:     config.Type,
@@ -176,6 +206,8 @@ func (f *cosignVerifierFactory) Create(_ string, verifierConfig config.VerifierC
 		config:           config,
 		isLegacy:         legacy,
 		trustPolicies:    trustPolicies,
+		predicateCheck:   predicateCheck,
+		algorithmPolicy:  algorithmPolicy,
 		namespace:        namespace,
 	}, nil
 }
@@ -193,7 +225,7 @@ func (v *cosignVerifier) Type() string {
 // CanVerify returns true if the referenceDescriptor's artifact type is in the list of artifact types supported by the verifier
 func (v *cosignVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
 	for _, at := range v.artifactTypes {
-		if at == "*" || at == referenceDescriptor.ArtifactType {
+		if verifier.MatchArtifactType(at, referenceDescriptor.ArtifactType) {
 			return true
 		}
 	}
@@ -208,8 +240,9 @@ func (v *cosignVerifier) Verify(ctx context.Context, subjectReference common.Ref
 }
 
 func (v *cosignVerifier) verifyInternal(ctx context.Context, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
-	// get the trust policy for the reference
-	trustPolicy, err := v.trustPolicies.GetScopedPolicy(subjectReference.Original)
+	// get the trust policy for the reference, subjects served from a
+	// pull-through cache are scoped by their upstream registry
+	trustPolicy, err := v.trustPolicies.GetScopedPolicy(utils.UpstreamReference(ctx, subjectReference.Original))
 	if err != nil {
 		return errorToVerifyResult(v.name, v.verifierType, err), nil
 	}
@@ -227,9 +260,10 @@ func (v *cosignVerifier) verifyInternal(ctx context.Context, subjectReference co
 		return errorToVerifyResult(v.name, v.verifierType, re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to get Cosign signature metadata for %s", referenceDescriptor.Digest)).WithError(err)), nil
 	}
 
-	// manifest must be an OCI Image
-	if referenceManifest.MediaType != imgspec.MediaTypeImageManifest {
-		return errorToVerifyResult(v.name, v.verifierType, re.ErrorCodeVerifyPluginFailure.WithDetail("The artifact metadata is not an OCI image")), nil
+	// manifest must be an OCI image or artifact manifest, neither of which
+	// requires an image config
+	if referenceManifest.MediaType != imgspec.MediaTypeImageManifest && referenceManifest.MediaType != ocispecs.MediaTypeArtifactManifest {
+		return errorToVerifyResult(v.name, v.verifierType, re.ErrorCodeVerifyPluginFailure.WithDetail("The artifact metadata is not an OCI image or artifact manifest")), nil
 	}
 
 	// get the subject image descriptor
@@ -246,8 +280,11 @@ func (v *cosignVerifier) verifyInternal(ctx context.Context, subjectReference co
 
 	sigExtensions := make([]cosignExtensionList, 0)
 	hasValidSignature := false
-	// check each signature found
-	for _, blob := range referenceManifest.Blobs {
+	// the expiries of the valid signatures, any of which the result relies on
+	var validUntil []*time.Time
+	// check each signature found, a signature layer listed more than once is
+	// only verified once
+	for _, blob := range uniqueBlobs(referenceManifest.Blobs) {
 		extensionListEntry := cosignExtensionList{
 			Signature:     blob.Annotations[static.SignatureAnnotationKey],
 			Verifications: make([]cosignExtension, 0),
@@ -266,10 +303,22 @@ func (v *cosignVerifier) verifyInternal(ctx context.Context, subjectReference co
 		if err != nil {
 			return errorToVerifyResult(v.name, v.verifierType, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to validate the Cosign signature").WithError(err)), nil
 		}
+		verify := cosign.VerifyImageSignature
+		blobOpts := cosignOpts
+		// the signed payload must reference the subject, so that a valid
+		// signature of another image cannot be transplanted onto it
+		blobOpts.ClaimVerifier = cosign.SimpleClaimVerifier
+		isAttestation := blob.MediaType == ctypes.DssePayloadType
+		if isAttestation {
+			// attestations are DSSE envelopes whose in-toto subject must be the verified subject
+			verify = cosign.VerifyBlobAttestation
+			blobOpts.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
+		}
+		signingTimeProven := false
 		if len(keysMap) > 0 {
 			// if keys are found, perform verification with keys
 			var verifications []cosignExtension
-			verifications, hasValidSignature, err = verifyWithKeys(ctx, keysMap, sig, blob.Annotations[static.SignatureAnnotationKey], blobBytes, staticOpts, &cosignOpts, subjectDescHash)
+			verifications, hasValidSignature, err = verifyWithKeys(ctx, keysMap, sig, blob.Annotations[static.SignatureAnnotationKey], blobBytes, staticOpts, &blobOpts, subjectDescHash, verify)
 			if err != nil {
 				return errorToVerifyResult(v.name, v.verifierType, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to validate the Cosign signature with keys").WithError(err)), nil
 			}
@@ -277,14 +326,40 @@ func (v *cosignVerifier) verifyInternal(ctx context.Context, subjectReference co
 		} else {
 			// if no keys are found, perform keyless verification
 			var extension cosignExtension
-			extension, hasValidSignature = verifyKeyless(ctx, sig, &cosignOpts, subjectDescHash)
+			extension, hasValidSignature = verifyKeyless(ctx, sig, &blobOpts, subjectDescHash, verify)
 			extensionListEntry.Verifications = append(extensionListEntry.Verifications, extension)
+			signingTimeProven = extension.BundleVerified
+		}
+		// the predicate is only trusted once the envelope signature is verified
+		if isAttestation && hasValidSignature {
+			var predicateType string
+			predicateType, err = v.checkPredicate(blobBytes, subjectDesc.Digest)
+			extensionListEntry.PredicateType = predicateType
+			if err != nil {
+				hasValidSignature = false
+				failVerifications(extensionListEntry.Verifications, err)
+			}
+		}
+		if len(keysMap) > 0 && hasValidSignature {
+			hasValidSignature = checkRetiredKeys(trustPolicy, sig, extensionListEntry.Verifications, time.Now())
+		}
+		if v.config != nil && v.config.VerifySigningTime && hasValidSignature {
+			if err = checkSigningTime(sig); err != nil {
+				hasValidSignature = false
+				failVerifications(extensionListEntry.Verifications, err)
+			}
+		}
+		if v.algorithmPolicy != nil && hasValidSignature {
+			hasValidSignature = v.checkAlgorithms(sig, keysMap, extensionListEntry.Verifications)
+		}
+		if hasValidSignature {
+			validUntil = append(validUntil, verifierutils.EarliestExpiry(certificateExpiries(sig, signingTimeProven)...))
 		}
 		sigExtensions = append(sigExtensions, extensionListEntry)
 	}
 
 	if hasValidSignature {
-		return verifier.NewVerifierResult(
+		result := verifier.NewVerifierResult(
 			"",
 			v.name,
 			v.verifierType,
@@ -292,11 +367,14 @@ func (v *cosignVerifier) verifyInternal(ctx context.Context, subjectReference co
 			true,
 			nil,
 			Extension{SignatureExtension: sigExtensions, TrustPolicy: trustPolicy.GetName()},
-		), nil
+		)
+		result.ValidUntil = verifierutils.LatestExpiry(validUntil...)
+		return result, nil
 	}
 
 	errorResult := errorToVerifyResult(v.name, v.verifierType, fmt.Errorf("no valid Cosign signatures found"))
 	errorResult.Extensions = Extension{SignatureExtension: sigExtensions, TrustPolicy: trustPolicy.GetName()}
+	errorResult.Remediation = fmt.Sprintf("Sign %s with cosign using a key or identity trusted by the trust policy %s.", subjectReference.Original, trustPolicy.GetName())
 	return errorResult, nil
 }
 
@@ -367,7 +445,7 @@ func (v *cosignVerifier) verifyLegacy(ctx context.Context, subjectReference comm
 
 	sigExtensions := make([]cosignExtension, 0)
 	signatures := []oci.Signature{}
-	for _, blob := range referenceManifest.Blobs {
+	for _, blob := range uniqueBlobs(referenceManifest.Blobs) {
 		blobBytes, err := referrerStore.GetBlobContent(ctx, subjectReference, blob.Digest)
 		if err != nil {
 			return errorToVerifyResult(v.name, v.verifierType, fmt.Errorf("failed to get blob content: %w", err)), nil
@@ -426,12 +504,7 @@ func parseVerifierConfig(verifierConfig config.VerifierConfig) (*PluginConfig, e
 	}
 	conf := PluginConfig{}
 
-	verifierConfigBytes, err := json.Marshal(verifierConfig)
-	if err != nil {
-		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, verifierName, re.EmptyLink, err, nil, re.HideStackTrace)
-	}
-
-	if err := json.Unmarshal(verifierConfigBytes, &conf); err != nil {
+	if err := commonutils.DecodeConfig(verifierConfig, &conf); err != nil {
 		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, verifierName, re.EmptyLink, err, fmt.Sprintf("failed to unmarshal to cosign verifier config from: %+v.", verifierConfig), re.HideStackTrace)
 	}
 
@@ -463,6 +536,21 @@ func loadPublicKey(keyRef string) (verifier signature.Verifier, err error) {
 	return signature.LoadECDSAVerifier(ed, crypto.SHA256)
 }
 
+// uniqueBlobs returns the blobs with the ones sharing the digest of a previous
+// blob removed.
+func uniqueBlobs(blobs []imgspec.Descriptor) []imgspec.Descriptor {
+	seen := make(map[digest.Digest]bool, len(blobs))
+	unique := make([]imgspec.Descriptor, 0, len(blobs))
+	for _, blob := range blobs {
+		if seen[blob.Digest] {
+			continue
+		}
+		seen[blob.Digest] = true
+		unique = append(unique, blob)
+	}
+	return unique
+}
+
 // StaticLayerOpts builds the cosign options for static layer signatures
 func staticLayerOpts(desc imgspec.Descriptor) ([]static.Option, error) {
 	options := []static.Option{}
@@ -527,8 +615,12 @@ func decodeASN1Signature(sig []byte) ([]byte, error) {
 	return rawSigBytes, nil
 }
 
+// verifySignatureFunc verifies a cosign signature or attestation against the
+// subject hash and returns whether the bundle was verified
+type verifySignatureFunc func(ctx context.Context, sig oci.Signature, h v1.Hash, co *cosign.CheckOpts) (bool, error)
+
 // verifyWithKeys verifies the signature with the keys map and returns the verification results
-func verifyWithKeys(ctx context.Context, keysMap map[PKKey]keymanagementprovider.PublicKey, sig oci.Signature, sigEncoded string, payload []byte, staticOpts []static.Option, cosignOpts *cosign.CheckOpts, subjectDescHash v1.Hash) ([]cosignExtension, bool, error) {
+func verifyWithKeys(ctx context.Context, keysMap map[PKKey]keymanagementprovider.PublicKey, sig oci.Signature, sigEncoded string, payload []byte, staticOpts []static.Option, cosignOpts *cosign.CheckOpts, subjectDescHash v1.Hash, verify verifySignatureFunc) ([]cosignExtension, bool, error) {
 	// check each key in the map of keys returned by the trust policy
 	var err error
 	verifications := make([]cosignExtension, 0)
@@ -551,7 +643,7 @@ func verifyWithKeys(ctx context.Context, keysMap map[PKKey]keymanagementprovider
 		}
 		cosignOpts.SigVerifier = verifier
 		// verify signature with cosign options + perform bundle verification
-		bundleVerified, err := cosign.VerifyImageSignature(ctx, sig, subjectDescHash, cosignOpts)
+		bundleVerified, err := verify(ctx, sig, subjectDescHash, cosignOpts)
 		extension := cosignExtension{
 			IsSuccess:      true,
 			BundleVerified: bundleVerified,
@@ -570,10 +662,10 @@ func verifyWithKeys(ctx context.Context, keysMap map[PKKey]keymanagementprovider
 }
 
 // verifyKeyless performs keyless verification and returns the verification results
-func verifyKeyless(ctx context.Context, sig oci.Signature, cosignOpts *cosign.CheckOpts, subjectDescHash v1.Hash) (cosignExtension, bool) {
+func verifyKeyless(ctx context.Context, sig oci.Signature, cosignOpts *cosign.CheckOpts, subjectDescHash v1.Hash, verify verifySignatureFunc) (cosignExtension, bool) {
 	// verify signature with cosign options + perform bundle verification
 	hasValidSignature := false
-	bundleVerified, err := cosign.VerifyImageSignature(ctx, sig, subjectDescHash, cosignOpts)
+	bundleVerified, err := verify(ctx, sig, subjectDescHash, cosignOpts)
 	extension := cosignExtension{
 		IsSuccess:      true,
 		BundleVerified: bundleVerified,
@@ -588,6 +680,158 @@ func verifyKeyless(ctx context.Context, sig oci.Signature, cosignOpts *cosign.Ch
 	return extension, hasValidSignature
 }
 
+// checkPredicate decodes the in-toto statement of a verified DSSE envelope and
+// validates its predicate and freshness, returning the predicate type
+func (v *cosignVerifier) checkPredicate(envelope []byte, subjectDigest digest.Digest) (string, error) {
+	statement, err := attestation.DecodeStatement(envelope)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode attestation statement: %w", err)
+	}
+	if err := v.predicateCheck.Check(statement, subjectDigest); err != nil {
+		return statement.PredicateType, fmt.Errorf("attestation predicate check failed: %w", err)
+	}
+	return statement.PredicateType, nil
+}
+
+// checkSigningTime checks the signature was integrated into the transparency
+// log while its signing certificate was valid. Signatures without a
+// certificate, i.e. signed with a key, are not checked.
+func checkSigningTime(sig oci.Signature) error {
+	cert, err := sig.Cert()
+	if err != nil {
+		return fmt.Errorf("failed to get the signing certificate: %w", err)
+	}
+	if cert == nil {
+		return nil
+	}
+	rekorBundle, err := sig.Bundle()
+	if err != nil {
+		return fmt.Errorf("failed to get the transparency log bundle: %w", err)
+	}
+	if rekorBundle == nil {
+		return fmt.Errorf("the signature has no transparency log bundle to determine the signing time")
+	}
+	return verifierutils.CheckSigningTime(time.Unix(rekorBundle.Payload.IntegratedTime, 0), cert)
+}
+
+// retiringTrustPolicy is implemented by trust policies with retired keys.
+type retiringTrustPolicy interface {
+	// retiredUntil returns the end of the grace period of the key if it is
+	// retired.
+	retiredUntil(key PKKey) (time.Time, bool)
+}
+
+// checkRetiredKeys fails the successful verifications of the signature with a
+// retired key if the signature was made after the grace period of the key and
+// returns whether a verification still succeeds. The signing time is the time
+// the signature was integrated into the verified transparency log bundle, or
+// now if the signature has none.
+func checkRetiredKeys(trustPolicy TrustPolicy, sig oci.Signature, verifications []cosignExtension, now time.Time) bool {
+	policy, hasRetiredKeys := trustPolicy.(retiringTrustPolicy)
+	hasValidSignature := false
+	for i := range verifications {
+		if !verifications[i].IsSuccess {
+			continue
+		}
+		if hasRetiredKeys {
+			if graceEnd, retired := policy.retiredUntil(verifications[i].KeyInformation); retired {
+				signingTime := now
+				if verifications[i].BundleVerified {
+					if rekorBundle, err := sig.Bundle(); err == nil && rekorBundle != nil {
+						signingTime = time.Unix(rekorBundle.Payload.IntegratedTime, 0)
+					}
+				}
+				if signingTime.After(graceEnd) {
+					failVerifications(verifications[i:i+1], fmt.Errorf("key %s was retired and its grace period ended at %s, before the signing time %s", verifications[i].KeyInformation.Name, graceEnd.UTC().Format(time.RFC3339), signingTime.UTC().Format(time.RFC3339)))
+					continue
+				}
+			}
+		}
+		hasValidSignature = true
+	}
+	return hasValidSignature
+}
+
+// checkAlgorithms fails the successful verifications of the signature relying
+// on a key, digest or certificate chain algorithm that is not allowed and
+// returns whether a verification still succeeds.
+func (v *cosignVerifier) checkAlgorithms(sig oci.Signature, keysMap map[PKKey]keymanagementprovider.PublicKey, verifications []cosignExtension) bool {
+	hasValidSignature := false
+	for i := range verifications {
+		if !verifications[i].IsSuccess {
+			continue
+		}
+		if err := v.checkVerificationAlgorithms(sig, keysMap, verifications[i].KeyInformation); err != nil {
+			failVerifications(verifications[i:i+1], err)
+			continue
+		}
+		hasValidSignature = true
+	}
+	return hasValidSignature
+}
+
+// checkVerificationAlgorithms returns an error if the key the signature was
+// verified with, or the certificate chain of a keyless signature, uses an
+// algorithm that is not allowed.
+func (v *cosignVerifier) checkVerificationAlgorithms(sig oci.Signature, keysMap map[PKKey]keymanagementprovider.PublicKey, keyInformation PKKey) error {
+	pubKey, isKey := keysMap[keyInformation]
+	if !isKey {
+		// keyless signatures are verified with the Fulcio certificate chain
+		cert, err := sig.Cert()
+		if err != nil || cert == nil {
+			return fmt.Errorf("failed to get the signing certificate to check its algorithms: %w", err)
+		}
+		chain, err := sig.Chain()
+		if err != nil {
+			return fmt.Errorf("failed to get the certificate chain to check its algorithms: %w", err)
+		}
+		if err := v.algorithmPolicy.CheckDigest(crypto.SHA256); err != nil {
+			return fmt.Errorf("signature: %w", err)
+		}
+		return v.algorithmPolicy.CheckCertificates(append([]*x509.Certificate{cert}, chain...))
+	}
+	hashType := crypto.SHA256
+	if pubKey.ProviderType == azurekeyvault.ProviderName {
+		var err error
+		if hashType, err = akvHashType(pubKey.Key); err != nil {
+			return err
+		}
+	}
+	if err := v.algorithmPolicy.CheckKey(pubKey.Key); err != nil {
+		return fmt.Errorf("key %s: %w", keyInformation.Name, err)
+	}
+	if err := v.algorithmPolicy.CheckDigest(hashType); err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+	return nil
+}
+
+// certificateExpiries returns the expiries of the signing certificate and
+// chain of a keyless signature. A verified transparency log entry proves the
+// signature was made while the short-lived signing certificate was valid, so
+// that only the chain of the certificate authority limits the result then.
+func certificateExpiries(sig oci.Signature, signingTimeProven bool) []time.Time {
+	var certs []*x509.Certificate
+	if cert, err := sig.Cert(); err == nil && cert != nil && !signingTimeProven {
+		certs = append(certs, cert)
+	}
+	if chain, err := sig.Chain(); err == nil {
+		certs = append(certs, chain...)
+	}
+	return verifierutils.CertificateChainExpiries(certs)
+}
+
+// failVerifications marks the successful verifications of a signature as failed
+func failVerifications(verifications []cosignExtension, err error) {
+	for i := range verifications {
+		if verifications[i].IsSuccess {
+			verifications[i].IsSuccess = false
+			verifications[i].Err = err.Error()
+			verifications[i].Summary = nil
+		}
+	}
+}
+
 // getKeyMapOptsDefault returns the map of keys and cosign options for the reference
 func getKeyMapOptsDefault(ctx context.Context, trustPolicy TrustPolicy, namespace string) (map[PKKey]keymanagementprovider.PublicKey, cosign.CheckOpts, error) {
 	// get the map of keys for that reference
@@ -607,20 +851,11 @@ func getKeyMapOptsDefault(ctx context.Context, trustPolicy TrustPolicy, namespac
 
 // processAKVSignature processes the AKV signature and returns the hash type, signature and error
 func processAKVSignature(sigEncoded string, staticSig oci.Signature, publicKey crypto.PublicKey, payloadBytes []byte, staticOpts []static.Option) (crypto.Hash, oci.Signature, error) {
-	var hashType crypto.Hash
-	switch keyType := publicKey.(type) {
-	case *rsa.PublicKey:
-		switch keyType.Size() {
-		case 256:
-			hashType = crypto.SHA256
-		case 384:
-			hashType = crypto.SHA384
-		case 512:
-			hashType = crypto.SHA512
-		default:
-			return crypto.SHA256, nil, fmt.Errorf("RSA key check: unsupported key size: %d", keyType.Size())
-		}
-
+	hashType, err := akvHashType(publicKey)
+	if err != nil {
+		return crypto.SHA256, nil, err
+	}
+	if _, ok := publicKey.(*rsa.PublicKey); ok {
 		// TODO: remove section after fix for bug in cosign azure key vault implementation
 		// tracking issue: https://github.com/sigstore/sigstore/issues/1384
 		// summary: azure keyvault implementation ASN.1 encodes sig after online signing with keyvault
@@ -639,21 +874,35 @@ func processAKVSignature(sigEncoded string, staticSig oci.Signature, publicKey c
 		if err != nil {
 			return crypto.SHA256, nil, re.ErrorCodeVerifyPluginFailure.WithDetail("RSA key check: failed to generate static signature").WithError(err)
 		}
+	}
+	return hashType, staticSig, nil
+}
+
+// akvHashType returns the hash Azure Key Vault signs with for the key.
+func akvHashType(publicKey crypto.PublicKey) (crypto.Hash, error) {
+	switch keyType := publicKey.(type) {
+	case *rsa.PublicKey:
+		switch keyType.Size() {
+		case 256:
+			return crypto.SHA256, nil
+		case 384:
+			return crypto.SHA384, nil
+		case 512:
+			return crypto.SHA512, nil
+		}
+		return crypto.SHA256, fmt.Errorf("RSA key check: unsupported key size: %d", keyType.Size())
 	case *ecdsa.PublicKey:
 		switch keyType.Curve {
 		case elliptic.P256():
-			hashType = crypto.SHA256
+			return crypto.SHA256, nil
 		case elliptic.P384():
-			hashType = crypto.SHA384
+			return crypto.SHA384, nil
 		case elliptic.P521():
-			hashType = crypto.SHA512
-		default:
-			return crypto.SHA256, nil, fmt.Errorf("ECDSA key check: unsupported key curve [%s]", keyType.Params().Name)
+			return crypto.SHA512, nil
 		}
-	default:
-		return crypto.SHA256, nil, fmt.Errorf("unsupported public key type [%T]", publicKey)
+		return crypto.SHA256, fmt.Errorf("ECDSA key check: unsupported key curve [%s]", keyType.Params().Name)
 	}
-	return hashType, staticSig, nil
+	return crypto.SHA256, fmt.Errorf("unsupported public key type [%T]", publicKey)
 }
 
 // verificationPerformedMessage returns a string list of all verifications performed
@@ -23,12 +23,18 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	imgspec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -38,8 +44,11 @@ import (
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
 	"github.com/ratify-project/ratify/pkg/verifier/config"
+	verifierutils "github.com/ratify-project/ratify/pkg/verifier/utils"
 	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/cosign/v2/pkg/cosign/bundle"
 	"github.com/sigstore/cosign/v2/pkg/oci/static"
+	ctypes "github.com/sigstore/cosign/v2/pkg/types"
 	"github.com/sigstore/rekor/pkg/generated/client"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
@@ -135,6 +144,33 @@ func TestCreate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid allowed signature algorithms",
+			config: config.VerifierConfig{
+				"name":                       "test",
+				"artifactTypes":              "testtype",
+				"allowedSignatureAlgorithms": []string{"RSA-3072", "ECDSA-P256", "SHA-256"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported allowed signature algorithm",
+			config: config.VerifierConfig{
+				"name":                       "test",
+				"artifactTypes":              "testtype",
+				"allowedSignatureAlgorithms": []string{"DSA-1024", "SHA-256"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "allowed signature algorithms without a digest",
+			config: config.VerifierConfig{
+				"name":                       "test",
+				"artifactTypes":              "testtype",
+				"allowedSignatureAlgorithms": []string{"RSA-3072"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid config with legacy and trust policies",
 			config: config.VerifierConfig{
@@ -416,6 +452,16 @@ func TestStaticLayerOpts(test *testing.T) {
 	}
 }
 
+// TestUniqueBlobs tests that a signature layer is only verified once
+func TestUniqueBlobs(t *testing.T) {
+	first := imgspec.Descriptor{Digest: digest.FromString("first")}
+	second := imgspec.Descriptor{Digest: digest.FromString("second")}
+	unique := uniqueBlobs([]imgspec.Descriptor{first, second, first})
+	if len(unique) != 2 || unique[0].Digest != first.Digest || unique[1].Digest != second.Digest {
+		t.Fatalf("expected blobs %v, got %v", []imgspec.Descriptor{first, second}, unique)
+	}
+}
+
 // TestErrorToVerifyResult tests the errorToVerifyResult function
 func TestErrorToVerifyResult(t *testing.T) {
 	verifierResult := errorToVerifyResult("test", "cosign", fmt.Errorf("test error"))
@@ -512,7 +558,8 @@ func TestGetKeysMaps_FailingGetKeys(t *testing.T) {
 func TestVerifyInternal(t *testing.T) {
 	cosignMediaType := "application/vnd.dev.cosign.simplesigning.v1+json"
 	validSignatureBlob := []byte("test")
-	subjectDigest := digest.Digest("sha256:5678")
+	// the digest referenced by the payload of the signatures signed with keys
+	subjectDigest := digest.Digest("sha256:d37ada95d47ad12224c205a938129df7a3e52345828b4fa27b03a98825d1e2e7")
 	testRefDigest := digest.Digest("sha256:1234")
 	blobDigest := digest.Digest("valid blob")
 	//nolint:gosec // this is a test key
@@ -617,7 +664,7 @@ mmBwUAwwW0Uc+Nt3bDOCiB1nUsICv1ry
 				},
 			},
 			expectedResultMessagePrefix: "Failed to validate the Cosign signature",
-			expectedErrorReason:         "The artifact metadata is not an OCI image",
+			expectedErrorReason:         "The artifact metadata is not an OCI image or artifact manifest",
 		},
 		{
 			name:         "failed subject descriptor fetch",
@@ -631,7 +678,7 @@ mmBwUAwwW0Uc+Nt3bDOCiB1nUsICv1ry
 				},
 			},
 			expectedResultMessagePrefix: "Failed to validate the Cosign signature",
-			expectedErrorReason:         "subject not found for sha256:5678",
+			expectedErrorReason:         fmt.Sprintf("subject not found for %s", subjectDigest),
 		},
 		{
 			name:         "failed to fetch blob",
@@ -1034,6 +1081,425 @@ mmBwUAwwW0Uc+Nt3bDOCiB1nUsICv1ry
 	}
 }
 
+// TestVerifyInternal_Attestation tests the verification of DSSE attestations
+func TestVerifyInternal_Attestation(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	subjectDigest := digest.FromString("subject")
+	testRefDigest := digest.FromString("reference")
+	subjectRef := common.Reference{
+		Digest:   subjectDigest,
+		Original: ratifySampleImageRef,
+		Tag:      "v1",
+	}
+	refDescriptor := ocispecs.ReferenceDescriptor{
+		ArtifactType: ctypes.DssePayloadType,
+		Descriptor: imgspec.Descriptor{
+			Digest:    testRefDigest,
+			MediaType: imgspec.MediaTypeImageManifest,
+		},
+	}
+	statement := func(subject digest.Digest) string {
+		return fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","subject":[{"name":"test","digest":{"sha256":"%s"}}],"predicateType":"https://cosign.sigstore.dev/attestation/vuln/v1","predicate":{"scanner":{"uri":"pkg:github/aquasecurity/trivy"}}}`, subject.Encoded())
+	}
+	// signEnvelope signs the statement as a DSSE envelope and replaces its payload with the given one
+	signEnvelope := func(signed, payload string) []byte {
+		pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(ctypes.IntotoPayloadType), ctypes.IntotoPayloadType, len(signed), signed)
+		hash := sha256.Sum256([]byte(pae))
+		sig, err := ecdsa.SignASN1(rand.Reader, privKey, hash[:])
+		if err != nil {
+			t.Fatalf("failed to sign envelope: %v", err)
+		}
+		return []byte(fmt.Sprintf(`{"payloadType":"%s","payload":"%s","signatures":[{"keyid":"","sig":"%s"}]}`, ctypes.IntotoPayloadType, base64.StdEncoding.EncodeToString([]byte(payload)), base64.StdEncoding.EncodeToString(sig)))
+	}
+
+	tc := []struct {
+		name              string
+		envelope          []byte
+		attestations      map[string]interface{}
+		expectedSuccess   bool
+		expectedVerifyErr string
+		expectedPredicate string
+	}{
+		{
+			name:              "valid attestation",
+			envelope:          signEnvelope(statement(subjectDigest), statement(subjectDigest)),
+			expectedSuccess:   true,
+			expectedPredicate: "https://cosign.sigstore.dev/attestation/vuln/v1",
+		},
+		{
+			name:              "tampered payload",
+			envelope:          signEnvelope(statement(subjectDigest), strings.Replace(statement(subjectDigest), "trivy", "grype", 1)),
+			expectedSuccess:   false,
+			expectedVerifyErr: "accepted signatures do not match threshold",
+		},
+		{
+			name:              "attestation of another subject",
+			envelope:          signEnvelope(statement(digest.FromString("other")), statement(digest.FromString("other"))),
+			expectedSuccess:   false,
+			expectedVerifyErr: "no matching subject digest found",
+		},
+		{
+			name:     "predicate type not accepted",
+			envelope: signEnvelope(statement(subjectDigest), statement(subjectDigest)),
+			attestations: map[string]interface{}{
+				"predicateTypes": []string{"https://slsa.dev/provenance/v1"},
+			},
+			expectedSuccess:   false,
+			expectedVerifyErr: "attestation predicate check failed",
+			expectedPredicate: "https://cosign.sigstore.dev/attestation/vuln/v1",
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			getKeyMapOpts = func(_ context.Context, _ TrustPolicy, _ string) (map[PKKey]keymanagementprovider.PublicKey, cosign.CheckOpts, error) {
+				return map[PKKey]keymanagementprovider.PublicKey{
+					{Provider: "test"}: {Key: privKey.Public()},
+				}, cosign.CheckOpts{IgnoreSCT: true, IgnoreTlog: true}, nil
+			}
+			envelopeDigest := digest.FromBytes(tt.envelope)
+			store := &mocks.MemoryTestStore{
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					testRefDigest: {
+						MediaType: imgspec.MediaTypeImageManifest,
+						Blobs: []imgspec.Descriptor{
+							{
+								Digest:    envelopeDigest,
+								MediaType: ctypes.DssePayloadType,
+							},
+						},
+					},
+				},
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDigest: {
+						Descriptor: imgspec.Descriptor{
+							Digest:    subjectDigest,
+							MediaType: imgspec.MediaTypeImageManifest,
+						},
+					},
+				},
+				Blobs: map[digest.Digest][]byte{
+					envelopeDigest: tt.envelope,
+				},
+			}
+			validConfig := config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": ctypes.DssePayloadType,
+				"type":          "cosign",
+				"trustPolicies": []TrustPolicyConfig{
+					{
+						Name:    "test-policy",
+						Keyless: KeylessConfig{CertificateIdentity: testIdentity, CertificateOIDCIssuer: testIssuer},
+						Scopes:  []string{"*"},
+					},
+				},
+			}
+			if tt.attestations != nil {
+				validConfig["attestations"] = tt.attestations
+			}
+			verifierFactory := cosignVerifierFactory{}
+			cosignVerifier, err := verifierFactory.Create("", validConfig, "", "test-namespace")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			result, _ := cosignVerifier.Verify(context.Background(), subjectRef, refDescriptor, store)
+			if result.IsSuccess != tt.expectedSuccess {
+				t.Fatalf("expected success %v, got %v: %s", tt.expectedSuccess, result.IsSuccess, result.ErrorReason)
+			}
+			extension, ok := result.Extensions.(Extension)
+			if !ok || len(extension.SignatureExtension) != 1 {
+				t.Fatalf("unexpected extensions %+v", result.Extensions)
+			}
+			signatureExtension := extension.SignatureExtension[0]
+			if signatureExtension.PredicateType != tt.expectedPredicate {
+				t.Errorf("expected predicate type %q, got %q", tt.expectedPredicate, signatureExtension.PredicateType)
+			}
+			if len(signatureExtension.Verifications) != 1 || !strings.Contains(signatureExtension.Verifications[0].Err, tt.expectedVerifyErr) {
+				t.Errorf("expected verification error containing %q, got %+v", tt.expectedVerifyErr, signatureExtension.Verifications)
+			}
+		})
+	}
+}
+
+// TestVerifyInternal_PayloadDigest tests that a signature is only accepted for
+// the subject referenced by its payload
+func TestVerifyInternal_PayloadDigest(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	subjectDigest := digest.FromString("subject")
+	testRefDigest := digest.FromString("reference")
+	subjectRef := common.Reference{
+		Digest:   subjectDigest,
+		Original: ratifySampleImageRef,
+		Tag:      "v1",
+	}
+	refDescriptor := ocispecs.ReferenceDescriptor{
+		ArtifactType: "application/vnd.dev.cosign.artifact.sig.v1+json",
+		Descriptor: imgspec.Descriptor{
+			Digest:    testRefDigest,
+			MediaType: imgspec.MediaTypeImageManifest,
+		},
+	}
+	simpleSigning := func(imageDigest digest.Digest) []byte {
+		return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"localhost:5000/net-monitor"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, imageDigest))
+	}
+
+	tc := []struct {
+		name              string
+		payload           []byte
+		expectedSuccess   bool
+		expectedVerifyErr string
+	}{
+		{
+			name:            "signature of the subject",
+			payload:         simpleSigning(subjectDigest),
+			expectedSuccess: true,
+		},
+		{
+			name:              "signature transplanted from another image",
+			payload:           simpleSigning(digest.FromString("other")),
+			expectedSuccess:   false,
+			expectedVerifyErr: "invalid or missing digest in claim: " + digest.FromString("other").String(),
+		},
+		{
+			name:              "payload without an image digest",
+			payload:           []byte(`{"critical":{"type":"cosign container image signature"}}`),
+			expectedSuccess:   false,
+			expectedVerifyErr: "invalid or missing digest in claim",
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			getKeyMapOpts = func(_ context.Context, _ TrustPolicy, _ string) (map[PKKey]keymanagementprovider.PublicKey, cosign.CheckOpts, error) {
+				return map[PKKey]keymanagementprovider.PublicKey{
+					{Provider: "test"}: {Key: privKey.Public()},
+				}, cosign.CheckOpts{IgnoreSCT: true, IgnoreTlog: true}, nil
+			}
+			hash := sha256.Sum256(tt.payload)
+			sig, err := ecdsa.SignASN1(rand.Reader, privKey, hash[:])
+			if err != nil {
+				t.Fatalf("failed to sign payload: %v", err)
+			}
+			payloadDigest := digest.FromBytes(tt.payload)
+			store := &mocks.MemoryTestStore{
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					testRefDigest: {
+						MediaType: imgspec.MediaTypeImageManifest,
+						Blobs: []imgspec.Descriptor{
+							{
+								Digest:    payloadDigest,
+								MediaType: "application/vnd.dev.cosign.simplesigning.v1+json",
+								Annotations: map[string]string{
+									static.SignatureAnnotationKey: base64.StdEncoding.EncodeToString(sig),
+								},
+							},
+						},
+					},
+				},
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDigest: {
+						Descriptor: imgspec.Descriptor{
+							Digest:    subjectDigest,
+							MediaType: imgspec.MediaTypeImageManifest,
+						},
+					},
+				},
+				Blobs: map[digest.Digest][]byte{
+					payloadDigest: tt.payload,
+				},
+			}
+			verifierFactory := cosignVerifierFactory{}
+			cosignVerifier, err := verifierFactory.Create("", config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": "application/vnd.dev.cosign.artifact.sig.v1+json",
+				"type":          "cosign",
+				"trustPolicies": []TrustPolicyConfig{
+					{
+						Name:    "test-policy",
+						Keyless: KeylessConfig{CertificateIdentity: testIdentity, CertificateOIDCIssuer: testIssuer},
+						Scopes:  []string{"*"},
+					},
+				},
+			}, "", "test-namespace")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			result, _ := cosignVerifier.Verify(context.Background(), subjectRef, refDescriptor, store)
+			if result.IsSuccess != tt.expectedSuccess {
+				t.Fatalf("expected success %v, got %v: %s", tt.expectedSuccess, result.IsSuccess, result.ErrorReason)
+			}
+			extension, ok := result.Extensions.(Extension)
+			if !ok || len(extension.SignatureExtension) != 1 {
+				t.Fatalf("unexpected extensions %+v", result.Extensions)
+			}
+			verifications := extension.SignatureExtension[0].Verifications
+			if len(verifications) != 1 || !strings.Contains(verifications[0].Err, tt.expectedVerifyErr) {
+				t.Errorf("expected verification error containing %q, got %+v", tt.expectedVerifyErr, verifications)
+			}
+		})
+	}
+}
+
+// TestVerifyInternal_ConfiglessArtifact tests verifying the signatures of an
+// artifact manifest without an image config
+func TestVerifyInternal_ConfiglessArtifact(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	subjectContent := []byte(`{"mediaType":"application/vnd.oci.artifact.manifest.v1+json","artifactType":"application/spdx+json","blobs":[]}`)
+	subjectDigest := digest.FromBytes(subjectContent)
+	testRefDigest := digest.FromString("reference")
+	subjectRef := common.Reference{
+		Digest:   subjectDigest,
+		Original: ratifySampleImageRef,
+		Tag:      "v1",
+	}
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"localhost:5000/net-monitor"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, subjectDigest))
+	payloadDigest := digest.FromBytes(payload)
+	hash := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, privKey, hash[:])
+	if err != nil {
+		t.Fatalf("failed to sign payload: %v", err)
+	}
+	getKeyMapOpts = func(_ context.Context, _ TrustPolicy, _ string) (map[PKKey]keymanagementprovider.PublicKey, cosign.CheckOpts, error) {
+		return map[PKKey]keymanagementprovider.PublicKey{
+			{Provider: "test"}: {Key: privKey.Public()},
+		}, cosign.CheckOpts{IgnoreSCT: true, IgnoreTlog: true}, nil
+	}
+
+	for _, mediaType := range []string{imgspec.MediaTypeImageManifest, ocispecs.MediaTypeArtifactManifest} {
+		t.Run(mediaType, func(t *testing.T) {
+			refDescriptor := ocispecs.ReferenceDescriptor{
+				ArtifactType: "application/vnd.dev.cosign.artifact.sig.v1+json",
+				Descriptor: imgspec.Descriptor{
+					Digest:    testRefDigest,
+					MediaType: mediaType,
+				},
+			}
+			store := &mocks.MemoryTestStore{
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					testRefDigest: {
+						MediaType: mediaType,
+						Blobs: []imgspec.Descriptor{
+							{
+								Digest:    payloadDigest,
+								MediaType: "application/vnd.dev.cosign.simplesigning.v1+json",
+								Annotations: map[string]string{
+									static.SignatureAnnotationKey: base64.StdEncoding.EncodeToString(sig),
+								},
+							},
+						},
+					},
+				},
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDigest: {
+						Descriptor: imgspec.Descriptor{
+							Digest:    subjectDigest,
+							MediaType: ocispecs.MediaTypeArtifactManifest,
+							Size:      int64(len(subjectContent)),
+						},
+					},
+				},
+				Blobs: map[digest.Digest][]byte{
+					payloadDigest: payload,
+				},
+			}
+			verifierFactory := cosignVerifierFactory{}
+			cosignVerifier, err := verifierFactory.Create("", config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": "application/vnd.dev.cosign.artifact.sig.v1+json",
+				"type":          "cosign",
+				"trustPolicies": []TrustPolicyConfig{
+					{
+						Name:    "test-policy",
+						Keyless: KeylessConfig{CertificateIdentity: testIdentity, CertificateOIDCIssuer: testIssuer},
+						Scopes:  []string{"*"},
+					},
+				},
+			}, "", "test-namespace")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			result, err := cosignVerifier.Verify(context.Background(), subjectRef, refDescriptor, store)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !result.IsSuccess {
+				t.Fatalf("expected the signature of the config-less artifact to be verified: %s %s", result.Message, result.ErrorReason)
+			}
+		})
+	}
+}
+
+// TestVerifyInternal_SignatureMissing tests the remediation of a subject
+// without Cosign signatures
+func TestVerifyInternal_SignatureMissing(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	testRefDigest := digest.FromString("reference")
+	subjectRef := common.Reference{
+		Digest:   subjectDigest,
+		Original: ratifySampleImageRef,
+		Tag:      "v1",
+	}
+	refDescriptor := ocispecs.ReferenceDescriptor{
+		ArtifactType: "application/vnd.dev.cosign.artifact.sig.v1+json",
+		Descriptor: imgspec.Descriptor{
+			Digest:    testRefDigest,
+			MediaType: imgspec.MediaTypeImageManifest,
+		},
+	}
+	getKeyMapOpts = func(_ context.Context, _ TrustPolicy, _ string) (map[PKKey]keymanagementprovider.PublicKey, cosign.CheckOpts, error) {
+		return map[PKKey]keymanagementprovider.PublicKey{}, cosign.CheckOpts{IgnoreSCT: true, IgnoreTlog: true}, nil
+	}
+	store := &mocks.MemoryTestStore{
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+			testRefDigest: {MediaType: imgspec.MediaTypeImageManifest},
+		},
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			subjectDigest: {
+				Descriptor: imgspec.Descriptor{
+					Digest:    subjectDigest,
+					MediaType: imgspec.MediaTypeImageManifest,
+				},
+			},
+		},
+	}
+	verifierFactory := cosignVerifierFactory{}
+	cosignVerifier, err := verifierFactory.Create("", config.VerifierConfig{
+		"name":          "test",
+		"artifactTypes": "application/vnd.dev.cosign.artifact.sig.v1+json",
+		"type":          "cosign",
+		"trustPolicies": []TrustPolicyConfig{
+			{
+				Name:    "test-policy",
+				Keyless: KeylessConfig{CertificateIdentity: testIdentity, CertificateOIDCIssuer: testIssuer},
+				Scopes:  []string{"*"},
+			},
+		},
+	}, "", "test-namespace")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	result, err := cosignVerifier.Verify(context.Background(), subjectRef, refDescriptor, store)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.IsSuccess {
+		t.Fatalf("expected verification of a subject without signatures to fail")
+	}
+	expectedRemediation := fmt.Sprintf("Sign %s with cosign using a key or identity trusted by the trust policy test-policy.", ratifySampleImageRef)
+	if result.Remediation != expectedRemediation {
+		t.Errorf("expected remediation %q, got %q", expectedRemediation, result.Remediation)
+	}
+}
+
 // TestVerificationMessage tests the verificationMessage function
 func TestVerificationMessage(t *testing.T) {
 	tc := []struct {
@@ -1150,3 +1616,300 @@ func TestProcessAKVSignature_RSAKey(t *testing.T) {
 		})
 	}
 }
+
+// TestCheckSigningTime tests the signing time of a keyless signature is checked
+// against the validity window of its signing certificate
+func TestCheckSigningTime(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(10 * time.Minute),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+
+	tests := []struct {
+		name        string
+		opts        []static.Option
+		expectError bool
+	}{
+		{
+			name: "signing time within the validity window",
+			opts: []static.Option{static.WithCertChain(certPEM, nil), static.WithBundle(&bundle.RekorBundle{Payload: bundle.RekorPayload{IntegratedTime: notBefore.Add(5 * time.Minute).Unix()}})},
+		},
+		{
+			name:        "signing time before the validity window",
+			opts:        []static.Option{static.WithCertChain(certPEM, nil), static.WithBundle(&bundle.RekorBundle{Payload: bundle.RekorPayload{IntegratedTime: notBefore.Add(-time.Minute).Unix()}})},
+			expectError: true,
+		},
+		{
+			name:        "signing time after the validity window",
+			opts:        []static.Option{static.WithCertChain(certPEM, nil), static.WithBundle(&bundle.RekorBundle{Payload: bundle.RekorPayload{IntegratedTime: notBefore.Add(time.Hour).Unix()}})},
+			expectError: true,
+		},
+		{
+			name:        "no transparency log bundle",
+			opts:        []static.Option{static.WithCertChain(certPEM, nil)},
+			expectError: true,
+		},
+		{
+			name: "signed with a key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := static.NewSignature([]byte("payload"), "signature", tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create signature: %v", err)
+			}
+			if err := checkSigningTime(sig); (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+// TestCertificateExpiries tests the short-lived signing certificate of a
+// keyless signature only limits the result if the signing time is not proven
+func TestCertificateExpiries(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newCertPEM := func(name string, notAfter time.Time) []byte {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    notBefore,
+			NotAfter:     notAfter,
+		}
+		certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	}
+	leafNotAfter := notBefore.Add(10 * time.Minute)
+	rootNotAfter := notBefore.AddDate(10, 0, 0)
+	sig, err := static.NewSignature([]byte("payload"), "signature", static.WithCertChain(newCertPEM("signer", leafNotAfter), newCertPEM("fulcio", rootNotAfter)))
+	if err != nil {
+		t.Fatalf("failed to create signature: %v", err)
+	}
+
+	if expiresAt := verifierutils.EarliestExpiry(certificateExpiries(sig, false)...); expiresAt == nil || !expiresAt.Equal(leafNotAfter) {
+		t.Fatalf("expected the signing certificate to limit the result to %v, got %v", leafNotAfter, expiresAt)
+	}
+	if expiresAt := verifierutils.EarliestExpiry(certificateExpiries(sig, true)...); expiresAt == nil || !expiresAt.Equal(rootNotAfter) {
+		t.Fatalf("expected the chain to limit the result to %v once the signing time is proven, got %v", rootNotAfter, expiresAt)
+	}
+}
+
+// TestCheckRetiredKeys tests the signatures of a retired key are only valid
+// if they were made within the grace period of the key
+func TestCheckRetiredKeys(t *testing.T) {
+	keyPath := "../../../test/testdata/cosign.pub"
+	retiredAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trustPolicy, err := CreateTrustPolicy(TrustPolicyConfig{
+		Name:   "test",
+		Scopes: []string{"*"},
+		Keys: []KeyConfig{
+			{
+				File:               keyPath,
+				RetiredAt:          retiredAt.Format(time.RFC3339),
+				RetiredGracePeriod: "72h",
+			},
+		},
+	}, "cosign")
+	if err != nil {
+		t.Fatalf("failed to create trust policy: %v", err)
+	}
+	retiredKey := PKKey{Provider: fileProviderName, Name: keyPath}
+	withSigningTime := func(signingTime time.Time) []static.Option {
+		return []static.Option{static.WithBundle(&bundle.RekorBundle{Payload: bundle.RekorPayload{IntegratedTime: signingTime.Unix()}})}
+	}
+
+	tests := []struct {
+		name           string
+		trustPolicy    TrustPolicy
+		opts           []static.Option
+		bundleVerified bool
+		now            time.Time
+		key            PKKey
+		expectValid    bool
+	}{
+		{
+			name:           "signed before the retirement",
+			trustPolicy:    trustPolicy,
+			opts:           withSigningTime(retiredAt.Add(-time.Hour)),
+			bundleVerified: true,
+			now:            retiredAt.Add(30 * 24 * time.Hour),
+			key:            retiredKey,
+			expectValid:    true,
+		},
+		{
+			name:           "signed inside the grace window",
+			trustPolicy:    trustPolicy,
+			opts:           withSigningTime(retiredAt.Add(24 * time.Hour)),
+			bundleVerified: true,
+			now:            retiredAt.Add(30 * 24 * time.Hour),
+			key:            retiredKey,
+			expectValid:    true,
+		},
+		{
+			name:           "signed outside the grace window",
+			trustPolicy:    trustPolicy,
+			opts:           withSigningTime(retiredAt.Add(96 * time.Hour)),
+			bundleVerified: true,
+			now:            retiredAt.Add(96 * time.Hour),
+			key:            retiredKey,
+		},
+		{
+			name:        "unverified bundle is verified inside the grace window",
+			trustPolicy: trustPolicy,
+			opts:        withSigningTime(retiredAt.Add(96 * time.Hour)),
+			now:         retiredAt.Add(24 * time.Hour),
+			key:         retiredKey,
+			expectValid: true,
+		},
+		{
+			name:        "no bundle is verified outside the grace window",
+			trustPolicy: trustPolicy,
+			now:         retiredAt.Add(96 * time.Hour),
+			key:         retiredKey,
+		},
+		{
+			name:        "key that is not retired",
+			trustPolicy: trustPolicy,
+			now:         retiredAt.Add(96 * time.Hour),
+			key:         PKKey{Provider: "kmp", Name: "current"},
+			expectValid: true,
+		},
+		{
+			name:        "trust policy without retired keys",
+			trustPolicy: &mockTrustPolicy{},
+			now:         retiredAt.Add(96 * time.Hour),
+			key:         retiredKey,
+			expectValid: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := static.NewSignature([]byte("payload"), "signature", tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create signature: %v", err)
+			}
+			verifications := []cosignExtension{{IsSuccess: true, BundleVerified: tt.bundleVerified, KeyInformation: tt.key}}
+			if valid := checkRetiredKeys(tt.trustPolicy, sig, verifications, tt.now); valid != tt.expectValid {
+				t.Fatalf("expected valid %v, got %v", tt.expectValid, valid)
+			}
+			if verifications[0].IsSuccess != tt.expectValid {
+				t.Fatalf("expected verification success %v, got %+v", tt.expectValid, verifications[0])
+			}
+		})
+	}
+}
+
+// TestCheckAlgorithms tests the verifications relying on a disallowed key,
+// digest or certificate algorithm are failed
+func TestCheckAlgorithms(t *testing.T) {
+	rsa1024Key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ecdsaP256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ecdsaP384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	newCertPEM := func(key *ecdsa.PrivateKey) []byte {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "signer"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	}
+
+	rsaKey := PKKey{Provider: "kmp", Name: "rsa"}
+	ecdsaKey := PKKey{Provider: "kmp", Name: "ecdsa"}
+	keysMap := map[PKKey]keymanagementprovider.PublicKey{
+		rsaKey:   {Key: &rsa1024Key.PublicKey},
+		ecdsaKey: {Key: &ecdsaP256Key.PublicKey},
+	}
+	allowed := []string{"RSA-3072", "ECDSA-P256", "SHA-256"}
+
+	tests := []struct {
+		name          string
+		opts          []static.Option
+		verifications []cosignExtension
+		expectValid   []bool
+	}{
+		{
+			name:          "allowed key",
+			verifications: []cosignExtension{{IsSuccess: true, KeyInformation: ecdsaKey}},
+			expectValid:   []bool{true},
+		},
+		{
+			name:          "key size not allowed",
+			verifications: []cosignExtension{{IsSuccess: true, KeyInformation: rsaKey}},
+			expectValid:   []bool{false},
+		},
+		{
+			name:          "only the verification with a disallowed key fails",
+			verifications: []cosignExtension{{IsSuccess: true, KeyInformation: rsaKey}, {IsSuccess: true, KeyInformation: ecdsaKey}},
+			expectValid:   []bool{false, true},
+		},
+		{
+			name:          "keyless certificate with an allowed key",
+			opts:          []static.Option{static.WithCertChain(newCertPEM(ecdsaP256Key), nil)},
+			verifications: []cosignExtension{{IsSuccess: true}},
+			expectValid:   []bool{true},
+		},
+		{
+			name:          "keyless certificate with a disallowed curve",
+			opts:          []static.Option{static.WithCertChain(newCertPEM(ecdsaP384Key), nil)},
+			verifications: []cosignExtension{{IsSuccess: true}},
+			expectValid:   []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithmPolicy, err := verifierutils.NewAlgorithmPolicy(allowed)
+			if err != nil {
+				t.Fatalf("failed to create the algorithm policy: %v", err)
+			}
+			v := &cosignVerifier{algorithmPolicy: algorithmPolicy}
+			sig, err := static.NewSignature([]byte("payload"), "signature", tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create signature: %v", err)
+			}
+			hasValidSignature := v.checkAlgorithms(sig, keysMap, tt.verifications)
+			if hasValidSignature != slices.Contains(tt.expectValid, true) {
+				t.Fatalf("expected a valid signature %v, got %v", slices.Contains(tt.expectValid, true), hasValidSignature)
+			}
+			for i, verification := range tt.verifications {
+				if verification.IsSuccess != tt.expectValid[i] {
+					t.Fatalf("expected verification %d to succeed %v, got %+v", i, tt.expectValid[i], verification)
+				}
+			}
+		})
+	}
+}
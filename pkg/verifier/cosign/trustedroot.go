@@ -0,0 +1,467 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/tuf"
+	"github.com/theupdateframework/go-tuf/client"
+	filejsonstore "github.com/theupdateframework/go-tuf/client/filejsonstore"
+)
+
+const (
+	// trustedRootTarget is the name of the TUF target holding the Sigstore trusted root.
+	trustedRootTarget    = "trusted_root.json"
+	trustedRootMediaType = "application/vnd.dev.sigstore.trustedroot+json"
+
+	// defaultTrustedRootRefreshInterval is how long a trusted root fetched
+	// from a TUF mirror is used before it is fetched again.
+	defaultTrustedRootRefreshInterval = time.Hour
+	// trustedRootFetchTimeout bounds each fetch of a trusted root from a TUF
+	// mirror.
+	trustedRootFetchTimeout = time.Minute
+)
+
+var (
+	// tufTrustedRoots are the trusted roots fetched from TUF mirrors, shared
+	// by the trust policies of the same mirror so that recreated verifiers
+	// reuse them.
+	tufTrustedRootsMu sync.Mutex
+	tufTrustedRoots   = map[string]*tufTrustedRoot{}
+
+	// tufCacheDirs guard the TUF metadata cache directories of the mirrors so
+	// that a directory is only updated by one fetch at a time.
+	tufCacheDirsMu sync.Mutex
+	tufCacheDirs   = map[string]*sync.Mutex{}
+
+	// tufCacheDir is the directory the TUF metadata of each mirror is cached
+	// in, in a subdirectory of its own.
+	tufCacheDir = defaultTUFCacheDir()
+
+	// sigstoreTUFRoot is the initial TUF root of the public Sigstore
+	// repository, used to bootstrap trust in a mirror if no root is provided.
+	//go:embed sigstore_tuf_root.json
+	sigstoreTUFRoot []byte
+)
+
+// TrustedRootConfig describes where the Sigstore trusted root bundle is loaded
+// from. Exactly one of File or TUFMirror must be set.
+type TrustedRootConfig struct {
+	// File is the path to a pre-fetched trusted_root.json for air-gapped use.
+	File string `json:"file,omitempty"`
+	// TUFMirror is the URL of the TUF repository serving trusted_root.json.
+	TUFMirror string `json:"tufMirror,omitempty"`
+	// TUFRoot is the path to the initial TUF root.json used to bootstrap trust
+	// in TUFMirror. The embedded Sigstore root is used if not provided.
+	TUFRoot string `json:"tufRoot,omitempty"`
+	// RefreshInterval is how long the trusted root fetched from TUFMirror is
+	// used before it is fetched again in the background, e.g. 30m. Defaults
+	// to 1h.
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+}
+
+// trustedRootMaterial is the verification material extracted from a Sigstore
+// trusted root bundle.
+type trustedRootMaterial struct {
+	rootCerts         *x509.CertPool
+	intermediateCerts *x509.CertPool
+	rekorPubKeys      *cosign.TrustedTransparencyLogPubKeys
+	ctLogPubKeys      *cosign.TrustedTransparencyLogPubKeys
+}
+
+// sigstoreTrustedRoot is the JSON representation of the Sigstore TrustedRoot
+// message defined in https://github.com/sigstore/protobuf-specs.
+type sigstoreTrustedRoot struct {
+	MediaType              string                 `json:"mediaType"`
+	Tlogs                  []transparencyLog      `json:"tlogs"`
+	CertificateAuthorities []certificateAuthority `json:"certificateAuthorities"`
+	Ctlogs                 []transparencyLog      `json:"ctlogs"`
+}
+
+type transparencyLog struct {
+	BaseURL   string    `json:"baseUrl"`
+	PublicKey publicKey `json:"publicKey"`
+	LogID     struct {
+		KeyID []byte `json:"keyId"`
+	} `json:"logId"`
+}
+
+type publicKey struct {
+	RawBytes []byte   `json:"rawBytes"`
+	ValidFor validity `json:"validFor"`
+}
+
+type certificateAuthority struct {
+	URI       string `json:"uri"`
+	CertChain struct {
+		Certificates []struct {
+			RawBytes []byte `json:"rawBytes"`
+		} `json:"certificates"`
+	} `json:"certChain"`
+	ValidFor validity `json:"validFor"`
+}
+
+type validity struct {
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// contains returns whether t is within the validity period.
+func (v validity) contains(t time.Time) bool {
+	return (v.Start == nil || !t.Before(*v.Start)) && (v.End == nil || !t.After(*v.End))
+}
+
+// loadTrustedRoot loads the Sigstore trusted root from the configured source
+// and extracts its verification material.
+func loadTrustedRoot(ctx context.Context, config TrustedRootConfig) (*trustedRootMaterial, error) {
+	var data []byte
+	var err error
+	if config.File != "" {
+		data, err = os.ReadFile(config.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted root file %s: %w", config.File, err)
+		}
+	} else {
+		data, err = fetchTrustedRootFromTUF(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseTrustedRoot(data)
+}
+
+// tufTrustedRoot is the trusted root fetched from a TUF mirror. It is cached
+// for the refresh interval and fetched again in the background afterwards
+// while the cached one is used, so that verifications only wait for the
+// mirror before the trusted root was first fetched.
+type tufTrustedRoot struct {
+	config          TrustedRootConfig
+	refreshInterval time.Duration
+	load            func(context.Context, TrustedRootConfig) (*trustedRootMaterial, error)
+	now             func() time.Time
+
+	mu         sync.Mutex
+	material   *trustedRootMaterial
+	fetchedAt  time.Time
+	refreshing *trustedRootRefresh
+}
+
+// trustedRootRefresh is a fetch of the trusted root in progress.
+type trustedRootRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// sharedTUFTrustedRoot returns the trusted root of the TUF mirror of the
+// configuration, creating it on first use.
+func sharedTUFTrustedRoot(config TrustedRootConfig) (*tufTrustedRoot, error) {
+	refreshInterval := defaultTrustedRootRefreshInterval
+	if config.RefreshInterval != "" {
+		var err error
+		if refreshInterval, err = time.ParseDuration(config.RefreshInterval); err != nil {
+			return nil, fmt.Errorf("invalid trusted root refreshInterval %s: %w", config.RefreshInterval, err)
+		}
+		if refreshInterval <= 0 {
+			return nil, fmt.Errorf("trusted root refreshInterval must be positive, got %s", config.RefreshInterval)
+		}
+	}
+
+	key := strings.Join([]string{config.TUFMirror, config.TUFRoot, refreshInterval.String()}, "|")
+	tufTrustedRootsMu.Lock()
+	defer tufTrustedRootsMu.Unlock()
+	if trustedRoot, ok := tufTrustedRoots[key]; ok {
+		return trustedRoot, nil
+	}
+	trustedRoot := &tufTrustedRoot{
+		config:          config,
+		refreshInterval: refreshInterval,
+		load:            loadTrustedRoot,
+		now:             time.Now,
+	}
+	tufTrustedRoots[key] = trustedRoot
+	return trustedRoot, nil
+}
+
+// get returns the cached trusted root, starting a refresh in the background
+// once it is older than the refresh interval. It waits for the fetch only if
+// no trusted root was fetched yet and returns its error if it fails.
+func (r *tufTrustedRoot) get(ctx context.Context) (*trustedRootMaterial, error) {
+	r.mu.Lock()
+	if r.material != nil {
+		if r.now().Sub(r.fetchedAt) >= r.refreshInterval {
+			r.startRefresh()
+		}
+		material := r.material
+		r.mu.Unlock()
+		return material, nil
+	}
+	call := r.startRefresh()
+	r.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.material, nil
+}
+
+// startRefresh starts fetching the trusted root unless a fetch is in progress
+// and returns the fetch. r.mu must be held.
+func (r *tufTrustedRoot) startRefresh() *trustedRootRefresh {
+	if r.refreshing != nil {
+		return r.refreshing
+	}
+	call := &trustedRootRefresh{done: make(chan struct{})}
+	r.refreshing = call
+	go func() {
+		// the fetch is shared by the verifications waiting for it, so it is
+		// bounded by its own timeout rather than by their contexts
+		ctx, cancel := context.WithTimeout(context.Background(), trustedRootFetchTimeout)
+		defer cancel()
+		material, err := r.load(ctx, r.config)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		switch {
+		case err == nil:
+			r.material = material
+			r.fetchedAt = r.now()
+		case r.material != nil:
+			// the cached trusted root is used until it is fetched again
+			// after the refresh interval
+			logger.GetLogger(ctx, logOpt).Warnf("failed to refresh the Sigstore trusted root from TUF mirror %s, using the cached one: %v", r.config.TUFMirror, err)
+			r.fetchedAt = r.now()
+		}
+		call.err = err
+		r.refreshing = nil
+		close(call.done)
+	}()
+	return call
+}
+
+// fetchTrustedRootFromTUF updates the TUF metadata of the configured mirror,
+// cached in a directory of its own, and returns the verified
+// trusted_root.json target. Each mirror has its own TUF client so that trust
+// policies of different mirrors do not share state.
+func fetchTrustedRootFromTUF(ctx context.Context, config TrustedRootConfig) ([]byte, error) {
+	root := sigstoreTUFRoot
+	if config.TUFRoot != "" {
+		var err error
+		if root, err = os.ReadFile(config.TUFRoot); err != nil {
+			return nil, fmt.Errorf("failed to read TUF root %s: %w", config.TUFRoot, err)
+		}
+	}
+
+	// mirrors bootstrapped from different roots must not trust each other's
+	// metadata
+	key := sha256.Sum256(append([]byte(config.TUFMirror+"\n"), root...))
+	cacheDir := filepath.Join(tufCacheDir, hex.EncodeToString(key[:]))
+	unlock := lockTUFCacheDir(cacheDir)
+	defer unlock()
+
+	local, err := filejsonstore.NewFileJSONStore(cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open TUF metadata cache %s: %w", cacheDir, err)
+	}
+	defer local.Close()
+	remote, err := client.HTTPRemoteStore(config.TUFMirror, nil, &http.Client{Transport: contextTransport{ctx: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("invalid TUF mirror %s: %w", config.TUFMirror, err)
+	}
+	tufClient := client.NewClient(local, remote)
+
+	meta, err := local.GetMeta()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached TUF metadata of mirror %s: %w", config.TUFMirror, err)
+	}
+	if _, ok := meta["root.json"]; !ok {
+		if err := tufClient.Init(root); err != nil {
+			return nil, fmt.Errorf("failed to initialize TUF client for mirror %s: %w", config.TUFMirror, err)
+		}
+	}
+	if _, err := tufClient.Update(); err != nil {
+		return nil, fmt.Errorf("failed to update TUF metadata from mirror %s: %w", config.TUFMirror, err)
+	}
+	var target tufTarget
+	if err := tufClient.Download(trustedRootTarget, &target); err != nil {
+		return nil, fmt.Errorf("failed to get %s from TUF mirror %s: %w", trustedRootTarget, config.TUFMirror, err)
+	}
+	return target.Bytes(), nil
+}
+
+// lockTUFCacheDir locks the TUF metadata cache directory and returns the
+// function unlocking it.
+func lockTUFCacheDir(dir string) func() {
+	tufCacheDirsMu.Lock()
+	mu, ok := tufCacheDirs[dir]
+	if !ok {
+		mu = &sync.Mutex{}
+		tufCacheDirs[dir] = mu
+	}
+	tufCacheDirsMu.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+// defaultTUFCacheDir returns the directory TUF metadata is cached in by
+// default, in the user cache directory if there is one.
+func defaultTUFCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ratify", "tuf")
+}
+
+// tufTarget is a TUF target downloaded into memory.
+type tufTarget struct {
+	bytes.Buffer
+}
+
+// Delete discards the target downloaded so far if its download fails.
+func (t *tufTarget) Delete() error {
+	t.Reset()
+	return nil
+}
+
+// contextTransport sends the requests of a TUF client with a context, as the
+// client does not take one.
+type contextTransport struct {
+	ctx context.Context
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return http.DefaultTransport.RoundTrip(req.WithContext(t.ctx))
+}
+
+// parseTrustedRoot parses a Sigstore trusted root bundle. Certificate chains
+// are verified to be signed by their issuers and transparency log IDs are
+// checked against their public keys so that a tampered bundle is rejected.
+// Certificate authorities and logs outside their validity period are not
+// trusted.
+func parseTrustedRoot(data []byte) (*trustedRootMaterial, error) {
+	return parseTrustedRootAt(data, time.Now())
+}
+
+// parseTrustedRootAt parses a Sigstore trusted root bundle, trusting the
+// certificate authorities and logs valid at now.
+func parseTrustedRootAt(data []byte, now time.Time) (*trustedRootMaterial, error) {
+	var trustedRoot sigstoreTrustedRoot
+	if err := json.Unmarshal(data, &trustedRoot); err != nil {
+		return nil, fmt.Errorf("failed to parse trusted root: %w", err)
+	}
+	if !strings.HasPrefix(trustedRoot.MediaType, trustedRootMediaType) {
+		return nil, fmt.Errorf("unsupported trusted root media type %q", trustedRoot.MediaType)
+	}
+
+	material := &trustedRootMaterial{
+		rootCerts:         x509.NewCertPool(),
+		intermediateCerts: x509.NewCertPool(),
+	}
+	for _, ca := range trustedRoot.CertificateAuthorities {
+		if !ca.ValidFor.contains(now) {
+			continue
+		}
+		if err := addCertChain(material, ca); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if material.rekorPubKeys, err = transparencyLogPubKeys(trustedRoot.Tlogs, now); err != nil {
+		return nil, fmt.Errorf("invalid transparency log: %w", err)
+	}
+	if material.ctLogPubKeys, err = transparencyLogPubKeys(trustedRoot.Ctlogs, now); err != nil {
+		return nil, fmt.Errorf("invalid certificate transparency log: %w", err)
+	}
+	return material, nil
+}
+
+// addCertChain verifies the certificate chain of a certificate authority,
+// ordered from leaf-most to root, and adds it to the trusted pools.
+func addCertChain(material *trustedRootMaterial, ca certificateAuthority) error {
+	chain := ca.CertChain.Certificates
+	if len(chain) == 0 {
+		return fmt.Errorf("certificate authority %s has an empty certificate chain", ca.URI)
+	}
+	certs := make([]*x509.Certificate, 0, len(chain))
+	for _, c := range chain {
+		cert, err := x509.ParseCertificate(c.RawBytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse certificate of certificate authority %s: %w", ca.URI, err)
+		}
+		certs = append(certs, cert)
+	}
+	for i, cert := range certs {
+		issuer := cert
+		if i+1 < len(certs) {
+			issuer = certs[i+1]
+		}
+		if err := cert.CheckSignatureFrom(issuer); err != nil {
+			return fmt.Errorf("invalid certificate chain for certificate authority %s: %w", ca.URI, err)
+		}
+	}
+	for _, cert := range certs[:len(certs)-1] {
+		material.intermediateCerts.AddCert(cert)
+	}
+	material.rootCerts.AddCert(certs[len(certs)-1])
+	return nil
+}
+
+// transparencyLogPubKeys converts the transparency log instances valid at now
+// into cosign trusted public keys, keyed by log ID.
+func transparencyLogPubKeys(logs []transparencyLog, now time.Time) (*cosign.TrustedTransparencyLogPubKeys, error) {
+	pubKeys := cosign.NewTrustedTransparencyLogPubKeys()
+	for _, log := range logs {
+		if !log.PublicKey.ValidFor.contains(now) {
+			continue
+		}
+		pubKey, err := x509.ParsePKIXPublicKey(log.PublicKey.RawBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key of log %s: %w", log.BaseURL, err)
+		}
+		logID, err := cosign.GetTransparencyLogID(pubKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute log ID of log %s: %w", log.BaseURL, err)
+		}
+		if len(log.LogID.KeyID) > 0 && hex.EncodeToString(log.LogID.KeyID) != logID {
+			return nil, fmt.Errorf("log ID of log %s does not match its public key", log.BaseURL)
+		}
+		pubKeys.Keys[logID] = cosign.TransparencyLogPubKey{PubKey: pubKey, Status: tuf.Active}
+	}
+	return &pubKeys, nil
+}
@@ -0,0 +1,395 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/tuf"
+	gotuf "github.com/theupdateframework/go-tuf"
+)
+
+const (
+	validTrustedRootPath    = "../../../test/testdata/sigstore_trusted_root.json"
+	tamperedTrustedRootPath = "../../../test/testdata/sigstore_trusted_root_tampered.json"
+)
+
+func TestParseTrustedRoot(t *testing.T) {
+	valid, err := os.ReadFile(validTrustedRootPath)
+	if err != nil {
+		t.Fatalf("failed to read trusted root fixture: %v", err)
+	}
+	tampered, err := os.ReadFile(tamperedTrustedRootPath)
+	if err != nil {
+		t.Fatalf("failed to read trusted root fixture: %v", err)
+	}
+
+	// a trusted root whose rekor log ID no longer matches its public key
+	var root map[string]interface{}
+	if err := json.Unmarshal(valid, &root); err != nil {
+		t.Fatalf("failed to unmarshal trusted root fixture: %v", err)
+	}
+	root["tlogs"].([]interface{})[0].(map[string]interface{})["logId"] = map[string]interface{}{"keyId": "dGFtcGVyZWQ="}
+	mismatchedLogID, _ := json.Marshal(root)
+
+	root["mediaType"] = "application/json"
+	invalidMediaType, _ := json.Marshal(root)
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{
+			name:    "valid trusted root",
+			data:    valid,
+			wantErr: false,
+		},
+		{
+			name:    "tampered certificate chain",
+			data:    tampered,
+			wantErr: true,
+		},
+		{
+			name:    "mismatched log ID",
+			data:    mismatchedLogID,
+			wantErr: true,
+		},
+		{
+			name:    "invalid media type",
+			data:    invalidMediaType,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			data:    []byte("{"),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			material, err := parseTrustedRoot(tc.data)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if material.rootCerts == nil || material.intermediateCerts == nil {
+				t.Fatalf("expected root and intermediate certificates to be set")
+			}
+			if len(material.rekorPubKeys.Keys) != 1 {
+				t.Fatalf("expected 1 rekor public key, got %d", len(material.rekorPubKeys.Keys))
+			}
+			if len(material.ctLogPubKeys.Keys) != 1 {
+				t.Fatalf("expected 1 CT log public key, got %d", len(material.ctLogPubKeys.Keys))
+			}
+			for _, key := range material.rekorPubKeys.Keys {
+				if key.Status != tuf.Active {
+					t.Fatalf("expected rekor key to be active")
+				}
+			}
+		})
+	}
+}
+
+func TestParseTrustedRoot_ValidFor(t *testing.T) {
+	valid, err := os.ReadFile(validTrustedRootPath)
+	if err != nil {
+		t.Fatalf("failed to read trusted root fixture: %v", err)
+	}
+	// the certificate authority and logs of the fixture are valid from 2024
+	material, err := parseTrustedRootAt(valid, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(material.rekorPubKeys.Keys) != 0 || len(material.ctLogPubKeys.Keys) != 0 {
+		t.Fatalf("expected logs not valid yet to be untrusted")
+	}
+	if material.rootCerts.Equal(mustParseTrustedRoot(t, valid).rootCerts) {
+		t.Fatalf("expected a certificate authority not valid yet to be untrusted")
+	}
+
+	// logs and certificate authorities are untrusted after their validity
+	var root map[string]interface{}
+	if err := json.Unmarshal(valid, &root); err != nil {
+		t.Fatalf("failed to unmarshal trusted root fixture: %v", err)
+	}
+	expired := map[string]interface{}{"start": "2024-01-01T00:00:00Z", "end": "2024-06-01T00:00:00Z"}
+	root["tlogs"].([]interface{})[0].(map[string]interface{})["publicKey"].(map[string]interface{})["validFor"] = expired
+	root["certificateAuthorities"].([]interface{})[0].(map[string]interface{})["validFor"] = expired
+	data, _ := json.Marshal(root)
+	material = mustParseTrustedRoot(t, data)
+	if len(material.rekorPubKeys.Keys) != 0 || len(material.ctLogPubKeys.Keys) != 1 {
+		t.Fatalf("expected only the expired transparency log to be untrusted")
+	}
+	if !material.rootCerts.Equal(x509.NewCertPool()) {
+		t.Fatalf("expected the expired certificate authority to be untrusted")
+	}
+}
+
+func mustParseTrustedRoot(t *testing.T, data []byte) *trustedRootMaterial {
+	t.Helper()
+	material, err := parseTrustedRoot(data)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return material
+}
+
+func TestLoadTrustedRoot(t *testing.T) {
+	if _, err := loadTrustedRoot(context.Background(), TrustedRootConfig{File: validTrustedRootPath}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := loadTrustedRoot(context.Background(), TrustedRootConfig{File: "nonexistent.json"}); err == nil {
+		t.Fatalf("expected error for missing trusted root file")
+	}
+	if _, err := loadTrustedRoot(context.Background(), TrustedRootConfig{TUFMirror: "https://tuf.example.com", TUFRoot: "nonexistent.json"}); err == nil {
+		t.Fatalf("expected error for missing TUF root")
+	}
+}
+
+func TestGetCosignOpts_TrustedRoot(t *testing.T) {
+	tp, err := CreateTrustPolicy(TrustPolicyConfig{
+		Name:   "test",
+		Scopes: []string{"*"},
+		Keyless: KeylessConfig{
+			CertificateIdentity:   "test-identity",
+			CertificateOIDCIssuer: "https://test-issuer.com",
+		},
+		RekorURL: "https://rekor.ratify.dev",
+		TrustedRoot: &TrustedRootConfig{
+			File: validTrustedRootPath,
+		},
+	}, "test-verifier")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	opts, err := tp.GetCosignOpts(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if opts.RootCerts == nil || opts.IntermediateCerts == nil {
+		t.Fatalf("expected trusted root certificates to be used")
+	}
+	if opts.RekorPubKeys == nil || len(opts.RekorPubKeys.Keys) != 1 {
+		t.Fatalf("expected rekor public keys from trusted root")
+	}
+	if opts.CTLogPubKeys == nil || len(opts.CTLogPubKeys.Keys) != 1 {
+		t.Fatalf("expected CT log public keys from trusted root")
+	}
+}
+
+func TestTUFTrustedRoot(t *testing.T) {
+	var mu sync.Mutex
+	loads := 0
+	var loadErr error
+	now := time.Now()
+	trustedRoot := &tufTrustedRoot{
+		config:          TrustedRootConfig{TUFMirror: "https://tuf.example.com"},
+		refreshInterval: time.Hour,
+		load: func(_ context.Context, _ TrustedRootConfig) (*trustedRootMaterial, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			loads++
+			if loadErr != nil {
+				return nil, loadErr
+			}
+			return &trustedRootMaterial{}, nil
+		},
+		now: func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		},
+	}
+	loadCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return loads
+	}
+	waitRefresh := func() {
+		trustedRoot.mu.Lock()
+		call := trustedRoot.refreshing
+		trustedRoot.mu.Unlock()
+		if call != nil {
+			<-call.done
+		}
+	}
+
+	first, err := trustedRoot.get(context.Background())
+	if err != nil || first == nil {
+		t.Fatalf("expected the trusted root to be fetched, got %v", err)
+	}
+	if _, err := trustedRoot.get(context.Background()); err != nil || loadCount() != 1 {
+		t.Fatalf("expected the cached trusted root to be used, got %d fetches, err: %v", loadCount(), err)
+	}
+
+	// an expired trusted root is used while it is refreshed in the background
+	mu.Lock()
+	now = now.Add(2 * time.Hour)
+	loadErr = fmt.Errorf("mirror unavailable")
+	mu.Unlock()
+	if cached, err := trustedRoot.get(context.Background()); err != nil || cached != first {
+		t.Fatalf("expected the cached trusted root during the refresh, got %v", err)
+	}
+	waitRefresh()
+	if loadCount() != 2 {
+		t.Fatalf("expected the trusted root to be refreshed, got %d fetches", loadCount())
+	}
+	// a failed refresh keeps the cached trusted root until the next interval
+	if cached, err := trustedRoot.get(context.Background()); err != nil || cached != first || loadCount() != 2 {
+		t.Fatalf("expected the cached trusted root after a failed refresh, got %d fetches, err: %v", loadCount(), err)
+	}
+
+	mu.Lock()
+	now = now.Add(2 * time.Hour)
+	loadErr = nil
+	mu.Unlock()
+	_, _ = trustedRoot.get(context.Background())
+	waitRefresh()
+	if refreshed, err := trustedRoot.get(context.Background()); err != nil || refreshed == first {
+		t.Fatalf("expected the refreshed trusted root, got %v", err)
+	}
+
+	failing := &tufTrustedRoot{
+		refreshInterval: time.Hour,
+		load: func(_ context.Context, _ TrustedRootConfig) (*trustedRootMaterial, error) {
+			return nil, fmt.Errorf("mirror unavailable")
+		},
+		now: time.Now,
+	}
+	if _, err := failing.get(context.Background()); err == nil {
+		t.Fatalf("expected an error before the trusted root was fetched")
+	}
+}
+
+func TestSharedTUFTrustedRoot(t *testing.T) {
+	config := TrustedRootConfig{TUFMirror: "https://shared.tuf.example.com"}
+	first, err := sharedTUFTrustedRoot(config)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if second, _ := sharedTUFTrustedRoot(config); second != first {
+		t.Fatalf("expected trust policies of the same mirror to share the trusted root")
+	}
+	if _, err := sharedTUFTrustedRoot(TrustedRootConfig{TUFMirror: config.TUFMirror, RefreshInterval: "-1m"}); err == nil {
+		t.Fatalf("expected an error for a negative refresh interval")
+	}
+}
+
+// newTestTUFMirror serves a TUF repository with the trusted root target and
+// returns its URL and initial root.
+func newTestTUFMirror(t *testing.T, trustedRoot []byte) (string, []byte) {
+	t.Helper()
+	store := gotuf.MemoryStore(nil, map[string][]byte{trustedRootTarget: trustedRoot})
+	repo, err := gotuf.NewRepo(store)
+	if err != nil {
+		t.Fatalf("failed to create TUF repository: %v", err)
+	}
+	if err := repo.Init(false); err != nil {
+		t.Fatalf("failed to initialize TUF repository: %v", err)
+	}
+	for _, role := range []string{"root", "targets", "snapshot", "timestamp"} {
+		if _, err := repo.GenKey(role); err != nil {
+			t.Fatalf("failed to generate %s key: %v", role, err)
+		}
+	}
+	if err := repo.AddTarget(trustedRootTarget, nil); err != nil {
+		t.Fatalf("failed to add target: %v", err)
+	}
+	if err := repo.Snapshot(); err != nil {
+		t.Fatalf("failed to snapshot: %v", err)
+	}
+	if err := repo.Timestamp(); err != nil {
+		t.Fatalf("failed to timestamp: %v", err)
+	}
+	if err := repo.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	meta, err := store.GetMeta()
+	if err != nil {
+		t.Fatalf("failed to get metadata: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if target, ok := strings.CutPrefix(name, "targets/"); ok && target == trustedRootTarget {
+			_, _ = w.Write(trustedRoot)
+			return
+		}
+		if data, ok := meta[name]; ok {
+			_, _ = w.Write(data)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, meta["root.json"]
+}
+
+func TestFetchTrustedRootFromTUF(t *testing.T) {
+	cacheDir := tufCacheDir
+	tufCacheDir = t.TempDir()
+	t.Cleanup(func() { tufCacheDir = cacheDir })
+
+	writeRoot := func(name string, root []byte) string {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, root, 0o600); err != nil {
+			t.Fatalf("failed to write TUF root: %v", err)
+		}
+		return path
+	}
+	firstTarget := []byte(`{"mirror":"first"}`)
+	firstMirror, firstRoot := newTestTUFMirror(t, firstTarget)
+	secondTarget := []byte(`{"mirror":"second"}`)
+	secondMirror, secondRoot := newTestTUFMirror(t, secondTarget)
+	first := TrustedRootConfig{TUFMirror: firstMirror, TUFRoot: writeRoot("first.json", firstRoot)}
+	second := TrustedRootConfig{TUFMirror: secondMirror, TUFRoot: writeRoot("second.json", secondRoot)}
+
+	// each mirror is fetched with its own client, also after the other one
+	for _, tc := range []struct {
+		config TrustedRootConfig
+		want   []byte
+	}{{first, firstTarget}, {second, secondTarget}, {first, firstTarget}} {
+		data, err := fetchTrustedRootFromTUF(context.Background(), tc.config)
+		if err != nil {
+			t.Fatalf("expected no error fetching from %s, got %v", tc.config.TUFMirror, err)
+		}
+		if !bytes.Equal(data, tc.want) {
+			t.Fatalf("expected trusted root %s from %s, got %s", tc.want, tc.config.TUFMirror, data)
+		}
+	}
+
+	// a mirror is not trusted with the root of another one
+	if _, err := fetchTrustedRootFromTUF(context.Background(), TrustedRootConfig{TUFMirror: secondMirror, TUFRoot: first.TUFRoot}); err == nil {
+		t.Fatalf("expected an error for a mirror not signed by the TUF root")
+	}
+}
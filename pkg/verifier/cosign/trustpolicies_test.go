@@ -16,7 +16,10 @@ limitations under the License.
 package cosign
 
 import (
+	"context"
 	"testing"
+
+	"github.com/ratify-project/ratify/pkg/utils"
 )
 
 // TestCreateTrustPolicies tests the CreateTrustPolicies function
@@ -221,6 +224,35 @@ func TestGetScopedPolicy(t *testing.T) {
 	}
 }
 
+// TestGetScopedPolicy_PullThroughCache tests that references pulled through a
+// pull-through cache are matched against scopes of the upstream registry
+func TestGetScopedPolicy_PullThroughCache(t *testing.T) {
+	policies, err := CreateTrustPolicies([]TrustPolicyConfig{
+		{
+			Name:    "upstream",
+			Scopes:  []string{"docker.io/library/*"},
+			Keyless: KeylessConfig{CertificateIdentity: "test-identity", CertificateOIDCIssuer: "https://test-issuer.com"},
+		},
+	}, "test-verifier")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reference := "cache.example.com/library/nginx:1.25"
+
+	if _, err := policies.GetScopedPolicy(utils.UpstreamReference(context.Background(), reference)); err == nil {
+		t.Fatalf("expected no policy to match the cache host without a mapping")
+	}
+
+	ctx := utils.WithUpstreamRegistries(context.Background(), map[string]string{"cache.example.com": "docker.io"})
+	policy, err := policies.GetScopedPolicy(utils.UpstreamReference(ctx, reference))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.GetName() != "upstream" {
+		t.Fatalf("GetScopedPolicy() policy name = %v, want upstream", policy.GetName())
+	}
+}
+
 // TestValidateScopes tests the validateScopes function
 func TestValidateScopes(t *testing.T) {
 	tc := []struct {
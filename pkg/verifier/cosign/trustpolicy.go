@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"time"
 
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
@@ -36,6 +37,12 @@ type KeyConfig struct {
 	Name     string `json:"name,omitempty"`
 	Version  string `json:"version,omitempty"`
 	File     string `json:"file,omitempty"`
+	// RetiredAt is the RFC 3339 time the key was retired by a rotation.
+	// Signatures made with a retired key after its grace period are invalid.
+	RetiredAt string `json:"retiredAt,omitempty"`
+	// RetiredGracePeriod is the duration, e.g. 72h, after RetiredAt during
+	// which signatures made with the retired key remain valid. Defaults to 0.
+	RetiredGracePeriod string `json:"retiredGracePeriod,omitempty"`
 }
 
 type KeylessConfig struct {
@@ -44,6 +51,15 @@ type KeylessConfig struct {
 	CertificateIdentityRegExp   string `json:"certificateIdentityRegExp,omitempty"`
 	CertificateOIDCIssuer       string `json:"certificateOIDCIssuer,omitempty"`
 	CertificateOIDCIssuerRegExp string `json:"certificateOIDCIssuerRegExp,omitempty"`
+	// The GitHub Actions workflow claims the Fulcio certificate must carry,
+	// e.g. the workflow repository and ref of the CI that signs images.
+	// Certificates of any other workflow fail the verification even if they
+	// match the identity and issuer. Any workflow is accepted if empty.
+	CertificateGithubWorkflowRepository string `json:"certificateGithubWorkflowRepository,omitempty"`
+	CertificateGithubWorkflowRef        string `json:"certificateGithubWorkflowRef,omitempty"`
+	CertificateGithubWorkflowName       string `json:"certificateGithubWorkflowName,omitempty"`
+	CertificateGithubWorkflowTrigger    string `json:"certificateGithubWorkflowTrigger,omitempty"`
+	CertificateGithubWorkflowSha        string `json:"certificateGithubWorkflowSha,omitempty"`
 }
 
 type TrustPolicyConfig struct {
@@ -54,6 +70,9 @@ type TrustPolicyConfig struct {
 	Keyless    KeylessConfig `json:"keyless,omitempty"`
 	TLogVerify *bool         `json:"tLogVerify,omitempty"`
 	RekorURL   string        `json:"rekorURL,omitempty"`
+	// TrustedRoot pins the Fulcio, Rekor and CT log trust material to a
+	// Sigstore trusted root bundle instead of the default public good roots.
+	TrustedRoot *TrustedRootConfig `json:"trustedRoot,omitempty"`
 }
 
 type PKKey struct {
@@ -63,11 +82,17 @@ type PKKey struct {
 }
 
 type trustPolicy struct {
-	scopes       []string
-	localKeys    map[PKKey]keymanagementprovider.PublicKey
+	scopes    []string
+	localKeys map[PKKey]keymanagementprovider.PublicKey
+	// retiredKeys maps the retired keys to the end of their grace period
+	retiredKeys  map[PKKey]time.Time
 	config       TrustPolicyConfig
 	verifierName string
 	isKeyless    bool
+	// trustedRoot is the trust material loaded from a local trusted root file
+	trustedRoot *trustedRootMaterial
+	// tufTrustedRoot is the trusted root fetched from a TUF mirror
+	tufTrustedRoot *tufTrustedRoot
 }
 
 type TrustPolicy interface {
@@ -102,7 +127,11 @@ func CreateTrustPolicy(config TrustPolicyConfig, verifierName string) (TrustPoli
 	}
 
 	keyMap := make(map[PKKey]keymanagementprovider.PublicKey)
+	retiredKeys := make(map[PKKey]time.Time)
 	for _, keyConfig := range config.Keys {
+		if keyConfig.RetiredAt != "" {
+			retiredKeys[keyConfig.pkKey()] = keyConfig.graceEnd()
+		}
 		// check if the key is defined by file path or by key management provider
 		if keyConfig.File != "" {
 			pubKey, err := loadKeyFromPath(keyConfig.File)
@@ -113,6 +142,23 @@ func CreateTrustPolicy(config TrustPolicyConfig, verifierName string) (TrustPoli
 		}
 	}
 
+	// an offline trusted root is loaded once so that an invalid bundle fails fast
+	var trustedRoot *trustedRootMaterial
+	if config.TrustedRoot != nil && config.TrustedRoot.File != "" {
+		var err error
+		trustedRoot, err = loadTrustedRoot(context.Background(), *config.TrustedRoot)
+		if err != nil {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy [%s]: failed to load the trusted root from file %s", config.Name, config.TrustedRoot.File)).WithError(err).WithRemediation("Ensure that the trusted root file is a valid Sigstore trusted root bundle.")
+		}
+	}
+	var tufTrustedRoot *tufTrustedRoot
+	if config.TrustedRoot != nil && config.TrustedRoot.TUFMirror != "" {
+		var err error
+		if tufTrustedRoot, err = sharedTUFTrustedRoot(*config.TrustedRoot); err != nil {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy [%s]", config.Name)).WithError(err)
+		}
+	}
+
 	if config.RekorURL == "" {
 		config.RekorURL = DefaultRekorURL
 	}
@@ -126,11 +172,14 @@ func CreateTrustPolicy(config TrustPolicyConfig, verifierName string) (TrustPoli
 	}
 
 	return &trustPolicy{
-		scopes:       config.Scopes,
-		localKeys:    keyMap,
-		config:       config,
-		verifierName: verifierName,
-		isKeyless:    config.Keyless != KeylessConfig{},
+		scopes:         config.Scopes,
+		localKeys:      keyMap,
+		retiredKeys:    retiredKeys,
+		config:         config,
+		verifierName:   verifierName,
+		isKeyless:      config.Keyless != KeylessConfig{},
+		trustedRoot:    trustedRoot,
+		tufTrustedRoot: tufTrustedRoot,
 	}, nil
 }
 
@@ -174,6 +223,33 @@ func (tp *trustPolicy) GetKeys(ctx context.Context, _ string) (map[PKKey]keymana
 	return keyMap, nil
 }
 
+// retiredUntil returns the end of the grace period of the key if it is
+// retired.
+func (tp *trustPolicy) retiredUntil(key PKKey) (time.Time, bool) {
+	graceEnd, retired := tp.retiredKeys[key]
+	return graceEnd, retired
+}
+
+// pkKey returns the key of the configured key in the map of keys of the trust
+// policy.
+func (keyConfig KeyConfig) pkKey() PKKey {
+	if keyConfig.File != "" {
+		return PKKey{Provider: fileProviderName, Name: keyConfig.File}
+	}
+	return PKKey{Provider: keyConfig.Provider, Name: keyConfig.Name, Version: keyConfig.Version}
+}
+
+// graceEnd returns the end of the grace period of a retired key. The
+// retirement is validated with the trust policy.
+func (keyConfig KeyConfig) graceEnd() time.Time {
+	retiredAt, _ := time.Parse(time.RFC3339, keyConfig.RetiredAt)
+	var gracePeriod time.Duration
+	if keyConfig.RetiredGracePeriod != "" {
+		gracePeriod, _ = time.ParseDuration(keyConfig.RetiredGracePeriod)
+	}
+	return retiredAt.Add(gracePeriod)
+}
+
 // GetScopes returns the scopes defined in the trust policy
 func (tp *trustPolicy) GetScopes() []string {
 	return tp.scopes
@@ -181,7 +257,10 @@ func (tp *trustPolicy) GetScopes() []string {
 
 func (tp *trustPolicy) GetCosignOpts(ctx context.Context) (cosign.CheckOpts, error) {
 	cosignOpts := cosign.CheckOpts{}
-	var err error
+	trustedRoot, err := tp.getTrustedRoot(ctx)
+	if err != nil {
+		return cosignOpts, err
+	}
 	// if tlog verification is enabled, set the rekor client and public keys
 	if tp.config.TLogVerify != nil && *tp.config.TLogVerify {
 		cosignOpts.IgnoreTlog = false
@@ -190,10 +269,14 @@ func (tp *trustPolicy) GetCosignOpts(ctx context.Context) (cosign.CheckOpts, err
 		if err != nil {
 			return cosignOpts, re.ErrorCodeConfigInvalid.WithDetail(fmt.Errorf("Failed to create Rekor client from URL %s", tp.config.RekorURL)).WithRemediation("Ensure that the Rekor URL is valid.").WithError(err)
 		}
-		// Fetches the Rekor public keys from the Rekor server
-		cosignOpts.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
-		if err != nil {
-			return cosignOpts, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to fetch Rekor public keys").WithRemediation(fmt.Sprintf("Please check if the Rekor server %s is available", tp.config.RekorURL)).WithError(err)
+		if trustedRoot != nil {
+			cosignOpts.RekorPubKeys = trustedRoot.rekorPubKeys
+		} else {
+			// Fetches the Rekor public keys from the Rekor server
+			cosignOpts.RekorPubKeys, err = cosign.GetRekorPubs(ctx)
+			if err != nil {
+				return cosignOpts, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to fetch Rekor public keys").WithRemediation(fmt.Sprintf("Please check if the Rekor server %s is available", tp.config.RekorURL)).WithError(err)
+			}
 		}
 	} else {
 		cosignOpts.IgnoreTlog = true
@@ -201,37 +284,72 @@ func (tp *trustPolicy) GetCosignOpts(ctx context.Context) (cosign.CheckOpts, err
 
 	// if keyless verification is enabled, set the root certificates, intermediate certificates, and certificate transparency log public keys
 	if tp.isKeyless {
-		roots, err := fulcio.GetRoots()
-		if err != nil || roots == nil {
-			return cosignOpts, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to get fulcio root").WithError(err).WithRemediation("Please check if Fulcio is available")
+		if trustedRoot != nil {
+			cosignOpts.RootCerts = trustedRoot.rootCerts
+			cosignOpts.IntermediateCerts = trustedRoot.intermediateCerts
+		} else {
+			roots, err := fulcio.GetRoots()
+			if err != nil || roots == nil {
+				return cosignOpts, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to get fulcio root").WithError(err).WithRemediation("Please check if Fulcio is available")
+			}
+			cosignOpts.RootCerts = roots
 		}
-		cosignOpts.RootCerts = roots
 		if tp.config.Keyless.CTLogVerify != nil && *tp.config.Keyless.CTLogVerify {
-			cosignOpts.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx)
-			if err != nil {
-				return cosignOpts, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to fetch certificate transparency log public keys").WithError(err).WithRemediation("Please check if TUF root is available")
+			if trustedRoot != nil {
+				cosignOpts.CTLogPubKeys = trustedRoot.ctLogPubKeys
+			} else {
+				cosignOpts.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx)
+				if err != nil {
+					return cosignOpts, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to fetch certificate transparency log public keys").WithError(err).WithRemediation("Please check if TUF root is available")
+				}
 			}
 		} else {
 			cosignOpts.IgnoreSCT = true
 		}
-		cosignOpts.IntermediateCerts, err = fulcio.GetIntermediates()
-		if err != nil {
-			return cosignOpts, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to get fulcio intermediate certificates").WithError(err).WithRemediation("Please check if Fulcio is available")
-		}
-		// Set the certificate identity and issuer for keyless verification
-		cosignOpts.Identities = []cosign.Identity{
-			{
-				IssuerRegExp:  tp.config.Keyless.CertificateOIDCIssuerRegExp,
-				Issuer:        tp.config.Keyless.CertificateOIDCIssuer,
-				SubjectRegExp: tp.config.Keyless.CertificateIdentityRegExp,
-				Subject:       tp.config.Keyless.CertificateIdentity,
-			},
+		if trustedRoot == nil {
+			cosignOpts.IntermediateCerts, err = fulcio.GetIntermediates()
+			if err != nil {
+				return cosignOpts, re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to get fulcio intermediate certificates").WithError(err).WithRemediation("Please check if Fulcio is available")
+			}
 		}
+		setCertificatePolicy(&cosignOpts, tp.config.Keyless)
 	}
 
 	return cosignOpts, nil
 }
 
+// setCertificatePolicy sets the identity, issuer and GitHub workflow claims
+// the Fulcio certificate must match for keyless verification.
+func setCertificatePolicy(cosignOpts *cosign.CheckOpts, keyless KeylessConfig) {
+	cosignOpts.Identities = []cosign.Identity{
+		{
+			IssuerRegExp:  keyless.CertificateOIDCIssuerRegExp,
+			Issuer:        keyless.CertificateOIDCIssuer,
+			SubjectRegExp: keyless.CertificateIdentityRegExp,
+			Subject:       keyless.CertificateIdentity,
+		},
+	}
+	cosignOpts.CertGithubWorkflowRepository = keyless.CertificateGithubWorkflowRepository
+	cosignOpts.CertGithubWorkflowRef = keyless.CertificateGithubWorkflowRef
+	cosignOpts.CertGithubWorkflowName = keyless.CertificateGithubWorkflowName
+	cosignOpts.CertGithubWorkflowTrigger = keyless.CertificateGithubWorkflowTrigger
+	cosignOpts.CertGithubWorkflowSha = keyless.CertificateGithubWorkflowSha
+}
+
+// getTrustedRoot returns the trust material of the configured Sigstore trusted
+// root. A TUF-backed trusted root is cached and refreshed from the mirror in
+// the background. Returns nil if no trusted root is configured.
+func (tp *trustPolicy) getTrustedRoot(ctx context.Context) (*trustedRootMaterial, error) {
+	if tp.tufTrustedRoot == nil {
+		return tp.trustedRoot, nil
+	}
+	trustedRoot, err := tp.tufTrustedRoot.get(ctx)
+	if err != nil {
+		return nil, re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to load the Sigstore trusted root from TUF mirror %s", tp.config.TrustedRoot.TUFMirror)).WithError(err).WithRemediation("Please check if the TUF mirror is available and the TUF root is valid")
+	}
+	return trustedRoot, nil
+}
+
 // validate checks if the trust policy configuration is valid
 // returns an error if the configuration is invalid
 func validate(config TrustPolicyConfig) error {
@@ -271,6 +389,22 @@ func validate(config TrustPolicyConfig) error {
 		if keyConfig.Version != "" && keyConfig.Name == "" {
 			return re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy %s: key name is required when key version is defined", config.Name))
 		}
+		if err := validateRetirement(config.Name, keyConfig); err != nil {
+			return err
+		}
+	}
+
+	// validate trusted root configuration
+	if config.TrustedRoot != nil {
+		if (config.TrustedRoot.File == "") == (config.TrustedRoot.TUFMirror == "") {
+			return re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy %s: exactly one of trusted root 'file' or 'tufMirror' must be specified", config.Name))
+		}
+		if config.TrustedRoot.TUFRoot != "" && config.TrustedRoot.TUFMirror == "" {
+			return re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy %s: trusted root 'tufRoot' requires 'tufMirror'", config.Name))
+		}
+		if config.TrustedRoot.RefreshInterval != "" && config.TrustedRoot.TUFMirror == "" {
+			return re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy %s: trusted root 'refreshInterval' requires 'tufMirror'", config.Name))
+		}
 	}
 
 	// validate keyless configuration
@@ -296,6 +430,30 @@ func validate(config TrustPolicyConfig) error {
 	return nil
 }
 
+// validateRetirement returns an error if the retirement of the key is invalid.
+func validateRetirement(policyName string, keyConfig KeyConfig) error {
+	if keyConfig.RetiredAt == "" {
+		if keyConfig.RetiredGracePeriod != "" {
+			return re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy %s: 'retiredGracePeriod' requires 'retiredAt'", policyName))
+		}
+		return nil
+	}
+	// all keys of a provider cannot be retired at once
+	if keyConfig.File == "" && keyConfig.Name == "" {
+		return re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy %s: key name or file is required when the key is retired", policyName))
+	}
+	if _, err := time.Parse(time.RFC3339, keyConfig.RetiredAt); err != nil {
+		return re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy %s: 'retiredAt' must be an RFC 3339 time", policyName)).WithError(err)
+	}
+	if keyConfig.RetiredGracePeriod != "" {
+		gracePeriod, err := time.ParseDuration(keyConfig.RetiredGracePeriod)
+		if err != nil || gracePeriod < 0 {
+			return re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid trust policy %s: 'retiredGracePeriod' must be a non-negative duration, got %s", policyName, keyConfig.RetiredGracePeriod))
+		}
+	}
+	return nil
+}
+
 // loadKeyFromPath loads a public key from a file path and returns it
 // TODO: look into supporting cosign's blob.LoadFileOrURL to support URL + env variables
 func loadKeyFromPath(filePath string) (crypto.PublicKey, error) {
@@ -19,10 +19,17 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"fmt"
+	"math/big"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	ctxUtils "github.com/ratify-project/ratify/internal/context"
 	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
@@ -97,6 +104,78 @@ func TestCreateTrustPolicy(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid retired local key",
+			cfg: TrustPolicyConfig{
+				Name:   "test",
+				Scopes: []string{"*"},
+				Keys: []KeyConfig{
+					{
+						File:               "../../../test/testdata/cosign.pub",
+						RetiredAt:          "2024-01-01T00:00:00Z",
+						RetiredGracePeriod: "72h",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid retirement time",
+			cfg: TrustPolicyConfig{
+				Name:   "test",
+				Scopes: []string{"*"},
+				Keys: []KeyConfig{
+					{
+						File:      "../../../test/testdata/cosign.pub",
+						RetiredAt: "2024-01-01",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative retirement grace period",
+			cfg: TrustPolicyConfig{
+				Name:   "test",
+				Scopes: []string{"*"},
+				Keys: []KeyConfig{
+					{
+						File:               "../../../test/testdata/cosign.pub",
+						RetiredAt:          "2024-01-01T00:00:00Z",
+						RetiredGracePeriod: "-1h",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "retirement grace period without retirement time",
+			cfg: TrustPolicyConfig{
+				Name:   "test",
+				Scopes: []string{"*"},
+				Keys: []KeyConfig{
+					{
+						File:               "../../../test/testdata/cosign.pub",
+						RetiredGracePeriod: "72h",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "retired key management provider without key name",
+			cfg: TrustPolicyConfig{
+				Name:   "test",
+				Scopes: []string{"*"},
+				Keys: []KeyConfig{
+					{
+						Provider:  "kmp",
+						RetiredAt: "2024-01-01T00:00:00Z",
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid keyless config with rekor specified",
 			cfg: TrustPolicyConfig{
@@ -109,6 +188,36 @@ func TestCreateTrustPolicy(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid keyless config with trusted root file",
+			cfg: TrustPolicyConfig{
+				Name:   "test",
+				Scopes: []string{"*"},
+				Keyless: KeylessConfig{
+					CertificateIdentity:   "test-identity",
+					CertificateOIDCIssuer: "https://test-issuer.com",
+				},
+				TrustedRoot: &TrustedRootConfig{
+					File: validTrustedRootPath,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tampered trusted root file",
+			cfg: TrustPolicyConfig{
+				Name:   "test",
+				Scopes: []string{"*"},
+				Keyless: KeylessConfig{
+					CertificateIdentity:   "test-identity",
+					CertificateOIDCIssuer: "https://test-issuer.com",
+				},
+				TrustedRoot: &TrustedRootConfig{
+					File: tamperedTrustedRootPath,
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid config version",
 			cfg: TrustPolicyConfig{
@@ -434,8 +543,72 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "trusted root with both file and tuf mirror",
+			policyConfig: TrustPolicyConfig{
+				Version: "1.0.0",
+				Name:    "test",
+				Scopes:  []string{"*"},
+				Keyless: KeylessConfig{
+					CertificateIdentity:   "test-identity",
+					CertificateOIDCIssuer: "https://test-issuer.com",
+				},
+				TrustedRoot: &TrustedRootConfig{
+					File:      validTrustedRootPath,
+					TUFMirror: "https://tuf-repo-cdn.sigstore.dev",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trusted root without source",
+			policyConfig: TrustPolicyConfig{
+				Version: "1.0.0",
+				Name:    "test",
+				Scopes:  []string{"*"},
+				Keyless: KeylessConfig{
+					CertificateIdentity:   "test-identity",
+					CertificateOIDCIssuer: "https://test-issuer.com",
+				},
+				TrustedRoot: &TrustedRootConfig{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trusted root tuf root without mirror",
+			policyConfig: TrustPolicyConfig{
+				Version: "1.0.0",
+				Name:    "test",
+				Scopes:  []string{"*"},
+				Keyless: KeylessConfig{
+					CertificateIdentity:   "test-identity",
+					CertificateOIDCIssuer: "https://test-issuer.com",
+				},
+				TrustedRoot: &TrustedRootConfig{
+					File:    validTrustedRootPath,
+					TUFRoot: "root.json",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid trusted root tuf config",
+			policyConfig: TrustPolicyConfig{
+				Version: "1.0.0",
+				Name:    "test",
+				Scopes:  []string{"*"},
+				Keyless: KeylessConfig{
+					CertificateIdentity:   "test-identity",
+					CertificateOIDCIssuer: "https://test-issuer.com",
+				},
+				TrustedRoot: &TrustedRootConfig{
+					TUFMirror: "https://tuf-repo-cdn.sigstore.dev",
+					TUFRoot:   "root.json",
+				},
+			},
+			wantErr: false,
+		},
 	}
-
 	for _, tt := range tc {
 		t.Run(tt.name, func(t *testing.T) {
 			actual := validate(tt.policyConfig)
@@ -535,3 +708,92 @@ func TestGetCosignOpts(t *testing.T) {
 		})
 	}
 }
+
+// newTestFulcioCert creates a certificate carrying the SAN and the GitHub
+// Actions OIDC claims Fulcio issues for a workflow run.
+func newTestFulcioCert(t *testing.T, subject, issuer, repository, ref string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	san, err := url.Parse(subject)
+	if err != nil {
+		t.Fatalf("failed to parse subject: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sigstore"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{san},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, Value: []byte(issuer)},
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 5}, Value: []byte(repository)},
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 6}, Value: []byte(ref)},
+		},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestSetCertificatePolicy_CIIdentity(t *testing.T) {
+	const (
+		ciIdentity = "https://github.com/ratify-project/ratify/.github/workflows/release.yml@refs/heads/main"
+		ciIssuer   = "https://token.actions.githubusercontent.com"
+	)
+	keyless := KeylessConfig{
+		CertificateIdentity:                 ciIdentity,
+		CertificateOIDCIssuer:               ciIssuer,
+		CertificateGithubWorkflowRepository: "ratify-project/ratify",
+		CertificateGithubWorkflowRef:        "refs/heads/main",
+	}
+	cosignOpts := cosign.CheckOpts{}
+	setCertificatePolicy(&cosignOpts, keyless)
+
+	tests := []struct {
+		name      string
+		cert      *x509.Certificate
+		expectErr bool
+	}{
+		{
+			name: "CI identity",
+			cert: newTestFulcioCert(t, ciIdentity, ciIssuer, "ratify-project/ratify", "refs/heads/main"),
+		},
+		{
+			name:      "impostor identity",
+			cert:      newTestFulcioCert(t, "https://github.com/impostor/ratify/.github/workflows/release.yml@refs/heads/main", ciIssuer, "impostor/ratify", "refs/heads/main"),
+			expectErr: true,
+		},
+		{
+			name:      "impostor issuer",
+			cert:      newTestFulcioCert(t, ciIdentity, "https://accounts.example.com", "ratify-project/ratify", "refs/heads/main"),
+			expectErr: true,
+		},
+		{
+			name:      "CI identity from another workflow ref",
+			cert:      newTestFulcioCert(t, ciIdentity, ciIssuer, "ratify-project/ratify", "refs/heads/feature"),
+			expectErr: true,
+		},
+		{
+			name:      "CI identity from another repository",
+			cert:      newTestFulcioCert(t, ciIdentity, ciIssuer, "impostor/ratify", "refs/heads/main"),
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cosign.CheckCertificatePolicy(tt.cert, &cosignOpts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("CheckCertificatePolicy() error = %v, expectErr = %v", err, tt.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import "sync"
+
+// disabledVerifiers holds the names of the verifiers disabled at runtime.
+// Verifiers are enabled unless disabled by their config or the admin API. The
+// state is keyed by name only, verifiers of the same name in different
+// namespaces share it.
+var disabledVerifiers sync.Map
+
+// SetEnabled enables or disables the verifier with the given name. A disabled
+// verifier is skipped by the executor until it is enabled again.
+func SetEnabled(name string, enabled bool) {
+	if enabled {
+		disabledVerifiers.Delete(name)
+		return
+	}
+	disabledVerifiers.Store(name, struct{}{})
+}
+
+// IsEnabled returns false if the verifier with the given name is disabled.
+func IsEnabled(name string) bool {
+	_, disabled := disabledVerifiers.Load(name)
+	return !disabled
+}
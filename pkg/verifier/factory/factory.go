@@ -16,6 +16,9 @@ limitations under the License.
 package factory
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -65,6 +68,13 @@ func CreateVerifierFromConfig(verifierConfig config.VerifierConfig, configVersio
 		verifierTypeStr = value.(string)
 	}
 
+	enabled := true
+	if value, ok := verifierConfig[types.Enabled]; ok {
+		if enabled, ok = value.(bool); !ok {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("The %s field of the Verifier configuration must be a boolean, got %v", types.Enabled, value))
+		}
+	}
+
 	if strings.ContainsRune(verifierTypeStr, os.PathSeparator) {
 		return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid name [%s] in the Verifier configuration, [%v] is disallowed", verifierTypeStr, os.PathSeparator))
 	}
@@ -88,6 +98,35 @@ func CreateVerifierFromConfig(verifierConfig config.VerifierConfig, configVersio
 		}
 	}
 
+	referenceVerifier, err := createVerifier(verifierTypeStr, verifierConfig, configVersion, pluginBinDir, namespace)
+	if err != nil {
+		return nil, err
+	}
+	// the enabled state is reset on every config reload, overriding a toggle
+	// of the admin API.
+	verifier.SetEnabled(referenceVerifier.Name(), enabled)
+	if hash, err := hashVerifierConfig(verifierConfig, configVersion, namespace); err != nil {
+		logrus.Warnf("failed to hash the config of verifier %s: %v", referenceVerifier.Name(), err)
+	} else {
+		verifier.SetConfigHash(referenceVerifier, hash)
+	}
+	return referenceVerifier, nil
+}
+
+// hashVerifierConfig returns the hash of the config a verifier is created
+// from, so that results cached under a previous config are not reused.
+func hashVerifierConfig(verifierConfig config.VerifierConfig, configVersion string, namespace string) (string, error) {
+	// maps are marshaled with sorted keys, the hash is stable
+	configBytes, err := json.Marshal(verifierConfig)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s", configVersion, namespace, configBytes)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func createVerifier(verifierTypeStr string, verifierConfig config.VerifierConfig, configVersion string, pluginBinDir []string, namespace string) (verifier.ReferenceVerifier, error) {
 	verifierFactory, ok := builtInVerifiers[verifierTypeStr]
 	if ok {
 		return verifierFactory.Create(configVersion, verifierConfig, pluginBinDir[0], namespace)
@@ -129,11 +168,13 @@ func CreateVerifiersFromConfig(verifiersConfig config.VerifiersConfig, defaultPl
 
 	// TODO: do we need to append defaultPlugin path?
 	for _, verifierConfig := range verifiersConfig.Verifiers {
-		verifier, err := CreateVerifierFromConfig(verifierConfig, verifiersConfig.Version, verifiersConfig.PluginBinDirs, namespace)
+		referenceVerifier, err := CreateVerifierFromConfig(verifierConfig, verifiersConfig.Version, verifiersConfig.PluginBinDirs, namespace)
 		if err != nil {
+			// the verifiers created so far are discarded
+			verifier.DeleteConfigHashes(verifiers)
 			return nil, re.ErrorCodePluginInitFailure.WithError(err)
 		}
-		verifiers = append(verifiers, verifier)
+		verifiers = append(verifiers, referenceVerifier)
 	}
 
 	return verifiers, nil
@@ -104,6 +104,93 @@ func TestCreateVerifiersFromConfig_BuiltInVerifiers_ReturnsExpected(t *testing.T
 	}
 }
 
+func TestCreateVerifierFromConfig_Enabled(t *testing.T) {
+	builtInVerifiers = map[string]VerifierFactory{
+		"test-verifier": &TestVerifierFactory{},
+	}
+	defer verifier.SetEnabled("test-verifier-0", true)
+
+	if _, err := CreateVerifierFromConfig(config.VerifierConfig{"name": "test-verifier-0", "type": "test-verifier", "enabled": false}, "", []string{"test/dir"}, constants.EmptyNamespace); err != nil {
+		t.Fatalf("create verifier failed with err %v", err)
+	}
+	if verifier.IsEnabled("test-verifier-0") {
+		t.Fatalf("expected the verifier to be disabled by its config")
+	}
+
+	// reloading the config without the flag enables the verifier again
+	if _, err := CreateVerifierFromConfig(config.VerifierConfig{"name": "test-verifier-0", "type": "test-verifier"}, "", []string{"test/dir"}, constants.EmptyNamespace); err != nil {
+		t.Fatalf("create verifier failed with err %v", err)
+	}
+	if !verifier.IsEnabled("test-verifier-0") {
+		t.Fatalf("expected the verifier to be enabled by default")
+	}
+
+	if _, err := CreateVerifierFromConfig(config.VerifierConfig{"name": "test-verifier-0", "type": "test-verifier", "enabled": "no"}, "", []string{"test/dir"}, constants.EmptyNamespace); err == nil {
+		t.Fatalf("expected an error for a non-boolean enabled field")
+	}
+}
+
+func TestCreateVerifierFromConfig_ConfigHash(t *testing.T) {
+	builtInVerifiers = map[string]VerifierFactory{
+		"test-verifier": &TestVerifierFactory{},
+	}
+	configHash := func(verifierConfig config.VerifierConfig) string {
+		t.Helper()
+		created, err := CreateVerifierFromConfig(verifierConfig, "1.0.0", []string{"test/dir"}, constants.EmptyNamespace)
+		if err != nil {
+			t.Fatalf("create verifier failed with err %v", err)
+		}
+		defer verifier.DeleteConfigHash(created)
+		hash := verifier.ConfigHash(created)
+		if hash == "" {
+			t.Fatalf("expected the config hash of the verifier to be recorded")
+		}
+		return hash
+	}
+
+	original := configHash(config.VerifierConfig{"name": "test-verifier-0", "type": "test-verifier", "trustPolicy": "a"})
+	if reloaded := configHash(config.VerifierConfig{"type": "test-verifier", "trustPolicy": "a", "name": "test-verifier-0"}); reloaded != original {
+		t.Fatalf("expected the same config to have the same hash, got %s and %s", original, reloaded)
+	}
+	if changed := configHash(config.VerifierConfig{"name": "test-verifier-0", "type": "test-verifier", "trustPolicy": "b"}); changed == original {
+		t.Fatalf("expected a changed config to have a different hash")
+	}
+}
+
+// recordingVerifierFactory records the verifiers it creates.
+type recordingVerifierFactory struct {
+	created []verifier.ReferenceVerifier
+}
+
+func (f *recordingVerifierFactory) Create(_ string, _ config.VerifierConfig, pluginDirectory string, _ string) (verifier.ReferenceVerifier, error) {
+	created := &TestVerifier{verifierDirectory: pluginDirectory}
+	f.created = append(f.created, created)
+	return created, nil
+}
+
+func TestCreateVerifiersFromConfig_Failure_DeletesConfigHashes(t *testing.T) {
+	factory := &recordingVerifierFactory{}
+	builtInVerifiers = map[string]VerifierFactory{
+		"test-verifier": factory,
+	}
+	verifiersConfig := config.VerifiersConfig{
+		Verifiers: []config.VerifierConfig{
+			{"name": "test-verifier-0", "type": "test-verifier"},
+			{"name": "test-verifier-1", "type": "test-verifier", "enabled": "no"},
+		},
+	}
+
+	if _, err := CreateVerifiersFromConfig(verifiersConfig, "test/dir", constants.EmptyNamespace); err == nil {
+		t.Fatalf("expected an error for the invalid second verifier")
+	}
+	if len(factory.created) != 1 {
+		t.Fatalf("expected the first verifier to be created, got %d verifiers", len(factory.created))
+	}
+	if hash := verifier.ConfigHash(factory.created[0]); hash != "" {
+		t.Fatalf("expected the config hash of the discarded verifier to be deleted, got %s", hash)
+	}
+}
+
 func TestCreateVerifiersFromConfig_InvalidConfig_ReturnsErr(t *testing.T) {
 	verifierConfig := map[string]interface{}{
 		"name": "test-verifier-0",
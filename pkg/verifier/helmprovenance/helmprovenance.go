@@ -0,0 +1,259 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmprovenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/opencontainers/go-digest"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	commonutils "github.com/ratify-project/ratify/pkg/common/utils"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/config"
+	"github.com/ratify-project/ratify/pkg/verifier/factory"
+	"github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+const (
+	verifierType string = "helmprovenance"
+	// ProvenanceArtifactType is the artifact type of Helm chart provenance
+	// files, which is also the media type of the provenance layer.
+	ProvenanceArtifactType string = "application/vnd.cncf.helm.chart.provenance.v1.prov"
+	// ChartContentMediaType is the media type of the chart archive layer of
+	// Helm charts stored in OCI registries.
+	ChartContentMediaType string = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+)
+
+// PluginConfig is the configuration of the Helm provenance verifier.
+type PluginConfig struct {
+	Name          string `json:"name"`
+	Type          string `json:"type,omitempty"`
+	ArtifactTypes string `json:"artifactTypes,omitempty"`
+	// KeyManagementProviders are the names of the key management providers
+	// supplying the PGP public keyrings trusted to sign chart provenance.
+	KeyManagementProviders []string `json:"keyManagementProviders"`
+	NestedReferences       []string `json:"nestedArtifactTypes,omitempty"`
+}
+
+// Extension is the structure for the verifier result extensions.
+type Extension struct {
+	Signer      string `json:"signer,omitempty"`
+	KeyID       string `json:"keyId,omitempty"`
+	ChartName   string `json:"chartName,omitempty"`
+	Version     string `json:"version,omitempty"`
+	ChartDigest string `json:"chartDigest,omitempty"`
+}
+
+type helmProvenanceVerifier struct {
+	name                   string
+	verifierType           string
+	artifactTypes          []string
+	nestedReferences       []string
+	keyManagementProviders []string
+}
+
+type helmProvenanceVerifierFactory struct{}
+
+var logOpt = logger.Option{
+	ComponentType: logger.Verifier,
+}
+
+// init() registers the Helm provenance verifier with the factory
+func init() {
+	factory.Register(verifierType, &helmProvenanceVerifierFactory{})
+}
+
+// Create creates a new Helm provenance verifier
+func (f *helmProvenanceVerifierFactory) Create(_ string, verifierConfig config.VerifierConfig, _ string, namespace string) (verifier.ReferenceVerifier, error) {
+	logger.GetLogger(context.Background(), logOpt).Debugf("creating helm provenance verifier with config %v, namespace '%v'", verifierConfig, namespace)
+	conf, err := parseVerifierConfig(verifierConfig)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail("Failed to create the Helm Provenance Verifier").WithError(err)
+	}
+
+	return &helmProvenanceVerifier{
+		name:                   conf.Name,
+		verifierType:           conf.Type,
+		artifactTypes:          strings.Split(conf.ArtifactTypes, ","),
+		nestedReferences:       conf.NestedReferences,
+		keyManagementProviders: conf.KeyManagementProviders,
+	}, nil
+}
+
+// Name returns the name of the verifier
+func (v *helmProvenanceVerifier) Name() string {
+	return v.name
+}
+
+// Type returns the type of the verifier
+func (v *helmProvenanceVerifier) Type() string {
+	return v.verifierType
+}
+
+// CanVerify returns true if the artifact type of the reference is in the list
+// of artifact types supported by the verifier
+func (v *helmProvenanceVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	for _, at := range v.artifactTypes {
+		if verifier.MatchArtifactType(at, referenceDescriptor.ArtifactType) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetNestedReferences returns the nested artifact types to verify
+func (v *helmProvenanceVerifier) GetNestedReferences() []string {
+	return v.nestedReferences
+}
+
+// Verify checks that the provenance file is signed by a key of the configured
+// keyrings and that it records the digest of the chart archive of the subject.
+func (v *helmProvenanceVerifier) Verify(ctx context.Context, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	keyring, err := v.getKeyring(ctx)
+	if err != nil {
+		return v.errorToVerifyResult(err), nil
+	}
+
+	provenance, err := fetchProvenance(ctx, subjectReference, referenceDescriptor, referrerStore)
+	if err != nil {
+		return v.errorToVerifyResult(err), nil
+	}
+	signed, err := verifyProvenance(provenance, keyring)
+	if err != nil {
+		return v.errorToVerifyResult(err), nil
+	}
+
+	chartDigest, err := fetchChartDigest(ctx, subjectReference, referrerStore)
+	if err != nil {
+		return v.errorToVerifyResult(err), nil
+	}
+	extension := Extension{
+		Signer:      signed.signer,
+		KeyID:       signed.keyID,
+		ChartName:   signed.chart.Name,
+		Version:     signed.chart.Version,
+		ChartDigest: chartDigest.String(),
+	}
+	if !signed.hasDigest(chartDigest) {
+		verifierErr := re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("The provenance file does not record the digest %s of the chart archive, it records %s", chartDigest, strings.Join(signed.digests(), ", ")))
+		return verifier.NewVerifierResult("", v.name, v.verifierType, "", false, &verifierErr, extension), nil
+	}
+
+	return verifier.NewVerifierResult("", v.name, v.verifierType, "Helm chart provenance verification success.", true, nil, extension), nil
+}
+
+// getKeyring returns the PGP entities of the configured key management
+// providers.
+func (v *helmProvenanceVerifier) getKeyring(ctx context.Context) (openpgp.EntityList, error) {
+	var keyring openpgp.EntityList
+	for _, provider := range v.keyManagementProviders {
+		keys, err := keymanagementprovider.GetKeysFromMap(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]keymanagementprovider.KMPMapKey, 0, len(keys))
+		for key := range keys {
+			names = append(names, key)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return names[i].Name < names[j].Name
+		})
+		for _, name := range names {
+			if entity, ok := keys[name].Key.(*openpgp.Entity); ok {
+				keyring = append(keyring, entity)
+			}
+		}
+	}
+	if len(keyring) == 0 {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("No PGP keys found in the key management providers %s", strings.Join(v.keyManagementProviders, ", "))).WithRemediation("Configure a key management provider of content type pgpKeyring with the keys trusted to sign the charts.")
+	}
+	return keyring, nil
+}
+
+// fetchProvenance returns the content of the provenance file of the referrer.
+func fetchProvenance(ctx context.Context, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) ([]byte, error) {
+	manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, referenceDescriptor)
+	if err != nil {
+		return nil, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Failed to fetch the manifest of the provenance %s", referenceDescriptor.Digest)).WithError(err)
+	}
+
+	for _, blob := range manifest.Blobs {
+		if blob.MediaType == ProvenanceArtifactType || len(manifest.Blobs) == 1 {
+			content, err := referrerStore.GetBlobContent(ctx, subjectReference, blob.Digest)
+			if err != nil {
+				return nil, re.ErrorCodeGetBlobContentFailure.WithDetail(fmt.Sprintf("Failed to fetch the provenance file %s", blob.Digest)).WithError(err)
+			}
+			return content, nil
+		}
+	}
+	return nil, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("The artifact %s has no layer of media type %s", referenceDescriptor.Digest, ProvenanceArtifactType))
+}
+
+// fetchChartDigest returns the digest of the chart archive layer of the
+// subject.
+func fetchChartDigest(ctx context.Context, subjectReference common.Reference, referrerStore referrerstore.ReferrerStore) (digest.Digest, error) {
+	subjectDesc, err := referrerStore.GetSubjectDescriptor(ctx, subjectReference)
+	if err != nil {
+		return "", re.ErrorCodeGetSubjectDescriptorFailure.WithDetail(fmt.Sprintf("Failed to resolve the subject %s", subjectReference)).WithError(err)
+	}
+	manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, ocispecs.ReferenceDescriptor{Descriptor: subjectDesc.Descriptor})
+	if err != nil {
+		return "", re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Failed to fetch the manifest of the subject %s", subjectReference)).WithError(err)
+	}
+	for _, blob := range manifest.Blobs {
+		if blob.MediaType == ChartContentMediaType {
+			return blob.Digest, nil
+		}
+	}
+	return "", re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("The subject %s is not a Helm chart, it has no layer of media type %s", subjectReference, ChartContentMediaType))
+}
+
+func (v *helmProvenanceVerifier) errorToVerifyResult(err error) verifier.VerifierResult {
+	verifierErr := re.ErrorCodeVerifyReferenceFailure.WithDetail("Failed to validate the Helm chart provenance").WithError(err)
+	return verifier.NewVerifierResult("", v.name, v.verifierType, "", false, &verifierErr, nil)
+}
+
+// parseVerifierConfig parses the verifier config and returns a PluginConfig
+func parseVerifierConfig(verifierConfig config.VerifierConfig) (*PluginConfig, error) {
+	verifierName, hasName := verifierConfig[types.Name].(string)
+	if !hasName {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, "", re.EmptyLink, nil, "missing name in verifier config", re.HideStackTrace)
+	}
+	conf := PluginConfig{}
+	if err := commonutils.DecodeConfig(verifierConfig, &conf); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, verifierName, re.EmptyLink, err, fmt.Sprintf("failed to unmarshal to helm provenance verifier config from: %+v.", verifierConfig), re.HideStackTrace)
+	}
+
+	if conf.Type == "" {
+		conf.Type = verifierType
+	}
+	if conf.ArtifactTypes == "" {
+		conf.ArtifactTypes = ProvenanceArtifactType
+	}
+	if len(conf.KeyManagementProviders) == 0 {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, verifierName, re.EmptyLink, nil, "missing keyManagementProviders in verifier config", re.HideStackTrace)
+	}
+	return &conf, nil
+}
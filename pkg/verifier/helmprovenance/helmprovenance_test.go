@@ -0,0 +1,194 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmprovenance
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier/config"
+)
+
+const testKMP = "helm-keys"
+
+var (
+	subjectDigest    = digest.FromString("chart manifest")
+	chartDigest      = digest.FromString("chart archive")
+	provenanceDigest = digest.FromString("provenance manifest")
+	provenanceBlob   = digest.FromString("provenance")
+)
+
+func newTestEntity(t *testing.T, name string) *openpgp.Entity {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return entity
+}
+
+func signProvenance(t *testing.T, signer *openpgp.Entity, recordedDigest digest.Digest) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := clearsign.Encode(&buf, signer.PrivateKey, nil)
+	if err != nil {
+		t.Fatalf("failed to sign provenance: %v", err)
+	}
+	message := fmt.Sprintf("apiVersion: v2\nname: mychart\nversion: 0.1.0\n\n...\nfiles:\n  mychart-0.1.0.tgz: %s\n", recordedDigest)
+	if _, err := w.Write([]byte(message)); err != nil {
+		t.Fatalf("failed to sign provenance: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to sign provenance: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func newTestStore(provenance []byte) *mocks.MemoryTestStore {
+	return &mocks.MemoryTestStore{
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			subjectDigest: {Descriptor: oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: subjectDigest}},
+		},
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+			subjectDigest: {
+				MediaType: oci.MediaTypeImageManifest,
+				Blobs:     []oci.Descriptor{{MediaType: ChartContentMediaType, Digest: chartDigest}},
+			},
+			provenanceDigest: {
+				MediaType:    oci.MediaTypeImageManifest,
+				ArtifactType: ProvenanceArtifactType,
+				Blobs:        []oci.Descriptor{{MediaType: ProvenanceArtifactType, Digest: provenanceBlob}},
+			},
+		},
+		Blobs: map[digest.Digest][]byte{
+			provenanceBlob: provenance,
+		},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	trusted := newTestEntity(t, "trusted")
+	untrusted := newTestEntity(t, "untrusted")
+	keymanagementprovider.SaveSecrets(testKMP, "inline", map[keymanagementprovider.KMPMapKey]crypto.PublicKey{
+		{Name: fmt.Sprintf("%X", trusted.PrimaryKey.Fingerprint)}: trusted,
+	}, nil)
+	defer keymanagementprovider.DeleteResourceFromMap(testKMP)
+
+	tampered := bytes.Replace(signProvenance(t, trusted, chartDigest), []byte("version: 0.1.0"), []byte("version: 0.2.0"), 1)
+	tests := []struct {
+		name          string
+		provenance    []byte
+		expectSuccess bool
+		expectError   string
+	}{
+		{
+			name:          "valid provenance",
+			provenance:    signProvenance(t, trusted, chartDigest),
+			expectSuccess: true,
+		},
+		{
+			name:        "signed by untrusted key",
+			provenance:  signProvenance(t, untrusted, chartDigest),
+			expectError: "failed to verify the provenance signature",
+		},
+		{
+			name:        "tampered provenance",
+			provenance:  tampered,
+			expectError: "failed to verify the provenance signature",
+		},
+		{
+			name:        "digest mismatch",
+			provenance:  signProvenance(t, trusted, digest.FromString("other chart")),
+			expectError: "does not record the digest " + chartDigest.String(),
+		},
+		{
+			name:        "not clearsigned",
+			provenance:  []byte("files:\n  mychart-0.1.0.tgz: " + chartDigest.String()),
+			expectError: "not a PGP clearsigned message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := (&helmProvenanceVerifierFactory{}).Create("", config.VerifierConfig{
+				"name":                   "helm",
+				"type":                   verifierType,
+				"keyManagementProviders": []string{testKMP},
+			}, "", "")
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+			subjectRef := common.Reference{Original: "localhost:5000/mychart@" + subjectDigest.String(), Digest: subjectDigest}
+			referenceDesc := ocispecs.ReferenceDescriptor{Descriptor: oci.Descriptor{Digest: provenanceDigest}, ArtifactType: ProvenanceArtifactType}
+			if !v.CanVerify(context.Background(), referenceDesc) {
+				t.Fatalf("expected verifier to verify artifact type %s", ProvenanceArtifactType)
+			}
+
+			result, err := v.Verify(context.Background(), subjectRef, referenceDesc, newTestStore(tt.provenance))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tt.expectSuccess {
+				t.Fatalf("expected success %v, got %v: %s", tt.expectSuccess, result.IsSuccess, result.ErrorReason)
+			}
+			if tt.expectSuccess {
+				extension := result.Extensions.(Extension)
+				if extension.ChartName != "mychart" || extension.Version != "0.1.0" || extension.ChartDigest != chartDigest.String() {
+					t.Fatalf("unexpected extensions %+v", extension)
+				}
+				return
+			}
+			if !strings.Contains(result.ErrorReason+result.Message, tt.expectError) {
+				t.Fatalf("expected error containing %q, got reason %q, message %q", tt.expectError, result.ErrorReason, result.Message)
+			}
+		})
+	}
+}
+
+func TestVerify_NoKeys(t *testing.T) {
+	v, err := (&helmProvenanceVerifierFactory{}).Create("", config.VerifierConfig{
+		"name":                   "helm",
+		"keyManagementProviders": []string{"missing"},
+	}, "", "")
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	result, err := v.Verify(context.Background(), common.Reference{Digest: subjectDigest}, ocispecs.ReferenceDescriptor{Descriptor: oci.Descriptor{Digest: provenanceDigest}}, newTestStore(nil))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.IsSuccess {
+		t.Fatalf("expected verification to fail without PGP keys")
+	}
+}
+
+func TestCreate_MissingKeyManagementProviders(t *testing.T) {
+	if _, err := (&helmProvenanceVerifierFactory{}).Create("", config.VerifierConfig{"name": "helm"}, "", ""); err == nil {
+		t.Fatalf("expected error for missing keyManagementProviders")
+	}
+}
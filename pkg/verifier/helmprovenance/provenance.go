@@ -0,0 +1,106 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmprovenance
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/opencontainers/go-digest"
+	"gopkg.in/yaml.v2"
+)
+
+// messageBlockSeparator separates the chart metadata from the file digests in
+// the signed message of a provenance file.
+var messageBlockSeparator = []byte("\n...\n")
+
+// chartMetadata is the subset of Chart.yaml recorded in a provenance file.
+type chartMetadata struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+// sumCollection lists the digests of the files signed by a provenance file,
+// keyed by file name, e.g. mychart-0.1.0.tgz: sha256:...
+type sumCollection struct {
+	Files map[string]string `yaml:"files"`
+}
+
+// signedProvenance is the content of a provenance file whose signature was
+// verified.
+type signedProvenance struct {
+	signer string
+	keyID  string
+	chart  chartMetadata
+	sums   sumCollection
+}
+
+// verifyProvenance checks the clearsigned provenance file is signed by a key of
+// the keyring and returns its signed content.
+func verifyProvenance(provenance []byte, keyring openpgp.EntityList) (*signedProvenance, error) {
+	block, _ := clearsign.Decode(provenance)
+	if block == nil {
+		return nil, fmt.Errorf("provenance file is not a PGP clearsigned message")
+	}
+	signer, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the provenance signature: %w", err)
+	}
+
+	parts := bytes.Split(block.Plaintext, messageBlockSeparator)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("provenance message must have the chart metadata and the file digests")
+	}
+	signed := &signedProvenance{
+		keyID: fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint),
+	}
+	if identity := signer.PrimaryIdentity(); identity != nil {
+		signed.signer = identity.Name
+	}
+	if err := yaml.Unmarshal(parts[0], &signed.chart); err != nil {
+		return nil, fmt.Errorf("failed to parse the chart metadata of the provenance: %w", err)
+	}
+	if err := yaml.Unmarshal(parts[1], &signed.sums); err != nil {
+		return nil, fmt.Errorf("failed to parse the file digests of the provenance: %w", err)
+	}
+	if len(signed.sums.Files) == 0 {
+		return nil, fmt.Errorf("provenance does not record any file digest")
+	}
+	return signed, nil
+}
+
+// hasDigest returns true if the provenance records the digest for any file.
+func (p *signedProvenance) hasDigest(d digest.Digest) bool {
+	for _, sum := range p.sums.Files {
+		if sum == d.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// digests returns the sorted file digests recorded by the provenance.
+func (p *signedProvenance) digests() []string {
+	digests := make([]string, 0, len(p.sums.Files))
+	for _, sum := range p.sums.Files {
+		digests = append(digests, sum)
+	}
+	sort.Strings(digests)
+	return digests
+}
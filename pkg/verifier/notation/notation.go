@@ -17,27 +17,34 @@ package notation
 
 import (
 	"context"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"fmt"
 	paths "path/filepath"
 	"strings"
+	"time"
 
 	ratifyconfig "github.com/ratify-project/ratify/config"
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/common"
+	commonutils "github.com/ratify-project/ratify/pkg/common/utils"
 	"github.com/ratify-project/ratify/pkg/homedir"
+	"github.com/ratify-project/ratify/pkg/metrics"
 
 	"github.com/notaryproject/notation-go/log"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/utils"
 	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/pkg/verifier/config"
 	"github.com/ratify-project/ratify/pkg/verifier/factory"
 	"github.com/ratify-project/ratify/pkg/verifier/types"
+	verifierutils "github.com/ratify-project/ratify/pkg/verifier/utils"
 
-	_ "github.com/notaryproject/notation-core-go/signature/cose" // register COSE signature
-	_ "github.com/notaryproject/notation-core-go/signature/jws"  // register JWS signature
+	sig "github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
 	"github.com/notaryproject/notation-go"
 	notationVerifier "github.com/notaryproject/notation-go/verifier"
 	"github.com/notaryproject/notation-go/verifier/trustpolicy"
@@ -53,6 +60,14 @@ const (
 	trustStoreTypeTSA              = string(truststore.TypeTSA)
 )
 
+// envelopeFormats maps the media types of the supported signature envelopes to
+// the name of their format. The envelope is parsed according to the media type
+// of the signature blob.
+var envelopeFormats = map[string]string{
+	jws.MediaTypeEnvelope:  "jws",
+	cose.MediaTypeEnvelope: "cose",
+}
+
 // NotationPluginVerifierConfig describes the configuration of notation verifier
 type NotationPluginVerifierConfig struct { //nolint:revive // ignore linter to have unique type name
 	Name          string `json:"name"`
@@ -77,6 +92,20 @@ type NotationPluginVerifierConfig struct { //nolint:revive // ignore linter to h
 	VerificationCertStores verificationCertStores `json:"verificationCertStores"`
 	// TrustPolicyDoc represents a trustpolicy.json document. Reference: https://pkg.go.dev/github.com/notaryproject/notation-go@v0.12.0-beta.1.0.20221125022016-ab113ebd2a6c/verifier/trustpolicy#Document
 	TrustPolicyDoc trustpolicy.Document `json:"trustPolicyDoc"`
+	// VerifySigningTime fails the verification if the signing time claim of
+	// the signature is outside the validity window of the signing certificate.
+	VerifySigningTime bool `json:"verifySigningTime,omitempty"`
+	// TrustedIdentityMatching normalizes the distinguished names compared
+	// when matching the trusted identities of the trust policies.
+	TrustedIdentityMatching TrustedIdentityMatching `json:"trustedIdentityMatching,omitempty"`
+	// AllowedSignatureAlgorithms are the key and digest algorithms the
+	// signature and the certificate chain may use, e.g. RSA-3072, ECDSA-P256
+	// or SHA-256. Any algorithm supported by notation is allowed if empty.
+	AllowedSignatureAlgorithms []string `json:"allowedSignatureAlgorithms,omitempty"`
+	// CertificateExpiryWarning is a duration, e.g. 720h. A successful
+	// verification is reported as a warning if a certificate of the signing
+	// chain expires within it. Disabled if empty.
+	CertificateExpiryWarning string `json:"certificateExpiryWarning,omitempty"`
 }
 
 type notationPluginVerifier struct {
@@ -84,6 +113,13 @@ type notationPluginVerifier struct {
 	verifierType     string
 	artifactTypes    []string
 	notationVerifier *notation.Verifier
+	trustPolicyDoc   *trustpolicy.Document
+	// trustedIdentities are the trusted identities matched by Ratify, keyed by
+	// trust policy name.
+	trustedIdentities map[string][]trustedIdentity
+	verifySigningTime bool
+	algorithmPolicy   *verifierutils.AlgorithmPolicy
+	certExpiryWarning time.Duration
 }
 
 type notationPluginVerifierFactory struct{}
@@ -104,6 +140,24 @@ func (f *notationPluginVerifierFactory) Create(_ string, verifierConfig config.V
 		return nil, re.ErrorCodePluginInitFailure.WithDetail("Failed to create the Notation Verifier").WithError(err)
 	}
 
+	trustedIdentities, err := extractTrustedIdentityPatterns(&conf.TrustPolicyDoc, conf.TrustedIdentityMatching)
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.WithDetail("Failed to create the Notation Verifier").WithError(err)
+	}
+
+	algorithmPolicy, err := verifierutils.NewAlgorithmPolicy(conf.AllowedSignatureAlgorithms)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail("Failed to create the Notation Verifier").WithError(err)
+	}
+
+	var certExpiryWarning time.Duration
+	if conf.CertificateExpiryWarning != "" {
+		certExpiryWarning, err = time.ParseDuration(conf.CertificateExpiryWarning)
+		if err != nil || certExpiryWarning < 0 {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid certificateExpiryWarning %q of the Notation Verifier, expected a positive duration such as 720h", conf.CertificateExpiryWarning)).WithError(err)
+		}
+	}
+
 	verifyService, err := getVerifierService(conf, pluginDirectory)
 	if err != nil {
 		return nil, re.ErrorCodePluginInitFailure.WithDetail("Failed to create the Notation Verifier").WithError(err)
@@ -111,10 +165,15 @@ func (f *notationPluginVerifierFactory) Create(_ string, verifierConfig config.V
 
 	artifactTypes := strings.Split(conf.ArtifactTypes, ",")
 	return &notationPluginVerifier{
-		name:             verifierName,
-		verifierType:     verifierTypeStr,
-		artifactTypes:    artifactTypes,
-		notationVerifier: &verifyService,
+		name:              verifierName,
+		verifierType:      verifierTypeStr,
+		artifactTypes:     artifactTypes,
+		notationVerifier:  &verifyService,
+		trustPolicyDoc:    &conf.TrustPolicyDoc,
+		trustedIdentities: trustedIdentities,
+		verifySigningTime: conf.VerifySigningTime,
+		algorithmPolicy:   algorithmPolicy,
+		certExpiryWarning: certExpiryWarning,
 	}, nil
 }
 
@@ -128,7 +187,7 @@ func (v *notationPluginVerifier) Type() string {
 
 func (v *notationPluginVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
 	for _, at := range v.artifactTypes {
-		if at == "*" || at == referenceDescriptor.ArtifactType {
+		if verifier.MatchArtifactType(at, referenceDescriptor.ArtifactType) {
 			return true
 		}
 	}
@@ -143,29 +202,36 @@ func (v *notationPluginVerifier) Verify(ctx context.Context,
 
 	subjectDesc, err := store.GetSubjectDescriptor(ctx, subjectReference)
 	if err != nil {
-		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the Notation signature of the artifact: %+v", subjectReference)).WithError(err)
+		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the Notation signature of the artifact: %+v", subjectReference)).WithError(err).WithRemediation(fmt.Sprintf("Please ensure the artifact [%s] exists and Ratify is authorized to pull it from the registry.", subjectReference.Original))
 	}
 
 	referenceManifest, err := store.GetReferenceManifest(ctx, subjectReference, referenceDescriptor)
 	if err != nil {
-		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the Notation signature: %+v", referenceDescriptor)).WithError(err)
+		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the Notation signature: %+v", referenceDescriptor)).WithError(err).WithRemediation(fmt.Sprintf("Please ensure the signature [%s@%s] exists and Ratify is authorized to pull it from the registry.", subjectReference.Path, referenceDescriptor.Digest.String()))
 	}
 
 	if len(referenceManifest.Blobs) != 1 {
 		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Notation signature manifest requires exactly one signature envelope blob, got %d", len(referenceManifest.Blobs))).WithRemediation(fmt.Sprintf("Please inspect the artifact [%s@%s] is correctly signed by Notation signer", subjectReference.Path, referenceDescriptor.Digest.String()))
 	}
 	blobDesc := referenceManifest.Blobs[0]
+	envelopeFormat, ok := envelopeFormats[blobDesc.MediaType]
+	if !ok {
+		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Notation signature envelope of media type %s is not supported, expected %s or %s", blobDesc.MediaType, jws.MediaTypeEnvelope, cose.MediaTypeEnvelope)).WithRemediation(fmt.Sprintf("Please inspect the artifact [%s@%s] is correctly signed by Notation signer", subjectReference.Path, referenceDescriptor.Digest.String()))
+	}
+	extensions["EnvelopeFormat"] = envelopeFormat
 	refBlob, err := store.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
 	if err != nil {
-		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the Notation signature of the artifact: %+v", subjectReference)).WithError(err)
+		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the Notation signature of the artifact: %+v", subjectReference)).WithError(err).WithRemediation(fmt.Sprintf("Please ensure the signature [%s@%s] exists and Ratify is authorized to pull it from the registry.", subjectReference.Path, referenceDescriptor.Digest.String()))
 	}
 
 	// TODO: notation verify API only accepts digested reference now.
 	// Pass in tagged reference instead once notation-go supports it.
-	subjectRef := fmt.Sprintf("%s@%s", subjectReference.Path, subjectReference.Digest.String())
+	// subjects served from a pull-through cache are scoped by their upstream
+	// registry
+	subjectRef := utils.UpstreamReference(ctx, fmt.Sprintf("%s@%s", subjectReference.Path, subjectReference.Digest.String()))
 	outcome, err := v.verifySignature(ctx, subjectRef, blobDesc.MediaType, subjectDesc.Descriptor, refBlob)
 	if err != nil {
-		return verifier.VerifierResult{IsSuccess: false, Extensions: extensions}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the Notation signature: %+v", referenceDescriptor)).WithError(err)
+		return verifier.VerifierResult{IsSuccess: false, Extensions: extensions}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the Notation signature: %+v", referenceDescriptor)).WithError(err).WithRemediation(fmt.Sprintf("Please sign the artifact [%s] with notation using a certificate trusted by the trust policy that applies to it, and ensure the signature is not expired or revoked.", subjectRef))
 	}
 
 	// Note: notation verifier already validates certificate chain is not empty.
@@ -173,7 +239,36 @@ func (v *notationPluginVerifier) Verify(ctx context.Context,
 	extensions["Issuer"] = cert.Issuer.String()
 	extensions["SN"] = cert.Subject.String()
 
-	return verifier.NewVerifierResult("", v.name, v.verifierType, "Notation signature verification success", true, nil, extensions), nil
+	if err := v.verifyTrustedIdentity(subjectRef, cert.Subject); err != nil {
+		return verifier.VerifierResult{IsSuccess: false, Extensions: extensions}, err
+	}
+
+	if err := v.checkAlgorithms(outcome.EnvelopeContent.SignerInfo); err != nil {
+		return verifier.VerifierResult{IsSuccess: false, Extensions: extensions}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("The Notation signature uses a disallowed algorithm: %+v", referenceDescriptor)).WithError(err).WithRemediation("Please sign the artifact with a key and certificate chain using the allowed signature algorithms.")
+	}
+
+	if v.verifySigningTime {
+		signingTime := outcome.EnvelopeContent.SignerInfo.SignedAttributes.SigningTime
+		extensions["SigningTime"] = signingTime.UTC().Format(time.RFC3339)
+		if err := verifierutils.CheckSigningTime(signingTime, cert); err != nil {
+			return verifier.VerifierResult{IsSuccess: false, Extensions: extensions}, re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to validate the signing time of the Notation signature: %+v", referenceDescriptor)).WithError(err).WithRemediation("Please ensure the artifact is signed while the signing certificate is valid.")
+		}
+	}
+
+	result := verifier.NewVerifierResult("", v.name, v.verifierType, "Notation signature verification success", true, nil, extensions)
+	signerInfo := outcome.EnvelopeContent.SignerInfo
+	result.ValidUntil = validUntil(outcome)
+	if v.certExpiryWarning > 0 {
+		if expiring := verifierutils.ExpiringCertificate(signerInfo.CertificateChain, v.certExpiryWarning, time.Now()); expiring != nil {
+			expiresAt := expiring.NotAfter.UTC().Format(time.RFC3339)
+			extensions["ExpiringCertificate"] = expiring.Subject.String()
+			extensions["CertificateExpiresAt"] = expiresAt
+			result.Level = verifier.LevelWarn
+			result.Message = fmt.Sprintf("Notation signature verification success, but certificate [%s] expires at %s", expiring.Subject.String(), expiresAt)
+			metrics.ReportCertificateExpiryWarning(ctx, v.name)
+		}
+	}
+	return result, nil
 }
 
 func getVerifierService(conf *NotationPluginVerifierConfig, pluginDirectory string) (notation.Verifier, error) {
@@ -198,15 +293,75 @@ func (v *notationPluginVerifier) verifySignature(ctx context.Context, subjectRef
 	return (*v.notationVerifier).Verify(ctx, subjectDesc, refBlob, opts)
 }
 
-func parseVerifierConfig(verifierConfig config.VerifierConfig, _ string) (*NotationPluginVerifierConfig, error) {
-	conf := &NotationPluginVerifierConfig{}
+// validUntil returns the earliest expiry of the signature and of the
+// certificate chain it relies on. A verified timestamp countersignature proves
+// the signature was made while the signing certificate was valid, so that
+// only the expiry of the root from the trust store limits the result then.
+func validUntil(outcome *notation.VerificationOutcome) *time.Time {
+	signerInfo := outcome.EnvelopeContent.SignerInfo
+	certs := signerInfo.CertificateChain
+	if len(certs) > 0 && timestampVerified(outcome) {
+		certs = certs[len(certs)-1:]
+	}
+	return verifierutils.EarliestExpiry(append(verifierutils.CertificateChainExpiries(certs), signerInfo.SignedAttributes.Expiry)...)
+}
+
+// timestampVerified reports whether the signature carries a timestamp
+// countersignature that was verified against the trust policy.
+func timestampVerified(outcome *notation.VerificationOutcome) bool {
+	if len(outcome.EnvelopeContent.SignerInfo.UnsignedAttributes.TimestampSignature) == 0 {
+		return false
+	}
+	for _, result := range outcome.VerificationResults {
+		if result != nil && result.Type == trustpolicy.TypeAuthenticTimestamp {
+			return result.Action != trustpolicy.ActionSkip && result.Error == nil
+		}
+	}
+	return false
+}
 
-	verifierConfigBytes, err := json.Marshal(verifierConfig)
+// checkAlgorithms returns an error if the signature or its certificate chain
+// uses an algorithm that is not allowed.
+func (v *notationPluginVerifier) checkAlgorithms(signerInfo sig.SignerInfo) error {
+	if v.algorithmPolicy == nil {
+		return nil
+	}
+	if err := v.algorithmPolicy.CheckDigest(signerInfo.SignatureAlgorithm.Hash()); err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+	return v.algorithmPolicy.CheckCertificates(signerInfo.CertificateChain)
+}
+
+// verifyTrustedIdentity matches the signing certificate subject against the
+// trusted identities of the applicable trust policy if they contain regex
+// patterns. Other trusted identities are already validated by notation.
+func (v *notationPluginVerifier) verifyTrustedIdentity(subjectRef string, subject pkix.Name) error {
+	if len(v.trustedIdentities) == 0 {
+		return nil
+	}
+	policy, err := v.trustPolicyDoc.GetApplicableTrustPolicy(subjectRef)
 	if err != nil {
-		return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Failed to parse the Notation Verifier configuration: %+v", verifierConfig)).WithError(err)
+		return re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Failed to find the applicable trust policy for the artifact: %s", subjectRef)).WithError(err)
 	}
+	identities, ok := v.trustedIdentities[policy.Name]
+	if !ok {
+		return nil
+	}
+	if _, err := subjectAttributes(subject); err != nil {
+		return re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Signing certificate subject %q cannot be matched against the trusted identities of trust policy %s", subject.String(), policy.Name)).WithError(err)
+	}
+	for _, identity := range identities {
+		if identity.matches(subject) {
+			return nil
+		}
+	}
+	return re.ErrorCodeVerifyReferenceFailure.WithDetail(fmt.Sprintf("Signing certificate subject %q does not match any trusted identity of trust policy %s", subject.String(), policy.Name)).WithRemediation("Please ensure the artifact is signed by a trusted identity of the trust policy.")
+}
+
+func parseVerifierConfig(verifierConfig config.VerifierConfig, _ string) (*NotationPluginVerifierConfig, error) {
+	conf := &NotationPluginVerifierConfig{}
 
-	if err := json.Unmarshal(verifierConfigBytes, &conf); err != nil {
+	if err := commonutils.DecodeConfig(verifierConfig, conf); err != nil {
 		return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Failed to parse the Notation Verifier configuration: %+v", verifierConfig)).WithError(err)
 	}
 
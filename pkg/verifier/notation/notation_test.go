@@ -15,24 +15,40 @@ package notation
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"math/big"
+	"os"
 	paths "path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	sig "github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
 	"github.com/notaryproject/notation-go"
+	notationsigner "github.com/notaryproject/notation-go/signer"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	ratifyconfig "github.com/ratify-project/ratify/config"
+	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/pkg/common"
 	"github.com/ratify-project/ratify/pkg/homedir"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	"github.com/ratify-project/ratify/pkg/referrerstore/config"
 	"github.com/ratify-project/ratify/pkg/verifier"
+	verifierutils "github.com/ratify-project/ratify/pkg/verifier/utils"
 )
 
 const (
@@ -78,10 +94,12 @@ var (
 	}
 	testNotationPluginVerifier notation.Verifier = mockNotationPluginVerifier{}
 	validBlobDesc                                = ocispec.Descriptor{
-		Digest: testDigest,
+		MediaType: jws.MediaTypeEnvelope,
+		Digest:    testDigest,
 	}
 	validBlobDesc2 = ocispec.Descriptor{
-		Digest: testDigest2,
+		MediaType: jws.MediaTypeEnvelope,
+		Digest:    testDigest2,
 	}
 	defaultCertDir  = paths.Join(homedir.Get(), ratifyconfig.ConfigFileDir, defaultCertPath)
 	testTrustPolicy = map[string]interface{}{
@@ -179,6 +197,18 @@ func TestCanVerify(t *testing.T) {
 			referenceArtifact: testArtifactType1,
 			expect:            true,
 		},
+		{
+			name:              "wildcard pattern does not match the subject",
+			artifactTypes:     []string{"*"},
+			referenceArtifact: ocispecs.SubjectArtifactType,
+			expect:            false,
+		},
+		{
+			name:              "subject matched",
+			artifactTypes:     []string{ocispecs.SubjectArtifactType},
+			referenceArtifact: ocispecs.SubjectArtifactType,
+			expect:            true,
+		},
 		{
 			name:              "type unmatched",
 			artifactTypes:     []string{testArtifactType2},
@@ -303,6 +333,26 @@ func TestParseVerifierConfig(t *testing.T) {
 	}
 }
 
+func TestParseVerifierConfig_FieldErrors(t *testing.T) {
+	configMap := map[string]interface{}{
+		"name":              test,
+		"artifactTypes":     []string{"application/vnd.cncf.notary.signature"},
+		"verificationCerts": "/usr/local/certs",
+	}
+	_, err := parseVerifierConfig(configMap, "")
+	if err == nil {
+		t.Fatalf("expected error for malformed fields")
+	}
+	for _, expected := range []string{
+		"artifactTypes: expected string, got array",
+		"verificationCerts: expected array, got string",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
+
 func TestVerifySignature(t *testing.T) {
 	v := &notationPluginVerifier{
 		notationVerifier: &testNotationPluginVerifier,
@@ -382,6 +432,8 @@ func TestVerify(t *testing.T) {
 		manifest  ocispecs.ReferenceManifest
 		refBlob   []byte
 		expectErr bool
+		// remediation is the expected remediation of the error, if set
+		remediation string
 	}{
 		{
 			name:      "failed getting subject descriptor",
@@ -406,8 +458,9 @@ func TestVerify(t *testing.T) {
 			manifest: ocispecs.ReferenceManifest{
 				Blobs: []ocispec.Descriptor{validBlobDesc2},
 			},
-			expect:    failedResult,
-			expectErr: true,
+			expect:      failedResult,
+			expectErr:   true,
+			remediation: fmt.Sprintf("Please sign the artifact [%s@%s] with notation using a certificate trusted by the trust policy that applies to it, and ensure the signature is not expired or revoked.", validRef2.Path, validRef2.Digest),
 		},
 		{
 			name:    "multiple signature blobs",
@@ -460,6 +513,244 @@ func TestVerify(t *testing.T) {
 			if result.IsSuccess != tt.expect.IsSuccess {
 				t.Fatalf("expect %+v, got %+v", tt.expect, result)
 			}
+			var ratifyErr re.Error
+			if tt.remediation != "" && (!errors.As(err, &ratifyErr) || ratifyErr.GetRemediation() != tt.remediation) {
+				t.Fatalf("expected remediation %q, got error %v", tt.remediation, err)
+			}
+		})
+	}
+}
+
+type outcomeNotationVerifier struct {
+	outcome *notation.VerificationOutcome
+}
+
+func (v outcomeNotationVerifier) Verify(_ context.Context, _ ocispec.Descriptor, _ []byte, _ notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return v.outcome, nil
+}
+
+func TestVerify_SigningTime(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cert := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "signer"},
+		NotBefore: notBefore,
+		NotAfter:  notBefore.AddDate(1, 0, 0),
+	}
+	tests := []struct {
+		name              string
+		signingTime       time.Time
+		verifySigningTime bool
+		expectErr         bool
+	}{
+		{
+			name:              "signing time within the validity window",
+			signingTime:       notBefore.AddDate(0, 6, 0),
+			verifySigningTime: true,
+		},
+		{
+			name:              "signing time before the validity window",
+			signingTime:       notBefore.Add(-time.Hour),
+			verifySigningTime: true,
+			expectErr:         true,
+		},
+		{
+			name:              "signing time after the validity window",
+			signingTime:       notBefore.AddDate(2, 0, 0),
+			verifySigningTime: true,
+			expectErr:         true,
+		},
+		{
+			name:        "signing time outside the validity window is not checked",
+			signingTime: notBefore.AddDate(2, 0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var notationVerifier notation.Verifier = outcomeNotationVerifier{outcome: &notation.VerificationOutcome{
+				EnvelopeContent: &sig.EnvelopeContent{
+					SignerInfo: sig.SignerInfo{
+						SignedAttributes: sig.SignedAttributes{SigningTime: tt.signingTime},
+						CertificateChain: []*x509.Certificate{cert},
+					},
+				},
+			}}
+			v := &notationPluginVerifier{
+				notationVerifier:  &notationVerifier,
+				verifySigningTime: tt.verifySigningTime,
+			}
+			store := &mockStore{
+				refBlob:  testRefBlob,
+				manifest: ocispecs.ReferenceManifest{Blobs: []ocispec.Descriptor{validBlobDesc}},
+			}
+
+			result, err := v.Verify(context.Background(), validRef, ocispecs.ReferenceDescriptor{}, store)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("error = %v, expectErr = %v", err, tt.expectErr)
+			}
+			if result.IsSuccess == tt.expectErr {
+				t.Fatalf("expected success %v, got %+v", !tt.expectErr, result)
+			}
+			if !tt.expectErr && (result.ValidUntil == nil || !result.ValidUntil.Equal(cert.NotAfter)) {
+				t.Fatalf("expected the result to be valid until %v, got %v", cert.NotAfter, result.ValidUntil)
+			}
+		})
+	}
+}
+
+func TestValidUntil(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	leaf := &x509.Certificate{NotAfter: now.Add(time.Hour)}
+	root := &x509.Certificate{NotAfter: now.AddDate(10, 0, 0)}
+	timestamped := []*notation.ValidationResult{{Type: trustpolicy.TypeAuthenticTimestamp, Action: trustpolicy.ActionEnforce}}
+	tests := []struct {
+		name              string
+		timestamp         []byte
+		results           []*notation.ValidationResult
+		signatureExpiry   time.Time
+		expectedExpiresAt time.Time
+	}{
+		{
+			name:              "not timestamped",
+			expectedExpiresAt: leaf.NotAfter,
+		},
+		{
+			name:              "timestamp verified",
+			timestamp:         []byte("timestamp"),
+			results:           timestamped,
+			expectedExpiresAt: root.NotAfter,
+		},
+		{
+			name:      "timestamp not verified",
+			timestamp: []byte("timestamp"),
+			results: []*notation.ValidationResult{
+				{Type: trustpolicy.TypeAuthenticTimestamp, Action: trustpolicy.ActionLog, Error: fmt.Errorf("timestamp not trusted")},
+			},
+			expectedExpiresAt: leaf.NotAfter,
+		},
+		{
+			name:              "timestamp verification skipped",
+			timestamp:         []byte("timestamp"),
+			results:           []*notation.ValidationResult{{Type: trustpolicy.TypeAuthenticTimestamp, Action: trustpolicy.ActionSkip}},
+			expectedExpiresAt: leaf.NotAfter,
+		},
+		{
+			name:              "signature expiry applies to timestamped signatures",
+			timestamp:         []byte("timestamp"),
+			results:           timestamped,
+			signatureExpiry:   now.AddDate(1, 0, 0),
+			expectedExpiresAt: now.AddDate(1, 0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := &notation.VerificationOutcome{
+				EnvelopeContent: &sig.EnvelopeContent{
+					SignerInfo: sig.SignerInfo{
+						SignedAttributes:   sig.SignedAttributes{Expiry: tt.signatureExpiry},
+						UnsignedAttributes: sig.UnsignedAttributes{TimestampSignature: tt.timestamp},
+						CertificateChain:   []*x509.Certificate{leaf, root},
+					},
+				},
+				VerificationResults: tt.results,
+			}
+			if expiresAt := validUntil(outcome); expiresAt == nil || !expiresAt.Equal(tt.expectedExpiresAt) {
+				t.Fatalf("expected the result to be valid until %v, got %v", tt.expectedExpiresAt, expiresAt)
+			}
+		})
+	}
+}
+
+func TestVerify_AllowedSignatureAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	newCert := func(publicKey interface{}, signatureAlgorithm x509.SignatureAlgorithm) *x509.Certificate {
+		return &x509.Certificate{
+			Subject:            pkix.Name{CommonName: "signer"},
+			RawSubject:         []byte("signer"),
+			RawIssuer:          []byte("ca"),
+			PublicKey:          publicKey,
+			SignatureAlgorithm: signatureAlgorithm,
+		}
+	}
+	tests := []struct {
+		name               string
+		allowed            []string
+		signatureAlgorithm sig.Algorithm
+		cert               *x509.Certificate
+		expectErr          bool
+	}{
+		{
+			name:               "allowed key and digests",
+			allowed:            []string{"RSA-3072", "ECDSA-P256", "SHA-256"},
+			signatureAlgorithm: sig.AlgorithmES256,
+			cert:               newCert(&ecdsaKey.PublicKey, x509.ECDSAWithSHA256),
+		},
+		{
+			name:               "key size not allowed",
+			allowed:            []string{"RSA-3072", "ECDSA-P256", "SHA-256"},
+			signatureAlgorithm: sig.AlgorithmPS256,
+			cert:               newCert(&rsaKey.PublicKey, x509.SHA256WithRSA),
+			expectErr:          true,
+		},
+		{
+			name:               "signature digest not allowed",
+			allowed:            []string{"ECDSA-P256", "SHA-384"},
+			signatureAlgorithm: sig.AlgorithmES256,
+			cert:               newCert(&ecdsaKey.PublicKey, x509.ECDSAWithSHA384),
+			expectErr:          true,
+		},
+		{
+			name:               "certificate signed with SHA-1",
+			allowed:            []string{"ECDSA-P256", "SHA-256"},
+			signatureAlgorithm: sig.AlgorithmES256,
+			cert:               newCert(&ecdsaKey.PublicKey, x509.SHA1WithRSA),
+			expectErr:          true,
+		},
+		{
+			name:               "any algorithm is allowed by default",
+			signatureAlgorithm: sig.AlgorithmPS256,
+			cert:               newCert(&rsaKey.PublicKey, x509.SHA1WithRSA),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithmPolicy, err := verifierutils.NewAlgorithmPolicy(tt.allowed)
+			if err != nil {
+				t.Fatalf("failed to create the algorithm policy: %v", err)
+			}
+			var notationVerifier notation.Verifier = outcomeNotationVerifier{outcome: &notation.VerificationOutcome{
+				EnvelopeContent: &sig.EnvelopeContent{
+					SignerInfo: sig.SignerInfo{
+						SignatureAlgorithm: tt.signatureAlgorithm,
+						CertificateChain:   []*x509.Certificate{tt.cert},
+					},
+				},
+			}}
+			v := &notationPluginVerifier{
+				notationVerifier: &notationVerifier,
+				algorithmPolicy:  algorithmPolicy,
+			}
+			store := &mockStore{
+				refBlob:  testRefBlob,
+				manifest: ocispecs.ReferenceManifest{Blobs: []ocispec.Descriptor{validBlobDesc}},
+			}
+
+			result, err := v.Verify(context.Background(), validRef, ocispecs.ReferenceDescriptor{}, store)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("error = %v, expectErr = %v", err, tt.expectErr)
+			}
+			if result.IsSuccess == tt.expectErr {
+				t.Fatalf("expected success %v, got %+v", !tt.expectErr, result)
+			}
 		})
 	}
 }
@@ -558,3 +849,330 @@ func TestNormalizeLegacyCertStore(t *testing.T) {
 		})
 	}
 }
+
+// subjectStore serves a single signature envelope blob for the given subject.
+type subjectStore struct {
+	mockStore
+	subjectDesc ocispec.Descriptor
+}
+
+func (s subjectStore) GetSubjectDescriptor(_ context.Context, _ common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	return &ocispecs.SubjectDescriptor{Descriptor: s.subjectDesc}, nil
+}
+
+// newTestSigningCert creates a self-signed code signing certificate expiring
+// at notAfter and writes it to a temporary file.
+func newTestSigningCert(t *testing.T, notAfter time.Time) (*ecdsa.PrivateKey, *x509.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ratify.test", Organization: []string{"Ratify"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	certPath := paths.Join(t.TempDir(), "signer.crt")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	return key, cert, certPath
+}
+
+func TestVerify_EnvelopeFormats(t *testing.T) {
+	key, cert, certPath := newTestSigningCert(t, time.Now().Add(time.Hour))
+
+	conf := &NotationPluginVerifierConfig{
+		VerificationCerts: []string{certPath},
+		TrustPolicyDoc: trustpolicy.Document{
+			Version: "1.0",
+			TrustPolicies: []trustpolicy.TrustPolicy{{
+				Name:                  "default",
+				RegistryScopes:        []string{"*"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:certs"},
+				TrustedIdentities:     []string{"*"},
+			}},
+		},
+	}
+	notationVerifier, err := getVerifierService(conf, "")
+	if err != nil {
+		t.Fatalf("failed to create the notation verifier: %v", err)
+	}
+	v := &notationPluginVerifier{notationVerifier: &notationVerifier}
+
+	subjectContent := []byte(`{"schemaVersion":2}`)
+	subjectDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(subjectContent),
+		Size:      int64(len(subjectContent)),
+	}
+	subjectRef := common.Reference{
+		Path:     "localhost:5000/net-monitor",
+		Digest:   subjectDesc.Digest,
+		Original: "localhost:5000/net-monitor@" + subjectDesc.Digest.String(),
+	}
+	signer, err := notationsigner.New(key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	signatures := make(map[string][]byte)
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		signature, _, err := signer.Sign(context.Background(), subjectDesc, notation.SignerSignOptions{SignatureMediaType: mediaType})
+		if err != nil {
+			t.Fatalf("failed to sign with %s envelope: %v", mediaType, err)
+		}
+		signatures[mediaType] = signature
+	}
+
+	tests := []struct {
+		name           string
+		mediaType      string
+		signature      []byte
+		expectErr      bool
+		envelopeFormat string
+	}{
+		{
+			name:           "JWS envelope",
+			mediaType:      jws.MediaTypeEnvelope,
+			signature:      signatures[jws.MediaTypeEnvelope],
+			envelopeFormat: "jws",
+		},
+		{
+			name:           "COSE envelope",
+			mediaType:      cose.MediaTypeEnvelope,
+			signature:      signatures[cose.MediaTypeEnvelope],
+			envelopeFormat: "cose",
+		},
+		{
+			name:      "JWS envelope with COSE media type",
+			mediaType: cose.MediaTypeEnvelope,
+			signature: signatures[jws.MediaTypeEnvelope],
+			expectErr: true,
+		},
+		{
+			name:      "unsupported envelope media type",
+			mediaType: "application/octet-stream",
+			signature: signatures[jws.MediaTypeEnvelope],
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := subjectStore{
+				mockStore: mockStore{
+					refBlob: tt.signature,
+					manifest: ocispecs.ReferenceManifest{
+						Blobs: []ocispec.Descriptor{{MediaType: tt.mediaType, Digest: digest.FromBytes(tt.signature)}},
+					},
+				},
+				subjectDesc: subjectDesc,
+			}
+			result, err := v.Verify(context.Background(), subjectRef, ocispecs.ReferenceDescriptor{}, store)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("error = %v, expectErr = %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if !result.IsSuccess {
+				t.Fatalf("expected the %s signature to be verified, got %+v", tt.envelopeFormat, result)
+			}
+			if format := result.Extensions.(map[string]string)["EnvelopeFormat"]; format != tt.envelopeFormat {
+				t.Fatalf("expected envelope format %s, got %s", tt.envelopeFormat, format)
+			}
+		})
+	}
+}
+
+func TestVerify_ConfiglessArtifact(t *testing.T) {
+	key, cert, certPath := newTestSigningCert(t, time.Now().Add(time.Hour))
+
+	conf := &NotationPluginVerifierConfig{
+		VerificationCerts: []string{certPath},
+		TrustPolicyDoc: trustpolicy.Document{
+			Version: "1.0",
+			TrustPolicies: []trustpolicy.TrustPolicy{{
+				Name:                  "default",
+				RegistryScopes:        []string{"*"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:certs"},
+				TrustedIdentities:     []string{"*"},
+			}},
+		},
+	}
+	notationVerifier, err := getVerifierService(conf, "")
+	if err != nil {
+		t.Fatalf("failed to create the notation verifier: %v", err)
+	}
+	v := &notationPluginVerifier{notationVerifier: &notationVerifier}
+	signer, err := notationsigner.New(key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		mediaType string
+		content   []byte
+	}{
+		{
+			name:      "artifact manifest without config",
+			mediaType: ocispecs.MediaTypeArtifactManifest,
+			content:   []byte(`{"mediaType":"application/vnd.oci.artifact.manifest.v1+json","artifactType":"application/spdx+json","blobs":[]}`),
+		},
+		{
+			name:      "image manifest with empty config",
+			mediaType: ocispec.MediaTypeImageManifest,
+			content:   []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","artifactType":"application/vnd.example.model","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subjectDesc := ocispec.Descriptor{
+				MediaType: tt.mediaType,
+				Digest:    digest.FromBytes(tt.content),
+				Size:      int64(len(tt.content)),
+			}
+			subjectRef := common.Reference{
+				Path:     "localhost:5000/sbom",
+				Digest:   subjectDesc.Digest,
+				Original: "localhost:5000/sbom@" + subjectDesc.Digest.String(),
+			}
+			signature, _, err := signer.Sign(context.Background(), subjectDesc, notation.SignerSignOptions{SignatureMediaType: jws.MediaTypeEnvelope})
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			store := subjectStore{
+				mockStore: mockStore{
+					refBlob: signature,
+					manifest: ocispecs.ReferenceManifest{
+						MediaType: ocispecs.MediaTypeArtifactManifest,
+						Blobs:     []ocispec.Descriptor{{MediaType: jws.MediaTypeEnvelope, Digest: digest.FromBytes(signature)}},
+					},
+				},
+				subjectDesc: subjectDesc,
+			}
+			result, err := v.Verify(context.Background(), subjectRef, ocispecs.ReferenceDescriptor{}, store)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !result.IsSuccess {
+				t.Fatalf("expected the signature of the config-less artifact to be verified, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestVerify_CertificateExpiryWarning(t *testing.T) {
+	key, cert, certPath := newTestSigningCert(t, time.Now().Add(time.Hour))
+	conf := &NotationPluginVerifierConfig{
+		VerificationCerts: []string{certPath},
+		TrustPolicyDoc: trustpolicy.Document{
+			Version: "1.0",
+			TrustPolicies: []trustpolicy.TrustPolicy{{
+				Name:                  "default",
+				RegistryScopes:        []string{"*"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:certs"},
+				TrustedIdentities:     []string{"*"},
+			}},
+		},
+	}
+	notationVerifier, err := getVerifierService(conf, "")
+	if err != nil {
+		t.Fatalf("failed to create the notation verifier: %v", err)
+	}
+
+	subjectContent := []byte(`{"schemaVersion":2}`)
+	subjectDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(subjectContent),
+		Size:      int64(len(subjectContent)),
+	}
+	subjectRef := common.Reference{
+		Path:     "localhost:5000/net-monitor",
+		Digest:   subjectDesc.Digest,
+		Original: "localhost:5000/net-monitor@" + subjectDesc.Digest.String(),
+	}
+	signer, err := notationsigner.New(key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	signature, _, err := signer.Sign(context.Background(), subjectDesc, notation.SignerSignOptions{SignatureMediaType: jws.MediaTypeEnvelope})
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	store := subjectStore{
+		mockStore: mockStore{
+			refBlob: signature,
+			manifest: ocispecs.ReferenceManifest{
+				Blobs: []ocispec.Descriptor{{MediaType: jws.MediaTypeEnvelope, Digest: digest.FromBytes(signature)}},
+			},
+		},
+		subjectDesc: subjectDesc,
+	}
+
+	tests := []struct {
+		name       string
+		window     time.Duration
+		expectWarn bool
+	}{
+		{
+			name:       "certificate expires within the window",
+			window:     24 * time.Hour,
+			expectWarn: true,
+		},
+		{
+			name:   "certificate expires after the window",
+			window: 30 * time.Minute,
+		},
+		{
+			name: "warning disabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &notationPluginVerifier{notationVerifier: &notationVerifier, certExpiryWarning: tt.window}
+			result, err := v.Verify(context.Background(), subjectRef, ocispecs.ReferenceDescriptor{}, store)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.IsSuccess {
+				t.Fatalf("expected the signature to be verified, got %+v", result)
+			}
+			extensions := result.Extensions.(map[string]string)
+			if !tt.expectWarn {
+				if result.Level == verifier.LevelWarn {
+					t.Fatalf("expected no warning, got %+v", result)
+				}
+				if _, ok := extensions["ExpiringCertificate"]; ok {
+					t.Fatalf("expected no expiring certificate extension, got %+v", extensions)
+				}
+				return
+			}
+			if result.Level != verifier.LevelWarn {
+				t.Fatalf("expected level %s, got %s", verifier.LevelWarn, result.Level)
+			}
+			if extensions["ExpiringCertificate"] != cert.Subject.String() {
+				t.Fatalf("expected expiring certificate %s, got %s", cert.Subject.String(), extensions["ExpiringCertificate"])
+			}
+			if expected := cert.NotAfter.UTC().Format(time.RFC3339); extensions["CertificateExpiresAt"] != expected {
+				t.Fatalf("expected certificate expiry %s, got %s", expected, extensions["CertificateExpiresAt"])
+			}
+		})
+	}
+}
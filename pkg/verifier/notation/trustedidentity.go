@@ -0,0 +1,300 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	re "github.com/ratify-project/ratify/errors"
+)
+
+const (
+	x509SubjectPrefix       = "x509.subject:"
+	wildcardTrustedIdentity = "*"
+	// regexMetaCharacters are the characters marking a trusted identity as a
+	// regex pattern. '.', '+' and '\' are excluded as they are valid in exact
+	// distinguished names.
+	regexMetaCharacters = "*?[](){}|^$"
+)
+
+// TrustedIdentityMatching configures how Ratify normalizes distinguished names
+// when matching trusted identities. Matching is strict by default.
+type TrustedIdentityMatching struct {
+	// CaseInsensitive compares the attribute types and values of
+	// distinguished names ignoring case.
+	CaseInsensitive bool `json:"caseInsensitive,omitempty"`
+	// IgnoreAttributeOrder matches the attributes of a regex pattern against
+	// the attributes of the signing certificate subject of the same type in
+	// any order, and allows the subject to carry attributes the pattern does
+	// not list.
+	IgnoreAttributeOrder bool `json:"ignoreAttributeOrder,omitempty"`
+}
+
+func (m TrustedIdentityMatching) normalized() bool {
+	return m.CaseInsensitive || m.IgnoreAttributeOrder
+}
+
+// attributeTypes maps the attribute type names of distinguished names to the
+// OIDs of the attribute types.
+var attributeTypes = map[string]string{
+	"C":            "2.5.4.6",
+	"ST":           "2.5.4.8",
+	"S":            "2.5.4.8",
+	"L":            "2.5.4.7",
+	"STREET":       "2.5.4.9",
+	"POSTALCODE":   "2.5.4.17",
+	"O":            "2.5.4.10",
+	"OU":           "2.5.4.11",
+	"CN":           "2.5.4.3",
+	"SERIALNUMBER": "2.5.4.5",
+}
+
+// attributePattern matches the value of an attribute of the signing
+// certificate subject.
+type attributePattern struct {
+	// oid is the OID of the attribute type.
+	oid string
+	// pattern matches the whole attribute value if the entry is a regex.
+	pattern *regexp.Regexp
+	// value is the attribute value of an exact entry.
+	value string
+}
+
+// subjectAttribute is an attribute of the signing certificate subject.
+type subjectAttribute struct {
+	oid   string
+	value string
+}
+
+// trustedIdentity is a trusted identity entry of a trust policy that is
+// matched by Ratify instead of notation. Every attribute is matched on its own
+// against the subject attribute of the same type, so a pattern never matches
+// across attribute boundaries.
+type trustedIdentity struct {
+	attributes []attributePattern
+	// exact entries match if the subject carries their attributes, as
+	// notation matches a subset of the certificate subject.
+	exact bool
+	// ignoreAttributeOrder matches the attributes of a regex entry in any
+	// order and allows subject attributes the entry does not list. Otherwise
+	// the entry lists the subject attributes in the order of the subject.
+	ignoreAttributeOrder bool
+	// caseInsensitive compares the attribute values of an exact entry
+	// ignoring case.
+	caseInsensitive bool
+}
+
+// matches returns true if the subject of the signing certificate matches the
+// trusted identity. Subjects repeating an attribute type never match.
+func (i trustedIdentity) matches(subject pkix.Name) bool {
+	attributes, err := subjectAttributes(subject)
+	if err != nil {
+		return false
+	}
+	if !i.exact && !i.ignoreAttributeOrder {
+		if len(attributes) != len(i.attributes) {
+			return false
+		}
+		for idx, attribute := range i.attributes {
+			if attributes[idx].oid != attribute.oid || !attribute.matches(attributes[idx].value, i.caseInsensitive) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, attribute := range i.attributes {
+		matched := false
+		for _, subjectAttribute := range attributes {
+			if subjectAttribute.oid == attribute.oid {
+				matched = attribute.matches(subjectAttribute.value, i.caseInsensitive)
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matches returns true if the attribute value matches the pattern or equals
+// the value of an exact entry.
+func (a attributePattern) matches(value string, caseInsensitive bool) bool {
+	if a.pattern != nil {
+		return a.pattern.MatchString(value)
+	}
+	return value == a.value || caseInsensitive && strings.EqualFold(value, a.value)
+}
+
+// subjectAttributes returns the attributes of the signing certificate subject
+// in the order of its string representation. It fails if the subject repeats
+// an attribute type, as the attributes are matched by type.
+func subjectAttributes(subject pkix.Name) ([]subjectAttribute, error) {
+	names := subject.Names
+	if len(names) == 0 {
+		for _, rdn := range subject.ToRDNSequence() {
+			names = append(names, rdn...)
+		}
+	}
+	attributes := make([]subjectAttribute, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	// the string representation lists the attributes in reverse order
+	for idx := len(names) - 1; idx >= 0; idx-- {
+		oid := names[idx].Type.String()
+		if seen[oid] {
+			return nil, fmt.Errorf("subject %q repeats the attribute type %s", subject.String(), attributeTypeName(names[idx].Type))
+		}
+		seen[oid] = true
+		attributes = append(attributes, subjectAttribute{oid: oid, value: fmt.Sprint(names[idx].Value)})
+	}
+	return attributes, nil
+}
+
+// attributeTypeName returns the name of the attribute type, or its OID if it
+// has no name.
+func attributeTypeName(oid asn1.ObjectIdentifier) string {
+	for name, typeOID := range attributeTypes {
+		if typeOID == oid.String() && name != "S" {
+			return name
+		}
+	}
+	return oid.String()
+}
+
+// extractTrustedIdentityPatterns takes over the trusted identities of every trust
+// policy containing a regex pattern entry, or of every trust policy with
+// x509.subject entries if the matching normalizes distinguished names. The
+// identities of such a policy are replaced with the wildcard so notation
+// accepts any signing certificate and the returned identities, keyed by trust
+// policy name, are matched after notation verification succeeds. Exact entries
+// in the same policy keep notation's semantics of matching a subset of the
+// certificate subject.
+func extractTrustedIdentityPatterns(doc *trustpolicy.Document, matching TrustedIdentityMatching) (map[string][]trustedIdentity, error) {
+	identitiesByPolicy := make(map[string][]trustedIdentity)
+	for idx := range doc.TrustPolicies {
+		policy := &doc.TrustPolicies[idx]
+		hasPattern := false
+		for _, identity := range policy.TrustedIdentities {
+			if strings.HasPrefix(identity, x509SubjectPrefix) && (matching.normalized() || strings.ContainsAny(strings.TrimPrefix(identity, x509SubjectPrefix), regexMetaCharacters)) {
+				hasPattern = true
+				break
+			}
+		}
+		if !hasPattern {
+			continue
+		}
+
+		identities := make([]trustedIdentity, 0, len(policy.TrustedIdentities))
+		for _, identity := range policy.TrustedIdentities {
+			if !strings.HasPrefix(identity, x509SubjectPrefix) {
+				return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Trust policy %s mixes the trusted identity %q with regex patterns, only x509.subject entries are supported alongside patterns", policy.Name, identity))
+			}
+			value := strings.TrimSpace(strings.TrimPrefix(identity, x509SubjectPrefix))
+			identity, err := parseTrustedIdentity(value, matching)
+			if err != nil {
+				return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Trust policy %s has an invalid trusted identity %q", policy.Name, value)).WithError(err)
+			}
+			identities = append(identities, identity)
+		}
+		identitiesByPolicy[policy.Name] = identities
+		policy.TrustedIdentities = []string{wildcardTrustedIdentity}
+	}
+	return identitiesByPolicy, nil
+}
+
+// parseTrustedIdentity parses a trusted identity into its attributes. The
+// attribute values of a regex entry are compiled as patterns matching the
+// whole value of the attribute.
+func parseTrustedIdentity(value string, matching TrustedIdentityMatching) (trustedIdentity, error) {
+	identity := trustedIdentity{
+		exact:                !strings.ContainsAny(value, regexMetaCharacters),
+		ignoreAttributeOrder: matching.IgnoreAttributeOrder,
+		caseInsensitive:      matching.CaseInsensitive,
+	}
+	flags := ""
+	if matching.CaseInsensitive {
+		flags = "(?i)"
+	}
+	seen := make(map[string]bool)
+	for _, attribute := range splitPatternAttributes(value) {
+		key, attributeValue, found := strings.Cut(attribute, "=")
+		if !found {
+			return trustedIdentity{}, fmt.Errorf("attribute %q is not of the form TYPE=value", strings.TrimSpace(attribute))
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		oid, ok := attributeTypes[key]
+		if !ok {
+			return trustedIdentity{}, fmt.Errorf("attribute type %s is not supported", key)
+		}
+		if seen[oid] {
+			return trustedIdentity{}, fmt.Errorf("attribute type %s is repeated", key)
+		}
+		seen[oid] = true
+		attributeValue = strings.TrimSpace(attributeValue)
+		if identity.exact {
+			identity.attributes = append(identity.attributes, attributePattern{oid: oid, value: unescapeAttributeValue(attributeValue)})
+			continue
+		}
+		pattern, err := regexp.Compile(flags + "^(?:" + attributeValue + ")$")
+		if err != nil {
+			return trustedIdentity{}, err
+		}
+		identity.attributes = append(identity.attributes, attributePattern{oid: oid, pattern: pattern})
+	}
+	return identity, nil
+}
+
+// splitPatternAttributes splits a distinguished name or regex distinguished
+// name pattern at the commas separating its attributes, i.e. the unescaped
+// commas outside of groups, character classes and repetition counts.
+func splitPatternAttributes(pattern string) []string {
+	var attributes []string
+	depth, start := 0, 0
+	for idx := 0; idx < len(pattern); idx++ {
+		switch pattern[idx] {
+		case '\\':
+			idx++
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				attributes = append(attributes, pattern[start:idx])
+				start = idx + 1
+			}
+		}
+	}
+	return append(attributes, pattern[start:])
+}
+
+// unescapeAttributeValue removes the backslashes escaping the special
+// characters of an exact attribute value.
+func unescapeAttributeValue(value string) string {
+	var b strings.Builder
+	for idx := 0; idx < len(value); idx++ {
+		if value[idx] == '\\' && idx+1 < len(value) {
+			idx++
+		}
+		b.WriteByte(value[idx])
+	}
+	return b.String()
+}
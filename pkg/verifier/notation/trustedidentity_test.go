@@ -0,0 +1,346 @@
+// Copyright The Ratify Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notation
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"reflect"
+	"testing"
+
+	sig "github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+)
+
+type mockSubjectNotationVerifier struct {
+	subject pkix.Name
+}
+
+func (v mockSubjectNotationVerifier) Verify(_ context.Context, _ ocispec.Descriptor, _ []byte, _ notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return &notation.VerificationOutcome{
+		EnvelopeContent: &sig.EnvelopeContent{
+			SignerInfo: sig.SignerInfo{
+				CertificateChain: []*x509.Certificate{{Subject: v.subject}},
+			},
+		},
+	}, nil
+}
+
+func newTestTrustPolicyDoc(trustedIdentities ...string) trustpolicy.Document {
+	return trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{
+				Name:                  "default",
+				RegistryScopes:        []string{"*"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:certs"},
+				TrustedIdentities:     trustedIdentities,
+			},
+		},
+	}
+}
+
+func TestExtractTrustedIdentityPatterns(t *testing.T) {
+	tests := []struct {
+		name                      string
+		trustedIdentities         []string
+		expectedTrustedIdentities []string
+		expectPatterns            bool
+		expectErr                 bool
+	}{
+		{
+			name:                      "exact identities are left to notation",
+			trustedIdentities:         []string{"x509.subject: CN=build-1,O=Acme"},
+			expectedTrustedIdentities: []string{"x509.subject: CN=build-1,O=Acme"},
+		},
+		{
+			name:                      "wildcard is left to notation",
+			trustedIdentities:         []string{"*"},
+			expectedTrustedIdentities: []string{"*"},
+		},
+		{
+			name:                      "regex identity is taken over",
+			trustedIdentities:         []string{"x509.subject: CN=build-.*,O=Acme", "x509.subject: CN=release,O=Acme"},
+			expectedTrustedIdentities: []string{"*"},
+			expectPatterns:            true,
+		},
+		{
+			name:              "invalid regex",
+			trustedIdentities: []string{"x509.subject: CN=build-(.*,O=Acme"},
+			expectErr:         true,
+		},
+		{
+			name:              "pattern without attribute types",
+			trustedIdentities: []string{"x509.subject: .*Acme.*"},
+			expectErr:         true,
+		},
+		{
+			name:              "pattern with a repeated attribute type",
+			trustedIdentities: []string{"x509.subject: CN=build-.*,O=Acme,O=Contoso"},
+			expectErr:         true,
+		},
+		{
+			name:              "regex mixed with wildcard",
+			trustedIdentities: []string{"*", "x509.subject: CN=build-.*,O=Acme"},
+			expectErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newTestTrustPolicyDoc(tt.trustedIdentities...)
+			identities, err := extractTrustedIdentityPatterns(&doc, TrustedIdentityMatching{})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("error = %v, expectErr = %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if _, ok := identities["default"]; ok != tt.expectPatterns {
+				t.Fatalf("expected patterns %v, got %v", tt.expectPatterns, identities)
+			}
+			if !reflect.DeepEqual(doc.TrustPolicies[0].TrustedIdentities, tt.expectedTrustedIdentities) {
+				t.Fatalf("expected trusted identities %v, got %v", tt.expectedTrustedIdentities, doc.TrustPolicies[0].TrustedIdentities)
+			}
+		})
+	}
+}
+
+func TestVerify_TrustedIdentityPatterns(t *testing.T) {
+	tests := []struct {
+		name      string
+		subject   pkix.Name
+		expectErr bool
+	}{
+		{
+			name:    "subject matches regex identity",
+			subject: pkix.Name{CommonName: "build-42", Organization: []string{"Acme"}},
+		},
+		{
+			name:    "subject matches exact identity",
+			subject: pkix.Name{CommonName: "release", Organization: []string{"Acme"}, Country: []string{"US"}},
+		},
+		{
+			name:      "subject does not match regex identity",
+			subject:   pkix.Name{CommonName: "dev-42", Organization: []string{"Acme"}},
+			expectErr: true,
+		},
+		{
+			name:      "subject organization does not match",
+			subject:   pkix.Name{CommonName: "build-42", Organization: []string{"Other"}},
+			expectErr: true,
+		},
+		{
+			name:      "common name spoofing another attribute",
+			subject:   pkix.Name{CommonName: "build-x,O=Acme", Organization: []string{"Attacker"}},
+			expectErr: true,
+		},
+		{
+			name:      "repeated organization",
+			subject:   pkix.Name{CommonName: "build-42", Organization: []string{"Attacker", "Acme"}},
+			expectErr: true,
+		},
+		{
+			name: "repeated attribute type in the parsed names",
+			subject: pkix.Name{Names: []pkix.AttributeTypeAndValue{
+				{Type: asn1.ObjectIdentifier{2, 5, 4, 10}, Value: "Acme"},
+				{Type: asn1.ObjectIdentifier{2, 5, 4, 3}, Value: "build-42"},
+				{Type: asn1.ObjectIdentifier{2, 5, 4, 10}, Value: "Attacker"},
+			}},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newTestTrustPolicyDoc("x509.subject: CN=build-.*,O=Acme", "x509.subject: CN=release,O=Acme")
+			identities, err := extractTrustedIdentityPatterns(&doc, TrustedIdentityMatching{})
+			if err != nil {
+				t.Fatalf("failed to extract trusted identities: %v", err)
+			}
+			var notationVerifier notation.Verifier = mockSubjectNotationVerifier{subject: tt.subject}
+			v := &notationPluginVerifier{
+				notationVerifier:  &notationVerifier,
+				trustPolicyDoc:    &doc,
+				trustedIdentities: identities,
+			}
+			store := &mockStore{
+				refBlob: testRefBlob,
+				manifest: ocispecs.ReferenceManifest{
+					Blobs: []ocispec.Descriptor{validBlobDesc},
+				},
+			}
+			ref := common.Reference{
+				Original: "registry.example.com/repo:v1",
+				Path:     "registry.example.com/repo",
+				Digest:   digest.FromString("test"),
+			}
+
+			result, err := v.Verify(context.Background(), ref, ocispecs.ReferenceDescriptor{}, store)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("error = %v, expectErr = %v", err, tt.expectErr)
+			}
+			if result.IsSuccess == tt.expectErr {
+				t.Fatalf("expected IsSuccess %v, got %v", !tt.expectErr, result.IsSuccess)
+			}
+		})
+	}
+}
+
+func TestVerify_TrustedIdentityMatching(t *testing.T) {
+	subject := pkix.Name{CommonName: "Build-42", Organization: []string{"ACME Corp"}, Country: []string{"US"}}
+	tests := []struct {
+		name              string
+		trustedIdentities []string
+		matching          TrustedIdentityMatching
+		expectErr         bool
+	}{
+		{
+			name:              "exact identity differing in case is rejected by default",
+			trustedIdentities: []string{"x509.subject: CN=build-42,O=Acme Corp"},
+			expectErr:         true,
+		},
+		{
+			name:              "exact identity differing in case",
+			trustedIdentities: []string{"x509.subject: CN=build-42,O=Acme Corp"},
+			matching:          TrustedIdentityMatching{CaseInsensitive: true},
+		},
+		{
+			name:              "exact identity differing in attribute order and case",
+			trustedIdentities: []string{"x509.subject: c=us,o=acme corp,cn=build-42"},
+			matching:          TrustedIdentityMatching{CaseInsensitive: true},
+		},
+		{
+			name:              "exact identity with a different value",
+			trustedIdentities: []string{"x509.subject: CN=build-43,O=Acme Corp"},
+			matching:          TrustedIdentityMatching{CaseInsensitive: true},
+			expectErr:         true,
+		},
+		{
+			name:              "regex identity differing in case is rejected by default",
+			trustedIdentities: []string{"x509.subject: CN=build-[0-9]+,O=Acme Corp,C=US"},
+			expectErr:         true,
+		},
+		{
+			name:              "regex identity differing in case",
+			trustedIdentities: []string{"x509.subject: CN=build-[0-9]+,O=Acme Corp,C=US"},
+			matching:          TrustedIdentityMatching{CaseInsensitive: true},
+		},
+		{
+			name:              "regex identity differing in attribute order is rejected by default",
+			trustedIdentities: []string{"x509.subject: C=US,O=ACME Corp,CN=Build-[0-9]+"},
+			expectErr:         true,
+		},
+		{
+			name:              "regex identity differing in attribute order",
+			trustedIdentities: []string{"x509.subject: C=US,O=ACME Corp,CN=Build-[0-9]{1,3}"},
+			matching:          TrustedIdentityMatching{IgnoreAttributeOrder: true},
+		},
+		{
+			name:              "regex identity differing in attribute order and case",
+			trustedIdentities: []string{"x509.subject: c=us,o=acme corp,cn=build-[0-9]+"},
+			matching:          TrustedIdentityMatching{CaseInsensitive: true, IgnoreAttributeOrder: true},
+		},
+		{
+			name:              "regex identity listing a subset of the attributes",
+			trustedIdentities: []string{"x509.subject: CN=Build-.*,C=US"},
+			matching:          TrustedIdentityMatching{IgnoreAttributeOrder: true},
+		},
+		{
+			name:              "regex identity listing a subset of the attributes is rejected by default",
+			trustedIdentities: []string{"x509.subject: CN=Build-.*,C=US"},
+			expectErr:         true,
+		},
+		{
+			name:              "regex identity with a missing attribute",
+			trustedIdentities: []string{"x509.subject: C=US,OU=Release,CN=Build-[0-9]+"},
+			matching:          TrustedIdentityMatching{IgnoreAttributeOrder: true},
+			expectErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newTestTrustPolicyDoc(tt.trustedIdentities...)
+			identities, err := extractTrustedIdentityPatterns(&doc, tt.matching)
+			if err != nil {
+				t.Fatalf("failed to extract trusted identities: %v", err)
+			}
+			if len(identities) == 0 {
+				// exact identities without normalization are matched by
+				// notation, which compares them case-sensitively
+				if !tt.expectErr {
+					t.Fatal("expected the identities to be matched by Ratify")
+				}
+				return
+			}
+			identity := identities["default"][0]
+			if identity.matches(subject) == tt.expectErr {
+				t.Fatalf("expected match %v for subject %q", !tt.expectErr, subject.String())
+			}
+		})
+	}
+}
+
+func TestTrustedIdentityMatching_Spoofing(t *testing.T) {
+	spoofed := pkix.Name{CommonName: "build-x,O=Acme", Organization: []string{"Attacker"}}
+	repeated := pkix.Name{CommonName: "build-42", Organization: []string{"Attacker", "Acme"}}
+	for _, matching := range []TrustedIdentityMatching{
+		{CaseInsensitive: true},
+		{IgnoreAttributeOrder: true},
+		{CaseInsensitive: true, IgnoreAttributeOrder: true},
+	} {
+		doc := newTestTrustPolicyDoc("x509.subject: CN=build-.*,O=Acme", "x509.subject: O=Acme")
+		identities, err := extractTrustedIdentityPatterns(&doc, matching)
+		if err != nil {
+			t.Fatalf("failed to extract trusted identities: %v", err)
+		}
+		for _, identity := range identities["default"] {
+			if identity.matches(spoofed) {
+				t.Errorf("expected subject %q not to match with %+v", spoofed.String(), matching)
+			}
+			if identity.matches(repeated) {
+				t.Errorf("expected subject %q repeating the organization not to match with %+v", repeated.String(), matching)
+			}
+		}
+	}
+}
+
+func TestTrustedIdentity_EscapedComma(t *testing.T) {
+	doc := newTestTrustPolicyDoc(`x509.subject: CN=build-1,O=Contoso\, Inc`)
+	identities, err := extractTrustedIdentityPatterns(&doc, TrustedIdentityMatching{CaseInsensitive: true})
+	if err != nil {
+		t.Fatalf("failed to extract trusted identities: %v", err)
+	}
+	identity := identities["default"][0]
+	if !identity.matches(pkix.Name{CommonName: "build-1", Organization: []string{"Contoso, Inc"}}) {
+		t.Fatal("expected the escaped comma to be part of the organization")
+	}
+	if identity.matches(pkix.Name{CommonName: "build-1", Organization: []string{"Contoso"}}) {
+		t.Fatal("expected the organization to be matched in full")
+	}
+}
+
+func TestSplitPatternAttributes(t *testing.T) {
+	attributes := splitPatternAttributes(`CN=build-[0-9]{1,3},O=(Acme|Contoso\, Inc),C=US`)
+	expected := []string{"CN=build-[0-9]{1,3}", `O=(Acme|Contoso\, Inc)`, "C=US"}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Fatalf("expected %v, got %v", expected, attributes)
+	}
+}
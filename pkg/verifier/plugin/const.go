@@ -21,3 +21,13 @@ const (
 	SubjectEnvKey = "RATIFY_VERIFIER_SUBJECT"
 	VersionEnvKey = "RATIFY_VERIFIER_VERSION"
 )
+
+const (
+	// OnCrashFail fails the verification of a reference if the plugin crashes.
+	OnCrashFail = "fail"
+	// OnCrashSkip skips the result of a crashed plugin with a warning.
+	OnCrashSkip = "skip"
+
+	// maxReportedStderrBytes bounds the plugin stderr included in reports.
+	maxReportedStderrBytes = 4096
+)
@@ -18,9 +18,12 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/pkg/common"
@@ -43,6 +46,9 @@ type VerifierPlugin struct {
 	path             []string
 	rawConfig        config.VerifierConfig
 	executor         pluginCommon.Executor
+	// onCrash is the behavior when the plugin crashes, OnCrashFail or
+	// OnCrashSkip.
+	onCrash string
 }
 
 // NewVerifier creates a new verifier from the given configuration
@@ -70,6 +76,30 @@ func NewVerifier(version string, verifierConfig config.VerifierConfig, pluginPat
 		artifactTypes = append(artifactTypes, "*")
 	}
 
+	onCrash := OnCrashFail
+	if oc, ok := verifierConfig[types.OnCrash]; ok {
+		onCrash = fmt.Sprintf("%s", oc)
+		if onCrash != OnCrashFail && onCrash != OnCrashSkip {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("%s of verifier %s must be %s or %s, got %s", types.OnCrash, verifierName, OnCrashFail, OnCrashSkip, onCrash))
+		}
+	}
+
+	executor := &pluginCommon.DefaultExecutor{Stderr: os.Stderr}
+	if pt, ok := verifierConfig[types.PluginTimeout]; ok {
+		timeout, err := time.ParseDuration(fmt.Sprintf("%s", pt))
+		if err != nil || timeout <= 0 {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("%s of verifier %s must be a positive duration, got %v", types.PluginTimeout, verifierName, pt))
+		}
+		executor.Timeout = timeout
+	}
+	if mob, ok := verifierConfig[types.MaxOutputBytes]; ok {
+		maxOutputBytes, err := parseByteCount(mob)
+		if err != nil || maxOutputBytes <= 0 {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("%s of verifier %s must be a positive number of bytes, got %v", types.MaxOutputBytes, verifierName, mob))
+		}
+		executor.MaxOutputBytes = maxOutputBytes
+	}
+
 	return &VerifierPlugin{
 		name:             fmt.Sprintf("%s", verifierName),
 		verifierType:     verifierType,
@@ -78,13 +108,33 @@ func NewVerifier(version string, verifierConfig config.VerifierConfig, pluginPat
 		rawConfig:        verifierConfig,
 		artifactTypes:    artifactTypes,
 		nestedReferences: nestedReferences,
-		executor:         &pluginCommon.DefaultExecutor{Stderr: os.Stderr},
+		executor:         executor,
+		onCrash:          onCrash,
 	}, nil
 }
 
+// parseByteCount parses a byte count decoded from a JSON or YAML config.
+func parseByteCount(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("byte count %v is not an integer", v)
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unsupported byte count %v", value)
+	}
+}
+
 func (vp *VerifierPlugin) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
 	for _, at := range vp.artifactTypes {
-		if at == "*" || at == referenceDescriptor.ArtifactType {
+		if verifier.MatchArtifactType(at, referenceDescriptor.ArtifactType) {
 			return true
 		}
 	}
@@ -106,6 +156,10 @@ func (vp *VerifierPlugin) Verify(ctx context.Context,
 	referrerStoreConfig := store.GetConfig()
 	vr, err := vp.verifyReference(ctx, subjectReference, referenceDescriptor, referrerStoreConfig)
 	if err != nil {
+		var crashErr *pluginCommon.CrashError
+		if errors.As(err, &crashErr) {
+			return vp.crashResult(crashErr), nil
+		}
 		return verifier.VerifierResult{IsSuccess: false}, err
 	}
 
@@ -145,17 +199,45 @@ func (vp *VerifierPlugin) verifyReference(
 
 	stdoutBytes, err := vp.executor.ExecutePlugin(ctx, pluginPath, nil, verifierConfigBytes, pluginArgs.AsEnviron())
 	if err != nil {
+		var crashErr *pluginCommon.CrashError
+		if errors.As(err, &crashErr) {
+			return nil, crashErr
+		}
 		return nil, re.ErrorCodeVerifyPluginFailure.NewError(re.Verifier, vp.name, re.EmptyLink, err, nil, re.HideStackTrace)
 	}
 
 	result, err := types.GetVerifierResult(stdoutBytes)
 	if err != nil {
-		return nil, err
+		// a plugin that exits cleanly without a result is treated as crashed
+		return nil, &pluginCommon.CrashError{
+			Err:    fmt.Errorf("plugin returned malformed output: %w", err),
+			Stdout: string(stdoutBytes),
+		}
 	}
 
 	return result, nil
 }
 
+// crashResult returns the result of a reference whose verification crashed the
+// plugin, including the captured stderr of the plugin.
+func (vp *VerifierPlugin) crashResult(crashErr *pluginCommon.CrashError) verifier.VerifierResult {
+	stderr := crashErr.Stderr
+	if len(stderr) > maxReportedStderrBytes {
+		stderr = stderr[:maxReportedStderrBytes]
+	}
+	extensions := map[string]interface{}{
+		"exitCode": crashErr.ExitCode,
+		"stderr":   stderr,
+	}
+	if vp.onCrash == OnCrashSkip {
+		result := verifier.NewVerifierResult("", vp.name, vp.verifierType, fmt.Sprintf("verifier plugin crashed, skipping its result: %v", crashErr.Err), true, nil, extensions)
+		result.Level = verifier.LevelWarn
+		return result
+	}
+	verifierErr := re.ErrorCodeVerifyPluginFailure.NewError(re.Verifier, vp.name, re.EmptyLink, crashErr.Err, "verifier plugin crashed", re.HideStackTrace)
+	return verifier.NewVerifierResult("", vp.name, vp.verifierType, "verifier plugin crashed", false, &verifierErr, extensions)
+}
+
 func (vp *VerifierPlugin) GetNestedReferences() []string {
 	return vp.nestedReferences
 }
@@ -17,12 +17,19 @@ package plugin
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ratify-project/ratify/pkg/common"
+	pluginCommon "github.com/ratify-project/ratify/pkg/common/plugin"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	sm "github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
 )
 
 const (
@@ -218,3 +225,219 @@ func TestVerify_IsSuccessFalse_Expected(t *testing.T) {
 		t.Fatal("plugin expected to return isSuccess as false but got as true")
 	}
 }
+
+// writePlugin writes a shell script plugin to a temporary directory and returns
+// the directory.
+func writePlugin(t *testing.T, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on windows")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0700); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return dir
+}
+
+func TestVerify_PluginCrash(t *testing.T) {
+	testCases := []struct {
+		name            string
+		script          string
+		onCrash         string
+		expectedSuccess bool
+		expectedLevel   string
+		expectedStderr  string
+	}{
+		{
+			name:           "non-zero exit fails closed by default",
+			script:         "echo 'panic: runtime error' >&2\nexit 2\n",
+			expectedLevel:  verifier.LevelFail,
+			expectedStderr: "panic: runtime error\n",
+		},
+		{
+			name:            "non-zero exit is skipped with warning",
+			script:          "echo 'panic: runtime error' >&2\nexit 2\n",
+			onCrash:         OnCrashSkip,
+			expectedSuccess: true,
+			expectedLevel:   verifier.LevelWarn,
+			expectedStderr:  "panic: runtime error\n",
+		},
+		{
+			name:          "malformed output fails closed",
+			script:        "echo 'this is not a verifier result'\n",
+			onCrash:       OnCrashFail,
+			expectedLevel: verifier.LevelFail,
+		},
+		{
+			name:            "malformed output is skipped with warning",
+			script:          "echo '{\"isSuccess\": '\n",
+			onCrash:         OnCrashSkip,
+			expectedSuccess: true,
+			expectedLevel:   verifier.LevelWarn,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writePlugin(t, testPlugin, tc.script)
+			verifierConfig := map[string]interface{}{
+				"name": testPlugin,
+			}
+			if tc.onCrash != "" {
+				verifierConfig["onCrash"] = tc.onCrash
+			}
+			verifierPlugin, err := NewVerifier("1.0.0", verifierConfig, []string{dir})
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+
+			result, err := verifierPlugin.Verify(context.Background(), common.Reference{Original: "localhost"}, ocispecs.ReferenceDescriptor{}, &sm.TestStore{})
+			if err != nil {
+				t.Fatalf("expected crash to be reported in the result, got error %v", err)
+			}
+			if result.IsSuccess != tc.expectedSuccess {
+				t.Fatalf("expected isSuccess %v, got %v", tc.expectedSuccess, result.IsSuccess)
+			}
+			if result.GetLevel() != tc.expectedLevel {
+				t.Fatalf("expected level %s, got %s", tc.expectedLevel, result.GetLevel())
+			}
+			extensions := result.Extensions.(map[string]interface{})
+			if extensions["stderr"] != tc.expectedStderr {
+				t.Fatalf("expected stderr %q in report, got %q", tc.expectedStderr, extensions["stderr"])
+			}
+		})
+	}
+}
+
+func TestVerify_PluginFailResultIsNotCrash(t *testing.T) {
+	dir := writePlugin(t, testPlugin, "echo '{\"isSuccess\": false, \"message\": \"signature invalid\"}'\n")
+	verifierPlugin, err := NewVerifier("1.0.0", map[string]interface{}{"name": testPlugin, "onCrash": OnCrashSkip}, []string{dir})
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	result, err := verifierPlugin.Verify(context.Background(), common.Reference{Original: "localhost"}, ocispecs.ReferenceDescriptor{}, &sm.TestStore{})
+	if err != nil {
+		t.Fatalf("plugin execution failed %v", err)
+	}
+	if result.IsSuccess || result.Message != "signature invalid" {
+		t.Fatalf("expected the fail result of the plugin, got %+v", result)
+	}
+}
+
+func TestNewVerifier_ExecutionLimits(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		config                 map[string]interface{}
+		expectErr              bool
+		expectedTimeout        time.Duration
+		expectedMaxOutputBytes int64
+	}{
+		{
+			name:   "defaults",
+			config: map[string]interface{}{},
+		},
+		{
+			name: "configured limits",
+			config: map[string]interface{}{
+				"pluginTimeout":  "10s",
+				"maxOutputBytes": float64(2048),
+			},
+			expectedTimeout:        10 * time.Second,
+			expectedMaxOutputBytes: 2048,
+		},
+		{
+			name:      "invalid timeout",
+			config:    map[string]interface{}{"pluginTimeout": "soon"},
+			expectErr: true,
+		},
+		{
+			name:      "negative max output bytes",
+			config:    map[string]interface{}{"maxOutputBytes": -1},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config["name"] = testPlugin
+			v, err := NewVerifier("1.0.0", tc.config, []string{})
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			executor := v.(*VerifierPlugin).executor.(*pluginCommon.DefaultExecutor)
+			if executor.Timeout != tc.expectedTimeout || executor.MaxOutputBytes != tc.expectedMaxOutputBytes {
+				t.Fatalf("expected timeout %v and max output bytes %d, got %v and %d", tc.expectedTimeout, tc.expectedMaxOutputBytes, executor.Timeout, executor.MaxOutputBytes)
+			}
+		})
+	}
+}
+
+func TestVerify_PluginExceedsLimits(t *testing.T) {
+	testCases := []struct {
+		name        string
+		script      string
+		config      map[string]interface{}
+		expectedErr error
+	}{
+		{
+			name:        "plugin sleeps past the timeout",
+			script:      "sleep 10\n",
+			config:      map[string]interface{}{"pluginTimeout": "200ms"},
+			expectedErr: pluginCommon.ErrTimeout,
+		},
+		{
+			name:        "plugin emits oversized output",
+			script:      "head -c 4096 /dev/zero\n",
+			config:      map[string]interface{}{"maxOutputBytes": 1024},
+			expectedErr: pluginCommon.ErrOutputLimitExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writePlugin(t, testPlugin, tc.script)
+			tc.config["name"] = testPlugin
+			tc.config["onCrash"] = OnCrashSkip
+			verifierPlugin, err := NewVerifier("1.0.0", tc.config, []string{dir})
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+
+			_, err = verifierPlugin.Verify(context.Background(), common.Reference{Original: "localhost"}, ocispecs.ReferenceDescriptor{}, &sm.TestStore{})
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestNewVerifier_InvalidOnCrash(t *testing.T) {
+	if _, err := NewVerifier("1.0.0", map[string]interface{}{"name": testPlugin, "onCrash": "ignore"}, []string{}); err == nil {
+		t.Fatal("expected error for unsupported onCrash behavior")
+	}
+}
+
+func TestVerify_ExecutorErrorIsNotCrash(t *testing.T) {
+	verifierPlugin := &VerifierPlugin{
+		name:      testPlugin,
+		rawConfig: map[string]interface{}{"name": testPlugin},
+		onCrash:   OnCrashSkip,
+		executor: &TestExecutor{
+			find: func(_ string, _ []string) (string, error) {
+				return testPath, nil
+			},
+			execute: func(_ context.Context, _ string, _ []string, _ []byte, _ []string) ([]byte, error) {
+				return nil, pluginCommon.NewError(1, "failed to start plugin", "")
+			},
+		},
+	}
+
+	if _, err := verifierPlugin.Verify(context.Background(), common.Reference{Original: "localhost"}, ocispecs.ReferenceDescriptor{}, &sm.TestStore{}); err == nil {
+		t.Fatal("expected executor error to be returned")
+	}
+}
@@ -15,13 +15,42 @@ limitations under the License.
 
 package verifier
 
-import "github.com/ratify-project/ratify/errors"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/redact"
+)
+
+const (
+	// LevelPass indicates the verification passed.
+	LevelPass = "pass"
+	// LevelWarn indicates the verification found an issue that should be
+	// surfaced without failing the verification on its own. Policies decide
+	// whether a warning blocks admission.
+	LevelWarn = "warn"
+	// LevelFail indicates the verification failed.
+	LevelFail = "fail"
+	// LevelSkip indicates the verifier did not run because a policy condition
+	// excludes the subject from its verification or the verifier is disabled.
+	// Skips are reported with IsSuccess set to true.
+	LevelSkip = "skip"
+	// LevelTimeout indicates the verifier did not complete within the latency
+	// budget of the executor. Timeouts are reported with IsSuccess set to
+	// false and policies decide on the results of the completed verifiers.
+	LevelTimeout = "timeout"
+)
 
 // VerifierResult describes the result of verifying a reference manifest for a subject.
 // Note: This struct is used to represent the result of verification in v0.
 type VerifierResult struct { //nolint:revive // ignore linter to have unique type name
 	Subject   string `json:"subject,omitempty"`
 	IsSuccess bool   `json:"isSuccess"`
+	// Level is one of LevelPass, LevelWarn, LevelFail, LevelSkip or LevelTimeout. If empty, the level
+	// is derived from IsSuccess. Warnings should be reported with IsSuccess
+	// set to true so that policies unaware of levels do not block on them.
+	Level string `json:"level,omitempty"`
 	// Name will be deprecated in v2, tracking issue: https://github.com/ratify-project/ratify/issues/1707
 	Name         string `json:"name,omitempty"`
 	VerifierName string `json:"verifierName,omitempty"`
@@ -35,6 +64,14 @@ type VerifierResult struct { //nolint:revive // ignore linter to have unique typ
 	Remediation     string           `json:"remediation,omitempty"`
 	Extensions      interface{}      `json:"extensions,omitempty"`
 	NestedResults   []VerifierResult `json:"nestedResults,omitempty"`
+	// ValidUntil is the earliest expiry of the trust material and signature
+	// the result relies on, if known. Cached results are not used past it.
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+
+	// cause is the error the verification failed with, if any. It is not
+	// serialized and lets the executor classify the failure, e.g. as
+	// transient.
+	cause error
 }
 
 // NewVerifierResult creates a new VerifierResult object with the given parameters.
@@ -58,5 +95,42 @@ func NewVerifierResult(subject, verifierName, verifierType, message string, isSu
 		ErrorReason:  errorReason,
 		Remediation:  remediation,
 		Extensions:   extensions,
+		cause:        causeOf(err),
+	}
+}
+
+// causeOf returns the error as an error interface, nil if there is none.
+func causeOf(err *errors.Error) error {
+	if err == nil {
+		return nil
+	}
+	return *err
+}
+
+// Cause returns the error the verification failed with, nil if the result was
+// not created from an error.
+func (vr VerifierResult) Cause() error {
+	return vr.cause
+}
+
+// MarshalJSON serializes the result with credentials masked in its messages.
+func (vr VerifierResult) MarshalJSON() ([]byte, error) {
+	type verifierResult VerifierResult
+	result := verifierResult(vr)
+	result.Message = redact.String(result.Message)
+	result.ErrorReason = redact.String(result.ErrorReason)
+	result.Remediation = redact.String(result.Remediation)
+	return json.Marshal(result)
+}
+
+// GetLevel returns the level of the result, deriving it from IsSuccess if no
+// level is set explicitly.
+func (vr VerifierResult) GetLevel() string {
+	if vr.Level != "" {
+		return vr.Level
+	}
+	if vr.IsSuccess {
+		return LevelPass
 	}
+	return LevelFail
 }
@@ -16,10 +16,13 @@ limitations under the License.
 package verifier
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/redact"
 )
 
 const (
@@ -81,3 +84,54 @@ func TestNewVerifierResult(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   VerifierResult
+		expected string
+	}{
+		{
+			name:     "derived pass",
+			result:   VerifierResult{IsSuccess: true},
+			expected: LevelPass,
+		},
+		{
+			name:     "derived fail",
+			result:   VerifierResult{IsSuccess: false},
+			expected: LevelFail,
+		},
+		{
+			name:     "explicit warn",
+			result:   VerifierResult{IsSuccess: true, Level: LevelWarn},
+			expected: LevelWarn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if level := tt.result.GetLevel(); level != tt.expected {
+				t.Errorf("expected level %s, got %s", tt.expected, level)
+			}
+		})
+	}
+}
+
+func TestVerifierResult_MarshalJSON(t *testing.T) {
+	const refreshToken = "test-marshal-refresh-token"
+	redact.Register(refreshToken)
+
+	verifierErr := errors.ErrorCodeVerifyPluginFailure.WithDetail("failed to fetch signature").WithError(fmt.Errorf("registry rejected refresh token %s", refreshToken))
+	result := NewVerifierResult("", "notation", "notation", "", false, &verifierErr, nil)
+	result.NestedResults = []VerifierResult{{Message: "nested failure with refresh token " + refreshToken}}
+
+	output, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("failed to marshal verifier result: %v", err)
+	}
+	if strings.Contains(string(output), refreshToken) {
+		t.Fatalf("expected refresh token to be redacted from serialized result, got %s", output)
+	}
+	if result.NestedResults[0].Message != "nested failure with refresh token "+refreshToken {
+		t.Fatalf("expected marshaling not to modify the result")
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+const (
+	// UnknownArtifactTypeSkip ignores referrers no verifier is routed to.
+	UnknownArtifactTypeSkip = "skip"
+	// UnknownArtifactTypeWarn reports a warning for referrers no verifier is
+	// routed to.
+	UnknownArtifactTypeWarn = "warn"
+	// UnknownArtifactTypeFail fails the verification of referrers no verifier
+	// is routed to.
+	UnknownArtifactTypeFail = "fail"
+)
+
+// Detector inspects a referrer descriptor and returns the type the referrer is
+// routed by, e.g. a signature format recognized from its annotations. It
+// returns an empty string if the format is not recognized.
+type Detector func(ctx context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) string
+
+type registry struct {
+	mu        sync.RWMutex
+	mappings  map[string]string
+	detectors []Detector
+}
+
+var defaultRegistry = &registry{mappings: make(map[string]string)}
+
+// RegisterMapping routes referrers of the artifact or media type to the
+// verifier with the given name or type.
+func RegisterMapping(artifactType, verifierName string) {
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	defaultRegistry.mappings[artifactType] = verifierName
+}
+
+// RegisterDetector adds a detection hook consulted, in registration order,
+// before the artifact and media type of a referrer.
+func RegisterDetector(detector Detector) {
+	if detector == nil {
+		panic("detector cannot be nil")
+	}
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	defaultRegistry.detectors = append(defaultRegistry.detectors, detector)
+}
+
+// ValidateUnknownArtifactType returns an error if the action for referrers no
+// verifier is routed to is not supported.
+func ValidateUnknownArtifactType(action string) error {
+	switch action {
+	case "", UnknownArtifactTypeSkip, UnknownArtifactTypeWarn, UnknownArtifactTypeFail:
+		return nil
+	}
+	return fmt.Errorf("unknownArtifactType must be %s, %s or %s, got %s", UnknownArtifactTypeSkip, UnknownArtifactTypeWarn, UnknownArtifactTypeFail, action)
+}
+
+// ValidateArtifactTypeAliases returns an error if an alias maps to an empty
+// artifact type or to another alias.
+func ValidateArtifactTypeAliases(aliases map[string]string) error {
+	for alias, artifactType := range aliases {
+		if artifactType == "" {
+			return fmt.Errorf("artifactTypeAliases must map %s to an artifact type", alias)
+		}
+		if _, ok := aliases[artifactType]; ok && artifactType != alias {
+			return fmt.Errorf("artifactTypeAliases must not chain aliases, %s maps to alias %s", alias, artifactType)
+		}
+	}
+	return nil
+}
+
+// NormalizeArtifactType returns the artifact type the aliases map the artifact
+// type to, or the artifact type itself if it is not an alias.
+func NormalizeArtifactType(artifactType string, aliases map[string]string) string {
+	if normalized, ok := aliases[artifactType]; ok {
+		return normalized
+	}
+	return artifactType
+}
+
+// Route returns the verifiers the referrer is routed to. The detected type,
+// artifact type and media type of the referrer are looked up, in that order,
+// in the given mappings and then in the registered mappings. A mapping matches
+// a verifier by name or type. Referrers without a matching mapping are routed
+// to the verifiers declaring they can verify them.
+func Route(ctx context.Context, referenceDescriptor ocispecs.ReferenceDescriptor, verifiers []verifier.ReferenceVerifier, mappings map[string]string) []verifier.ReferenceVerifier {
+	defaultRegistry.mu.RLock()
+	detectors := defaultRegistry.detectors
+	defaultRegistry.mu.RUnlock()
+
+	types := make([]string, 0, len(detectors)+2)
+	for _, detector := range detectors {
+		if detected := detector(ctx, referenceDescriptor); detected != "" {
+			types = append(types, detected)
+			break
+		}
+	}
+	types = append(types, referenceDescriptor.ArtifactType, referenceDescriptor.MediaType)
+
+	for _, artifactType := range types {
+		if artifactType == "" {
+			continue
+		}
+		verifierName, ok := mappings[artifactType]
+		if !ok {
+			verifierName, ok = defaultRegistry.mapping(artifactType)
+		}
+		if !ok {
+			continue
+		}
+		if routed := verifiersNamed(verifiers, verifierName); len(routed) > 0 {
+			return routed
+		}
+	}
+
+	var routed []verifier.ReferenceVerifier
+	for _, v := range verifiers {
+		if v.CanVerify(ctx, referenceDescriptor) {
+			routed = append(routed, v)
+		}
+	}
+	return routed
+}
+
+func (r *registry) mapping(artifactType string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	verifierName, ok := r.mappings[artifactType]
+	return verifierName, ok
+}
+
+func verifiersNamed(verifiers []verifier.ReferenceVerifier, name string) []verifier.ReferenceVerifier {
+	var matched []verifier.ReferenceVerifier
+	for _, v := range verifiers {
+		if v.Name() == name || v.Type() == name {
+			matched = append(matched, v)
+		}
+	}
+	return matched
+}
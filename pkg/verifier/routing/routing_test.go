@@ -0,0 +1,199 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routing
+
+import (
+	"context"
+	"testing"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+const (
+	notationArtifactType = "application/vnd.cncf.notary.signature"
+	customArtifactType   = "application/vnd.example.signature"
+	customFormat         = "example"
+)
+
+type testVerifier struct {
+	name         string
+	verifierType string
+	artifactType string
+}
+
+func (v *testVerifier) Name() string {
+	return v.name
+}
+
+func (v *testVerifier) Type() string {
+	return v.verifierType
+}
+
+func (v *testVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	return referenceDescriptor.ArtifactType == v.artifactType
+}
+
+func (v *testVerifier) Verify(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor, _ referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	return verifier.VerifierResult{IsSuccess: true}, nil
+}
+
+func (v *testVerifier) GetNestedReferences() []string {
+	return []string{}
+}
+
+func resetRegistry(t *testing.T) {
+	t.Helper()
+	saved := defaultRegistry
+	defaultRegistry = &registry{mappings: make(map[string]string)}
+	t.Cleanup(func() { defaultRegistry = saved })
+}
+
+func names(verifiers []verifier.ReferenceVerifier) []string {
+	result := make([]string, 0, len(verifiers))
+	for _, v := range verifiers {
+		result = append(result, v.Name())
+	}
+	return result
+}
+
+func TestRoute(t *testing.T) {
+	verifiers := []verifier.ReferenceVerifier{
+		&testVerifier{name: "notation-1", verifierType: "notation", artifactType: notationArtifactType},
+		&testVerifier{name: "custom-1", verifierType: "custom"},
+	}
+	annotatedDetector := func(_ context.Context, desc ocispecs.ReferenceDescriptor) string {
+		if desc.Annotations["format"] == customFormat {
+			return customFormat
+		}
+		return ""
+	}
+
+	testCases := []struct {
+		name       string
+		desc       ocispecs.ReferenceDescriptor
+		mappings   map[string]string
+		registered map[string]string
+		detector   Detector
+		expected   []string
+	}{
+		{
+			name:     "falls back to verifiers able to verify the referrer",
+			desc:     ocispecs.ReferenceDescriptor{ArtifactType: notationArtifactType},
+			expected: []string{"notation-1"},
+		},
+		{
+			name:     "no verifier for unknown artifact type",
+			desc:     ocispecs.ReferenceDescriptor{ArtifactType: customArtifactType},
+			expected: []string{},
+		},
+		{
+			name:       "registered mapping routes by verifier type",
+			desc:       ocispecs.ReferenceDescriptor{ArtifactType: customArtifactType},
+			registered: map[string]string{customArtifactType: "custom"},
+			expected:   []string{"custom-1"},
+		},
+		{
+			name:       "configured mapping takes precedence over registered mapping",
+			desc:       ocispecs.ReferenceDescriptor{ArtifactType: customArtifactType},
+			mappings:   map[string]string{customArtifactType: "notation-1"},
+			registered: map[string]string{customArtifactType: "custom"},
+			expected:   []string{"notation-1"},
+		},
+		{
+			name:     "mapping to a missing verifier falls back",
+			desc:     ocispecs.ReferenceDescriptor{ArtifactType: notationArtifactType},
+			mappings: map[string]string{notationArtifactType: "missing"},
+			expected: []string{"notation-1"},
+		},
+		{
+			name: "detected format is routed",
+			desc: ocispecs.ReferenceDescriptor{
+				Descriptor:   oci.Descriptor{Annotations: map[string]string{"format": customFormat}},
+				ArtifactType: notationArtifactType,
+			},
+			registered: map[string]string{customFormat: "custom-1"},
+			detector:   annotatedDetector,
+			expected:   []string{"custom-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetRegistry(t)
+			for artifactType, verifierName := range tc.registered {
+				RegisterMapping(artifactType, verifierName)
+			}
+			if tc.detector != nil {
+				RegisterDetector(tc.detector)
+			}
+
+			routed := names(Route(context.Background(), tc.desc, verifiers, tc.mappings))
+			if len(routed) != len(tc.expected) {
+				t.Fatalf("expected verifiers %v, got %v", tc.expected, routed)
+			}
+			for idx := range routed {
+				if routed[idx] != tc.expected[idx] {
+					t.Fatalf("expected verifiers %v, got %v", tc.expected, routed)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateUnknownArtifactType(t *testing.T) {
+	for _, action := range []string{"", UnknownArtifactTypeSkip, UnknownArtifactTypeWarn, UnknownArtifactTypeFail} {
+		if err := ValidateUnknownArtifactType(action); err != nil {
+			t.Fatalf("expected action %q to be valid, got %v", action, err)
+		}
+	}
+	if err := ValidateUnknownArtifactType("ignore"); err == nil {
+		t.Fatalf("expected error for unsupported action")
+	}
+}
+
+func TestValidateArtifactTypeAliases(t *testing.T) {
+	testCases := []struct {
+		name        string
+		aliases     map[string]string
+		expectedErr bool
+	}{
+		{name: "no aliases"},
+		{name: "aliases", aliases: map[string]string{"sbom/cyclonedx": "application/vnd.cyclonedx+json", "cyclonedx": "application/vnd.cyclonedx+json"}},
+		{name: "empty artifact type", aliases: map[string]string{"sbom/cyclonedx": ""}, expectedErr: true},
+		{name: "chained alias", aliases: map[string]string{"cyclonedx": "sbom/cyclonedx", "sbom/cyclonedx": "application/vnd.cyclonedx+json"}, expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ValidateArtifactTypeAliases(tc.aliases); (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeArtifactType(t *testing.T) {
+	aliases := map[string]string{"sbom/cyclonedx": "application/vnd.cyclonedx+json"}
+	if normalized := NormalizeArtifactType("sbom/cyclonedx", aliases); normalized != "application/vnd.cyclonedx+json" {
+		t.Fatalf("expected the alias to be normalized, got %s", normalized)
+	}
+	if normalized := NormalizeArtifactType(notationArtifactType, aliases); normalized != notationArtifactType {
+		t.Fatalf("expected the artifact type to be kept, got %s", normalized)
+	}
+}
@@ -18,8 +18,10 @@ package types
 import (
 	"encoding/json"
 	"io"
+	"time"
 
 	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/redact"
 	"github.com/ratify-project/ratify/pkg/verifier"
 )
 
@@ -31,6 +33,10 @@ const (
 	ArtifactTypes    string = "artifactTypes"
 	NestedReferences string = "nestedReferences"
 	Source           string = "source"
+	OnCrash          string = "onCrash"
+	PluginTimeout    string = "pluginTimeout"
+	MaxOutputBytes   string = "maxOutputBytes"
+	Enabled          string = "enabled"
 )
 
 const (
@@ -49,6 +55,7 @@ const (
 // VerifierResult describes the verification result returned from the verifier plugin
 type VerifierResult struct {
 	IsSuccess   bool   `json:"isSuccess"`
+	Level       string `json:"level,omitempty"`
 	Message     string `json:"message"`
 	ErrorReason string `json:"errorReason,omitempty"`
 	Remediation string `json:"remediation,omitempty"`
@@ -59,6 +66,25 @@ type VerifierResult struct {
 	Type         string      `json:"type,omitempty"`
 	VerifierType string      `json:"verifierType,omitempty"`
 	Extensions   interface{} `json:"extensions"`
+	// ValidUntil is the earliest expiry of the trust material and signature
+	// the result relies on, if known.
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+}
+
+// MarshalJSON serializes the result with credentials masked in its messages.
+func (vr VerifierResult) MarshalJSON() ([]byte, error) {
+	type verifierResult VerifierResult
+	result := verifierResult(vr)
+	result.Message = redact.String(result.Message)
+	result.ErrorReason = redact.String(result.ErrorReason)
+	result.Remediation = redact.String(result.Remediation)
+	return json.Marshal(result)
+}
+
+// GetLevel returns the severity level of the result, derived from IsSuccess if
+// the verifier did not set one.
+func (vr VerifierResult) GetLevel() string {
+	return verifier.VerifierResult{IsSuccess: vr.IsSuccess, Level: vr.Level}.GetLevel()
 }
 
 // GetVerifierResult encodes the given JSON data into verify result object
@@ -69,12 +95,16 @@ func GetVerifierResult(result []byte) (*verifier.VerifierResult, error) {
 	}
 	return &verifier.VerifierResult{
 		IsSuccess:    vResult.IsSuccess,
+		Level:        vResult.Level,
 		Message:      vResult.Message,
+		ErrorReason:  vResult.ErrorReason,
+		Remediation:  vResult.Remediation,
 		Name:         vResult.Name,
 		Type:         vResult.Type,
 		VerifierName: vResult.Name,
 		VerifierType: vResult.Type,
 		Extensions:   vResult.Extensions,
+		ValidUntil:   vResult.ValidUntil,
 	}, nil
 }
 
@@ -112,6 +142,7 @@ func CreateVerifierResult(verifierName, verifierType, message string, isSuccess
 func NewVerifierResult(result verifier.VerifierResult) VerifierResult {
 	return VerifierResult{
 		IsSuccess:    result.IsSuccess,
+		Level:        result.Level,
 		Message:      result.Message,
 		Name:         result.Name,
 		Type:         result.Type,
@@ -120,5 +151,6 @@ func NewVerifierResult(result verifier.VerifierResult) VerifierResult {
 		Extensions:   result.Extensions,
 		ErrorReason:  result.ErrorReason,
 		Remediation:  result.Remediation,
+		ValidUntil:   result.ValidUntil,
 	}
 }
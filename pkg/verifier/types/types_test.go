@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/verifier"
 )
 
 const (
@@ -81,3 +82,32 @@ func TestCreateVerifierResult(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVerifierResult_Remediation(t *testing.T) {
+	result, err := GetVerifierResult([]byte(`{"isSuccess":false,"message":"` + testMsg1 + `","errorReason":"` + testErrReason + `","remediation":"` + testRemediation + `"}`))
+	if err != nil {
+		t.Fatalf("failed to get verifier result: %v", err)
+	}
+	if result.ErrorReason != testErrReason {
+		t.Errorf("expected error reason %s, got %s", testErrReason, result.ErrorReason)
+	}
+	if result.Remediation != testRemediation {
+		t.Errorf("expected remediation %s, got %s", testRemediation, result.Remediation)
+	}
+}
+
+func TestVerifierResultLevel(t *testing.T) {
+	result, err := GetVerifierResult([]byte(`{"isSuccess":true,"level":"warn","message":"` + testMsg1 + `"}`))
+	if err != nil {
+		t.Fatalf("failed to get verifier result: %v", err)
+	}
+	if result.GetLevel() != verifier.LevelWarn {
+		t.Errorf("expected level %s, got %s", verifier.LevelWarn, result.GetLevel())
+	}
+	if level := NewVerifierResult(*result).GetLevel(); level != verifier.LevelWarn {
+		t.Errorf("expected level %s, got %s", verifier.LevelWarn, level)
+	}
+	if level := (VerifierResult{IsSuccess: false}).GetLevel(); level != verifier.LevelFail {
+		t.Errorf("expected level %s, got %s", verifier.LevelFail, level)
+	}
+}
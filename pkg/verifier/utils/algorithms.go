@@ -0,0 +1,174 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// digestAlgorithms names the digests of the signature algorithms.
+var digestAlgorithms = map[crypto.Hash]string{
+	crypto.MD5:    "MD5",
+	crypto.SHA1:   "SHA-1",
+	crypto.SHA256: "SHA-256",
+	crypto.SHA384: "SHA-384",
+	crypto.SHA512: "SHA-512",
+}
+
+// certificateDigests are the digests of the certificate signature algorithms.
+// Ed25519 signs the certificate without a separate digest.
+var certificateDigests = map[x509.SignatureAlgorithm]crypto.Hash{
+	x509.MD5WithRSA:       crypto.MD5,
+	x509.SHA1WithRSA:      crypto.SHA1,
+	x509.ECDSAWithSHA1:    crypto.SHA1,
+	x509.SHA256WithRSA:    crypto.SHA256,
+	x509.ECDSAWithSHA256:  crypto.SHA256,
+	x509.SHA256WithRSAPSS: crypto.SHA256,
+	x509.SHA384WithRSA:    crypto.SHA384,
+	x509.ECDSAWithSHA384:  crypto.SHA384,
+	x509.SHA384WithRSAPSS: crypto.SHA384,
+	x509.SHA512WithRSA:    crypto.SHA512,
+	x509.ECDSAWithSHA512:  crypto.SHA512,
+	x509.SHA512WithRSAPSS: crypto.SHA512,
+}
+
+// AlgorithmPolicy restricts the keys and digests a signature may use. Keys are
+// allowed by type and size, e.g. RSA-3072, ECDSA-P256 or ED25519, and digests
+// by name, e.g. SHA-256.
+type AlgorithmPolicy struct {
+	keys    map[string]bool
+	digests map[string]bool
+}
+
+// NewAlgorithmPolicy returns the policy allowing the given key and digest
+// algorithms, or nil if none is given so that any algorithm is allowed.
+func NewAlgorithmPolicy(allowed []string) (*AlgorithmPolicy, error) {
+	if len(allowed) == 0 {
+		return nil, nil
+	}
+	policy := &AlgorithmPolicy{keys: make(map[string]bool), digests: make(map[string]bool)}
+	for _, algorithm := range allowed {
+		algorithm = strings.ToUpper(strings.TrimSpace(algorithm))
+		switch {
+		case isDigestAlgorithm(algorithm):
+			policy.digests[algorithm] = true
+		case isKeyAlgorithm(algorithm):
+			policy.keys[algorithm] = true
+		default:
+			return nil, fmt.Errorf("unsupported signature algorithm %q, expected a key such as RSA-3072, ECDSA-P256 or ED25519, or a digest such as SHA-256", algorithm)
+		}
+	}
+	if len(policy.keys) == 0 || len(policy.digests) == 0 && !policy.keys["ED25519"] {
+		return nil, fmt.Errorf("allowed signature algorithms must list at least one key and one digest algorithm")
+	}
+	return policy, nil
+}
+
+func isDigestAlgorithm(algorithm string) bool {
+	for _, name := range digestAlgorithms {
+		if name == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+func isKeyAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case "ECDSA-P256", "ECDSA-P384", "ECDSA-P521", "ED25519":
+		return true
+	}
+	var bits int
+	_, err := fmt.Sscanf(algorithm, "RSA-%d", &bits)
+	return err == nil && algorithm == fmt.Sprintf("RSA-%d", bits)
+}
+
+// KeyAlgorithm returns the type and size of the public key, e.g. RSA-2048.
+func KeyAlgorithm(publicKey crypto.PublicKey) string {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + strings.ReplaceAll(key.Curve.Params().Name, "-", "")
+	case ed25519.PublicKey:
+		return "ED25519"
+	}
+	return fmt.Sprintf("%T", publicKey)
+}
+
+// CheckKey returns an error if the type or size of the key is not allowed.
+func (p *AlgorithmPolicy) CheckKey(publicKey crypto.PublicKey) error {
+	if p == nil {
+		return nil
+	}
+	if algorithm := KeyAlgorithm(publicKey); !p.keys[algorithm] {
+		return fmt.Errorf("key algorithm %s is not allowed", algorithm)
+	}
+	return nil
+}
+
+// CheckDigest returns an error if the digest is not allowed.
+func (p *AlgorithmPolicy) CheckDigest(hash crypto.Hash) error {
+	if p == nil {
+		return nil
+	}
+	name, ok := digestAlgorithms[hash]
+	if !ok {
+		name = hash.String()
+	}
+	if !p.digests[name] {
+		return fmt.Errorf("digest algorithm %s is not allowed", name)
+	}
+	return nil
+}
+
+// CheckCertificates returns an error if the key or signature algorithm of a
+// certificate of the chain is not allowed. The self-signature of a root
+// certificate is not checked since its trust does not rely on it.
+func (p *AlgorithmPolicy) CheckCertificates(certs []*x509.Certificate) error {
+	if p == nil {
+		return nil
+	}
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		if err := p.CheckKey(cert.PublicKey); err != nil {
+			return fmt.Errorf("certificate %q: %w", cert.Subject.String(), err)
+		}
+		if bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			continue
+		}
+		if cert.SignatureAlgorithm == x509.PureEd25519 {
+			continue
+		}
+		hash, ok := certificateDigests[cert.SignatureAlgorithm]
+		if !ok {
+			return fmt.Errorf("certificate %q: signature algorithm %s is not allowed", cert.Subject.String(), cert.SignatureAlgorithm)
+		}
+		if err := p.CheckDigest(hash); err != nil {
+			return fmt.Errorf("certificate %q: %w", cert.Subject.String(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// EarliestExpiry returns the earliest of the given expiries, ignoring zero
+// times, or nil if none is set.
+func EarliestExpiry(expiries ...time.Time) *time.Time {
+	var earliest *time.Time
+	for _, expiry := range expiries {
+		if expiry.IsZero() {
+			continue
+		}
+		if earliest == nil || expiry.Before(*earliest) {
+			expiry := expiry
+			earliest = &expiry
+		}
+	}
+	return earliest
+}
+
+// LatestExpiry returns the latest expiry of alternative signatures, any of
+// which a result can rely on, or nil if one of them does not expire or none
+// is given.
+func LatestExpiry(expiries ...*time.Time) *time.Time {
+	var latest *time.Time
+	for _, expiry := range expiries {
+		if expiry == nil {
+			return nil
+		}
+		if latest == nil || expiry.After(*latest) {
+			latest = expiry
+		}
+	}
+	return latest
+}
+
+// CertificateChainExpiries returns the expiries of the certificates of a
+// chain.
+func CertificateChainExpiries(certs []*x509.Certificate) []time.Time {
+	expiries := make([]time.Time, 0, len(certs))
+	for _, cert := range certs {
+		if cert != nil {
+			expiries = append(expiries, cert.NotAfter)
+		}
+	}
+	return expiries
+}
+
+// ExpiringCertificate returns the first certificate of a chain to expire
+// within window after now, or nil if none does.
+func ExpiringCertificate(certs []*x509.Certificate, window time.Duration, now time.Time) *x509.Certificate {
+	var expiring *x509.Certificate
+	deadline := now.Add(window)
+	for _, cert := range certs {
+		if cert == nil || cert.NotAfter.After(deadline) {
+			continue
+		}
+		if expiring == nil || cert.NotAfter.Before(expiring.NotAfter) {
+			expiring = cert
+		}
+	}
+	return expiring
+}
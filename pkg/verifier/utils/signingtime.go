@@ -0,0 +1,37 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// CheckSigningTime returns an error if the signing time falls outside the
+// validity window of the signing certificate.
+func CheckSigningTime(signingTime time.Time, cert *x509.Certificate) error {
+	if cert == nil {
+		return fmt.Errorf("no signing certificate to check the signing time %s against", signingTime.UTC().Format(time.RFC3339))
+	}
+	if signingTime.IsZero() {
+		return fmt.Errorf("the signature has no signing time")
+	}
+	if signingTime.Before(cert.NotBefore) || signingTime.After(cert.NotAfter) {
+		return fmt.Errorf("signing time %s is outside the validity window [%s, %s] of signing certificate %q", signingTime.UTC().Format(time.RFC3339), cert.NotBefore.UTC().Format(time.RFC3339), cert.NotAfter.UTC().Format(time.RFC3339), cert.Subject.String())
+	}
+	return nil
+}
@@ -24,9 +24,10 @@ import (
 
 // VerifierCache is an interface that defines methods to set/get results from a cache
 type VerifierCache interface {
-	// GetVerifyResult gets the result from the cache with the given subject as the key
-	GetVerifyResult(ctx context.Context, subjectRefString string) (et.VerifyResult, bool)
+	// GetVerifyResult gets the result from the cache with the given subject as the key,
+	// along with the time the subject was verified at
+	GetVerifyResult(ctx context.Context, subjectRefString string) (et.VerifyResult, time.Time, bool)
 
-	// SetVerifyResult sets the verify result in the cache with the given TTL
-	SetVerifyResult(ctx context.Context, subjectRefString string, verifyResult et.VerifyResult, ttl time.Duration)
+	// SetVerifyResult sets the verify result of the subject verified at verifiedAt in the cache with the given TTL
+	SetVerifyResult(ctx context.Context, subjectRefString string, verifyResult et.VerifyResult, verifiedAt time.Time, ttl time.Duration)
 }
@@ -27,14 +27,21 @@ type Cache struct {
 	syncMap *SyncMapWithExpiration
 }
 
-func (memoryCache Cache) GetVerifyResult(_ context.Context, subjectRefString string) (et.VerifyResult, bool) {
+// cachedVerifyResult is a verify result along with the time it was verified at.
+type cachedVerifyResult struct {
+	verifyResult et.VerifyResult
+	verifiedAt   time.Time
+}
+
+func (memoryCache Cache) GetVerifyResult(_ context.Context, subjectRefString string) (et.VerifyResult, time.Time, bool) {
 	item, ok := memoryCache.syncMap.GetEntry(subjectRefString)
 	if !ok {
-		return et.VerifyResult{}, false
+		return et.VerifyResult{}, time.Time{}, false
 	}
-	return item.(et.VerifyResult), true
+	cached := item.(cachedVerifyResult)
+	return cached.verifyResult, cached.verifiedAt, true
 }
 
-func (memoryCache Cache) SetVerifyResult(_ context.Context, subjectRefString string, verifyResult et.VerifyResult, ttl time.Duration) {
-	memoryCache.syncMap.SetEntry(subjectRefString, verifyResult, ttl)
+func (memoryCache Cache) SetVerifyResult(_ context.Context, subjectRefString string, verifyResult et.VerifyResult, verifiedAt time.Time, ttl time.Duration) {
+	memoryCache.syncMap.SetEntry(subjectRefString, cachedVerifyResult{verifyResult: verifyResult, verifiedAt: verifiedAt}, ttl)
 }
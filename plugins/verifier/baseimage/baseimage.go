@@ -0,0 +1,496 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/attestation"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+)
+
+const (
+	// MissingBaseImageFail fails verification if no base image is found.
+	MissingBaseImageFail string = "fail"
+	// MissingBaseImageWarn reports a warning if no base image is found.
+	MissingBaseImageWarn string = "warn"
+
+	dockerPurlPrefix   string = "pkg:docker/"
+	dockerImageSource  string = "docker-image://"
+	repositoryWildcard string = "/*"
+)
+
+type PluginConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// AllowedBaseImages lists the approved base images. Each entry is either a
+	// digest (sha256:...), a repository (docker.io/library/alpine), a
+	// repository prefix (mcr.microsoft.com/*) or a repository pinned to a
+	// digest (docker.io/library/alpine@sha256:...).
+	AllowedBaseImages []string `json:"allowedBaseImages"`
+	// MissingBaseImage is either 'fail' or 'warn' and decides the result if no
+	// base image information is found. Defaults to 'fail'.
+	MissingBaseImage string `json:"missingBaseImage,omitempty"`
+	// ReplayProtection is rejected: the provenance read by the verifier is not
+	// authenticated, its replay protection is configured on the attestations
+	// of the cosign verifier verifying its signature instead.
+	ReplayProtection *attestation.ReplayProtectionConfig `json:"replayProtection,omitempty"`
+	// VerifySubjectDigest fails verification if the subject digest is not one
+	// of the digests the provenance declares for the artifacts it built.
+	VerifySubjectDigest bool `json:"verifySubjectDigest,omitempty"`
+}
+
+type PluginInputConfig struct {
+	Config PluginConfig `json:"config"`
+}
+
+// baseImage is a base image referenced by the build of the subject.
+type baseImage struct {
+	Repository string `json:"repository,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+func (b baseImage) String() string {
+	if b.Repository == "" {
+		return b.Digest
+	}
+	if b.Digest == "" {
+		return b.Repository
+	}
+	return b.Repository + "@" + b.Digest
+}
+
+// provenance holds the fields of SLSA v0.2 and v1 provenance predicates that
+// reference the images used by the build.
+type provenance struct {
+	// SLSA v0.2, as produced by BuildKit
+	Materials  []resourceDescriptor `json:"materials"`
+	Invocation struct {
+		Parameters buildRequest `json:"parameters"`
+	} `json:"invocation"`
+	BuildConfig *buildConfig `json:"buildConfig"`
+	// SLSA v1
+	BuildDefinition struct {
+		ExternalParameters struct {
+			Request buildRequest `json:"request"`
+		} `json:"externalParameters"`
+		InternalParameters struct {
+			BuildConfig *buildConfig `json:"buildConfig"`
+		} `json:"internalParameters"`
+		ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+}
+
+// buildRequest holds the frontend arguments of a BuildKit build. The image of
+// a gateway frontend, e.g. docker/dockerfile:1.5, is a material of the build
+// but not a base image of the subject.
+type buildRequest struct {
+	Args map[string]string `json:"args"`
+}
+
+// buildConfig is the LLB definition of a BuildKit build, only recorded in
+// provenance generated in max mode.
+type buildConfig struct {
+	Definition []buildStep `json:"llbDefinition"`
+}
+
+// buildStep is an LLB operation. Its inputs are the outputs of other steps,
+// e.g. step0:0, the first of them being the filesystem the step builds on.
+type buildStep struct {
+	ID string `json:"id"`
+	Op struct {
+		Op struct {
+			Source *struct {
+				Identifier string `json:"identifier"`
+			} `json:"source"`
+		} `json:"Op"`
+	} `json:"op"`
+	Inputs []string `json:"inputs"`
+}
+
+type resourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// subjectDigestMismatchError is returned if the provenance does not declare
+// the digest of the subject as built.
+type subjectDigestMismatchError struct {
+	subjectDigest   digest.Digest
+	declaredDigests []string
+}
+
+func (e *subjectDigestMismatchError) Error() string {
+	if len(e.declaredDigests) == 0 {
+		return fmt.Sprintf("provenance declares no subject digest to compare with %s", e.subjectDigest)
+	}
+	return fmt.Sprintf("subject digest %s does not match the provenance subject digests %s", e.subjectDigest, strings.Join(e.declaredDigests, ", "))
+}
+
+func main() {
+	skel.PluginMain("baseimage", "1.0.0", VerifyReference, []string{"1.0.0"})
+}
+
+func parseInput(stdin []byte) (*PluginConfig, error) {
+	conf := PluginInputConfig{}
+
+	if err := json.Unmarshal(stdin, &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse stdin for the input: %w", err)
+	}
+
+	if conf.Config.MissingBaseImage == "" {
+		conf.Config.MissingBaseImage = MissingBaseImageFail
+	}
+	if conf.Config.MissingBaseImage != MissingBaseImageFail && conf.Config.MissingBaseImage != MissingBaseImageWarn {
+		return nil, fmt.Errorf("missingBaseImage must be %s or %s, got %s", MissingBaseImageFail, MissingBaseImageWarn, conf.Config.MissingBaseImage)
+	}
+	if conf.Config.ReplayProtection != nil {
+		return nil, fmt.Errorf("replayProtection is not supported since the provenance is not authenticated by the verifier, configure attestations.replayProtection of the cosign verifier instead")
+	}
+
+	return &conf.Config, nil
+}
+
+// VerifyReference verifies that the base image of the final build stage of the
+// subject, as recorded in its provenance or in its base image annotations, is
+// approved.
+func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+	input, err := parseInput(args.StdinData)
+	if err != nil {
+		return nil, err
+	}
+	verifierType := input.Name
+	if input.Type != "" {
+		verifierType = input.Type
+	}
+
+	ctx := context.Background()
+	baseImages, err := provenanceBaseImages(ctx, subjectReference, referenceDescriptor, referrerStore, input.VerifySubjectDigest)
+	var mismatchErr *subjectDigestMismatchError
+	if errors.As(err, &mismatchErr) {
+		extensions := map[string]interface{}{
+			"subjectDigest":            mismatchErr.subjectDigest.String(),
+			"provenanceSubjectDigests": mismatchErr.declaredDigests,
+		}
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Provenance does not match subject %s: %s.", subjectReference, mismatchErr.Error()))
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, extensions)
+		return &result, nil
+	}
+	if err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to read provenance of subject %s.", subjectReference)).WithError(err)
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, nil)
+		return &result, nil
+	}
+	if len(baseImages) == 0 {
+		baseImages = annotationBaseImages(ctx, subjectReference, referrerStore)
+	}
+
+	if len(baseImages) == 0 {
+		message := fmt.Sprintf("No base image information found for subject %s.", subjectReference)
+		if input.MissingBaseImage == MissingBaseImageWarn {
+			result := verifier.NewVerifierResult("", input.Name, verifierType, message, true, nil, nil)
+			result.Level = verifier.LevelWarn
+			return &result, nil
+		}
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(message)
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, nil)
+		return &result, nil
+	}
+
+	var disallowed []string
+	for _, image := range baseImages {
+		if !isAllowed(image, input.AllowedBaseImages) {
+			disallowed = append(disallowed, image.String())
+		}
+	}
+	extensions := map[string]interface{}{"baseImages": baseImages}
+	if len(disallowed) > 0 {
+		extensions["disallowedBaseImages"] = disallowed
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Base images are not approved: %s.", strings.Join(disallowed, ", ")))
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, extensions)
+		return &result, nil
+	}
+
+	result := verifier.NewVerifierResult("", input.Name, verifierType, "All base images are approved.", true, nil, extensions)
+	return &result, nil
+}
+
+// provenanceBaseImages returns the images referenced by the provenance
+// attestation after checking, if verifySubjectDigest is set, that it declares
+// the subject digest.
+func provenanceBaseImages(ctx context.Context, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore, verifySubjectDigest bool) ([]baseImage, error) {
+	referenceManifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, referenceDescriptor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reference manifest %s: %w", referenceDescriptor.Digest, err)
+	}
+
+	var baseImages []baseImage
+	for _, blobDesc := range referenceManifest.Blobs {
+		blob, err := referrerStore.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch blob %s: %w", blobDesc.Digest, err)
+		}
+		statement, err := attestation.DecodeStatement(blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse provenance in blob %s: %w", blobDesc.Digest, err)
+		}
+		if verifySubjectDigest {
+			if err := checkSubjectDigest(statement, subjectReference.Digest); err != nil {
+				return nil, err
+			}
+		}
+		images, err := parseProvenance(statement)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse provenance in blob %s: %w", blobDesc.Digest, err)
+		}
+		baseImages = append(baseImages, images...)
+	}
+	return baseImages, nil
+}
+
+// checkSubjectDigest returns a subjectDigestMismatchError if the subject digest
+// is not among the digests of the statement subjects.
+func checkSubjectDigest(statement *attestation.Statement, subjectDigest digest.Digest) error {
+	var declaredDigests []string
+	for _, subject := range statement.Subject {
+		for algorithm, encoded := range subject.Digest {
+			if algorithm == subjectDigest.Algorithm().String() && encoded == subjectDigest.Encoded() {
+				return nil
+			}
+			declaredDigests = append(declaredDigests, algorithm+":"+encoded)
+		}
+	}
+	sort.Strings(declaredDigests)
+	return &subjectDigestMismatchError{subjectDigest: subjectDigest, declaredDigests: declaredDigests}
+}
+
+// parseProvenance extracts the base image of the final stage of the build from
+// an in-toto provenance statement. The other images used by the build, such as
+// the frontend or the bases of intermediate stages, are not base images of the
+// subject. The final stage is traced through the build definition if the
+// provenance records it. Otherwise the base image is only known if the build
+// used a single image besides the frontend.
+func parseProvenance(statement *attestation.Statement) ([]baseImage, error) {
+	var predicate provenance
+	if len(statement.Predicate) > 0 {
+		if err := json.Unmarshal(statement.Predicate, &predicate); err != nil {
+			return nil, err
+		}
+	}
+
+	request := predicate.Invocation.Parameters
+	if request.Args == nil {
+		request = predicate.BuildDefinition.ExternalParameters.Request
+	}
+	frontend := frontendRepository(request)
+	var images []baseImage
+	dependencies := append(predicate.Materials, predicate.BuildDefinition.ResolvedDependencies...)
+	for _, dependency := range dependencies {
+		if image, ok := parseDockerPurl(dependency); ok && image.Repository != frontend {
+			images = append(images, image)
+		}
+	}
+
+	config := predicate.BuildConfig
+	if config == nil {
+		config = predicate.BuildDefinition.InternalParameters.BuildConfig
+	}
+	if config == nil || len(config.Definition) == 0 {
+		if len(images) != 1 {
+			return nil, nil
+		}
+		return images, nil
+	}
+	image, ok := config.finalStageBase()
+	if !ok {
+		return nil, nil
+	}
+	if image.Digest == "" {
+		// the digest the source was resolved to is a material of the build
+		for _, material := range images {
+			if material.Repository == image.Repository {
+				image.Digest = material.Digest
+				break
+			}
+		}
+	}
+	return []baseImage{image}, nil
+}
+
+// frontendRepository returns the repository of the gateway frontend image of
+// the build, empty if the build used the built-in frontend.
+func frontendRepository(request buildRequest) string {
+	source := request.Args["source"]
+	if source == "" {
+		source = request.Args["cmdline"]
+	}
+	if source == "" {
+		return ""
+	}
+	return normalizeRepository(source)
+}
+
+// finalStageBase follows the filesystem of the output of the build back to the
+// image it is based on. It returns false if the final stage is not based on an
+// image, e.g. FROM scratch.
+func (config buildConfig) finalStageBase() (baseImage, bool) {
+	steps := make(map[string]buildStep, len(config.Definition))
+	for _, step := range config.Definition {
+		steps[step.ID] = step
+	}
+	// the output of the build is the last step of the definition
+	step := config.Definition[len(config.Definition)-1]
+	for range config.Definition {
+		if source := step.Op.Op.Source; source != nil {
+			return parseImageSource(source.Identifier)
+		}
+		if len(step.Inputs) == 0 {
+			return baseImage{}, false
+		}
+		id, _, _ := strings.Cut(step.Inputs[0], ":")
+		next, ok := steps[id]
+		if !ok {
+			return baseImage{}, false
+		}
+		step = next
+	}
+	return baseImage{}, false
+}
+
+// parseImageSource converts the identifier of an LLB image source, e.g.
+// docker-image://docker.io/library/alpine:3.18@sha256:..., into a base image.
+func parseImageSource(identifier string) (baseImage, bool) {
+	if !strings.HasPrefix(identifier, dockerImageSource) {
+		return baseImage{}, false
+	}
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(identifier, dockerImageSource))
+	if err != nil {
+		return baseImage{}, false
+	}
+	image := baseImage{Repository: named.Name()}
+	if digested, ok := named.(reference.Digested); ok {
+		image.Digest = digested.Digest().String()
+	}
+	return image, true
+}
+
+// parseDockerPurl converts a docker package URL, e.g.
+// pkg:docker/alpine@3.18?platform=linux%2Famd64, into a base image.
+func parseDockerPurl(dependency resourceDescriptor) (baseImage, bool) {
+	if !strings.HasPrefix(dependency.URI, dockerPurlPrefix) {
+		return baseImage{}, false
+	}
+	name := strings.TrimPrefix(dependency.URI, dockerPurlPrefix)
+	var qualifiers string
+	if i := strings.Index(name, "?"); i >= 0 {
+		name, qualifiers = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if values, err := url.ParseQuery(qualifiers); err == nil && values.Get("repository_url") != "" {
+		name = values.Get("repository_url") + "/" + name
+	}
+
+	image := baseImage{Repository: normalizeRepository(name)}
+	if sha256, ok := dependency.Digest["sha256"]; ok {
+		image.Digest = "sha256:" + sha256
+	}
+	return image, true
+}
+
+// annotationBaseImages returns the base image recorded in the OCI base image
+// annotations of the subject manifest.
+func annotationBaseImages(ctx context.Context, subjectReference common.Reference, referrerStore referrerstore.ReferrerStore) []baseImage {
+	subjectDesc, err := referrerStore.GetSubjectDescriptor(ctx, subjectReference)
+	if err != nil {
+		return nil
+	}
+	manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, ocispecs.ReferenceDescriptor{Descriptor: subjectDesc.Descriptor})
+	if err != nil {
+		return nil
+	}
+
+	name := manifest.Annotations[imagespec.AnnotationBaseImageName]
+	digest := manifest.Annotations[imagespec.AnnotationBaseImageDigest]
+	if name == "" && digest == "" {
+		return nil
+	}
+	image := baseImage{Digest: digest}
+	if name != "" {
+		image.Repository = normalizeRepository(name)
+	}
+	return []baseImage{image}
+}
+
+// normalizeRepository returns the fully qualified repository of an image
+// reference without tag or digest, e.g. alpine:3.18 becomes
+// docker.io/library/alpine.
+func normalizeRepository(name string) string {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return name
+	}
+	return named.Name()
+}
+
+// isAllowed reports whether the base image matches any of the allowlist
+// entries.
+func isAllowed(image baseImage, allowedBaseImages []string) bool {
+	for _, allowed := range allowedBaseImages {
+		repository, digest := allowed, ""
+		if i := strings.LastIndex(allowed, "@"); i >= 0 {
+			repository, digest = allowed[:i], allowed[i+1:]
+		} else if strings.HasPrefix(allowed, "sha256:") {
+			repository, digest = "", allowed
+		}
+
+		if digest != "" && digest != image.Digest {
+			continue
+		}
+		if repository == "" {
+			return true
+		}
+		if image.Repository == "" {
+			continue
+		}
+		if strings.HasSuffix(repository, repositoryWildcard) {
+			if strings.HasPrefix(image.Repository, strings.TrimSuffix(repository, "*")) {
+				return true
+			}
+			continue
+		}
+		if normalizeRepository(repository) == image.Repository {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,397 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/attestation"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+)
+
+const (
+	alpineDigest  = "sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b"
+	ubuntuDigest  = "sha256:8a37d68f4f73ebf3d4efafbcf66379bf3728902a8038616808f04e34a9ab63ee"
+	golangDigest  = "sha256:9bf6b9b3b2f4b0c0e3b5c8a1f2f4e9f4e0d2c6c3a8b1b8d7c6a5f4e3d2c1b0a9"
+	slsaV02Format = `{
+	"_type": "https://in-toto.io/Statement/v0.1",
+	"predicateType": "https://slsa.dev/provenance/v0.2",
+	"predicate": {
+		"builder": {"id": ""},
+		"buildType": "https://mobyproject.org/buildkit@v1",
+		"materials": [
+			{
+				"uri": "pkg:docker/%s?platform=linux%%2Famd64",
+				"digest": {"sha256": "%s"}
+			},
+			{
+				"uri": "https://github.com/ratify-project/ratify.git#main",
+				"digest": {"sha1": "e4b2a5c1d7f8"}
+			}
+		]
+	}
+}`
+	slsaV1Format = `{
+	"_type": "https://in-toto.io/Statement/v1",
+	"predicateType": "https://slsa.dev/provenance/v1",
+	"predicate": {
+		"buildDefinition": {
+			"buildType": "https://mobyproject.org/buildkit@v1",
+			"resolvedDependencies": [
+				{
+					"uri": "pkg:docker/%s?platform=linux%%2Famd64",
+					"digest": {"sha256": "%s"}
+				}
+			]
+		}
+	}
+}`
+)
+
+func TestParseProvenance(t *testing.T) {
+	slsaV1 := fmt.Sprintf(slsaV1Format, "golang@1.22", golangDigest[len("sha256:"):])
+	// a multi-stage build of an alpine image from a golang builder stage with
+	// the docker/dockerfile frontend
+	multiStageMaterials := fmt.Sprintf(`[
+		{"uri": "pkg:docker/docker/dockerfile@1.5", "digest": {"sha256": "%s"}},
+		{"uri": "pkg:docker/golang@1.22", "digest": {"sha256": "%s"}},
+		{"uri": "pkg:docker/alpine@3.18", "digest": {"sha256": "%s"}}
+	]`, ubuntuDigest[len("sha256:"):], golangDigest[len("sha256:"):], alpineDigest[len("sha256:"):])
+	multiStageDefinition := fmt.Sprintf(`{"llbDefinition": [
+		{"id": "step0", "op": {"Op": {"source": {"identifier": "docker-image://docker.io/library/golang:1.22@%s"}}}},
+		{"id": "step1", "op": {"Op": {"exec": {}}}, "inputs": ["step0:0"]},
+		{"id": "step2", "op": {"Op": {"source": {"identifier": "docker-image://docker.io/library/alpine:3.18"}}}},
+		{"id": "step3", "op": {"Op": {"file": {}}}, "inputs": ["step2:0", "step1:0"]},
+		{"id": "step4", "op": {"Op": null}, "inputs": ["step3:0"]}
+	]}`, golangDigest)
+	frontendArgs := `{"source": "docker/dockerfile:1.5", "cmdline": "docker/dockerfile:1.5"}`
+	tests := []struct {
+		name     string
+		blob     string
+		expected []baseImage
+		isErr    bool
+	}{
+		{
+			name:     "slsa v0.2 materials",
+			blob:     fmt.Sprintf(slsaV02Format, "alpine@3.18", alpineDigest[len("sha256:"):]),
+			expected: []baseImage{{Repository: "docker.io/library/alpine", Digest: alpineDigest}},
+		},
+		{
+			name:     "slsa v1 resolved dependencies",
+			blob:     slsaV1,
+			expected: []baseImage{{Repository: "docker.io/library/golang", Digest: golangDigest}},
+		},
+		{
+			name:     "dsse envelope",
+			blob:     fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":"%s"}`, base64.StdEncoding.EncodeToString([]byte(slsaV1))),
+			expected: []baseImage{{Repository: "docker.io/library/golang", Digest: golangDigest}},
+		},
+		{
+			name:     "repository url qualifier",
+			blob:     fmt.Sprintf(slsaV02Format, "dotnet/runtime@8.0?repository_url=mcr.microsoft.com&", ubuntuDigest[len("sha256:"):]),
+			expected: []baseImage{{Repository: "mcr.microsoft.com/dotnet/runtime", Digest: ubuntuDigest}},
+		},
+		{
+			name:     "slsa v0.2 frontend image excluded",
+			blob:     fmt.Sprintf(`{"predicate": {"invocation": {"parameters": {"frontend": "gateway.v0", "args": %s}}, "materials": [{"uri": "pkg:docker/docker/dockerfile@1.5", "digest": {"sha256": "%s"}}, {"uri": "pkg:docker/alpine@3.18", "digest": {"sha256": "%s"}}]}}`, frontendArgs, ubuntuDigest[len("sha256:"):], alpineDigest[len("sha256:"):]),
+			expected: []baseImage{{Repository: "docker.io/library/alpine", Digest: alpineDigest}},
+		},
+		{
+			name:     "slsa v0.2 multi-stage build without build definition",
+			blob:     fmt.Sprintf(`{"predicate": {"invocation": {"parameters": {"frontend": "gateway.v0", "args": %s}}, "materials": %s}}`, frontendArgs, multiStageMaterials),
+			expected: nil,
+		},
+		{
+			name:     "slsa v0.2 multi-stage build with build definition",
+			blob:     fmt.Sprintf(`{"predicate": {"invocation": {"parameters": {"frontend": "gateway.v0", "args": %s}}, "buildConfig": %s, "materials": %s}}`, frontendArgs, multiStageDefinition, multiStageMaterials),
+			expected: []baseImage{{Repository: "docker.io/library/alpine", Digest: alpineDigest}},
+		},
+		{
+			name:     "slsa v1 multi-stage build with build definition",
+			blob:     fmt.Sprintf(`{"predicate": {"buildDefinition": {"externalParameters": {"request": {"frontend": "gateway.v0", "args": %s}}, "internalParameters": {"buildConfig": %s}, "resolvedDependencies": %s}}}`, frontendArgs, multiStageDefinition, multiStageMaterials),
+			expected: []baseImage{{Repository: "docker.io/library/alpine", Digest: alpineDigest}},
+		},
+		{
+			name:     "final stage from scratch",
+			blob:     fmt.Sprintf(`{"predicate": {"buildConfig": {"llbDefinition": [{"id": "step0", "op": {"Op": {"file": {}}}}, {"id": "step1", "op": {"Op": null}, "inputs": ["step0:0"]}]}, "materials": %s}}`, multiStageMaterials),
+			expected: nil,
+		},
+		{
+			name:  "invalid json",
+			blob:  "invalid",
+			isErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var images []baseImage
+			statement, err := attestation.DecodeStatement([]byte(tt.blob))
+			if err == nil {
+				images, err = parseProvenance(statement)
+			}
+			if tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+			if fmt.Sprint(images) != fmt.Sprint(tt.expected) {
+				t.Fatalf("expected base images %v, got %v", tt.expected, images)
+			}
+		})
+	}
+}
+
+func TestIsAllowed(t *testing.T) {
+	image := baseImage{Repository: "docker.io/library/alpine", Digest: alpineDigest}
+	tests := []struct {
+		name     string
+		image    baseImage
+		allowed  []string
+		expected bool
+	}{
+		{
+			name:     "empty allowlist",
+			image:    image,
+			expected: false,
+		},
+		{
+			name:     "digest",
+			image:    image,
+			allowed:  []string{alpineDigest},
+			expected: true,
+		},
+		{
+			name:     "short repository name",
+			image:    image,
+			allowed:  []string{"alpine"},
+			expected: true,
+		},
+		{
+			name:     "repository prefix",
+			image:    image,
+			allowed:  []string{"docker.io/library/*"},
+			expected: true,
+		},
+		{
+			name:     "repository prefix does not match",
+			image:    image,
+			allowed:  []string{"mcr.microsoft.com/*"},
+			expected: false,
+		},
+		{
+			name:     "repository pinned to digest",
+			image:    image,
+			allowed:  []string{"docker.io/library/alpine@" + alpineDigest},
+			expected: true,
+		},
+		{
+			name:     "repository pinned to other digest",
+			image:    image,
+			allowed:  []string{"docker.io/library/alpine@" + ubuntuDigest},
+			expected: false,
+		},
+		{
+			name:     "digest only base image against repository",
+			image:    baseImage{Digest: alpineDigest},
+			allowed:  []string{"alpine"},
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := isAllowed(tt.image, tt.allowed); allowed != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, allowed)
+			}
+		})
+	}
+}
+
+func TestVerifyReference(t *testing.T) {
+	subjectDigest := digest.FromString("test_subject_digest")
+	otherSubjectDigest := digest.FromString("other_subject_digest")
+	manifestDigest := digest.FromString("test_manifest_digest")
+	blobDigest := digest.FromString("test_blob_digest")
+	alpineProvenance := fmt.Sprintf(slsaV02Format, "alpine@3.18", alpineDigest[len("sha256:"):])
+	timedProvenanceFormat := `{
+	"_type": "https://in-toto.io/Statement/v0.1",
+	"subject": [{"name": "test_subject_path", "digest": {"sha256": "%s"}}],
+	"predicateType": "https://slsa.dev/provenance/v0.2",
+	"predicate": {
+		"metadata": {"buildFinishedOn": "%s"},
+		"materials": [{"uri": "pkg:docker/alpine@3.18", "digest": {"sha256": "%s"}}]
+	}
+}`
+	freshProvenance := fmt.Sprintf(timedProvenanceFormat, subjectDigest.Encoded(), time.Now().Add(-time.Hour).UTC().Format(time.RFC3339), alpineDigest[len("sha256:"):])
+
+	tests := []struct {
+		name               string
+		stdinData          string
+		provenance         string
+		subjectAnnotations map[string]string
+		isSuccess          bool
+		level              string
+		message            string
+		errorReason        string
+		isErr              bool
+	}{
+		{
+			name:      "invalid stdin data",
+			stdinData: "invalid",
+			isErr:     true,
+		},
+		{
+			name:      "invalid missingBaseImage",
+			stdinData: `{"config":{"name":"baseimage","missingBaseImage":"ignore"}}`,
+			isErr:     true,
+		},
+		{
+			name:       "approved base image",
+			stdinData:  `{"config":{"name":"baseimage","allowedBaseImages":["docker.io/library/alpine"]}}`,
+			provenance: alpineProvenance,
+			isSuccess:  true,
+			level:      verifier.LevelPass,
+		},
+		{
+			name:        "disallowed base image",
+			stdinData:   `{"config":{"name":"baseimage","allowedBaseImages":["mcr.microsoft.com/*"]}}`,
+			provenance:  alpineProvenance,
+			isSuccess:   false,
+			level:       verifier.LevelFail,
+			errorReason: fmt.Sprintf("Base images are not approved: docker.io/library/alpine@%s.", alpineDigest),
+		},
+		{
+			name:       "approved base image from annotations",
+			stdinData:  `{"config":{"name":"baseimage","allowedBaseImages":["` + ubuntuDigest + `"]}}`,
+			provenance: `{"predicate":{}}`,
+			subjectAnnotations: map[string]string{
+				oci.AnnotationBaseImageName:   "ubuntu:22.04",
+				oci.AnnotationBaseImageDigest: ubuntuDigest,
+			},
+			isSuccess: true,
+			level:     verifier.LevelPass,
+		},
+		{
+			name:        "missing base image fails by default",
+			stdinData:   `{"config":{"name":"baseimage","allowedBaseImages":["alpine"]}}`,
+			provenance:  `{"predicate":{}}`,
+			isSuccess:   false,
+			level:       verifier.LevelFail,
+			errorReason: "No base image information found for subject test_subject_path@" + subjectDigest.String() + ".",
+		},
+		{
+			name:       "missing base image warns",
+			stdinData:  `{"config":{"name":"baseimage","allowedBaseImages":["alpine"],"missingBaseImage":"warn"}}`,
+			provenance: `{"predicate":{}}`,
+			isSuccess:  true,
+			level:      verifier.LevelWarn,
+		},
+		{
+			name:       "provenance subject digest matches",
+			stdinData:  `{"config":{"name":"baseimage","allowedBaseImages":["docker.io/library/alpine"],"verifySubjectDigest":true}}`,
+			provenance: freshProvenance,
+			isSuccess:  true,
+			level:      verifier.LevelPass,
+		},
+		{
+			name:        "provenance subject digest mismatch",
+			stdinData:   `{"config":{"name":"baseimage","allowedBaseImages":["docker.io/library/alpine"],"verifySubjectDigest":true}}`,
+			provenance:  fmt.Sprintf(timedProvenanceFormat, otherSubjectDigest.Encoded(), time.Now().UTC().Format(time.RFC3339), alpineDigest[len("sha256:"):]),
+			isSuccess:   false,
+			level:       verifier.LevelFail,
+			errorReason: fmt.Sprintf("Provenance does not match subject test_subject_path@%s: subject digest %s does not match the provenance subject digests %s.", subjectDigest, subjectDigest, otherSubjectDigest),
+		},
+		{
+			name:        "provenance without subject digest",
+			stdinData:   `{"config":{"name":"baseimage","allowedBaseImages":["docker.io/library/alpine"],"verifySubjectDigest":true}}`,
+			provenance:  alpineProvenance,
+			isSuccess:   false,
+			level:       verifier.LevelFail,
+			errorReason: fmt.Sprintf("Provenance does not match subject test_subject_path@%s: provenance declares no subject digest to compare with %s.", subjectDigest, subjectDigest),
+		},
+		{
+			name:      "replay protection of unauthenticated provenance",
+			stdinData: `{"config":{"name":"baseimage","allowedBaseImages":["docker.io/library/alpine"],"replayProtection":{"maxAge":"24h"}}}`,
+			isErr:     true,
+		},
+		{
+			name:       "invalid provenance",
+			stdinData:  `{"config":{"name":"baseimage","allowedBaseImages":["alpine"],"missingBaseImage":"warn"}}`,
+			provenance: "invalid",
+			isSuccess:  false,
+			level:      verifier.LevelFail,
+			message:    "Failed to read provenance of subject test_subject_path@" + subjectDigest.String() + ".",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subjectRef := common.Reference{
+				Path:     "test_subject_path",
+				Original: "test_subject_path@" + subjectDigest.String(),
+				Digest:   subjectDigest,
+			}
+			store := &mocks.MemoryTestStore{
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDigest: {Descriptor: oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: subjectDigest}},
+				},
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					subjectDigest: {Annotations: tt.subjectAnnotations},
+					manifestDigest: {
+						Blobs: []oci.Descriptor{{MediaType: "application/vnd.in-toto+json", Digest: blobDigest}},
+					},
+				},
+				Blobs: map[digest.Digest][]byte{
+					blobDigest: []byte(tt.provenance),
+				},
+			}
+			cmdArgs := skel.CmdArgs{
+				Version:   "1.0.0",
+				Subject:   subjectRef.String(),
+				StdinData: []byte(tt.stdinData),
+			}
+			refDesc := ocispecs.ReferenceDescriptor{
+				ArtifactType: "application/vnd.in-toto+json",
+				Descriptor:   oci.Descriptor{Digest: manifestDigest},
+			}
+
+			result, err := VerifyReference(&cmdArgs, subjectRef, refDesc, store)
+			if tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+			if tt.isErr {
+				return
+			}
+			if result.IsSuccess != tt.isSuccess {
+				t.Fatalf("expected success %v, got %v: %s", tt.isSuccess, result.IsSuccess, result.ErrorReason)
+			}
+			if result.GetLevel() != tt.level {
+				t.Fatalf("expected level %s, got %s", tt.level, result.GetLevel())
+			}
+			if tt.message != "" && result.Message != tt.message {
+				t.Fatalf("expected message %q, got %q", tt.message, result.Message)
+			}
+			if tt.errorReason != "" && result.ErrorReason != tt.errorReason {
+				t.Fatalf("expected error reason %q, got %q", tt.errorReason, result.ErrorReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+)
+
+const (
+	// ActionFail fails verification.
+	ActionFail string = "fail"
+	// ActionWarn reports a warning.
+	ActionWarn string = "warn"
+
+	SpdxJSONMediaType      string = "application/spdx+json"
+	CycloneDXJSONMediaType string = "application/vnd.cyclonedx+json"
+
+	eolDateLayout            string = "2006-01-02"
+	spdxOperatingSystem      string = "OPERATING-SYSTEM"
+	cycloneDXOperatingSystem string = "operating-system"
+)
+
+type PluginConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// EOLDates maps base operating systems to their end-of-life date in the
+	// form YYYY-MM-DD. A base OS is the os-release ID optionally followed by a
+	// version prefix, e.g. alpine:3.15, debian:10 or windows:10.0.17763. The
+	// entry with the longest version prefix matching the base OS applies.
+	EOLDates map[string]string `json:"eolDates"`
+	// PastEOL is either 'fail' or 'warn' and decides the result if a base OS
+	// is past its end-of-life date. Defaults to 'fail'.
+	PastEOL string `json:"pastEOL,omitempty"`
+	// MissingBaseOS is either 'fail' or 'warn' and decides the result if the
+	// base OS of the subject is not found. Defaults to 'fail'.
+	MissingBaseOS string `json:"missingBaseOS,omitempty"`
+}
+
+type PluginInputConfig struct {
+	Config PluginConfig `json:"config"`
+}
+
+// baseOS is the operating system an image is built on.
+type baseOS struct {
+	ID      string `json:"id"`
+	Version string `json:"version,omitempty"`
+}
+
+func (o baseOS) String() string {
+	if o.Version == "" {
+		return o.ID
+	}
+	return o.ID + ":" + o.Version
+}
+
+// sbomDocument holds the fields of SPDX and CycloneDX JSON documents
+// describing the operating system packages.
+type sbomDocument struct {
+	// SPDX
+	Packages []struct {
+		Name                  string `json:"name"`
+		VersionInfo           string `json:"versionInfo"`
+		PrimaryPackagePurpose string `json:"primaryPackagePurpose"`
+	} `json:"packages"`
+	// CycloneDX
+	Components []struct {
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"components"`
+}
+
+func main() {
+	skel.PluginMain("baseoseol", "1.0.0", VerifyReference, []string{"1.0.0"})
+}
+
+func parseInput(stdin []byte) (*PluginConfig, map[string]time.Time, error) {
+	conf := PluginInputConfig{}
+
+	if err := json.Unmarshal(stdin, &conf); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse stdin for the input: %w", err)
+	}
+
+	for _, action := range []*string{&conf.Config.PastEOL, &conf.Config.MissingBaseOS} {
+		if *action == "" {
+			*action = ActionFail
+		}
+	}
+	if conf.Config.PastEOL != ActionFail && conf.Config.PastEOL != ActionWarn {
+		return nil, nil, fmt.Errorf("pastEOL must be %s or %s, got %s", ActionFail, ActionWarn, conf.Config.PastEOL)
+	}
+	if conf.Config.MissingBaseOS != ActionFail && conf.Config.MissingBaseOS != ActionWarn {
+		return nil, nil, fmt.Errorf("missingBaseOS must be %s or %s, got %s", ActionFail, ActionWarn, conf.Config.MissingBaseOS)
+	}
+	eolDates := make(map[string]time.Time, len(conf.Config.EOLDates))
+	for os, date := range conf.Config.EOLDates {
+		if strings.TrimSpace(os) == "" {
+			return nil, nil, fmt.Errorf("eolDates must not contain an empty base OS")
+		}
+		eolDate, err := time.Parse(eolDateLayout, date)
+		if err != nil {
+			return nil, nil, fmt.Errorf("end-of-life date of %s must be in the form YYYY-MM-DD, got %s", os, date)
+		}
+		eolDates[strings.ToLower(os)] = eolDate
+	}
+
+	return &conf.Config, eolDates, nil
+}
+
+// VerifyReference verifies that the base OS of the subject is not past its
+// end-of-life date. The base OS is read from the SBOM if the reference is one,
+// otherwise from the os.version of the image config of the subject.
+func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+	input, eolDates, err := parseInput(args.StdinData)
+	if err != nil {
+		return nil, err
+	}
+	verifierType := input.Name
+	if input.Type != "" {
+		verifierType = input.Type
+	}
+
+	ctx := context.Background()
+	var systems []baseOS
+	if isSBOM(referenceDescriptor.ArtifactType) {
+		systems, err = sbomBaseOS(ctx, subjectReference, referenceDescriptor, referrerStore)
+	} else {
+		systems, err = imageConfigBaseOS(ctx, subjectReference, referrerStore)
+	}
+	if err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to read the base OS of subject %s.", subjectReference)).WithError(err)
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, nil)
+		return &result, nil
+	}
+
+	if len(systems) == 0 {
+		message := fmt.Sprintf("No base OS information found for subject %s.", subjectReference)
+		return actionResult(input.Name, verifierType, input.MissingBaseOS, message, nil), nil
+	}
+
+	var pastEOL []string
+	eolExtensions := make(map[string]string, len(systems))
+	for _, system := range systems {
+		entry, eolDate, ok := lookupEOLDate(eolDates, system)
+		if !ok {
+			continue
+		}
+		eolExtensions[system.String()] = eolDate.Format(eolDateLayout)
+		if !time.Now().Before(eolDate) {
+			pastEOL = append(pastEOL, fmt.Sprintf("%s reached end of life on %s (%s)", system, eolDate.Format(eolDateLayout), entry))
+		}
+	}
+	extensions := map[string]interface{}{"baseOS": systems, "eolDates": eolExtensions}
+	if len(pastEOL) > 0 {
+		extensions["pastEOL"] = pastEOL
+		message := fmt.Sprintf("Base OS is past end of life: %s.", strings.Join(pastEOL, "; "))
+		return actionResult(input.Name, verifierType, input.PastEOL, message, extensions), nil
+	}
+
+	result := verifier.NewVerifierResult("", input.Name, verifierType, "Base OS is not past end of life.", true, nil, extensions)
+	return &result, nil
+}
+
+// actionResult returns a warning or a failure with the message depending on
+// the configured action.
+func actionResult(name string, verifierType string, action string, message string, extensions map[string]interface{}) *verifier.VerifierResult {
+	if action == ActionWarn {
+		result := verifier.NewVerifierResult("", name, verifierType, message, true, nil, extensions)
+		result.Level = verifier.LevelWarn
+		return &result
+	}
+	verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(message)
+	result := verifier.NewVerifierResult("", name, verifierType, "", false, &verifierErr, extensions)
+	return &result
+}
+
+// isSBOM reports whether the artifact type is the one of an SPDX or CycloneDX
+// JSON SBOM.
+func isSBOM(artifactType string) bool {
+	return artifactType == SpdxJSONMediaType || artifactType == CycloneDXJSONMediaType
+}
+
+// sbomBaseOS returns the operating systems described by the SBOM.
+func sbomBaseOS(ctx context.Context, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) ([]baseOS, error) {
+	referenceManifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, referenceDescriptor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reference manifest %s: %w", referenceDescriptor.Digest, err)
+	}
+
+	var systems []baseOS
+	for _, blobDesc := range referenceManifest.Blobs {
+		blob, _, err := su.ReadBlob(ctx, referrerStore, subjectReference, blobDesc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch blob %s: %w", blobDesc.Digest, err)
+		}
+		var document sbomDocument
+		if err := json.Unmarshal(blob, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse SBOM in blob %s: %w", blobDesc.Digest, err)
+		}
+		for _, pkg := range document.Packages {
+			if pkg.PrimaryPackagePurpose == spdxOperatingSystem {
+				systems = append(systems, newBaseOS(pkg.Name, pkg.VersionInfo))
+			}
+		}
+		for _, component := range document.Components {
+			if component.Type == cycloneDXOperatingSystem {
+				systems = append(systems, newBaseOS(component.Name, component.Version))
+			}
+		}
+	}
+	return systems, nil
+}
+
+// imageConfigBaseOS returns the operating system of the image config of the
+// subject if it carries an os.version, as Windows images do.
+func imageConfigBaseOS(ctx context.Context, subjectReference common.Reference, referrerStore referrerstore.ReferrerStore) ([]baseOS, error) {
+	subjectDesc, err := referrerStore.GetSubjectDescriptor(ctx, subjectReference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve subject: %w", err)
+	}
+	manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, ocispecs.ReferenceDescriptor{Descriptor: subjectDesc.Descriptor})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subject manifest: %w", err)
+	}
+	if manifest.Config == nil {
+		return nil, nil
+	}
+	blob, _, err := su.ReadBlob(ctx, referrerStore, subjectReference, *manifest.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image config %s: %w", manifest.Config.Digest, err)
+	}
+	// Docker image configs share the layout of OCI image configs
+	var image imagespec.Image
+	if err := json.Unmarshal(blob, &image); err != nil {
+		return nil, fmt.Errorf("failed to parse image config %s: %w", manifest.Config.Digest, err)
+	}
+	if image.OS == "" || image.OSVersion == "" {
+		return nil, nil
+	}
+	return []baseOS{newBaseOS(image.OS, image.OSVersion)}, nil
+}
+
+func newBaseOS(id string, version string) baseOS {
+	return baseOS{ID: strings.ToLower(strings.TrimSpace(id)), Version: strings.TrimSpace(version)}
+}
+
+// lookupEOLDate returns the entry of the dataset with the longest version
+// prefix of the base OS, e.g. alpine:3.15 for alpine 3.15.4, and its
+// end-of-life date.
+func lookupEOLDate(eolDates map[string]time.Time, system baseOS) (string, time.Time, bool) {
+	version := strings.ToLower(system.Version)
+	for {
+		entry := baseOS{ID: system.ID, Version: version}.String()
+		if eolDate, ok := eolDates[entry]; ok {
+			return entry, eolDate, true
+		}
+		if version == "" {
+			return "", time.Time{}, false
+		}
+		if i := strings.LastIndexAny(version, ".-+"); i >= 0 {
+			version = version[:i]
+		} else {
+			version = ""
+		}
+	}
+}
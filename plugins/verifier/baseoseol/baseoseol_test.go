@@ -0,0 +1,202 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+)
+
+const (
+	eolConfig = `{"config":{"name":"baseoseol","eolDates":{"alpine:3.15":"2023-11-01","alpine":"2099-01-01","debian:12":"2099-06-30","windows:10.0.17763":"2024-01-09","windows:10.0.20348":"2099-10-14"}%s}}`
+
+	alpineSpdx    = `{"spdxVersion":"SPDX-2.3","packages":[{"name":"busybox","versionInfo":"1.34.1-r7"},{"name":"alpine","versionInfo":"3.15.4","primaryPackagePurpose":"OPERATING-SYSTEM"}]}`
+	debianCdx     = `{"bomFormat":"CycloneDX","components":[{"type":"library","name":"bash","version":"5.2.15"},{"type":"operating-system","name":"debian","version":"12.5"}]}`
+	ubuntuSpdx    = `{"spdxVersion":"SPDX-2.3","packages":[{"name":"ubuntu","versionInfo":"22.04","primaryPackagePurpose":"OPERATING-SYSTEM"}]}`
+	noOSSpdx      = `{"spdxVersion":"SPDX-2.3","packages":[{"name":"busybox","versionInfo":"1.34.1-r7"}]}`
+	ltsc2019Image = `{"architecture":"amd64","os":"windows","os.version":"10.0.17763.5458"}`
+	ltsc2022Image = `{"architecture":"amd64","os":"windows","os.version":"10.0.20348.2227"}`
+	linuxImage    = `{"architecture":"amd64","os":"linux"}`
+)
+
+func TestVerifyReference(t *testing.T) {
+	subjectDigest := digest.FromString("test_subject_digest")
+	sbomDigest := digest.FromString("test_sbom_digest")
+	sbomBlobDigest := digest.FromString("test_sbom_blob_digest")
+	configDigest := digest.FromString("test_config_digest")
+
+	tests := []struct {
+		name         string
+		options      string
+		artifactType string
+		blob         string
+		isSuccess    bool
+		level        string
+		errorReason  string
+	}{
+		{
+			name:         "SPDX base OS past end of life",
+			artifactType: SpdxJSONMediaType,
+			blob:         alpineSpdx,
+			errorReason:  "Base OS is past end of life: alpine:3.15.4 reached end of life on 2023-11-01 (alpine:3.15).",
+		},
+		{
+			name:         "SPDX base OS past end of life with warning",
+			options:      `,"pastEOL":"warn"`,
+			artifactType: SpdxJSONMediaType,
+			blob:         alpineSpdx,
+			isSuccess:    true,
+			level:        verifier.LevelWarn,
+		},
+		{
+			name:         "CycloneDX base OS supported",
+			artifactType: CycloneDXJSONMediaType,
+			blob:         debianCdx,
+			isSuccess:    true,
+		},
+		{
+			name:         "base OS not in the dataset",
+			artifactType: SpdxJSONMediaType,
+			blob:         ubuntuSpdx,
+			isSuccess:    true,
+		},
+		{
+			name:         "SBOM without base OS",
+			artifactType: SpdxJSONMediaType,
+			blob:         noOSSpdx,
+			errorReason:  "No base OS information found for subject test_subject_path@" + subjectDigest.String() + ".",
+		},
+		{
+			name:         "SBOM without base OS with warning",
+			options:      `,"missingBaseOS":"warn"`,
+			artifactType: SpdxJSONMediaType,
+			blob:         noOSSpdx,
+			isSuccess:    true,
+			level:        verifier.LevelWarn,
+		},
+		{
+			name:         "invalid SBOM",
+			artifactType: SpdxJSONMediaType,
+			blob:         "invalid",
+		},
+		{
+			name:        "image config base OS past end of life",
+			blob:        ltsc2019Image,
+			errorReason: "Base OS is past end of life: windows:10.0.17763.5458 reached end of life on 2024-01-09 (windows:10.0.17763).",
+		},
+		{
+			name:      "image config base OS supported",
+			blob:      ltsc2022Image,
+			isSuccess: true,
+		},
+		{
+			name: "image config without os.version",
+			blob: linuxImage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subjectRef := common.Reference{
+				Path:     "test_subject_path",
+				Original: "test_subject_path@" + subjectDigest.String(),
+				Digest:   subjectDigest,
+			}
+			store := &mocks.MemoryTestStore{
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDigest: {Descriptor: oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: subjectDigest}},
+				},
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					subjectDigest: {MediaType: oci.MediaTypeImageManifest, Config: &oci.Descriptor{MediaType: oci.MediaTypeImageConfig, Digest: configDigest}},
+					sbomDigest:    {MediaType: oci.MediaTypeImageManifest, Blobs: []oci.Descriptor{{Digest: sbomBlobDigest}}},
+				},
+				Blobs: map[digest.Digest][]byte{
+					configDigest:   []byte(tt.blob),
+					sbomBlobDigest: []byte(tt.blob),
+				},
+			}
+			refDesc := ocispecs.ReferenceDescriptor{
+				ArtifactType: "application/vnd.cncf.notary.signature",
+				Descriptor:   oci.Descriptor{Digest: digest.FromString("test_signature_digest")},
+			}
+			if tt.artifactType != "" {
+				refDesc = ocispecs.ReferenceDescriptor{ArtifactType: tt.artifactType, Descriptor: oci.Descriptor{Digest: sbomDigest}}
+			}
+			cmdArgs := skel.CmdArgs{
+				Version:   "1.0.0",
+				Subject:   subjectRef.String(),
+				StdinData: []byte(fmt.Sprintf(eolConfig, tt.options)),
+			}
+
+			result, err := VerifyReference(&cmdArgs, subjectRef, refDesc, store)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsSuccess != tt.isSuccess {
+				t.Fatalf("expected success %v, got %v: %s", tt.isSuccess, result.IsSuccess, result.ErrorReason)
+			}
+			if result.GetLevel() != tt.level && tt.level != "" {
+				t.Fatalf("expected level %s, got %s", tt.level, result.GetLevel())
+			}
+			if tt.errorReason != "" && result.ErrorReason != tt.errorReason {
+				t.Fatalf("expected error reason %q, got %q", tt.errorReason, result.ErrorReason)
+			}
+		})
+	}
+}
+
+func TestLookupEOLDate(t *testing.T) {
+	_, eolDates, err := parseInput([]byte(fmt.Sprintf(eolConfig, "")))
+	if err != nil {
+		t.Fatalf("failed to parse input: %v", err)
+	}
+	tests := []struct {
+		system        baseOS
+		expectedEntry string
+	}{
+		{system: baseOS{ID: "alpine", Version: "3.15.4"}, expectedEntry: "alpine:3.15"},
+		{system: baseOS{ID: "alpine", Version: "3.19.1"}, expectedEntry: "alpine"},
+		{system: baseOS{ID: "debian", Version: "12"}, expectedEntry: "debian:12"},
+		{system: baseOS{ID: "debian", Version: "11.9"}},
+		{system: baseOS{ID: "windows", Version: "10.0.17763.5458"}, expectedEntry: "windows:10.0.17763"},
+	}
+	for _, tt := range tests {
+		entry, _, ok := lookupEOLDate(eolDates, tt.system)
+		if entry != tt.expectedEntry || ok != (tt.expectedEntry != "") {
+			t.Fatalf("expected entry %q for %s, got %q", tt.expectedEntry, tt.system, entry)
+		}
+	}
+}
+
+func TestParseInput_Invalid(t *testing.T) {
+	for _, stdin := range []string{
+		"invalid",
+		`{"config":{"name":"baseoseol","pastEOL":"ignore"}}`,
+		`{"config":{"name":"baseoseol","missingBaseOS":"ignore"}}`,
+		`{"config":{"name":"baseoseol","eolDates":{"alpine:3.15":"November 2023"}}}`,
+		`{"config":{"name":"baseoseol","eolDates":{" ":"2023-11-01"}}}`,
+	} {
+		if _, _, err := parseInput([]byte(stdin)); err == nil {
+			t.Fatalf("expected error for config %s", stdin)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+)
+
+const (
+	rootUser     string = "root"
+	rootUID      string = "0"
+	tcpProtocol  string = "tcp"
+	portProtocol string = "/"
+)
+
+type PluginConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// DisallowRootUser fails images running as root, i.e. with an empty user,
+	// root or uid 0.
+	DisallowRootUser bool `json:"disallowRootUser,omitempty"`
+	// RequiredLabels maps the labels the image must carry to their expected
+	// value. An empty value only requires the label to be present.
+	RequiredLabels map[string]string `json:"requiredLabels,omitempty"`
+	// RequiredEnv lists the environment variables the image must set.
+	RequiredEnv []string `json:"requiredEnv,omitempty"`
+	// DisallowedEnv lists the environment variables the image must not set.
+	DisallowedEnv []string `json:"disallowedEnv,omitempty"`
+	// AllowedExposedPorts lists the ports the image may expose, e.g. 8080 or
+	// 53/udp. Ports without protocol are tcp. Any port is allowed if empty.
+	AllowedExposedPorts []string `json:"allowedExposedPorts,omitempty"`
+}
+
+type PluginInputConfig struct {
+	Config PluginConfig `json:"config"`
+}
+
+func main() {
+	skel.PluginMain("imageconfig", "1.0.0", VerifyReference, []string{"1.0.0"})
+}
+
+func parseInput(stdin []byte) (*PluginConfig, error) {
+	conf := PluginInputConfig{}
+
+	if err := json.Unmarshal(stdin, &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse stdin for the input: %w", err)
+	}
+
+	return &conf.Config, nil
+}
+
+// VerifyReference verifies that the config of the subject image satisfies the
+// configured assertions. The verifier must be configured with the
+// ocispecs.SubjectArtifactType artifact type so that the config is verified
+// once per subject, including subjects without referrers.
+func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+	input, err := parseInput(args.StdinData)
+	if err != nil {
+		return nil, err
+	}
+	verifierType := input.Name
+	if input.Type != "" {
+		verifierType = input.Type
+	}
+
+	if referenceDescriptor.ArtifactType != ocispecs.SubjectArtifactType {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Image config is verified once per subject, configure artifact type %s instead of the referrer artifact type %s.", ocispecs.SubjectArtifactType, referenceDescriptor.ArtifactType))
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, nil)
+		return &result, nil
+	}
+
+	imageConfig, err := fetchImageConfig(context.Background(), subjectReference, referenceDescriptor, referrerStore)
+	if err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to fetch image config of subject %s.", subjectReference)).WithError(err)
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, nil)
+		return &result, nil
+	}
+
+	violations := checkImageConfig(input, imageConfig)
+	if len(violations) > 0 {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Image config assertions failed: %s.", strings.Join(violations, "; ")))
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, map[string]interface{}{"violations": violations})
+		return &result, nil
+	}
+
+	result := verifier.NewVerifierResult("", input.Name, verifierType, "Image config satisfies all assertions.", true, nil, nil)
+	return &result, nil
+}
+
+// fetchImageConfig returns the config of the subject image described by
+// subjectDesc.
+func fetchImageConfig(ctx context.Context, subjectReference common.Reference, subjectDesc ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (imagespec.ImageConfig, error) {
+	manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, subjectDesc)
+	if err != nil {
+		return imagespec.ImageConfig{}, fmt.Errorf("failed to fetch subject manifest: %w", err)
+	}
+	if manifest.Config == nil {
+		return imagespec.ImageConfig{}, fmt.Errorf("subject manifest of media type %s has no image config", subjectDesc.MediaType)
+	}
+	blob, _, err := su.ReadBlob(ctx, referrerStore, subjectReference, *manifest.Config)
+	if err != nil {
+		return imagespec.ImageConfig{}, fmt.Errorf("failed to fetch image config %s: %w", manifest.Config.Digest, err)
+	}
+	// Docker image configs share the layout of OCI image configs
+	var image imagespec.Image
+	if err := json.Unmarshal(blob, &image); err != nil {
+		return imagespec.ImageConfig{}, fmt.Errorf("failed to parse image config %s: %w", manifest.Config.Digest, err)
+	}
+	return image.Config, nil
+}
+
+// checkImageConfig returns the assertions the image config violates.
+func checkImageConfig(input *PluginConfig, config imagespec.ImageConfig) []string {
+	var violations []string
+	if input.DisallowRootUser && isRootUser(config.User) {
+		violations = append(violations, fmt.Sprintf("image runs as root user %q", config.User))
+	}
+
+	labelKeys := make([]string, 0, len(input.RequiredLabels))
+	for key := range input.RequiredLabels {
+		labelKeys = append(labelKeys, key)
+	}
+	slices.Sort(labelKeys)
+	for _, key := range labelKeys {
+		value, ok := config.Labels[key]
+		switch {
+		case !ok:
+			violations = append(violations, fmt.Sprintf("required label %s is missing", key))
+		case input.RequiredLabels[key] != "" && value != input.RequiredLabels[key]:
+			violations = append(violations, fmt.Sprintf("label %s is %q, expected %q", key, value, input.RequiredLabels[key]))
+		}
+	}
+
+	env := make(map[string]bool, len(config.Env))
+	for _, variable := range config.Env {
+		name, _, _ := strings.Cut(variable, "=")
+		env[name] = true
+	}
+	for _, name := range input.RequiredEnv {
+		if !env[name] {
+			violations = append(violations, fmt.Sprintf("required environment variable %s is not set", name))
+		}
+	}
+	for _, name := range input.DisallowedEnv {
+		if env[name] {
+			violations = append(violations, fmt.Sprintf("disallowed environment variable %s is set", name))
+		}
+	}
+
+	if len(input.AllowedExposedPorts) > 0 {
+		allowed := make(map[string]bool, len(input.AllowedExposedPorts))
+		for _, port := range input.AllowedExposedPorts {
+			allowed[normalizePort(port)] = true
+		}
+		ports := make([]string, 0, len(config.ExposedPorts))
+		for port := range config.ExposedPorts {
+			ports = append(ports, normalizePort(port))
+		}
+		slices.Sort(ports)
+		for _, port := range ports {
+			if !allowed[port] {
+				violations = append(violations, fmt.Sprintf("exposed port %s is not allowed", port))
+			}
+		}
+	}
+	return violations
+}
+
+// isRootUser reports whether the user of an image config, in the form
+// user[:group], resolves to root.
+func isRootUser(user string) bool {
+	name, _, _ := strings.Cut(user, ":")
+	return name == "" || name == rootUser || name == rootUID
+}
+
+// normalizePort returns the port in the form port/protocol, defaulting to tcp.
+func normalizePort(port string) string {
+	port = strings.ToLower(strings.TrimSpace(port))
+	if !strings.Contains(port, portProtocol) {
+		port += portProtocol + tcpProtocol
+	}
+	return port
+}
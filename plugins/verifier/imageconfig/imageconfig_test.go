@@ -0,0 +1,235 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+)
+
+const compliantConfig = `{
+	"architecture": "amd64",
+	"os": "linux",
+	"config": {
+		"User": "65532:65532",
+		"Env": ["PATH=/usr/local/bin:/usr/bin", "APP_ENV=production"],
+		"Labels": {"org.opencontainers.image.source": "https://github.com/ratify-project/ratify", "team": "security"},
+		"ExposedPorts": {"8080/tcp": {}}
+	}
+}`
+
+func TestCheckImageConfig(t *testing.T) {
+	config := oci.ImageConfig{
+		User:         "65532:65532",
+		Env:          []string{"PATH=/usr/bin", "APP_ENV=production", "DEBUG="},
+		Labels:       map[string]string{"team": "security"},
+		ExposedPorts: map[string]struct{}{"8080/tcp": {}, "53/udp": {}},
+	}
+	tests := []struct {
+		name       string
+		input      PluginConfig
+		config     oci.ImageConfig
+		violations []string
+	}{
+		{
+			name:   "no assertions",
+			config: oci.ImageConfig{},
+		},
+		{
+			name:   "non-root user",
+			input:  PluginConfig{DisallowRootUser: true},
+			config: config,
+		},
+		{
+			name:       "empty user runs as root",
+			input:      PluginConfig{DisallowRootUser: true},
+			config:     oci.ImageConfig{},
+			violations: []string{`image runs as root user ""`},
+		},
+		{
+			name:       "root user",
+			input:      PluginConfig{DisallowRootUser: true},
+			config:     oci.ImageConfig{User: "root"},
+			violations: []string{`image runs as root user "root"`},
+		},
+		{
+			name:       "root uid with group",
+			input:      PluginConfig{DisallowRootUser: true},
+			config:     oci.ImageConfig{User: "0:1000"},
+			violations: []string{`image runs as root user "0:1000"`},
+		},
+		{
+			name:   "required labels present",
+			input:  PluginConfig{RequiredLabels: map[string]string{"team": "security"}},
+			config: config,
+		},
+		{
+			name:       "required label missing or mismatched",
+			input:      PluginConfig{RequiredLabels: map[string]string{"team": "platform", "owner": ""}},
+			config:     config,
+			violations: []string{"required label owner is missing", `label team is "security", expected "platform"`},
+		},
+		{
+			name:   "required env set",
+			input:  PluginConfig{RequiredEnv: []string{"APP_ENV", "DEBUG"}},
+			config: config,
+		},
+		{
+			name:       "required env missing",
+			input:      PluginConfig{RequiredEnv: []string{"LOG_LEVEL"}},
+			config:     config,
+			violations: []string{"required environment variable LOG_LEVEL is not set"},
+		},
+		{
+			name:       "disallowed env set",
+			input:      PluginConfig{DisallowedEnv: []string{"DEBUG", "AWS_SECRET_ACCESS_KEY"}},
+			config:     config,
+			violations: []string{"disallowed environment variable DEBUG is set"},
+		},
+		{
+			name:   "exposed ports allowed",
+			input:  PluginConfig{AllowedExposedPorts: []string{"8080", "53/UDP"}},
+			config: config,
+		},
+		{
+			name:       "exposed port not allowed",
+			input:      PluginConfig{AllowedExposedPorts: []string{"8080/tcp"}},
+			config:     config,
+			violations: []string{"exposed port 53/udp is not allowed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := checkImageConfig(&tt.input, tt.config)
+			if len(violations) != len(tt.violations) {
+				t.Fatalf("expected violations %v, got %v", tt.violations, violations)
+			}
+			for i := range violations {
+				if violations[i] != tt.violations[i] {
+					t.Fatalf("expected violations %v, got %v", tt.violations, violations)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyReference(t *testing.T) {
+	subjectDigest := digest.FromString("test_subject_digest")
+	configDigest := digest.FromString(compliantConfig)
+
+	tests := []struct {
+		name         string
+		stdinData    string
+		config       *oci.Descriptor
+		configBlob   string
+		artifactType string
+		isSuccess    bool
+		errorReason  string
+		isErr        bool
+	}{
+		{
+			name:      "invalid stdin data",
+			stdinData: "invalid",
+			isErr:     true,
+		},
+		{
+			name:       "compliant image config",
+			stdinData:  `{"config":{"name":"imageconfig","disallowRootUser":true,"requiredLabels":{"org.opencontainers.image.source":""},"requiredEnv":["APP_ENV"],"allowedExposedPorts":["8080"]}}`,
+			config:     &oci.Descriptor{MediaType: oci.MediaTypeImageConfig, Digest: configDigest},
+			configBlob: compliantConfig,
+			isSuccess:  true,
+		},
+		{
+			name:        "non-compliant image config",
+			stdinData:   `{"config":{"name":"imageconfig","requiredLabels":{"team":"platform"},"disallowedEnv":["APP_ENV"]}}`,
+			config:      &oci.Descriptor{MediaType: oci.MediaTypeImageConfig, Digest: configDigest},
+			configBlob:  compliantConfig,
+			isSuccess:   false,
+			errorReason: `Image config assertions failed: label team is "security", expected "platform"; disallowed environment variable APP_ENV is set.`,
+		},
+		{
+			name:         "referrer artifact type",
+			stdinData:    `{"config":{"name":"imageconfig","disallowRootUser":true}}`,
+			config:       &oci.Descriptor{MediaType: oci.MediaTypeImageConfig, Digest: configDigest},
+			configBlob:   compliantConfig,
+			artifactType: "application/vnd.cncf.notary.signature",
+			isSuccess:    false,
+			errorReason:  "Image config is verified once per subject, configure artifact type application/vnd.ratify.subject.v1 instead of the referrer artifact type application/vnd.cncf.notary.signature.",
+		},
+		{
+			name:      "subject without image config",
+			stdinData: `{"config":{"name":"imageconfig","disallowRootUser":true}}`,
+			isSuccess: false,
+		},
+		{
+			name:       "invalid image config",
+			stdinData:  `{"config":{"name":"imageconfig","disallowRootUser":true}}`,
+			config:     &oci.Descriptor{MediaType: oci.MediaTypeImageConfig, Digest: configDigest},
+			configBlob: "invalid",
+			isSuccess:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subjectRef := common.Reference{
+				Path:     "test_subject_path",
+				Original: "test_subject_path@" + subjectDigest.String(),
+				Digest:   subjectDigest,
+			}
+			store := &mocks.MemoryTestStore{
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					subjectDigest: {MediaType: oci.MediaTypeImageManifest, Config: tt.config},
+				},
+				Blobs: map[digest.Digest][]byte{
+					configDigest: []byte(tt.configBlob),
+				},
+			}
+			cmdArgs := skel.CmdArgs{
+				Version:   "1.0.0",
+				Subject:   subjectRef.String(),
+				StdinData: []byte(tt.stdinData),
+			}
+			// the subject is verified as a reference of the subject artifact type
+			refDesc := ocispecs.ReferenceDescriptor{
+				ArtifactType: ocispecs.SubjectArtifactType,
+				Descriptor:   oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: subjectDigest},
+			}
+			if tt.artifactType != "" {
+				refDesc.ArtifactType = tt.artifactType
+			}
+
+			result, err := VerifyReference(&cmdArgs, subjectRef, refDesc, store)
+			if tt.isErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.isErr, err)
+			}
+			if tt.isErr {
+				return
+			}
+			if result.IsSuccess != tt.isSuccess {
+				t.Fatalf("expected success %v, got %v: %s", tt.isSuccess, result.IsSuccess, result.ErrorReason)
+			}
+			if tt.errorReason != "" && result.ErrorReason != tt.errorReason {
+				t.Fatalf("expected error reason %q, got %q", tt.errorReason, result.ErrorReason)
+			}
+		})
+	}
+}
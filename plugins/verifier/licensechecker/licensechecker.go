@@ -26,6 +26,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
 	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
 )
@@ -81,7 +82,7 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, desc
 	}
 
 	for _, blobDesc := range referenceManifest.Blobs {
-		refBlob, err := store.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
+		refBlob, _, err := su.ReadBlob(ctx, store, subjectReference, blobDesc)
 		if err != nil {
 			return nil, err
 		}
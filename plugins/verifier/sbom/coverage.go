@@ -0,0 +1,239 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+// layerIDCommentPattern matches the "layerID: sha256:..." comments syft adds
+// to the files it catalogs in SPDX SBOMs.
+var layerIDCommentPattern = regexp.MustCompile(`^layerID: (sha256:[a-f0-9]{64})$`)
+
+// layerIDPropertySuffix is the suffix of the name of the properties holding
+// the layer of a location in CycloneDX SBOMs, e.g. syft:location:0:layerID.
+const layerIDPropertySuffix = ":layerID"
+
+// imageLayer identifies a layer of the subject image by the digest of its
+// blob and, if the image config lists it, the digest of its uncompressed
+// content.
+type imageLayer struct {
+	Digest digest.Digest
+	DiffID digest.Digest
+}
+
+// LayerCoverageReport is the extension data reporting the layers of the
+// subject image the SBOM describes.
+type LayerCoverageReport struct {
+	Coverage        float64  `json:"coverage"`
+	Threshold       float64  `json:"threshold"`
+	UncoveredLayers []string `json:"uncoveredLayers,omitempty"`
+}
+
+// checkLayerCoverage fails the result of a valid SBOM if it describes less
+// than the configured share of the layers of the subject image. The SBOM blob
+// is streamed from the store once more to collect the layers it describes.
+func checkLayerCoverage(ctx context.Context, input *PluginConfig, verifierType string, subjectReference common.Reference, referrerStore referrerstore.ReferrerStore, blobDesc imagespec.Descriptor, result *verifier.VerifierResult) *verifier.VerifierResult {
+	layers, err := fetchImageLayers(ctx, subjectReference, referrerStore)
+	if err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to derive the layers of the subject %s", subjectReference)).WithError(err)
+		failed := verifier.NewVerifierResult("", input.Name, verifierType, "SBOM validation failed", false, &verifierErr, result.Extensions)
+		return &failed
+	}
+
+	refBlob, _, err := su.OpenBlob(ctx, referrerStore, subjectReference, blobDesc)
+	if err != nil {
+		storeErr := re.ErrorCodeGetBlobContentFailure.WithDetail(fmt.Sprintf("Failed to fetch blob for subject: %s digest: %s", subjectReference, blobDesc.Digest)).WithError(err)
+		failed := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &storeErr, result.Extensions)
+		return &failed
+	}
+	defer refBlob.Close()
+	report := computeLayerCoverage(refBlob, layers)
+	report.Threshold = input.MinLayerCoverage
+	extensionData := map[string]interface{}{LayerCoverage: report}
+	if extensions, ok := result.Extensions.(map[string]interface{}); ok {
+		for key, value := range extensions {
+			extensionData[key] = value
+		}
+	}
+
+	if report.Coverage < input.MinLayerCoverage {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("SBOM covers %.0f%% of the image layers, below the required %.0f%%.", report.Coverage*100, input.MinLayerCoverage*100)).WithRemediation("Please review extensions data for the image layers the SBOM does not cover.")
+		failed := verifier.NewVerifierResult("", input.Name, verifierType, "SBOM validation failed", false, &verifierErr, extensionData)
+		return &failed
+	}
+	result.Extensions = extensionData
+	return result
+}
+
+// fetchImageLayers returns the layers of the subject image, along with their
+// diff IDs if the image config lists one per layer.
+func fetchImageLayers(ctx context.Context, subjectReference common.Reference, referrerStore referrerstore.ReferrerStore) ([]imageLayer, error) {
+	subjectDesc, err := referrerStore.GetSubjectDescriptor(ctx, subjectReference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve subject: %w", err)
+	}
+	manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, ocispecs.ReferenceDescriptor{Descriptor: subjectDesc.Descriptor})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subject manifest: %w", err)
+	}
+	if len(manifest.Blobs) == 0 {
+		return nil, fmt.Errorf("subject manifest of media type %s has no layers", subjectDesc.MediaType)
+	}
+
+	layers := make([]imageLayer, len(manifest.Blobs))
+	for i, blob := range manifest.Blobs {
+		layers[i].Digest = blob.Digest
+	}
+	if manifest.Config == nil {
+		return layers, nil
+	}
+	blob, _, err := su.ReadBlob(ctx, referrerStore, subjectReference, *manifest.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image config %s: %w", manifest.Config.Digest, err)
+	}
+	var image imagespec.Image
+	if err := json.Unmarshal(blob, &image); err != nil {
+		return nil, fmt.Errorf("failed to parse image config %s: %w", manifest.Config.Digest, err)
+	}
+	if len(image.RootFS.DiffIDs) == len(layers) {
+		for i, diffID := range image.RootFS.DiffIDs {
+			layers[i].DiffID = diffID
+		}
+	}
+	return layers, nil
+}
+
+// computeLayerCoverage returns the share of the layers whose digest or diff ID
+// appears in the SBOM read from r.
+func computeLayerCoverage(r io.Reader, layers []imageLayer) LayerCoverageReport {
+	described := make(map[digest.Digest]bool)
+	// a malformed SBOM only covers the layers described before the error
+	_ = collectLayerIDs(r, described)
+
+	report := LayerCoverageReport{}
+	covered := 0
+	for _, layer := range layers {
+		if described[layer.Digest] || (layer.DiffID != "" && described[layer.DiffID]) {
+			covered++
+			continue
+		}
+		report.UncoveredLayers = append(report.UncoveredLayers, layer.Digest.String())
+	}
+	if len(layers) > 0 {
+		report.Coverage = float64(covered) / float64(len(layers))
+	}
+	return report
+}
+
+// jsonObject tracks the fields of a JSON object being decoded that locate the
+// contents of the SBOM in the image layers.
+type jsonObject struct {
+	isArray  bool
+	atKey    bool
+	key      string
+	name     string
+	value    string
+	hasValue bool
+}
+
+// collectLayerIDs records the layer digests referenced by the fields of the
+// SBOM locating its contents in the image layers. Digests of other fields,
+// e.g. package checksums, are not layer references. The SBOM is decoded token
+// by token so that it is never held in memory as a whole.
+func collectLayerIDs(r io.Reader, described map[digest.Digest]bool) error {
+	decoder := json.NewDecoder(r)
+	var stack []*jsonObject
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var top *jsonObject
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		switch v := token.(type) {
+		case json.Delim:
+			switch v {
+			case '{', '[':
+				stack = append(stack, &jsonObject{isArray: v == '[', atKey: v == '{'})
+			default:
+				if !top.isArray && strings.HasSuffix(top.name, layerIDPropertySuffix) && top.hasValue {
+					if parsed, err := digest.Parse(top.value); err == nil {
+						described[parsed] = true
+					}
+				}
+				stack = stack[:len(stack)-1]
+				if len(stack) > 0 {
+					stack[len(stack)-1].valueDecoded()
+				}
+			}
+		case string:
+			if top == nil {
+				continue
+			}
+			if top.atKey {
+				top.key = v
+				top.atKey = false
+				continue
+			}
+			switch top.key {
+			case "comment":
+				if match := layerIDCommentPattern.FindStringSubmatch(v); match != nil {
+					described[digest.Digest(match[1])] = true
+				}
+			case "name":
+				top.name = v
+			case "value":
+				top.value, top.hasValue = v, true
+			}
+			top.valueDecoded()
+		default:
+			if top != nil {
+				top.valueDecoded()
+			}
+		}
+	}
+}
+
+// valueDecoded expects the next token of an object to be a key once the value
+// of the current key is decoded.
+func (o *jsonObject) valueDecoded() {
+	if !o.isArray {
+		o.atKey = true
+		o.key = ""
+	}
+}
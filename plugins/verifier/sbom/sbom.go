@@ -16,16 +16,17 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/pkg/common"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
 	"github.com/ratify-project/ratify/plugins/verifier/sbom/utils"
 
 	// This import is required to utilize the oras built-in referrer store
@@ -33,9 +34,6 @@ import (
 	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"
 	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
-	jsonLoader "github.com/spdx/tools-golang/json"
-	"github.com/spdx/tools-golang/spdx"
-	"github.com/spdx/tools-golang/spdx/v2/v2_3"
 )
 
 // PluginConfig describes the configuration of the sbom verifier
@@ -44,6 +42,10 @@ type PluginConfig struct {
 	Type               string              `json:"type"`
 	DisallowedLicenses []string            `json:"disallowedLicenses,omitempty"`
 	DisallowedPackages []utils.PackageInfo `json:"disallowedPackages,omitempty"`
+	// MinLayerCoverage is the minimum share, between 0 and 1, of the layers
+	// of the subject image the SBOM must describe. The check is disabled if
+	// unset.
+	MinLayerCoverage float64 `json:"minLayerCoverage,omitempty"`
 }
 
 type PluginInputConfig struct {
@@ -55,6 +57,7 @@ const (
 	CreationInfo      string = "creationInfo"
 	LicenseViolation  string = "licenseViolations"
 	PackageViolation  string = "packageViolations"
+	LayerCoverage     string = "layerCoverage"
 )
 
 func main() {
@@ -67,6 +70,9 @@ func parseInput(stdin []byte) (*PluginConfig, error) {
 	if err := json.Unmarshal(stdin, &conf); err != nil {
 		return nil, fmt.Errorf("failed to parse stdin for the input: %w", err)
 	}
+	if conf.Config.MinLayerCoverage < 0 || conf.Config.MinLayerCoverage > 1 {
+		return nil, fmt.Errorf("minLayerCoverage must be between 0 and 1, got %v", conf.Config.MinLayerCoverage)
+	}
 
 	return &conf.Config, nil
 }
@@ -97,17 +103,23 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 
 	artifactType := referenceDescriptor.ArtifactType
 	for _, blobDesc := range referenceManifest.Blobs {
-		refBlob, err := referrerStore.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
-
+		// the SBOM is streamed from the store so that large documents are
+		// never held in memory as a whole
+		refBlob, _, err := su.OpenBlob(ctx, referrerStore, subjectReference, blobDesc)
 		if err != nil {
 			storeErr := re.ErrorCodeGetBlobContentFailure.WithDetail(fmt.Sprintf("Failed to fetch blob for subject: %s digest: %s", subjectReference, blobDesc.Digest)).WithError(err)
 			result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &storeErr, nil)
 			return &result, nil
 		}
+		defer refBlob.Close()
 
 		switch artifactType {
 		case SpdxJSONMediaType:
-			return processSpdxJSONMediaType(input.Name, verifierType, refBlob, input.DisallowedLicenses, input.DisallowedPackages), nil
+			result := processSpdxJSONMediaType(input.Name, verifierType, refBlob, input.DisallowedLicenses, input.DisallowedPackages)
+			if result.IsSuccess && input.MinLayerCoverage > 0 {
+				return checkLayerCoverage(ctx, input, verifierType, subjectReference, referrerStore, blobDesc, result), nil
+			}
+			return result, nil
 		default:
 			storeErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Unsupported artifactType: %s", artifactType))
 			result := verifier.NewVerifierResult("", input.Name, verifierType, "Failed to process SBOM blobs.", false, &storeErr, nil)
@@ -118,17 +130,6 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 	return &result, nil
 }
 
-// getViolations returns the package and license violations based on the deny list
-func getViolations(spdxDoc *spdx.Document, disallowedLicenses []string, disallowedPackages []utils.PackageInfo) ([]utils.PackageLicense, []utils.PackageLicense) {
-	packageLicenses := utils.GetPackageLicenses(*spdxDoc)
-	// load disallowed packageInfo into a map for easier existence check
-	packageMap, packageNameMap := loadDisallowedPackagesMap(disallowedPackages)
-
-	// detect violation
-	licenseViolation, packageViolation := filterDisallowedPackages(packageLicenses, disallowedLicenses, packageMap, packageNameMap)
-	return packageViolation, licenseViolation
-}
-
 // load disallowed packageInfo, and disallowed packageName into a map for easier existence check
 func loadDisallowedPackagesMap(packages []utils.PackageInfo) (map[utils.PackageInfo]struct{}, map[string]struct{}) {
 	packagesInfo := map[utils.PackageInfo]struct{}{}
@@ -144,44 +145,53 @@ func loadDisallowedPackagesMap(packages []utils.PackageInfo) (map[utils.PackageI
 	return packagesInfo, packagesName
 }
 
-// parse through the spdx blob and returns the verifier result
-func processSpdxJSONMediaType(name string, verifierType string, refBlob []byte, disallowedLicenses []string, disallowedPackages []utils.PackageInfo) *verifier.VerifierResult {
-	var err error
-	var spdxDoc *v2_3.Document
-	if spdxDoc, err = jsonLoader.Read(bytes.NewReader(refBlob)); spdxDoc != nil && err == nil {
-		if len(disallowedLicenses) != 0 || len(disallowedPackages) != 0 {
-			packageViolation, licenseViolation := getViolations(spdxDoc, disallowedLicenses, disallowedPackages)
-
-			var extensionData = make(map[string]interface{})
-			extensionData[CreationInfo] = spdxDoc.CreationInfo
-			if len(licenseViolation) != 0 {
-				extensionData[LicenseViolation] = licenseViolation
-			}
+// parse through the spdx blob and returns the verifier result. The packages
+// are checked against the deny lists as they are decoded.
+func processSpdxJSONMediaType(name string, verifierType string, refBlob io.Reader, disallowedLicenses []string, disallowedPackages []utils.PackageInfo) *verifier.VerifierResult {
+	// load disallowed packageInfo into a map for easier existence check
+	packageMap, packageNameMap := loadDisallowedPackagesMap(disallowedPackages)
+	checkViolations := len(disallowedLicenses) != 0 || len(disallowedPackages) != 0
 
-			if len(packageViolation) != 0 {
-				extensionData[PackageViolation] = packageViolation
-			}
+	var licenseViolation, packageViolation []utils.PackageLicense
+	creationInfo, err := utils.ReadPackageLicenses(refBlob, func(packageLicense utils.PackageLicense) {
+		if !checkViolations {
+			return
+		}
+		licenses, packages := filterDisallowedPackages([]utils.PackageLicense{packageLicense}, disallowedLicenses, packageMap, packageNameMap)
+		licenseViolation = append(licenseViolation, licenses...)
+		packageViolation = append(packageViolation, packages...)
+	})
+	if err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("failed to verify artifact: %s", name)).WithError(err)
+		result := verifier.NewVerifierResult("", name, verifierType, "", false, &verifierErr, nil)
+		return &result
+	}
 
-			if len(licenseViolation) != 0 || len(packageViolation) != 0 {
-				sbomErr := errors.ErrorCodeVerifyPluginFailure.WithDetail("License or package violation found.").WithRemediation("Please review extensions data for license and package violation found.")
-				result := verifier.NewVerifierResult("", name, verifierType, "SBOM validation failed", false, &sbomErr, extensionData)
-				return &result
-			}
+	if len(licenseViolation) != 0 || len(packageViolation) != 0 {
+		var extensionData = make(map[string]interface{})
+		extensionData[CreationInfo] = creationInfo
+		if len(licenseViolation) != 0 {
+			extensionData[LicenseViolation] = licenseViolation
 		}
 
-		result := verifier.NewVerifierResult(
-			"",
-			name,
-			verifierType,
-			"SBOM verification success. No license or package violation found.",
-			true,
-			nil,
-			map[string]interface{}{CreationInfo: spdxDoc.CreationInfo},
-		)
+		if len(packageViolation) != 0 {
+			extensionData[PackageViolation] = packageViolation
+		}
+
+		sbomErr := errors.ErrorCodeVerifyPluginFailure.WithDetail("License or package violation found.").WithRemediation("Please review extensions data for license and package violation found.")
+		result := verifier.NewVerifierResult("", name, verifierType, "SBOM validation failed", false, &sbomErr, extensionData)
 		return &result
 	}
-	verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("failed to verify artifact: %s", name)).WithError(err)
-	result := verifier.NewVerifierResult("", name, verifierType, "", false, &verifierErr, nil)
+
+	result := verifier.NewVerifierResult(
+		"",
+		name,
+		verifierType,
+		"SBOM verification success. No license or package violation found.",
+		true,
+		nil,
+		map[string]interface{}{CreationInfo: creationInfo},
+	)
 	return &result
 }
 
@@ -15,6 +15,8 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -22,6 +24,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/opencontainers/go-digest"
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/ratify-project/ratify/pkg/common"
@@ -38,7 +41,7 @@ func TestProcessSPDXJsonMediaType(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error reading %s", filepath.Join("testdata", "bom.json"))
 	}
-	vr := processSpdxJSONMediaType("test", "", b, nil, nil)
+	vr := processSpdxJSONMediaType("test", "", bytes.NewReader(b), nil, nil)
 	if !vr.IsSuccess {
 		t.Fatalf("expected to successfully verify schema")
 	}
@@ -49,7 +52,7 @@ func TestProcessInvalidSPDXJsonMediaType(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error reading %s", filepath.Join("testdata", "invalid-bom.json"))
 	}
-	report := processSpdxJSONMediaType("test", "", b, nil, nil)
+	report := processSpdxJSONMediaType("test", "", bytes.NewReader(b), nil, nil)
 
 	if !strings.Contains(report.Message, "failed to verify artifact") {
 		t.Fatalf("report message: %s does not contain expected error message", report.Message)
@@ -226,6 +229,209 @@ func TestVerifyReference(t *testing.T) {
 	}
 }
 
+func TestVerifyReference_ZstdBlob(t *testing.T) {
+	bom, err := os.ReadFile(filepath.Join("testdata", "bom.json"))
+	if err != nil {
+		t.Fatalf("error reading %s", filepath.Join("testdata", "bom.json"))
+	}
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("failed to create zstd writer: %v", err)
+	}
+	compressed := encoder.EncodeAll(bom, nil)
+	encoder.Close()
+
+	manifestDigest := digest.FromString("test_manifest_digest")
+	blobDigest := digest.FromBytes(compressed)
+	testStore := &mocks.MemoryTestStore{
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+			manifestDigest: {Blobs: []oci.Descriptor{{MediaType: SpdxJSONMediaType + "+zstd", Digest: blobDigest}}},
+		},
+		Blobs: map[digest.Digest][]byte{blobDigest: compressed},
+	}
+	cmdArgs := &skel.CmdArgs{
+		Version:   "1.0.0",
+		Subject:   "test_subject",
+		StdinData: []byte(`{"config":{"name":"sbom","type":"sbom"}}`),
+	}
+	refDesc := ocispecs.ReferenceDescriptor{
+		Descriptor:   oci.Descriptor{Digest: manifestDigest},
+		ArtifactType: SpdxJSONMediaType,
+	}
+	verifierResult, err := VerifyReference(cmdArgs, common.Reference{Path: "test_subject_path", Original: "test_subject"}, refDesc, testStore)
+	if err != nil {
+		t.Fatalf("verifyReference() unexpected error: %v", err)
+	}
+	if !verifierResult.IsSuccess {
+		t.Fatalf("expected zstd-compressed SBOM to verify, got message %s error %s", verifierResult.Message, verifierResult.ErrorReason)
+	}
+}
+
+func TestVerifyReference_LayerCoverage(t *testing.T) {
+	sbom, err := os.ReadFile(filepath.Join("testdata", "syftbom.spdx.json"))
+	if err != nil {
+		t.Fatalf("error reading %s", filepath.Join("testdata", "syftbom.spdx.json"))
+	}
+	// the diff ID of the layer syft cataloged the files of
+	coveredDiffID := digest.Digest("sha256:8e012198eea15b2554b07014081c85fec4967a1b9cc4b65bd9a4bce3ae1c0c88")
+	coveredLayer := digest.FromString("covered_layer")
+	uncoveredLayer := digest.FromString("uncovered_layer")
+	subjectDigest := digest.FromString("test_subject_digest")
+	sbomManifestDigest := digest.FromString("test_sbom_manifest")
+	sbomDigest := digest.FromString("test_sbom")
+	configDigest := digest.FromString("test_config")
+
+	tests := []struct {
+		name             string
+		minLayerCoverage string
+		layers           []digest.Digest
+		diffIDs          []digest.Digest
+		wantSuccess      bool
+		wantCoverage     float64
+		wantErrorReason  string
+	}{
+		{
+			name:             "complete sbom",
+			minLayerCoverage: "1",
+			layers:           []digest.Digest{coveredLayer},
+			diffIDs:          []digest.Digest{coveredDiffID},
+			wantSuccess:      true,
+			wantCoverage:     1,
+		},
+		{
+			name:             "partial sbom below threshold",
+			minLayerCoverage: "1",
+			layers:           []digest.Digest{coveredLayer, uncoveredLayer},
+			diffIDs:          []digest.Digest{coveredDiffID, uncoveredLayer},
+			wantSuccess:      false,
+			wantCoverage:     0.5,
+			wantErrorReason:  "SBOM covers 50% of the image layers, below the required 100%.",
+		},
+		{
+			name:             "partial sbom above threshold",
+			minLayerCoverage: "0.5",
+			layers:           []digest.Digest{coveredLayer, uncoveredLayer},
+			diffIDs:          []digest.Digest{coveredDiffID, uncoveredLayer},
+			wantSuccess:      true,
+			wantCoverage:     0.5,
+		},
+		{
+			name:             "diff IDs not derivable",
+			minLayerCoverage: "1",
+			layers:           []digest.Digest{coveredLayer},
+			wantSuccess:      false,
+			wantCoverage:     0,
+			wantErrorReason:  "SBOM covers 0% of the image layers, below the required 100%.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers := make([]oci.Descriptor, len(tt.layers))
+			for i, layer := range tt.layers {
+				layers[i] = oci.Descriptor{MediaType: oci.MediaTypeImageLayerGzip, Digest: layer}
+			}
+			imageConfig, err := json.Marshal(oci.Image{RootFS: oci.RootFS{Type: "layers", DiffIDs: tt.diffIDs}})
+			if err != nil {
+				t.Fatalf("failed to marshal image config: %v", err)
+			}
+			testStore := &mocks.MemoryTestStore{
+				Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+					subjectDigest: {Descriptor: oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: subjectDigest}},
+				},
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+					subjectDigest: {
+						MediaType: oci.MediaTypeImageManifest,
+						Config:    &oci.Descriptor{MediaType: oci.MediaTypeImageConfig, Digest: configDigest},
+						Blobs:     layers,
+					},
+					sbomManifestDigest: {Blobs: []oci.Descriptor{{MediaType: SpdxJSONMediaType, Digest: sbomDigest}}},
+				},
+				Blobs: map[digest.Digest][]byte{sbomDigest: sbom, configDigest: imageConfig},
+			}
+			cmdArgs := &skel.CmdArgs{
+				Version:   "1.0.0",
+				Subject:   "test_subject",
+				StdinData: []byte(fmt.Sprintf(`{"config":{"name":"sbom","type":"sbom","minLayerCoverage":%s}}`, tt.minLayerCoverage)),
+			}
+			subjectRef := common.Reference{
+				Path:     "test_subject_path",
+				Digest:   subjectDigest,
+				Original: "test_subject",
+			}
+			refDesc := ocispecs.ReferenceDescriptor{
+				Descriptor:   oci.Descriptor{Digest: sbomManifestDigest},
+				ArtifactType: SpdxJSONMediaType,
+			}
+
+			verifierResult, err := VerifyReference(cmdArgs, subjectRef, refDesc, testStore)
+			if err != nil {
+				t.Fatalf("verifyReference() unexpected error: %v", err)
+			}
+			if verifierResult.IsSuccess != tt.wantSuccess {
+				t.Fatalf("verifyReference() success = %v, want %v: %s", verifierResult.IsSuccess, tt.wantSuccess, verifierResult.Message)
+			}
+			if verifierResult.ErrorReason != tt.wantErrorReason {
+				t.Fatalf("verifyReference() error reason = %s, want %s", verifierResult.ErrorReason, tt.wantErrorReason)
+			}
+			extensions, ok := verifierResult.Extensions.(map[string]interface{})
+			if !ok {
+				t.Fatalf("verifyReference() extensions = %v, want map", verifierResult.Extensions)
+			}
+			report, ok := extensions[LayerCoverage].(LayerCoverageReport)
+			if !ok {
+				t.Fatalf("verifyReference() extensions have no layer coverage report")
+			}
+			if report.Coverage != tt.wantCoverage {
+				t.Fatalf("verifyReference() coverage = %v, want %v", report.Coverage, tt.wantCoverage)
+			}
+		})
+	}
+}
+
+func TestComputeLayerCoverage(t *testing.T) {
+	layer := digest.FromString("layer")
+	tests := []struct {
+		name         string
+		sbom         string
+		wantCoverage float64
+	}{
+		{
+			name:         "spdx file layer comment",
+			sbom:         fmt.Sprintf(`{"files": [{"fileName": "/bin/sh", "comment": "layerID: %s"}]}`, layer),
+			wantCoverage: 1,
+		},
+		{
+			name:         "cyclonedx location property",
+			sbom:         fmt.Sprintf(`{"components": [{"name": "musl", "properties": [{"name": "syft:location:0:layerID", "value": "%s"}]}]}`, layer),
+			wantCoverage: 1,
+		},
+		{
+			name:         "package checksum equal to the layer digest",
+			sbom:         fmt.Sprintf(`{"packages": [{"name": "musl", "checksums": [{"algorithm": "SHA256", "checksumValue": "%s"}], "comment": "built from %s"}]}`, layer.Encoded(), layer),
+			wantCoverage: 0,
+		},
+		{
+			name:         "unrelated digest field",
+			sbom:         fmt.Sprintf(`{"metadata": {"digest": "%s"}}`, layer),
+			wantCoverage: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := computeLayerCoverage(strings.NewReader(tt.sbom), []imageLayer{{Digest: layer}})
+			if report.Coverage != tt.wantCoverage {
+				t.Fatalf("computeLayerCoverage() coverage = %v, want %v", report.Coverage, tt.wantCoverage)
+			}
+		})
+	}
+}
+
+func TestParseInput_InvalidMinLayerCoverage(t *testing.T) {
+	if _, err := parseInput([]byte(`{"config":{"name":"sbom","minLayerCoverage":1.5}}`)); err == nil {
+		t.Fatalf("expected an error for a layer coverage above 1")
+	}
+}
+
 func TestGetViolations(t *testing.T) {
 	disallowedPackage := utils.PackageInfo{
 		Name:    "libcrypto3",
@@ -335,7 +541,7 @@ func TestGetViolations(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run("test scenario", func(t *testing.T) {
-			report := processSpdxJSONMediaType("test", "", b, tc.disallowedLicenses, tc.disallowedPackages)
+			report := processSpdxJSONMediaType("test", "", bytes.NewReader(b), tc.disallowedLicenses, tc.disallowedPackages)
 
 			if len(tc.expectedPackageViolations) != 0 || len(tc.expectedLicenseViolations) != 0 {
 				if report.IsSuccess {
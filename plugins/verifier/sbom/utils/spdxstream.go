@@ -0,0 +1,154 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spdx/tools-golang/spdx/v2/v2_1"
+	"github.com/spdx/tools-golang/spdx/v2/v2_2"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+)
+
+// errUnexpectedEnd is returned for empty or truncated documents, matching the
+// error of json.Unmarshal.
+var errUnexpectedEnd = errors.New("unexpected end of JSON input")
+
+// spdxPackage holds the fields of an SPDX package the verifier checks.
+type spdxPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"versionInfo"`
+	License string `json:"licenseConcluded"`
+}
+
+// ReadPackageLicenses decodes the SPDX JSON document from r incrementally and
+// calls fn with the license of each package as it is decoded, so that neither
+// the whole document nor all of its packages are held in memory. Fields other
+// than the packages, the creation info and the SPDX version are skipped. It
+// returns the creation info of the document. On error, the packages already
+// passed to fn must be discarded.
+func ReadPackageLicenses(r io.Reader, fn func(PackageLicense)) (*v2_3.CreationInfo, error) {
+	creationInfo, err := readPackageLicenses(r, fn)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, errUnexpectedEnd
+	}
+	return creationInfo, err
+}
+
+func readPackageLicenses(r io.Reader, fn func(PackageLicense)) (*v2_3.CreationInfo, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, fmt.Errorf("not a valid SPDX JSON document: %w", err)
+	}
+
+	var version *string
+	var creationInfo *v2_3.CreationInfo
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token {
+		case "spdxVersion":
+			if err := decoder.Decode(&version); err != nil {
+				return nil, fmt.Errorf("failed to decode spdxVersion: %w", err)
+			}
+		case "creationInfo":
+			if err := decoder.Decode(&creationInfo); err != nil {
+				return nil, fmt.Errorf("failed to decode creationInfo: %w", err)
+			}
+		case "packages":
+			if err := readPackages(decoder, fn); err != nil {
+				return nil, err
+			}
+		default:
+			if err := skipValue(decoder); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, fmt.Errorf("not a valid SPDX JSON document: %w", err)
+	}
+
+	if version == nil {
+		return nil, fmt.Errorf("JSON document does not contain spdxVersion field")
+	}
+	switch *version {
+	case v2_1.Version, v2_2.Version, v2_3.Version:
+		return creationInfo, nil
+	default:
+		return nil, fmt.Errorf("unsupported SPDX version: %s", *version)
+	}
+}
+
+// readPackages decodes the packages array one package at a time.
+func readPackages(decoder *json.Decoder, fn func(PackageLicense)) error {
+	if err := expectDelim(decoder, '['); err != nil {
+		return fmt.Errorf("failed to decode packages: %w", err)
+	}
+	for decoder.More() {
+		var p spdxPackage
+		if err := decoder.Decode(&p); err != nil {
+			return fmt.Errorf("failed to decode package: %w", err)
+		}
+		fn(PackageLicense{
+			Name:    p.Name,
+			Version: p.Version,
+			License: p.License,
+		})
+	}
+	return expectDelim(decoder, ']')
+}
+
+// skipValue consumes the next value token by token, without buffering it
+// whole.
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			default:
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// expectDelim consumes the next token and returns an error unless it is the
+// delimiter.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
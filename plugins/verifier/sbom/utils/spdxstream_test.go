@@ -0,0 +1,151 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeSyntheticSBOM writes an SPDX document with the given number of packages
+// and files, placing spdxVersion last, and returns the number of bytes
+// written.
+func writeSyntheticSBOM(w io.Writer, packages, files int) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var written int64
+	write := func(format string, args ...interface{}) {
+		n, _ := fmt.Fprintf(bw, format, args...)
+		written += int64(n)
+	}
+	write(`{"SPDXID":"SPDXRef-DOCUMENT","name":"synthetic","creationInfo":{"created":"2024-01-01T00:00:00Z","creators":["Tool: synthetic"]},"packages":[`)
+	for i := 0; i < packages; i++ {
+		if i > 0 {
+			write(",")
+		}
+		write(`{"name":"package-%d","SPDXID":"SPDXRef-Package-%d","versionInfo":"1.0.%d","downloadLocation":"NOASSERTION","licenseConcluded":"MIT","externalRefs":[{"referenceCategory":"PACKAGE-MANAGER","referenceType":"purl","referenceLocator":"pkg:generic/package-%d@1.0.%d"}]}`, i, i, i, i, i)
+	}
+	write(`],"files":[`)
+	for i := 0; i < files; i++ {
+		if i > 0 {
+			write(",")
+		}
+		write(`{"fileName":"/usr/lib/file-%d","SPDXID":"SPDXRef-File-%d","checksums":[{"algorithm":"SHA1","checksumValue":"%040d"}],"comment":"layerID: sha256:%064d"}`, i, i, i, i)
+	}
+	write(`],"spdxVersion":"SPDX-2.3"}`)
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+func TestReadPackageLicenses_LargeSBOM(t *testing.T) {
+	const packages, files = 100000, 100000
+	r, w := io.Pipe()
+	size := make(chan int64, 1)
+	go func() {
+		n, err := writeSyntheticSBOM(w, packages, files)
+		size <- n
+		w.CloseWithError(err)
+	}()
+
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	baseline := stats.HeapAlloc
+	var peak uint64
+
+	count := 0
+	var last PackageLicense
+	creationInfo, err := ReadPackageLicenses(r, func(p PackageLicense) {
+		if p.Name != fmt.Sprintf("package-%d", count) || p.Version != fmt.Sprintf("1.0.%d", count) || p.License != "MIT" {
+			t.Fatalf("unexpected package %d: %+v", count, p)
+		}
+		count++
+		last = p
+		if count%10000 == 0 {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak {
+				peak = stats.HeapAlloc
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if count != packages || last.Name != fmt.Sprintf("package-%d", packages-1) {
+		t.Fatalf("expected %d packages, got %d ending with %+v", packages, count, last)
+	}
+	if creationInfo == nil || creationInfo.Created != "2024-01-01T00:00:00Z" {
+		t.Fatalf("unexpected creation info %+v", creationInfo)
+	}
+
+	// the heap must stay well below the size of the document, which buffering
+	// or unmarshalling it whole would exceed
+	documentSize := <-size
+	if peak > baseline && int64(peak-baseline) > documentSize/4 {
+		t.Fatalf("expected the heap to grow by less than %d bytes, grew by %d", documentSize/4, peak-baseline)
+	}
+}
+
+func TestReadPackageLicenses_Errors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		document      string
+		expectedError string
+	}{
+		{
+			name:          "empty document",
+			expectedError: "unexpected end of JSON input",
+		},
+		{
+			name:          "truncated document",
+			document:      `{"spdxVersion":"SPDX-2.3","packages":[{"name":"a"`,
+			expectedError: "unexpected end of JSON input",
+		},
+		{
+			name:          "not an object",
+			document:      `["SPDX-2.3"]`,
+			expectedError: "not a valid SPDX JSON document",
+		},
+		{
+			name:          "missing spdxVersion",
+			document:      `{"packages":[{"name":"a"}]}`,
+			expectedError: "JSON document does not contain spdxVersion field",
+		},
+		{
+			name:          "unsupported spdxVersion",
+			document:      `{"spdxVersion":"SPDX-3.0","packages":[]}`,
+			expectedError: "unsupported SPDX version: SPDX-3.0",
+		},
+		{
+			name:          "packages not an array",
+			document:      `{"spdxVersion":"SPDX-2.3","packages":{}}`,
+			expectedError: "failed to decode packages",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadPackageLicenses(strings.NewReader(tc.document), func(PackageLicense) {})
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
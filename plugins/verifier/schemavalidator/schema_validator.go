@@ -26,6 +26,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
 	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
 	"github.com/ratify-project/ratify/plugins/verifier/schemavalidator/schemavalidation"
@@ -79,12 +80,12 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 	}
 
 	for _, blobDesc := range referenceManifest.Blobs {
-		refBlob, err := referrerStore.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
+		refBlob, mediaType, err := su.ReadBlob(ctx, referrerStore, subjectReference, blobDesc)
 		if err != nil {
 			return nil, fmt.Errorf("error fetching blob for subject:[%s] digest:[%s]", subjectReference, blobDesc.Digest)
 		}
 
-		err = processMediaType(schemaMap, blobDesc.MediaType, refBlob)
+		err = processMediaType(schemaMap, mediaType, refBlob)
 		if err != nil {
 			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("schema validation failed for digest:[%s], media type:[%s].", blobDesc.Digest, blobDesc.MediaType)).WithError(err)
 			result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, nil)
@@ -0,0 +1,69 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/owenrumney/go-sarif/v2/sarif"
+)
+
+// findingConcurrency returns the number of workers evaluating the findings of
+// a report, GOMAXPROCS unless configured.
+func findingConcurrency(input *PluginConfig) int {
+	if input.FindingConcurrency > 0 {
+		return input.FindingConcurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// minFindingsPerWorker is the smallest batch of findings evaluated by a
+// worker, below which the cost of a goroutine outweighs the evaluation.
+const minFindingsPerWorker = 1024
+
+// evaluateFindings calls evaluate on each finding using at most concurrency
+// workers and returns the outcomes in the order of the findings, so that
+// merging them gives the same result as evaluating the findings serially. The
+// findings are split into contiguous batches, one per worker, and small
+// reports are evaluated serially.
+func evaluateFindings[T any](findings []*sarif.Result, concurrency int, evaluate func(*sarif.Result) T) []T {
+	outcomes := make([]T, len(findings))
+	if batches := (len(findings) + minFindingsPerWorker - 1) / minFindingsPerWorker; concurrency > batches {
+		concurrency = batches
+	}
+	if concurrency <= 1 {
+		for i, finding := range findings {
+			outcomes[i] = evaluate(finding)
+		}
+		return outcomes
+	}
+
+	batchSize := (len(findings) + concurrency - 1) / concurrency
+	var wg sync.WaitGroup
+	for start := 0; start < len(findings); start += batchSize {
+		end := min(start+batchSize, len(findings))
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				outcomes[i] = evaluate(findings[i])
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return outcomes
+}
@@ -0,0 +1,200 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+const (
+	ReportSourceReferrer string = "referrer"
+	ReportSourceExternal string = "external"
+	ReportSourceBoth     string = "both"
+
+	// HTTPReportResolverType is the type of the resolver fetching reports
+	// from an HTTP API.
+	HTTPReportResolverType string = "http"
+
+	externalReportDigestPlaceholder = "{digest}"
+	defaultExternalReportTimeout    = 10 * time.Second
+	// maxExternalReportSize bounds the size of a report read from an
+	// external API.
+	maxExternalReportSize = 32 << 20
+)
+
+// ExternalReportConfig configures the external API resolving the
+// vulnerability reports of subjects by digest.
+type ExternalReportConfig struct {
+	// Type is the type of the resolver. Defaults to "http".
+	Type string `json:"type,omitempty"`
+	// URL is the endpoint returning the report of a subject, the "{digest}"
+	// placeholder is replaced by the subject digest, e.g.
+	// https://scanner.example.com/reports/{digest}.
+	URL string `json:"url"`
+	// ArtifactType is the artifact type of the returned reports. Defaults to
+	// the SARIF artifact type.
+	ArtifactType string `json:"artifactType,omitempty"`
+	// Timeout bounds each request to the API. Defaults to 10s.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Report is a vulnerability report resolved for a subject.
+type Report struct {
+	ArtifactType string
+	Content      []byte
+	// CreatedAt is the creation time of the report, zero if unknown.
+	CreatedAt time.Time
+}
+
+// ReportResolver resolves the vulnerability report of a subject by digest.
+type ReportResolver interface {
+	Resolve(ctx context.Context, subjectDigest digest.Digest) (Report, error)
+}
+
+// reportResolverFactories maps resolver types to their constructors.
+var reportResolverFactories = map[string]func(conf ExternalReportConfig) (ReportResolver, error){
+	HTTPReportResolverType: newHTTPReportResolver,
+}
+
+// validateReportSource validates the report source and the external report
+// configuration it requires.
+func validateReportSource(source string, conf *ExternalReportConfig) error {
+	switch source {
+	case "", ReportSourceReferrer:
+		return nil
+	case ReportSourceExternal, ReportSourceBoth:
+	default:
+		return fmt.Errorf("reportSource must be one of %s, %s or %s, got %s", ReportSourceReferrer, ReportSourceExternal, ReportSourceBoth, source)
+	}
+	if conf == nil {
+		return fmt.Errorf("externalReport must be configured for reportSource %s", source)
+	}
+	_, err := newReportResolver(*conf)
+	return err
+}
+
+// newReportResolver creates the resolver of the configured type.
+func newReportResolver(conf ExternalReportConfig) (ReportResolver, error) {
+	resolverType := conf.Type
+	if resolverType == "" {
+		resolverType = HTTPReportResolverType
+	}
+	factory, ok := reportResolverFactories[resolverType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported externalReport type: %s", resolverType)
+	}
+	return factory(conf)
+}
+
+// httpReportResolver fetches reports from an HTTP API.
+type httpReportResolver struct {
+	url          string
+	artifactType string
+	client       *http.Client
+}
+
+func newHTTPReportResolver(conf ExternalReportConfig) (ReportResolver, error) {
+	if !strings.Contains(conf.URL, externalReportDigestPlaceholder) {
+		return nil, fmt.Errorf("externalReport url must contain the %s placeholder: %s", externalReportDigestPlaceholder, conf.URL)
+	}
+	if !strings.HasPrefix(conf.URL, "http://") && !strings.HasPrefix(conf.URL, "https://") {
+		return nil, fmt.Errorf("externalReport url must be an http or https URL: %s", conf.URL)
+	}
+	timeout := defaultExternalReportTimeout
+	if conf.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(conf.Timeout); err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("externalReport timeout must be a positive duration: %s", conf.Timeout)
+		}
+	}
+	artifactType := conf.ArtifactType
+	if artifactType == "" {
+		artifactType = SarifArtifactType
+	}
+	return &httpReportResolver{
+		url:          conf.URL,
+		artifactType: artifactType,
+		client:       &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Resolve fetches the report of the subject. The creation time of the report
+// is read from the Last-Modified header of the response.
+func (r *httpReportResolver) Resolve(ctx context.Context, subjectDigest digest.Digest) (Report, error) {
+	reportURL := strings.ReplaceAll(r.url, externalReportDigestPlaceholder, subjectDigest.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reportURL, nil)
+	if err != nil {
+		return Report{}, err
+	}
+	req.Header.Set("Accept", r.artifactType)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return Report{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Report{}, fmt.Errorf("no report found for digest %s", subjectDigest)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Report{}, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, reportURL)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxExternalReportSize+1))
+	if err != nil {
+		return Report{}, err
+	}
+	if len(content) > maxExternalReportSize {
+		return Report{}, fmt.Errorf("report of digest %s exceeds %d bytes", subjectDigest, maxExternalReportSize)
+	}
+
+	report := Report{ArtifactType: r.artifactType, Content: content}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		if report.CreatedAt, err = http.ParseTime(lastModified); err != nil {
+			return Report{}, fmt.Errorf("error parsing Last-Modified header:[%s]", lastModified)
+		}
+	}
+	return report, nil
+}
+
+// verifyExternalReport verifies the report of the subject resolved from the
+// external API. A report without a creation time fails the maximum age check.
+func verifyExternalReport(ctx context.Context, input *PluginConfig, verifierType string, subjectReference common.Reference) (*verifier.VerifierResult, error) {
+	resolver, err := newReportResolver(*input.ExternalReport)
+	if err != nil {
+		return nil, err
+	}
+	report, err := resolver.Resolve(ctx, subjectReference.Digest)
+	if err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to resolve external report for subject:[%s].", subjectReference)).WithError(err)
+		result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, nil)
+		return &result, nil
+	}
+
+	if result := checkMaximumAge(input, verifierType, report.CreatedAt); result != nil {
+		return result, nil
+	}
+
+	return evaluateReport(input, verifierType, report.ArtifactType, report.Content, report.CreatedAt, subjectReference.Digest.String())
+}
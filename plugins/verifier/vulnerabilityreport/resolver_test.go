@@ -0,0 +1,229 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+)
+
+// newFakeReportAPI serves the given reports keyed by subject digest under
+// /reports/{digest}.
+func newFakeReportAPI(t *testing.T, reports map[digest.Digest]string, lastModified time.Time) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, ok := reports[digest.Digest(r.URL.Path[len("/reports/"):])]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if !lastModified.IsZero() {
+			w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+		}
+		w.Header().Set("Content-Type", SarifArtifactType)
+		_, _ = w.Write([]byte(report))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPReportResolver_Resolve(t *testing.T) {
+	subjectDigest := digest.FromString("test_subject")
+	lastModified := time.Now().Add(-time.Hour).Truncate(time.Second)
+	server := newFakeReportAPI(t, map[digest.Digest]string{subjectDigest: sampleSarifReport}, lastModified)
+
+	resolver, err := newReportResolver(ExternalReportConfig{URL: server.URL + "/reports/{digest}"})
+	if err != nil {
+		t.Fatalf("failed to create resolver: %v", err)
+	}
+	report, err := resolver.Resolve(context.Background(), subjectDigest)
+	if err != nil {
+		t.Fatalf("failed to resolve report: %v", err)
+	}
+	if report.ArtifactType != SarifArtifactType {
+		t.Fatalf("expected artifact type %s, got %s", SarifArtifactType, report.ArtifactType)
+	}
+	if string(report.Content) != sampleSarifReport {
+		t.Fatalf("unexpected report content: %s", report.Content)
+	}
+	if !report.CreatedAt.Equal(lastModified) {
+		t.Fatalf("expected creation time %v, got %v", lastModified, report.CreatedAt)
+	}
+
+	if _, err := resolver.Resolve(context.Background(), digest.FromString("unknown")); err == nil {
+		t.Fatalf("expected an error for a digest without report")
+	}
+}
+
+func TestVerifyReference_ExternalReport(t *testing.T) {
+	subjectDigest := digest.FromString("test_subject")
+	manifestDigest := digest.FromString("test_manifest_digest")
+	blobDigest := digest.FromString("test_blob_digest")
+	server := newFakeReportAPI(t, map[digest.Digest]string{subjectDigest: sampleSarifReport}, time.Now().Add(-time.Hour))
+	reportURL := server.URL + "/reports/{digest}"
+
+	tests := []struct {
+		name          string
+		stdinData     string
+		subjectDigest digest.Digest
+		artifactType  string
+		blobContent   string
+		isSuccess     bool
+		message       string
+		errorReason   string
+	}{
+		{
+			name:          "external report passes",
+			stdinData:     fmt.Sprintf(`{"config":{"name":"vulnerabilityreport","reportSource":"external","externalReport":{"url":%q}}}`, reportURL),
+			subjectDigest: subjectDigest,
+			artifactType:  ocispecs.SubjectArtifactType,
+			isSuccess:     true,
+			message:       "Validation succeeded",
+		},
+		{
+			name:          "external report applies the policy",
+			stdinData:     fmt.Sprintf(`{"config":{"name":"vulnerabilityreport","reportSource":"external","disallowedSeverities":["critical"],"externalReport":{"url":%q}}}`, reportURL),
+			subjectDigest: subjectDigest,
+			artifactType:  ocispecs.SubjectArtifactType,
+			message:       "Found disallowed severities. See extensions field for details.",
+		},
+		{
+			name:          "external report older than maximum age",
+			stdinData:     fmt.Sprintf(`{"config":{"name":"vulnerabilityreport","reportSource":"external","maximumAge":"1m","externalReport":{"url":%q}}}`, reportURL),
+			subjectDigest: subjectDigest,
+			artifactType:  ocispecs.SubjectArtifactType,
+			errorReason:   "Report is older than maximum age:[1m].",
+		},
+		{
+			name:          "no external report for subject",
+			stdinData:     fmt.Sprintf(`{"config":{"name":"vulnerabilityreport","reportSource":"external","externalReport":{"url":%q}}}`, reportURL),
+			subjectDigest: digest.FromString("unknown"),
+			artifactType:  ocispecs.SubjectArtifactType,
+			message:       fmt.Sprintf("Failed to resolve external report for subject:[%s].", "test_subject"),
+			errorReason:   fmt.Sprintf("no report found for digest %s", digest.FromString("unknown")),
+		},
+		{
+			name:          "external report source does not verify referrers",
+			stdinData:     fmt.Sprintf(`{"config":{"name":"vulnerabilityreport","reportSource":"external","externalReport":{"url":%q}}}`, reportURL),
+			subjectDigest: subjectDigest,
+			artifactType:  SarifArtifactType,
+			blobContent:   sampleSarifReport,
+			errorReason:   fmt.Sprintf("Report source %q resolves the report of the subject, configure artifact type %s instead of the referrer artifact types.", ReportSourceExternal, ocispecs.SubjectArtifactType),
+		},
+		{
+			name:          "referrer report source does not verify the subject",
+			stdinData:     `{"config":{"name":"vulnerabilityreport"}}`,
+			subjectDigest: subjectDigest,
+			artifactType:  ocispecs.SubjectArtifactType,
+			errorReason:   fmt.Sprintf("Report source %q does not resolve the report of the subject, artifact type %s requires report source %s or %s.", "", ocispecs.SubjectArtifactType, ReportSourceExternal, ReportSourceBoth),
+		},
+		{
+			name:          "both sources verify the external report of the subject",
+			stdinData:     fmt.Sprintf(`{"config":{"name":"vulnerabilityreport","reportSource":"both","disallowedSeverities":["critical"],"externalReport":{"url":%q}}}`, reportURL),
+			subjectDigest: subjectDigest,
+			artifactType:  ocispecs.SubjectArtifactType,
+			blobContent:   "{}",
+			message:       "Found disallowed severities. See extensions field for details.",
+		},
+		{
+			name:          "both sources verify the referrer report",
+			stdinData:     fmt.Sprintf(`{"config":{"name":"vulnerabilityreport","reportSource":"both","externalReport":{"url":%q}}}`, reportURL),
+			subjectDigest: digest.FromString("unknown"),
+			artifactType:  SarifArtifactType,
+			blobContent:   sampleSarifReport,
+			isSuccess:     true,
+			message:       "Validation succeeded",
+		},
+		{
+			name:          "both sources fail on referrer report",
+			stdinData:     fmt.Sprintf(`{"config":{"name":"vulnerabilityreport","reportSource":"both","externalReport":{"url":%q}}}`, reportURL),
+			subjectDigest: subjectDigest,
+			artifactType:  SarifArtifactType,
+			blobContent:   "{}",
+			message:       fmt.Sprintf("Schema validation failed for digest:[%s],artifact type:[%s].", blobDigest, SarifArtifactType),
+			errorReason:   "version is required: runs is required: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmdArgs := skel.CmdArgs{
+				Version:   "1.0.0",
+				Subject:   "test_subject",
+				StdinData: []byte(tt.stdinData),
+			}
+			annotations := map[string]string{
+				"org.opencontainers.image.created": time.Now().Format(time.RFC3339),
+			}
+			testStore := &mocks.MemoryTestStore{
+				Manifests: map[digest.Digest]ocispecs.ReferenceManifest{manifestDigest: {
+					Annotations: annotations,
+					Blobs:       []oci.Descriptor{{Digest: blobDigest, MediaType: SarifArtifactType}},
+				}},
+				Blobs: map[digest.Digest][]byte{blobDigest: []byte(tt.blobContent)},
+			}
+			subjectRef := common.Reference{
+				Path:     "test_subject_path",
+				Digest:   tt.subjectDigest,
+				Original: "test_subject",
+			}
+			refDesc := ocispecs.ReferenceDescriptor{
+				Descriptor: oci.Descriptor{
+					Digest:      manifestDigest,
+					Annotations: annotations,
+				},
+				ArtifactType: tt.artifactType,
+			}
+			verifierResult, err := VerifyReference(&cmdArgs, subjectRef, refDesc, testStore)
+			if err != nil {
+				t.Fatalf("verifyReference() unexpected error: %v", err)
+			}
+			if verifierResult.IsSuccess != tt.isSuccess {
+				t.Fatalf("verifyReference() isSuccess = %v, want %v: %s %s", verifierResult.IsSuccess, tt.isSuccess, verifierResult.Message, verifierResult.ErrorReason)
+			}
+			if verifierResult.Message != tt.message {
+				t.Fatalf("verifyReference() message = %s, want %s", verifierResult.Message, tt.message)
+			}
+			if verifierResult.ErrorReason != tt.errorReason {
+				t.Fatalf("verifyReference() error reason = %s, want %s", verifierResult.ErrorReason, tt.errorReason)
+			}
+		})
+	}
+}
+
+func TestParseInput_InvalidReportSource(t *testing.T) {
+	for _, stdin := range []string{
+		`{"config":{"name":"vulnerabilityreport","reportSource":"registry"}}`,
+		`{"config":{"name":"vulnerabilityreport","reportSource":"external"}}`,
+		`{"config":{"name":"vulnerabilityreport","reportSource":"external","externalReport":{"url":"https://scanner.example.com/reports"}}}`,
+		`{"config":{"name":"vulnerabilityreport","reportSource":"both","externalReport":{"url":"ftp://scanner.example.com/{digest}"}}}`,
+		`{"config":{"name":"vulnerabilityreport","reportSource":"external","externalReport":{"url":"https://scanner.example.com/{digest}","timeout":"-1s"}}}`,
+		`{"config":{"name":"vulnerabilityreport","reportSource":"external","externalReport":{"type":"grpc","url":"https://scanner.example.com/{digest}"}}}`,
+	} {
+		if _, err := parseInput([]byte(stdin)); err == nil {
+			t.Fatalf("expected error for config %s", stdin)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// AllowedScanner is a scanner trusted to produce vulnerability reports.
+type AllowedScanner struct {
+	// Name is the name of the scanner as reported in the tool driver of the
+	// report, compared case-insensitively.
+	Name string `json:"name"`
+	// MinVersion is the minimum semantic version of the scanner. Any version
+	// is allowed if empty.
+	MinVersion string `json:"minVersion,omitempty"`
+}
+
+// validateAllowedScanners validates the configured scanner allowlist.
+func validateAllowedScanners(allowedScanners []AllowedScanner) error {
+	for _, scanner := range allowedScanners {
+		if scanner.Name == "" {
+			return fmt.Errorf("allowedScanners entry must have a name")
+		}
+		if scanner.MinVersion != "" && !semver.IsValid(canonicalVersion(scanner.MinVersion)) {
+			return fmt.Errorf("minVersion of scanner %s is not a valid semantic version: %s", scanner.Name, scanner.MinVersion)
+		}
+	}
+	return nil
+}
+
+// isScannerAllowed reports whether the scanner with the given name and version
+// is in the allowlist. A scanner with a minimum version is only allowed if the
+// report carries a valid version at least as high.
+func isScannerAllowed(allowedScanners []AllowedScanner, name string, version string) bool {
+	for _, scanner := range allowedScanners {
+		if !strings.EqualFold(scanner.Name, name) {
+			continue
+		}
+		if scanner.MinVersion == "" {
+			return true
+		}
+		reportVersion := canonicalVersion(version)
+		if semver.IsValid(reportVersion) && semver.Compare(reportVersion, canonicalVersion(scanner.MinVersion)) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalVersion prefixes the version with "v" as expected by the semver
+// package, scanners usually report their version without it.
+func canonicalVersion(version string) string {
+	if version == "" || strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
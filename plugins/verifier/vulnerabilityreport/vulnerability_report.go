@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
 	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
 	"github.com/ratify-project/ratify/plugins/verifier/vulnerabilityreport/schemavalidation"
@@ -59,6 +61,24 @@ type PluginConfig struct {
 	DisallowedSeverities  []string `json:"disallowedSeverities,omitempty"`
 	Passthrough           bool     `json:"passthrough,omitempty"`
 	DenylistCVEs          []string `json:"denylistCVEs,omitempty"`
+	// FindingConcurrency is the number of workers evaluating the findings of
+	// a report against the deny list and the disallowed severities. Defaults
+	// to GOMAXPROCS. Small reports are evaluated by fewer workers.
+	FindingConcurrency int `json:"findingConcurrency,omitempty"`
+	// AllowedScanners is the allowlist of the scanners trusted to produce
+	// the reports. Reports of any scanner are accepted if empty.
+	AllowedScanners []AllowedScanner `json:"allowedScanners,omitempty"`
+	// ReportSource is where the reports are read from: "referrer" (default)
+	// for the reports attached to the subject, "external" for the report
+	// resolved from ExternalReport by subject digest, or "both" to verify
+	// both. The external report is resolved once per subject, when the
+	// verifier is configured with the ocispecs.SubjectArtifactType artifact
+	// type, e.g. "application/vnd.ratify.subject.v1,application/sarif+json"
+	// for "both".
+	ReportSource string `json:"reportSource,omitempty"`
+	// ExternalReport configures the external API resolving the reports of
+	// subjects by digest. Required unless ReportSource is "referrer".
+	ExternalReport *ExternalReportConfig `json:"externalReport,omitempty"`
 }
 
 type PluginInputConfig struct {
@@ -75,6 +95,15 @@ func parseInput(stdin []byte) (*PluginConfig, error) {
 	if err := json.Unmarshal(stdin, &conf); err != nil {
 		return nil, fmt.Errorf("failed to parse stdin for the input: %w", err)
 	}
+	if conf.Config.FindingConcurrency < 0 {
+		return nil, fmt.Errorf("findingConcurrency must not be negative, got %d", conf.Config.FindingConcurrency)
+	}
+	if err := validateAllowedScanners(conf.Config.AllowedScanners); err != nil {
+		return nil, err
+	}
+	if err := validateReportSource(conf.Config.ReportSource, conf.Config.ExternalReport); err != nil {
+		return nil, err
+	}
 
 	return &conf.Config, nil
 }
@@ -93,6 +122,31 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 	if input.CreatedAnnotationName == "" {
 		input.CreatedAnnotationName = DefaultCreatedAnnotation
 	}
+
+	ctx := context.Background()
+	if referenceDescriptor.ArtifactType == ocispecs.SubjectArtifactType {
+		if input.ReportSource != ReportSourceExternal && input.ReportSource != ReportSourceBoth {
+			return reportSourceMismatch(input, verifierType, fmt.Sprintf("Report source %q does not resolve the report of the subject, artifact type %s requires report source %s or %s.", input.ReportSource, ocispecs.SubjectArtifactType, ReportSourceExternal, ReportSourceBoth)), nil
+		}
+		return verifyExternalReport(ctx, input, verifierType, subjectReference)
+	}
+	if input.ReportSource == ReportSourceExternal {
+		return reportSourceMismatch(input, verifierType, fmt.Sprintf("Report source %q resolves the report of the subject, configure artifact type %s instead of the referrer artifact types.", ReportSourceExternal, ocispecs.SubjectArtifactType)), nil
+	}
+	return verifyReferrerReport(ctx, input, verifierType, subjectReference, referenceDescriptor, referrerStore)
+}
+
+// reportSourceMismatch returns the failed result of a reference that the
+// configured report source does not verify.
+func reportSourceMismatch(input *PluginConfig, verifierType string, detail string) *verifier.VerifierResult {
+	verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(detail)
+	result := verifier.NewVerifierResult("", input.Name, verifierType, "", false, &verifierErr, nil)
+	return &result
+}
+
+// verifyReferrerReport verifies the report attached to the subject as the
+// referrer described by referenceDescriptor.
+func verifyReferrerReport(ctx context.Context, input *PluginConfig, verifierType string, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
 	createdTime, err := extractCreationTimestamp(input.CreatedAnnotationName, referenceDescriptor)
 	if err != nil {
 		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to create timestamp annotation.").WithError(err)
@@ -101,38 +155,10 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 	}
 
 	// check report is newer than allowed maximum age
-	if input.MaximumAge != "" {
-		ok, err := validateMaximumAge(input.MaximumAge, createdTime)
-		if err != nil {
-			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to validate maximum age.").WithError(err)
-			result := verifier.NewVerifierResult(
-				"",
-				input.Name,
-				verifierType,
-				"",
-				false,
-				&verifierErr,
-				map[string]interface{}{CreatedAnnotation: createdTime},
-			)
-			return &result, nil
-		}
-		if !ok {
-			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Report is older than maximum age:[%s].", input.MaximumAge))
-			result := verifier.NewVerifierResult(
-				"",
-				input.Name,
-				verifierType,
-				"",
-				false,
-				&verifierErr,
-				map[string]interface{}{CreatedAnnotation: createdTime},
-			)
-			return &result, nil
-		}
+	if result := checkMaximumAge(input, verifierType, createdTime); result != nil {
+		return result, nil
 	}
 
-	ctx := context.Background()
-
 	referenceManifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, referenceDescriptor)
 	if err != nil {
 		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to fetch reference manifest for subject: %s, reference descriptor: %v.", subjectReference, referenceDescriptor)).WithError(err)
@@ -163,7 +189,7 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 	}
 
 	blobDesc := referenceManifest.Blobs[0]
-	refBlob, err := referrerStore.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
+	refBlob, _, err := su.ReadBlob(ctx, referrerStore, subjectReference, blobDesc)
 	if err != nil {
 		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to fetch blob for subject:[%s] digest:[%s].", subjectReference, blobDesc.Digest)).WithError(err)
 		result := verifier.NewVerifierResult(
@@ -178,6 +204,49 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 		return &result, nil
 	}
 
+	return evaluateReport(input, verifierType, referenceDescriptor.ArtifactType, refBlob, createdTime, blobDesc.Digest.String())
+}
+
+// checkMaximumAge returns the failed result of a report created at
+// createdTime that is older than the configured maximum age, nil otherwise.
+func checkMaximumAge(input *PluginConfig, verifierType string, createdTime time.Time) *verifier.VerifierResult {
+	if input.MaximumAge != "" {
+		ok, err := validateMaximumAge(input.MaximumAge, createdTime)
+		if err != nil {
+			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to validate maximum age.").WithError(err)
+			result := verifier.NewVerifierResult(
+				"",
+				input.Name,
+				verifierType,
+				"",
+				false,
+				&verifierErr,
+				map[string]interface{}{CreatedAnnotation: createdTime},
+			)
+			return &result
+		}
+		if !ok {
+			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Report is older than maximum age:[%s].", input.MaximumAge))
+			result := verifier.NewVerifierResult(
+				"",
+				input.Name,
+				verifierType,
+				"",
+				false,
+				&verifierErr,
+				map[string]interface{}{CreatedAnnotation: createdTime},
+			)
+			return &result
+		}
+	}
+
+	return nil
+}
+
+// evaluateReport validates the content of a report of the given artifact type
+// against the plugin configuration. reportDigest identifies the report in
+// error messages.
+func evaluateReport(input *PluginConfig, verifierType string, artifactType string, report []byte, createdTime time.Time, reportDigest string) (*verifier.VerifierResult, error) {
 	// skip all validation if passthrough is enabled
 	if input.Passthrough {
 		result := verifier.NewVerifierResult(
@@ -190,15 +259,15 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 			map[string]interface{}{
 				CreatedAnnotation: createdTime,
 				"passthrough":     true,
-				"report":          string(refBlob),
+				"report":          string(report),
 			},
 		)
 		return &result, nil
 	}
 
 	// validate json schema
-	if err := verifyJSONSchema(referenceDescriptor.ArtifactType, refBlob, input.SchemaURL); err != nil {
-		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Schema validation failed for digest:[%s],artifact type:[%s].", blobDesc.Digest, referenceDescriptor.ArtifactType)).WithError(err)
+	if err := verifyJSONSchema(artifactType, report, input.SchemaURL); err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Schema validation failed for digest:[%s],artifact type:[%s].", reportDigest, artifactType)).WithError(err)
 		result := verifier.NewVerifierResult(
 			"",
 			input.Name,
@@ -211,8 +280,8 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 		return &result, nil
 	}
 
-	if referenceDescriptor.ArtifactType == SarifArtifactType {
-		return processSarifReport(input, input.Name, verifierType, refBlob, createdTime)
+	if artifactType == SarifArtifactType {
+		return processSarifReport(input, input.Name, verifierType, report, createdTime)
 	}
 
 	result := verifier.NewVerifierResult(
@@ -275,8 +344,31 @@ func processSarifReport(input *PluginConfig, verifierName string, verifierType s
 		return &result, nil
 	}
 	scannerName := strings.ToLower(sarifReport.Runs[0].Tool.Driver.Name)
+	if len(input.AllowedScanners) > 0 {
+		scannerVersion := ""
+		if sarifReport.Runs[0].Tool.Driver.Version != nil {
+			scannerVersion = *sarifReport.Runs[0].Tool.Driver.Version
+		}
+		if !isScannerAllowed(input.AllowedScanners, scannerName, scannerVersion) {
+			result := verifier.NewVerifierResult(
+				"",
+				verifierName,
+				verifierType,
+				fmt.Sprintf("Scanner [%s] version [%s] is not allowed.", scannerName, scannerVersion),
+				false,
+				nil,
+				map[string]interface{}{
+					"scanner":         scannerName,
+					"scannerVersion":  scannerVersion,
+					"allowedScanners": input.AllowedScanners,
+					CreatedAnnotation: createdTime,
+				},
+			)
+			return &result, nil
+		}
+	}
 	if len(input.DenylistCVEs) > 0 {
-		verifierReport, err := verifyDenyListCVEs(input.Name, verifierType, scannerName, sarifReport, input.DenylistCVEs, createdTime)
+		verifierReport, err := verifyDenyListCVEs(input.Name, verifierType, scannerName, sarifReport, input.DenylistCVEs, findingConcurrency(input), createdTime)
 		if err != nil {
 			return nil, err
 		}
@@ -285,7 +377,7 @@ func processSarifReport(input *PluginConfig, verifierName string, verifierType s
 		}
 	}
 	if len(input.DisallowedSeverities) > 0 {
-		verifierReport, err := verifyDisallowedSeverities(input.Name, verifierType, scannerName, sarifReport, input.DisallowedSeverities, createdTime)
+		verifierReport, err := verifyDisallowedSeverities(input.Name, verifierType, scannerName, sarifReport, input.DisallowedSeverities, findingConcurrency(input), createdTime)
 		if err != nil {
 			return nil, err
 		}
@@ -310,7 +402,7 @@ func processSarifReport(input *PluginConfig, verifierName string, verifierType s
 }
 
 // verifyDenyListCVEs verifies that the report does not contain any deny-listed CVEs
-func verifyDenyListCVEs(verifierName string, verifierType string, scannerName string, sarifReport *sarif.Report, denylistCVEs []string, createdTime time.Time) (*verifier.VerifierResult, error) {
+func verifyDenyListCVEs(verifierName string, verifierType string, scannerName string, sarifReport *sarif.Report, denylistCVEs []string, concurrency int, createdTime time.Time) (*verifier.VerifierResult, error) {
 	denylistCVESet := make(map[string]bool)
 	denylistViolations := []string{}
 
@@ -319,14 +411,22 @@ func verifyDenyListCVEs(verifierName string, verifierType string, scannerName st
 		denylistCVESet[strings.ToLower(cve)] = false
 	}
 
+	// evaluate the results concurrently, the set is only read by the workers
+	ruleIDs := evaluateFindings(sarifReport.Runs[0].Results, concurrency, func(result *sarif.Result) string {
+		if result.RuleID == nil {
+			return ""
+		}
+		return strings.ToLower(*result.RuleID)
+	})
+
 	// iterate over the results and check which cves are deny-listed
-	for _, result := range sarifReport.Runs[0].Results {
-		if result.RuleID == nil || *result.RuleID == "" {
+	for i, ruleIDLower := range ruleIDs {
+		if ruleIDLower == "" {
 			verifierResult := verifier.NewVerifierResult(
 				"",
 				verifierName,
 				verifierType,
-				fmt.Sprintf("Rule id not found for result:[%v].", result),
+				fmt.Sprintf("Rule id not found for result:[%v].", sarifReport.Runs[0].Results[i]),
 				false,
 				nil,
 				map[string]interface{}{
@@ -336,16 +436,19 @@ func verifyDenyListCVEs(verifierName string, verifierType string, scannerName st
 			)
 			return &verifierResult, nil
 		}
-		ruleIDLower := strings.ToLower(*result.RuleID)
 		if _, ok := denylistCVESet[ruleIDLower]; ok {
 			denylistCVESet[ruleIDLower] = true
 		}
 	}
 
-	// iterate over the denylistCVESet and add the deny-listed cves to the list of violations
-	for cve, isDenylisted := range denylistCVESet {
-		if isDenylisted {
-			denylistViolations = append(denylistViolations, cve)
+	// add the deny-listed cves to the list of violations in the configured
+	// order so that the result does not depend on the map iteration order
+	for _, cve := range denylistCVEs {
+		cveLower := strings.ToLower(cve)
+		if denylistCVESet[cveLower] {
+			denylistViolations = append(denylistViolations, cveLower)
+			// report duplicated deny list entries once
+			denylistCVESet[cveLower] = false
 		}
 	}
 
@@ -364,6 +467,7 @@ func verifyDenyListCVEs(verifierName string, verifierType string, scannerName st
 				CreatedAnnotation: createdTime,
 			},
 		)
+		result.Remediation = fmt.Sprintf("Upgrade the packages affected by %s to fixed versions or remove them from the image.", strings.Join(denylistViolations, ", "))
 		return &result, nil
 	}
 
@@ -382,16 +486,26 @@ func verifyDenyListCVEs(verifierName string, verifierType string, scannerName st
 	return &result, nil
 }
 
+// severityOutcome is the outcome of evaluating a result against the
+// disallowed severities, either a failed verifier result or the severity of
+// the rule of the result.
+type severityOutcome struct {
+	failure  *verifier.VerifierResult
+	ruleID   string
+	severity string
+}
+
 // verifyDisallowedSeverities verifies that the report does not contain any disallowed severity levels
-func verifyDisallowedSeverities(verifierName string, verifierType string, scannerName string, sarifReport *sarif.Report, disallowedSeverities []string, createdTime time.Time) (*verifier.VerifierResult, error) {
+func verifyDisallowedSeverities(verifierName string, verifierType string, scannerName string, sarifReport *sarif.Report, disallowedSeverities []string, concurrency int, createdTime time.Time) (*verifier.VerifierResult, error) {
 	ruleMap := make(map[string]*sarif.ReportingDescriptor)
 	violatingRules := make(map[string]string)
 	// create a map of rule id to rule for easy lookup
 	for _, rule := range sarifReport.Runs[0].Tool.Driver.Rules {
 		ruleMap[rule.ID] = rule
 	}
-	// iterate over the results and check if the severity is disallowed
-	for _, result := range sarifReport.Runs[0].Results {
+	// evaluate the severity of the results concurrently, the rule map is only
+	// read by the workers
+	outcomes := evaluateFindings(sarifReport.Runs[0].Results, concurrency, func(result *sarif.Result) severityOutcome {
 		if result.RuleID == nil || *result.RuleID == "" {
 			verifierResult := verifier.NewVerifierResult(
 				"",
@@ -405,7 +519,7 @@ func verifyDisallowedSeverities(verifierName string, verifierType string, scanne
 					CreatedAnnotation: createdTime,
 				},
 			)
-			return &verifierResult, nil
+			return severityOutcome{failure: &verifierResult}
 		}
 		rule, ok := ruleMap[*result.RuleID]
 		if !ok {
@@ -421,7 +535,7 @@ func verifyDisallowedSeverities(verifierName string, verifierType string, scanne
 					CreatedAnnotation: createdTime,
 				},
 			)
-			return &verifierResult, nil
+			return severityOutcome{failure: &verifierResult}
 		}
 		severity, err := extractSeverity(scannerName, *rule)
 		if err != nil {
@@ -438,12 +552,19 @@ func verifyDisallowedSeverities(verifierName string, verifierType string, scanne
 					CreatedAnnotation: createdTime,
 				},
 			)
-			return &verifierResult, nil
+			return severityOutcome{failure: &verifierResult}
+		}
+		return severityOutcome{ruleID: rule.ID, severity: severity}
+	})
+	// merge the outcomes in the order of the results, the first failure wins
+	for _, outcome := range outcomes {
+		if outcome.failure != nil {
+			return outcome.failure, nil
 		}
 		// check if the severity is disallowed and add it to the map of violating CVE IDs
 		for _, disallowed := range disallowedSeverities {
-			if strings.EqualFold(severity, disallowed) {
-				violatingRules[rule.ID] = severity
+			if strings.EqualFold(outcome.severity, disallowed) {
+				violatingRules[outcome.ruleID] = outcome.severity
 			}
 		}
 	}
@@ -463,6 +584,7 @@ func verifyDisallowedSeverities(verifierName string, verifierType string, scanne
 				CreatedAnnotation:      createdTime,
 			},
 		)
+		result.Remediation = severityRemediation(violatingRules)
 		return &result, nil
 	}
 	result := verifier.NewVerifierResult(
@@ -480,6 +602,20 @@ func verifyDisallowedSeverities(verifierName string, verifierType string, scanne
 	return &result, nil
 }
 
+// severityRemediation returns the remediation of the findings of disallowed
+// severities, listing the violating rules in a stable order.
+func severityRemediation(violatingRules map[string]string) string {
+	ruleIDs := make([]string, 0, len(violatingRules))
+	for ruleID := range violatingRules {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Strings(ruleIDs)
+	for i, ruleID := range ruleIDs {
+		ruleIDs[i] = fmt.Sprintf("%s (%s)", ruleID, violatingRules[ruleID])
+	}
+	return fmt.Sprintf("Upgrade the packages affected by %s to versions without findings of disallowed severity or remove them from the image.", strings.Join(ruleIDs, ", "))
+}
+
 // extractSeverity extracts the severity from the rule help text using regex
 // relies on the help text being in the format "Severity: <severity>"
 // currently only supports trivy and grype scanners
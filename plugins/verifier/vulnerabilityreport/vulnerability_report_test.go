@@ -17,6 +17,8 @@ package main
 
 import (
 	"fmt"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -387,6 +389,48 @@ func TestProcessSarifReport(t *testing.T) {
 				err:     nil,
 			},
 		},
+		{
+			name: "approved scanner",
+			args: args{
+				input: PluginConfig{
+					Name:            "test_verifier",
+					AllowedScanners: []AllowedScanner{{Name: TrivyScannerName}, {Name: "Grype", MinVersion: "0.70.0"}},
+				},
+				blobContent: sampleSarifReport,
+			},
+			want: want{
+				message: "Validation succeeded",
+				err:     nil,
+			},
+		},
+		{
+			name: "scanner not in allowlist",
+			args: args{
+				input: PluginConfig{
+					Name:            "test_verifier",
+					AllowedScanners: []AllowedScanner{{Name: TrivyScannerName, MinVersion: "0.50.0"}},
+				},
+				blobContent: sampleSarifReport,
+			},
+			want: want{
+				message: "Scanner [grype] version [0.71.0] is not allowed.",
+				err:     nil,
+			},
+		},
+		{
+			name: "scanner below minimum version",
+			args: args{
+				input: PluginConfig{
+					Name:            "test_verifier",
+					AllowedScanners: []AllowedScanner{{Name: GrypeScannerName, MinVersion: "v0.72.0"}},
+				},
+				blobContent: sampleSarifReport,
+			},
+			want: want{
+				message: "Scanner [grype] version [0.71.0] is not allowed.",
+				err:     nil,
+			},
+		},
 		{
 			name: "Validation succeeded",
 			args: args{
@@ -433,6 +477,7 @@ func TestVerifyDenyListCVEs(t *testing.T) {
 	type want struct {
 		message     string
 		errorReason string
+		remediation string
 		err         error
 	}
 	tests := []struct {
@@ -496,8 +541,9 @@ func TestVerifyDenyListCVEs(t *testing.T) {
 				},
 			},
 			want: want{
-				message: "Found denied CVEs. See extensions field for details.",
-				err:     nil,
+				message:     "Found denied CVEs. See extensions field for details.",
+				remediation: "Upgrade the packages affected by cve-2021-1234 to fixed versions or remove them from the image.",
+				err:         nil,
 			},
 		},
 		{
@@ -534,7 +580,7 @@ func TestVerifyDenyListCVEs(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			verifierReport, err := verifyDenyListCVEs("test_verifier", "", TrivyScannerName, &tests[i].args.sarifReport, tt.args.denyListCVEs, time.Now())
+			verifierReport, err := verifyDenyListCVEs("test_verifier", "", TrivyScannerName, &tests[i].args.sarifReport, tt.args.denyListCVEs, 1, time.Now())
 			if err != nil && err.Error() != tt.want.err.Error() {
 				t.Fatalf("verifyDenyListCVEs() error = %v, wantErr %v", err, tt.want.err)
 			}
@@ -544,6 +590,9 @@ func TestVerifyDenyListCVEs(t *testing.T) {
 			if verifierReport.ErrorReason != tt.want.errorReason {
 				t.Fatalf("verifyDenyListCVEs() verifier report error reaon = %s, want = %s", verifierReport.ErrorReason, tt.want.errorReason)
 			}
+			if verifierReport.Remediation != tt.want.remediation {
+				t.Fatalf("verifyDenyListCVEs() verifier report remediation = %s, want = %s", verifierReport.Remediation, tt.want.remediation)
+			}
 		})
 	}
 }
@@ -561,6 +610,7 @@ func TestVerifyDisallowedSeverities(t *testing.T) {
 	type want struct {
 		message     string
 		errorReason string
+		remediation string
 		err         error
 	}
 	tests := []struct {
@@ -703,8 +753,9 @@ func TestVerifyDisallowedSeverities(t *testing.T) {
 				},
 			},
 			want: want{
-				message: "Found disallowed severities. See extensions field for details.",
-				err:     nil,
+				message:     "Found disallowed severities. See extensions field for details.",
+				remediation: "Upgrade the packages affected by RULEID (high) to versions without findings of disallowed severity or remove them from the image.",
+				err:         nil,
 			},
 		},
 		{
@@ -745,7 +796,7 @@ func TestVerifyDisallowedSeverities(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			verifierReport, err := verifyDisallowedSeverities("test_verifier", "", TrivyScannerName, &tests[i].args.sarifReport, tt.args.disallowedSeverities, time.Now())
+			verifierReport, err := verifyDisallowedSeverities("test_verifier", "", TrivyScannerName, &tests[i].args.sarifReport, tt.args.disallowedSeverities, 1, time.Now())
 			if err != nil && err.Error() != tt.want.err.Error() {
 				t.Fatalf("verifyDisallowedSeverities() error = %v, wantErr %v", err, tt.want.err)
 				return
@@ -757,6 +808,9 @@ func TestVerifyDisallowedSeverities(t *testing.T) {
 			if verifierReport.ErrorReason != tt.want.errorReason {
 				t.Fatalf("verifyDisalowedServerities() verifier report error reason = %s, want = %s", verifierReport.ErrorReason, tt.want.errorReason)
 			}
+			if verifierReport.Remediation != tt.want.remediation {
+				t.Fatalf("verifyDisallowedSeverities() verifier report remediation = %s, want = %s", verifierReport.Remediation, tt.want.remediation)
+			}
 		})
 	}
 }
@@ -1003,3 +1057,172 @@ func TestValidateMaxiumAge(t *testing.T) {
 		})
 	}
 }
+
+// largeSarifReport returns a trivy report with the given number of results
+// spread over 100 rules of alternating severities. The results at the indexes
+// of missingRules reference an unknown rule.
+func largeSarifReport(results int, missingRules ...int) *sarif.Report {
+	severities := []string{"LOW", "MEDIUM", "HIGH", "CRITICAL"}
+	driver := &sarif.ToolComponent{Name: TrivyScannerName}
+	for i := 0; i < 100; i++ {
+		text := fmt.Sprintf("Severity: %s", severities[i%len(severities)])
+		driver.Rules = append(driver.Rules, &sarif.ReportingDescriptor{
+			ID:   fmt.Sprintf("CVE-2024-%04d", i),
+			Help: &sarif.MultiformatMessageString{Text: &text},
+		})
+	}
+	run := &sarif.Run{Tool: sarif.Tool{Driver: driver}}
+	for i := 0; i < results; i++ {
+		ruleID := fmt.Sprintf("CVE-2024-%04d", i%100)
+		run.Results = append(run.Results, &sarif.Result{RuleID: &ruleID})
+	}
+	for _, i := range missingRules {
+		ruleID := fmt.Sprintf("CVE-2023-%04d", i)
+		run.Results[i].RuleID = &ruleID
+	}
+	return &sarif.Report{Runs: []*sarif.Run{run}}
+}
+
+// TestEvaluateFindings_LargeReport tests that evaluating the findings of a
+// large report concurrently gives the same results as the serial path
+func TestEvaluateFindings_LargeReport(t *testing.T) {
+	createdTime := time.Now()
+	tests := []struct {
+		name   string
+		report *sarif.Report
+	}{
+		{
+			name:   "violations found",
+			report: largeSarifReport(50000),
+		},
+		{
+			name:   "first missing rule reported",
+			report: largeSarifReport(50000, 42000, 31000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denylist := []string{"cve-2024-0099", "CVE-2024-0003", "CVE-2024-0042", "CVE-2025-0001"}
+			serial, err := verifyDenyListCVEs("test_verifier", "", TrivyScannerName, tt.report, denylist, 1, createdTime)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			concurrent, err := verifyDenyListCVEs("test_verifier", "", TrivyScannerName, tt.report, denylist, 8, createdTime)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(serial, concurrent) {
+				t.Fatalf("expected the concurrent deny list result %+v to equal the serial result %+v", concurrent, serial)
+			}
+
+			disallowed := []string{"high", "critical"}
+			serial, err = verifyDisallowedSeverities("test_verifier", "", TrivyScannerName, tt.report, disallowed, 1, createdTime)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			concurrent, err = verifyDisallowedSeverities("test_verifier", "", TrivyScannerName, tt.report, disallowed, 8, createdTime)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(serial, concurrent) {
+				t.Fatalf("expected the concurrent severity result %+v to equal the serial result %+v", concurrent, serial)
+			}
+			if serial.IsSuccess {
+				t.Fatalf("expected the verification to fail")
+			}
+		})
+	}
+
+	// the first missing rule in the order of the results is reported
+	report := largeSarifReport(50000, 42000, 31000)
+	result, err := verifyDisallowedSeverities("test_verifier", "", TrivyScannerName, report, []string{"high"}, 8, createdTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := fmt.Sprintf("Rule not found for result:[%v].", report.Runs[0].Results[31000]); result.Message != expected {
+		t.Fatalf("expected message %s, got %s", expected, result.Message)
+	}
+}
+
+// TestEvaluateFindings_BoundedConcurrency tests that findings are evaluated
+// by at most the configured number of workers, and small reports serially
+func TestEvaluateFindings_BoundedConcurrency(t *testing.T) {
+	report := largeSarifReport(10 * minFindingsPerWorker)
+	for _, concurrency := range []int{1, 4} {
+		var active, maxActive int32
+		outcomes := evaluateFindings(report.Runs[0].Results, concurrency, func(result *sarif.Result) string {
+			current := atomic.AddInt32(&active, 1)
+			for {
+				observed := atomic.LoadInt32(&maxActive)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxActive, observed, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Microsecond)
+			atomic.AddInt32(&active, -1)
+			return *result.RuleID
+		})
+		if maxActive > int32(concurrency) {
+			t.Fatalf("expected at most %d concurrent evaluations, got %d", concurrency, maxActive)
+		}
+		for i, outcome := range outcomes {
+			if outcome != *report.Runs[0].Results[i].RuleID {
+				t.Fatalf("expected outcome %d to be %s, got %s", i, *report.Runs[0].Results[i].RuleID, outcome)
+			}
+		}
+	}
+
+	var active, maxActive int32
+	evaluateFindings(largeSarifReport(minFindingsPerWorker).Runs[0].Results, 8, func(result *sarif.Result) string {
+		if current := atomic.AddInt32(&active, 1); current > maxActive {
+			maxActive = current
+		}
+		atomic.AddInt32(&active, -1)
+		return *result.RuleID
+	})
+	if maxActive != 1 {
+		t.Fatalf("expected a small report to be evaluated serially, got %d concurrent evaluations", maxActive)
+	}
+}
+
+func TestParseInput_NegativeFindingConcurrency(t *testing.T) {
+	if _, err := parseInput([]byte(`{"config":{"name":"vulnerabilityreport","findingConcurrency":-1}}`)); err == nil {
+		t.Fatalf("expected an error for a negative findingConcurrency")
+	}
+}
+
+func TestIsScannerAllowed(t *testing.T) {
+	allowedScanners := []AllowedScanner{{Name: TrivyScannerName, MinVersion: "0.50.0"}, {Name: GrypeScannerName}}
+	tests := []struct {
+		name    string
+		scanner string
+		version string
+		want    bool
+	}{
+		{name: "at minimum version", scanner: TrivyScannerName, version: "0.50.0", want: true},
+		{name: "above minimum version", scanner: TrivyScannerName, version: "v0.51.2", want: true},
+		{name: "below minimum version", scanner: TrivyScannerName, version: "0.49.9", want: false},
+		{name: "missing version", scanner: TrivyScannerName, version: "", want: false},
+		{name: "invalid version", scanner: TrivyScannerName, version: "latest", want: false},
+		{name: "any version allowed", scanner: GrypeScannerName, version: "", want: true},
+		{name: "unknown scanner", scanner: "snyk", version: "1.0.0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isScannerAllowed(allowedScanners, tt.scanner, tt.version); got != tt.want {
+				t.Fatalf("isScannerAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInput_InvalidAllowedScanners(t *testing.T) {
+	for _, stdin := range []string{
+		`{"config":{"name":"vulnerabilityreport","allowedScanners":[{"minVersion":"0.50.0"}]}}`,
+		`{"config":{"name":"vulnerabilityreport","allowedScanners":[{"name":"trivy","minVersion":"latest"}]}}`,
+	} {
+		if _, err := parseInput([]byte(stdin)); err == nil {
+			t.Fatalf("expected error for config %s", stdin)
+		}
+	}
+}